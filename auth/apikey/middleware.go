@@ -0,0 +1,76 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+
+	"github.com/inturn/kit/endpoint"
+)
+
+type contextKey string
+
+const (
+	// APIKeyContextKey holds the key used to store an API key in the context.
+	APIKeyContextKey contextKey = "APIKey"
+
+	// PrincipalContextKey holds the key used to store the Principal of a
+	// verified API key in the context.
+	PrincipalContextKey contextKey = "APIKeyPrincipal"
+)
+
+var (
+	// ErrKeyContextMissing denotes an API key was not passed into the
+	// authenticating middleware's context.
+	ErrKeyContextMissing = errors.New("API key was not passed through the context")
+
+	// ErrKeyInvalid denotes an API key is not known to the Store.
+	ErrKeyInvalid = errors.New("API key is invalid")
+)
+
+// Principal is the identity an API key belongs to.
+type Principal struct {
+	// ID identifies the owner of the key, e.g. a client or user ID.
+	ID string
+
+	// Scopes lists the permissions granted to the key.
+	Scopes []string
+}
+
+// HasScope reports whether scope is granted to the principal.
+func (p Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// PrincipalFromContext returns the Principal stored in ctx by NewAuthenticator.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(PrincipalContextKey).(Principal)
+	return p, ok
+}
+
+// NewAuthenticator creates a new API key authenticating middleware. The key
+// is expected in the context, where the transport handlers of this package
+// put it, and is verified against store. The Principal of a valid key is
+// added to the endpoint context. Particularly useful for servers.
+func NewAuthenticator(store Store) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+			key, ok := ctx.Value(APIKeyContextKey).(string)
+			if !ok || key == "" {
+				return nil, ErrKeyContextMissing
+			}
+
+			principal, err := store.Lookup(ctx, key)
+			if err != nil {
+				return nil, err
+			}
+
+			ctx = context.WithValue(ctx, PrincipalContextKey, principal)
+			return next(ctx, request)
+		}
+	}
+}
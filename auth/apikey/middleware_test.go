@@ -0,0 +1,154 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewAuthenticator(t *testing.T) {
+	store := StaticStore(map[string]Principal{
+		"k1": {ID: "client-1", Scopes: []string{"read"}},
+	})
+
+	e := func(ctx context.Context, i interface{}) (interface{}, error) { return ctx, nil }
+	authenticator := NewAuthenticator(store)(e)
+
+	if _, err := authenticator(context.Background(), struct{}{}); err != ErrKeyContextMissing {
+		t.Errorf("want %v, have %v", ErrKeyContextMissing, err)
+	}
+
+	ctx := context.WithValue(context.Background(), APIKeyContextKey, "unknown")
+	if _, err := authenticator(ctx, struct{}{}); err != ErrKeyInvalid {
+		t.Errorf("want %v, have %v", ErrKeyInvalid, err)
+	}
+
+	ctx = context.WithValue(context.Background(), APIKeyContextKey, "k1")
+	resp, err := authenticator(ctx, struct{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	principal, ok := PrincipalFromContext(resp.(context.Context))
+	if !ok {
+		t.Fatal("want principal in context")
+	}
+	if want, have := "client-1", principal.ID; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if !principal.HasScope("read") || principal.HasScope("write") {
+		t.Errorf("unexpected scopes %v", principal.Scopes)
+	}
+}
+
+func TestStoreFuncError(t *testing.T) {
+	errLookup := errors.New("database unavailable")
+	store := StoreFunc(func(context.Context, string) (Principal, error) { return Principal{}, errLookup })
+
+	e := func(ctx context.Context, i interface{}) (interface{}, error) { return ctx, nil }
+	ctx := context.WithValue(context.Background(), APIKeyContextKey, "k1")
+	if _, err := NewAuthenticator(store)(e)(ctx, struct{}{}); err != errLookup {
+		t.Errorf("want %v, have %v", errLookup, err)
+	}
+}
+
+func TestCachedStore(t *testing.T) {
+	var (
+		lookups int
+		fail    bool
+	)
+	next := StoreFunc(func(_ context.Context, key string) (Principal, error) {
+		lookups++
+		if fail {
+			return Principal{}, errors.New("database unavailable")
+		}
+		if key != "k1" {
+			return Principal{}, ErrKeyInvalid
+		}
+		return Principal{ID: "client-1"}, nil
+	})
+
+	now := time.Now()
+	store := CachedStore(next, time.Minute).(*cachedStore)
+	store.now = func() time.Time { return now }
+
+	lookup := func(key string) error {
+		_, err := store.Lookup(context.Background(), key)
+		return err
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := lookup("k1"); err != nil {
+			t.Fatal(err)
+		}
+		if err := lookup("k2"); err != ErrKeyInvalid {
+			t.Fatalf("want %v, have %v", ErrKeyInvalid, err)
+		}
+	}
+	if want, have := 2, lookups; want != have {
+		t.Errorf("want %d lookups, have %d", want, have)
+	}
+
+	// lookup failures are not cached
+	fail = true
+	for i := 0; i < 2; i++ {
+		if err := lookup("k3"); err == nil {
+			t.Fatal("want error, have nil")
+		}
+	}
+	if want, have := 4, lookups; want != have {
+		t.Errorf("want %d lookups, have %d", want, have)
+	}
+
+	// expired entries are looked up again
+	fail = false
+	now = now.Add(time.Minute)
+	if err := lookup("k1"); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := 5, lookups; want != have {
+		t.Errorf("want %d lookups, have %d", want, have)
+	}
+}
+
+func TestCachedStoreSize(t *testing.T) {
+	var lookups int
+	next := StoreFunc(func(_ context.Context, key string) (Principal, error) {
+		lookups++
+		if key[0] != 'k' {
+			return Principal{}, ErrKeyInvalid
+		}
+		return Principal{ID: key}, nil
+	})
+	store := CachedStore(next, time.Minute, CachedStoreSize(2), CachedStoreInvalidSize(1))
+	lookup := func(key string) {
+		store.Lookup(context.Background(), key)
+	}
+
+	lookup("k1")
+	lookup("k2")
+	// invalid keys don't evict valid ones
+	for _, key := range []string{"x1", "x2", "x3"} {
+		lookup(key)
+	}
+	lookup("k1")
+	lookup("k2")
+	if want, have := 5, lookups; want != have {
+		t.Errorf("want %d lookups, have %d", want, have)
+	}
+
+	lookup("k3") // evicts k1
+	lookup("k1")
+	lookup("x3")
+	if want, have := 7, lookups; want != have {
+		t.Errorf("want %d lookups, have %d", want, have)
+	}
+
+	// with invalid keys not cached, every lookup of them reaches next
+	store = CachedStore(next, time.Minute, CachedStoreInvalidSize(0))
+	lookup("x1")
+	lookup("x1")
+	if want, have := 9, lookups; want != have {
+		t.Errorf("want %d lookups, have %d", want, have)
+	}
+}
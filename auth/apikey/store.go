@@ -0,0 +1,129 @@
+package apikey
+
+import (
+	"context"
+	"crypto/sha256"
+	"time"
+
+	"github.com/inturn/kit/internal/lru"
+)
+
+// Store looks up the Principal an API key belongs to. Unknown keys should be
+// reported with ErrKeyInvalid; other errors are treated as lookup failures.
+type Store interface {
+	Lookup(ctx context.Context, key string) (Principal, error)
+}
+
+// StoreFunc is an adapter to allow the use of ordinary functions as Stores.
+type StoreFunc func(ctx context.Context, key string) (Principal, error)
+
+// Lookup implements Store.
+func (f StoreFunc) Lookup(ctx context.Context, key string) (Principal, error) {
+	return f(ctx, key)
+}
+
+type staticStore map[[sha256.Size]byte]Principal
+
+// StaticStore returns a Store serving a fixed set of keys. Keys are indexed by
+// their SHA-256 hash, so lookups don't leak how much of a key matched.
+func StaticStore(keys map[string]Principal) Store {
+	s := make(staticStore, len(keys))
+	for key, principal := range keys {
+		s[sha256.Sum256([]byte(key))] = principal
+	}
+	return s
+}
+
+func (s staticStore) Lookup(_ context.Context, key string) (Principal, error) {
+	principal, ok := s[sha256.Sum256([]byte(key))]
+	if !ok {
+		return Principal{}, ErrKeyInvalid
+	}
+	return principal, nil
+}
+
+type cacheEntry struct {
+	principal Principal
+	expires   time.Time
+}
+
+type cachedStore struct {
+	next        Store
+	ttl         time.Duration
+	size        int
+	invalidSize int
+	now         func() time.Time
+
+	keys    *lru.Cache
+	invalid *lru.Cache
+}
+
+// CachedStoreOption sets an optional parameter for CachedStore.
+type CachedStoreOption func(*cachedStore)
+
+// CachedStoreSize sets the maximum number of cached principals. When the
+// cache is full, the least recently used one is dropped. By default, up to
+// 10000 principals are cached.
+func CachedStoreSize(n int) CachedStoreOption {
+	return func(s *cachedStore) { s.size = n }
+}
+
+// CachedStoreInvalidSize sets the maximum number of cached ErrKeyInvalid
+// results. They are held apart from the principals, so clients trying
+// random keys can't evict valid ones. Zero disables caching of invalid keys.
+// By default, up to 1000 invalid keys are cached.
+func CachedStoreInvalidSize(n int) CachedStoreOption {
+	return func(s *cachedStore) { s.invalidSize = n }
+}
+
+// CachedStore returns a Store caching the results of next for ttl, e.g. to
+// avoid a database query per request. Both found principals and
+// ErrKeyInvalid results are cached; other errors are not. Keys are held by
+// their SHA-256 hash only.
+func CachedStore(next Store, ttl time.Duration, options ...CachedStoreOption) Store {
+	s := &cachedStore{
+		next:        next,
+		ttl:         ttl,
+		size:        10000,
+		invalidSize: 1000,
+		now:         time.Now,
+	}
+	for _, option := range options {
+		option(s)
+	}
+	s.keys = lru.New(s.size)
+	if s.invalidSize > 0 {
+		s.invalid = lru.New(s.invalidSize)
+	}
+	return s
+}
+
+func (s *cachedStore) Lookup(ctx context.Context, key string) (Principal, error) {
+	hash := sha256.Sum256([]byte(key))
+	k := string(hash[:])
+	now := s.now()
+
+	if v, ok := s.keys.Get(k); ok {
+		if entry := v.(cacheEntry); now.Before(entry.expires) {
+			return entry.principal, nil
+		}
+	}
+	if s.invalid != nil {
+		if v, ok := s.invalid.Get(k); ok && now.Before(v.(time.Time)) {
+			return Principal{}, ErrKeyInvalid
+		}
+	}
+
+	principal, err := s.next.Lookup(ctx, key)
+	switch {
+	case err == ErrKeyInvalid:
+		if s.invalid != nil {
+			s.invalid.Add(k, now.Add(s.ttl))
+		}
+		return Principal{}, err
+	case err != nil:
+		return Principal{}, err
+	}
+	s.keys.Add(k, cacheEntry{principal: principal, expires: now.Add(s.ttl)})
+	return principal, nil
+}
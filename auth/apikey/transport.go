@@ -0,0 +1,122 @@
+package apikey
+
+import (
+	"context"
+	stdhttp "net/http"
+	"strings"
+
+	"github.com/streadway/amqp"
+	"google.golang.org/grpc/metadata"
+
+	amqptransport "github.com/inturn/kit/transport/amqp"
+	"github.com/inturn/kit/transport/grpc"
+	"github.com/inturn/kit/transport/http"
+)
+
+// DefaultHeader is the conventional header carrying API keys. It's used when
+// an empty header name is passed to the functions of this file.
+const DefaultHeader = "X-API-Key"
+
+// HTTPToContext moves an API key from the given request header to context.
+// Particularly useful for servers.
+func HTTPToContext(header string) http.RequestFunc {
+	header = headerName(header)
+	return func(ctx context.Context, r *stdhttp.Request) context.Context {
+		key := r.Header.Get(header)
+		if key == "" {
+			return ctx
+		}
+		return context.WithValue(ctx, APIKeyContextKey, key)
+	}
+}
+
+// HTTPQueryToContext moves an API key from the given URL query parameter to
+// context. Query parameters tend to end up in access logs, so prefer headers
+// where clients support them. Particularly useful for servers.
+func HTTPQueryToContext(param string) http.RequestFunc {
+	return func(ctx context.Context, r *stdhttp.Request) context.Context {
+		key := r.URL.Query().Get(param)
+		if key == "" {
+			return ctx
+		}
+		return context.WithValue(ctx, APIKeyContextKey, key)
+	}
+}
+
+// ContextToHTTP moves an API key from context to the given request header.
+// Particularly useful for clients.
+func ContextToHTTP(header string) http.RequestFunc {
+	header = headerName(header)
+	return func(ctx context.Context, r *stdhttp.Request) context.Context {
+		if key, ok := ctx.Value(APIKeyContextKey).(string); ok {
+			r.Header.Set(header, key)
+		}
+		return ctx
+	}
+}
+
+// GRPCToContext moves an API key from the given grpc metadata key to context.
+// Particularly useful for servers.
+func GRPCToContext(header string) grpc.ServerRequestFunc {
+	// capital letters are illegal in HTTP/2 header names.
+	header = strings.ToLower(headerName(header))
+	return func(ctx context.Context, md metadata.MD) context.Context {
+		if v := md[header]; len(v) > 0 && v[0] != "" {
+			ctx = context.WithValue(ctx, APIKeyContextKey, v[0])
+		}
+		return ctx
+	}
+}
+
+// ContextToGRPC moves an API key from context to the given grpc metadata key.
+// Particularly useful for clients.
+func ContextToGRPC(header string) grpc.ClientRequestFunc {
+	header = strings.ToLower(headerName(header))
+	return func(ctx context.Context, md *metadata.MD) context.Context {
+		if key, ok := ctx.Value(APIKeyContextKey).(string); ok {
+			(*md)[header] = []string{key}
+		}
+		return ctx
+	}
+}
+
+// AMQPToContext moves an API key from the given AMQP message header to
+// context. Particularly useful for subscribers.
+func AMQPToContext(header string) amqptransport.RequestFunc {
+	header = headerName(header)
+	return func(ctx context.Context, _ *amqp.Publishing, d *amqp.Delivery) context.Context {
+		var key string
+		switch v := d.Headers[header].(type) {
+		case string:
+			key = v
+		case []byte:
+			key = string(v)
+		}
+		if key == "" {
+			return ctx
+		}
+		return context.WithValue(ctx, APIKeyContextKey, key)
+	}
+}
+
+// ContextToAMQP moves an API key from context to the given AMQP message
+// header. Particularly useful for publishers.
+func ContextToAMQP(header string) amqptransport.RequestFunc {
+	header = headerName(header)
+	return func(ctx context.Context, pub *amqp.Publishing, _ *amqp.Delivery) context.Context {
+		if key, ok := ctx.Value(APIKeyContextKey).(string); ok {
+			if pub.Headers == nil {
+				pub.Headers = amqp.Table{}
+			}
+			pub.Headers[header] = key
+		}
+		return ctx
+	}
+}
+
+func headerName(header string) string {
+	if header == "" {
+		return DefaultHeader
+	}
+	return header
+}
@@ -0,0 +1,73 @@
+package apikey
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/streadway/amqp"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestHTTPToContext(t *testing.T) {
+	reqFunc := HTTPToContext("")
+
+	r, _ := http.NewRequest("GET", "/", nil)
+	ctx := reqFunc(context.Background(), r)
+	if ctx.Value(APIKeyContextKey) != nil {
+		t.Error("Context shouldn't contain the API key")
+	}
+
+	r.Header.Set(DefaultHeader, "k1")
+	ctx = reqFunc(context.Background(), r)
+	if want, have := "k1", ctx.Value(APIKeyContextKey); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
+
+func TestHTTPQueryToContext(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/?api_key=k1", nil)
+	ctx := HTTPQueryToContext("api_key")(context.Background(), r)
+	if want, have := "k1", ctx.Value(APIKeyContextKey); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
+
+func TestContextToHTTP(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/", nil)
+	ctx := context.WithValue(context.Background(), APIKeyContextKey, "k1")
+	ContextToHTTP("X-Custom-Key")(ctx, r)
+	if want, have := "k1", r.Header.Get("X-Custom-Key"); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
+
+func TestGRPCRoundTrip(t *testing.T) {
+	md := metadata.MD{}
+	ctx := context.WithValue(context.Background(), APIKeyContextKey, "k1")
+	ContextToGRPC("")(ctx, &md)
+	if want, have := "k1", md["x-api-key"][0]; want != have {
+		t.Fatalf("want %v, have %v", want, have)
+	}
+
+	ctx = GRPCToContext("")(context.Background(), md)
+	if want, have := "k1", ctx.Value(APIKeyContextKey); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
+
+func TestAMQPRoundTrip(t *testing.T) {
+	pub := amqp.Publishing{}
+	ctx := context.WithValue(context.Background(), APIKeyContextKey, "k1")
+	ContextToAMQP("")(ctx, &pub, nil)
+
+	ctx = AMQPToContext("")(context.Background(), nil, &amqp.Delivery{Headers: pub.Headers})
+	if want, have := "k1", ctx.Value(APIKeyContextKey); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+
+	ctx = AMQPToContext("")(context.Background(), nil, &amqp.Delivery{Headers: amqp.Table{DefaultHeader: []byte("k2")}})
+	if want, have := "k2", ctx.Value(APIKeyContextKey); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
@@ -0,0 +1,226 @@
+package authz
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/inturn/kit/endpoint"
+)
+
+// Subject is the authenticated caller of an endpoint.
+type Subject struct {
+	// ID identifies the caller, e.g. the subject claim of a JWT.
+	ID string
+
+	// Roles lists the roles held by the caller.
+	Roles []string
+
+	// Scopes lists the scopes granted to the caller.
+	Scopes []string
+
+	// Issuer is the party that authenticated the caller, e.g. the issuer
+	// claim of a JWT.
+	Issuer string
+
+	// Audience lists the recipients the caller's credentials are meant for.
+	Audience []string
+}
+
+// HasRole reports whether role is held by the subject.
+func (s Subject) HasRole(role string) bool {
+	return contains(s.Roles, role)
+}
+
+// HasScope reports whether scope is granted to the subject.
+func (s Subject) HasScope(scope string) bool {
+	return contains(s.Scopes, scope)
+}
+
+// SubjectFunc returns the Subject of a request from its context, where an
+// authentication middleware has put the caller's claims. It returns false if
+// the request is not authenticated.
+type SubjectFunc func(ctx context.Context) (Subject, bool)
+
+// Requirement declares what a subject needs to be allowed to call an
+// endpoint.
+type Requirement struct {
+	// Scopes must all be granted to the subject.
+	Scopes []string
+
+	// Roles lists roles of which the subject must hold at least one. If
+	// empty, no role is required.
+	Roles []string
+
+	// Resource and Action describe the endpoint for policy engines deciding
+	// by resource and action, like casbin.
+	Resource string
+	Action   string
+
+	// Issuer, if set, must be the issuer of the subject. Subjects from other
+	// issuers fail with an UnauthenticatedError.
+	Issuer string
+
+	// Audience, if set, must be among the audience of the subject. Other
+	// subjects fail with an UnauthenticatedError.
+	Audience string
+}
+
+// Policy decides whether a subject fulfills a requirement. It returns false
+// if access is denied and an error if it could not decide.
+type Policy interface {
+	Allow(ctx context.Context, s Subject, r Requirement) (bool, error)
+}
+
+// PolicyFunc is an adapter to allow the use of ordinary functions as
+// Policies.
+type PolicyFunc func(ctx context.Context, s Subject, r Requirement) (bool, error)
+
+// Allow implements Policy.
+func (f PolicyFunc) Allow(ctx context.Context, s Subject, r Requirement) (bool, error) {
+	return f(ctx, s, r)
+}
+
+// StaticPolicy returns a Policy allowing subjects granted all scopes and
+// holding at least one of the roles of a requirement. Resource and Action are
+// ignored.
+func StaticPolicy() Policy {
+	return PolicyFunc(func(_ context.Context, s Subject, r Requirement) (bool, error) {
+		for _, scope := range r.Scopes {
+			if !s.HasScope(scope) {
+				return false, nil
+			}
+		}
+		if len(r.Roles) == 0 {
+			return true, nil
+		}
+		for _, role := range r.Roles {
+			if s.HasRole(role) {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+}
+
+// ErrUnauthenticated denotes a request without a subject reached the
+// authorizing middleware.
+var ErrUnauthenticated error = UnauthenticatedError{}
+
+// UnauthenticatedError denotes a request is not or not validly authenticated.
+// It is encoded as 401 Unauthorized by the HTTP transport and as
+// codes.Unauthenticated by the gRPC transport.
+type UnauthenticatedError struct {
+	// Err is the reason authentication failed, if known.
+	Err error
+}
+
+// Error implements the error interface.
+func (e UnauthenticatedError) Error() string {
+	if e.Err == nil {
+		return "request is not authenticated"
+	}
+	return "request is not authenticated: " + e.Err.Error()
+}
+
+// StatusCode implements the StatusCoder interface of the HTTP transport.
+func (UnauthenticatedError) StatusCode() int {
+	return http.StatusUnauthorized
+}
+
+// GRPCStatus is recognized by gRPC when returning errors from a handler.
+func (e UnauthenticatedError) GRPCStatus() *status.Status {
+	return status.New(codes.Unauthenticated, e.Error())
+}
+
+// IsUnauthenticated reports whether err is an UnauthenticatedError.
+func IsUnauthenticated(err error) bool {
+	_, ok := err.(UnauthenticatedError)
+	return ok
+}
+
+// ForbiddenError denotes an authenticated subject is not allowed to call an
+// endpoint. It is encoded as 403 Forbidden by the HTTP transport and as
+// codes.PermissionDenied by the gRPC transport.
+type ForbiddenError struct {
+	// Subject is the ID of the denied subject.
+	Subject string
+}
+
+// Error implements the error interface.
+func (e ForbiddenError) Error() string {
+	if e.Subject == "" {
+		return "access forbidden"
+	}
+	return fmt.Sprintf("access forbidden for %q", e.Subject)
+}
+
+// StatusCode implements the StatusCoder interface of the HTTP transport.
+func (ForbiddenError) StatusCode() int {
+	return http.StatusForbidden
+}
+
+// GRPCStatus is recognized by gRPC when returning errors from a handler.
+func (e ForbiddenError) GRPCStatus() *status.Status {
+	return status.New(codes.PermissionDenied, e.Error())
+}
+
+// IsForbidden reports whether err is a ForbiddenError, e.g. for transport
+// error encoders choosing a reply.
+func IsForbidden(err error) bool {
+	_, ok := err.(ForbiddenError)
+	return ok
+}
+
+// NewAuthorizer creates a new authorizing middleware. It denies requests
+// whose subject, as returned by subject, does not fulfill req according to
+// policy with a ForbiddenError, and requests without subject or with a
+// subject from the wrong issuer or audience with an UnauthenticatedError.
+// Place it inside the authenticating middleware, e.g. jwt.NewParser.
+func NewAuthorizer(subject SubjectFunc, policy Policy, req Requirement) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+			s, ok := subject(ctx)
+			if !ok {
+				return nil, ErrUnauthenticated
+			}
+			if err := checkCredentials(s, req); err != nil {
+				return nil, err
+			}
+
+			allowed, err := policy.Allow(ctx, s, req)
+			if err != nil {
+				return nil, err
+			}
+			if !allowed {
+				return nil, ForbiddenError{Subject: s.ID}
+			}
+
+			return next(ctx, request)
+		}
+	}
+}
+
+// checkCredentials checks that the subject's credentials were issued by and
+// for the parties the requirement expects.
+func checkCredentials(s Subject, req Requirement) error {
+	if req.Issuer != "" && s.Issuer != req.Issuer {
+		return UnauthenticatedError{Err: fmt.Errorf("unexpected issuer %q", s.Issuer)}
+	}
+	if req.Audience != "" && !contains(s.Audience, req.Audience) {
+		return UnauthenticatedError{Err: fmt.Errorf("audience %q not granted", req.Audience)}
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
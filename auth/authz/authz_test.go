@@ -0,0 +1,98 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	httptransport "github.com/inturn/kit/transport/http"
+)
+
+type subjectKey struct{}
+
+func contextSubject(ctx context.Context) (Subject, bool) {
+	s, ok := ctx.Value(subjectKey{}).(Subject)
+	return s, ok
+}
+
+func TestStaticPolicy(t *testing.T) {
+	policy := StaticPolicy()
+	subject := Subject{ID: "alice", Roles: []string{"admin"}, Scopes: []string{"read", "write"}}
+
+	for _, tt := range []struct {
+		name string
+		req  Requirement
+		want bool
+	}{
+		{"no requirement", Requirement{}, true},
+		{"granted scopes", Requirement{Scopes: []string{"read", "write"}}, true},
+		{"missing scope", Requirement{Scopes: []string{"read", "delete"}}, false},
+		{"any role", Requirement{Roles: []string{"owner", "admin"}}, true},
+		{"missing role", Requirement{Roles: []string{"owner"}}, false},
+		{"scopes and roles", Requirement{Scopes: []string{"read"}, Roles: []string{"admin"}}, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			have, err := policy.Allow(context.Background(), subject, tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want != have {
+				t.Errorf("want %v, have %v", tt.want, have)
+			}
+		})
+	}
+}
+
+func TestNewAuthorizer(t *testing.T) {
+	e := func(ctx context.Context, i interface{}) (interface{}, error) { return true, nil }
+	authorizer := NewAuthorizer(contextSubject, StaticPolicy(), Requirement{Scopes: []string{"write"}})(e)
+
+	if _, err := authorizer(context.Background(), nil); err != ErrUnauthenticated {
+		t.Errorf("want %v, have %v", ErrUnauthenticated, err)
+	}
+
+	ctx := context.WithValue(context.Background(), subjectKey{}, Subject{ID: "bob", Scopes: []string{"read"}})
+	_, err := authorizer(ctx, nil)
+	if want, have := (ForbiddenError{Subject: "bob"}), err; want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	if !IsForbidden(err) {
+		t.Error("want IsForbidden to be true")
+	}
+
+	ctx = context.WithValue(context.Background(), subjectKey{}, Subject{ID: "alice", Scopes: []string{"write"}})
+	if _, err := authorizer(ctx, nil); err != nil {
+		t.Errorf("want no error, have %v", err)
+	}
+
+	errPolicy := errors.New("policy unavailable")
+	failing := PolicyFunc(func(context.Context, Subject, Requirement) (bool, error) { return false, errPolicy })
+	if _, err := NewAuthorizer(contextSubject, failing, Requirement{})(e)(ctx, nil); err != errPolicy {
+		t.Errorf("want %v, have %v", errPolicy, err)
+	}
+}
+
+func TestErrorEncoding(t *testing.T) {
+	for _, tt := range []struct {
+		err      error
+		wantHTTP int
+		wantGRPC codes.Code
+	}{
+		{ErrUnauthenticated, http.StatusUnauthorized, codes.Unauthenticated},
+		{ForbiddenError{Subject: "bob"}, http.StatusForbidden, codes.PermissionDenied},
+	} {
+		rec := httptest.NewRecorder()
+		httptransport.DefaultErrorEncoder(context.Background(), tt.err, rec)
+		if want, have := tt.wantHTTP, rec.Code; want != have {
+			t.Errorf("%v: want HTTP status %d, have %d", tt.err, want, have)
+		}
+		if want, have := tt.wantGRPC, status.Code(tt.err); want != have {
+			t.Errorf("%v: want gRPC code %s, have %s", tt.err, want, have)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package authz
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/inturn/kit/endpoint"
+)
+
+// Registry holds the requirements of a service's endpoints, declared by
+// endpoint name, and enforces them centrally. Endpoints without declared
+// requirement are denied, so a forgotten declaration can't open an endpoint.
+type Registry struct {
+	subject      SubjectFunc
+	policy       Policy
+	authenticate endpoint.Middleware
+
+	mtx          sync.RWMutex
+	requirements map[string]Requirement
+	public       map[string]bool
+}
+
+// RegistryOption sets an optional parameter for Registries.
+type RegistryOption func(*Registry)
+
+// RegistryAuthenticator sets the authenticating middleware run before the
+// requirements are checked, e.g. jwt.NewParser. Errors it returns are turned
+// into UnauthenticatedErrors, so every transport reports failed
+// authentication alike. By default, the context is expected to be
+// authenticated already.
+func RegistryAuthenticator(m endpoint.Middleware) RegistryOption {
+	return func(r *Registry) { r.authenticate = m }
+}
+
+// NewRegistry returns an empty Registry deciding with policy on the subjects
+// returned by subject.
+func NewRegistry(subject SubjectFunc, policy Policy, options ...RegistryOption) *Registry {
+	r := &Registry{
+		subject:      subject,
+		policy:       policy,
+		requirements: map[string]Requirement{},
+		public:       map[string]bool{},
+	}
+	for _, option := range options {
+		option(r)
+	}
+	return r
+}
+
+// Require declares the requirement of the named endpoint.
+func (r *Registry) Require(name string, req Requirement) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.requirements[name] = req
+	delete(r.public, name)
+}
+
+// Public declares the named endpoint as callable without authentication.
+func (r *Registry) Public(name string) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.public[name] = true
+	delete(r.requirements, name)
+}
+
+// Requirements returns the declared requirements by endpoint name, e.g. to
+// document or audit them.
+func (r *Registry) Requirements() map[string]Requirement {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	reqs := make(map[string]Requirement, len(r.requirements))
+	for name, req := range r.requirements {
+		reqs[name] = req
+	}
+	return reqs
+}
+
+// Undeclared returns the sorted names of the given endpoints which have
+// neither a requirement nor are public, e.g. to fail at startup.
+func (r *Registry) Undeclared(names ...string) []string {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	var undeclared []string
+	for _, name := range names {
+		if _, ok := r.requirements[name]; !ok && !r.public[name] {
+			undeclared = append(undeclared, name)
+		}
+	}
+	sort.Strings(undeclared)
+	return undeclared
+}
+
+// Middleware returns a middleware enforcing the requirement of the named
+// endpoint. The requirement is looked up per request, so endpoints may be
+// wrapped before their requirements are declared. Requests to endpoints
+// without declaration fail with a ForbiddenError.
+func (r *Registry) Middleware(name string) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		authorized := func(ctx context.Context, request interface{}) (interface{}, error) {
+			r.mtx.RLock()
+			req, ok := r.requirements[name]
+			r.mtx.RUnlock()
+			if !ok {
+				return nil, ForbiddenError{}
+			}
+			return NewAuthorizer(r.subject, r.policy, req)(next)(ctx, request)
+		}
+
+		authenticated := authorized
+		if r.authenticate != nil {
+			authenticated = r.authenticate(func(ctx context.Context, request interface{}) (interface{}, error) {
+				response, err := authorized(ctx, request)
+				// wrap errors of the endpoint, so that they aren't mistaken
+				// for authentication errors below
+				if err != nil {
+					return nil, endpointError{err}
+				}
+				return response, nil
+			})
+		}
+
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			r.mtx.RLock()
+			public := r.public[name]
+			r.mtx.RUnlock()
+			if public {
+				return next(ctx, request)
+			}
+
+			response, err := authenticated(ctx, request)
+			switch e := err.(type) {
+			case nil:
+				return response, nil
+			case endpointError:
+				return nil, e.err
+			case UnauthenticatedError, ForbiddenError:
+				return nil, err
+			}
+			if r.authenticate != nil {
+				return nil, UnauthenticatedError{Err: err}
+			}
+			return nil, err
+		}
+	}
+}
+
+// endpointError marks errors returned from within the authenticating
+// middleware.
+type endpointError struct {
+	err error
+}
+
+func (e endpointError) Error() string { return e.err.Error() }
@@ -0,0 +1,97 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/inturn/kit/endpoint"
+)
+
+func TestRegistry(t *testing.T) {
+	errToken := errors.New("token invalid")
+	errBusiness := errors.New("order not found")
+
+	// authenticates requests carrying a subject, like jwt.NewParser does for
+	// requests carrying a token
+	authenticate := func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			if _, ok := contextSubject(ctx); !ok {
+				return nil, errToken
+			}
+			return next(ctx, request)
+		}
+	}
+
+	registry := NewRegistry(contextSubject, StaticPolicy(), RegistryAuthenticator(authenticate))
+
+	e := func(ctx context.Context, request interface{}) (interface{}, error) {
+		if request == "fail" {
+			return nil, errBusiness
+		}
+		return true, nil
+	}
+	endpoints := map[string]endpoint.Endpoint{}
+	for _, name := range []string{"GetOrder", "DeleteOrder", "Health", "Undeclared"} {
+		endpoints[name] = registry.Middleware(name)(e)
+	}
+
+	registry.Require("GetOrder", Requirement{Scopes: []string{"orders:read"}, Issuer: "https://idp"})
+	registry.Require("DeleteOrder", Requirement{Scopes: []string{"orders:write"}, Audience: "orders"})
+	registry.Public("Health")
+
+	alice := context.WithValue(context.Background(), subjectKey{}, Subject{
+		ID:       "alice",
+		Scopes:   []string{"orders:read"},
+		Issuer:   "https://idp",
+		Audience: []string{"orders"},
+	})
+	mallory := context.WithValue(context.Background(), subjectKey{}, Subject{
+		ID:     "mallory",
+		Scopes: []string{"orders:read", "orders:write"},
+		Issuer: "https://evil",
+	})
+
+	for _, tt := range []struct {
+		name     string
+		endpoint string
+		ctx      context.Context
+		request  interface{}
+		check    func(error) bool
+	}{
+		{"allowed", "GetOrder", alice, nil, func(err error) bool { return err == nil }},
+		{"business error", "GetOrder", alice, "fail", func(err error) bool { return err == errBusiness }},
+		{"missing scope", "DeleteOrder", alice, nil, IsForbidden},
+		{"unauthenticated", "GetOrder", context.Background(), nil, func(err error) bool {
+			return err == UnauthenticatedError{Err: errToken}
+		}},
+		{"wrong issuer", "GetOrder", mallory, nil, IsUnauthenticated},
+		{"wrong audience", "DeleteOrder", mallory, nil, IsUnauthenticated},
+		{"public", "Health", context.Background(), nil, func(err error) bool { return err == nil }},
+		{"undeclared", "Undeclared", alice, nil, IsForbidden},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := endpoints[tt.endpoint](tt.ctx, tt.request)
+			if !tt.check(err) {
+				t.Errorf("unexpected error %v", err)
+			}
+		})
+	}
+
+	if want, have := []string{"Undeclared"}, registry.Undeclared("GetOrder", "Health", "Undeclared"); !reflect.DeepEqual(want, have) {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	if want, have := 2, len(registry.Requirements()); want != have {
+		t.Errorf("want %d requirements, have %d", want, have)
+	}
+}
+
+func TestRegistryWithoutAuthenticator(t *testing.T) {
+	registry := NewRegistry(contextSubject, StaticPolicy())
+	registry.Require("GetOrder", Requirement{})
+
+	if _, err := registry.Middleware("GetOrder")(endpoint.Nop)(context.Background(), nil); err != ErrUnauthenticated {
+		t.Errorf("want %v, have %v", ErrUnauthenticated, err)
+	}
+}
@@ -0,0 +1,64 @@
+package authz
+
+import (
+	"context"
+	"strings"
+
+	stdjwt "github.com/dgrijalva/jwt-go"
+
+	"github.com/inturn/kit/auth/apikey"
+	"github.com/inturn/kit/auth/jwt"
+)
+
+// JWTSubject returns a SubjectFunc reading the claims stored in the context
+// by jwt.NewParser. The claims must be jwt.MapClaims. The subject ID, issuer
+// and audience are read from the "sub", "iss" and "aud" claims, roles and scopes from rolesClaim and scopesClaim,
+// which may hold a list of strings or a space separated string as the OAuth2
+// "scope" claim does.
+func JWTSubject(rolesClaim, scopesClaim string) SubjectFunc {
+	return func(ctx context.Context) (Subject, bool) {
+		claims, ok := ctx.Value(jwt.JWTClaimsContextKey).(stdjwt.MapClaims)
+		if !ok {
+			return Subject{}, false
+		}
+		sub, _ := claims["sub"].(string)
+		iss, _ := claims["iss"].(string)
+		return Subject{
+			ID:       sub,
+			Roles:    stringsClaim(claims[rolesClaim]),
+			Scopes:   stringsClaim(claims[scopesClaim]),
+			Issuer:   iss,
+			Audience: stringsClaim(claims["aud"]),
+		}, true
+	}
+}
+
+// APIKeySubject returns a SubjectFunc reading the principal stored in the
+// context by apikey.NewAuthenticator.
+func APIKeySubject() SubjectFunc {
+	return func(ctx context.Context) (Subject, bool) {
+		p, ok := apikey.PrincipalFromContext(ctx)
+		if !ok {
+			return Subject{}, false
+		}
+		return Subject{ID: p.ID, Scopes: p.Scopes}, true
+	}
+}
+
+func stringsClaim(v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		return strings.Fields(v)
+	case []string:
+		return v
+	case []interface{}:
+		list := make([]string, 0, len(v))
+		for _, e := range v {
+			if s, ok := e.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
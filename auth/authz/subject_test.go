@@ -0,0 +1,45 @@
+package authz
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	stdjwt "github.com/dgrijalva/jwt-go"
+
+	"github.com/inturn/kit/auth/apikey"
+	"github.com/inturn/kit/auth/jwt"
+)
+
+func TestJWTSubject(t *testing.T) {
+	subject := JWTSubject("roles", "scope")
+
+	if _, ok := subject(context.Background()); ok {
+		t.Error("want no subject without claims")
+	}
+
+	ctx := context.WithValue(context.Background(), jwt.JWTClaimsContextKey, stdjwt.MapClaims{
+		"sub":   "alice",
+		"roles": []interface{}{"admin", "auditor"},
+		"scope": "read write",
+	})
+	have, ok := subject(ctx)
+	if !ok {
+		t.Fatal("want subject")
+	}
+	want := Subject{ID: "alice", Roles: []string{"admin", "auditor"}, Scopes: []string{"read", "write"}}
+	if !reflect.DeepEqual(want, have) {
+		t.Errorf("want %+v, have %+v", want, have)
+	}
+}
+
+func TestAPIKeySubject(t *testing.T) {
+	ctx := context.WithValue(context.Background(), apikey.PrincipalContextKey, apikey.Principal{ID: "client-1", Scopes: []string{"read"}})
+	have, ok := APIKeySubject()(ctx)
+	if !ok {
+		t.Fatal("want subject")
+	}
+	if want := (Subject{ID: "client-1", Scopes: []string{"read"}}); !reflect.DeepEqual(want, have) {
+		t.Errorf("want %+v, have %+v", want, have)
+	}
+}
@@ -29,12 +29,13 @@ func (AuthError) Error() string {
 	return http.StatusText(http.StatusUnauthorized)
 }
 
-// Headers is an implementation of the Headerer interface in inturn/http.
+// Headers is an implementation of the Headerer interface in inturn/http. The
+// challenge announces UTF-8 as the credentials charset as per RFC 7617.
 func (e AuthError) Headers() http.Header {
 	return http.Header{
 		"Content-Type":           []string{"text/plain; charset=utf-8"},
 		"X-Content-Type-Options": []string{"nosniff"},
-		"WWW-Authenticate":       []string{fmt.Sprintf(`Basic realm=%q`, e.Realm)},
+		"WWW-Authenticate":       []string{fmt.Sprintf(`Basic realm=%q, charset="UTF-8"`, e.Realm)},
 	}
 }
 
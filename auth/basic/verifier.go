@@ -0,0 +1,214 @@
+package basic
+
+import (
+	"bufio"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/inturn/kit/endpoint"
+	httptransport "github.com/inturn/kit/transport/http"
+)
+
+type contextKey int
+
+const (
+	// ContextKeyUsername holds the key used to store the username of an
+	// authenticated request in the context.
+	ContextKeyUsername contextKey = iota
+)
+
+// Verifier verifies a username and password pair. Implementations should
+// take constant time regardless of which of the two is wrong.
+type Verifier func(ctx context.Context, username, password string) bool
+
+// StaticVerifier returns a Verifier accepting the username and password
+// pairs in users. Comparisons are constant-time.
+func StaticVerifier(users map[string]string) Verifier {
+	hashed := make(map[string][]byte, len(users))
+	for u, p := range users {
+		hashed[u] = toHashSlice([]byte(p))
+	}
+	// compared against for unknown users, so they take as long as known ones
+	dummy := toHashSlice(nil)
+
+	return func(_ context.Context, username, password string) bool {
+		required, ok := hashed[username]
+		if !ok {
+			required = dummy
+		}
+		match := subtle.ConstantTimeCompare(toHashSlice([]byte(password)), required) == 1
+		return ok && match
+	}
+}
+
+// HtpasswdVerifier returns a Verifier accepting the users of an htpasswd
+// file read from r. Passwords hashed with bcrypt, Apache MD5 (apr1) and SHA1
+// are supported.
+func HtpasswdVerifier(r io.Reader) (Verifier, error) {
+	users := map[string]string{}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		i := strings.IndexByte(entry, ':')
+		if i < 1 {
+			return nil, fmt.Errorf("htpasswd: malformed entry on line %d", line)
+		}
+		hash := entry[i+1:]
+		if !strings.HasPrefix(hash, "$2") && !strings.HasPrefix(hash, "$apr1$") && !strings.HasPrefix(hash, "{SHA}") {
+			return nil, fmt.Errorf("htpasswd: unsupported hash on line %d", line)
+		}
+		users[entry[:i]] = hash
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return func(_ context.Context, username, password string) bool {
+		hash, ok := users[username]
+		if !ok {
+			return false
+		}
+		switch {
+		case strings.HasPrefix(hash, "$2"):
+			return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+		case strings.HasPrefix(hash, "$apr1$"):
+			salt := strings.SplitN(hash[len("$apr1$"):], "$", 2)[0]
+			return subtle.ConstantTimeCompare([]byte(apr1(password, salt)), []byte(hash)) == 1
+		default:
+			sum := sha1.Sum([]byte(password))
+			given := "{SHA}" + base64.StdEncoding.EncodeToString(sum[:])
+			return subtle.ConstantTimeCompare([]byte(given), []byte(hash)) == 1
+		}
+	}, nil
+}
+
+// HtpasswdFile returns a Verifier accepting the users of the htpasswd file
+// at path. See HtpasswdVerifier.
+func HtpasswdFile(path string) (Verifier, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return HtpasswdVerifier(f)
+}
+
+// VerifierMiddleware returns a Basic Authentication middleware checking
+// credentials with verify. Failed authentications return an AuthError, which
+// the HTTP transport encodes as a 401 response with a challenge for realm.
+// On success the username is stored in the context under
+// ContextKeyUsername.
+func VerifierMiddleware(verify Verifier, realm string) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			auth, ok := ctx.Value(httptransport.ContextKeyRequestAuthorization).(string)
+			if !ok {
+				return nil, AuthError{realm}
+			}
+
+			givenUser, givenPassword, ok := parseBasicAuth(auth)
+			if !ok {
+				return nil, AuthError{realm}
+			}
+
+			if !verify(ctx, string(givenUser), string(givenPassword)) {
+				return nil, AuthError{realm}
+			}
+
+			ctx = context.WithValue(ctx, ContextKeyUsername, string(givenUser))
+			return next(ctx, request)
+		}
+	}
+}
+
+// HTTPToContext moves the Authorization header of the request to the context
+// where the middlewares of this package expect it. Use it as a ServerBefore
+// when not using httptransport.PopulateRequestContext.
+func HTTPToContext() httptransport.RequestFunc {
+	return func(ctx context.Context, r *http.Request) context.Context {
+		return context.WithValue(ctx, httptransport.ContextKeyRequestAuthorization, r.Header.Get("Authorization"))
+	}
+}
+
+// apr1 computes the Apache variant of the MD5 based crypt(3) hash.
+func apr1(password, salt string) string {
+	const magic = "$apr1$"
+	pw := []byte(password)
+	if len(salt) > 8 {
+		salt = salt[:8]
+	}
+
+	alt := md5.New()
+	alt.Write(pw)
+	alt.Write([]byte(salt))
+	alt.Write(pw)
+	altSum := alt.Sum(nil)
+
+	h := md5.New()
+	h.Write(pw)
+	h.Write([]byte(magic + salt))
+	for i := len(pw); i > 0; i -= 16 {
+		n := i
+		if n > 16 {
+			n = 16
+		}
+		h.Write(altSum[:n])
+	}
+	for i := len(pw); i > 0; i >>= 1 {
+		if i&1 != 0 {
+			h.Write([]byte{0})
+		} else {
+			h.Write(pw[:1])
+		}
+	}
+	sum := h.Sum(nil)
+
+	for i := 0; i < 1000; i++ {
+		r := md5.New()
+		if i&1 != 0 {
+			r.Write(pw)
+		} else {
+			r.Write(sum)
+		}
+		if i%3 != 0 {
+			r.Write([]byte(salt))
+		}
+		if i%7 != 0 {
+			r.Write(pw)
+		}
+		if i&1 != 0 {
+			r.Write(sum)
+		} else {
+			r.Write(pw)
+		}
+		sum = r.Sum(nil)
+	}
+
+	const itoa64 = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	out := make([]byte, 0, 22)
+	encode := func(v uint, n int) {
+		for ; n > 0; n-- {
+			out = append(out, itoa64[v&0x3f])
+			v >>= 6
+		}
+	}
+	for _, g := range [][3]int{{0, 6, 12}, {1, 7, 13}, {2, 8, 14}, {3, 9, 15}, {4, 10, 5}} {
+		encode(uint(sum[g[0]])<<16|uint(sum[g[1]])<<8|uint(sum[g[2]]), 4)
+	}
+	encode(uint(sum[11]), 2)
+
+	return magic + salt + "$" + string(out)
+}
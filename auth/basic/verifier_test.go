@@ -0,0 +1,152 @@
+package basic
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+
+	httptransport "github.com/inturn/kit/transport/http"
+)
+
+func TestStaticVerifier(t *testing.T) {
+	verify := StaticVerifier(map[string]string{"alice": "secret"})
+
+	for _, tt := range []struct {
+		user, password string
+		want           bool
+	}{
+		{"alice", "secret", true},
+		{"alice", "wrong", false},
+		{"bob", "secret", false},
+		{"", "", false},
+	} {
+		if have := verify(context.Background(), tt.user, tt.password); tt.want != have {
+			t.Errorf("%s:%s: want %v, have %v", tt.user, tt.password, tt.want, have)
+		}
+	}
+}
+
+func TestHtpasswdVerifier(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := strings.Join([]string{
+		"# users",
+		"bcrypt:" + string(hash),
+		"apr1:$apr1$saltsalt$LrttParrLPdxvgutaSXWJ0",
+		"",
+		"sha:{SHA}5en6G6MezRroT3XKqkdPOmY/BfQ=",
+	}, "\n")
+
+	verify, err := HtpasswdVerifier(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, user := range []string{"bcrypt", "apr1", "sha"} {
+		if !verify(context.Background(), user, "secret") {
+			t.Errorf("%s: want valid password", user)
+		}
+		if verify(context.Background(), user, "wrong") {
+			t.Errorf("%s: want invalid password", user)
+		}
+	}
+	if verify(context.Background(), "unknown", "secret") {
+		t.Error("unknown: want invalid user")
+	}
+}
+
+func TestHtpasswdVerifierErrors(t *testing.T) {
+	for _, file := range []string{
+		"missing-colon",
+		":$apr1$saltsalt$LrttParrLPdxvgutaSXWJ0",
+		"plain:secret",
+	} {
+		if _, err := HtpasswdVerifier(strings.NewReader(file)); err == nil {
+			t.Errorf("%q: want error, have nil", file)
+		}
+	}
+}
+
+func TestHtpasswdFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "htpasswd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, ".htpasswd")
+	if err := ioutil.WriteFile(path, []byte("apr1:$apr1$saltsalt$LrttParrLPdxvgutaSXWJ0\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	verify, err := HtpasswdFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !verify(context.Background(), "apr1", "secret") {
+		t.Error("want valid password")
+	}
+
+	if _, err := HtpasswdFile(filepath.Join(dir, "missing")); err == nil {
+		t.Error("want error for missing file, have nil")
+	}
+}
+
+func TestVerifierMiddleware(t *testing.T) {
+	realm := "test realm"
+	verify := StaticVerifier(map[string]string{"alice": "secret"})
+
+	var user interface{}
+	next := func(ctx context.Context, request interface{}) (interface{}, error) {
+		user = ctx.Value(ContextKeyUsername)
+		return true, nil
+	}
+
+	for _, tt := range []struct {
+		name       string
+		authHeader interface{}
+		wantErr    error
+	}{
+		{"Isn't valid with nil header", nil, AuthError{realm}},
+		{"Isn't valid with malformed header", "Bearer token", AuthError{realm}},
+		{"Isn't valid for wrong password", makeAuthString("alice", "wrong"), AuthError{realm}},
+		{"Is valid for correct creds", makeAuthString("alice", "secret"), nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), httptransport.ContextKeyRequestAuthorization, tt.authHeader)
+			_, err := VerifierMiddleware(verify, realm)(next)(ctx, nil)
+			if err != tt.wantErr {
+				t.Errorf("want error %v, have %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	if want, have := "alice", user; want != have {
+		t.Errorf("want username %v, have %v", want, have)
+	}
+}
+
+func TestHTTPToContext(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/", nil)
+	r.SetBasicAuth("alice", "secret")
+
+	ctx := HTTPToContext()(context.Background(), r)
+	if want, have := makeAuthString("alice", "secret"), ctx.Value(httptransport.ContextKeyRequestAuthorization); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
+
+func TestAuthErrorChallenge(t *testing.T) {
+	want := `Basic realm="test realm", charset="UTF-8"`
+	if have := (AuthError{"test realm"}).Headers()["WWW-Authenticate"][0]; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
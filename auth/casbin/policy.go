@@ -0,0 +1,27 @@
+package casbin
+
+import (
+	"context"
+
+	stdcasbin "github.com/casbin/casbin"
+
+	"github.com/inturn/kit/auth/authz"
+)
+
+// NewPolicy returns an authz.Policy enforcing the access control model and
+// policy of enforcer. A subject is allowed if its ID or any of its roles may
+// perform the requirement's Action on its Resource.
+func NewPolicy(enforcer *stdcasbin.Enforcer) authz.Policy {
+	return authz.PolicyFunc(func(_ context.Context, s authz.Subject, r authz.Requirement) (bool, error) {
+		for _, sub := range append([]string{s.ID}, s.Roles...) {
+			allowed, err := enforcer.EnforceSafe(sub, r.Resource, r.Action)
+			if err != nil {
+				return false, err
+			}
+			if allowed {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+}
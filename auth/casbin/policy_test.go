@@ -0,0 +1,34 @@
+package casbin
+
+import (
+	"context"
+	"testing"
+
+	stdcasbin "github.com/casbin/casbin"
+
+	"github.com/inturn/kit/auth/authz"
+)
+
+func TestNewPolicy(t *testing.T) {
+	enforcer := stdcasbin.NewEnforcer("testdata/basic_model.conf", "testdata/keymatch_policy.csv")
+	policy := NewPolicy(enforcer)
+
+	for _, tt := range []struct {
+		subject authz.Subject
+		req     authz.Requirement
+		want    bool
+	}{
+		{authz.Subject{ID: "alice"}, authz.Requirement{Resource: "/alice_data/resource1", Action: "POST"}, true},
+		{authz.Subject{ID: "alice"}, authz.Requirement{Resource: "/alice_data/resource2", Action: "POST"}, false},
+		{authz.Subject{ID: "dave", Roles: []string{"bob"}}, authz.Requirement{Resource: "/alice_data/resource2", Action: "GET"}, true},
+		{authz.Subject{ID: "dave"}, authz.Requirement{Resource: "/alice_data/resource2", Action: "GET"}, false},
+	} {
+		have, err := policy.Allow(context.Background(), tt.subject, tt.req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tt.want != have {
+			t.Errorf("%+v %+v: want %v, have %v", tt.subject, tt.req, tt.want, have)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package jwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+
+	"github.com/inturn/kit/log"
+)
+
+var (
+	// ErrKIDMissing denotes a token has no key ID header (kid), which is
+	// required to look up its key in a JWKS.
+	ErrKIDMissing = errors.New("JWT Token has no key ID")
+
+	// ErrKeyNotFound denotes the key ID of a token is not part of the JWKS.
+	ErrKeyNotFound = errors.New("JWT Token key ID not found in JWKS")
+)
+
+// JWKS is a JSON Web Key Set fetched from a remote URL, typically the
+// jwks_uri of an identity provider. Keys are cached and refreshed in the
+// background. When a token refers to an unknown key ID, the set is refreshed
+// on demand so keys rotated by the provider are picked up without delay.
+//
+// Its Keyfunc method can be passed to NewParser. Combine it with the
+// ParserIssuer and ParserAudience options to validate tokens issued by the
+// provider.
+type JWKS struct {
+	url                string
+	client             *http.Client
+	fetchTimeout       time.Duration
+	refreshInterval    time.Duration
+	minRefreshInterval time.Duration
+	logger             log.Logger
+
+	mtx       sync.RWMutex
+	keys      map[string]interface{}
+	lastFetch time.Time
+
+	refreshMtx sync.Mutex
+	quit       chan struct{}
+	stopOnce   sync.Once
+}
+
+// JWKSOption sets an optional parameter for the JWKS.
+type JWKSOption func(*JWKS)
+
+// JWKSHTTPClient sets the client used to fetch the key set. By default
+// http.DefaultClient is used.
+func JWKSHTTPClient(client *http.Client) JWKSOption {
+	return func(j *JWKS) { j.client = client }
+}
+
+// JWKSFetchTimeout sets the time limit of a fetch of the key set. Fetches
+// triggered by unknown key IDs block the requests carrying them, so a
+// provider which stops responding mustn't hold them up for long. A zero
+// timeout disables it. By default it is five seconds.
+func JWKSFetchTimeout(d time.Duration) JWKSOption {
+	return func(j *JWKS) { j.fetchTimeout = d }
+}
+
+// JWKSRefreshInterval sets the interval of the background refresh. A zero
+// interval disables it. By default the key set is refreshed every hour.
+func JWKSRefreshInterval(d time.Duration) JWKSOption {
+	return func(j *JWKS) { j.refreshInterval = d }
+}
+
+// JWKSMinRefreshInterval sets the minimum interval between two fetches
+// triggered by unknown key IDs, protecting the provider against tokens with
+// forged key IDs. By default it is one minute.
+func JWKSMinRefreshInterval(d time.Duration) JWKSOption {
+	return func(j *JWKS) { j.minRefreshInterval = d }
+}
+
+// JWKSLogger sets the logger used to report background refresh errors. By
+// default they are not logged.
+func JWKSLogger(logger log.Logger) JWKSOption {
+	return func(j *JWKS) { j.logger = logger }
+}
+
+// NewJWKS fetches the key set at url and returns a JWKS which keeps it up to
+// date. Call Stop to end the background refresh.
+func NewJWKS(url string, options ...JWKSOption) (*JWKS, error) {
+	j := &JWKS{
+		url:                url,
+		client:             http.DefaultClient,
+		fetchTimeout:       5 * time.Second,
+		refreshInterval:    time.Hour,
+		minRefreshInterval: time.Minute,
+		logger:             log.NewNopLogger(),
+		quit:               make(chan struct{}),
+	}
+	for _, option := range options {
+		option(j)
+	}
+
+	if err := j.Refresh(context.Background()); err != nil {
+		return nil, err
+	}
+
+	if j.refreshInterval > 0 {
+		go j.loop()
+	}
+
+	return j, nil
+}
+
+// Keyfunc returns the key matching the key ID header (kid) of token. It
+// implements jwt.Keyfunc.
+func (j *JWKS) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, ok := token.Header["kid"].(string)
+	if !ok || kid == "" {
+		return nil, ErrKIDMissing
+	}
+
+	if key, ok := j.key(kid); ok {
+		return key, nil
+	}
+
+	// The provider may have rotated its keys.
+	if err := j.refreshStale(context.Background()); err != nil {
+		return nil, err
+	}
+	if key, ok := j.key(kid); ok {
+		return key, nil
+	}
+
+	return nil, ErrKeyNotFound
+}
+
+// refreshStale fetches the key set unless it was fetched within the minimum
+// refresh interval. The interval is checked once the fetches before are
+// done, so a burst of tokens with unknown key IDs results in a single
+// request.
+func (j *JWKS) refreshStale(ctx context.Context) error {
+	j.refreshMtx.Lock()
+	defer j.refreshMtx.Unlock()
+
+	j.mtx.RLock()
+	stale := time.Since(j.lastFetch) >= j.minRefreshInterval
+	j.mtx.RUnlock()
+	if !stale {
+		return nil
+	}
+	return j.fetch(ctx)
+}
+
+func (j *JWKS) key(kid string) (interface{}, bool) {
+	j.mtx.RLock()
+	defer j.mtx.RUnlock()
+	key, ok := j.keys[kid]
+	return key, ok
+}
+
+// Refresh fetches the key set. On failure the previously fetched keys are
+// kept.
+func (j *JWKS) Refresh(ctx context.Context) error {
+	j.refreshMtx.Lock()
+	defer j.refreshMtx.Unlock()
+	return j.fetch(ctx)
+}
+
+// fetch fetches the key set. refreshMtx must be held.
+func (j *JWKS) fetch(ctx context.Context) error {
+	if j.fetchTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.fetchTimeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequest("GET", j.url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := j.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetching JWKS: unexpected status %s", resp.Status)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("decoding JWKS: %v", err)
+	}
+
+	keys := make(map[string]interface{}, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kid == "" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			// skip keys we don't understand, the other keys may still be
+			// of use.
+			continue
+		}
+		keys[k.Kid] = key
+	}
+
+	j.mtx.Lock()
+	j.keys = keys
+	j.lastFetch = time.Now()
+	j.mtx.Unlock()
+
+	return nil
+}
+
+// Stop ends the background refresh.
+func (j *JWKS) Stop() {
+	j.stopOnce.Do(func() { close(j.quit) })
+}
+
+func (j *JWKS) loop() {
+	ticker := time.NewTicker(j.refreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := j.Refresh(context.Background()); err != nil {
+				j.logger.Log("component", "jwks", "url", j.url, "err", err)
+			}
+		case <-j.quit:
+			return
+		}
+	}
+}
+
+// jsonWebKey holds the members of a JSON Web Key (RFC 7517) used for
+// signature verification.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+
+	// symmetric
+	K string `json:"k"`
+}
+
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+
+	case "oct":
+		return base64.RawURLEncoding.DecodeString(k.K)
+	}
+
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,196 @@
+package jwt
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+type jwksServer struct {
+	mtx     sync.Mutex
+	keys    map[string]*rsa.PrivateKey
+	fetches int
+}
+
+func (s *jwksServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.fetches++
+
+	set := map[string][]map[string]string{"keys": {}}
+	for kid, key := range s.keys {
+		set["keys"] = append(set["keys"], map[string]string{
+			"kty": "RSA",
+			"kid": kid,
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	}
+	json.NewEncoder(w).Encode(set)
+}
+
+func (s *jwksServer) rotate(t *testing.T, kid string) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.mtx.Lock()
+	s.keys = map[string]*rsa.PrivateKey{kid: key}
+	s.mtx.Unlock()
+	return key
+}
+
+func signRS256(t *testing.T, kid string, key *rsa.PrivateKey) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.StandardClaims{Issuer: "idp", Audience: "svc"})
+	token.Header["kid"] = kid
+	s, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestJWKS(t *testing.T) {
+	s := &jwksServer{}
+	key1 := s.rotate(t, "k1")
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	jwks, err := NewJWKS(server.URL, JWKSRefreshInterval(0), JWKSMinRefreshInterval(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer jwks.Stop()
+
+	e := func(ctx context.Context, i interface{}) (interface{}, error) { return ctx, nil }
+	parser := NewParser(
+		jwks.Keyfunc, jwt.SigningMethodRS256, StandardClaimsFactory,
+		ParserIssuer("idp"), ParserAudience("svc"),
+	)(e)
+
+	ctx := context.WithValue(context.Background(), JWTTokenContextKey, signRS256(t, "k1", key1))
+	if _, err := parser(ctx, nil); err != nil {
+		t.Fatalf("k1: unexpected error: %v", err)
+	}
+
+	// The provider rotates its key; the new key ID triggers a refresh.
+	key2 := s.rotate(t, "k2")
+	ctx = context.WithValue(context.Background(), JWTTokenContextKey, signRS256(t, "k2", key2))
+	if _, err := parser(ctx, nil); err != nil {
+		t.Fatalf("k2: unexpected error: %v", err)
+	}
+	if want, have := 2, s.fetches; want != have {
+		t.Errorf("incorrect number of fetches, want %d, have %d", want, have)
+	}
+
+	// Unknown key IDs are rejected.
+	ctx = context.WithValue(context.Background(), JWTTokenContextKey, signRS256(t, "k3", key2))
+	if _, err := parser(ctx, nil); err != ErrKeyNotFound {
+		t.Errorf("k3: want %v, have %v", ErrKeyNotFound, err)
+	}
+}
+
+func TestJWKSMinRefreshInterval(t *testing.T) {
+	s := &jwksServer{}
+	s.rotate(t, "k1")
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	jwks, err := NewJWKS(server.URL, JWKSRefreshInterval(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		token := &jwt.Token{Header: map[string]interface{}{"kid": "unknown"}}
+		if _, err := jwks.Keyfunc(token); err != ErrKeyNotFound {
+			t.Errorf("want %v, have %v", ErrKeyNotFound, err)
+		}
+	}
+	if want, have := 1, s.fetches; want != have {
+		t.Errorf("incorrect number of fetches, want %d, have %d", want, have)
+	}
+
+	if _, err := jwks.Keyfunc(&jwt.Token{Header: map[string]interface{}{}}); err != ErrKIDMissing {
+		t.Errorf("want %v, have %v", ErrKIDMissing, err)
+	}
+}
+
+func TestJWKSConcurrentUnknownKIDs(t *testing.T) {
+	s := &jwksServer{}
+	s.rotate(t, "k1")
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	jwks, err := NewJWKS(server.URL, JWKSRefreshInterval(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks.mtx.Lock()
+	jwks.lastFetch = time.Time{} // stale
+	jwks.mtx.Unlock()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			jwks.Keyfunc(&jwt.Token{Header: map[string]interface{}{"kid": "unknown"}})
+		}()
+	}
+	wg.Wait()
+
+	// the initial fetch and a single refresh
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if want, have := 2, s.fetches; want != have {
+		t.Errorf("incorrect number of fetches, want %d, have %d", want, have)
+	}
+}
+
+func TestJWKSFetchTimeout(t *testing.T) {
+	s := &jwksServer{}
+	s.rotate(t, "k1")
+	hang := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mtx.Lock()
+		fetches := s.fetches
+		s.mtx.Unlock()
+		if fetches > 0 {
+			<-hang // the provider stops responding after the initial fetch
+		}
+		s.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	defer close(hang)
+
+	jwks, err := NewJWKS(server.URL, JWKSRefreshInterval(0), JWKSMinRefreshInterval(0), JWKSFetchTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		_, err := jwks.Keyfunc(&jwt.Token{Header: map[string]interface{}{"kid": "unknown"}})
+		errc <- err
+	}()
+	select {
+	case err := <-errc:
+		if err == nil || err == ErrKeyNotFound {
+			t.Errorf("want fetch error, have %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Keyfunc blocked by a hanging fetch")
+	}
+}
@@ -42,6 +42,14 @@ var (
 	// ErrUnexpectedSigningMethod denotes a token was signed with an unexpected
 	// signing method.
 	ErrUnexpectedSigningMethod = errors.New("unexpected signing method")
+
+	// ErrInvalidIssuer denotes a token's issuer claim (iss) does not match
+	// the expected issuer.
+	ErrInvalidIssuer = errors.New("JWT Token has an invalid issuer")
+
+	// ErrInvalidAudience denotes a token's audience claim (aud) does not
+	// match the expected audience.
+	ErrInvalidAudience = errors.New("JWT Token has an invalid audience")
 )
 
 // NewSigner creates a new JWT token generating middleware, specifying key ID,
@@ -82,11 +90,63 @@ func StandardClaimsFactory() jwt.Claims {
 	return &jwt.StandardClaims{}
 }
 
+// ClaimsValidator validates the claims of a token with a valid signature.
+// Returning an error rejects the token.
+type ClaimsValidator func(ctx context.Context, claims jwt.Claims) error
+
+// ParserOption sets an optional parameter for the parsing middleware.
+type ParserOption func(*parser)
+
+type parser struct {
+	validators []ClaimsValidator
+}
+
+// ParserClaimsValidator adds validators run against the claims of every token
+// after its signature and time based claims have been verified.
+func ParserClaimsValidator(validators ...ClaimsValidator) ParserOption {
+	return func(p *parser) { p.validators = append(p.validators, validators...) }
+}
+
+// ParserIssuer rejects tokens whose issuer claim (iss) is not iss with
+// ErrInvalidIssuer. The claims must implement VerifyIssuer, as
+// jwt.StandardClaims and jwt.MapClaims do.
+func ParserIssuer(iss string) ParserOption {
+	return ParserClaimsValidator(func(_ context.Context, claims jwt.Claims) error {
+		c, ok := claims.(interface {
+			VerifyIssuer(cmp string, req bool) bool
+		})
+		if !ok || !c.VerifyIssuer(iss, true) {
+			return ErrInvalidIssuer
+		}
+		return nil
+	})
+}
+
+// ParserAudience rejects tokens whose audience claim (aud) is not aud with
+// ErrInvalidAudience. The claims must implement VerifyAudience, as
+// jwt.StandardClaims and jwt.MapClaims do.
+func ParserAudience(aud string) ParserOption {
+	return ParserClaimsValidator(func(_ context.Context, claims jwt.Claims) error {
+		c, ok := claims.(interface {
+			VerifyAudience(cmp string, req bool) bool
+		})
+		if !ok || !c.VerifyAudience(aud, true) {
+			return ErrInvalidAudience
+		}
+		return nil
+	})
+}
+
 // NewParser creates a new JWT token parsing middleware, specifying a
 // jwt.Keyfunc interface, the signing method and the claims type to be used. NewParser
 // adds the resulting claims to endpoint context or returns error on invalid token.
 // Particularly useful for servers.
-func NewParser(keyFunc jwt.Keyfunc, method jwt.SigningMethod, newClaims ClaimsFactory) endpoint.Middleware {
+func NewParser(keyFunc jwt.Keyfunc, method jwt.SigningMethod, newClaims ClaimsFactory, options ...ParserOption) endpoint.Middleware {
+	p := parser{}
+	for _, option := range options {
+		option(&p)
+	}
+
 	return func(next endpoint.Endpoint) endpoint.Endpoint {
 		return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 			// tokenString is stored in the context from the transport handlers.
@@ -135,6 +195,12 @@ func NewParser(keyFunc jwt.Keyfunc, method jwt.SigningMethod, newClaims ClaimsFa
 				return nil, ErrTokenInvalid
 			}
 
+			for _, validate := range p.validators {
+				if err := validate(ctx, token.Claims); err != nil {
+					return nil, err
+				}
+			}
+
 			ctx = context.WithValue(ctx, JWTClaimsContextKey, token.Claims)
 
 			return next(ctx, request)
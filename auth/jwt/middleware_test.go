@@ -219,3 +219,27 @@ func TestIssue562(t *testing.T) {
 	}
 	wg.Wait()
 }
+
+func TestJWTParserClaimsValidation(t *testing.T) {
+	e := func(ctx context.Context, i interface{}) (interface{}, error) { return ctx, nil }
+	keys := func(token *jwt.Token) (interface{}, error) { return key, nil }
+	ctx := context.WithValue(context.Background(), JWTTokenContextKey, standardSignedKey)
+
+	for _, tc := range []struct {
+		options []ParserOption
+		want    error
+	}{
+		{nil, nil},
+		{[]ParserOption{ParserAudience("go-kit")}, nil},
+		{[]ParserOption{ParserAudience("other")}, ErrInvalidAudience},
+		{[]ParserOption{ParserIssuer("idp")}, ErrInvalidIssuer},
+		{[]ParserOption{ParserClaimsValidator(func(context.Context, jwt.Claims) error {
+			return ErrTokenInvalid
+		})}, ErrTokenInvalid},
+	} {
+		parser := NewParser(keys, method, StandardClaimsFactory, tc.options...)(e)
+		if _, have := parser(ctx, struct{}{}); tc.want != have {
+			t.Errorf("want %v, have %v", tc.want, have)
+		}
+	}
+}
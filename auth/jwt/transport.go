@@ -6,15 +6,18 @@ import (
 	stdhttp "net/http"
 	"strings"
 
+	"github.com/streadway/amqp"
 	"google.golang.org/grpc/metadata"
 
+	amqptransport "github.com/inturn/kit/transport/amqp"
 	"github.com/inturn/kit/transport/grpc"
 	"github.com/inturn/kit/transport/http"
 )
 
 const (
-	bearer       string = "bearer"
-	bearerFormat string = "Bearer %s"
+	bearer         string = "bearer"
+	bearerFormat   string = "Bearer %s"
+	amqpAuthHeader string = "Authorization"
 )
 
 // HTTPToContext moves a JWT from request header to context. Particularly
@@ -75,6 +78,45 @@ func ContextToGRPC() grpc.ClientRequestFunc {
 	}
 }
 
+// AMQPToContext moves a JWT from the AMQP message headers to context.
+// Particularly useful for subscribers.
+func AMQPToContext() amqptransport.RequestFunc {
+	return func(ctx context.Context, _ *amqp.Publishing, d *amqp.Delivery) context.Context {
+		var authHeader string
+		switch v := d.Headers[amqpAuthHeader].(type) {
+		case string:
+			authHeader = v
+		case []byte:
+			authHeader = string(v)
+		default:
+			return ctx
+		}
+
+		token, ok := extractTokenFromAuthHeader(authHeader)
+		if ok {
+			ctx = context.WithValue(ctx, JWTTokenContextKey, token)
+		}
+
+		return ctx
+	}
+}
+
+// ContextToAMQP moves a JWT from context to the AMQP message headers.
+// Particularly useful for publishers.
+func ContextToAMQP() amqptransport.RequestFunc {
+	return func(ctx context.Context, pub *amqp.Publishing, _ *amqp.Delivery) context.Context {
+		token, ok := ctx.Value(JWTTokenContextKey).(string)
+		if ok {
+			if pub.Headers == nil {
+				pub.Headers = amqp.Table{}
+			}
+			pub.Headers[amqpAuthHeader] = generateAuthHeaderFromToken(token)
+		}
+
+		return ctx
+	}
+}
+
 func extractTokenFromAuthHeader(val string) (token string, ok bool) {
 	authHeaderParts := strings.Split(val, " ")
 	if len(authHeaderParts) != 2 || strings.ToLower(authHeaderParts[0]) != bearer {
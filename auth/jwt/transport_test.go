@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"testing"
 
+	"github.com/streadway/amqp"
 	"google.golang.org/grpc/metadata"
 )
 
@@ -123,3 +124,47 @@ func TestContextToGRPC(t *testing.T) {
 		t.Errorf("JWT tokens did not match: expecting %s got %s", signedKey, token[0])
 	}
 }
+
+func TestAMQPToContext(t *testing.T) {
+	reqFunc := AMQPToContext()
+
+	// No Authorization header is passed
+	ctx := reqFunc(context.Background(), nil, &amqp.Delivery{})
+	if ctx.Value(JWTTokenContextKey) != nil {
+		t.Error("Context should not contain a JWT Token")
+	}
+
+	// Invalid Authorization header is passed
+	ctx = reqFunc(context.Background(), nil, &amqp.Delivery{Headers: amqp.Table{"Authorization": signedKey}})
+	if ctx.Value(JWTTokenContextKey) != nil {
+		t.Error("Context should not contain a JWT Token")
+	}
+
+	// Authorization header is correct
+	ctx = reqFunc(context.Background(), nil, &amqp.Delivery{Headers: amqp.Table{
+		"Authorization": []byte(generateAuthHeaderFromToken(signedKey)),
+	}})
+	token, ok := ctx.Value(JWTTokenContextKey).(string)
+	if !ok || token != signedKey {
+		t.Errorf("Context doesn't contain the expected encoded token value; expected: %s, got: %s", signedKey, token)
+	}
+}
+
+func TestContextToAMQP(t *testing.T) {
+	reqFunc := ContextToAMQP()
+
+	// No JWT Token is passed in the context
+	pub := amqp.Publishing{}
+	reqFunc(context.Background(), &pub, nil)
+	if _, ok := pub.Headers["Authorization"]; ok {
+		t.Error("authorization key should not exist in headers")
+	}
+
+	// Correct JWT Token is passed in the context
+	ctx := context.WithValue(context.Background(), JWTTokenContextKey, signedKey)
+	reqFunc(ctx, &pub, nil)
+	expected := generateAuthHeaderFromToken(signedKey)
+	if token := pub.Headers["Authorization"]; token != expected {
+		t.Errorf("Authorization header does not contain the expected JWT token; expected %s, got %s", expected, token)
+	}
+}
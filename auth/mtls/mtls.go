@@ -0,0 +1,173 @@
+package mtls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"strings"
+
+	"github.com/inturn/kit/auth/authz"
+	"github.com/inturn/kit/endpoint"
+)
+
+type contextKey string
+
+// IdentityContextKey holds the key used to store the Identity of a TLS client
+// in the context.
+const IdentityContextKey contextKey = "MTLSIdentity"
+
+// Identity is the identity a client presented with its certificate.
+type Identity struct {
+	// SPIFFEID is the spiffe:// URI SAN of the certificate, if any.
+	SPIFFEID string
+
+	// DNSNames are the DNS SANs of the certificate.
+	DNSNames []string
+
+	// CommonName is the common name of the certificate's subject.
+	CommonName string
+
+	// Certificate is the client's leaf certificate.
+	Certificate *x509.Certificate
+}
+
+// TrustDomain returns the trust domain of the SPIFFE ID, e.g. "example.org"
+// for "spiffe://example.org/orders".
+func (id Identity) TrustDomain() string {
+	rest := strings.TrimPrefix(id.SPIFFEID, "spiffe://")
+	if rest == id.SPIFFEID {
+		return ""
+	}
+	if i := strings.IndexByte(rest, '/'); i >= 0 {
+		return rest[:i]
+	}
+	return rest
+}
+
+// IdentityFromContext returns the Identity stored in ctx by the *ToContext
+// request functions of this package.
+func IdentityFromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(IdentityContextKey).(Identity)
+	return id, ok
+}
+
+// identityFromState returns the identity of the verified client certificate
+// of a TLS connection.
+func identityFromState(state *tls.ConnectionState) (Identity, bool) {
+	// VerifiedChains is only set if the server verified the client
+	// certificate, i.e. with tls.VerifyClientCertIfGiven or
+	// tls.RequireAndVerifyClientCert.
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return Identity{}, false
+	}
+	cert := state.VerifiedChains[0][0]
+
+	id := Identity{
+		DNSNames:    cert.DNSNames,
+		CommonName:  cert.Subject.CommonName,
+		Certificate: cert,
+	}
+	for _, uri := range cert.URIs {
+		if uri.Scheme == "spiffe" {
+			id.SPIFFEID = uri.String()
+			break
+		}
+	}
+	return id, true
+}
+
+// Matcher reports whether an identity is allowed.
+type Matcher func(id Identity) bool
+
+// AllowSPIFFEIDs returns a Matcher allowing identities with one of the given
+// SPIFFE IDs.
+func AllowSPIFFEIDs(ids ...string) Matcher {
+	return func(id Identity) bool {
+		return id.SPIFFEID != "" && contains(ids, id.SPIFFEID)
+	}
+}
+
+// AllowTrustDomains returns a Matcher allowing identities whose SPIFFE ID
+// belongs to one of the given trust domains.
+func AllowTrustDomains(domains ...string) Matcher {
+	return func(id Identity) bool {
+		td := id.TrustDomain()
+		return td != "" && contains(domains, td)
+	}
+}
+
+// AllowDNSNames returns a Matcher allowing identities with at least one of
+// the given DNS SANs.
+func AllowDNSNames(names ...string) Matcher {
+	return func(id Identity) bool {
+		for _, name := range id.DNSNames {
+			if contains(names, name) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// AnyOf returns a Matcher allowing identities allowed by any of matchers.
+func AnyOf(matchers ...Matcher) Matcher {
+	return func(id Identity) bool {
+		for _, m := range matchers {
+			if m(id) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// NewAuthorizer creates a new middleware allowing only requests from clients
+// whose identity, put into the context by the *ToContext request functions,
+// is allowed by match. Requests without identity fail with
+// authz.ErrUnauthenticated, denied ones with an authz.ForbiddenError.
+// Particularly useful for servers.
+func NewAuthorizer(match Matcher) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+			id, ok := IdentityFromContext(ctx)
+			if !ok {
+				return nil, authz.ErrUnauthenticated
+			}
+			if !match(id) {
+				return nil, authz.ForbiddenError{Subject: id.name()}
+			}
+			return next(ctx, request)
+		}
+	}
+}
+
+// Subject returns an authz.SubjectFunc using the client identity as subject,
+// with its SPIFFE ID, first DNS name or common name as ID, in that order.
+func Subject() authz.SubjectFunc {
+	return func(ctx context.Context) (authz.Subject, bool) {
+		id, ok := IdentityFromContext(ctx)
+		if !ok {
+			return authz.Subject{}, false
+		}
+		return authz.Subject{ID: id.name()}, true
+	}
+}
+
+func (id Identity) name() string {
+	switch {
+	case id.SPIFFEID != "":
+		return id.SPIFFEID
+	case len(id.DNSNames) > 0:
+		return id.DNSNames[0]
+	}
+	return id.CommonName
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package mtls
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/inturn/kit/auth/authz"
+)
+
+func newCertificate(t *testing.T, template *x509.Certificate, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+// newClientCertificate returns a CA and a client certificate signed by it.
+func newClientCertificate(t *testing.T, spiffeID string, dnsNames ...string) (*x509.Certificate, tls.Certificate) {
+	ca, caKey := newCertificate(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "test CA"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil, nil)
+
+	template := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "client"},
+		DNSNames:    dnsNames,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+	}
+	if spiffeID != "" {
+		u, _ := url.Parse(spiffeID)
+		template.URIs = []*url.URL{u}
+	}
+	cert, key := newCertificate(t, template, ca, caKey)
+
+	return ca, tls.Certificate{Certificate: [][]byte{cert.Raw}, PrivateKey: key, Leaf: cert}
+}
+
+func TestIdentityFromState(t *testing.T) {
+	_, client := newClientCertificate(t, "spiffe://example.org/orders", "orders.internal")
+
+	if _, ok := identityFromState(&tls.ConnectionState{PeerCertificates: []*x509.Certificate{client.Leaf}}); ok {
+		t.Error("want no identity for unverified certificates")
+	}
+
+	id, ok := identityFromState(&tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{client.Leaf}}})
+	if !ok {
+		t.Fatal("want identity")
+	}
+	if want, have := "spiffe://example.org/orders", id.SPIFFEID; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := "example.org", id.TrustDomain(); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := "client", id.CommonName; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
+
+func TestMatchers(t *testing.T) {
+	id := Identity{SPIFFEID: "spiffe://example.org/orders", DNSNames: []string{"orders.internal"}}
+
+	for _, tt := range []struct {
+		name  string
+		match Matcher
+		want  bool
+	}{
+		{"SPIFFE ID", AllowSPIFFEIDs("spiffe://example.org/orders"), true},
+		{"other SPIFFE ID", AllowSPIFFEIDs("spiffe://example.org/billing"), false},
+		{"trust domain", AllowTrustDomains("example.org"), true},
+		{"other trust domain", AllowTrustDomains("example.com"), false},
+		{"DNS name", AllowDNSNames("orders.internal"), true},
+		{"other DNS name", AllowDNSNames("billing.internal"), false},
+		{"any of", AnyOf(AllowDNSNames("billing.internal"), AllowTrustDomains("example.org")), true},
+	} {
+		if have := tt.match(id); tt.want != have {
+			t.Errorf("%s: want %v, have %v", tt.name, tt.want, have)
+		}
+	}
+
+	if AllowTrustDomains("")(Identity{}) {
+		t.Error("want identity without SPIFFE ID to not match an empty trust domain")
+	}
+}
+
+func TestNewAuthorizer(t *testing.T) {
+	e := func(ctx context.Context, i interface{}) (interface{}, error) { return true, nil }
+	authorizer := NewAuthorizer(AllowSPIFFEIDs("spiffe://example.org/orders"))(e)
+
+	if _, err := authorizer(context.Background(), nil); err != authz.ErrUnauthenticated {
+		t.Errorf("want %v, have %v", authz.ErrUnauthenticated, err)
+	}
+
+	ctx := context.WithValue(context.Background(), IdentityContextKey, Identity{SPIFFEID: "spiffe://example.org/billing"})
+	_, err := authorizer(ctx, nil)
+	if want, have := (authz.ForbiddenError{Subject: "spiffe://example.org/billing"}), err; want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+
+	ctx = context.WithValue(context.Background(), IdentityContextKey, Identity{SPIFFEID: "spiffe://example.org/orders"})
+	if _, err := authorizer(ctx, nil); err != nil {
+		t.Errorf("want no error, have %v", err)
+	}
+
+	if s, ok := Subject()(ctx); !ok || s.ID != "spiffe://example.org/orders" {
+		t.Errorf("unexpected subject %+v", s)
+	}
+}
@@ -0,0 +1,47 @@
+package mtls
+
+import (
+	"context"
+	stdhttp "net/http"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/inturn/kit/transport/grpc"
+	"github.com/inturn/kit/transport/http"
+)
+
+// HTTPToContext moves the identity of the verified client certificate of the
+// TLS connection to context. The server must be configured to verify client
+// certificates, otherwise no identity is found. Particularly useful for
+// servers.
+func HTTPToContext() http.RequestFunc {
+	return func(ctx context.Context, r *stdhttp.Request) context.Context {
+		if id, ok := identityFromState(r.TLS); ok {
+			ctx = context.WithValue(ctx, IdentityContextKey, id)
+		}
+		return ctx
+	}
+}
+
+// GRPCToContext moves the identity of the verified client certificate of the
+// connection to context. The server must use TLS credentials verifying client
+// certificates, otherwise no identity is found. Particularly useful for
+// servers.
+func GRPCToContext() grpc.ServerRequestFunc {
+	return func(ctx context.Context, _ metadata.MD) context.Context {
+		p, ok := peer.FromContext(ctx)
+		if !ok {
+			return ctx
+		}
+		info, ok := p.AuthInfo.(credentials.TLSInfo)
+		if !ok {
+			return ctx
+		}
+		if id, ok := identityFromState(&info.State); ok {
+			ctx = context.WithValue(ctx, IdentityContextKey, id)
+		}
+		return ctx
+	}
+}
@@ -0,0 +1,73 @@
+package mtls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/inturn/kit/endpoint"
+	httptransport "github.com/inturn/kit/transport/http"
+)
+
+func TestHTTPToContext(t *testing.T) {
+	ca, client := newClientCertificate(t, "spiffe://example.org/orders")
+
+	var id Identity
+	server := httptest.NewUnstartedServer(httptransport.NewServer(
+		func(ctx context.Context, request interface{}) (interface{}, error) {
+			id, _ = IdentityFromContext(ctx)
+			return struct{}{}, nil
+		},
+		func(context.Context, *http.Request) (interface{}, error) { return struct{}{}, nil },
+		func(context.Context, http.ResponseWriter, interface{}) error { return nil },
+		httptransport.ServerBefore(HTTPToContext()),
+	))
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: pool}
+	server.StartTLS()
+	defer server.Close()
+
+	transport := server.Client().Transport.(*http.Transport)
+	transport.TLSClientConfig.Certificates = []tls.Certificate{client}
+
+	resp, err := server.Client().Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if want, have := "spiffe://example.org/orders", id.SPIFFEID; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
+
+func TestGRPCToContext(t *testing.T) {
+	_, client := newClientCertificate(t, "", "orders.internal")
+
+	ctx := GRPCToContext()(context.Background(), metadata.MD{})
+	if _, ok := IdentityFromContext(ctx); ok {
+		t.Error("want no identity without peer")
+	}
+
+	ctx = peer.NewContext(context.Background(), &peer.Peer{
+		Addr: &net.TCPAddr{},
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+			VerifiedChains: [][]*x509.Certificate{{client.Leaf}},
+		}},
+	})
+	ctx = GRPCToContext()(ctx, metadata.MD{})
+
+	_, err := NewAuthorizer(AllowDNSNames("orders.internal"))(endpoint.Nop)(ctx, nil)
+	if err != nil {
+		t.Errorf("want no error, have %v", err)
+	}
+}
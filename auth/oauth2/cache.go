@@ -0,0 +1,136 @@
+package oauth2
+
+import (
+	"context"
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// IntrospectionCache caches the results of an Introspector by the SHA-256
+// hash of the token. Results of active tokens are cached until the token
+// expires, but no longer than the configured TTL, so revocations take effect
+// after at most that TTL.
+type IntrospectionCache struct {
+	next        Introspector
+	ttl         time.Duration
+	negativeTTL time.Duration
+	size        int
+	now         func() time.Time
+	group       singleflight.Group
+
+	mtx     sync.Mutex
+	entries map[[sha256.Size]byte]introspectionEntry
+}
+
+type introspectionEntry struct {
+	result  Introspection
+	expires time.Time
+}
+
+// IntrospectionCacheOption sets an optional parameter for
+// IntrospectionCaches.
+type IntrospectionCacheOption func(*IntrospectionCache)
+
+// IntrospectionCacheTTL sets the maximum duration the result of an active
+// token is cached. By default, results are cached for up to 5 minutes.
+func IntrospectionCacheTTL(d time.Duration) IntrospectionCacheOption {
+	return func(c *IntrospectionCache) { c.ttl = d }
+}
+
+// IntrospectionCacheNegativeTTL sets the duration the result of an inactive
+// token is cached. Zero disables caching of inactive tokens. By default,
+// results are cached for 10 seconds.
+func IntrospectionCacheNegativeTTL(d time.Duration) IntrospectionCacheOption {
+	return func(c *IntrospectionCache) { c.negativeTTL = d }
+}
+
+// IntrospectionCacheSize sets the maximum number of cached results. When the
+// cache is full, expired results are dropped and, if none are, new results
+// are not cached. By default, up to 10000 results are cached.
+func IntrospectionCacheSize(n int) IntrospectionCacheOption {
+	return func(c *IntrospectionCache) { c.size = n }
+}
+
+// NewIntrospectionCache returns an IntrospectionCache in front of next.
+// Concurrent introspections of the same token are collapsed into one.
+func NewIntrospectionCache(next Introspector, options ...IntrospectionCacheOption) *IntrospectionCache {
+	c := &IntrospectionCache{
+		next:        next,
+		ttl:         5 * time.Minute,
+		negativeTTL: 10 * time.Second,
+		size:        10000,
+		now:         time.Now,
+		entries:     map[[sha256.Size]byte]introspectionEntry{},
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// Introspect implements Introspector. Errors of the underlying Introspector
+// are not cached.
+func (c *IntrospectionCache) Introspect(ctx context.Context, token string) (Introspection, error) {
+	hash := sha256.Sum256([]byte(token))
+
+	c.mtx.Lock()
+	entry, ok := c.entries[hash]
+	c.mtx.Unlock()
+	if ok && c.now().Before(entry.expires) {
+		return entry.result, nil
+	}
+
+	v, err, _ := c.group.Do(string(hash[:]), func() (interface{}, error) {
+		result, err := c.next.Introspect(ctx, token)
+		if err != nil {
+			return nil, err
+		}
+		c.store(hash, result)
+		return result, nil
+	})
+	if err != nil {
+		return Introspection{}, err
+	}
+	return v.(Introspection), nil
+}
+
+// Invalidate drops the cached result of token, e.g. after it was revoked.
+func (c *IntrospectionCache) Invalidate(token string) {
+	hash := sha256.Sum256([]byte(token))
+	c.mtx.Lock()
+	delete(c.entries, hash)
+	c.mtx.Unlock()
+}
+
+func (c *IntrospectionCache) store(hash [sha256.Size]byte, result Introspection) {
+	now := c.now()
+	ttl := c.negativeTTL
+	if result.Active {
+		ttl = c.ttl
+		if result.ExpiresAt > 0 {
+			if untilExpiry := time.Unix(result.ExpiresAt, 0).Sub(now); untilExpiry < ttl {
+				ttl = untilExpiry
+			}
+		}
+	}
+	if ttl <= 0 {
+		return
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if len(c.entries) >= c.size {
+		for h, e := range c.entries {
+			if !now.Before(e.expires) {
+				delete(c.entries, h)
+			}
+		}
+		if len(c.entries) >= c.size {
+			return
+		}
+	}
+	c.entries[hash] = introspectionEntry{result: result, expires: now.Add(ttl)}
+}
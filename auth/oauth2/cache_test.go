@@ -0,0 +1,128 @@
+package oauth2
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestIntrospectionCache(t *testing.T) {
+	now := time.Unix(1000000000, 0)
+	var (
+		calls int32
+		fail  bool
+	)
+	next := IntrospectorFunc(func(_ context.Context, token string) (Introspection, error) {
+		atomic.AddInt32(&calls, 1)
+		if fail {
+			return Introspection{}, errors.New("introspection endpoint unavailable")
+		}
+		switch token {
+		case "long":
+			return Introspection{Active: true, ExpiresAt: now.Add(time.Hour).Unix()}, nil
+		case "short":
+			return Introspection{Active: true, ExpiresAt: now.Add(time.Minute).Unix()}, nil
+		}
+		return Introspection{}, nil
+	})
+
+	cache := NewIntrospectionCache(next, IntrospectionCacheTTL(5*time.Minute), IntrospectionCacheNegativeTTL(10*time.Second))
+	cache.now = func() time.Time { return now }
+
+	introspect := func(token string) Introspection {
+		result, err := cache.Introspect(context.Background(), token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+	assertCalls := func(want int32) {
+		t.Helper()
+		if have := atomic.LoadInt32(&calls); want != have {
+			t.Errorf("want %d introspections, have %d", want, have)
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		introspect("long")
+		introspect("short")
+		introspect("revoked")
+	}
+	assertCalls(3)
+
+	// the negative result expires first
+	now = now.Add(10 * time.Second)
+	introspect("revoked")
+	assertCalls(4)
+
+	// results of active tokens expire with the token
+	now = now.Add(50 * time.Second)
+	introspect("long")
+	assertCalls(4)
+	introspect("short")
+	assertCalls(5)
+
+	// but no later than the TTL
+	now = now.Add(4 * time.Minute)
+	introspect("long")
+	assertCalls(6)
+
+	// errors are not cached
+	fail = true
+	for i := 0; i < 2; i++ {
+		if _, err := cache.Introspect(context.Background(), "other"); err == nil {
+			t.Fatal("want error, have nil")
+		}
+	}
+	assertCalls(8)
+
+	fail = false
+	cache.Invalidate("long")
+	introspect("long")
+	assertCalls(9)
+}
+
+func TestIntrospectionCacheSize(t *testing.T) {
+	next := IntrospectorFunc(func(context.Context, string) (Introspection, error) {
+		return Introspection{Active: true}, nil
+	})
+	cache := NewIntrospectionCache(next, IntrospectionCacheSize(2))
+	for _, token := range []string{"a", "b", "c"} {
+		cache.Introspect(context.Background(), token)
+	}
+	if want, have := 2, len(cache.entries); want != have {
+		t.Errorf("want %d entries, have %d", want, have)
+	}
+}
+
+func TestIntrospectionCacheSingleflight(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	next := IntrospectorFunc(func(context.Context, string) (Introspection, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return Introspection{Active: true}, nil
+	})
+	cache := NewIntrospectionCache(next)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := cache.Introspect(context.Background(), "token"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if want, have := int32(1), atomic.LoadInt32(&calls); want != have {
+		t.Errorf("want %d introspections, have %d", want, have)
+	}
+}
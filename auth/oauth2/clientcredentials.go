@@ -0,0 +1,136 @@
+package oauth2
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/inturn/kit/endpoint"
+)
+
+type contextKey string
+
+// TokenContextKey holds the key used to store an *oauth2.Token in the
+// context.
+const TokenContextKey contextKey = "OAuth2Token"
+
+// TokenSource obtains tokens with the OAuth2 client credentials grant and
+// caches them until shortly before they expire. Concurrent requests for a new
+// token are collapsed into a single call to the token endpoint.
+type TokenSource struct {
+	fetch  func(ctx context.Context) (*oauth2.Token, error)
+	leeway time.Duration
+	now    func() time.Time
+	group  singleflight.Group
+
+	mtx   sync.RWMutex
+	token *oauth2.Token
+}
+
+// TokenSourceOption sets an optional parameter for TokenSources.
+type TokenSourceOption func(*TokenSource)
+
+// TokenSourceRefreshLeeway sets how long before its expiry a cached token is
+// replaced. Refreshing early keeps tokens from expiring in flight. By default,
+// tokens are refreshed a minute before they expire.
+func TokenSourceRefreshLeeway(d time.Duration) TokenSourceOption {
+	return func(s *TokenSource) { s.leeway = d }
+}
+
+// TokenSourceHTTPClient sets the HTTP client used to call the token endpoint.
+// By default, http.DefaultClient is used.
+func TokenSourceHTTPClient(client *http.Client) TokenSourceOption {
+	return func(s *TokenSource) {
+		fetch := s.fetch
+		s.fetch = func(ctx context.Context) (*oauth2.Token, error) {
+			return fetch(context.WithValue(ctx, oauth2.HTTPClient, client))
+		}
+	}
+}
+
+// NewTokenSource returns a TokenSource obtaining tokens from the token
+// endpoint described by config.
+func NewTokenSource(config clientcredentials.Config, options ...TokenSourceOption) *TokenSource {
+	s := &TokenSource{
+		fetch:  config.Token,
+		leeway: time.Minute,
+		now:    time.Now,
+	}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+// Token returns the cached token, or obtains a new one if the cached token is
+// about to expire. If obtaining a new token fails while the cached token has
+// not expired yet, the cached token is returned. Callers waiting on the same
+// refresh share its result, which is bound to the context of the first
+// caller.
+func (s *TokenSource) Token(ctx context.Context) (*oauth2.Token, error) {
+	s.mtx.RLock()
+	token := s.token
+	s.mtx.RUnlock()
+	if s.fresh(token) {
+		return token, nil
+	}
+
+	v, err, _ := s.group.Do("token", func() (interface{}, error) {
+		token, err := s.fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+		s.mtx.Lock()
+		s.token = token
+		s.mtx.Unlock()
+		return token, nil
+	})
+	if err != nil {
+		if token != nil && s.valid(token) {
+			return token, nil
+		}
+		return nil, err
+	}
+	return v.(*oauth2.Token), nil
+}
+
+// Invalidate drops the cached token, e.g. after a server rejected it, so the
+// next call to Token obtains a new one.
+func (s *TokenSource) Invalidate() {
+	s.mtx.Lock()
+	s.token = nil
+	s.mtx.Unlock()
+}
+
+func (s *TokenSource) fresh(token *oauth2.Token) bool {
+	if token == nil || token.AccessToken == "" {
+		return false
+	}
+	return token.Expiry.IsZero() || s.now().Add(s.leeway).Before(token.Expiry)
+}
+
+func (s *TokenSource) valid(token *oauth2.Token) bool {
+	return token.Expiry.IsZero() || s.now().Before(token.Expiry)
+}
+
+// NewTokenFetcher creates a new middleware adding a token obtained from source
+// to the endpoint context, where the ContextTo* request functions of this
+// package pick it up. Failing to obtain a token fails the request before it
+// is sent. Particularly useful for clients.
+func NewTokenFetcher(source *TokenSource) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+			token, err := source.Token(ctx)
+			if err != nil {
+				return nil, err
+			}
+			ctx = context.WithValue(ctx, TokenContextKey, token)
+			return next(ctx, request)
+		}
+	}
+}
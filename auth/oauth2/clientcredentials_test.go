@@ -0,0 +1,141 @@
+package oauth2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+func newTokenServer(t *testing.T, expiresIn int, fail *int32) (*httptest.Server, *int32) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		if fail != nil && atomic.LoadInt32(fail) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if want, have := "client_credentials", r.FormValue("grant_type"); want != have {
+			t.Errorf("want grant type %q, have %q", want, have)
+		}
+		time.Sleep(10 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer","expires_in":%d}`, n, expiresIn)
+	}))
+	return srv, &calls
+}
+
+func TestTokenSourceSingleflight(t *testing.T) {
+	srv, calls := newTokenServer(t, 3600, nil)
+	defer srv.Close()
+
+	source := NewTokenSource(clientcredentials.Config{
+		ClientID:     "id",
+		ClientSecret: "secret",
+		TokenURL:     srv.URL,
+	}, TokenSourceHTTPClient(srv.Client()))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, err := source.Token(context.Background())
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if want, have := "token-1", token.AccessToken; want != have {
+				t.Errorf("want %q, have %q", want, have)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if want, have := int32(1), atomic.LoadInt32(calls); want != have {
+		t.Errorf("want %d token requests, have %d", want, have)
+	}
+}
+
+func TestTokenSourceRefresh(t *testing.T) {
+	var fail int32
+	srv, calls := newTokenServer(t, 600, &fail)
+	defer srv.Close()
+
+	now := time.Now()
+	source := NewTokenSource(clientcredentials.Config{TokenURL: srv.URL}, TokenSourceRefreshLeeway(time.Minute))
+	source.now = func() time.Time { return now }
+
+	token := func() string {
+		token, err := source.Token(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token.AccessToken
+	}
+
+	if want, have := "token-1", token(); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+
+	// within the leeway the token is refreshed
+	now = now.Add(9*time.Minute + time.Second)
+	if want, have := "token-2", token(); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+
+	// a failed refresh falls back to the unexpired token
+	atomic.StoreInt32(&fail, 1)
+	now = now.Add(30 * time.Second)
+	if want, have := "token-2", token(); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+
+	// but not to an expired one
+	now = now.Add(time.Minute)
+	if _, err := source.Token(context.Background()); err == nil {
+		t.Error("want error, have nil")
+	}
+
+	atomic.StoreInt32(&fail, 0)
+	source.Invalidate()
+	if want, have := "token-5", token(); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := int32(5), atomic.LoadInt32(calls); want != have {
+		t.Errorf("want %d token requests, have %d", want, have)
+	}
+}
+
+func TestNewTokenFetcher(t *testing.T) {
+	srv, _ := newTokenServer(t, 3600, nil)
+	defer srv.Close()
+
+	source := NewTokenSource(clientcredentials.Config{TokenURL: srv.URL})
+	e := func(ctx context.Context, i interface{}) (interface{}, error) { return ctx, nil }
+
+	resp, err := NewTokenFetcher(source)(e)(context.Background(), struct{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, ok := resp.(context.Context).Value(TokenContextKey).(*oauth2.Token)
+	if !ok {
+		t.Fatal("want token in context")
+	}
+	if want, have := "token-1", token.AccessToken; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+
+	srv.Close()
+	source.Invalidate()
+	if _, err := NewTokenFetcher(source)(e)(context.Background(), struct{}{}); err == nil {
+		t.Error("want error, have nil")
+	}
+}
@@ -0,0 +1,169 @@
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/inturn/kit/auth/authz"
+	"github.com/inturn/kit/endpoint"
+)
+
+const (
+	// AccessTokenContextKey holds the key used to store the access token of
+	// an incoming request in the context.
+	AccessTokenContextKey contextKey = "OAuth2AccessToken"
+
+	// IntrospectionContextKey holds the key used to store the Introspection
+	// of a validated access token in the context.
+	IntrospectionContextKey contextKey = "OAuth2Introspection"
+)
+
+var (
+	// ErrTokenContextMissing denotes an access token was not passed into the
+	// validating middleware's context.
+	ErrTokenContextMissing = errors.New("access token was not passed through the context")
+
+	// ErrTokenInactive denotes an access token is not active, i.e. it is
+	// expired, revoked or unknown to the authorization server.
+	ErrTokenInactive = errors.New("access token is not active")
+)
+
+// Introspection is the information about an access token returned by an
+// OAuth2 token introspection endpoint as defined in RFC 7662.
+type Introspection struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Username  string `json:"username,omitempty"`
+	Subject   string `json:"sub,omitempty"`
+	Issuer    string `json:"iss,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+}
+
+// Introspector validates access tokens.
+type Introspector interface {
+	Introspect(ctx context.Context, token string) (Introspection, error)
+}
+
+// IntrospectorFunc is an adapter to allow the use of ordinary functions as
+// Introspectors.
+type IntrospectorFunc func(ctx context.Context, token string) (Introspection, error)
+
+// Introspect implements Introspector.
+func (f IntrospectorFunc) Introspect(ctx context.Context, token string) (Introspection, error) {
+	return f(ctx, token)
+}
+
+type remoteIntrospector struct {
+	url          string
+	clientID     string
+	clientSecret string
+	client       *http.Client
+}
+
+// RemoteIntrospectorOption sets an optional parameter for remote
+// Introspectors.
+type RemoteIntrospectorOption func(*remoteIntrospector)
+
+// RemoteIntrospectorHTTPClient sets the HTTP client used to call the
+// introspection endpoint. By default, http.DefaultClient is used.
+func RemoteIntrospectorHTTPClient(client *http.Client) RemoteIntrospectorOption {
+	return func(i *remoteIntrospector) { i.client = client }
+}
+
+// NewRemoteIntrospector returns an Introspector calling the RFC 7662 token
+// introspection endpoint at url, authenticating with the given client
+// credentials. Wrap it with NewIntrospectionCache to avoid a call per
+// request.
+func NewRemoteIntrospector(url, clientID, clientSecret string, options ...RemoteIntrospectorOption) Introspector {
+	i := &remoteIntrospector{
+		url:          url,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		client:       http.DefaultClient,
+	}
+	for _, option := range options {
+		option(i)
+	}
+	return i
+}
+
+func (i *remoteIntrospector) Introspect(ctx context.Context, token string) (Introspection, error) {
+	form := url.Values{"token": {token}, "token_type_hint": {"access_token"}}
+	req, err := http.NewRequest("POST", i.url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Introspection{}, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(i.clientID), url.QueryEscape(i.clientSecret))
+
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return Introspection{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Introspection{}, fmt.Errorf("token introspection failed: %s", resp.Status)
+	}
+
+	var result Introspection
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return Introspection{}, err
+	}
+	return result, nil
+}
+
+// NewValidator creates a new middleware validating the access token in the
+// context with introspector. Inactive tokens fail with ErrTokenInactive;
+// the Introspection of active ones is added to the endpoint context.
+// Particularly useful for servers.
+func NewValidator(introspector Introspector) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+			token, ok := ctx.Value(AccessTokenContextKey).(string)
+			if !ok || token == "" {
+				return nil, ErrTokenContextMissing
+			}
+
+			result, err := introspector.Introspect(ctx, token)
+			if err != nil {
+				return nil, err
+			}
+			if !result.Active {
+				return nil, ErrTokenInactive
+			}
+
+			ctx = context.WithValue(ctx, IntrospectionContextKey, result)
+			return next(ctx, request)
+		}
+	}
+}
+
+// Subject returns an authz.SubjectFunc reading the Introspection stored in the
+// context by NewValidator. The subject ID is the token's subject, or its
+// client ID for tokens issued to clients.
+func Subject() authz.SubjectFunc {
+	return func(ctx context.Context) (authz.Subject, bool) {
+		result, ok := ctx.Value(IntrospectionContextKey).(Introspection)
+		if !ok {
+			return authz.Subject{}, false
+		}
+		id := result.Subject
+		if id == "" {
+			id = result.ClientID
+		}
+		return authz.Subject{
+			ID:     id,
+			Scopes: strings.Fields(result.Scope),
+			Issuer: result.Issuer,
+		}, true
+	}
+}
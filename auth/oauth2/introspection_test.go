@@ -0,0 +1,79 @@
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/inturn/kit/auth/authz"
+)
+
+func TestRemoteIntrospector(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id, secret, _ := r.BasicAuth(); id != "rs" || secret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		result := Introspection{}
+		if r.FormValue("token") == "valid" {
+			result = Introspection{Active: true, Scope: "orders:read", ClientID: "web", Subject: "alice", ExpiresAt: 2000000000}
+		}
+		json.NewEncoder(w).Encode(result)
+	}))
+	defer srv.Close()
+
+	introspector := NewRemoteIntrospector(srv.URL, "rs", "secret", RemoteIntrospectorHTTPClient(srv.Client()))
+
+	result, err := introspector.Introspect(context.Background(), "valid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := (Introspection{Active: true, Scope: "orders:read", ClientID: "web", Subject: "alice", ExpiresAt: 2000000000}), result; want != have {
+		t.Errorf("want %+v, have %+v", want, have)
+	}
+
+	result, err = introspector.Introspect(context.Background(), "revoked")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Active {
+		t.Error("want inactive token")
+	}
+
+	if _, err := NewRemoteIntrospector(srv.URL, "rs", "wrong").Introspect(context.Background(), "valid"); err == nil {
+		t.Error("want error, have nil")
+	}
+}
+
+func TestNewValidator(t *testing.T) {
+	introspector := IntrospectorFunc(func(_ context.Context, token string) (Introspection, error) {
+		return Introspection{Active: token == "valid", Scope: "a b", ClientID: "web"}, nil
+	})
+	e := func(ctx context.Context, i interface{}) (interface{}, error) { return ctx, nil }
+	validator := NewValidator(introspector)(e)
+
+	if _, err := validator(context.Background(), nil); err != ErrTokenContextMissing {
+		t.Errorf("want %v, have %v", ErrTokenContextMissing, err)
+	}
+
+	ctx := context.WithValue(context.Background(), AccessTokenContextKey, "revoked")
+	if _, err := validator(ctx, nil); err != ErrTokenInactive {
+		t.Errorf("want %v, have %v", ErrTokenInactive, err)
+	}
+
+	ctx = context.WithValue(context.Background(), AccessTokenContextKey, "valid")
+	resp, err := validator(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, ok := Subject()(resp.(context.Context))
+	if !ok {
+		t.Fatal("want subject")
+	}
+	if want, have := (authz.Subject{ID: "web", Scopes: []string{"a", "b"}}), subject; !reflect.DeepEqual(want, have) {
+		t.Errorf("want %+v, have %+v", want, have)
+	}
+}
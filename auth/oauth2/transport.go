@@ -0,0 +1,111 @@
+package oauth2
+
+import (
+	"context"
+	stdhttp "net/http"
+	"strings"
+
+	"github.com/streadway/amqp"
+	"golang.org/x/oauth2"
+	"google.golang.org/grpc/metadata"
+
+	amqptransport "github.com/inturn/kit/transport/amqp"
+	"github.com/inturn/kit/transport/grpc"
+	"github.com/inturn/kit/transport/http"
+)
+
+const (
+	bearer         string = "bearer"
+	amqpAuthHeader string = "Authorization"
+)
+
+// HTTPToContext moves a bearer access token from the Authorization request
+// header to context. Particularly useful for servers.
+func HTTPToContext() http.RequestFunc {
+	return func(ctx context.Context, r *stdhttp.Request) context.Context {
+		if token, ok := extractBearerToken(r.Header.Get("Authorization")); ok {
+			ctx = context.WithValue(ctx, AccessTokenContextKey, token)
+		}
+		return ctx
+	}
+}
+
+// GRPCToContext moves a bearer access token from the authorization grpc
+// metadata to context. Particularly useful for servers.
+func GRPCToContext() grpc.ServerRequestFunc {
+	return func(ctx context.Context, md metadata.MD) context.Context {
+		if v := md["authorization"]; len(v) > 0 {
+			if token, ok := extractBearerToken(v[0]); ok {
+				ctx = context.WithValue(ctx, AccessTokenContextKey, token)
+			}
+		}
+		return ctx
+	}
+}
+
+// AMQPToContext moves a bearer access token from the Authorization AMQP
+// message header to context. Particularly useful for subscribers.
+func AMQPToContext() amqptransport.RequestFunc {
+	return func(ctx context.Context, _ *amqp.Publishing, d *amqp.Delivery) context.Context {
+		var header string
+		switch v := d.Headers[amqpAuthHeader].(type) {
+		case string:
+			header = v
+		case []byte:
+			header = string(v)
+		}
+		if token, ok := extractBearerToken(header); ok {
+			ctx = context.WithValue(ctx, AccessTokenContextKey, token)
+		}
+		return ctx
+	}
+}
+
+// ContextToHTTP moves a token from context to the Authorization request
+// header. Particularly useful for clients.
+func ContextToHTTP() http.RequestFunc {
+	return func(ctx context.Context, r *stdhttp.Request) context.Context {
+		if token, ok := ctx.Value(TokenContextKey).(*oauth2.Token); ok {
+			token.SetAuthHeader(r)
+		}
+		return ctx
+	}
+}
+
+// ContextToGRPC moves a token from context to the authorization grpc
+// metadata. Particularly useful for clients.
+func ContextToGRPC() grpc.ClientRequestFunc {
+	return func(ctx context.Context, md *metadata.MD) context.Context {
+		if token, ok := ctx.Value(TokenContextKey).(*oauth2.Token); ok {
+			// capital "Key" is illegal in HTTP/2.
+			(*md)["authorization"] = []string{authHeader(token)}
+		}
+		return ctx
+	}
+}
+
+// ContextToAMQP moves a token from context to the Authorization AMQP message
+// header. Particularly useful for publishers.
+func ContextToAMQP() amqptransport.RequestFunc {
+	return func(ctx context.Context, pub *amqp.Publishing, _ *amqp.Delivery) context.Context {
+		if token, ok := ctx.Value(TokenContextKey).(*oauth2.Token); ok {
+			if pub.Headers == nil {
+				pub.Headers = amqp.Table{}
+			}
+			pub.Headers[amqpAuthHeader] = authHeader(token)
+		}
+		return ctx
+	}
+}
+
+func authHeader(token *oauth2.Token) string {
+	return token.Type() + " " + token.AccessToken
+}
+
+func extractBearerToken(header string) (string, bool) {
+	parts := strings.Split(header, " ")
+	if len(parts) != 2 || strings.ToLower(parts[0]) != bearer || parts[1] == "" {
+		return "", false
+	}
+	return parts[1], true
+}
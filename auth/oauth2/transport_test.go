@@ -0,0 +1,63 @@
+package oauth2
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/streadway/amqp"
+	"golang.org/x/oauth2"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestContextToTransports(t *testing.T) {
+	ctx := context.WithValue(context.Background(), TokenContextKey, &oauth2.Token{AccessToken: "abc", TokenType: "bearer"})
+	want := "Bearer abc"
+
+	r, _ := http.NewRequest("GET", "/", nil)
+	ContextToHTTP()(ctx, r)
+	if have := r.Header.Get("Authorization"); want != have {
+		t.Errorf("HTTP: want %q, have %q", want, have)
+	}
+
+	md := metadata.MD{}
+	ContextToGRPC()(ctx, &md)
+	if have := md["authorization"][0]; want != have {
+		t.Errorf("gRPC: want %q, have %q", want, have)
+	}
+
+	pub := amqp.Publishing{}
+	ContextToAMQP()(ctx, &pub, nil)
+	if have := pub.Headers[amqpAuthHeader]; want != have {
+		t.Errorf("AMQP: want %q, have %q", want, have)
+	}
+
+	r, _ = http.NewRequest("GET", "/", nil)
+	ContextToHTTP()(context.Background(), r)
+	if have := r.Header.Get("Authorization"); have != "" {
+		t.Errorf("HTTP: want no header, have %q", have)
+	}
+}
+
+func TestToContextTransports(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/", nil)
+	r.Header.Set("Authorization", "Bearer abc")
+	if want, have := "abc", HTTPToContext()(context.Background(), r).Value(AccessTokenContextKey); want != have {
+		t.Errorf("HTTP: want %v, have %v", want, have)
+	}
+
+	md := metadata.MD{"authorization": []string{"bearer abc"}}
+	if want, have := "abc", GRPCToContext()(context.Background(), md).Value(AccessTokenContextKey); want != have {
+		t.Errorf("gRPC: want %v, have %v", want, have)
+	}
+
+	d := &amqp.Delivery{Headers: amqp.Table{amqpAuthHeader: []byte("Bearer abc")}}
+	if want, have := "abc", AMQPToContext()(context.Background(), nil, d).Value(AccessTokenContextKey); want != have {
+		t.Errorf("AMQP: want %v, have %v", want, have)
+	}
+
+	r.Header.Set("Authorization", "Basic abc")
+	if have := HTTPToContext()(context.Background(), r).Value(AccessTokenContextKey); have != nil {
+		t.Errorf("HTTP: want no token, have %v", have)
+	}
+}
@@ -0,0 +1,100 @@
+package signature
+
+import (
+	"context"
+	"encoding/base64"
+
+	"github.com/streadway/amqp"
+
+	"github.com/inturn/kit/endpoint"
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+const (
+	// SignatureHeader is the AMQP message header holding the base64 encoded
+	// signature of the message body.
+	SignatureHeader = "X-Signature"
+
+	// KeyIDHeader is the AMQP message header holding the ID of the key the
+	// message was signed with.
+	KeyIDHeader = "X-Signature-Key-Id"
+)
+
+type contextKey string
+
+// VerificationContextKey holds the key used to store the result of the
+// signature verification of a delivery in the context.
+const VerificationContextKey contextKey = "SignatureVerification"
+
+// verification wraps the verification result, so that a successful
+// verification can be told apart from a missing one.
+type verification struct {
+	err error
+}
+
+// SignAMQP signs the body of the outgoing message with signer, adding the
+// signature and key ID headers. Publisher before functions run after the
+// request is encoded, so the complete body is signed. Particularly useful for
+// publishers.
+func SignAMQP(signer Signer) amqptransport.RequestFunc {
+	return func(ctx context.Context, pub *amqp.Publishing, _ *amqp.Delivery) context.Context {
+		if pub.Headers == nil {
+			pub.Headers = amqp.Table{}
+		}
+		pub.Headers[SignatureHeader] = base64.StdEncoding.EncodeToString(signer.Sign(pub.Body))
+		pub.Headers[KeyIDHeader] = signer.KeyID()
+		return ctx
+	}
+}
+
+// VerifyAMQP verifies the signature of the delivery's body with verifier and
+// stores the result in the context. Before functions can't abort a delivery,
+// so NewVerifier must wrap the endpoint to reject tampered messages.
+// Particularly useful for subscribers.
+func VerifyAMQP(verifier Verifier) amqptransport.RequestFunc {
+	return func(ctx context.Context, _ *amqp.Publishing, d *amqp.Delivery) context.Context {
+		return context.WithValue(ctx, VerificationContextKey, verification{verify(verifier, d)})
+	}
+}
+
+func verify(verifier Verifier, d *amqp.Delivery) error {
+	encoded, ok := header(d.Headers, SignatureHeader)
+	if !ok {
+		return ErrSignatureMissing
+	}
+	sig, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return ErrSignatureInvalid
+	}
+	keyID, _ := header(d.Headers, KeyIDHeader)
+	return verifier.Verify(keyID, d.Body, sig)
+}
+
+func header(table amqp.Table, key string) (string, bool) {
+	switch v := table[key].(type) {
+	case string:
+		return v, true
+	case []byte:
+		return string(v), true
+	}
+	return "", false
+}
+
+// NewVerifier creates a new middleware rejecting requests whose delivery
+// failed signature verification by VerifyAMQP, returning the verification
+// error to the subscriber's error encoder. Requests not verified at all are
+// rejected with ErrSignatureMissing. Particularly useful for subscribers.
+func NewVerifier() endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+			v, ok := ctx.Value(VerificationContextKey).(verification)
+			if !ok {
+				return nil, ErrSignatureMissing
+			}
+			if v.err != nil {
+				return nil, v.err
+			}
+			return next(ctx, request)
+		}
+	}
+}
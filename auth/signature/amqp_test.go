@@ -0,0 +1,91 @@
+package signature
+
+import (
+	"context"
+	"testing"
+
+	"github.com/streadway/amqp"
+
+	"github.com/inturn/kit/endpoint"
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+type mockChannel struct {
+	published []amqp.Publishing
+}
+
+func (ch *mockChannel) Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	ch.published = append(ch.published, msg)
+	return nil
+}
+
+func (ch *mockChannel) Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error) {
+	c := make(chan amqp.Delivery, 1)
+	last := ch.published[len(ch.published)-1]
+	c <- amqp.Delivery{CorrelationId: last.CorrelationId}
+	return c, nil
+}
+
+func TestAMQPSigning(t *testing.T) {
+	ch := &mockChannel{}
+	publisher := amqptransport.NewPublisher(
+		ch,
+		&amqp.Queue{Name: "replies"},
+		func(_ context.Context, pub *amqp.Publishing, request interface{}) error {
+			pub.Body = []byte(request.(string))
+			return nil
+		},
+		func(context.Context, *amqp.Delivery) (interface{}, error) { return nil, nil },
+		amqptransport.PublisherBefore(SignAMQP(HMACSigner("k1", []byte("secret")))),
+	).Endpoint()
+
+	if _, err := publisher(context.Background(), `{"order":42}`); err != nil {
+		t.Fatal(err)
+	}
+	pub := ch.published[0]
+
+	var (
+		called  bool
+		lastErr error
+	)
+	subscriber := amqptransport.NewSubscriber(
+		NewVerifier()(func(context.Context, interface{}) (interface{}, error) {
+			called = true
+			return nil, nil
+		}),
+		func(context.Context, *amqp.Delivery) (interface{}, error) { return nil, nil },
+		amqptransport.EncodeNopResponse,
+		amqptransport.SubscriberBefore(VerifyAMQP(HMACVerifier(map[string][]byte{"k1": []byte("secret")}))),
+		amqptransport.SubscriberErrorEncoder(func(_ context.Context, err error, _ *amqp.Delivery, _ amqptransport.Channel, _ *amqp.Publishing) {
+			lastErr = err
+		}),
+	)
+
+	for _, tt := range []struct {
+		name    string
+		deliv   amqp.Delivery
+		wantErr error
+	}{
+		{"valid", amqp.Delivery{Headers: pub.Headers, Body: pub.Body}, nil},
+		{"tampered", amqp.Delivery{Headers: pub.Headers, Body: []byte(`{"order":43}`)}, ErrSignatureInvalid},
+		{"unsigned", amqp.Delivery{Body: pub.Body}, ErrSignatureMissing},
+		{"malformed", amqp.Delivery{Headers: amqp.Table{SignatureHeader: "%%%"}, Body: pub.Body}, ErrSignatureInvalid},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			called, lastErr = false, nil
+			subscriber.ServeDelivery(&mockChannel{})(&tt.deliv)
+			if want, have := tt.wantErr, lastErr; want != have {
+				t.Errorf("want %v, have %v", want, have)
+			}
+			if want, have := tt.wantErr == nil, called; want != have {
+				t.Errorf("want endpoint called %v, have %v", want, have)
+			}
+		})
+	}
+}
+
+func TestNewVerifierWithoutVerification(t *testing.T) {
+	if _, err := NewVerifier()(endpoint.Nop)(context.Background(), nil); err != ErrSignatureMissing {
+		t.Errorf("want %v, have %v", ErrSignatureMissing, err)
+	}
+}
@@ -0,0 +1,109 @@
+package signature
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+)
+
+var (
+	// ErrSignatureMissing denotes a message carried no signature.
+	ErrSignatureMissing = errors.New("message signature is missing")
+
+	// ErrSignatureInvalid denotes a message signature did not match the
+	// message, i.e. the message was tampered with or signed with another
+	// key.
+	ErrSignatureInvalid = errors.New("message signature is invalid")
+
+	// ErrUnknownKey denotes a message was signed with a key ID unknown to the
+	// verifier.
+	ErrUnknownKey = errors.New("message signing key is unknown")
+)
+
+// Signer signs messages with a key identified by its key ID.
+type Signer interface {
+	KeyID() string
+	Sign(msg []byte) []byte
+}
+
+// Verifier verifies message signatures made with the key identified by keyID.
+// It returns nil for valid signatures.
+type Verifier interface {
+	Verify(keyID string, msg, sig []byte) error
+}
+
+type hmacSigner struct {
+	keyID string
+	key   []byte
+}
+
+// HMACSigner returns a Signer computing HMAC-SHA256 signatures. HMAC keys are
+// shared between publishers and subscribers, so use it where all parties are
+// equally trusted.
+func HMACSigner(keyID string, key []byte) Signer {
+	return hmacSigner{keyID: keyID, key: key}
+}
+
+func (s hmacSigner) KeyID() string { return s.keyID }
+
+func (s hmacSigner) Sign(msg []byte) []byte {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(msg)
+	return mac.Sum(nil)
+}
+
+type hmacVerifier map[string][]byte
+
+// HMACVerifier returns a Verifier checking HMAC-SHA256 signatures against the
+// keys indexed by key ID.
+func HMACVerifier(keys map[string][]byte) Verifier {
+	return hmacVerifier(keys)
+}
+
+func (v hmacVerifier) Verify(keyID string, msg, sig []byte) error {
+	key, ok := v[keyID]
+	if !ok {
+		return ErrUnknownKey
+	}
+	if !hmac.Equal(hmacSigner{key: key}.Sign(msg), sig) {
+		return ErrSignatureInvalid
+	}
+	return nil
+}
+
+type ed25519Signer struct {
+	keyID string
+	key   ed25519.PrivateKey
+}
+
+// Ed25519Signer returns a Signer computing Ed25519 signatures. Subscribers
+// only need the public key, so they can't forge messages themselves.
+func Ed25519Signer(keyID string, key ed25519.PrivateKey) Signer {
+	return ed25519Signer{keyID: keyID, key: key}
+}
+
+func (s ed25519Signer) KeyID() string { return s.keyID }
+
+func (s ed25519Signer) Sign(msg []byte) []byte {
+	return ed25519.Sign(s.key, msg)
+}
+
+type ed25519Verifier map[string]ed25519.PublicKey
+
+// Ed25519Verifier returns a Verifier checking Ed25519 signatures against the
+// public keys indexed by key ID.
+func Ed25519Verifier(keys map[string]ed25519.PublicKey) Verifier {
+	return ed25519Verifier(keys)
+}
+
+func (v ed25519Verifier) Verify(keyID string, msg, sig []byte) error {
+	key, ok := v[keyID]
+	if !ok {
+		return ErrUnknownKey
+	}
+	if !ed25519.Verify(key, msg, sig) {
+		return ErrSignatureInvalid
+	}
+	return nil
+}
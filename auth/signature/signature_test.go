@@ -0,0 +1,38 @@
+package signature
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+)
+
+func TestSignersAndVerifiers(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name     string
+		signer   Signer
+		verifier Verifier
+	}{
+		{"HMAC", HMACSigner("k1", []byte("secret")), HMACVerifier(map[string][]byte{"k1": []byte("secret")})},
+		{"Ed25519", Ed25519Signer("k1", priv), Ed25519Verifier(map[string]ed25519.PublicKey{"k1": pub})},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := []byte(`{"order":42}`)
+			sig := tt.signer.Sign(msg)
+
+			if err := tt.verifier.Verify(tt.signer.KeyID(), msg, sig); err != nil {
+				t.Errorf("want valid signature, have %v", err)
+			}
+			if want, have := ErrSignatureInvalid, tt.verifier.Verify(tt.signer.KeyID(), []byte(`{"order":43}`), sig); want != have {
+				t.Errorf("want %v, have %v", want, have)
+			}
+			if want, have := ErrUnknownKey, tt.verifier.Verify("k2", msg, sig); want != have {
+				t.Errorf("want %v, have %v", want, have)
+			}
+		})
+	}
+}
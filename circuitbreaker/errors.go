@@ -0,0 +1,64 @@
+package circuitbreaker
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// OpenError is returned by the circuit breaker middlewares when a request is
+// rejected without calling the endpoint, because the circuit is open or the
+// half-open circuit admits no more requests. It is encoded as 503 Service
+// Unavailable by the HTTP transport and as codes.Unavailable by the gRPC
+// transport; AMQP error encoders may use RetryAfter to delay redelivery.
+type OpenError struct {
+	// Breaker is the name of the circuit breaker, if known.
+	Breaker string
+
+	// RetryAfter is the duration after which the circuit breaker lets
+	// requests through again, or zero if unknown.
+	RetryAfter time.Duration
+
+	// Err is the error returned by the underlying circuit breaker package.
+	Err error
+}
+
+// Error implements the error interface. It returns the message of the
+// underlying error.
+func (e OpenError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the error of the underlying circuit breaker package.
+func (e OpenError) Unwrap() error {
+	return e.Err
+}
+
+// StatusCode implements the StatusCoder interface of the HTTP transport.
+func (OpenError) StatusCode() int {
+	return http.StatusServiceUnavailable
+}
+
+// Headers implements the Headerer interface of the HTTP transport, setting
+// Retry-After if known.
+func (e OpenError) Headers() http.Header {
+	if e.RetryAfter <= 0 {
+		return nil
+	}
+	secs := int((e.RetryAfter + time.Second - 1) / time.Second)
+	return http.Header{"Retry-After": []string{strconv.Itoa(secs)}}
+}
+
+// GRPCStatus is recognized by gRPC when returning errors from a handler.
+func (e OpenError) GRPCStatus() *status.Status {
+	return status.New(codes.Unavailable, e.Error())
+}
+
+// IsOpen reports whether err is an OpenError.
+func IsOpen(err error) bool {
+	_, ok := err.(OpenError)
+	return ok
+}
@@ -0,0 +1,41 @@
+package circuitbreaker_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sony/gobreaker"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/inturn/kit/circuitbreaker"
+	httptransport "github.com/inturn/kit/transport/http"
+)
+
+func TestOpenErrorEncoding(t *testing.T) {
+	err := circuitbreaker.OpenError{RetryAfter: 1500 * time.Millisecond, Err: gobreaker.ErrOpenState}
+
+	rec := httptest.NewRecorder()
+	httptransport.DefaultErrorEncoder(context.Background(), err, rec)
+	if want, have := http.StatusServiceUnavailable, rec.Code; want != have {
+		t.Errorf("want HTTP status %d, have %d", want, have)
+	}
+	if want, have := "2", rec.Header().Get("Retry-After"); want != have {
+		t.Errorf("want Retry-After %q, have %q", want, have)
+	}
+
+	if want, have := codes.Unavailable, status.Code(err); want != have {
+		t.Errorf("want gRPC code %s, have %s", want, have)
+	}
+
+	if !errors.Is(err, gobreaker.ErrOpenState) {
+		t.Error("want OpenError to wrap gobreaker.ErrOpenState")
+	}
+	if !circuitbreaker.IsOpen(err) || circuitbreaker.IsOpen(gobreaker.ErrOpenState) {
+		t.Error("unexpected IsOpen result")
+	}
+}
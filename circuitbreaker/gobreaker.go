@@ -2,6 +2,7 @@ package circuitbreaker
 
 import (
 	"context"
+	"time"
 
 	"github.com/sony/gobreaker"
 
@@ -11,12 +12,95 @@ import (
 // Gobreaker returns an endpoint.Middleware that implements the circuit
 // breaker pattern using the sony/gobreaker package. Only errors returned by
 // the wrapped endpoint count against the circuit breaker's error count.
+// Rejected requests fail with an OpenError wrapping gobreaker.ErrOpenState or
+// gobreaker.ErrTooManyRequests.
 //
 // See http://godoc.org/github.com/sony/gobreaker for more information.
 func Gobreaker(cb *gobreaker.CircuitBreaker) endpoint.Middleware {
+	return gobreakerMiddleware(cb, gobreakerConfig{observer: nopObserver{}}, OpenError{})
+}
+
+// GobreakerOption sets an optional parameter for NewGobreaker.
+type GobreakerOption func(*gobreakerConfig)
+
+type gobreakerConfig struct {
+	isFailure func(error) bool
+	observer  Observer
+}
+
+// GobreakerIsFailure sets the function deciding which errors returned by the
+// wrapped endpoint count against the circuit breaker's error count, e.g. to
+// ignore validation errors or canceled requests. Errors not counted are still
+// returned. By default, every error counts.
+func GobreakerIsFailure(f func(error) bool) GobreakerOption {
+	return func(c *gobreakerConfig) { c.isFailure = f }
+}
+
+// GobreakerObserver sets the Observer notified of the state transitions,
+// rejections and results of the circuit breaker. Settings.OnStateChange is
+// still called.
+func GobreakerObserver(o Observer) GobreakerOption {
+	return func(c *gobreakerConfig) { c.observer = o }
+}
+
+// NewGobreaker returns an endpoint.Middleware like Gobreaker, creating the
+// circuit breaker from settings. All of the settings are passed through:
+// ReadyToTrip decides when the circuit opens, OnStateChange observes state
+// transitions, Interval sets the cyclic period of the closed state after
+// which counts are cleared, and Timeout the period of the open state after
+// which the circuit turns half-open. Rejected requests fail with an
+// OpenError carrying the breaker name and Timeout as RetryAfter.
+func NewGobreaker(settings gobreaker.Settings, options ...GobreakerOption) endpoint.Middleware {
+	config := gobreakerConfig{observer: nopObserver{}}
+	for _, option := range options {
+		option(&config)
+	}
+
+	onStateChange := settings.OnStateChange
+	settings.OnStateChange = func(name string, from, to gobreaker.State) {
+		config.observer.StateChange(name, State(from.String()), State(to.String()))
+		if onStateChange != nil {
+			onStateChange(name, from, to)
+		}
+	}
+
+	open := OpenError{Breaker: settings.Name, RetryAfter: settings.Timeout}
+	if open.RetryAfter <= 0 {
+		open.RetryAfter = defaultGobreakerTimeout
+	}
+
+	return gobreakerMiddleware(gobreaker.NewCircuitBreaker(settings), config, open)
+}
+
+// gobreakerMiddleware returns rejections of cb as copies of open.
+func gobreakerMiddleware(cb *gobreaker.CircuitBreaker, config gobreakerConfig, open OpenError) endpoint.Middleware {
 	return func(next endpoint.Endpoint) endpoint.Endpoint {
 		return func(ctx context.Context, request interface{}) (interface{}, error) {
-			return cb.Execute(func() (interface{}, error) { return next(ctx, request) })
+			var ignored error
+			response, err := cb.Execute(func() (interface{}, error) {
+				response, err := next(ctx, request)
+				if err != nil && config.isFailure != nil && !config.isFailure(err) {
+					ignored = err
+					return response, nil
+				}
+				return response, err
+			})
+			switch {
+			case ignored != nil:
+				config.observer.Done(cb.Name(), nil)
+				return response, ignored
+			case err == gobreaker.ErrOpenState || err == gobreaker.ErrTooManyRequests:
+				e := open
+				e.Err = err
+				config.observer.Rejected(cb.Name(), e)
+				return nil, e
+			}
+			config.observer.Done(cb.Name(), err)
+			return response, err
 		}
 	}
 }
+
+// defaultGobreakerTimeout is the open state period sony/gobreaker uses if
+// Settings.Timeout is not set.
+const defaultGobreakerTimeout = 60 * time.Second
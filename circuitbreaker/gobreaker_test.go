@@ -1,7 +1,12 @@
 package circuitbreaker_test
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/sony/gobreaker"
 
@@ -17,3 +22,47 @@ func TestGobreaker(t *testing.T) {
 	)
 	testFailingEndpoint(t, breaker, primeWith, shouldPass, 0, circuitOpenError)
 }
+
+func TestNewGobreaker(t *testing.T) {
+	var transitions []string
+	breaker := circuitbreaker.NewGobreaker(gobreaker.Settings{
+		Name:    "inventory",
+		Timeout: 30 * time.Second,
+		ReadyToTrip: func(counts gobreaker.Counts) bool {
+			return counts.ConsecutiveFailures >= 2
+		},
+		OnStateChange: func(name string, from, to gobreaker.State) {
+			transitions = append(transitions, fmt.Sprintf("%s: %s -> %s", name, from, to))
+		},
+	}, circuitbreaker.GobreakerIsFailure(func(err error) bool { return err != errIgnored }))
+
+	var err error
+	e := breaker(func(context.Context, interface{}) (interface{}, error) { return nil, err })
+
+	// ignored errors are returned, but don't trip the breaker
+	err = errIgnored
+	for i := 0; i < 5; i++ {
+		if _, have := e(context.Background(), nil); have != errIgnored {
+			t.Fatalf("want %v, have %v", errIgnored, have)
+		}
+	}
+
+	err = errors.New("unavailable")
+	for i := 0; i < 2; i++ {
+		e(context.Background(), nil)
+	}
+
+	_, have := e(context.Background(), nil)
+	open, ok := have.(circuitbreaker.OpenError)
+	if !ok {
+		t.Fatalf("want OpenError, have %v", have)
+	}
+	if want, have := (circuitbreaker.OpenError{Breaker: "inventory", RetryAfter: 30 * time.Second, Err: gobreaker.ErrOpenState}), open; want != have {
+		t.Errorf("want %+v, have %+v", want, have)
+	}
+	if want, have := []string{"inventory: closed -> open"}, transitions; !reflect.DeepEqual(want, have) {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
+
+var errIgnored = errors.New("invalid argument")
@@ -2,6 +2,7 @@ package circuitbreaker
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/streadway/handy/breaker"
@@ -16,19 +17,30 @@ import (
 //
 // See http://godoc.org/github.com/streadway/handy/breaker for more
 // information.
-func HandyBreaker(cb breaker.Breaker) endpoint.Middleware {
+func HandyBreaker(cb breaker.Breaker, options ...HandyBreakerOption) endpoint.Middleware {
+	config := handyBreakerConfig{observer: nopObserver{}}
+	for _, option := range options {
+		option(&config)
+	}
+	state := &handyState{state: StateClosed}
+
 	return func(next endpoint.Endpoint) endpoint.Endpoint {
 		return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 			if !cb.Allow() {
+				config.observe(state, StateOpen)
+				config.observer.Rejected(config.name, breaker.ErrCircuitOpen)
 				return nil, breaker.ErrCircuitOpen
 			}
+			config.observe(state, StateHalfOpen)
 
 			defer func(begin time.Time) {
 				if err == nil {
 					cb.Success(time.Since(begin))
+					config.observe(state, StateClosed)
 				} else {
 					cb.Failure(time.Since(begin))
 				}
+				config.observer.Done(config.name, err)
 			}(time.Now())
 
 			response, err = next(ctx, request)
@@ -36,3 +48,51 @@ func HandyBreaker(cb breaker.Breaker) endpoint.Middleware {
 		}
 	}
 }
+
+// HandyBreakerOption sets an optional parameter for HandyBreaker.
+type HandyBreakerOption func(*handyBreakerConfig)
+
+type handyBreakerConfig struct {
+	name     string
+	observer Observer
+}
+
+// HandyBreakerObserver sets the Observer notified of the state transitions,
+// rejections and results of the circuit breaker, reported as breaker name.
+// As streadway/handy/breaker doesn't expose its state, transitions are
+// inferred from whether requests are allowed and succeed: a rejection means
+// the circuit is open, a request allowed while it's open means it's half-open
+// and a success means it's closed.
+func HandyBreakerObserver(name string, o Observer) HandyBreakerOption {
+	return func(c *handyBreakerConfig) {
+		c.name = name
+		c.observer = o
+	}
+}
+
+// handyState is the state of a handy breaker as last observed.
+type handyState struct {
+	mtx   sync.Mutex
+	state State
+}
+
+// observe records that the circuit may have transitioned to state. Only
+// transitions from open to half-open, and from half-open to closed, are
+// inferred from allowed requests and successes respectively.
+func (c handyBreakerConfig) observe(s *handyState, to State) {
+	s.mtx.Lock()
+	from := s.state
+	switch {
+	case to == StateOpen:
+	case to == StateHalfOpen && from == StateOpen:
+	case to == StateClosed && from == StateHalfOpen:
+	default:
+		to = from
+	}
+	s.state = to
+	s.mtx.Unlock()
+
+	if from != to {
+		c.observer.StateChange(c.name, from, to)
+	}
+}
@@ -2,6 +2,7 @@ package circuitbreaker
 
 import (
 	"context"
+	"sync"
 
 	"github.com/afex/hystrix-go/hystrix"
 
@@ -9,23 +10,160 @@ import (
 )
 
 // Hystrix returns an endpoint.Middleware that implements the circuit
-// breaker pattern using the afex/hystrix-go package.
+// breaker pattern using the afex/hystrix-go package. Requests rejected
+// because the circuit is open or the command's concurrency limit is reached
+// fail with an OpenError wrapping hystrix.ErrCircuitOpen or
+// hystrix.ErrMaxConcurrency.
 //
 // When using this circuit breaker, please configure your commands separately.
 //
 // See https://godoc.org/github.com/afex/hystrix-go/hystrix for more
 // information.
 func Hystrix(commandName string) endpoint.Middleware {
+	return hystrixMiddleware(commandName, hystrixConfig{observer: nopObserver{}})
+}
+
+// FallbackFunc returns the response of a request which failed or was
+// rejected by a circuit breaker with err, e.g. a cached or default response.
+// Returning an error fails the request with that error.
+type FallbackFunc func(ctx context.Context, request interface{}, err error) (response interface{}, _ error)
+
+// HystrixOption sets an optional parameter for NewHystrix.
+type HystrixOption func(*hystrixConfig)
+
+type hystrixConfig struct {
+	fallback FallbackFunc
+	observer Observer
+}
+
+// HystrixFallback sets the function called for requests which failed, timed
+// out or were rejected. By default, the error is returned.
+func HystrixFallback(f FallbackFunc) HystrixOption {
+	return func(c *hystrixConfig) { c.fallback = f }
+}
+
+// HystrixObserver sets the Observer notified of the state transitions,
+// rejections and results of the command. hystrix-go doesn't notify of state
+// transitions, so whether the circuit is open is checked after every request
+// and only transitions between open and closed are observed.
+func HystrixObserver(o Observer) HystrixOption {
+	return func(c *hystrixConfig) { c.observer = o }
+}
+
+// NewHystrix configures the hystrix command commandName with config and
+// returns an endpoint.Middleware like Hystrix executing requests as that
+// command. Naming commands after endpoints configures the error percent
+// threshold, sleep window, timeout and concurrency limit per endpoint.
+func NewHystrix(commandName string, config hystrix.CommandConfig, options ...HystrixOption) endpoint.Middleware {
+	hystrix.ConfigureCommand(commandName, config)
+
+	c := hystrixConfig{observer: nopObserver{}}
+	for _, option := range options {
+		option(&c)
+	}
+	return hystrixMiddleware(commandName, c)
+}
+
+// NewHystrixStreamHandler returns a started handler serving the metrics and
+// circuit states of all hystrix commands in the format of the Hystrix
+// dashboard. Call Stop on the handler when shutting down.
+func NewHystrixStreamHandler() *hystrix.StreamHandler {
+	h := hystrix.NewStreamHandler()
+	h.Start()
+	return h
+}
+
+func hystrixMiddleware(commandName string, config hystrixConfig) endpoint.Middleware {
+	fallback := config.fallback
+	state := &hystrixState{state: StateClosed}
+
 	return func(next endpoint.Endpoint) endpoint.Endpoint {
 		return func(ctx context.Context, request interface{}) (response interface{}, err error) {
-			var resp interface{}
-			if err := hystrix.Do(commandName, func() (err error) {
-				resp, err = next(ctx, request)
-				return err
-			}, nil); err != nil {
-				return nil, err
+			var (
+				resp         interface{}
+				fallbackResp interface{}
+				fallbackErr  error
+				fellBack     bool
+				cause        error
+			)
+			defer func() {
+				switch cause {
+				case hystrix.ErrCircuitOpen, hystrix.ErrMaxConcurrency:
+					config.observer.Rejected(commandName, hystrixError(commandName, cause))
+				default:
+					config.observer.Done(commandName, cause)
+				}
+				state.observe(commandName, config.observer)
+			}()
+
+			if fallback == nil {
+				err = hystrix.Do(commandName, func() (err error) {
+					resp, err = next(ctx, request)
+					return err
+				}, nil)
+				cause = err
+			} else {
+				err = hystrix.DoC(ctx, commandName, func(ctx context.Context) (err error) {
+					resp, err = next(ctx, request)
+					return err
+				}, func(ctx context.Context, err error) error {
+					fellBack, cause = true, err
+					fallbackResp, fallbackErr = fallback(ctx, request, hystrixError(commandName, err))
+					return fallbackErr
+				})
+			}
+
+			switch {
+			case fellBack && fallbackErr != nil:
+				return nil, fallbackErr
+			case fellBack:
+				return fallbackResp, nil
+			case err != nil:
+				return nil, hystrixError(commandName, err)
 			}
 			return resp, nil
 		}
 	}
 }
+
+// hystrixError maps rejections of the hystrix command to OpenErrors.
+func hystrixError(commandName string, err error) error {
+	if err != hystrix.ErrCircuitOpen && err != hystrix.ErrMaxConcurrency {
+		return err
+	}
+	e := OpenError{Breaker: commandName, Err: err}
+	if err == hystrix.ErrCircuitOpen {
+		if settings, ok := hystrix.GetCircuitSettings()[commandName]; ok {
+			e.RetryAfter = settings.SleepWindow
+		}
+	}
+	return e
+}
+
+// hystrixState is the state of a hystrix circuit as last observed.
+type hystrixState struct {
+	mtx   sync.Mutex
+	state State
+}
+
+// observe notifies o if the circuit of commandName opened or closed since it
+// was last observed.
+func (s *hystrixState) observe(commandName string, o Observer) {
+	circuit, _, err := hystrix.GetCircuit(commandName)
+	if err != nil {
+		return
+	}
+	to := StateClosed
+	if circuit.IsOpen() {
+		to = StateOpen
+	}
+
+	s.mtx.Lock()
+	from := s.state
+	s.state = to
+	s.mtx.Unlock()
+
+	if from != to {
+		o.StateChange(commandName, from, to)
+	}
+}
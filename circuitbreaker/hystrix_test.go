@@ -1,6 +1,8 @@
 package circuitbreaker_test
 
 import (
+	"context"
+	"errors"
 	"io/ioutil"
 	stdlog "log"
 	"testing"
@@ -38,3 +40,47 @@ func TestHystrix(t *testing.T) {
 
 	testFailingEndpoint(t, breaker, primeWith, shouldPass, requestDelay, openCircuitError)
 }
+
+func TestNewHystrixFallback(t *testing.T) {
+	stdlog.SetOutput(ioutil.Discard)
+
+	const commandName = "fallback-endpoint"
+	errUnavailable := errors.New("unavailable")
+
+	var fallbackErr error
+	breaker := circuitbreaker.NewHystrix(commandName, hystrix.CommandConfig{
+		ErrorPercentThreshold:  1,
+		RequestVolumeThreshold: 1,
+		SleepWindow:            60000,
+	}, circuitbreaker.HystrixFallback(func(ctx context.Context, request interface{}, err error) (interface{}, error) {
+		fallbackErr = err
+		if request == "no fallback" {
+			return nil, err
+		}
+		return "cached", nil
+	}))
+	e := breaker(func(context.Context, interface{}) (interface{}, error) { return nil, errUnavailable })
+
+	response, err := e(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "cached", response; want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	if want, have := errUnavailable, fallbackErr; want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+
+	// wait for hystrix to open the circuit
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, err = e(context.Background(), "no fallback"); circuitbreaker.IsOpen(err) || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if want, have := (circuitbreaker.OpenError{Breaker: commandName, RetryAfter: time.Minute, Err: hystrix.ErrCircuitOpen}), err; want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
@@ -0,0 +1,53 @@
+package circuitbreaker
+
+import (
+	"context"
+
+	"github.com/inturn/kit/endpoint"
+	"github.com/inturn/kit/internal/lru"
+)
+
+// KeyFunc derives the key of the circuit breaker handling a request, e.g. the
+// downstream host or tenant, from the context and the request.
+type KeyFunc func(ctx context.Context, request interface{}) string
+
+// KeyedOption sets an optional parameter for NewKeyed.
+type KeyedOption func(*keyedConfig)
+
+type keyedConfig struct {
+	size int
+}
+
+// KeyedSize sets the maximum number of keys a circuit breaker is kept for.
+// If more keys are seen, the breaker of the key not seen for the longest time
+// is discarded, and recreated closed the next time that key is seen. It
+// defaults to 10000.
+func KeyedSize(n int) KeyedOption {
+	return func(c *keyedConfig) { c.size = n }
+}
+
+// NewKeyed returns an endpoint.Middleware with a circuit breaker per key, so
+// that one failing downstream host or tenant doesn't open the circuit for
+// all others. The breaker of a key is created by newBreaker when the key is
+// first seen, e.g.
+//
+//	circuitbreaker.NewKeyed(hostKey, func(host string) endpoint.Middleware {
+//	    return circuitbreaker.NewGobreaker(gobreaker.Settings{Name: host})
+//	})
+func NewKeyed(key KeyFunc, newBreaker func(key string) endpoint.Middleware, options ...KeyedOption) endpoint.Middleware {
+	config := keyedConfig{size: 10000}
+	for _, option := range options {
+		option(&config)
+	}
+
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		endpoints := lru.New(config.size)
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			k := key(ctx, request)
+			e := endpoints.GetOrCreate(k, func() interface{} {
+				return newBreaker(k)(next)
+			}).(endpoint.Endpoint)
+			return e(ctx, request)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package circuitbreaker_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/sony/gobreaker"
+
+	"github.com/inturn/kit/circuitbreaker"
+	"github.com/inturn/kit/endpoint"
+)
+
+func TestKeyed(t *testing.T) {
+	var (
+		hostKey = func(_ context.Context, request interface{}) string { return request.(string) }
+		breaker = circuitbreaker.NewKeyed(hostKey, func(host string) endpoint.Middleware {
+			return circuitbreaker.NewGobreaker(gobreaker.Settings{
+				Name:        host,
+				ReadyToTrip: func(counts gobreaker.Counts) bool { return counts.ConsecutiveFailures >= 1 },
+			})
+		})
+		e = breaker(func(_ context.Context, request interface{}) (interface{}, error) {
+			if request == "down" {
+				return nil, errors.New("unavailable")
+			}
+			return nil, nil
+		})
+	)
+
+	e(context.Background(), "down")
+	if _, err := e(context.Background(), "down"); !circuitbreaker.IsOpen(err) {
+		t.Errorf("want circuit of failing host open, have %v", err)
+	}
+	if _, err := e(context.Background(), "up"); err != nil {
+		t.Errorf("want other hosts unaffected, have %v", err)
+	}
+}
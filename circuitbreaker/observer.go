@@ -0,0 +1,157 @@
+package circuitbreaker
+
+import (
+	"sync"
+	"time"
+
+	"github.com/inturn/kit/log"
+	"github.com/inturn/kit/metrics"
+)
+
+// State is the state of a circuit breaker.
+type State string
+
+// States of a circuit breaker.
+const (
+	StateClosed   State = "closed"
+	StateHalfOpen State = "half-open"
+	StateOpen     State = "open"
+)
+
+// Observer observes the behavior of circuit breakers. Pass it to the breaker
+// adapters with GobreakerObserver, HystrixObserver or HandyBreakerObserver.
+// Implementations must be safe for concurrent use.
+type Observer interface {
+	// StateChange is called when breaker transitions between states.
+	StateChange(breaker string, from, to State)
+
+	// Rejected is called for requests rejected by breaker with err.
+	Rejected(breaker string, err error)
+
+	// Done is called for requests executed by breaker. err is nil for
+	// requests not counted as failures.
+	Done(breaker string, err error)
+}
+
+// Observers returns an Observer notifying all of observers.
+func Observers(observers ...Observer) Observer {
+	return multiObserver(observers)
+}
+
+type multiObserver []Observer
+
+func (m multiObserver) StateChange(breaker string, from, to State) {
+	for _, o := range m {
+		o.StateChange(breaker, from, to)
+	}
+}
+
+func (m multiObserver) Rejected(breaker string, err error) {
+	for _, o := range m {
+		o.Rejected(breaker, err)
+	}
+}
+
+func (m multiObserver) Done(breaker string, err error) {
+	for _, o := range m {
+		o.Done(breaker, err)
+	}
+}
+
+type nopObserver struct{}
+
+func (nopObserver) StateChange(string, State, State) {}
+func (nopObserver) Rejected(string, error)           {}
+func (nopObserver) Done(string, error)               {}
+
+// NewLogObserver returns an Observer logging the state transitions of
+// circuit breakers to logger. Individual requests are not logged.
+func NewLogObserver(logger log.Logger) Observer {
+	return logObserver{logger}
+}
+
+type logObserver struct {
+	logger log.Logger
+}
+
+func (o logObserver) StateChange(breaker string, from, to State) {
+	o.logger.Log("breaker", breaker, "from", from, "to", to)
+}
+
+func (logObserver) Rejected(string, error) {}
+func (logObserver) Done(string, error)     {}
+
+// NewMetricsObserver returns an Observer counting state transitions with
+// label values "breaker", "from" and "to", counting rejected requests with
+// label value "breaker", and setting errorRate with label value "breaker" to
+// the fraction of the requests executed in the last 10 seconds that failed.
+func NewMetricsObserver(transitions, rejections metrics.Counter, errorRate metrics.Gauge) Observer {
+	return &metricsObserver{
+		transitions: transitions,
+		rejections:  rejections,
+		errorRate:   errorRate,
+		windows:     map[string]*rollingWindow{},
+		now:         time.Now,
+	}
+}
+
+type metricsObserver struct {
+	transitions metrics.Counter
+	rejections  metrics.Counter
+	errorRate   metrics.Gauge
+
+	mtx     sync.Mutex
+	windows map[string]*rollingWindow
+	now     func() time.Time
+}
+
+func (o *metricsObserver) StateChange(breaker string, from, to State) {
+	o.transitions.With("breaker", breaker, "from", string(from), "to", string(to)).Add(1)
+}
+
+func (o *metricsObserver) Rejected(breaker string, _ error) {
+	o.rejections.With("breaker", breaker).Add(1)
+}
+
+func (o *metricsObserver) Done(breaker string, err error) {
+	o.mtx.Lock()
+	w, ok := o.windows[breaker]
+	if !ok {
+		w = &rollingWindow{}
+		o.windows[breaker] = w
+	}
+	rate := w.add(o.now().Unix(), err != nil)
+	o.mtx.Unlock()
+
+	o.errorRate.With("breaker", breaker).Set(rate)
+}
+
+// rollingWindow counts requests and failures in one second buckets.
+type rollingWindow struct {
+	buckets [10]struct {
+		second          int64
+		total, failures int
+	}
+}
+
+// add records a request in the bucket of second and returns the fraction of
+// failed requests in the window ending at second.
+func (w *rollingWindow) add(second int64, failed bool) float64 {
+	b := &w.buckets[second%int64(len(w.buckets))]
+	if b.second != second {
+		b.second, b.total, b.failures = second, 0, 0
+	}
+	b.total++
+	if failed {
+		b.failures++
+	}
+
+	var total, failures int
+	for _, b := range w.buckets {
+		if second-b.second < int64(len(w.buckets)) {
+			total += b.total
+			failures += b.failures
+		}
+	}
+	return float64(failures) / float64(total)
+}
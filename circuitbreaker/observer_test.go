@@ -0,0 +1,102 @@
+package circuitbreaker_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/sony/gobreaker"
+	handybreaker "github.com/streadway/handy/breaker"
+
+	"github.com/inturn/kit/circuitbreaker"
+	"github.com/inturn/kit/metrics/metricstest"
+)
+
+type recordingObserver struct {
+	mtx         sync.Mutex
+	transitions []string
+	rejected    int
+	failed      int
+	succeeded   int
+}
+
+func (o *recordingObserver) StateChange(breaker string, from, to circuitbreaker.State) {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	o.transitions = append(o.transitions, fmt.Sprintf("%s: %s -> %s", breaker, from, to))
+}
+
+func (o *recordingObserver) Rejected(string, error) {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	o.rejected++
+}
+
+func (o *recordingObserver) Done(_ string, err error) {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	if err != nil {
+		o.failed++
+	} else {
+		o.succeeded++
+	}
+}
+
+func TestGobreakerObserver(t *testing.T) {
+	var (
+		o        = &recordingObserver{}
+		counters = metricstest.NewCounter("transitions")
+		rejected = metricstest.NewCounter("rejections")
+		rate     = metricstest.NewGauge("error_rate")
+		breaker  = circuitbreaker.NewGobreaker(gobreaker.Settings{
+			Name: "inventory",
+			ReadyToTrip: func(counts gobreaker.Counts) bool {
+				return counts.ConsecutiveFailures >= 2
+			},
+		}, circuitbreaker.GobreakerObserver(circuitbreaker.Observers(
+			o,
+			circuitbreaker.NewMetricsObserver(counters, rejected, rate),
+		)))
+		err error
+		e   = breaker(func(context.Context, interface{}) (interface{}, error) { return nil, err })
+	)
+
+	e(context.Background(), nil)
+	err = errors.New("unavailable")
+	for i := 0; i < 4; i++ {
+		e(context.Background(), nil)
+	}
+
+	if want, have := []string{"inventory: closed -> open"}, o.transitions; fmt.Sprint(want) != fmt.Sprint(have) {
+		t.Errorf("want transitions %v, have %v", want, have)
+	}
+	if want, have := [3]int{1, 2, 2}, [3]int{o.succeeded, o.failed, o.rejected}; want != have {
+		t.Errorf("want succeeded, failed, rejected %v, have %v", want, have)
+	}
+
+	metricstest.AssertCounter(t, counters, 1, "breaker", "inventory", "from", "closed", "to", "open")
+	metricstest.AssertCounter(t, rejected, 2, "breaker", "inventory")
+	metricstest.AssertGauge(t, rate, 2./3, "breaker", "inventory")
+}
+
+func TestHandyBreakerObserver(t *testing.T) {
+	var (
+		o       = &recordingObserver{}
+		cb      = handybreaker.NewBreaker(0.05)
+		breaker = circuitbreaker.HandyBreaker(cb, circuitbreaker.HandyBreakerObserver("inventory", o))
+		e       = breaker(func(context.Context, interface{}) (interface{}, error) { return nil, errors.New("unavailable") })
+	)
+
+	for i := 0; i < 1000 && o.rejected == 0; i++ {
+		e(context.Background(), nil)
+	}
+
+	if want, have := []string{"inventory: closed -> open"}, o.transitions; fmt.Sprint(want) != fmt.Sprint(have) {
+		t.Errorf("want transitions %v, have %v", want, have)
+	}
+	if want, have := 1, o.rejected; want != have {
+		t.Errorf("want %d rejected, have %d", want, have)
+	}
+}
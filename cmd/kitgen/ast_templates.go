@@ -7,5 +7,5 @@ package main
 import "golang.org/x/tools/godoc/vfs/mapfs"
 
 var ASTTemplates = mapfs.New(map[string]string{
-	`full.go`: "package foo\n\nimport (\n	\"context\"\n	\"encoding/json\"\n	\"errors\"\n	\"net/http\"\n\n	\"github.com/inturn/kit/endpoint\"\n	httptransport \"github.com/inturn/kit/transport/http\"\n)\n\ntype ExampleService struct {\n}\n\ntype ExampleRequest struct {\n	I int\n	S string\n}\ntype ExampleResponse struct {\n	S   string\n	Err error\n}\n\ntype Endpoints struct {\n	ExampleEndpoint endpoint.Endpoint\n}\n\nfunc (f ExampleService) ExampleEndpoint(ctx context.Context, i int, s string) (string, error) {\n	panic(errors.New(\"not implemented\"))\n}\n\nfunc makeExampleEndpoint(f ExampleService) endpoint.Endpoint {\n	return func(ctx context.Context, request interface{}) (interface{}, error) {\n		req := request.(ExampleRequest)\n		s, err := f.ExampleEndpoint(ctx, req.I, req.S)\n		return ExampleResponse{S: s, Err: err}, nil\n	}\n}\n\nfunc inlineHandlerBuilder(m *http.ServeMux, endpoints Endpoints) {\n	m.Handle(\"/bar\", httptransport.NewServer(endpoints.ExampleEndpoint, DecodeExampleRequest, EncodeExampleResponse))\n}\n\nfunc NewHTTPHandler(endpoints Endpoints) http.Handler {\n	m := http.NewServeMux()\n	inlineHandlerBuilder(m, endpoints)\n	return m\n}\n\nfunc DecodeExampleRequest(_ context.Context, r *http.Request) (interface{}, error) {\n	var req ExampleRequest\n	err := json.NewDecoder(r.Body).Decode(&req)\n	return req, err\n}\n\nfunc EncodeExampleResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {\n	w.Header().Set(\"Content-Type\", \"application/json; charset=utf-8\")\n	return json.NewEncoder(w).Encode(response)\n}\n",
+	`full.go`: "package foo\n\nimport (\n	\"context\"\n	\"encoding/json\"\n	\"errors\"\n	\"fmt\"\n	\"net/http\"\n\n	\"github.com/streadway/amqp\"\n\n	\"github.com/inturn/kit/endpoint\"\n	amqptransport \"github.com/inturn/kit/transport/amqp\"\n	grpctransport \"github.com/inturn/kit/transport/grpc\"\n	httptransport \"github.com/inturn/kit/transport/http\"\n)\n\ntype ExampleService struct {\n}\n\ntype ExampleRequest struct {\n	I int\n	S string\n}\ntype ExampleResponse struct {\n	S   string\n	Err error\n}\n\ntype Endpoints struct {\n	ExampleEndpoint endpoint.Endpoint\n}\n\nfunc (f ExampleService) ExampleEndpoint(ctx context.Context, i int, s string) (string, error) {\n	panic(errors.New(\"not implemented\"))\n}\n\nfunc makeExampleEndpoint(f ExampleService) endpoint.Endpoint {\n	return func(ctx context.Context, request interface{}) (interface{}, error) {\n		req := request.(ExampleRequest)\n		s, err := f.ExampleEndpoint(ctx, req.I, req.S)\n		return ExampleResponse{S: s, Err: err}, nil\n	}\n}\n\nfunc inlineEndpointBuilder(endpoints Endpoints, f ExampleService) {\n	endpoints.ExampleEndpoint = makeExampleEndpoint(f)\n}\n\nfunc inlineMiddlewareBuilder(endpoints Endpoints, mw []endpoint.Middleware, idx int) {\n	endpoints.ExampleEndpoint = mw[idx](endpoints.ExampleEndpoint)\n}\n\nfunc NewEndpoints(f ExampleService, mw ...endpoint.Middleware) Endpoints {\n	var endpoints Endpoints\n	inlineEndpointBuilder(endpoints, f)\n	for idx := len(mw) - 1; idx >= 0; idx-- {\n		inlineMiddlewareBuilder(endpoints, mw, idx)\n	}\n	return endpoints\n}\n\nfunc inlineHandlerBuilder(m *http.ServeMux, endpoints Endpoints) {\n	m.Handle(\"/bar\", httptransport.NewServer(endpoints.ExampleEndpoint, DecodeExampleRequest, EncodeExampleResponse))\n}\n\nfunc NewHTTPHandler(endpoints Endpoints) http.Handler {\n	m := http.NewServeMux()\n	inlineHandlerBuilder(m, endpoints)\n	return m\n}\n\nfunc DecodeExampleRequest(_ context.Context, r *http.Request) (interface{}, error) {\n	var req ExampleRequest\n	err := json.NewDecoder(r.Body).Decode(&req)\n	return req, err\n}\n\nfunc EncodeExampleResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {\n	w.Header().Set(\"Content-Type\", \"application/json; charset=utf-8\")\n	return json.NewEncoder(w).Encode(response)\n}\n\nfunc inlineSubscriberBuilder(subscribers map[string]*amqptransport.Subscriber, endpoints Endpoints, options []amqptransport.SubscriberOption) {\n	subscribers[\"bar\"] = amqptransport.NewSubscriber(endpoints.ExampleEndpoint, DecodeAMQPExampleRequest, amqptransport.EncodeJSONResponse, options...)\n}\n\nfunc NewAMQPSubscribers(endpoints Endpoints, options ...amqptransport.SubscriberOption) map[string]*amqptransport.Subscriber {\n	subscribers := map[string]*amqptransport.Subscriber{}\n	inlineSubscriberBuilder(subscribers, endpoints, options)\n	return subscribers\n}\n\nfunc DecodeAMQPExampleRequest(_ context.Context, d *amqp.Delivery) (interface{}, error) {\n	var req ExampleRequest\n	err := json.Unmarshal(d.Body, &req)\n	return req, err\n}\n\nfunc inlineGRPCServerBuilder(servers map[string]*grpctransport.Server, endpoints Endpoints, options []grpctransport.ServerOption) {\n	servers[\"bar\"] = grpctransport.NewServer(endpoints.ExampleEndpoint, DecodeGRPCExampleRequest, EncodeGRPCExampleResponse, options...)\n}\n\nfunc NewGRPCServers(endpoints Endpoints, options ...grpctransport.ServerOption) map[string]*grpctransport.Server {\n	servers := map[string]*grpctransport.Server{}\n	inlineGRPCServerBuilder(servers, endpoints, options)\n	return servers\n}\n\nfunc DecodeGRPCExampleRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {\n	req, ok := grpcReq.(*ExampleRequest)\n	if !ok {\n		return nil, fmt.Errorf(\"unexpected gRPC request %T\", grpcReq)\n	}\n	return *req, nil\n}\n\nfunc EncodeGRPCExampleResponse(_ context.Context, response interface{}) (interface{}, error) {\n	resp := response.(ExampleResponse)\n	return &resp, nil\n}\n",
 })
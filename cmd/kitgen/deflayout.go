@@ -15,10 +15,14 @@ func (l deflayout) transformAST(ctx *sourceContext) (files, error) {
 
 	endpoints := out.addFile("endpoints/endpoints.go", "endpoints")
 	http := out.addFile("http/http.go", "http")
+	amqp := out.addFile("amqp/amqp.go", "amqp")
+	grpc := out.addFile("grpc/grpc.go", "grpc")
 	service := out.addFile("service/service.go", "service")
 
 	addImports(endpoints, ctx)
 	addImports(http, ctx)
+	addImports(amqp, ctx)
+	addImports(grpc, ctx)
 	addImports(service, ctx)
 
 	for _, typ := range ctx.types {
@@ -36,6 +40,7 @@ func (l deflayout) transformAST(ctx *sourceContext) (files, error) {
 		}
 
 		addEndpointsStruct(endpoints, iface)
+		addEndpointsConstructor(endpoints, iface)
 		addHTTPHandler(http, iface)
 
 		for _, meth := range iface.methods {
@@ -43,10 +48,22 @@ func (l deflayout) transformAST(ctx *sourceContext) (files, error) {
 			addEncoder(http, meth)
 		}
 
+		addAMQPSubscribers(amqp, iface)
+		for _, meth := range iface.methods {
+			addAMQPDecoder(amqp, meth)
+		}
+
+		addGRPCServers(grpc, iface)
+		for _, meth := range iface.methods {
+			addGRPCDecoder(grpc, meth)
+			addGRPCEncoder(grpc, meth)
+		}
+
 		for name := range out {
 			out[name] = selectify(out[name], "service", iface.stubName().Name, l.packagePath("service"))
 			for _, meth := range iface.methods {
 				out[name] = selectify(out[name], "endpoints", meth.requestStructName().Name, l.packagePath("endpoints"))
+				out[name] = selectify(out[name], "endpoints", meth.responseStructName().Name, l.packagePath("endpoints"))
 			}
 		}
 	}
@@ -27,12 +27,24 @@ func (f flat) transformAST(ctx *sourceContext) (files, error) {
 		}
 
 		addEndpointsStruct(root, iface)
+		addEndpointsConstructor(root, iface)
 		addHTTPHandler(root, iface)
 
 		for _, meth := range iface.methods {
 			addDecoder(root, meth)
 			addEncoder(root, meth)
 		}
+
+		addAMQPSubscribers(root, iface)
+		for _, meth := range iface.methods {
+			addAMQPDecoder(root, meth)
+		}
+
+		addGRPCServers(root, iface)
+		for _, meth := range iface.methods {
+			addGRPCDecoder(root, meth)
+			addGRPCEncoder(root, meth)
+		}
 	}
 
 	return formatNodes(outputTree{"gokit.go": root})
@@ -24,6 +24,70 @@ func (i iface) endpointsStruct() ast.Decl {
 	return structDecl(id("Endpoints"), fl)
 }
 
+func (i iface) endpointsConstructor() ast.Decl {
+	constructorFn := fetchFuncDecl("NewEndpoints")
+	constructorFn = replaceIdent(constructorFn, "ExampleService", i.stubName()).(*ast.FuncDecl)
+	constructorFn = replaceIdent(constructorFn, "f", i.receiverName()).(*ast.FuncDecl)
+
+	makeCalls := []ast.Stmt{}
+	wrapCalls := []ast.Stmt{}
+	for _, m := range i.methods {
+		makeCall := fetchFuncDecl("inlineEndpointBuilder").Body.List[0].(*ast.AssignStmt)
+		makeCall = replaceIdent(makeCall, "ExampleEndpoint", m.name).(*ast.AssignStmt)
+		makeCall = replaceIdent(makeCall, "makeExampleEndpoint", m.endpointMakerName()).(*ast.AssignStmt)
+		makeCall = replaceIdent(makeCall, "f", i.receiverName()).(*ast.AssignStmt)
+		makeCalls = append(makeCalls, makeCall)
+
+		wrapCall := fetchFuncDecl("inlineMiddlewareBuilder").Body.List[0].(*ast.AssignStmt)
+		wrapCall = replaceIdent(wrapCall, "ExampleEndpoint", m.name).(*ast.AssignStmt)
+		wrapCalls = append(wrapCalls, wrapCall)
+	}
+
+	pasteStmts(constructorFn.Body.List[2].(*ast.ForStmt).Body, 0, wrapCalls)
+	pasteStmts(constructorFn.Body, 1, makeCalls)
+
+	return constructorFn
+}
+
+func (i iface) amqpSubscribers() ast.Decl {
+	subscribersFn := fetchFuncDecl("NewAMQPSubscribers")
+
+	subscribeCalls := []ast.Stmt{}
+	for _, m := range i.methods {
+		subscribeCall := fetchFuncDecl("inlineSubscriberBuilder").Body.List[0].(*ast.AssignStmt)
+
+		subscribeCall = replaceLit(subscribeCall, `"bar"`, `"`+m.routingKey()+`"`).(*ast.AssignStmt)
+		subscribeCall = replaceIdent(subscribeCall, "ExampleEndpoint", m.name).(*ast.AssignStmt)
+		subscribeCall = replaceIdent(subscribeCall, "DecodeAMQPExampleRequest", m.amqpDecodeFuncName()).(*ast.AssignStmt)
+
+		subscribeCalls = append(subscribeCalls, subscribeCall)
+	}
+
+	pasteStmts(subscribersFn.Body, 1, subscribeCalls)
+
+	return subscribersFn
+}
+
+func (i iface) grpcServers() ast.Decl {
+	serversFn := fetchFuncDecl("NewGRPCServers")
+
+	serverCalls := []ast.Stmt{}
+	for _, m := range i.methods {
+		serverCall := fetchFuncDecl("inlineGRPCServerBuilder").Body.List[0].(*ast.AssignStmt)
+
+		serverCall = replaceLit(serverCall, `"bar"`, `"`+m.name.Name+`"`).(*ast.AssignStmt)
+		serverCall = replaceIdent(serverCall, "ExampleEndpoint", m.name).(*ast.AssignStmt)
+		serverCall = replaceIdent(serverCall, "DecodeGRPCExampleRequest", m.grpcDecodeFuncName()).(*ast.AssignStmt)
+		serverCall = replaceIdent(serverCall, "EncodeGRPCExampleResponse", m.grpcEncodeFuncName()).(*ast.AssignStmt)
+
+		serverCalls = append(serverCalls, serverCall)
+	}
+
+	pasteStmts(serversFn.Body, 1, serverCalls)
+
+	return serversFn
+}
+
 func (i iface) httpHandler() ast.Decl {
 	handlerFn := fetchFuncDecl("NewHTTPHandler")
 
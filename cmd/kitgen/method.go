@@ -51,6 +51,22 @@ func (m method) pathName() string {
 	return "/" + strings.ToLower(m.name.Name)
 }
 
+func (m method) routingKey() string {
+	return strings.ToLower(m.name.Name)
+}
+
+func (m method) amqpDecodeFuncName() *ast.Ident {
+	return id("DecodeAMQP" + m.name.Name + "Request")
+}
+
+func (m method) grpcDecodeFuncName() *ast.Ident {
+	return id("DecodeGRPC" + m.name.Name + "Request")
+}
+
+func (m method) grpcEncodeFuncName() *ast.Ident {
+	return id("EncodeGRPC" + m.name.Name + "Response")
+}
+
 func (m method) encodeFuncName() *ast.Ident {
 	return id("Encode" + m.name.Name + "Response")
 }
@@ -136,6 +152,27 @@ func (m method) decoderFunc() ast.Decl {
 	return fn
 }
 
+func (m method) amqpDecoderFunc() ast.Decl {
+	fn := fetchFuncDecl("DecodeAMQPExampleRequest")
+	fn.Name = m.amqpDecodeFuncName()
+	fn = replaceIdent(fn, "ExampleRequest", m.requestStructName()).(*ast.FuncDecl)
+	return fn
+}
+
+func (m method) grpcDecoderFunc() ast.Decl {
+	fn := fetchFuncDecl("DecodeGRPCExampleRequest")
+	fn.Name = m.grpcDecodeFuncName()
+	fn = replaceIdent(fn, "ExampleRequest", m.requestStructName()).(*ast.FuncDecl)
+	return fn
+}
+
+func (m method) grpcEncoderFunc() ast.Decl {
+	fn := fetchFuncDecl("EncodeGRPCExampleResponse")
+	fn.Name = m.grpcEncodeFuncName()
+	fn = replaceIdent(fn, "ExampleResponse", m.responseStructName()).(*ast.FuncDecl)
+	return fn
+}
+
 func (m method) encoderFunc() ast.Decl {
 	fn := fetchFuncDecl("EncodeExampleResponse")
 	fn.Name = m.encodeFuncName()
@@ -4,9 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 
+	"github.com/streadway/amqp"
+
 	"github.com/inturn/kit/endpoint"
+	amqptransport "github.com/inturn/kit/transport/amqp"
+	grpctransport "github.com/inturn/kit/transport/grpc"
 	httptransport "github.com/inturn/kit/transport/http"
 )
 
@@ -38,6 +43,23 @@ func makeExampleEndpoint(f ExampleService) endpoint.Endpoint {
 	}
 }
 
+func inlineEndpointBuilder(endpoints Endpoints, f ExampleService) {
+	endpoints.ExampleEndpoint = makeExampleEndpoint(f)
+}
+
+func inlineMiddlewareBuilder(endpoints Endpoints, mw []endpoint.Middleware, idx int) {
+	endpoints.ExampleEndpoint = mw[idx](endpoints.ExampleEndpoint)
+}
+
+func NewEndpoints(f ExampleService, mw ...endpoint.Middleware) Endpoints {
+	var endpoints Endpoints
+	inlineEndpointBuilder(endpoints, f)
+	for idx := len(mw) - 1; idx >= 0; idx-- {
+		inlineMiddlewareBuilder(endpoints, mw, idx)
+	}
+	return endpoints
+}
+
 func inlineHandlerBuilder(m *http.ServeMux, endpoints Endpoints) {
 	m.Handle("/bar", httptransport.NewServer(endpoints.ExampleEndpoint, DecodeExampleRequest, EncodeExampleResponse))
 }
@@ -58,3 +80,42 @@ func EncodeExampleResponse(_ context.Context, w http.ResponseWriter, response in
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	return json.NewEncoder(w).Encode(response)
 }
+
+func inlineSubscriberBuilder(subscribers map[string]*amqptransport.Subscriber, endpoints Endpoints, options []amqptransport.SubscriberOption) {
+	subscribers["bar"] = amqptransport.NewSubscriber(endpoints.ExampleEndpoint, DecodeAMQPExampleRequest, amqptransport.EncodeJSONResponse, options...)
+}
+
+func NewAMQPSubscribers(endpoints Endpoints, options ...amqptransport.SubscriberOption) map[string]*amqptransport.Subscriber {
+	subscribers := map[string]*amqptransport.Subscriber{}
+	inlineSubscriberBuilder(subscribers, endpoints, options)
+	return subscribers
+}
+
+func DecodeAMQPExampleRequest(_ context.Context, d *amqp.Delivery) (interface{}, error) {
+	var req ExampleRequest
+	err := json.Unmarshal(d.Body, &req)
+	return req, err
+}
+
+func inlineGRPCServerBuilder(servers map[string]*grpctransport.Server, endpoints Endpoints, options []grpctransport.ServerOption) {
+	servers["bar"] = grpctransport.NewServer(endpoints.ExampleEndpoint, DecodeGRPCExampleRequest, EncodeGRPCExampleResponse, options...)
+}
+
+func NewGRPCServers(endpoints Endpoints, options ...grpctransport.ServerOption) map[string]*grpctransport.Server {
+	servers := map[string]*grpctransport.Server{}
+	inlineGRPCServerBuilder(servers, endpoints, options)
+	return servers
+}
+
+func DecodeGRPCExampleRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req, ok := grpcReq.(*ExampleRequest)
+	if !ok {
+		return nil, fmt.Errorf("unexpected gRPC request %T", grpcReq)
+	}
+	return *req, nil
+}
+
+func EncodeGRPCExampleResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(ExampleResponse)
+	return &resp, nil
+}
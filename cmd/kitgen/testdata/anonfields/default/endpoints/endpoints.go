@@ -26,3 +26,12 @@ func MakeFooEndpoint(s service.Service) endpoint.Endpoint {
 type Endpoints struct {
 	Foo endpoint.Endpoint
 }
+
+func NewEndpoints(s service.Service, mw ...endpoint.Middleware) Endpoints {
+	var endpoints Endpoints
+	endpoints.Foo = MakeFooEndpoint(s)
+	for idx := len(mw) - 1; idx >= 0; idx-- {
+		endpoints.Foo = mw[idx](endpoints.Foo)
+	}
+	return endpoints
+}
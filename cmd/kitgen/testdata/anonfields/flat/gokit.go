@@ -3,8 +3,12 @@ package foo
 import "context"
 import "encoding/json"
 import "errors"
+import "fmt"
 import "net/http"
+import "github.com/streadway/amqp"
 import "github.com/inturn/kit/endpoint"
+import amqptransport "github.com/inturn/kit/transport/amqp"
+import grpctransport "github.com/inturn/kit/transport/grpc"
 import httptransport "github.com/inturn/kit/transport/http"
 
 type Service struct {
@@ -35,6 +39,14 @@ type Endpoints struct {
 	Foo endpoint.Endpoint
 }
 
+func NewEndpoints(s Service, mw ...endpoint.Middleware) Endpoints {
+	var endpoints Endpoints
+	endpoints.Foo = MakeFooEndpoint(s)
+	for idx := len(mw) - 1; idx >= 0; idx-- {
+		endpoints.Foo = mw[idx](endpoints.Foo)
+	}
+	return endpoints
+}
 func NewHTTPHandler(endpoints Endpoints) http.Handler {
 	m := http.NewServeMux()
 	m.Handle("/foo", httptransport.NewServer(endpoints.Foo, DecodeFooRequest, EncodeFooResponse))
@@ -49,3 +61,29 @@ func EncodeFooResponse(_ context.Context, w http.ResponseWriter, response interf
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	return json.NewEncoder(w).Encode(response)
 }
+func NewAMQPSubscribers(endpoints Endpoints, options ...amqptransport.SubscriberOption) map[string]*amqptransport.Subscriber {
+	subscribers := map[string]*amqptransport.Subscriber{}
+	subscribers["foo"] = amqptransport.NewSubscriber(endpoints.Foo, DecodeAMQPFooRequest, amqptransport.EncodeJSONResponse, options...)
+	return subscribers
+}
+func DecodeAMQPFooRequest(_ context.Context, d *amqp.Delivery) (interface{}, error) {
+	var req FooRequest
+	err := json.Unmarshal(d.Body, &req)
+	return req, err
+}
+func NewGRPCServers(endpoints Endpoints, options ...grpctransport.ServerOption) map[string]*grpctransport.Server {
+	servers := map[string]*grpctransport.Server{}
+	servers["Foo"] = grpctransport.NewServer(endpoints.Foo, DecodeGRPCFooRequest, EncodeGRPCFooResponse, options...)
+	return servers
+}
+func DecodeGRPCFooRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req, ok := grpcReq.(*FooRequest)
+	if !ok {
+		return nil, fmt.Errorf("unexpected gRPC request %T", grpcReq)
+	}
+	return *req, nil
+}
+func EncodeGRPCFooResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(FooResponse)
+	return &resp, nil
+}
@@ -0,0 +1,21 @@
+package amqp
+
+import "context"
+import "encoding/json"
+
+import "github.com/streadway/amqp"
+
+import amqptransport "github.com/inturn/kit/transport/amqp"
+
+import "github.com/inturn/kit/cmd/kitgen/testdata/foo/default/endpoints"
+
+func NewAMQPSubscribers(endpoints endpoints.Endpoints, options ...amqptransport.SubscriberOption) map[string]*amqptransport.Subscriber {
+	subscribers := map[string]*amqptransport.Subscriber{}
+	subscribers["bar"] = amqptransport.NewSubscriber(endpoints.Bar, DecodeAMQPBarRequest, amqptransport.EncodeJSONResponse, options...)
+	return subscribers
+}
+func DecodeAMQPBarRequest(_ context.Context, d *amqp.Delivery) (interface{}, error) {
+	var req endpoints.BarRequest
+	err := json.Unmarshal(d.Body, &req)
+	return req, err
+}
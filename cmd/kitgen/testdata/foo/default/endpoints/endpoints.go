@@ -26,3 +26,12 @@ func MakeBarEndpoint(f service.FooService) endpoint.Endpoint {
 type Endpoints struct {
 	Bar endpoint.Endpoint
 }
+
+func NewEndpoints(f service.FooService, mw ...endpoint.Middleware) Endpoints {
+	var endpoints Endpoints
+	endpoints.Bar = MakeBarEndpoint(f)
+	for idx := len(mw) - 1; idx >= 0; idx-- {
+		endpoints.Bar = mw[idx](endpoints.Bar)
+	}
+	return endpoints
+}
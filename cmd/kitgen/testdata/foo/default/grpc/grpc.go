@@ -0,0 +1,26 @@
+package grpc
+
+import "context"
+
+import "fmt"
+
+import grpctransport "github.com/inturn/kit/transport/grpc"
+
+import "github.com/inturn/kit/cmd/kitgen/testdata/foo/default/endpoints"
+
+func NewGRPCServers(endpoints endpoints.Endpoints, options ...grpctransport.ServerOption) map[string]*grpctransport.Server {
+	servers := map[string]*grpctransport.Server{}
+	servers["Bar"] = grpctransport.NewServer(endpoints.Bar, DecodeGRPCBarRequest, EncodeGRPCBarResponse, options...)
+	return servers
+}
+func DecodeGRPCBarRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req, ok := grpcReq.(*endpoints.BarRequest)
+	if !ok {
+		return nil, fmt.Errorf("unexpected gRPC request %T", grpcReq)
+	}
+	return *req, nil
+}
+func EncodeGRPCBarResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(endpoints.BarResponse)
+	return &resp, nil
+}
@@ -3,8 +3,12 @@ package foo
 import "context"
 import "encoding/json"
 import "errors"
+import "fmt"
 import "net/http"
+import "github.com/streadway/amqp"
 import "github.com/inturn/kit/endpoint"
+import amqptransport "github.com/inturn/kit/transport/amqp"
+import grpctransport "github.com/inturn/kit/transport/grpc"
 import httptransport "github.com/inturn/kit/transport/http"
 
 type FooService struct {
@@ -35,6 +39,14 @@ type Endpoints struct {
 	Bar endpoint.Endpoint
 }
 
+func NewEndpoints(f FooService, mw ...endpoint.Middleware) Endpoints {
+	var endpoints Endpoints
+	endpoints.Bar = MakeBarEndpoint(f)
+	for idx := len(mw) - 1; idx >= 0; idx-- {
+		endpoints.Bar = mw[idx](endpoints.Bar)
+	}
+	return endpoints
+}
 func NewHTTPHandler(endpoints Endpoints) http.Handler {
 	m := http.NewServeMux()
 	m.Handle("/bar", httptransport.NewServer(endpoints.Bar, DecodeBarRequest, EncodeBarResponse))
@@ -49,3 +61,29 @@ func EncodeBarResponse(_ context.Context, w http.ResponseWriter, response interf
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	return json.NewEncoder(w).Encode(response)
 }
+func NewAMQPSubscribers(endpoints Endpoints, options ...amqptransport.SubscriberOption) map[string]*amqptransport.Subscriber {
+	subscribers := map[string]*amqptransport.Subscriber{}
+	subscribers["bar"] = amqptransport.NewSubscriber(endpoints.Bar, DecodeAMQPBarRequest, amqptransport.EncodeJSONResponse, options...)
+	return subscribers
+}
+func DecodeAMQPBarRequest(_ context.Context, d *amqp.Delivery) (interface{}, error) {
+	var req BarRequest
+	err := json.Unmarshal(d.Body, &req)
+	return req, err
+}
+func NewGRPCServers(endpoints Endpoints, options ...grpctransport.ServerOption) map[string]*grpctransport.Server {
+	servers := map[string]*grpctransport.Server{}
+	servers["Bar"] = grpctransport.NewServer(endpoints.Bar, DecodeGRPCBarRequest, EncodeGRPCBarResponse, options...)
+	return servers
+}
+func DecodeGRPCBarRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req, ok := grpcReq.(*BarRequest)
+	if !ok {
+		return nil, fmt.Errorf("unexpected gRPC request %T", grpcReq)
+	}
+	return *req, nil
+}
+func EncodeGRPCBarResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(BarResponse)
+	return &resp, nil
+}
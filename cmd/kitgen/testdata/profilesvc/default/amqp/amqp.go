@@ -0,0 +1,69 @@
+package amqp
+
+import "context"
+import "encoding/json"
+
+import "github.com/streadway/amqp"
+
+import amqptransport "github.com/inturn/kit/transport/amqp"
+
+import "github.com/inturn/kit/cmd/kitgen/testdata/profilesvc/default/endpoints"
+
+func NewAMQPSubscribers(endpoints endpoints.Endpoints, options ...amqptransport.SubscriberOption) map[string]*amqptransport.Subscriber {
+	subscribers := map[string]*amqptransport.Subscriber{}
+	subscribers["postprofile"] = amqptransport.NewSubscriber(endpoints.PostProfile, DecodeAMQPPostProfileRequest, amqptransport.EncodeJSONResponse, options...)
+	subscribers["getprofile"] = amqptransport.NewSubscriber(endpoints.GetProfile, DecodeAMQPGetProfileRequest, amqptransport.EncodeJSONResponse, options...)
+	subscribers["putprofile"] = amqptransport.NewSubscriber(endpoints.PutProfile, DecodeAMQPPutProfileRequest, amqptransport.EncodeJSONResponse, options...)
+	subscribers["patchprofile"] = amqptransport.NewSubscriber(endpoints.PatchProfile, DecodeAMQPPatchProfileRequest, amqptransport.EncodeJSONResponse, options...)
+	subscribers["deleteprofile"] = amqptransport.NewSubscriber(endpoints.DeleteProfile, DecodeAMQPDeleteProfileRequest, amqptransport.EncodeJSONResponse, options...)
+	subscribers["getaddresses"] = amqptransport.NewSubscriber(endpoints.GetAddresses, DecodeAMQPGetAddressesRequest, amqptransport.EncodeJSONResponse, options...)
+	subscribers["getaddress"] = amqptransport.NewSubscriber(endpoints.GetAddress, DecodeAMQPGetAddressRequest, amqptransport.EncodeJSONResponse, options...)
+	subscribers["postaddress"] = amqptransport.NewSubscriber(endpoints.PostAddress, DecodeAMQPPostAddressRequest, amqptransport.EncodeJSONResponse, options...)
+	subscribers["deleteaddress"] = amqptransport.NewSubscriber(endpoints.DeleteAddress, DecodeAMQPDeleteAddressRequest, amqptransport.EncodeJSONResponse, options...)
+	return subscribers
+}
+func DecodeAMQPPostProfileRequest(_ context.Context, d *amqp.Delivery) (interface{}, error) {
+	var req endpoints.PostProfileRequest
+	err := json.Unmarshal(d.Body, &req)
+	return req, err
+}
+func DecodeAMQPGetProfileRequest(_ context.Context, d *amqp.Delivery) (interface{}, error) {
+	var req endpoints.GetProfileRequest
+	err := json.Unmarshal(d.Body, &req)
+	return req, err
+}
+func DecodeAMQPPutProfileRequest(_ context.Context, d *amqp.Delivery) (interface{}, error) {
+	var req endpoints.PutProfileRequest
+	err := json.Unmarshal(d.Body, &req)
+	return req, err
+}
+func DecodeAMQPPatchProfileRequest(_ context.Context, d *amqp.Delivery) (interface{}, error) {
+	var req endpoints.PatchProfileRequest
+	err := json.Unmarshal(d.Body, &req)
+	return req, err
+}
+func DecodeAMQPDeleteProfileRequest(_ context.Context, d *amqp.Delivery) (interface{}, error) {
+	var req endpoints.DeleteProfileRequest
+	err := json.Unmarshal(d.Body, &req)
+	return req, err
+}
+func DecodeAMQPGetAddressesRequest(_ context.Context, d *amqp.Delivery) (interface{}, error) {
+	var req endpoints.GetAddressesRequest
+	err := json.Unmarshal(d.Body, &req)
+	return req, err
+}
+func DecodeAMQPGetAddressRequest(_ context.Context, d *amqp.Delivery) (interface{}, error) {
+	var req endpoints.GetAddressRequest
+	err := json.Unmarshal(d.Body, &req)
+	return req, err
+}
+func DecodeAMQPPostAddressRequest(_ context.Context, d *amqp.Delivery) (interface{}, error) {
+	var req endpoints.PostAddressRequest
+	err := json.Unmarshal(d.Body, &req)
+	return req, err
+}
+func DecodeAMQPDeleteAddressRequest(_ context.Context, d *amqp.Delivery) (interface{}, error) {
+	var req endpoints.DeleteAddressRequest
+	err := json.Unmarshal(d.Body, &req)
+	return req, err
+}
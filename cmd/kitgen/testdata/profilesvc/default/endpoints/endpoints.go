@@ -160,3 +160,28 @@ type Endpoints struct {
 	PostAddress   endpoint.Endpoint
 	DeleteAddress endpoint.Endpoint
 }
+
+func NewEndpoints(s service.Service, mw ...endpoint.Middleware) Endpoints {
+	var endpoints Endpoints
+	endpoints.PostProfile = MakePostProfileEndpoint(s)
+	endpoints.GetProfile = MakeGetProfileEndpoint(s)
+	endpoints.PutProfile = MakePutProfileEndpoint(s)
+	endpoints.PatchProfile = MakePatchProfileEndpoint(s)
+	endpoints.DeleteProfile = MakeDeleteProfileEndpoint(s)
+	endpoints.GetAddresses = MakeGetAddressesEndpoint(s)
+	endpoints.GetAddress = MakeGetAddressEndpoint(s)
+	endpoints.PostAddress = MakePostAddressEndpoint(s)
+	endpoints.DeleteAddress = MakeDeleteAddressEndpoint(s)
+	for idx := len(mw) - 1; idx >= 0; idx-- {
+		endpoints.PostProfile = mw[idx](endpoints.PostProfile)
+		endpoints.GetProfile = mw[idx](endpoints.GetProfile)
+		endpoints.PutProfile = mw[idx](endpoints.PutProfile)
+		endpoints.PatchProfile = mw[idx](endpoints.PatchProfile)
+		endpoints.DeleteProfile = mw[idx](endpoints.DeleteProfile)
+		endpoints.GetAddresses = mw[idx](endpoints.GetAddresses)
+		endpoints.GetAddress = mw[idx](endpoints.GetAddress)
+		endpoints.PostAddress = mw[idx](endpoints.PostAddress)
+		endpoints.DeleteAddress = mw[idx](endpoints.DeleteAddress)
+	}
+	return endpoints
+}
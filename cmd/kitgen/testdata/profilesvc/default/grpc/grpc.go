@@ -0,0 +1,122 @@
+package grpc
+
+import "context"
+
+import "fmt"
+
+import grpctransport "github.com/inturn/kit/transport/grpc"
+
+import "github.com/inturn/kit/cmd/kitgen/testdata/profilesvc/default/endpoints"
+
+func NewGRPCServers(endpoints endpoints.Endpoints, options ...grpctransport.ServerOption) map[string]*grpctransport.Server {
+	servers := map[string]*grpctransport.Server{}
+	servers["PostProfile"] = grpctransport.NewServer(endpoints.PostProfile, DecodeGRPCPostProfileRequest, EncodeGRPCPostProfileResponse, options...)
+	servers["GetProfile"] = grpctransport.NewServer(endpoints.GetProfile, DecodeGRPCGetProfileRequest, EncodeGRPCGetProfileResponse, options...)
+	servers["PutProfile"] = grpctransport.NewServer(endpoints.PutProfile, DecodeGRPCPutProfileRequest, EncodeGRPCPutProfileResponse, options...)
+	servers["PatchProfile"] = grpctransport.NewServer(endpoints.PatchProfile, DecodeGRPCPatchProfileRequest, EncodeGRPCPatchProfileResponse, options...)
+	servers["DeleteProfile"] = grpctransport.NewServer(endpoints.DeleteProfile, DecodeGRPCDeleteProfileRequest, EncodeGRPCDeleteProfileResponse, options...)
+	servers["GetAddresses"] = grpctransport.NewServer(endpoints.GetAddresses, DecodeGRPCGetAddressesRequest, EncodeGRPCGetAddressesResponse, options...)
+	servers["GetAddress"] = grpctransport.NewServer(endpoints.GetAddress, DecodeGRPCGetAddressRequest, EncodeGRPCGetAddressResponse, options...)
+	servers["PostAddress"] = grpctransport.NewServer(endpoints.PostAddress, DecodeGRPCPostAddressRequest, EncodeGRPCPostAddressResponse, options...)
+	servers["DeleteAddress"] = grpctransport.NewServer(endpoints.DeleteAddress, DecodeGRPCDeleteAddressRequest, EncodeGRPCDeleteAddressResponse, options...)
+	return servers
+}
+func DecodeGRPCPostProfileRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req, ok := grpcReq.(*endpoints.PostProfileRequest)
+	if !ok {
+		return nil, fmt.Errorf("unexpected gRPC request %T", grpcReq)
+	}
+	return *req, nil
+}
+func EncodeGRPCPostProfileResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(endpoints.PostProfileResponse)
+	return &resp, nil
+}
+func DecodeGRPCGetProfileRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req, ok := grpcReq.(*endpoints.GetProfileRequest)
+	if !ok {
+		return nil, fmt.Errorf("unexpected gRPC request %T", grpcReq)
+	}
+	return *req, nil
+}
+func EncodeGRPCGetProfileResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(endpoints.GetProfileResponse)
+	return &resp, nil
+}
+func DecodeGRPCPutProfileRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req, ok := grpcReq.(*endpoints.PutProfileRequest)
+	if !ok {
+		return nil, fmt.Errorf("unexpected gRPC request %T", grpcReq)
+	}
+	return *req, nil
+}
+func EncodeGRPCPutProfileResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(endpoints.PutProfileResponse)
+	return &resp, nil
+}
+func DecodeGRPCPatchProfileRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req, ok := grpcReq.(*endpoints.PatchProfileRequest)
+	if !ok {
+		return nil, fmt.Errorf("unexpected gRPC request %T", grpcReq)
+	}
+	return *req, nil
+}
+func EncodeGRPCPatchProfileResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(endpoints.PatchProfileResponse)
+	return &resp, nil
+}
+func DecodeGRPCDeleteProfileRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req, ok := grpcReq.(*endpoints.DeleteProfileRequest)
+	if !ok {
+		return nil, fmt.Errorf("unexpected gRPC request %T", grpcReq)
+	}
+	return *req, nil
+}
+func EncodeGRPCDeleteProfileResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(endpoints.DeleteProfileResponse)
+	return &resp, nil
+}
+func DecodeGRPCGetAddressesRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req, ok := grpcReq.(*endpoints.GetAddressesRequest)
+	if !ok {
+		return nil, fmt.Errorf("unexpected gRPC request %T", grpcReq)
+	}
+	return *req, nil
+}
+func EncodeGRPCGetAddressesResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(endpoints.GetAddressesResponse)
+	return &resp, nil
+}
+func DecodeGRPCGetAddressRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req, ok := grpcReq.(*endpoints.GetAddressRequest)
+	if !ok {
+		return nil, fmt.Errorf("unexpected gRPC request %T", grpcReq)
+	}
+	return *req, nil
+}
+func EncodeGRPCGetAddressResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(endpoints.GetAddressResponse)
+	return &resp, nil
+}
+func DecodeGRPCPostAddressRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req, ok := grpcReq.(*endpoints.PostAddressRequest)
+	if !ok {
+		return nil, fmt.Errorf("unexpected gRPC request %T", grpcReq)
+	}
+	return *req, nil
+}
+func EncodeGRPCPostAddressResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(endpoints.PostAddressResponse)
+	return &resp, nil
+}
+func DecodeGRPCDeleteAddressRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req, ok := grpcReq.(*endpoints.DeleteAddressRequest)
+	if !ok {
+		return nil, fmt.Errorf("unexpected gRPC request %T", grpcReq)
+	}
+	return *req, nil
+}
+func EncodeGRPCDeleteAddressResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(endpoints.DeleteAddressResponse)
+	return &resp, nil
+}
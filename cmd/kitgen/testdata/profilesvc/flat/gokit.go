@@ -3,8 +3,12 @@ package profilesvc
 import "context"
 import "encoding/json"
 import "errors"
+import "fmt"
 import "net/http"
+import "github.com/streadway/amqp"
 import "github.com/inturn/kit/endpoint"
+import amqptransport "github.com/inturn/kit/transport/amqp"
+import grpctransport "github.com/inturn/kit/transport/grpc"
 import httptransport "github.com/inturn/kit/transport/http"
 
 type Profile struct {
@@ -202,6 +206,30 @@ type Endpoints struct {
 	DeleteAddress endpoint.Endpoint
 }
 
+func NewEndpoints(s Service, mw ...endpoint.Middleware) Endpoints {
+	var endpoints Endpoints
+	endpoints.PostProfile = MakePostProfileEndpoint(s)
+	endpoints.GetProfile = MakeGetProfileEndpoint(s)
+	endpoints.PutProfile = MakePutProfileEndpoint(s)
+	endpoints.PatchProfile = MakePatchProfileEndpoint(s)
+	endpoints.DeleteProfile = MakeDeleteProfileEndpoint(s)
+	endpoints.GetAddresses = MakeGetAddressesEndpoint(s)
+	endpoints.GetAddress = MakeGetAddressEndpoint(s)
+	endpoints.PostAddress = MakePostAddressEndpoint(s)
+	endpoints.DeleteAddress = MakeDeleteAddressEndpoint(s)
+	for idx := len(mw) - 1; idx >= 0; idx-- {
+		endpoints.PostProfile = mw[idx](endpoints.PostProfile)
+		endpoints.GetProfile = mw[idx](endpoints.GetProfile)
+		endpoints.PutProfile = mw[idx](endpoints.PutProfile)
+		endpoints.PatchProfile = mw[idx](endpoints.PatchProfile)
+		endpoints.DeleteProfile = mw[idx](endpoints.DeleteProfile)
+		endpoints.GetAddresses = mw[idx](endpoints.GetAddresses)
+		endpoints.GetAddress = mw[idx](endpoints.GetAddress)
+		endpoints.PostAddress = mw[idx](endpoints.PostAddress)
+		endpoints.DeleteAddress = mw[idx](endpoints.DeleteAddress)
+	}
+	return endpoints
+}
 func NewHTTPHandler(endpoints Endpoints) http.Handler {
 	m := http.NewServeMux()
 	m.Handle("/postprofile", httptransport.NewServer(endpoints.PostProfile, DecodePostProfileRequest, EncodePostProfileResponse))
@@ -296,3 +324,173 @@ func EncodeDeleteAddressResponse(_ context.Context, w http.ResponseWriter, respo
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	return json.NewEncoder(w).Encode(response)
 }
+func NewAMQPSubscribers(endpoints Endpoints, options ...amqptransport.SubscriberOption) map[string]*amqptransport.Subscriber {
+	subscribers := map[string]*amqptransport.Subscriber{}
+	subscribers["postprofile"] = amqptransport.NewSubscriber(endpoints.PostProfile, DecodeAMQPPostProfileRequest, amqptransport.EncodeJSONResponse, options...)
+	subscribers["getprofile"] = amqptransport.NewSubscriber(endpoints.GetProfile, DecodeAMQPGetProfileRequest, amqptransport.EncodeJSONResponse, options...)
+	subscribers["putprofile"] = amqptransport.NewSubscriber(endpoints.PutProfile, DecodeAMQPPutProfileRequest, amqptransport.EncodeJSONResponse, options...)
+	subscribers["patchprofile"] = amqptransport.NewSubscriber(endpoints.PatchProfile, DecodeAMQPPatchProfileRequest, amqptransport.EncodeJSONResponse, options...)
+	subscribers["deleteprofile"] = amqptransport.NewSubscriber(endpoints.DeleteProfile, DecodeAMQPDeleteProfileRequest, amqptransport.EncodeJSONResponse, options...)
+	subscribers["getaddresses"] = amqptransport.NewSubscriber(endpoints.GetAddresses, DecodeAMQPGetAddressesRequest, amqptransport.EncodeJSONResponse, options...)
+	subscribers["getaddress"] = amqptransport.NewSubscriber(endpoints.GetAddress, DecodeAMQPGetAddressRequest, amqptransport.EncodeJSONResponse, options...)
+	subscribers["postaddress"] = amqptransport.NewSubscriber(endpoints.PostAddress, DecodeAMQPPostAddressRequest, amqptransport.EncodeJSONResponse, options...)
+	subscribers["deleteaddress"] = amqptransport.NewSubscriber(endpoints.DeleteAddress, DecodeAMQPDeleteAddressRequest, amqptransport.EncodeJSONResponse, options...)
+	return subscribers
+}
+func DecodeAMQPPostProfileRequest(_ context.Context, d *amqp.Delivery) (interface{}, error) {
+	var req PostProfileRequest
+	err := json.Unmarshal(d.Body, &req)
+	return req, err
+}
+func DecodeAMQPGetProfileRequest(_ context.Context, d *amqp.Delivery) (interface{}, error) {
+	var req GetProfileRequest
+	err := json.Unmarshal(d.Body, &req)
+	return req, err
+}
+func DecodeAMQPPutProfileRequest(_ context.Context, d *amqp.Delivery) (interface{}, error) {
+	var req PutProfileRequest
+	err := json.Unmarshal(d.Body, &req)
+	return req, err
+}
+func DecodeAMQPPatchProfileRequest(_ context.Context, d *amqp.Delivery) (interface{}, error) {
+	var req PatchProfileRequest
+	err := json.Unmarshal(d.Body, &req)
+	return req, err
+}
+func DecodeAMQPDeleteProfileRequest(_ context.Context, d *amqp.Delivery) (interface{}, error) {
+	var req DeleteProfileRequest
+	err := json.Unmarshal(d.Body, &req)
+	return req, err
+}
+func DecodeAMQPGetAddressesRequest(_ context.Context, d *amqp.Delivery) (interface{}, error) {
+	var req GetAddressesRequest
+	err := json.Unmarshal(d.Body, &req)
+	return req, err
+}
+func DecodeAMQPGetAddressRequest(_ context.Context, d *amqp.Delivery) (interface{}, error) {
+	var req GetAddressRequest
+	err := json.Unmarshal(d.Body, &req)
+	return req, err
+}
+func DecodeAMQPPostAddressRequest(_ context.Context, d *amqp.Delivery) (interface{}, error) {
+	var req PostAddressRequest
+	err := json.Unmarshal(d.Body, &req)
+	return req, err
+}
+func DecodeAMQPDeleteAddressRequest(_ context.Context, d *amqp.Delivery) (interface{}, error) {
+	var req DeleteAddressRequest
+	err := json.Unmarshal(d.Body, &req)
+	return req, err
+}
+func NewGRPCServers(endpoints Endpoints, options ...grpctransport.ServerOption) map[string]*grpctransport.Server {
+	servers := map[string]*grpctransport.Server{}
+	servers["PostProfile"] = grpctransport.NewServer(endpoints.PostProfile, DecodeGRPCPostProfileRequest, EncodeGRPCPostProfileResponse, options...)
+	servers["GetProfile"] = grpctransport.NewServer(endpoints.GetProfile, DecodeGRPCGetProfileRequest, EncodeGRPCGetProfileResponse, options...)
+	servers["PutProfile"] = grpctransport.NewServer(endpoints.PutProfile, DecodeGRPCPutProfileRequest, EncodeGRPCPutProfileResponse, options...)
+	servers["PatchProfile"] = grpctransport.NewServer(endpoints.PatchProfile, DecodeGRPCPatchProfileRequest, EncodeGRPCPatchProfileResponse, options...)
+	servers["DeleteProfile"] = grpctransport.NewServer(endpoints.DeleteProfile, DecodeGRPCDeleteProfileRequest, EncodeGRPCDeleteProfileResponse, options...)
+	servers["GetAddresses"] = grpctransport.NewServer(endpoints.GetAddresses, DecodeGRPCGetAddressesRequest, EncodeGRPCGetAddressesResponse, options...)
+	servers["GetAddress"] = grpctransport.NewServer(endpoints.GetAddress, DecodeGRPCGetAddressRequest, EncodeGRPCGetAddressResponse, options...)
+	servers["PostAddress"] = grpctransport.NewServer(endpoints.PostAddress, DecodeGRPCPostAddressRequest, EncodeGRPCPostAddressResponse, options...)
+	servers["DeleteAddress"] = grpctransport.NewServer(endpoints.DeleteAddress, DecodeGRPCDeleteAddressRequest, EncodeGRPCDeleteAddressResponse, options...)
+	return servers
+}
+func DecodeGRPCPostProfileRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req, ok := grpcReq.(*PostProfileRequest)
+	if !ok {
+		return nil, fmt.Errorf("unexpected gRPC request %T", grpcReq)
+	}
+	return *req, nil
+}
+func EncodeGRPCPostProfileResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(PostProfileResponse)
+	return &resp, nil
+}
+func DecodeGRPCGetProfileRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req, ok := grpcReq.(*GetProfileRequest)
+	if !ok {
+		return nil, fmt.Errorf("unexpected gRPC request %T", grpcReq)
+	}
+	return *req, nil
+}
+func EncodeGRPCGetProfileResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(GetProfileResponse)
+	return &resp, nil
+}
+func DecodeGRPCPutProfileRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req, ok := grpcReq.(*PutProfileRequest)
+	if !ok {
+		return nil, fmt.Errorf("unexpected gRPC request %T", grpcReq)
+	}
+	return *req, nil
+}
+func EncodeGRPCPutProfileResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(PutProfileResponse)
+	return &resp, nil
+}
+func DecodeGRPCPatchProfileRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req, ok := grpcReq.(*PatchProfileRequest)
+	if !ok {
+		return nil, fmt.Errorf("unexpected gRPC request %T", grpcReq)
+	}
+	return *req, nil
+}
+func EncodeGRPCPatchProfileResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(PatchProfileResponse)
+	return &resp, nil
+}
+func DecodeGRPCDeleteProfileRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req, ok := grpcReq.(*DeleteProfileRequest)
+	if !ok {
+		return nil, fmt.Errorf("unexpected gRPC request %T", grpcReq)
+	}
+	return *req, nil
+}
+func EncodeGRPCDeleteProfileResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(DeleteProfileResponse)
+	return &resp, nil
+}
+func DecodeGRPCGetAddressesRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req, ok := grpcReq.(*GetAddressesRequest)
+	if !ok {
+		return nil, fmt.Errorf("unexpected gRPC request %T", grpcReq)
+	}
+	return *req, nil
+}
+func EncodeGRPCGetAddressesResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(GetAddressesResponse)
+	return &resp, nil
+}
+func DecodeGRPCGetAddressRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req, ok := grpcReq.(*GetAddressRequest)
+	if !ok {
+		return nil, fmt.Errorf("unexpected gRPC request %T", grpcReq)
+	}
+	return *req, nil
+}
+func EncodeGRPCGetAddressResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(GetAddressResponse)
+	return &resp, nil
+}
+func DecodeGRPCPostAddressRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req, ok := grpcReq.(*PostAddressRequest)
+	if !ok {
+		return nil, fmt.Errorf("unexpected gRPC request %T", grpcReq)
+	}
+	return *req, nil
+}
+func EncodeGRPCPostAddressResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(PostAddressResponse)
+	return &resp, nil
+}
+func DecodeGRPCDeleteAddressRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req, ok := grpcReq.(*DeleteAddressRequest)
+	if !ok {
+		return nil, fmt.Errorf("unexpected gRPC request %T", grpcReq)
+	}
+	return *req, nil
+}
+func EncodeGRPCDeleteAddressResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(DeleteAddressResponse)
+	return &resp, nil
+}
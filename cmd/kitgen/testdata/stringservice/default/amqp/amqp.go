@@ -0,0 +1,27 @@
+package amqp
+
+import "context"
+import "encoding/json"
+
+import "github.com/streadway/amqp"
+
+import amqptransport "github.com/inturn/kit/transport/amqp"
+
+import "github.com/inturn/kit/cmd/kitgen/testdata/stringservice/default/endpoints"
+
+func NewAMQPSubscribers(endpoints endpoints.Endpoints, options ...amqptransport.SubscriberOption) map[string]*amqptransport.Subscriber {
+	subscribers := map[string]*amqptransport.Subscriber{}
+	subscribers["concat"] = amqptransport.NewSubscriber(endpoints.Concat, DecodeAMQPConcatRequest, amqptransport.EncodeJSONResponse, options...)
+	subscribers["count"] = amqptransport.NewSubscriber(endpoints.Count, DecodeAMQPCountRequest, amqptransport.EncodeJSONResponse, options...)
+	return subscribers
+}
+func DecodeAMQPConcatRequest(_ context.Context, d *amqp.Delivery) (interface{}, error) {
+	var req endpoints.ConcatRequest
+	err := json.Unmarshal(d.Body, &req)
+	return req, err
+}
+func DecodeAMQPCountRequest(_ context.Context, d *amqp.Delivery) (interface{}, error) {
+	var req endpoints.CountRequest
+	err := json.Unmarshal(d.Body, &req)
+	return req, err
+}
@@ -42,3 +42,14 @@ type Endpoints struct {
 	Concat endpoint.Endpoint
 	Count  endpoint.Endpoint
 }
+
+func NewEndpoints(s service.Service, mw ...endpoint.Middleware) Endpoints {
+	var endpoints Endpoints
+	endpoints.Concat = MakeConcatEndpoint(s)
+	endpoints.Count = MakeCountEndpoint(s)
+	for idx := len(mw) - 1; idx >= 0; idx-- {
+		endpoints.Concat = mw[idx](endpoints.Concat)
+		endpoints.Count = mw[idx](endpoints.Count)
+	}
+	return endpoints
+}
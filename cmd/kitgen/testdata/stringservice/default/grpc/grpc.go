@@ -0,0 +1,38 @@
+package grpc
+
+import "context"
+
+import "fmt"
+
+import grpctransport "github.com/inturn/kit/transport/grpc"
+
+import "github.com/inturn/kit/cmd/kitgen/testdata/stringservice/default/endpoints"
+
+func NewGRPCServers(endpoints endpoints.Endpoints, options ...grpctransport.ServerOption) map[string]*grpctransport.Server {
+	servers := map[string]*grpctransport.Server{}
+	servers["Concat"] = grpctransport.NewServer(endpoints.Concat, DecodeGRPCConcatRequest, EncodeGRPCConcatResponse, options...)
+	servers["Count"] = grpctransport.NewServer(endpoints.Count, DecodeGRPCCountRequest, EncodeGRPCCountResponse, options...)
+	return servers
+}
+func DecodeGRPCConcatRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req, ok := grpcReq.(*endpoints.ConcatRequest)
+	if !ok {
+		return nil, fmt.Errorf("unexpected gRPC request %T", grpcReq)
+	}
+	return *req, nil
+}
+func EncodeGRPCConcatResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(endpoints.ConcatResponse)
+	return &resp, nil
+}
+func DecodeGRPCCountRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req, ok := grpcReq.(*endpoints.CountRequest)
+	if !ok {
+		return nil, fmt.Errorf("unexpected gRPC request %T", grpcReq)
+	}
+	return *req, nil
+}
+func EncodeGRPCCountResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(endpoints.CountResponse)
+	return &resp, nil
+}
@@ -3,8 +3,12 @@ package foo
 import "context"
 import "encoding/json"
 import "errors"
+import "fmt"
 import "net/http"
+import "github.com/streadway/amqp"
 import "github.com/inturn/kit/endpoint"
+import amqptransport "github.com/inturn/kit/transport/amqp"
+import grpctransport "github.com/inturn/kit/transport/grpc"
 import httptransport "github.com/inturn/kit/transport/http"
 
 type Service struct {
@@ -54,6 +58,16 @@ type Endpoints struct {
 	Count  endpoint.Endpoint
 }
 
+func NewEndpoints(s Service, mw ...endpoint.Middleware) Endpoints {
+	var endpoints Endpoints
+	endpoints.Concat = MakeConcatEndpoint(s)
+	endpoints.Count = MakeCountEndpoint(s)
+	for idx := len(mw) - 1; idx >= 0; idx-- {
+		endpoints.Concat = mw[idx](endpoints.Concat)
+		endpoints.Count = mw[idx](endpoints.Count)
+	}
+	return endpoints
+}
 func NewHTTPHandler(endpoints Endpoints) http.Handler {
 	m := http.NewServeMux()
 	m.Handle("/concat", httptransport.NewServer(endpoints.Concat, DecodeConcatRequest, EncodeConcatResponse))
@@ -78,3 +92,47 @@ func EncodeCountResponse(_ context.Context, w http.ResponseWriter, response inte
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	return json.NewEncoder(w).Encode(response)
 }
+func NewAMQPSubscribers(endpoints Endpoints, options ...amqptransport.SubscriberOption) map[string]*amqptransport.Subscriber {
+	subscribers := map[string]*amqptransport.Subscriber{}
+	subscribers["concat"] = amqptransport.NewSubscriber(endpoints.Concat, DecodeAMQPConcatRequest, amqptransport.EncodeJSONResponse, options...)
+	subscribers["count"] = amqptransport.NewSubscriber(endpoints.Count, DecodeAMQPCountRequest, amqptransport.EncodeJSONResponse, options...)
+	return subscribers
+}
+func DecodeAMQPConcatRequest(_ context.Context, d *amqp.Delivery) (interface{}, error) {
+	var req ConcatRequest
+	err := json.Unmarshal(d.Body, &req)
+	return req, err
+}
+func DecodeAMQPCountRequest(_ context.Context, d *amqp.Delivery) (interface{}, error) {
+	var req CountRequest
+	err := json.Unmarshal(d.Body, &req)
+	return req, err
+}
+func NewGRPCServers(endpoints Endpoints, options ...grpctransport.ServerOption) map[string]*grpctransport.Server {
+	servers := map[string]*grpctransport.Server{}
+	servers["Concat"] = grpctransport.NewServer(endpoints.Concat, DecodeGRPCConcatRequest, EncodeGRPCConcatResponse, options...)
+	servers["Count"] = grpctransport.NewServer(endpoints.Count, DecodeGRPCCountRequest, EncodeGRPCCountResponse, options...)
+	return servers
+}
+func DecodeGRPCConcatRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req, ok := grpcReq.(*ConcatRequest)
+	if !ok {
+		return nil, fmt.Errorf("unexpected gRPC request %T", grpcReq)
+	}
+	return *req, nil
+}
+func EncodeGRPCConcatResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(ConcatResponse)
+	return &resp, nil
+}
+func DecodeGRPCCountRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req, ok := grpcReq.(*CountRequest)
+	if !ok {
+		return nil, fmt.Errorf("unexpected gRPC request %T", grpcReq)
+	}
+	return *req, nil
+}
+func EncodeGRPCCountResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(CountResponse)
+	return &resp, nil
+}
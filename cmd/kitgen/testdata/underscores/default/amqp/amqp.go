@@ -0,0 +1,21 @@
+package amqp
+
+import "context"
+import "encoding/json"
+
+import "github.com/streadway/amqp"
+
+import amqptransport "github.com/inturn/kit/transport/amqp"
+
+import "github.com/inturn/kit/cmd/kitgen/testdata/underscores/default/endpoints"
+
+func NewAMQPSubscribers(endpoints endpoints.Endpoints, options ...amqptransport.SubscriberOption) map[string]*amqptransport.Subscriber {
+	subscribers := map[string]*amqptransport.Subscriber{}
+	subscribers["foo"] = amqptransport.NewSubscriber(endpoints.Foo, DecodeAMQPFooRequest, amqptransport.EncodeJSONResponse, options...)
+	return subscribers
+}
+func DecodeAMQPFooRequest(_ context.Context, d *amqp.Delivery) (interface{}, error) {
+	var req endpoints.FooRequest
+	err := json.Unmarshal(d.Body, &req)
+	return req, err
+}
@@ -0,0 +1,26 @@
+package grpc
+
+import "context"
+
+import "fmt"
+
+import grpctransport "github.com/inturn/kit/transport/grpc"
+
+import "github.com/inturn/kit/cmd/kitgen/testdata/underscores/default/endpoints"
+
+func NewGRPCServers(endpoints endpoints.Endpoints, options ...grpctransport.ServerOption) map[string]*grpctransport.Server {
+	servers := map[string]*grpctransport.Server{}
+	servers["Foo"] = grpctransport.NewServer(endpoints.Foo, DecodeGRPCFooRequest, EncodeGRPCFooResponse, options...)
+	return servers
+}
+func DecodeGRPCFooRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req, ok := grpcReq.(*endpoints.FooRequest)
+	if !ok {
+		return nil, fmt.Errorf("unexpected gRPC request %T", grpcReq)
+	}
+	return *req, nil
+}
+func EncodeGRPCFooResponse(_ context.Context, response interface{}) (interface{}, error) {
+	resp := response.(endpoints.FooResponse)
+	return &resp, nil
+}
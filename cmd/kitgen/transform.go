@@ -217,6 +217,30 @@ func addEndpointsStruct(root *ast.File, ifc iface) {
 	root.Decls = append(root.Decls, ifc.endpointsStruct())
 }
 
+func addEndpointsConstructor(root *ast.File, ifc iface) {
+	root.Decls = append(root.Decls, ifc.endpointsConstructor())
+}
+
+func addAMQPSubscribers(root *ast.File, ifc iface) {
+	root.Decls = append(root.Decls, ifc.amqpSubscribers())
+}
+
+func addAMQPDecoder(root *ast.File, meth method) {
+	root.Decls = append(root.Decls, meth.amqpDecoderFunc())
+}
+
+func addGRPCServers(root *ast.File, ifc iface) {
+	root.Decls = append(root.Decls, ifc.grpcServers())
+}
+
+func addGRPCDecoder(root *ast.File, meth method) {
+	root.Decls = append(root.Decls, meth.grpcDecoderFunc())
+}
+
+func addGRPCEncoder(root *ast.File, meth method) {
+	root.Decls = append(root.Decls, meth.grpcEncoderFunc())
+}
+
 func addHTTPHandler(root *ast.File, ifc iface) {
 	root.Decls = append(root.Decls, ifc.httpHandler())
 }
@@ -0,0 +1,27 @@
+// Package config loads the operational settings of a service, e.g. rate
+// limits, circuit breaker thresholds, timeouts and the logging and metrics
+// backends, from a YAML or JSON file and environment variables, so they can
+// be tuned without recompiling the service.
+//
+// Services declare their configuration as a struct, combining the sections
+// provided by this package with their own settings:
+//
+//	type Config struct {
+//		Log      config.Log                `json:"log" yaml:"log"`
+//		Metrics  config.Metrics            `json:"metrics" yaml:"metrics"`
+//		Limiters map[string]config.Limiter `json:"limiters" yaml:"limiters"`
+//		Breakers map[string]config.Breaker `json:"breakers" yaml:"breakers"`
+//		AMQP     config.AMQPSubscriber     `json:"amqp" yaml:"amqp"`
+//	}
+//
+//	var cfg Config
+//	if err := config.Load(&cfg, config.File("orders.yaml"), config.EnvPrefix("ORDERS")); err != nil {
+//		return err
+//	}
+//	logger := cfg.Log.Logger(os.Stderr)
+//	e = cfg.Limiters["create"].Middleware()(e)
+//	e = cfg.Breakers["create"].Middleware("create")(e)
+//
+// ORDERS_LOG_LEVEL=debug then overrides the level set in orders.yaml, and
+// ORDERS_LIMITERS_CREATE_RATE the rate limit of the create endpoint.
+package config
@@ -0,0 +1,338 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Defaulter is implemented by configuration sections setting defaults for
+// the fields left zero after loading.
+type Defaulter interface {
+	SetDefaults()
+}
+
+// Validator is implemented by configuration sections validating their
+// values after defaults were set.
+type Validator interface {
+	Validate() error
+}
+
+// Duration is a time.Duration read from strings like "1.5s" or "300ms".
+type Duration struct {
+	time.Duration
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"1s\": %v", err)
+	}
+	return d.parse(s)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (d *Duration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	return d.parse(s)
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return d.String(), nil
+}
+
+func (d *Duration) parse(s string) error {
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	d.Duration = v
+	return nil
+}
+
+type loadConfig struct {
+	file      string
+	envPrefix string
+	lookupEnv func(string) (string, bool)
+}
+
+// LoadOption sets an optional parameter for Load.
+type LoadOption func(*loadConfig)
+
+// File reads the configuration from path. Files ending in .json are read as
+// JSON, all others as YAML. Unknown keys are an error.
+func File(path string) LoadOption {
+	return func(c *loadConfig) { c.file = path }
+}
+
+// EnvPrefix overrides configuration values with environment variables named
+// after the prefix and the path of the value, e.g. PREFIX_LOG_LEVEL for the
+// level field of the log section. Path elements are the json names of
+// fields and the keys of maps, upper-cased with non-alphanumeric characters
+// replaced by underscores. Maps can only be overridden for keys already
+// present in the file. Slices of strings are read as comma-separated lists.
+func EnvPrefix(prefix string) LoadOption {
+	return func(c *loadConfig) { c.envPrefix = prefix }
+}
+
+// Load fills the struct v points to from the sources given as options, in
+// the order file, environment, then sets defaults and validates the result
+// by calling SetDefaults and Validate on every value implementing Defaulter
+// and Validator.
+func Load(v interface{}, options ...LoadOption) error {
+	c := loadConfig{lookupEnv: os.LookupEnv}
+	for _, option := range options {
+		option(&c)
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("config: Load needs a pointer to a struct, have %T", v)
+	}
+
+	if c.file != "" {
+		if err := readFile(c.file, v); err != nil {
+			return err
+		}
+	}
+	if c.envPrefix != "" {
+		if err := applyEnv(rv.Elem(), c.envPrefix, c.lookupEnv); err != nil {
+			return err
+		}
+	}
+	walk(rv.Elem(), "", func(v reflect.Value, _ string) error {
+		if d, ok := v.Addr().Interface().(Defaulter); ok {
+			d.SetDefaults()
+		}
+		return nil
+	})
+	return walk(rv.Elem(), "", func(v reflect.Value, path string) error {
+		if val, ok := v.Addr().Interface().(Validator); ok {
+			if err := val.Validate(); err != nil {
+				if path == "" {
+					return fmt.Errorf("config: %v", err)
+				}
+				return fmt.Errorf("config: %s: %v", path, err)
+			}
+		}
+		return nil
+	})
+}
+
+func readFile(path string, v interface{}) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config: %v", err)
+	}
+	if filepath.Ext(path) == ".json" {
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(v)
+	} else {
+		err = yaml.UnmarshalStrict(b, v)
+	}
+	if err != nil {
+		return fmt.Errorf("config: %s: %v", path, err)
+	}
+	return nil
+}
+
+var durationType = reflect.TypeOf(Duration{})
+
+// walk calls f with every addressable struct value reachable from v,
+// children first, and the dotted path to it. Map values are copied, passed
+// to f and stored back.
+func walk(v reflect.Value, path string, f func(v reflect.Value, path string) error) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return walk(v.Elem(), path, f)
+
+	case reflect.Struct:
+		if v.Type() == durationType {
+			return nil
+		}
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if field.PkgPath != "" { // unexported
+				continue
+			}
+			if err := walk(v.Field(i), join(path, fieldName(field), "."), f); err != nil {
+				return err
+			}
+		}
+		return f(v, path)
+
+	case reflect.Map:
+		for _, k := range v.MapKeys() {
+			if k.Kind() != reflect.String {
+				return nil
+			}
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(v.MapIndex(k))
+			if err := walk(elem, join(path, k.String(), "."), f); err != nil {
+				return err
+			}
+			v.SetMapIndex(k, elem)
+		}
+	}
+	return nil
+}
+
+// applyEnv sets the values reachable from v named by environment variables.
+func applyEnv(v reflect.Value, name string, lookup func(string) (string, bool)) error {
+	switch {
+	case v.Kind() == reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return applyEnv(v.Elem(), name, lookup)
+
+	case v.Kind() == reflect.Struct && v.Type() != durationType:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			if err := applyEnv(v.Field(i), join(name, envName(fieldName(field)), "_"), lookup); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case v.Kind() == reflect.Map:
+		for _, k := range v.MapKeys() {
+			if k.Kind() != reflect.String {
+				return nil
+			}
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(v.MapIndex(k))
+			if err := applyEnv(elem, join(name, envName(k.String()), "_"), lookup); err != nil {
+				return err
+			}
+			v.SetMapIndex(k, elem)
+		}
+		return nil
+	}
+
+	s, ok := lookup(name)
+	if !ok {
+		return nil
+	}
+	if err := setString(v, s); err != nil {
+		return fmt.Errorf("config: %s: %v", name, err)
+	}
+	return nil
+}
+
+// setString parses s into v.
+func setString(v reflect.Value, s string) error {
+	switch {
+	case v.Type() == durationType:
+		return v.Addr().Interface().(*Duration).parse(s)
+	case v.Type() == reflect.TypeOf(time.Duration(0)):
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", v.Type())
+		}
+		var elems []string
+		for _, e := range strings.Split(s, ",") {
+			if e = strings.TrimSpace(e); e != "" {
+				elems = append(elems, e)
+			}
+		}
+		v.Set(reflect.ValueOf(elems).Convert(v.Type()))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// fieldName returns the json name of field.
+func fieldName(field reflect.StructField) string {
+	if name := strings.Split(field.Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+		return name
+	}
+	return field.Name
+}
+
+// envName upper-cases name, separating words by underscores.
+func envName(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		switch {
+		case unicode.IsUpper(r) && i > 0 && unicode.IsLower(rune(name[i-1])):
+			b.WriteByte('_')
+			b.WriteRune(r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(unicode.ToUpper(r))
+		default:
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+func join(prefix, name, sep string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + sep + name
+}
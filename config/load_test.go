@@ -0,0 +1,184 @@
+package config_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/inturn/kit/config"
+)
+
+type testConfig struct {
+	Log      config.Log                `json:"log" yaml:"log"`
+	Limiters map[string]config.Limiter `json:"limiters" yaml:"limiters"`
+	Breaker  config.Breaker            `json:"breaker" yaml:"breaker"`
+	Server   config.HTTPServer         `json:"server" yaml:"server"`
+	Hosts    []string                  `json:"hosts" yaml:"hosts"`
+	Debug    bool                      `json:"debug" yaml:"debug"`
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadYAML(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+log:
+  format: json
+limiters:
+  create:
+    rate: 2.5
+    max_wait: 100ms
+server:
+  read_timeout: 5s
+hosts: [a, b]
+`)
+	var cfg testConfig
+	if err := config.Load(&cfg, config.File(path)); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "json", cfg.Log.Format; want != have {
+		t.Errorf("incorrect log format, want %q, have %q", want, have)
+	}
+	if want, have := "info", cfg.Log.Level; want != have {
+		t.Errorf("incorrect default log level, want %q, have %q", want, have)
+	}
+	create := cfg.Limiters["create"]
+	if want, have := 2.5, create.Rate; want != have {
+		t.Errorf("incorrect rate, want %v, have %v", want, have)
+	}
+	if want, have := 3, create.Burst; want != have {
+		t.Errorf("incorrect default burst, want %d, have %d", want, have)
+	}
+	if want, have := 100*time.Millisecond, create.MaxWait.Duration; want != have {
+		t.Errorf("incorrect max wait, want %v, have %v", want, have)
+	}
+	if want, have := 5*time.Second, cfg.Server.ReadTimeout.Duration; want != have {
+		t.Errorf("incorrect read timeout, want %v, have %v", want, have)
+	}
+	if want, have := uint32(5), cfg.Breaker.ConsecutiveFailures; want != have {
+		t.Errorf("incorrect default consecutive failures, want %d, have %d", want, have)
+	}
+	if want, have := "a,b", strings.Join(cfg.Hosts, ","); want != have {
+		t.Errorf("incorrect hosts, want %q, have %q", want, have)
+	}
+}
+
+func TestLoadJSON(t *testing.T) {
+	path := writeFile(t, "config.json", `{"breaker": {"timeout": "30s", "max_requests": 3}}`)
+	var cfg testConfig
+	if err := config.Load(&cfg, config.File(path)); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := 30*time.Second, cfg.Breaker.Timeout.Duration; want != have {
+		t.Errorf("incorrect timeout, want %v, have %v", want, have)
+	}
+	if want, have := uint32(3), cfg.Breaker.MaxRequests; want != have {
+		t.Errorf("incorrect max requests, want %d, have %d", want, have)
+	}
+}
+
+func TestLoadUnknownKey(t *testing.T) {
+	for _, name := range []string{"config.yaml", "config.json"} {
+		path := writeFile(t, name, `{"lgo": {"level": "debug"}}`)
+		var cfg testConfig
+		if err := config.Load(&cfg, config.File(path)); err == nil {
+			t.Errorf("%s: want error, have nil", name)
+		}
+	}
+}
+
+func TestLoadEnv(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+limiters:
+  create:
+    rate: 1
+`)
+	for k, v := range map[string]string{
+		"TEST_LOG_LEVEL":            "debug",
+		"TEST_LIMITERS_CREATE_RATE": "10",
+		"TEST_LIMITERS_DELETE_RATE": "10",
+		"TEST_SERVER_WRITE_TIMEOUT": "2s",
+		"TEST_BREAKER_MAX_REQUESTS": "4",
+		"TEST_HOSTS":                "x, y",
+		"TEST_DEBUG":                "true",
+	} {
+		os.Setenv(k, v)
+		defer os.Unsetenv(k)
+	}
+
+	var cfg testConfig
+	if err := config.Load(&cfg, config.File(path), config.EnvPrefix("TEST")); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "debug", cfg.Log.Level; want != have {
+		t.Errorf("incorrect log level, want %q, have %q", want, have)
+	}
+	if want, have := 10.0, cfg.Limiters["create"].Rate; want != have {
+		t.Errorf("incorrect rate, want %v, have %v", want, have)
+	}
+	if want, have := 10, cfg.Limiters["create"].Burst; want != have {
+		t.Errorf("incorrect burst, want %d, have %d", want, have)
+	}
+	if _, ok := cfg.Limiters["delete"]; ok {
+		t.Errorf("unexpected limiter delete")
+	}
+	if want, have := 2*time.Second, cfg.Server.WriteTimeout.Duration; want != have {
+		t.Errorf("incorrect write timeout, want %v, have %v", want, have)
+	}
+	if want, have := uint32(4), cfg.Breaker.MaxRequests; want != have {
+		t.Errorf("incorrect max requests, want %d, have %d", want, have)
+	}
+	if want, have := "x,y", strings.Join(cfg.Hosts, ","); want != have {
+		t.Errorf("incorrect hosts, want %q, have %q", want, have)
+	}
+	if !cfg.Debug {
+		t.Errorf("want debug, have false")
+	}
+}
+
+func TestLoadEnvInvalid(t *testing.T) {
+	os.Setenv("TEST_SERVER_READ_TIMEOUT", "soon")
+	defer os.Unsetenv("TEST_SERVER_READ_TIMEOUT")
+
+	var cfg testConfig
+	err := config.Load(&cfg, config.EnvPrefix("TEST"))
+	if err == nil || !strings.Contains(err.Error(), "TEST_SERVER_READ_TIMEOUT") {
+		t.Errorf("want error naming TEST_SERVER_READ_TIMEOUT, have %v", err)
+	}
+}
+
+func TestLoadValidate(t *testing.T) {
+	for content, want := range map[string]string{
+		`{"log": {"level": "verbose"}}`:          `config: log: unknown log level "verbose"`,
+		`{"limiters": {"create": {"rate": -1}}}`: "config: limiters.create: rate must not be negative",
+		`{"breaker": {"interval": "-1s"}}`:       "config: breaker: interval must not be negative",
+	} {
+		path := writeFile(t, "config.json", content)
+		var cfg testConfig
+		err := config.Load(&cfg, config.File(path))
+		if err == nil || err.Error() != want {
+			t.Errorf("want error %q, have %v", want, err)
+		}
+	}
+}
+
+func TestLoadNotStructPointer(t *testing.T) {
+	var cfg testConfig
+	if err := config.Load(cfg); err == nil {
+		t.Error("want error, have nil")
+	}
+}
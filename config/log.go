@@ -0,0 +1,70 @@
+package config
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/inturn/kit/log"
+	"github.com/inturn/kit/log/level"
+)
+
+// Log configures the logger of a service.
+type Log struct {
+	// Format is logfmt or json. The default is logfmt.
+	Format string `json:"format" yaml:"format"`
+
+	// Level is the lowest level logged, one of debug, info, warn, error or
+	// none. The default is info.
+	Level string `json:"level" yaml:"level"`
+}
+
+// SetDefaults implements Defaulter.
+func (c *Log) SetDefaults() {
+	if c.Format == "" {
+		c.Format = "logfmt"
+	}
+	if c.Level == "" {
+		c.Level = "info"
+	}
+}
+
+// Validate implements Validator.
+func (c Log) Validate() error {
+	switch c.Format {
+	case "logfmt", "json":
+	default:
+		return fmt.Errorf("unknown log format %q", c.Format)
+	}
+	if _, ok := levels[c.Level]; !ok {
+		return fmt.Errorf("unknown log level %q", c.Level)
+	}
+	return nil
+}
+
+var levels = map[string]level.Option{
+	"debug": level.AllowDebug(),
+	"info":  level.AllowInfo(),
+	"warn":  level.AllowWarn(),
+	"error": level.AllowError(),
+	"none":  level.AllowNone(),
+}
+
+// Logger returns a logger writing timestamped records to w in the
+// configured format, filtered by level.
+func (c Log) Logger(w io.Writer) log.Logger {
+	var logger log.Logger
+	switch c.Format {
+	case "json":
+		logger = log.NewJSONLogger(log.NewSyncWriter(w))
+	default:
+		logger = log.NewLogfmtLogger(log.NewSyncWriter(w))
+	}
+	logger = log.With(logger, "ts", log.DefaultTimestampUTC)
+
+	if allow, ok := levels[c.Level]; ok {
+		logger = level.NewFilter(logger, allow)
+	} else {
+		logger = level.NewFilter(logger, level.AllowInfo())
+	}
+	return logger
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/inturn/kit/metrics/provider"
+)
+
+// Metrics configures the metrics backend of a service.
+type Metrics struct {
+	// Provider is discard, expvar or prometheus. The default is discard.
+	// Backends pushing metrics, like statsd, need more setup than a
+	// configuration file provides and are constructed by hand.
+	Provider string `json:"provider" yaml:"provider"`
+
+	// Namespace and Subsystem prefix the names of Prometheus metrics.
+	Namespace string `json:"namespace" yaml:"namespace"`
+	Subsystem string `json:"subsystem" yaml:"subsystem"`
+}
+
+// SetDefaults implements Defaulter.
+func (c *Metrics) SetDefaults() {
+	if c.Provider == "" {
+		c.Provider = "discard"
+	}
+}
+
+// Validate implements Validator.
+func (c Metrics) Validate() error {
+	switch c.Provider {
+	case "discard", "expvar", "prometheus":
+		return nil
+	}
+	return fmt.Errorf("unknown metrics provider %q", c.Provider)
+}
+
+// NewProvider returns the configured metrics provider.
+func (c Metrics) NewProvider() provider.Provider {
+	switch c.Provider {
+	case "expvar":
+		return provider.NewExpvarProvider()
+	case "prometheus":
+		return provider.NewPrometheusProvider(c.Namespace, c.Subsystem)
+	}
+	return provider.NewDiscardProvider()
+}
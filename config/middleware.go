@@ -0,0 +1,117 @@
+package config
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/sony/gobreaker"
+	"golang.org/x/time/rate"
+
+	"github.com/inturn/kit/circuitbreaker"
+	"github.com/inturn/kit/endpoint"
+	"github.com/inturn/kit/ratelimit"
+)
+
+// Limiter configures a rate limit.
+type Limiter struct {
+	// Rate is the number of requests allowed per second. Zero disables the
+	// limit.
+	Rate float64 `json:"rate" yaml:"rate"`
+
+	// Burst is the number of requests allowed at once. The default is the
+	// rate, rounded up.
+	Burst int `json:"burst" yaml:"burst"`
+
+	// MaxWait is how long requests may be delayed to stay within the limit
+	// before they are rejected. By default, they are rejected right away.
+	MaxWait Duration `json:"max_wait" yaml:"max_wait"`
+}
+
+// SetDefaults implements Defaulter.
+func (c *Limiter) SetDefaults() {
+	if c.Burst == 0 {
+		c.Burst = int(math.Ceil(c.Rate))
+	}
+}
+
+// Validate implements Validator.
+func (c Limiter) Validate() error {
+	switch {
+	case c.Rate < 0:
+		return errors.New("rate must not be negative")
+	case c.Burst < 0:
+		return errors.New("burst must not be negative")
+	case c.MaxWait.Duration < 0:
+		return errors.New("max_wait must not be negative")
+	}
+	return nil
+}
+
+// Middleware returns an endpoint.Middleware enforcing the limit, see
+// ratelimit.NewRateLimiter.
+func (c Limiter) Middleware(options ...ratelimit.RateOption) endpoint.Middleware {
+	if c.Rate == 0 {
+		return func(next endpoint.Endpoint) endpoint.Endpoint { return next }
+	}
+	options = append([]ratelimit.RateOption{ratelimit.RateMaxWait(c.MaxWait.Duration)}, options...)
+	return ratelimit.NewRateLimiter(rate.NewLimiter(rate.Limit(c.Rate), c.Burst), options...)
+}
+
+// Breaker configures a circuit breaker.
+type Breaker struct {
+	// ConsecutiveFailures is the number of consecutive failures opening the
+	// circuit. The default is 5.
+	ConsecutiveFailures uint32 `json:"consecutive_failures" yaml:"consecutive_failures"`
+
+	// MaxRequests is the number of requests allowed while the circuit is
+	// half-open. The default is 1.
+	MaxRequests uint32 `json:"max_requests" yaml:"max_requests"`
+
+	// Interval is the period after which the failure count of the closed
+	// circuit is cleared. By default, it is cleared only on success.
+	Interval Duration `json:"interval" yaml:"interval"`
+
+	// Timeout is how long the circuit stays open before it turns
+	// half-open. The default is 60s.
+	Timeout Duration `json:"timeout" yaml:"timeout"`
+}
+
+// SetDefaults implements Defaulter.
+func (c *Breaker) SetDefaults() {
+	if c.ConsecutiveFailures == 0 {
+		c.ConsecutiveFailures = 5
+	}
+	if c.MaxRequests == 0 {
+		c.MaxRequests = 1
+	}
+	if c.Timeout.Duration == 0 {
+		c.Timeout.Duration = 60 * time.Second
+	}
+}
+
+// Validate implements Validator.
+func (c Breaker) Validate() error {
+	switch {
+	case c.Interval.Duration < 0:
+		return errors.New("interval must not be negative")
+	case c.Timeout.Duration < 0:
+		return errors.New("timeout must not be negative")
+	}
+	return nil
+}
+
+// Middleware returns an endpoint.Middleware implementing the circuit breaker
+// named name, see circuitbreaker.NewGobreaker.
+func (c Breaker) Middleware(name string, options ...circuitbreaker.GobreakerOption) endpoint.Middleware {
+	failures := c.ConsecutiveFailures
+	return circuitbreaker.NewGobreaker(gobreaker.Settings{
+		Name:        name,
+		MaxRequests: c.MaxRequests,
+		Interval:    c.Interval.Duration,
+		Timeout:     c.Timeout.Duration,
+		ReadyToTrip: func(counts gobreaker.Counts) bool {
+			return counts.ConsecutiveFailures >= failures
+		},
+	}, options...)
+}
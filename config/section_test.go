@@ -0,0 +1,73 @@
+package config_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/inturn/kit/config"
+	"github.com/inturn/kit/endpoint"
+	"github.com/inturn/kit/log/level"
+	"github.com/inturn/kit/ratelimit"
+)
+
+func TestLogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := config.Log{Format: "json", Level: "warn"}.Logger(&buf)
+	level.Info(logger).Log("msg", "hidden")
+	level.Warn(logger).Log("msg", "shown")
+
+	if have := buf.String(); strings.Contains(have, "hidden") || !strings.Contains(have, `"msg":"shown"`) {
+		t.Errorf("incorrect output %q", have)
+	}
+}
+
+func TestLimiterMiddleware(t *testing.T) {
+	e := config.Limiter{Rate: 1, Burst: 1}.Middleware()(endpoint.Nop)
+	if _, err := e(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e(context.Background(), nil); !errors.Is(err, ratelimit.ErrLimited) {
+		t.Errorf("want %v, have %v", ratelimit.ErrLimited, err)
+	}
+
+	e = config.Limiter{}.Middleware()(endpoint.Nop)
+	for i := 0; i < 10; i++ {
+		if _, err := e(context.Background(), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBreakerMiddleware(t *testing.T) {
+	var cfg config.Breaker
+	cfg.SetDefaults()
+	cfg.ConsecutiveFailures = 2
+
+	failing := errors.New("failing")
+	e := cfg.Middleware("test")(func(context.Context, interface{}) (interface{}, error) {
+		return nil, failing
+	})
+	for i := 0; i < 2; i++ {
+		if _, err := e(context.Background(), nil); err != failing {
+			t.Fatalf("want %v, have %v", failing, err)
+		}
+	}
+	if _, err := e(context.Background(), nil); err == failing {
+		t.Error("want open circuit, have endpoint error")
+	}
+}
+
+func TestAMQPSubscriberValidate(t *testing.T) {
+	cfg := config.AMQPSubscriber{ErrorEncoder: "drop"}
+	if err := cfg.Validate(); err == nil {
+		t.Error("want error, have nil")
+	}
+	cfg = config.AMQPSubscriber{}
+	cfg.SetDefaults()
+	if err := cfg.Validate(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/inturn/kit/log"
+	"github.com/inturn/kit/transport"
+	amqptransport "github.com/inturn/kit/transport/amqp"
+	httptransport "github.com/inturn/kit/transport/http"
+)
+
+// HTTPServer configures an HTTP server.
+type HTTPServer struct {
+	Addr         string   `json:"addr" yaml:"addr"`
+	ReadTimeout  Duration `json:"read_timeout" yaml:"read_timeout"`
+	WriteTimeout Duration `json:"write_timeout" yaml:"write_timeout"`
+	IdleTimeout  Duration `json:"idle_timeout" yaml:"idle_timeout"`
+}
+
+// Server returns an http.Server serving handler as configured.
+func (c HTTPServer) Server(handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:         c.Addr,
+		Handler:      handler,
+		ReadTimeout:  c.ReadTimeout.Duration,
+		WriteTimeout: c.WriteTimeout.Duration,
+		IdleTimeout:  c.IdleTimeout.Duration,
+	}
+}
+
+// HTTPClient configures the clients of an HTTP service.
+type HTTPClient struct {
+	// Timeout limits the time of a request including reading the response.
+	// By default, there is no limit.
+	Timeout Duration `json:"timeout" yaml:"timeout"`
+
+	// BufferedStream leaves the response body open for the decoder.
+	BufferedStream bool `json:"buffered_stream" yaml:"buffered_stream"`
+}
+
+// ClientOptions returns the options for httptransport.NewClient.
+func (c HTTPClient) ClientOptions() []httptransport.ClientOption {
+	var options []httptransport.ClientOption
+	if c.Timeout.Duration > 0 {
+		options = append(options, httptransport.SetClient(&http.Client{Timeout: c.Timeout.Duration}))
+	}
+	if c.BufferedStream {
+		options = append(options, httptransport.BufferedStream(true))
+	}
+	return options
+}
+
+// AMQPSubscriber configures AMQP subscribers.
+type AMQPSubscriber struct {
+	// ErrorEncoder handles deliveries which failed: ignore leaves them
+	// unacknowledged, nack_requeue returns them to the queue, reply replies
+	// with the error and reply_ack also acknowledges them. The default is
+	// ignore.
+	ErrorEncoder string `json:"error_encoder" yaml:"error_encoder"`
+}
+
+var errorEncoders = map[string]amqptransport.ErrorEncoder{
+	"ignore":       amqptransport.DefaultErrorEncoder,
+	"nack_requeue": amqptransport.SingleNackRequeueErrorEncoder,
+	"reply":        amqptransport.ReplyErrorEncoder,
+	"reply_ack":    amqptransport.ReplyAndAckErrorEncoder,
+}
+
+// SetDefaults implements Defaulter.
+func (c *AMQPSubscriber) SetDefaults() {
+	if c.ErrorEncoder == "" {
+		c.ErrorEncoder = "ignore"
+	}
+}
+
+// Validate implements Validator.
+func (c AMQPSubscriber) Validate() error {
+	if _, ok := errorEncoders[c.ErrorEncoder]; !ok {
+		return fmt.Errorf("unknown error encoder %q", c.ErrorEncoder)
+	}
+	return nil
+}
+
+// SubscriberOptions returns the options for amqptransport.NewSubscriber,
+// logging errors to logger.
+func (c AMQPSubscriber) SubscriberOptions(logger log.Logger) []amqptransport.SubscriberOption {
+	options := []amqptransport.SubscriberOption{
+		amqptransport.SubscriberErrorHandler(transport.NewLogErrorHandler(logger)),
+	}
+	if ee, ok := errorEncoders[c.ErrorEncoder]; ok {
+		options = append(options, amqptransport.SubscriberErrorEncoder(ee))
+	}
+	return options
+}
+
+// AMQPPublisher configures AMQP publishers.
+type AMQPPublisher struct {
+	// Timeout limits the time waiting for a reply. The default is 10s.
+	Timeout Duration `json:"timeout" yaml:"timeout"`
+}
+
+// PublisherOptions returns the options for amqptransport.NewPublisher.
+func (c AMQPPublisher) PublisherOptions() []amqptransport.PublisherOption {
+	var options []amqptransport.PublisherOption
+	if c.Timeout.Duration > 0 {
+		options = append(options, amqptransport.PublisherTimeout(c.Timeout.Duration))
+	}
+	return options
+}
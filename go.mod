@@ -1,12 +1,14 @@
 module github.com/inturn/kit
 
 require (
+	github.com/DATA-DOG/go-sqlmock v1.5.0
 	github.com/DataDog/datadog-go v0.0.0-20180822151419-281ae9f2d895 // indirect
 	github.com/Knetic/govaluate v3.0.0+incompatible // indirect
 	github.com/Shopify/sarama v1.19.0 // indirect
 	github.com/Shopify/toxiproxy v2.1.3+incompatible // indirect
 	github.com/VividCortex/gohistogram v1.0.0
 	github.com/afex/hystrix-go v0.0.0-20180502004556-fa1af6a1f4f5
+	github.com/alicebob/miniredis/v2 v2.11.0
 	github.com/apache/thrift v0.0.0-20181119175316-aa177ea4b30b
 	github.com/aws/aws-sdk-go v1.15.79
 	github.com/aws/aws-sdk-go-v2 v2.0.0-preview.4+incompatible
@@ -33,6 +35,7 @@ require (
 	github.com/go-stack/stack v1.8.0
 	github.com/golang/groupcache v0.0.0-20181024230925-c65c006176ff // indirect
 	github.com/golang/protobuf v1.2.0
+	github.com/gomodule/redigo v1.8.2
 	github.com/google/btree v0.0.0-20180813153112-4030bb1f1f0c // indirect
 	github.com/gorilla/context v1.1.1 // indirect
 	github.com/gorilla/mux v1.6.2
@@ -55,6 +58,7 @@ require (
 	github.com/kardianos/osext v0.0.0-20170510131534-ae77be60afb1 // indirect
 	github.com/kr/logfmt v0.0.0-20140226030751-b84e30acd515 // indirect
 	github.com/lightstep/lightstep-tracer-go v0.15.6
+	github.com/linkedin/goavro/v2 v2.9.8
 	github.com/miekg/dns v1.0.15 // indirect
 	github.com/mitchellh/go-testing-interface v1.0.0 // indirect
 	github.com/nats-io/gnatsd v1.3.0
@@ -69,15 +73,16 @@ require (
 	github.com/openzipkin-contrib/zipkin-go-opentracing v0.3.4
 	github.com/openzipkin/zipkin-go v0.1.3
 	github.com/openzipkin/zipkin-go-opentracing v0.3.4 // indirect
+	github.com/ory/dockertest/v3 v3.6.0
 	github.com/pact-foundation/pact-go v0.0.13
 	github.com/pborman/uuid v1.2.0
 	github.com/performancecopilot/speed v3.0.0+incompatible
-	github.com/pkg/errors v0.8.0
+	github.com/pkg/errors v0.8.1
 	github.com/prometheus/client_golang v0.9.1
 	github.com/rcrowley/go-metrics v0.0.0-20181016184325-3113b8401b8a // indirect
 	github.com/samuel/go-zookeeper v0.0.0-20180130194729-c4fab1ac1bec
 	github.com/sean-/seed v0.0.0-20170313163322-e2103e2c3529 // indirect
-	github.com/sirupsen/logrus v1.2.0
+	github.com/sirupsen/logrus v1.4.2
 	github.com/smartystreets/goconvey v0.0.0-20181108003508-044398e4856c // indirect
 	github.com/soheilhy/cmux v0.1.4 // indirect
 	github.com/sony/gobreaker v0.0.0-20181109014844-d928aaea92e1
@@ -85,17 +90,26 @@ require (
 	github.com/streadway/handy v0.0.0-20160402200321-f450267a206e
 	github.com/tmc/grpc-websocket-proxy v0.0.0-20171017195756-830351dc03c6 // indirect
 	github.com/tv42/httpunix v0.0.0-20150427012821-b75d8614f926 // indirect
+	github.com/uber/jaeger-client-go v2.16.0+incompatible
+	github.com/uber/jaeger-lib v2.4.1+incompatible // indirect
 	github.com/ugorji/go/codec v0.0.0-20181119220752-0165389f8c91 // indirect
 	github.com/xiang90/probing v0.0.0-20160813154853-07dd2e8dfe18 // indirect
 	go.etcd.io/etcd v3.3.10+incompatible
 	go.opencensus.io v0.18.0
-	golang.org/x/net v0.0.0-20181114220301-adae6a3d119a
-	golang.org/x/sync v0.0.0-20181108010431-42b317875d0f
+	go.opentelemetry.io/otel v1.0.0
+	go.opentelemetry.io/otel/sdk v1.0.0
+	go.opentelemetry.io/otel/trace v1.0.0
+	golang.org/x/crypto v0.0.0-20190308221718-c2843e01d9a2
+	golang.org/x/net v0.0.0-20191003171128-d98b1b443823
+	golang.org/x/oauth2 v0.0.0-20181017192945-9dcd33a902f4
+	golang.org/x/sync v0.0.0-20190423024810-112230192c58
 	golang.org/x/time v0.0.0-20181108054448-85acf8d2951c
-	golang.org/x/tools v0.0.0-20181120060634-fc4f04983f62
+	golang.org/x/tools v0.0.0-20190624222133-a101b041ded4
 	google.golang.org/grpc v1.16.0
 	gopkg.in/gcfg.v1 v1.2.3 // indirect
 	gopkg.in/ini.v1 v1.39.0 // indirect
+	gopkg.in/vmihailenco/msgpack.v2 v2.9.1
 	gopkg.in/warnings.v0 v0.1.2 // indirect
+	gopkg.in/yaml.v2 v2.2.7
 	sourcegraph.com/sourcegraph/appdash v0.0.0-20180531100431-4c381bd170b4
 )
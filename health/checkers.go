@@ -0,0 +1,162 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/streadway/amqp"
+
+	"github.com/inturn/kit/circuitbreaker"
+	"github.com/inturn/kit/sd"
+)
+
+// Pinger is implemented by clients able to check their connection, e.g.
+// *sql.DB.
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// Ping returns a Checker pinging p.
+func Ping(p Pinger) Checker {
+	return CheckerFunc(p.PingContext)
+}
+
+// AMQPConnection returns a Checker reporting conn as down once it is
+// closed. Closed connections aren't reopened, so it is usually registered
+// as a liveness check.
+func AMQPConnection(conn *amqp.Connection) Checker {
+	c := &amqpConnectionChecker{}
+	closed := conn.NotifyClose(make(chan *amqp.Error, 1))
+	go func() {
+		err := <-closed
+		c.mtx.Lock()
+		defer c.mtx.Unlock()
+		if err != nil {
+			c.err = fmt.Errorf("connection closed: %v", err)
+		} else {
+			c.err = errors.New("connection closed")
+		}
+	}()
+	return c
+}
+
+type amqpConnectionChecker struct {
+	mtx sync.Mutex
+	err error
+}
+
+func (c *amqpConnectionChecker) Check(context.Context) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.err
+}
+
+// InstancerChecker reports the service discovery of a dependency as down
+// while the instancer reports an error or no instances.
+type InstancerChecker struct {
+	instancer sd.Instancer
+	events    chan sd.Event
+	done      chan struct{}
+
+	mtx   sync.Mutex
+	event *sd.Event
+}
+
+// NewInstancerChecker returns an InstancerChecker observing instancer.
+// Stop it when it is no longer used.
+func NewInstancerChecker(instancer sd.Instancer) *InstancerChecker {
+	c := &InstancerChecker{
+		instancer: instancer,
+		events:    make(chan sd.Event),
+		done:      make(chan struct{}),
+	}
+	go c.receive()
+	instancer.Register(c.events)
+	return c
+}
+
+func (c *InstancerChecker) receive() {
+	for {
+		select {
+		case event := <-c.events:
+			c.mtx.Lock()
+			c.event = &event
+			c.mtx.Unlock()
+		case <-c.done:
+			return
+		}
+	}
+}
+
+// Check implements Checker.
+func (c *InstancerChecker) Check(context.Context) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	switch {
+	case c.event == nil:
+		return ErrNotChecked
+	case c.event.Err != nil:
+		return c.event.Err
+	case len(c.event.Instances) == 0:
+		return errors.New("no instances")
+	}
+	return nil
+}
+
+// Stop deregisters the checker from the instancer.
+func (c *InstancerChecker) Stop() {
+	c.instancer.Deregister(c.events)
+	close(c.done)
+}
+
+// BreakerChecker is a circuitbreaker.Observer reporting a dependency as down
+// while one of the circuit breakers it observes is open.
+type BreakerChecker struct {
+	mtx  sync.Mutex
+	open map[string]bool
+}
+
+var _ circuitbreaker.Observer = (*BreakerChecker)(nil)
+
+// NewBreakerChecker returns a BreakerChecker. Pass it to the circuit
+// breakers protecting the dependency, e.g. with
+// circuitbreaker.GobreakerObserver.
+func NewBreakerChecker() *BreakerChecker {
+	return &BreakerChecker{open: map[string]bool{}}
+}
+
+// StateChange implements circuitbreaker.Observer.
+func (c *BreakerChecker) StateChange(breaker string, _, to circuitbreaker.State) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if to == circuitbreaker.StateOpen {
+		c.open[breaker] = true
+	} else {
+		delete(c.open, breaker)
+	}
+}
+
+// Rejected implements circuitbreaker.Observer.
+func (c *BreakerChecker) Rejected(string, error) {}
+
+// Done implements circuitbreaker.Observer.
+func (c *BreakerChecker) Done(string, error) {}
+
+// Check implements Checker.
+func (c *BreakerChecker) Check(context.Context) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if len(c.open) == 0 {
+		return nil
+	}
+	open := make([]string, 0, len(c.open))
+	for breaker := range c.open {
+		open = append(open, breaker)
+	}
+	sort.Strings(open)
+	return fmt.Errorf("circuit open: %s", strings.Join(open, ", "))
+}
@@ -0,0 +1,61 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/inturn/kit/circuitbreaker"
+	"github.com/inturn/kit/health"
+	"github.com/inturn/kit/sd"
+)
+
+type errInstancer struct{ err error }
+
+func (i errInstancer) Register(ch chan<- sd.Event) { ch <- sd.Event{Err: i.err} }
+func (errInstancer) Deregister(chan<- sd.Event)    {}
+func (errInstancer) Stop()                         {}
+
+func TestInstancerChecker(t *testing.T) {
+	for _, testcase := range []struct {
+		instancer sd.Instancer
+		want      string
+	}{
+		{sd.FixedInstancer{"a:80"}, ""},
+		{sd.FixedInstancer{}, "no instances"},
+		{errInstancer{errors.New("dummy")}, "dummy"},
+	} {
+		c := health.NewInstancerChecker(testcase.instancer)
+		var have string
+		for i := 0; i < 1000; i++ {
+			err := c.Check(context.Background())
+			if err != health.ErrNotChecked {
+				if err != nil {
+					have = err.Error()
+				}
+				break
+			}
+		}
+		c.Stop()
+		if want := testcase.want; want != have {
+			t.Errorf("incorrect error, want %q, have %q", want, have)
+		}
+	}
+}
+
+func TestBreakerChecker(t *testing.T) {
+	c := health.NewBreakerChecker()
+	c.StateChange("b", circuitbreaker.StateClosed, circuitbreaker.StateOpen)
+	c.StateChange("a", circuitbreaker.StateClosed, circuitbreaker.StateOpen)
+
+	err := c.Check(context.Background())
+	if want, have := "circuit open: a, b", err.Error(); want != have {
+		t.Errorf("incorrect error, want %q, have %q", want, have)
+	}
+
+	c.StateChange("a", circuitbreaker.StateOpen, circuitbreaker.StateHalfOpen)
+	c.StateChange("b", circuitbreaker.StateOpen, circuitbreaker.StateHalfOpen)
+	if err := c.Check(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,26 @@
+// Package health aggregates the health of the components of a service, e.g.
+// AMQP connections, service discovery instancers, databases and circuit
+// breakers, and reports it to orchestrators and load balancers through
+// liveness and readiness endpoints.
+//
+// Components register named checkers with a Registry. Checks marked with
+// Liveness decide whether the process is alive and should be restarted if
+// not; all checks decide whether it is ready to receive traffic.
+//
+//	r := health.NewRegistry()
+//	defer r.Close()
+//	r.Register("db", health.Ping(db), health.CheckTimeout(time.Second))
+//	r.Register("amqp", health.AMQPConnection(conn), health.Liveness())
+//	r.Register("users", health.NewInstancerChecker(instancer), health.CheckInterval(10*time.Second))
+//
+//	mux.Handle("/healthz", health.LivenessHandler(r))
+//	mux.Handle("/readyz", health.ReadinessHandler(r))
+//	grpc_health_v1.RegisterHealthServer(grpcServer, health.NewGRPCServer(r))
+//
+// During graceful shutdown, the registry reports the service as not ready
+// before its servers stop, so load balancers take it out of rotation while
+// it still serves requests. Drain wraps the actors of a util/group.Group to
+// do so:
+//
+//	g.Add("http", r.Drain(group.HTTPServer(srv, l), 5*time.Second))
+package health
@@ -0,0 +1,90 @@
+package health
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCServer implements the gRPC health checking protocol on top of a
+// Registry. The empty service name reports the readiness of the service,
+// other names the check registered under them.
+type GRPCServer struct {
+	r        *Registry
+	interval time.Duration
+}
+
+var _ healthpb.HealthServer = (*GRPCServer)(nil)
+
+// GRPCServerOption sets an optional parameter for NewGRPCServer.
+type GRPCServerOption func(*GRPCServer)
+
+// GRPCWatchInterval sets the interval in which Watch runs the checks. The
+// default is 5s.
+func GRPCWatchInterval(d time.Duration) GRPCServerOption {
+	return func(s *GRPCServer) { s.interval = d }
+}
+
+// NewGRPCServer returns a GRPCServer reporting the checks of r.
+func NewGRPCServer(r *Registry, options ...GRPCServerOption) *GRPCServer {
+	s := &GRPCServer{r: r, interval: 5 * time.Second}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+// Check implements grpc_health_v1.HealthServer.
+func (s *GRPCServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	st, ok := s.status(ctx, req.Service)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "unknown service %q", req.Service)
+	}
+	return &healthpb.HealthCheckResponse{Status: st}, nil
+}
+
+// Watch implements grpc_health_v1.HealthServer. It sends the status of the
+// service whenever it changes, and SERVICE_UNKNOWN for unknown services.
+func (s *GRPCServer) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	last := healthpb.HealthCheckResponse_ServingStatus(-1)
+	for {
+		st, ok := s.status(stream.Context(), req.Service)
+		if !ok {
+			st = healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+		}
+		if st != last {
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: st}); err != nil {
+				return err
+			}
+			last = st
+		}
+		select {
+		case <-ticker.C:
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		}
+	}
+}
+
+func (s *GRPCServer) status(ctx context.Context, service string) (healthpb.HealthCheckResponse_ServingStatus, bool) {
+	var st Status
+	if service == "" {
+		st = s.r.Ready(ctx).Status
+	} else {
+		res, ok := s.r.Check(ctx, service)
+		if !ok {
+			return healthpb.HealthCheckResponse_UNKNOWN, false
+		}
+		st = res.Status
+	}
+	if st == StatusUp {
+		return healthpb.HealthCheckResponse_SERVING, true
+	}
+	return healthpb.HealthCheckResponse_NOT_SERVING, true
+}
@@ -0,0 +1,327 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/inturn/kit/util/group"
+)
+
+// DefaultTimeout is the time a check may take unless set otherwise with
+// RegistryTimeout or CheckTimeout.
+const DefaultTimeout = 5 * time.Second
+
+// ErrShuttingDown is reported by the readiness check once the registry was
+// shut down.
+var ErrShuttingDown = errors.New("shutting down")
+
+// ErrNotChecked is reported by asynchronous checks until their first run
+// completed.
+var ErrNotChecked = errors.New("not checked yet")
+
+// Checker checks the health of a component. It returns nil if the component
+// is healthy.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc is an adapter to allow the use of ordinary functions as
+// Checkers.
+type CheckerFunc func(ctx context.Context) error
+
+// Check implements Checker.
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Status is the health of a component or of the whole service.
+type Status string
+
+// Statuses reported by checks.
+const (
+	StatusUp   Status = "up"
+	StatusDown Status = "down"
+)
+
+// Result is the outcome of a single check.
+type Result struct {
+	Status Status `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Report is the outcome of a set of checks. Its status is StatusUp if all
+// checks are.
+type Report struct {
+	Status Status            `json:"status"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+// Registry runs the checks registered with it. It is safe for concurrent
+// use.
+type Registry struct {
+	timeout  time.Duration
+	mtx      sync.RWMutex
+	checks   map[string]*check
+	shutdown int32
+}
+
+// RegistryOption sets an optional parameter for NewRegistry.
+type RegistryOption func(*Registry)
+
+// RegistryTimeout sets the time checks may take unless set otherwise with
+// CheckTimeout. The default is DefaultTimeout.
+func RegistryTimeout(d time.Duration) RegistryOption {
+	return func(r *Registry) { r.timeout = d }
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry(options ...RegistryOption) *Registry {
+	r := &Registry{
+		timeout: DefaultTimeout,
+		checks:  map[string]*check{},
+	}
+	for _, option := range options {
+		option(r)
+	}
+	return r
+}
+
+// CheckOption sets an optional parameter for Register.
+type CheckOption func(*check)
+
+// CheckTimeout sets the time the check may take before it is reported as
+// down.
+func CheckTimeout(d time.Duration) CheckOption {
+	return func(c *check) { c.timeout = d }
+}
+
+// CheckCacheTTL caches the result of the check for ttl, for checks too
+// expensive to run on every probe.
+func CheckCacheTTL(ttl time.Duration) CheckOption {
+	return func(c *check) { c.ttl = ttl }
+}
+
+// CheckInterval runs the check in the background every interval instead of
+// on every probe, which then reports its latest result. Until the first run
+// completed, the check reports ErrNotChecked.
+func CheckInterval(interval time.Duration) CheckOption {
+	return func(c *check) { c.interval = interval }
+}
+
+// Liveness marks the check as deciding the liveness of the process, in
+// addition to its readiness. Only failures that require a restart, like a
+// connection that isn't recovered automatically, should be liveness checks.
+func Liveness() CheckOption {
+	return func(c *check) { c.liveness = true }
+}
+
+// Register adds the checker under name. It panics if name is taken.
+func (r *Registry) Register(name string, checker Checker, options ...CheckOption) {
+	c := &check{
+		checker: checker,
+		timeout: r.timeout,
+	}
+	for _, option := range options {
+		option(c)
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if _, ok := r.checks[name]; ok {
+		panic(fmt.Sprintf("health: check %q registered twice", name))
+	}
+	r.checks[name] = c
+	if c.interval > 0 {
+		c.start()
+	}
+}
+
+// Live runs the liveness checks.
+func (r *Registry) Live(ctx context.Context) Report {
+	return r.report(ctx, func(c *check) bool { return c.liveness })
+}
+
+// Ready runs all checks. Once the registry was shut down, it reports
+// ErrShuttingDown without running them.
+func (r *Registry) Ready(ctx context.Context) Report {
+	if atomic.LoadInt32(&r.shutdown) == 1 {
+		return Report{
+			Status: StatusDown,
+			Checks: map[string]Result{"shutdown": {Status: StatusDown, Error: ErrShuttingDown.Error()}},
+		}
+	}
+	return r.report(ctx, func(*check) bool { return true })
+}
+
+// Check runs the check registered under name. It returns false if there is
+// none.
+func (r *Registry) Check(ctx context.Context, name string) (Result, bool) {
+	r.mtx.RLock()
+	c, ok := r.checks[name]
+	r.mtx.RUnlock()
+	if !ok {
+		return Result{}, false
+	}
+	return c.result(ctx), true
+}
+
+// Shutdown marks the service as not ready. It can't be undone.
+func (r *Registry) Shutdown() {
+	atomic.StoreInt32(&r.shutdown, 1)
+}
+
+// Drain returns an Actor shutting down the registry when it is interrupted,
+// and interrupting a only after delay passed, to give load balancers time to
+// notice the service isn't ready anymore. The delay counts against the
+// shutdown timeout of the actor.
+func (r *Registry) Drain(a group.Actor, delay time.Duration) group.Actor {
+	return group.Actor{
+		Execute: a.Execute,
+		Interrupt: func(ctx context.Context) error {
+			r.Shutdown()
+			t := time.NewTimer(delay)
+			defer t.Stop()
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+			}
+			return a.Interrupt(ctx)
+		},
+	}
+}
+
+// Close stops the checks running in the background.
+func (r *Registry) Close() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	for _, c := range r.checks {
+		c.stop()
+	}
+}
+
+func (r *Registry) report(ctx context.Context, filter func(*check) bool) Report {
+	r.mtx.RLock()
+	checks := make(map[string]*check, len(r.checks))
+	for name, c := range r.checks {
+		if filter(c) {
+			checks[name] = c
+		}
+	}
+	r.mtx.RUnlock()
+
+	var (
+		mtx    sync.Mutex
+		wg     sync.WaitGroup
+		report = Report{Status: StatusUp, Checks: make(map[string]Result, len(checks))}
+	)
+	for name, c := range checks {
+		wg.Add(1)
+		go func(name string, c *check) {
+			defer wg.Done()
+			res := c.result(ctx)
+			mtx.Lock()
+			defer mtx.Unlock()
+			report.Checks[name] = res
+			if res.Status != StatusUp {
+				report.Status = StatusDown
+			}
+		}(name, c)
+	}
+	wg.Wait()
+	return report
+}
+
+type check struct {
+	checker  Checker
+	timeout  time.Duration
+	ttl      time.Duration
+	interval time.Duration
+	liveness bool
+
+	mtx     sync.Mutex
+	last    Result
+	checked time.Time
+	done    chan struct{}
+	once    sync.Once
+}
+
+func (c *check) result(ctx context.Context) Result {
+	switch {
+	case c.interval > 0:
+		c.mtx.Lock()
+		defer c.mtx.Unlock()
+		if c.checked.IsZero() {
+			return Result{Status: StatusDown, Error: ErrNotChecked.Error()}
+		}
+		return c.last
+
+	case c.ttl > 0:
+		c.mtx.Lock()
+		if !c.checked.IsZero() && time.Since(c.checked) < c.ttl {
+			defer c.mtx.Unlock()
+			return c.last
+		}
+		c.mtx.Unlock()
+		res := c.run(ctx)
+		c.store(res)
+		return res
+	}
+	return c.run(ctx)
+}
+
+// run runs the checker, giving up after the timeout even if the checker
+// ignores ctx.
+func (c *check) run(ctx context.Context) Result {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	errc := make(chan error, 1)
+	go func() { errc <- c.checker.Check(ctx) }()
+
+	var err error
+	select {
+	case err = <-errc:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err != nil {
+		return Result{Status: StatusDown, Error: err.Error()}
+	}
+	return Result{Status: StatusUp}
+}
+
+func (c *check) store(res Result) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.last = res
+	c.checked = time.Now()
+}
+
+func (c *check) start() {
+	c.done = make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+		for {
+			c.store(c.run(context.Background()))
+			select {
+			case <-ticker.C:
+			case <-c.done:
+				return
+			}
+		}
+	}()
+}
+
+func (c *check) stop() {
+	if c.done != nil {
+		c.once.Do(func() { close(c.done) })
+	}
+}
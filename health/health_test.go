@@ -0,0 +1,145 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/inturn/kit/health"
+	"github.com/inturn/kit/util/group"
+)
+
+var (
+	up   = health.CheckerFunc(func(context.Context) error { return nil })
+	down = health.CheckerFunc(func(context.Context) error { return errors.New("dummy") })
+)
+
+func TestRegistryReport(t *testing.T) {
+	r := health.NewRegistry()
+	r.Register("a", up, health.Liveness())
+	r.Register("b", down)
+
+	live := r.Live(context.Background())
+	if want, have := health.StatusUp, live.Status; want != have {
+		t.Errorf("incorrect liveness, want %q, have %q", want, have)
+	}
+	if want, have := 1, len(live.Checks); want != have {
+		t.Errorf("incorrect number of liveness checks, want %d, have %d", want, have)
+	}
+
+	ready := r.Ready(context.Background())
+	if want, have := health.StatusDown, ready.Status; want != have {
+		t.Errorf("incorrect readiness, want %q, have %q", want, have)
+	}
+	if want, have := (health.Result{Status: health.StatusDown, Error: "dummy"}), ready.Checks["b"]; want != have {
+		t.Errorf("incorrect result, want %v, have %v", want, have)
+	}
+
+	if _, ok := r.Check(context.Background(), "c"); ok {
+		t.Error("want unknown check, have ok")
+	}
+}
+
+func TestRegistryTimeout(t *testing.T) {
+	r := health.NewRegistry(health.RegistryTimeout(10 * time.Millisecond))
+	block := make(chan struct{})
+	defer close(block)
+	r.Register("slow", health.CheckerFunc(func(context.Context) error {
+		<-block // ignores its context
+		return nil
+	}))
+
+	res, _ := r.Check(context.Background(), "slow")
+	if want, have := context.DeadlineExceeded.Error(), res.Error; want != have {
+		t.Errorf("incorrect error, want %q, have %q", want, have)
+	}
+}
+
+func TestRegistryCache(t *testing.T) {
+	var calls int32
+	r := health.NewRegistry()
+	r.Register("cached", health.CheckerFunc(func(context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	}), health.CheckCacheTTL(time.Hour))
+
+	for i := 0; i < 3; i++ {
+		r.Ready(context.Background())
+	}
+	if want, have := int32(1), atomic.LoadInt32(&calls); want != have {
+		t.Errorf("incorrect number of calls, want %d, have %d", want, have)
+	}
+}
+
+func TestRegistryInterval(t *testing.T) {
+	release := make(chan struct{})
+	r := health.NewRegistry()
+	defer r.Close()
+	r.Register("async", health.CheckerFunc(func(context.Context) error {
+		<-release
+		return nil
+	}), health.CheckInterval(time.Millisecond))
+
+	res, _ := r.Check(context.Background(), "async")
+	if want, have := health.ErrNotChecked.Error(), res.Error; want != have {
+		t.Errorf("incorrect error, want %q, have %q", want, have)
+	}
+
+	close(release)
+	for i := 0; ; i++ {
+		if res, _ := r.Check(context.Background(), "async"); res.Status == health.StatusUp {
+			break
+		}
+		if i > 1000 {
+			t.Fatal("check didn't run")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRegistryRegisterTwice(t *testing.T) {
+	r := health.NewRegistry()
+	r.Register("a", up)
+	defer func() {
+		if recover() == nil {
+			t.Error("want panic, have none")
+		}
+	}()
+	r.Register("a", up)
+}
+
+func TestRegistryDrain(t *testing.T) {
+	r := health.NewRegistry()
+	r.Register("a", up)
+
+	var interrupted time.Time
+	a := r.Drain(group.Actor{
+		Execute: func() error { return nil },
+		Interrupt: func(context.Context) error {
+			interrupted = time.Now()
+			return nil
+		},
+	}, 20*time.Millisecond)
+
+	if want, have := health.StatusUp, r.Ready(context.Background()).Status; want != have {
+		t.Errorf("incorrect readiness, want %q, have %q", want, have)
+	}
+
+	begin := time.Now()
+	done := make(chan error)
+	go func() { done <- a.Interrupt(context.Background()) }()
+	time.Sleep(5 * time.Millisecond)
+
+	ready := r.Ready(context.Background())
+	if want, have := health.ErrShuttingDown.Error(), ready.Checks["shutdown"].Error; want != have {
+		t.Errorf("incorrect error, want %q, have %q", want, have)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if have := interrupted.Sub(begin); have < 20*time.Millisecond {
+		t.Errorf("actor interrupted after %v, before the delay", have)
+	}
+}
@@ -0,0 +1,31 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// LivenessHandler returns an http.Handler reporting the liveness checks of
+// r as JSON, with status 200 if they are up and 503 otherwise.
+func LivenessHandler(r *Registry) http.Handler {
+	return reportHandler(r.Live)
+}
+
+// ReadinessHandler returns an http.Handler reporting all checks of r as
+// JSON, with status 200 if they are up and 503 otherwise.
+func ReadinessHandler(r *Registry) http.Handler {
+	return reportHandler(r.Ready)
+}
+
+func reportHandler(check func(context.Context) Report) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		report := check(req.Context())
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		if report.Status != StatusUp {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(report)
+	})
+}
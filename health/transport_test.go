@@ -0,0 +1,69 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	"github.com/inturn/kit/health"
+)
+
+func TestHTTPHandlers(t *testing.T) {
+	r := health.NewRegistry()
+	r.Register("a", up, health.Liveness())
+	r.Register("b", down)
+
+	for _, testcase := range []struct {
+		handler http.Handler
+		code    int
+		status  health.Status
+	}{
+		{health.LivenessHandler(r), http.StatusOK, health.StatusUp},
+		{health.ReadinessHandler(r), http.StatusServiceUnavailable, health.StatusDown},
+	} {
+		rec := httptest.NewRecorder()
+		testcase.handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+		if want, have := testcase.code, rec.Code; want != have {
+			t.Errorf("incorrect status code, want %d, have %d", want, have)
+		}
+		var report health.Report
+		if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+			t.Fatal(err)
+		}
+		if want, have := testcase.status, report.Status; want != have {
+			t.Errorf("incorrect status, want %q, have %q", want, have)
+		}
+	}
+}
+
+func TestGRPCServerCheck(t *testing.T) {
+	r := health.NewRegistry()
+	r.Register("a", up)
+	r.Register("b", down)
+	s := health.NewGRPCServer(r)
+
+	for service, want := range map[string]healthpb.HealthCheckResponse_ServingStatus{
+		"":  healthpb.HealthCheckResponse_NOT_SERVING,
+		"a": healthpb.HealthCheckResponse_SERVING,
+		"b": healthpb.HealthCheckResponse_NOT_SERVING,
+	} {
+		resp, err := s.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if have := resp.Status; want != have {
+			t.Errorf("%q: incorrect status, want %s, have %s", service, want, have)
+		}
+	}
+
+	_, err := s.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "c"})
+	if want, have := codes.NotFound, status.Code(err); want != have {
+		t.Errorf("incorrect code, want %s, have %s", want, have)
+	}
+}
@@ -0,0 +1,94 @@
+// Package lru implements a size-bounded cache evicting the least recently
+// used entries, for use by Go kit packages keeping state per key.
+package lru
+
+import (
+	"container/list"
+	"sync"
+)
+
+// Cache is a string-keyed LRU cache safe for concurrent use.
+type Cache struct {
+	mtx   sync.Mutex
+	size  int
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type entry struct {
+	key   string
+	value interface{}
+}
+
+// New returns a Cache holding at most size entries.
+func New(size int) *Cache {
+	if size < 1 {
+		size = 1
+	}
+	return &Cache{
+		size:  size,
+		ll:    list.New(),
+		items: map[string]*list.Element{},
+	}
+}
+
+// GetOrCreate returns the value of key, calling create to create it if it
+// isn't cached. If the cache is full, the least recently used entry is
+// evicted. create is called with the cache locked and must not use it.
+func (c *Cache) GetOrCreate(key string, create func() interface{}) interface{} {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if e, ok := c.items[key]; ok {
+		c.ll.MoveToFront(e)
+		return e.Value.(*entry).value
+	}
+
+	value := create()
+	c.push(key, value)
+	return value
+}
+
+// Get returns the value of key and whether it is cached.
+func (c *Cache) Get(key string) (interface{}, bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if e, ok := c.items[key]; ok {
+		c.ll.MoveToFront(e)
+		return e.Value.(*entry).value, true
+	}
+	return nil, false
+}
+
+// Add sets the value of key. If the cache is full, the least recently used
+// entry is evicted.
+func (c *Cache) Add(key string, value interface{}) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if e, ok := c.items[key]; ok {
+		c.ll.MoveToFront(e)
+		e.Value.(*entry).value = value
+		return
+	}
+	c.push(key, value)
+}
+
+// push adds a new entry, evicting the least recently used one if the cache
+// is full. c.mtx must be held.
+func (c *Cache) push(key string, value interface{}) {
+	if c.ll.Len() >= c.size {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*entry).key)
+	}
+	c.items[key] = c.ll.PushFront(&entry{key, value})
+}
+
+// Len returns the number of cached entries.
+func (c *Cache) Len() int {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.ll.Len()
+}
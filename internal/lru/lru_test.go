@@ -0,0 +1,55 @@
+package lru_test
+
+import (
+	"testing"
+
+	"github.com/inturn/kit/internal/lru"
+)
+
+func TestCache(t *testing.T) {
+	var (
+		c       = lru.New(2)
+		created int
+		create  = func() interface{} { created++; return created }
+	)
+
+	c.GetOrCreate("a", create)
+	c.GetOrCreate("b", create)
+	c.GetOrCreate("a", create) // a is now the most recently used
+	c.GetOrCreate("c", create) // evicts b
+
+	if want, have := 3, created; want != have {
+		t.Errorf("want %d created, have %d", want, have)
+	}
+	if want, have := 1, c.GetOrCreate("a", create); want != have {
+		t.Errorf("want a cached as %d, have %v", want, have)
+	}
+	if want, have := 4, c.GetOrCreate("b", create); want != have {
+		t.Errorf("want b recreated as %d, have %v", want, have)
+	}
+	if want, have := 2, c.Len(); want != have {
+		t.Errorf("want %d entries, have %d", want, have)
+	}
+}
+
+func TestCacheGetAdd(t *testing.T) {
+	c := lru.New(2)
+	if _, ok := c.Get("a"); ok {
+		t.Error("want a not cached")
+	}
+
+	c.Add("a", 1)
+	c.Add("b", 2)
+	c.Add("a", 3) // updates a, which is now the most recently used
+	c.Add("c", 4) // evicts b
+
+	if v, ok := c.Get("a"); !ok || v != 3 {
+		t.Errorf("want a cached as 3, have %v (%v)", v, ok)
+	}
+	if _, ok := c.Get("b"); ok {
+		t.Error("want b evicted")
+	}
+	if want, have := 2, c.Len(); want != have {
+		t.Errorf("want %d entries, have %d", want, have)
+	}
+}
@@ -0,0 +1,202 @@
+// Package cardinality provides adapters that protect metrics backends from
+// label-cardinality explosions. Label values derived from user input, like
+// tenant IDs or request paths, can create an unbounded number of series in
+// backends like Prometheus. The adapters in this package sit in front of any
+// metrics implementation and rewrite unexpected label values to a single
+// overflow bucket before they reach the backend.
+//
+//    guard := cardinality.NewGuard(
+//        cardinality.Allow("method", "GET", "POST", "PUT", "DELETE"),
+//        cardinality.Limit(100),
+//        cardinality.Violations(violations),
+//    )
+//    requests := cardinality.NewCounter(prometheusCounter, guard)
+//    requests.With("method", r.Method, "tenant", tenant).Add(1)
+//
+package cardinality
+
+import (
+	"sync"
+
+	"github.com/inturn/kit/metrics"
+)
+
+// Other is the label value substituted for values rejected by a Guard.
+const Other = "other"
+
+// Guard decides which label values are passed through to the wrapped metrics.
+// Labels with an explicit allow-list only accept the listed values. All other
+// labels accept up to Limit distinct values, after which new values are
+// rewritten to Other. A Guard may be shared by many metrics, in which case the
+// limit applies to the union of values observed for each label name.
+type Guard struct {
+	mtx        sync.Mutex
+	allowed    map[string]map[string]struct{}
+	seen       map[string]map[string]struct{}
+	limit      int
+	other      string
+	violations metrics.Counter
+}
+
+// GuardOption sets an optional parameter for guards.
+type GuardOption func(*Guard)
+
+// Allow restricts the label to the given set of values. Any other value is
+// rewritten to Other, regardless of the limit.
+func Allow(label string, values ...string) GuardOption {
+	return func(g *Guard) {
+		set, ok := g.allowed[label]
+		if !ok {
+			set = map[string]struct{}{}
+			g.allowed[label] = set
+		}
+		for _, v := range values {
+			set[v] = struct{}{}
+		}
+	}
+}
+
+// Limit sets the maximum number of distinct values accepted for each label
+// without an allow-list. By default, 100 values are accepted. A limit of zero
+// or less disables the limit.
+func Limit(n int) GuardOption {
+	return func(g *Guard) { g.limit = n }
+}
+
+// OtherValue sets the label value substituted for rejected values. By
+// default, Other is used.
+func OtherValue(value string) GuardOption {
+	return func(g *Guard) { g.other = value }
+}
+
+// Violations sets a counter that is incremented every time a label value is
+// rejected. The counter is scoped with a "label" label carrying the name of
+// the offending label; the rejected value itself is never reported. By
+// default, violations are not counted.
+func Violations(c metrics.Counter) GuardOption {
+	return func(g *Guard) { g.violations = c }
+}
+
+// NewGuard returns a Guard configured by the passed options.
+func NewGuard(options ...GuardOption) *Guard {
+	g := &Guard{
+		allowed: map[string]map[string]struct{}{},
+		seen:    map[string]map[string]struct{}{},
+		limit:   100,
+		other:   Other,
+	}
+	for _, option := range options {
+		option(g)
+	}
+	return g
+}
+
+// Filter returns a copy of the label values with rejected values rewritten.
+// Label values are expected as alternating name, value pairs, as passed to the
+// With methods of the metrics interfaces.
+func (g *Guard) Filter(labelValues ...string) []string {
+	out := make([]string, len(labelValues))
+	copy(out, labelValues)
+	for i := 0; i+1 < len(out); i += 2 {
+		if !g.accept(out[i], out[i+1]) {
+			out[i+1] = g.other
+			if g.violations != nil {
+				g.violations.With("label", out[i]).Add(1)
+			}
+		}
+	}
+	return out
+}
+
+func (g *Guard) accept(label, value string) bool {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+
+	if set, ok := g.allowed[label]; ok {
+		_, ok := set[value]
+		return ok
+	}
+
+	set, ok := g.seen[label]
+	if !ok {
+		set = map[string]struct{}{}
+		g.seen[label] = set
+	}
+	if _, ok := set[value]; ok {
+		return true
+	}
+	if g.limit > 0 && len(set) >= g.limit {
+		return false
+	}
+	set[value] = struct{}{}
+	return true
+}
+
+// Counter wraps a counter and filters its label values through a Guard.
+type Counter struct {
+	next  metrics.Counter
+	guard *Guard
+}
+
+// NewCounter returns a guarded counter, wrapping the passed counter.
+func NewCounter(next metrics.Counter, g *Guard) *Counter {
+	return &Counter{next: next, guard: g}
+}
+
+// With implements Counter.
+func (c *Counter) With(labelValues ...string) metrics.Counter {
+	return &Counter{
+		next:  c.next.With(c.guard.Filter(labelValues...)...),
+		guard: c.guard,
+	}
+}
+
+// Add implements Counter.
+func (c *Counter) Add(delta float64) { c.next.Add(delta) }
+
+// Gauge wraps a gauge and filters its label values through a Guard.
+type Gauge struct {
+	next  metrics.Gauge
+	guard *Guard
+}
+
+// NewGauge returns a guarded gauge, wrapping the passed gauge.
+func NewGauge(next metrics.Gauge, g *Guard) *Gauge {
+	return &Gauge{next: next, guard: g}
+}
+
+// With implements Gauge.
+func (g *Gauge) With(labelValues ...string) metrics.Gauge {
+	return &Gauge{
+		next:  g.next.With(g.guard.Filter(labelValues...)...),
+		guard: g.guard,
+	}
+}
+
+// Set implements Gauge.
+func (g *Gauge) Set(value float64) { g.next.Set(value) }
+
+// Add implements metrics.Gauge.
+func (g *Gauge) Add(delta float64) { g.next.Add(delta) }
+
+// Histogram wraps a histogram and filters its label values through a Guard.
+type Histogram struct {
+	next  metrics.Histogram
+	guard *Guard
+}
+
+// NewHistogram returns a guarded histogram, wrapping the passed histogram.
+func NewHistogram(next metrics.Histogram, g *Guard) *Histogram {
+	return &Histogram{next: next, guard: g}
+}
+
+// With implements Histogram.
+func (h *Histogram) With(labelValues ...string) metrics.Histogram {
+	return &Histogram{
+		next:  h.next.With(h.guard.Filter(labelValues...)...),
+		guard: h.guard,
+	}
+}
+
+// Observe implements Histogram.
+func (h *Histogram) Observe(value float64) { h.next.Observe(value) }
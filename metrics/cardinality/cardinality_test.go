@@ -0,0 +1,105 @@
+package cardinality
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/inturn/kit/metrics"
+	"github.com/inturn/kit/metrics/generic"
+)
+
+func TestAllowList(t *testing.T) {
+	g := NewGuard(Allow("method", "GET", "POST"))
+
+	for _, tc := range []struct {
+		in, want []string
+	}{
+		{[]string{"method", "GET"}, []string{"method", "GET"}},
+		{[]string{"method", "POST"}, []string{"method", "POST"}},
+		{[]string{"method", "PATCH"}, []string{"method", Other}},
+	} {
+		if want, have := tc.want, g.Filter(tc.in...); !reflect.DeepEqual(want, have) {
+			t.Errorf("%v: want %v, have %v", tc.in, want, have)
+		}
+	}
+}
+
+func TestLimit(t *testing.T) {
+	g := NewGuard(Limit(2), OtherValue("overflow"))
+
+	for _, tc := range []struct {
+		in, want []string
+	}{
+		{[]string{"tenant", "a"}, []string{"tenant", "a"}},
+		{[]string{"tenant", "b"}, []string{"tenant", "b"}},
+		{[]string{"tenant", "c"}, []string{"tenant", "overflow"}},
+		{[]string{"tenant", "a"}, []string{"tenant", "a"}},
+		{[]string{"region", "c"}, []string{"region", "c"}},
+	} {
+		if want, have := tc.want, g.Filter(tc.in...); !reflect.DeepEqual(want, have) {
+			t.Errorf("%v: want %v, have %v", tc.in, want, have)
+		}
+	}
+}
+
+func TestViolationsLabel(t *testing.T) {
+	violations := &recordingCounter{}
+	g := NewGuard(Allow("method", "GET"), Violations(violations))
+	g.Filter("method", "TRACE")
+	g.Filter("method", "GET")
+	g.Filter("method", "HEAD")
+
+	if want, have := []string{"label", "method", "label", "method"}, violations.lvs; !reflect.DeepEqual(want, have) {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	if want, have := 2.0, violations.total; want != have {
+		t.Errorf("want %f, have %f", want, have)
+	}
+}
+
+func TestCounter(t *testing.T) {
+	g := NewGuard(Allow("code", "200", "500"))
+	c := NewCounter(generic.NewCounter("requests"), g)
+
+	scoped := c.With("code", "418").(*Counter).next.(*generic.Counter)
+	if want, have := []string{"code", Other}, scoped.LabelValues(); !reflect.DeepEqual(want, have) {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	scoped.Add(1)
+	if want, have := 1.0, scoped.Value(); want != have {
+		t.Errorf("want %f, have %f", want, have)
+	}
+}
+
+func TestGauge(t *testing.T) {
+	g := NewGuard(Limit(1))
+	gauge := NewGauge(generic.NewGauge("depth"), g)
+	gauge.With("queue", "a")
+
+	scoped := gauge.With("queue", "b").(*Gauge).next.(*generic.Gauge)
+	if want, have := []string{"queue", Other}, scoped.LabelValues(); !reflect.DeepEqual(want, have) {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	g := NewGuard(Allow("method", "GET"))
+	h := NewHistogram(generic.NewHistogram("latency", 50), g)
+
+	scoped := h.With("method", "GET", "path", "/users/123").(*Histogram).next.(*generic.Histogram)
+	if want, have := []string{"method", "GET", "path", "/users/123"}, scoped.LabelValues(); !reflect.DeepEqual(want, have) {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
+
+type recordingCounter struct {
+	lvs   []string
+	total float64
+}
+
+func (c *recordingCounter) With(labelValues ...string) metrics.Counter {
+	c.lvs = append(c.lvs, labelValues...)
+	return c
+}
+
+func (c *recordingCounter) Add(delta float64) { c.total += delta }
@@ -0,0 +1,189 @@
+package generic
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/inturn/kit/metrics"
+	"github.com/inturn/kit/metrics/internal/lv"
+)
+
+// DefaultObjectives are the quantiles reported by a WindowedHistogram when no
+// objectives are given.
+var DefaultObjectives = []float64{0.5, 0.9, 0.95, 0.99}
+
+const (
+	windowAgeBuckets = 5
+	windowMaxSamples = 1024
+)
+
+// WindowedHistogram is an in-memory histogram that computes quantiles over a
+// sliding time window, like a Prometheus Summary with MaxAge. It is intended
+// for backends that only accept pre-computed percentiles, where quantiles over
+// the entire lifetime of the process would be meaningless.
+//
+// The window is divided into five age buckets which are rotated out as they
+// expire, so observations leave the window in steps of one fifth of its
+// duration. Each age bucket retains at most 1024 uniformly sampled
+// observations, which bounds memory regardless of the observation rate.
+type WindowedHistogram struct {
+	Name string
+	lvs  lv.LabelValues
+	w    *window
+}
+
+// NewWindowedHistogram returns a histogram reporting the given quantile
+// objectives, 0.0 < q < 1.0, over the given window. If no objectives are
+// given, DefaultObjectives are used.
+func NewWindowedHistogram(name string, window time.Duration, objectives ...float64) *WindowedHistogram {
+	if len(objectives) == 0 {
+		objectives = DefaultObjectives
+	}
+	return &WindowedHistogram{
+		Name: name,
+		w:    newWindow(window, objectives),
+	}
+}
+
+// With implements Histogram.
+func (h *WindowedHistogram) With(labelValues ...string) metrics.Histogram {
+	return &WindowedHistogram{
+		Name: h.Name,
+		lvs:  h.lvs.With(labelValues...),
+		w:    h.w,
+	}
+}
+
+// Observe implements Histogram.
+func (h *WindowedHistogram) Observe(value float64) {
+	h.w.observe(value, time.Now())
+}
+
+// Quantile returns the value of the quantile q, 0.0 < q < 1.0, over the
+// observations currently in the window. It returns NaN if the window is
+// empty.
+func (h *WindowedHistogram) Quantile(q float64) float64 {
+	return quantile(h.w.samples(time.Now()), q)
+}
+
+// Quantiles returns the value of every configured objective over the
+// observations currently in the window.
+func (h *WindowedHistogram) Quantiles() map[float64]float64 {
+	samples := h.w.samples(time.Now())
+	m := make(map[float64]float64, len(h.w.objectives))
+	for _, q := range h.w.objectives {
+		m[q] = quantile(samples, q)
+	}
+	return m
+}
+
+// Objectives returns the quantiles configured for the histogram.
+func (h *WindowedHistogram) Objectives() []float64 {
+	return append([]float64(nil), h.w.objectives...)
+}
+
+// Count returns the number of observations made in the current window.
+func (h *WindowedHistogram) Count() uint64 {
+	h.w.mtx.Lock()
+	defer h.w.mtx.Unlock()
+	h.w.rotate(time.Now())
+	var n uint64
+	for _, b := range h.w.buckets {
+		n += b.n
+	}
+	return n
+}
+
+// LabelValues returns the set of label values attached to the histogram.
+func (h *WindowedHistogram) LabelValues() []string {
+	return h.lvs
+}
+
+type ageBucket struct {
+	values []float64
+	n      uint64 // total observations, including those not sampled
+}
+
+type window struct {
+	mtx        sync.Mutex
+	objectives []float64
+	width      time.Duration
+	buckets    [windowAgeBuckets]ageBucket
+	head       int
+	headStart  time.Time
+}
+
+func newWindow(d time.Duration, objectives []float64) *window {
+	width := d / windowAgeBuckets
+	if width <= 0 {
+		width = 1
+	}
+	return &window{
+		objectives: append([]float64(nil), objectives...),
+		width:      width,
+		headStart:  time.Now(),
+	}
+}
+
+// rotate clears every age bucket that expired by now. It must be called with
+// the lock held.
+func (w *window) rotate(now time.Time) {
+	for now.Sub(w.headStart) >= w.width {
+		w.head = (w.head + 1) % windowAgeBuckets
+		w.buckets[w.head] = ageBucket{}
+		w.headStart = w.headStart.Add(w.width)
+		if now.Sub(w.headStart) >= w.width*windowAgeBuckets {
+			// Idle for longer than the whole window: everything expired.
+			w.buckets = [windowAgeBuckets]ageBucket{}
+			w.headStart = now
+		}
+	}
+}
+
+func (w *window) observe(value float64, now time.Time) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	w.rotate(now)
+
+	b := &w.buckets[w.head]
+	b.n++
+	if len(b.values) < windowMaxSamples {
+		b.values = append(b.values, value)
+		return
+	}
+	// Reservoir sampling keeps a uniform sample of the age bucket.
+	if i := rand.Int63n(int64(b.n)); i < windowMaxSamples {
+		b.values[i] = value
+	}
+}
+
+func (w *window) samples(now time.Time) []float64 {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	w.rotate(now)
+
+	var all []float64
+	for _, b := range w.buckets {
+		all = append(all, b.values...)
+	}
+	sort.Float64s(all)
+	return all
+}
+
+// quantile returns the nearest-rank quantile of the sorted samples.
+func quantile(sorted []float64, q float64) float64 {
+	if len(sorted) == 0 {
+		return math.NaN()
+	}
+	idx := int(math.Ceil(q*float64(len(sorted)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
@@ -0,0 +1,59 @@
+package generic_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/inturn/kit/metrics/generic"
+	"github.com/inturn/kit/metrics/teststat"
+)
+
+func TestWindowedHistogram(t *testing.T) {
+	name := "my_windowed_histogram"
+	histogram := generic.NewWindowedHistogram(name, time.Minute).With("label", "histogram").(*generic.WindowedHistogram)
+	if want, have := name, histogram.Name; want != have {
+		t.Errorf("Name: want %q, have %q", want, have)
+	}
+	quantiles := func() (float64, float64, float64, float64) {
+		return histogram.Quantile(0.50), histogram.Quantile(0.90), histogram.Quantile(0.95), histogram.Quantile(0.99)
+	}
+	// The age bucket keeps a random sample of 1024 of the observations, so
+	// the standard error of the estimated p99 is about 0.5%. Allow for five
+	// of them, rather than the 1% of histograms keeping every observation.
+	if err := teststat.TestHistogram(histogram, quantiles, 0.025); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWindowedHistogramObjectives(t *testing.T) {
+	histogram := generic.NewWindowedHistogram("objectives", time.Minute, 0.5, 1)
+	for i := 1; i <= 10; i++ {
+		histogram.Observe(float64(i))
+	}
+	want := map[float64]float64{0.5: 5, 1: 10}
+	have := histogram.Quantiles()
+	for q, v := range want {
+		if have[q] != v {
+			t.Errorf("q%v: want %f, have %f", q, v, have[q])
+		}
+	}
+	if want, have := uint64(10), histogram.Count(); want != have {
+		t.Errorf("Count: want %d, have %d", want, have)
+	}
+}
+
+func TestWindowedHistogramExpiry(t *testing.T) {
+	histogram := generic.NewWindowedHistogram("expiry", 25*time.Millisecond)
+	histogram.Observe(123)
+	if want, have := 123.0, histogram.Quantile(0.5); want != have {
+		t.Errorf("want %f, have %f", want, have)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if have := histogram.Quantile(0.5); !math.IsNaN(have) {
+		t.Errorf("want NaN, have %f", have)
+	}
+	if want, have := uint64(0), histogram.Count(); want != have {
+		t.Errorf("Count: want %d, have %d", want, have)
+	}
+}
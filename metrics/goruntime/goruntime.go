@@ -0,0 +1,147 @@
+// Package goruntime reports Go runtime and process statistics through any
+// metrics backend supported by package metrics/provider. Prometheus users
+// already get these statistics from the default collectors in client_golang;
+// this package exists so that push-based and dimensionless backends like
+// StatsD, Graphite, and CloudWatch get the same visibility.
+//
+//    p := provider.NewStatsdProvider(s, stop)
+//    stopRuntime := goruntime.Register(p, 10*time.Second)
+//    defer stopRuntime()
+//
+package goruntime
+
+import (
+	"io/ioutil"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/inturn/kit/metrics"
+)
+
+// Provider constructs the metrics reported by a Collector. It is a subset of
+// provider.Provider, so any Provider from that package may be passed directly,
+// without this package depending on every supported backend.
+type Provider interface {
+	NewCounter(name string) metrics.Counter
+	NewGauge(name string) metrics.Gauge
+	NewHistogram(name string, buckets int) metrics.Histogram
+}
+
+// Collector samples runtime statistics into metrics created by a Provider.
+type Collector struct {
+	goroutines  metrics.Gauge
+	threads     metrics.Gauge
+	heapAlloc   metrics.Gauge
+	heapInuse   metrics.Gauge
+	heapObjects metrics.Gauge
+	sys         metrics.Gauge
+	openFDs     metrics.Gauge
+	gcCount     metrics.Counter
+	gcPause     metrics.Histogram
+
+	mtx    sync.Mutex
+	lastGC uint32
+}
+
+// NewCollector returns a Collector whose metrics are created by the passed
+// Provider. Metric names follow the Prometheus client conventions, e.g.
+// go_goroutines and go_gc_duration_seconds.
+func NewCollector(p Provider) *Collector {
+	return &Collector{
+		goroutines:  p.NewGauge("go_goroutines"),
+		threads:     p.NewGauge("go_threads"),
+		heapAlloc:   p.NewGauge("go_memstats_heap_alloc_bytes"),
+		heapInuse:   p.NewGauge("go_memstats_heap_inuse_bytes"),
+		heapObjects: p.NewGauge("go_memstats_heap_objects"),
+		sys:         p.NewGauge("go_memstats_sys_bytes"),
+		openFDs:     p.NewGauge("process_open_fds"),
+		gcCount:     p.NewCounter("go_gc_count"),
+		gcPause:     p.NewHistogram("go_gc_duration_seconds", 50),
+	}
+}
+
+// Collect takes a single sample of the runtime statistics. Every GC pause
+// that completed since the previous call is observed into the pause
+// histogram, up to the 256 most recent pauses retained by the runtime.
+func (c *Collector) Collect() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	c.goroutines.Set(float64(runtime.NumGoroutine()))
+	threads, _ := runtime.ThreadCreateProfile(nil)
+	c.threads.Set(float64(threads))
+	c.heapAlloc.Set(float64(ms.HeapAlloc))
+	c.heapInuse.Set(float64(ms.HeapInuse))
+	c.heapObjects.Set(float64(ms.HeapObjects))
+	c.sys.Set(float64(ms.Sys))
+
+	if n, ok := openFDs(); ok {
+		c.openFDs.Set(float64(n))
+	}
+
+	if ms.NumGC > c.lastGC {
+		delta := ms.NumGC - c.lastGC
+		c.gcCount.Add(float64(delta))
+		if delta > uint32(len(ms.PauseNs)) {
+			delta = uint32(len(ms.PauseNs))
+		}
+		for i := uint32(0); i < delta; i++ {
+			idx := (ms.NumGC - i + uint32(len(ms.PauseNs)) - 1) % uint32(len(ms.PauseNs))
+			c.gcPause.Observe(time.Duration(ms.PauseNs[idx]).Seconds())
+		}
+		c.lastGC = ms.NumGC
+	}
+}
+
+// CollectLoop is a helper method that invokes Collect every time the passed
+// channel fires. This method blocks until the channel is closed, so clients
+// probably want to run it in its own goroutine. For typical usage, create a
+// time.Ticker and pass its C channel to this method.
+func (c *Collector) CollectLoop(ch <-chan time.Time) {
+	for range ch {
+		c.Collect()
+	}
+}
+
+// Register creates a Collector from the Provider and samples it immediately
+// and then at every interval, in a new goroutine. The returned function stops
+// the sampling goroutine; it does not stop the Provider.
+func Register(p Provider, interval time.Duration) (stop func()) {
+	c := NewCollector(p)
+	c.Collect()
+
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				c.Collect()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ticker.Stop()
+			close(done)
+		})
+	}
+}
+
+// openFDs counts the entries in /proc/self/fd. It reports false on platforms
+// without procfs, in which case the gauge is left untouched.
+func openFDs() (int, bool) {
+	fds, err := ioutil.ReadDir("/proc/self/fd")
+	if err != nil {
+		return 0, false
+	}
+	return len(fds), true
+}
@@ -0,0 +1,79 @@
+package goruntime
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/inturn/kit/metrics"
+	"github.com/inturn/kit/metrics/generic"
+)
+
+func TestCollect(t *testing.T) {
+	p := newMockProvider()
+	c := NewCollector(p)
+
+	runtime.GC()
+	c.Collect()
+
+	if have := p.gauges["go_goroutines"].Value(); have < 1 {
+		t.Errorf("go_goroutines: want >= 1, have %f", have)
+	}
+	if have := p.gauges["go_memstats_heap_alloc_bytes"].Value(); have <= 0 {
+		t.Errorf("go_memstats_heap_alloc_bytes: want > 0, have %f", have)
+	}
+	if have := p.counters["go_gc_count"].Value(); have < 1 {
+		t.Errorf("go_gc_count: want >= 1, have %f", have)
+	}
+	if have := p.histograms["go_gc_duration_seconds"].ApproximateMovingAverage(); have < 0 {
+		t.Errorf("go_gc_duration_seconds: want >= 0, have %f", have)
+	}
+
+	before := p.counters["go_gc_count"].Value()
+	runtime.GC()
+	c.Collect()
+	if have := p.counters["go_gc_count"].Value(); have <= before {
+		t.Errorf("go_gc_count: want > %f, have %f", before, have)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	p := newMockProvider()
+	stop := Register(p, 1<<30)
+	defer stop()
+
+	if have := p.gauges["go_goroutines"].Value(); have < 1 {
+		t.Errorf("go_goroutines: want >= 1, have %f", have)
+	}
+	stop() // idempotent
+}
+
+type mockProvider struct {
+	counters   map[string]*generic.Counter
+	gauges     map[string]*generic.Gauge
+	histograms map[string]*generic.SimpleHistogram
+}
+
+func newMockProvider() *mockProvider {
+	return &mockProvider{
+		counters:   map[string]*generic.Counter{},
+		gauges:     map[string]*generic.Gauge{},
+		histograms: map[string]*generic.SimpleHistogram{},
+	}
+}
+
+func (p *mockProvider) NewCounter(name string) metrics.Counter {
+	p.counters[name] = generic.NewCounter(name)
+	return p.counters[name]
+}
+
+func (p *mockProvider) NewGauge(name string) metrics.Gauge {
+	p.gauges[name] = generic.NewGauge(name)
+	return p.gauges[name]
+}
+
+func (p *mockProvider) NewHistogram(name string, _ int) metrics.Histogram {
+	p.histograms[name] = generic.NewSimpleHistogram()
+	return p.histograms[name]
+}
+
+func (p *mockProvider) Stop() {}
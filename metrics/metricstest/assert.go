@@ -0,0 +1,75 @@
+package metricstest
+
+import (
+	"math"
+	"testing"
+)
+
+// Tolerance is the maximum absolute difference at which the assertion helpers
+// consider two float64 values equal.
+const Tolerance = 1e-9
+
+// AssertCounter fails the test if the counter's value for the label set
+// differs from want.
+func AssertCounter(t testing.TB, c *Counter, want float64, labelValues ...string) {
+	t.Helper()
+	if have := c.Value(labelValues...); !equal(want, have) {
+		t.Errorf("%s%v: want %f, have %f", c.Name, labelValues, want, have)
+	}
+}
+
+// AssertGauge fails the test if the gauge's value for the label set differs
+// from want.
+func AssertGauge(t testing.TB, g *Gauge, want float64, labelValues ...string) {
+	t.Helper()
+	if have := g.Value(labelValues...); !equal(want, have) {
+		t.Errorf("%s%v: want %f, have %f", g.Name, labelValues, want, have)
+	}
+}
+
+// AssertHistogramCount fails the test if the number of observations made by
+// the histogram for the label set differs from want.
+func AssertHistogramCount(t testing.TB, h *Histogram, want int, labelValues ...string) {
+	t.Helper()
+	if have := h.Count(labelValues...); want != have {
+		t.Errorf("%s%v: want %d observations, have %d", h.Name, labelValues, want, have)
+	}
+}
+
+// AssertHistogramSum fails the test if the sum of observations made by the
+// histogram for the label set differs from want.
+func AssertHistogramSum(t testing.TB, h *Histogram, want float64, labelValues ...string) {
+	t.Helper()
+	if have := h.Sum(labelValues...); !equal(want, have) {
+		t.Errorf("%s%v: want sum %f, have %f", h.Name, labelValues, want, have)
+	}
+}
+
+// AssertBucketCounts fails the test if the cumulative bucket counts of the
+// histogram for the label set differ from want. Keys of want are the
+// inclusive upper bounds of each bucket.
+func AssertBucketCounts(t testing.TB, h *Histogram, want map[float64]int, labelValues ...string) {
+	t.Helper()
+	for upper, n := range want {
+		if have := h.BucketCount(upper, labelValues...); n != have {
+			t.Errorf("%s%v: bucket le=%g: want %d, have %d", h.Name, labelValues, upper, n, have)
+		}
+	}
+}
+
+// AssertNotObserved fails the test if the metric recorded anything under the
+// label set. It accepts a *Counter, *Gauge, or *Histogram.
+func AssertNotObserved(t testing.TB, m interface{ LabelSets() [][]string }, labelValues ...string) {
+	t.Helper()
+	k := key(labelValues)
+	for _, lvs := range m.LabelSets() {
+		if key(lvs) == k {
+			t.Errorf("%v: want no observations, have some", labelValues)
+			return
+		}
+	}
+}
+
+func equal(want, have float64) bool {
+	return math.Abs(want-have) <= Tolerance
+}
@@ -0,0 +1,235 @@
+// Package metricstest provides in-memory metrics for unit-testing code that is
+// instrumented with the metrics interfaces, like endpoint and transport
+// middlewares. Unlike package generic, these metrics remember every
+// observation by label set, so tests can assert on exactly what was recorded
+// under which labels.
+//
+//    requests := metricstest.NewCounter("requests")
+//    mw := NewInstrumentingMiddleware(requests)
+//    // ... exercise mw ...
+//    metricstest.AssertCounter(t, requests, 1, "method", "Add", "error", "false")
+//
+// Label sets are compared without regard to the order in which label pairs
+// were passed to With.
+package metricstest
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/inturn/kit/metrics"
+	"github.com/inturn/kit/metrics/internal/lv"
+)
+
+// Counter is an in-memory counter that records values per label set.
+type Counter struct {
+	Name string
+	lvs  lv.LabelValues
+	s    *store
+}
+
+// NewCounter returns a new, usable Counter.
+func NewCounter(name string) *Counter {
+	return &Counter{Name: name, s: newStore()}
+}
+
+// With implements Counter. The returned counter shares storage with the
+// parent, so values remain visible through the parent's accessors.
+func (c *Counter) With(labelValues ...string) metrics.Counter {
+	return &Counter{Name: c.Name, lvs: with(c.lvs, labelValues), s: c.s}
+}
+
+// Add implements Counter.
+func (c *Counter) Add(delta float64) {
+	c.s.update(c.lvs, func(e *entry) { e.value += delta })
+}
+
+// Value returns the value of the counter for the given label set.
+func (c *Counter) Value(labelValues ...string) float64 {
+	return c.s.get(labelValues).value
+}
+
+// LabelSets returns every label set the counter has been updated with.
+func (c *Counter) LabelSets() [][]string {
+	return c.s.labelSets()
+}
+
+// Gauge is an in-memory gauge that records values per label set.
+type Gauge struct {
+	Name string
+	lvs  lv.LabelValues
+	s    *store
+}
+
+// NewGauge returns a new, usable Gauge.
+func NewGauge(name string) *Gauge {
+	return &Gauge{Name: name, s: newStore()}
+}
+
+// With implements Gauge. The returned gauge shares storage with the parent.
+func (g *Gauge) With(labelValues ...string) metrics.Gauge {
+	return &Gauge{Name: g.Name, lvs: with(g.lvs, labelValues), s: g.s}
+}
+
+// Set implements Gauge.
+func (g *Gauge) Set(value float64) {
+	g.s.update(g.lvs, func(e *entry) { e.value = value })
+}
+
+// Add implements metrics.Gauge.
+func (g *Gauge) Add(delta float64) {
+	g.s.update(g.lvs, func(e *entry) { e.value += delta })
+}
+
+// Value returns the value of the gauge for the given label set.
+func (g *Gauge) Value(labelValues ...string) float64 {
+	return g.s.get(labelValues).value
+}
+
+// LabelSets returns every label set the gauge has been updated with.
+func (g *Gauge) LabelSets() [][]string {
+	return g.s.labelSets()
+}
+
+// Histogram is an in-memory histogram that records every observation per
+// label set.
+type Histogram struct {
+	Name string
+	lvs  lv.LabelValues
+	s    *store
+}
+
+// NewHistogram returns a new, usable Histogram.
+func NewHistogram(name string) *Histogram {
+	return &Histogram{Name: name, s: newStore()}
+}
+
+// With implements Histogram. The returned histogram shares storage with the
+// parent.
+func (h *Histogram) With(labelValues ...string) metrics.Histogram {
+	return &Histogram{Name: h.Name, lvs: with(h.lvs, labelValues), s: h.s}
+}
+
+// Observe implements Histogram.
+func (h *Histogram) Observe(value float64) {
+	h.s.update(h.lvs, func(e *entry) {
+		e.value += value
+		e.observations = append(e.observations, value)
+	})
+}
+
+// Observations returns a copy of the observations for the given label set, in
+// the order they were made.
+func (h *Histogram) Observations(labelValues ...string) []float64 {
+	obs := h.s.get(labelValues).observations
+	return append([]float64(nil), obs...)
+}
+
+// Count returns the number of observations for the given label set.
+func (h *Histogram) Count(labelValues ...string) int {
+	return len(h.s.get(labelValues).observations)
+}
+
+// Sum returns the sum of observations for the given label set.
+func (h *Histogram) Sum(labelValues ...string) float64 {
+	return h.s.get(labelValues).value
+}
+
+// BucketCount returns the number of observations for the given label set that
+// are less than or equal to the upper bound, i.e. the cumulative count of a
+// Prometheus-style bucket.
+func (h *Histogram) BucketCount(upper float64, labelValues ...string) int {
+	var n int
+	for _, v := range h.s.get(labelValues).observations {
+		if v <= upper {
+			n++
+		}
+	}
+	return n
+}
+
+// LabelSets returns every label set the histogram has observed values with.
+func (h *Histogram) LabelSets() [][]string {
+	return h.s.labelSets()
+}
+
+// with scopes lvs by labelValues without sharing lvs' backing array, so
+// sibling metrics derived from the same parent never overwrite each other.
+func with(lvs lv.LabelValues, labelValues []string) lv.LabelValues {
+	return append(lv.LabelValues(nil), lvs...).With(labelValues...)
+}
+
+type entry struct {
+	lvs          []string
+	value        float64
+	observations []float64
+}
+
+type store struct {
+	mtx     sync.RWMutex
+	entries map[string]*entry
+}
+
+func newStore() *store {
+	return &store{entries: map[string]*entry{}}
+}
+
+func (s *store) update(labelValues []string, f func(*entry)) {
+	lvs := canonical(labelValues)
+	k := strings.Join(lvs, "\x00")
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	e, ok := s.entries[k]
+	if !ok {
+		e = &entry{lvs: lvs}
+		s.entries[k] = e
+	}
+	f(e)
+}
+
+func (s *store) get(labelValues []string) entry {
+	k := key(labelValues)
+
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	if e, ok := s.entries[k]; ok {
+		return *e
+	}
+	return entry{}
+}
+
+func (s *store) labelSets() [][]string {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	sets := make([][]string, 0, len(s.entries))
+	for _, e := range s.entries {
+		sets = append(sets, append([]string{}, e.lvs...))
+	}
+	sort.Slice(sets, func(i, j int) bool {
+		return strings.Join(sets[i], "\x00") < strings.Join(sets[j], "\x00")
+	})
+	return sets
+}
+
+// key returns a map key identifying the label set.
+func key(labelValues []string) string {
+	return strings.Join(canonical(labelValues), "\x00")
+}
+
+// canonical returns the label values with pairs sorted by label name, so that
+// equivalent label sets map to the same key regardless of With order.
+func canonical(labelValues []string) []string {
+	lvs := with(nil, labelValues)
+	pairs := make([][2]string, 0, len(lvs)/2)
+	for i := 0; i < len(lvs); i += 2 {
+		pairs = append(pairs, [2]string{lvs[i], lvs[i+1]})
+	}
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i][0] < pairs[j][0] })
+	out := make([]string, 0, len(lvs))
+	for _, p := range pairs {
+		out = append(out, p[0], p[1])
+	}
+	return out
+}
@@ -0,0 +1,67 @@
+package metricstest
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCounter(t *testing.T) {
+	c := NewCounter("requests")
+	c.With("method", "GET", "code", "200").Add(1)
+	c.With("code", "200", "method", "GET").Add(2)
+	c.With("method", "POST", "code", "500").Add(1)
+	c.Add(5)
+
+	AssertCounter(t, c, 3, "method", "GET", "code", "200")
+	AssertCounter(t, c, 1, "code", "500", "method", "POST")
+	AssertCounter(t, c, 5)
+	AssertCounter(t, c, 0, "method", "PUT")
+	AssertNotObserved(t, c, "method", "PUT")
+
+	want := [][]string{
+		{},
+		{"code", "200", "method", "GET"},
+		{"code", "500", "method", "POST"},
+	}
+	if have := c.LabelSets(); !reflect.DeepEqual(want, have) {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
+
+func TestSiblings(t *testing.T) {
+	c := NewCounter("requests")
+	parent := c.With("a", "1")
+	parent.With("b", "2").Add(1)
+	parent.With("b", "3").Add(1)
+
+	AssertCounter(t, c, 1, "a", "1", "b", "2")
+	AssertCounter(t, c, 1, "a", "1", "b", "3")
+}
+
+func TestGauge(t *testing.T) {
+	g := NewGauge("depth")
+	g.With("queue", "q").Set(10)
+	g.With("queue", "q").Add(-3)
+
+	AssertGauge(t, g, 7, "queue", "q")
+}
+
+func TestHistogram(t *testing.T) {
+	h := NewHistogram("latency")
+	for _, v := range []float64{0.1, 0.2, 0.5, 1, 2} {
+		h.With("method", "GET").Observe(v)
+	}
+
+	AssertHistogramCount(t, h, 5, "method", "GET")
+	AssertHistogramSum(t, h, 3.8, "method", "GET")
+	AssertBucketCounts(t, h, map[float64]int{0.25: 2, 1: 4, 10: 5}, "method", "GET")
+	if want, have := []float64{0.1, 0.2, 0.5, 1, 2}, h.Observations("method", "GET"); !reflect.DeepEqual(want, have) {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
+
+func TestOddLabelValues(t *testing.T) {
+	c := NewCounter("requests")
+	c.With("method").Add(1)
+	AssertCounter(t, c, 1, "method", "unknown")
+}
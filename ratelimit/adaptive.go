@@ -0,0 +1,159 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/inturn/kit/endpoint"
+)
+
+// ErrConcurrencyLimited is returned in the request path when the adaptive
+// concurrency limit is reached and the request is rejected.
+var ErrConcurrencyLimited = ShedError{Reason: "concurrency limit exceeded"}
+
+// AdaptiveLimiter limits the number of requests in flight to a limit it
+// adjusts continuously from the observed latencies, following the gradient
+// algorithm of Netflix' concurrency-limits. While latency stays close to its
+// long term average the limit grows; when requests start queuing and latency
+// rises above it, the limit shrinks, so excess load is rejected instead of
+// queuing up.
+type AdaptiveLimiter struct {
+	minLimit  float64
+	maxLimit  float64
+	tolerance float64
+	smoothing float64
+	queueSize func(limit float64) float64
+
+	mtx      sync.Mutex
+	limit    float64
+	inflight int
+	longRTT  float64 // exponential moving average of the latency in ns
+	samples  int
+}
+
+// AdaptiveOption sets an optional parameter for AdaptiveLimiters.
+type AdaptiveOption func(*AdaptiveLimiter)
+
+// AdaptiveInitialLimit sets the limit to start with. The default is 20.
+func AdaptiveInitialLimit(n int) AdaptiveOption {
+	return func(l *AdaptiveLimiter) { l.limit = float64(n) }
+}
+
+// AdaptiveMinLimit sets the lower bound of the limit. The default is 1.
+func AdaptiveMinLimit(n int) AdaptiveOption {
+	return func(l *AdaptiveLimiter) { l.minLimit = float64(n) }
+}
+
+// AdaptiveMaxLimit sets the upper bound of the limit. The default is 1000.
+func AdaptiveMaxLimit(n int) AdaptiveOption {
+	return func(l *AdaptiveLimiter) { l.maxLimit = float64(n) }
+}
+
+// AdaptiveTolerance sets by which factor latency may exceed its long term
+// average before the limit shrinks. The default is 1.5.
+func AdaptiveTolerance(f float64) AdaptiveOption {
+	return func(l *AdaptiveLimiter) { l.tolerance = f }
+}
+
+// AdaptiveSmoothing sets the weight of each new limit estimate, between 0 and
+// 1. Lower values adjust the limit more slowly. The default is 0.2.
+func AdaptiveSmoothing(f float64) AdaptiveOption {
+	return func(l *AdaptiveLimiter) { l.smoothing = f }
+}
+
+// NewAdaptiveLimiter returns an AdaptiveLimiter.
+func NewAdaptiveLimiter(options ...AdaptiveOption) *AdaptiveLimiter {
+	l := &AdaptiveLimiter{
+		minLimit:  1,
+		maxLimit:  1000,
+		tolerance: 1.5,
+		smoothing: 0.2,
+		queueSize: math.Sqrt,
+		limit:     20,
+	}
+	for _, option := range options {
+		option(l)
+	}
+	l.limit = math.Max(l.minLimit, math.Min(l.maxLimit, l.limit))
+	return l
+}
+
+// Acquire reserves a slot for a request if the limit allows. Every
+// successful Acquire must be followed by a Release.
+func (l *AdaptiveLimiter) Acquire() bool {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if l.inflight >= int(l.limit) {
+		return false
+	}
+	l.inflight++
+	return true
+}
+
+// Release frees the slot of a request which took rtt and adjusts the limit.
+func (l *AdaptiveLimiter) Release(rtt time.Duration) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	inflight := l.inflight
+	l.inflight--
+
+	shortRTT := float64(rtt)
+	if shortRTT <= 0 {
+		return
+	}
+
+	// The long term average adapts over roughly the last 600 samples, and
+	// quicker while warming up.
+	l.samples++
+	window := math.Min(float64(l.samples), 600)
+	l.longRTT += (shortRTT - l.longRTT) / window
+
+	// If latency stayed high for long, the long term average has drifted
+	// upwards; pull it down faster so that the limit can recover.
+	if l.longRTT/shortRTT > 2 {
+		l.longRTT *= 0.95
+	}
+
+	// Don't grow the limit while it isn't used, the observed latencies say
+	// nothing about higher concurrency.
+	if float64(inflight) < l.limit/2 {
+		return
+	}
+
+	gradient := math.Max(0.5, math.Min(1, l.tolerance*l.longRTT/shortRTT))
+	estimate := l.limit*gradient + l.queueSize(l.limit)
+	limit := l.limit*(1-l.smoothing) + estimate*l.smoothing
+	l.limit = math.Max(l.minLimit, math.Min(l.maxLimit, limit))
+}
+
+// Limit returns the current limit.
+func (l *AdaptiveLimiter) Limit() int {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return int(l.limit)
+}
+
+// InFlight returns the number of requests holding a slot.
+func (l *AdaptiveLimiter) InFlight() int {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return l.inflight
+}
+
+// NewConcurrencyLimiter returns an endpoint.Middleware that limits the
+// number of concurrent requests to the adaptive limit of l. Requests beyond
+// the limit are rejected with ErrConcurrencyLimited.
+func NewConcurrencyLimiter(l *AdaptiveLimiter) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			if !l.Acquire() {
+				return nil, ErrConcurrencyLimited
+			}
+			defer func(begin time.Time) { l.Release(time.Since(begin)) }(time.Now())
+			return next(ctx, request)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/inturn/kit/ratelimit"
+)
+
+// saturate issues one round of requests using the whole limit, each taking
+// rtt, and returns the new limit.
+func saturate(l *ratelimit.AdaptiveLimiter, rtt time.Duration) int {
+	n := 0
+	for l.Acquire() {
+		n++
+	}
+	for i := 0; i < n; i++ {
+		l.Release(rtt)
+	}
+	return l.Limit()
+}
+
+func TestAdaptiveLimiter(t *testing.T) {
+	l := ratelimit.NewAdaptiveLimiter(ratelimit.AdaptiveInitialLimit(10), ratelimit.AdaptiveMaxLimit(100))
+
+	// steady latency under full use grows the limit up to the maximum
+	for i := 0; i < 50; i++ {
+		saturate(l, 10*time.Millisecond)
+	}
+	if want, have := 100, l.Limit(); want != have {
+		t.Fatalf("want limit %d, have %d", want, have)
+	}
+
+	// queuing latency shrinks it
+	var limit int
+	for i := 0; i < 10; i++ {
+		limit = saturate(l, 50*time.Millisecond)
+	}
+	if limit >= 50 {
+		t.Errorf("want limit to shrink, have %d", limit)
+	}
+	if want, have := 0, l.InFlight(); want != have {
+		t.Errorf("want %d in flight, have %d", want, have)
+	}
+}
+
+func TestAdaptiveLimiterIdle(t *testing.T) {
+	l := ratelimit.NewAdaptiveLimiter(ratelimit.AdaptiveInitialLimit(10))
+
+	// a mostly idle limiter doesn't grow its limit
+	for i := 0; i < 100; i++ {
+		l.Acquire()
+		l.Release(10 * time.Millisecond)
+	}
+	if want, have := 10, l.Limit(); want != have {
+		t.Errorf("want limit %d, have %d", want, have)
+	}
+}
+
+func TestConcurrencyLimiter(t *testing.T) {
+	l := ratelimit.NewAdaptiveLimiter(ratelimit.AdaptiveInitialLimit(2), ratelimit.AdaptiveMaxLimit(2))
+
+	var (
+		release = make(chan struct{})
+		started = make(chan struct{})
+	)
+	e := ratelimit.NewConcurrencyLimiter(l)(func(context.Context, interface{}) (interface{}, error) {
+		started <- struct{}{}
+		<-release
+		return struct{}{}, nil
+	})
+
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := e(context.Background(), nil)
+			errs <- err
+		}()
+		<-started
+	}
+
+	if _, err := e(context.Background(), nil); err != ratelimit.ErrConcurrencyLimited {
+		t.Errorf("want %v, have %v", ratelimit.ErrConcurrencyLimited, err)
+	}
+	if !ratelimit.IsShed(ratelimit.ErrConcurrencyLimited) {
+		t.Error("want ErrConcurrencyLimited to be a ShedError")
+	}
+
+	close(release)
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+//go:build windows || plan9 || js || wasip1
+// +build windows plan9 js wasip1
+
+package ratelimit
+
+import "time"
+
+// processCPUTime is not supported on this platform.
+func processCPUTime() (time.Duration, bool) {
+	return 0, false
+}
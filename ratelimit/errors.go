@@ -0,0 +1,38 @@
+package ratelimit
+
+import (
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ShedError is returned in the request path when a request is rejected to
+// protect an overloaded service. It is encoded as 503 Service Unavailable by
+// the HTTP transport and as codes.Unavailable by the gRPC transport, telling
+// clients to retry elsewhere or later.
+type ShedError struct {
+	// Reason describes why the request was shed.
+	Reason string
+}
+
+// Error implements the error interface.
+func (e ShedError) Error() string {
+	return e.Reason
+}
+
+// StatusCode implements the StatusCoder interface of the HTTP transport.
+func (ShedError) StatusCode() int {
+	return http.StatusServiceUnavailable
+}
+
+// GRPCStatus is recognized by gRPC when returning errors from a handler.
+func (e ShedError) GRPCStatus() *status.Status {
+	return status.New(codes.Unavailable, e.Error())
+}
+
+// IsShed reports whether err is a ShedError.
+func IsShed(err error) bool {
+	_, ok := err.(ShedError)
+	return ok
+}
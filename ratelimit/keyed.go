@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"context"
+
+	"github.com/inturn/kit/endpoint"
+	"github.com/inturn/kit/internal/lru"
+)
+
+// KeyFunc derives the key a request is limited by, e.g. a tenant ID, from the
+// context and the request.
+type KeyFunc func(ctx context.Context, request interface{}) string
+
+// ContextKey returns a KeyFunc using the string value of key in the context,
+// e.g. a tenant ID put there by an authentication middleware. Requests
+// without a value share the empty key.
+func ContextKey(key interface{}) KeyFunc {
+	return func(ctx context.Context, _ interface{}) string {
+		s, _ := ctx.Value(key).(string)
+		return s
+	}
+}
+
+// KeyedOption sets an optional parameter for keyed limiters.
+type KeyedOption func(*keyedConfig)
+
+type keyedConfig struct {
+	size int
+}
+
+// KeyedSize sets the maximum number of keys a limiter is kept for. If more
+// keys are seen, the limiter of the key not seen for the longest time is
+// discarded, and recreated the next time that key is seen. It defaults to
+// 10000.
+func KeyedSize(n int) KeyedOption {
+	return func(c *keyedConfig) { c.size = n }
+}
+
+// NewKeyedLimiter returns an endpoint.Middleware limiting requests per key,
+// so that one tenant can't use up the capacity of all others. The limiter of
+// a key is created by newLimiter when the key is first seen, e.g.
+//
+//	ratelimit.NewKeyedLimiter(ratelimit.ContextKey(TenantContextKey), func(string) endpoint.Middleware {
+//	    return ratelimit.NewErroringLimiter(rate.NewLimiter(10, 10))
+//	})
+func NewKeyedLimiter(key KeyFunc, newLimiter func(key string) endpoint.Middleware, options ...KeyedOption) endpoint.Middleware {
+	config := keyedConfig{size: 10000}
+	for _, option := range options {
+		option(&config)
+	}
+
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		endpoints := lru.New(config.size)
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			k := key(ctx, request)
+			e := endpoints.GetOrCreate(k, func() interface{} {
+				return newLimiter(k)(next)
+			}).(endpoint.Endpoint)
+			return e(ctx, request)
+		}
+	}
+}
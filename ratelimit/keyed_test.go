@@ -0,0 +1,43 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/inturn/kit/endpoint"
+	"github.com/inturn/kit/ratelimit"
+)
+
+type tenantKey struct{}
+
+func TestKeyedLimiter(t *testing.T) {
+	var (
+		created int
+		e       = ratelimit.NewKeyedLimiter(ratelimit.ContextKey(tenantKey{}), func(string) endpoint.Middleware {
+			created++
+			return ratelimit.NewErroringLimiter(rate.NewLimiter(rate.Every(time.Minute), 1))
+		}, ratelimit.KeyedSize(2))(nopEndpoint)
+		tenant = func(id string) context.Context { return context.WithValue(context.Background(), tenantKey{}, id) }
+	)
+
+	for _, tc := range []struct {
+		tenant string
+		want   error
+	}{
+		{"a", nil},
+		{"a", ratelimit.ErrLimited}, // a is limited
+		{"b", nil},                  // but not b
+		{"c", nil},                  // evicts a
+		{"a", nil},                  // which starts over
+	} {
+		if _, have := e(tenant(tc.tenant), nil); tc.want != have {
+			t.Errorf("tenant %s: want %v, have %v", tc.tenant, tc.want, have)
+		}
+	}
+	if want, have := 4, created; want != have {
+		t.Errorf("want %d limiters created, have %d", want, have)
+	}
+}
@@ -0,0 +1,93 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/inturn/kit/endpoint"
+	"github.com/inturn/kit/metrics"
+	"github.com/inturn/kit/metrics/discard"
+)
+
+// RateOption sets an optional parameter for NewRateLimiter.
+type RateOption func(*rateConfig)
+
+type rateConfig struct {
+	maxWait   time.Duration
+	throttled metrics.Counter
+	delay     metrics.Histogram
+}
+
+// RateMaxWait sets how long requests may be delayed to stay within the rate
+// limit. Requests needing a longer delay, or a delay beyond the deadline of
+// their context, are rejected right away instead of waiting in vain. By
+// default, requests are not delayed at all and the limiter fails fast.
+func RateMaxWait(d time.Duration) RateOption {
+	return func(c *rateConfig) { c.maxWait = d }
+}
+
+// RateThrottled sets a counter incremented for every rejected request.
+func RateThrottled(c metrics.Counter) RateOption {
+	return func(cfg *rateConfig) { cfg.throttled = c }
+}
+
+// RateDelay sets a histogram observing the delay in seconds of every delayed
+// request.
+func RateDelay(h metrics.Histogram) RateOption {
+	return func(c *rateConfig) { c.delay = h }
+}
+
+// NewRateLimiter returns an endpoint.Middleware that limits the request rate
+// with limiter, which allows bursts of up to its burst size. Depending on
+// RateMaxWait, requests exceeding the rate are either rejected with
+// ErrLimited right away or delayed until a token is available. Delayed
+// requests whose context is canceled while waiting fail with the context's
+// error and return their token.
+func NewRateLimiter(limiter *rate.Limiter, options ...RateOption) endpoint.Middleware {
+	c := rateConfig{
+		throttled: discard.NewCounter(),
+		delay:     discard.NewHistogram(),
+	}
+	for _, option := range options {
+		option(&c)
+	}
+
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			r := limiter.Reserve()
+			if !r.OK() {
+				c.throttled.Add(1)
+				return nil, ErrLimited
+			}
+
+			delay := r.Delay()
+			if delay > 0 {
+				maxWait := c.maxWait
+				if deadline, ok := ctx.Deadline(); ok {
+					if remaining := time.Until(deadline); remaining < maxWait {
+						maxWait = remaining
+					}
+				}
+				if delay > maxWait {
+					r.Cancel()
+					c.throttled.Add(1)
+					return nil, ErrLimited
+				}
+
+				t := time.NewTimer(delay)
+				select {
+				case <-t.C:
+				case <-ctx.Done():
+					t.Stop()
+					r.Cancel()
+					return nil, ctx.Err()
+				}
+				c.delay.Observe(delay.Seconds())
+			}
+
+			return next(ctx, request)
+		}
+	}
+}
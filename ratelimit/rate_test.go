@@ -0,0 +1,74 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/inturn/kit/metrics/generic"
+	"github.com/inturn/kit/ratelimit"
+)
+
+func TestRateLimiterFailFast(t *testing.T) {
+	throttled := generic.NewCounter("throttled")
+	e := ratelimit.NewRateLimiter(rate.NewLimiter(rate.Every(time.Minute), 2), ratelimit.RateThrottled(throttled))(nopEndpoint)
+
+	// the burst passes, the rest is rejected
+	for i := 0; i < 2; i++ {
+		if _, err := e(context.Background(), nil); err != nil {
+			t.Fatalf("unexpected: %v", err)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := e(context.Background(), nil); err != ratelimit.ErrLimited {
+			t.Fatalf("want %v, have %v", ratelimit.ErrLimited, err)
+		}
+	}
+	if want, have := 3.0, throttled.Value(); want != have {
+		t.Errorf("want %f throttled, have %f", want, have)
+	}
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	var (
+		throttled = generic.NewCounter("throttled")
+		delay     = generic.NewHistogram("delay", 10)
+	)
+	limiter := rate.NewLimiter(rate.Every(20*time.Millisecond), 1)
+	e := ratelimit.NewRateLimiter(limiter,
+		ratelimit.RateMaxWait(time.Second),
+		ratelimit.RateThrottled(throttled),
+		ratelimit.RateDelay(delay),
+	)(nopEndpoint)
+
+	begin := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := e(context.Background(), nil); err != nil {
+			t.Fatalf("unexpected: %v", err)
+		}
+	}
+	if elapsed := time.Since(begin); elapsed < 30*time.Millisecond {
+		t.Errorf("want requests to be delayed, took %s", elapsed)
+	}
+	if want, have := 0.0, throttled.Value(); want != have {
+		t.Errorf("want %f throttled, have %f", want, have)
+	}
+	if have := delay.Quantile(0.5); have <= 0 {
+		t.Errorf("want delays to be observed, have %f", have)
+	}
+
+	// requests that couldn't make their deadline are rejected without waiting
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if _, err := e(ctx, nil); err != ratelimit.ErrLimited {
+		t.Errorf("want %v, have %v", ratelimit.ErrLimited, err)
+	}
+
+	// and return their token
+	time.Sleep(20 * time.Millisecond)
+	if _, err := e(context.Background(), nil); err != nil {
+		t.Errorf("unexpected: %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/inturn/kit/log"
+)
+
+// RedisScripter runs Lua scripts on a Redis server. Wrap the Eval command of
+// your Redis client of choice; the reply of the script is an array of
+// integers.
+type RedisScripter interface {
+	Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error)
+}
+
+// redisTokenBucket takes tokens from the bucket stored in the hash at
+// KEYS[1], refilling it first according to the time passed since the last
+// call. It replies whether the tokens were taken and, if not, how many
+// milliseconds until they are available.
+const redisTokenBucket = `
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local requested = tonumber(ARGV[4])
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate / 1000)
+
+local allowed = 0
+local wait = 0
+if tokens >= requested then
+	tokens = tokens - requested
+	allowed = 1
+else
+	wait = math.ceil((requested - tokens) * 1000 / rate)
+end
+
+redis.call("HMSET", KEYS[1], "tokens", tokens, "ts", math.max(now, ts))
+redis.call("PEXPIRE", KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+return {allowed, wait}
+`
+
+// RedisLimiter is a token bucket rate limiter whose state is kept in Redis,
+// so that all instances of a service share one limit, e.g. the rate limit of
+// a downstream API. Buckets are refilled using the clocks of the instances,
+// which should therefore be synchronized.
+//
+// RedisLimiter implements Allower and Waiter, so it can be used with
+// NewErroringLimiter and NewDelayingLimiter.
+type RedisLimiter struct {
+	client     RedisScripter
+	key        string
+	limit      rate.Limit
+	burst      int
+	failClosed bool
+	logger     log.Logger
+	now        func() time.Time
+}
+
+// RedisLimiterOption sets an optional parameter for RedisLimiters.
+type RedisLimiterOption func(*RedisLimiter)
+
+// RedisFailClosed makes the limiter reject requests when Redis can't be
+// reached. By default, requests are allowed, so that an outage of Redis
+// doesn't take down the service.
+func RedisFailClosed() RedisLimiterOption {
+	return func(l *RedisLimiter) { l.failClosed = true }
+}
+
+// RedisLogger sets the logger errors talking to Redis are logged to. By
+// default, no errors are logged.
+func RedisLogger(logger log.Logger) RedisLimiterOption {
+	return func(l *RedisLimiter) { l.logger = logger }
+}
+
+// NewRedisLimiter returns a RedisLimiter allowing limit events per second
+// with bursts of up to burst events, stored under key.
+func NewRedisLimiter(client RedisScripter, key string, limit rate.Limit, burst int, options ...RedisLimiterOption) *RedisLimiter {
+	l := &RedisLimiter{
+		client: client,
+		key:    key,
+		limit:  limit,
+		burst:  burst,
+		logger: log.NewNopLogger(),
+		now:    time.Now,
+	}
+	for _, option := range options {
+		option(l)
+	}
+	return l
+}
+
+// Take takes n tokens from the bucket if available. Otherwise it returns
+// how long until they are.
+func (l *RedisLimiter) Take(ctx context.Context, n int) (ok bool, retryAfter time.Duration, err error) {
+	if n > l.burst {
+		return false, 0, fmt.Errorf("ratelimit: %d tokens exceed burst of %d", n, l.burst)
+	}
+	now := l.now().UnixNano() / int64(time.Millisecond)
+	reply, err := l.client.Eval(ctx, redisTokenBucket, []string{l.key},
+		strconv.FormatFloat(float64(l.limit), 'f', -1, 64), l.burst, now, n)
+	if err != nil {
+		return false, 0, err
+	}
+
+	values, ok := reply.([]interface{})
+	if !ok || len(values) != 2 {
+		return false, 0, fmt.Errorf("ratelimit: unexpected reply %v", reply)
+	}
+	allowed, _ := values[0].(int64)
+	wait, _ := values[1].(int64)
+	return allowed == 1, time.Duration(wait) * time.Millisecond, nil
+}
+
+// Allow implements Allower.
+func (l *RedisLimiter) Allow() bool {
+	ok, _, err := l.Take(context.Background(), 1)
+	if err != nil {
+		l.logger.Log("err", err)
+		return !l.failClosed
+	}
+	return ok
+}
+
+// Wait implements Waiter. It waits until a token is available, unless that
+// would take longer than the deadline of ctx allows.
+func (l *RedisLimiter) Wait(ctx context.Context) error {
+	for {
+		ok, retryAfter, err := l.Take(ctx, 1)
+		if err != nil {
+			l.logger.Log("err", err)
+			if l.failClosed {
+				return err
+			}
+			return nil
+		}
+		if ok {
+			return nil
+		}
+
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < retryAfter {
+			return fmt.Errorf("ratelimit: Wait(n=1) would exceed context deadline")
+		}
+		// Other instances may take the token first, so try again when it
+		// should be available.
+		if retryAfter < time.Millisecond {
+			retryAfter = time.Millisecond
+		}
+		t := time.NewTimer(retryAfter)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gomodule/redigo/redis"
+	"golang.org/x/time/rate"
+
+	"github.com/inturn/kit/ratelimit"
+)
+
+type redigoScripter struct {
+	conn redis.Conn
+}
+
+func (s redigoScripter) Eval(_ context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	return redis.NewScript(len(keys), script).Do(s.conn, append(stringsToArgs(keys), args...)...)
+}
+
+func stringsToArgs(s []string) []interface{} {
+	args := make([]interface{}, len(s))
+	for i, v := range s {
+		args[i] = v
+	}
+	return args
+}
+
+func newRedisScripter(t *testing.T) (ratelimit.RedisScripter, func()) {
+	srv, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := redis.Dial("tcp", srv.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return redigoScripter{conn}, func() {
+		conn.Close()
+		srv.Close()
+	}
+}
+
+func TestRedisLimiter(t *testing.T) {
+	client, closer := newRedisScripter(t)
+	defer closer()
+
+	// two instances sharing a limit of 1 per second with bursts of 3
+	a := ratelimit.NewRedisLimiter(client, "downstream", 1, 3)
+	b := ratelimit.NewRedisLimiter(client, "downstream", 1, 3)
+
+	allowed := 0
+	for i := 0; i < 5; i++ {
+		if a.Allow() {
+			allowed++
+		}
+		if b.Allow() {
+			allowed++
+		}
+	}
+	if want, have := 3, allowed; want != have {
+		t.Errorf("want %d allowed, have %d", want, have)
+	}
+
+	ok, retryAfter, err := a.Take(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok || retryAfter <= 0 || retryAfter > time.Second {
+		t.Errorf("want retry within a second, have ok=%v retryAfter=%s", ok, retryAfter)
+	}
+
+	if _, _, err := a.Take(context.Background(), 4); err == nil {
+		t.Error("want error for tokens exceeding burst, have nil")
+	}
+}
+
+func TestRedisLimiterWait(t *testing.T) {
+	client, closer := newRedisScripter(t)
+	defer closer()
+
+	l := ratelimit.NewRedisLimiter(client, "downstream", rate.Every(20*time.Millisecond), 1)
+	e := ratelimit.NewDelayingLimiter(l)(nopEndpoint)
+
+	begin := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := e(context.Background(), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(begin); elapsed < 30*time.Millisecond {
+		t.Errorf("want requests to be delayed, took %s", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if _, err := e(ctx, nil); err == nil {
+		t.Error("want error for deadline before next token, have nil")
+	}
+}
+
+func TestRedisLimiterFailure(t *testing.T) {
+	client, closer := newRedisScripter(t)
+	closer()
+
+	if !ratelimit.NewRedisLimiter(client, "downstream", 1, 1).Allow() {
+		t.Error("want fail open by default")
+	}
+	if ratelimit.NewRedisLimiter(client, "downstream", 1, 1, ratelimit.RedisFailClosed()).Allow() {
+		t.Error("want fail closed")
+	}
+}
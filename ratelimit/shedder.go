@@ -0,0 +1,210 @@
+package ratelimit
+
+import (
+	"context"
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/inturn/kit/endpoint"
+)
+
+// ErrOverloaded is returned in the request path when a request is shed
+// because the service is overloaded.
+var ErrOverloaded = ShedError{Reason: "service overloaded"}
+
+type contextKey string
+
+// PriorityContextKey holds the Priority of a request. Requests without one
+// have PriorityNormal.
+const PriorityContextKey contextKey = "priority"
+
+// Priority is the importance of a request, deciding whether it may be shed.
+type Priority int
+
+// Priorities of requests.
+const (
+	PriorityLow    Priority = -1
+	PriorityNormal Priority = 0
+	PriorityHigh   Priority = 1
+)
+
+// WithPriority returns a copy of ctx carrying the priority p, e.g. to mark
+// batch jobs or health checks as PriorityLow.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, PriorityContextKey, p)
+}
+
+// PriorityFromContext returns the priority of the request.
+func PriorityFromContext(ctx context.Context) Priority {
+	if p, ok := ctx.Value(PriorityContextKey).(Priority); ok {
+		return p
+	}
+	return PriorityNormal
+}
+
+// Shedder rejects a growing fraction of low priority requests while the
+// service is overloaded, i.e. while process CPU utilization or the 99th
+// percentile latency of recent requests exceed their thresholds. The
+// fraction grows by a tenth every sample interval the service is overloaded
+// and shrinks by a tenth every interval it isn't. The recorded latencies are
+// discarded after an interval without executed requests, so a Shedder
+// rejecting all requests doesn't stay overloaded by stale latencies.
+type Shedder struct {
+	cpuThreshold     float64
+	latencyThreshold time.Duration
+	sheddable        Priority
+	interval         time.Duration
+	cpu              func() (time.Duration, bool)
+	now              func() time.Time
+
+	mtx       sync.Mutex
+	latencies []time.Duration // ring of recent latencies
+	next      int
+	observed  bool // whether a latency was recorded since the last sample
+	sampled   time.Time
+	cpuTime   time.Duration
+	tenths    int // fraction of sheddable requests rejected
+}
+
+// ShedderOption sets an optional parameter for Shedders.
+type ShedderOption func(*Shedder)
+
+// ShedderCPUThreshold sets the process CPU utilization, as a fraction of all
+// CPUs, above which the service is overloaded. The default is 0.8. Zero
+// disables shedding by CPU utilization, as does running on a platform where
+// it's not available.
+func ShedderCPUThreshold(f float64) ShedderOption {
+	return func(s *Shedder) { s.cpuThreshold = f }
+}
+
+// ShedderLatencyThreshold sets the 99th percentile latency above which the
+// service is overloaded. The percentile is computed over the last 1000
+// requests. By default, latency isn't considered.
+func ShedderLatencyThreshold(d time.Duration) ShedderOption {
+	return func(s *Shedder) { s.latencyThreshold = d }
+}
+
+// ShedderSheddable sets the highest priority of the requests that may be
+// shed. The default is PriorityLow.
+func ShedderSheddable(p Priority) ShedderOption {
+	return func(s *Shedder) { s.sheddable = p }
+}
+
+// ShedderInterval sets how often the load is sampled. The default is 250ms.
+func ShedderInterval(d time.Duration) ShedderOption {
+	return func(s *Shedder) { s.interval = d }
+}
+
+// NewShedder returns a Shedder.
+func NewShedder(options ...ShedderOption) *Shedder {
+	s := &Shedder{
+		cpuThreshold: 0.8,
+		sheddable:    PriorityLow,
+		interval:     250 * time.Millisecond,
+		cpu:          processCPUTime,
+		now:          time.Now,
+		latencies:    make([]time.Duration, 0, 1000),
+	}
+	for _, option := range options {
+		option(s)
+	}
+	s.sampled = s.now()
+	s.cpuTime, _ = s.cpu()
+	return s
+}
+
+// Allow reports whether a request with the priority in ctx should be
+// executed.
+func (s *Shedder) Allow(ctx context.Context) bool {
+	s.mtx.Lock()
+	s.sample()
+	fraction := float64(s.tenths) / 10
+	s.mtx.Unlock()
+
+	if fraction == 0 || PriorityFromContext(ctx) > s.sheddable {
+		return true
+	}
+	return rand.Float64() >= fraction
+}
+
+// Observe records the latency of an executed request.
+func (s *Shedder) Observe(latency time.Duration) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.observed = true
+	if len(s.latencies) < cap(s.latencies) {
+		s.latencies = append(s.latencies, latency)
+		return
+	}
+	s.latencies[s.next] = latency
+	s.next = (s.next + 1) % len(s.latencies)
+}
+
+// Fraction returns the fraction of sheddable requests currently rejected.
+func (s *Shedder) Fraction() float64 {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return float64(s.tenths) / 10
+}
+
+// sample adjusts the fraction of rejected requests once per interval. It
+// must be called with mtx held.
+func (s *Shedder) sample() {
+	now := s.now()
+	elapsed := now.Sub(s.sampled)
+	if elapsed < s.interval {
+		return
+	}
+	s.sampled = now
+
+	overloaded := false
+	if cpuTime, ok := s.cpu(); ok {
+		used := cpuTime - s.cpuTime
+		s.cpuTime = cpuTime
+		utilization := float64(used) / float64(elapsed) / float64(runtime.NumCPU())
+		overloaded = s.cpuThreshold > 0 && utilization > s.cpuThreshold
+	}
+	if !s.observed {
+		s.latencies, s.next = s.latencies[:0], 0
+	}
+	s.observed = false
+	if s.latencyThreshold > 0 && s.p99() > s.latencyThreshold {
+		overloaded = true
+	}
+
+	switch {
+	case overloaded && s.tenths < 10:
+		s.tenths++
+	case !overloaded && s.tenths > 0:
+		s.tenths--
+	}
+}
+
+// p99 returns the 99th percentile of the recorded latencies. It must be
+// called with mtx held.
+func (s *Shedder) p99() time.Duration {
+	if len(s.latencies) == 0 {
+		return 0
+	}
+	sorted := make([]time.Duration, len(s.latencies))
+	copy(sorted, s.latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[len(sorted)*99/100]
+}
+
+// NewLoadShedder returns an endpoint.Middleware rejecting requests with
+// ErrOverloaded as decided by s, and recording the latency of the others.
+func NewLoadShedder(s *Shedder) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			if !s.Allow(ctx) {
+				return nil, ErrOverloaded
+			}
+			defer func(begin time.Time) { s.Observe(time.Since(begin)) }(time.Now())
+			return next(ctx, request)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/inturn/kit/ratelimit"
+)
+
+func TestLoadShedder(t *testing.T) {
+	var (
+		s = ratelimit.NewShedder(
+			ratelimit.ShedderCPUThreshold(0),
+			ratelimit.ShedderLatencyThreshold(100*time.Millisecond),
+			ratelimit.ShedderInterval(time.Nanosecond), // sample on every request
+		)
+		e      = ratelimit.NewLoadShedder(s)(nopEndpoint)
+		low    = ratelimit.WithPriority(context.Background(), ratelimit.PriorityLow)
+		normal = context.Background()
+	)
+
+	if _, err := e(low, nil); err != nil {
+		t.Fatalf("want low priority request allowed before overload, have %v", err)
+	}
+
+	for i := 0; i < 1000; i++ {
+		s.Observe(time.Second)
+	}
+	for i := 0; i < 10; i++ {
+		s.Observe(time.Second)
+		s.Allow(normal)
+	}
+	if want, have := 1.0, s.Fraction(); want != have {
+		t.Fatalf("want fraction %v, have %v", want, have)
+	}
+	s.Observe(time.Second) // still overloaded at the next sample
+	if _, err := e(low, nil); err != ratelimit.ErrOverloaded {
+		t.Errorf("want %v, have %v", ratelimit.ErrOverloaded, err)
+	}
+	if _, err := e(normal, nil); err != nil {
+		t.Errorf("want normal priority request allowed, have %v", err)
+	}
+
+	for i := 0; i < 1000; i++ {
+		s.Observe(time.Millisecond)
+	}
+	for i := 0; i < 10; i++ {
+		s.Allow(normal)
+	}
+	if want, have := 0.0, s.Fraction(); want != have {
+		t.Errorf("want fraction %v after recovery, have %v", want, have)
+	}
+}
+
+func TestLoadShedderRecovers(t *testing.T) {
+	var (
+		s = ratelimit.NewShedder(
+			ratelimit.ShedderCPUThreshold(0),
+			ratelimit.ShedderLatencyThreshold(100*time.Millisecond),
+			ratelimit.ShedderSheddable(ratelimit.PriorityNormal),
+			ratelimit.ShedderInterval(time.Nanosecond), // sample on every request
+		)
+		e   = ratelimit.NewLoadShedder(s)(nopEndpoint)
+		ctx = context.Background()
+	)
+
+	for i := 0; i < 1000; i++ {
+		s.Observe(time.Second)
+	}
+	for i := 0; i < 10; i++ {
+		s.Observe(time.Second)
+		s.Allow(ctx)
+	}
+	if want, have := 1.0, s.Fraction(); want != have {
+		t.Fatalf("want fraction %v, have %v", want, have)
+	}
+
+	// all requests are sheddable, so none records a latency while they are
+	// all rejected, and the shedder only recovers once the slow latencies
+	// expire
+	for i := 0; i < 100 && s.Fraction() > 0; i++ {
+		e(ctx, nil)
+	}
+	if want, have := 0.0, s.Fraction(); want != have {
+		t.Errorf("want fraction %v after recovery, have %v", want, have)
+	}
+}
@@ -10,7 +10,7 @@ import (
 	"github.com/inturn/kit/log"
 	"github.com/inturn/kit/sd"
 	"github.com/inturn/kit/sd/internal/instance"
-	"github.com/inturn/kit/util/conn"
+	"github.com/inturn/kit/util/backoff"
 )
 
 const defaultIndex = 0
@@ -64,7 +64,7 @@ func (s *Instancer) loop(lastIndex uint64) {
 	var (
 		instances []string
 		err       error
-		d         time.Duration = 10 * time.Millisecond
+		b         = backoff.New(backoff.Capped(backoff.Jitter(backoff.Exponential(10*time.Millisecond), 0.5), time.Minute))
 	)
 	for {
 		instances, lastIndex, err = s.getInstances(lastIndex, s.quitc)
@@ -73,12 +73,11 @@ func (s *Instancer) loop(lastIndex uint64) {
 			return // stopped via quitc
 		case err != nil:
 			s.logger.Log("err", err)
-			time.Sleep(d)
-			d = conn.Exponential(d)
+			time.Sleep(b.Next())
 			s.cache.Update(sd.Event{Err: err})
 		default:
 			s.cache.Update(sd.Event{Instances: instances})
-			d = 10 * time.Millisecond
+			b.Reset()
 		}
 	}
 }
@@ -0,0 +1,93 @@
+package lb
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/inturn/kit/endpoint"
+)
+
+// RetryBudget limits retries to a fraction of the requests made, so that
+// retries don't amplify the load on a downstream service during an outage.
+// Every request deposits ratio tokens and every retry withdraws one; retries
+// are allowed as long as there are tokens left. A single RetryBudget may be
+// shared by all the retrying endpoints calling the same service.
+type RetryBudget struct {
+	mtx    sync.Mutex
+	ratio  int64 // in thousandths of a token, to avoid rounding errors
+	max    int64
+	tokens int64
+}
+
+// RetryBudgetOption sets an optional parameter for RetryBudgets.
+type RetryBudgetOption func(*RetryBudget)
+
+// RetryBudgetMax sets the maximum number of tokens the budget holds, i.e. the
+// number of retries allowed after a period without any. It defaults to 10.
+func RetryBudgetMax(max int) RetryBudgetOption {
+	return func(b *RetryBudget) { b.max = int64(max) * 1000 }
+}
+
+// NewRetryBudget returns a RetryBudget allowing ratio retries per request,
+// e.g. 0.1 to allow retrying 10% of the requests. The budget starts full.
+func NewRetryBudget(ratio float64, options ...RetryBudgetOption) *RetryBudget {
+	b := &RetryBudget{
+		ratio: int64(ratio*1000 + 0.5),
+		max:   10 * 1000,
+	}
+	for _, option := range options {
+		option(b)
+	}
+	b.tokens = b.max
+	return b
+}
+
+// Deposit adds tokens to the budget for a request. It should be called once
+// per request, not per attempt.
+func (b *RetryBudget) Deposit() {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.tokens += b.ratio
+	if b.tokens > b.max {
+		b.tokens = b.max
+	}
+}
+
+// Withdraw takes a token from the budget for a retry. It returns false if the
+// budget is exhausted, in which case the request should not be retried.
+func (b *RetryBudget) Withdraw() bool {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	if b.tokens < 1000 {
+		return false
+	}
+	b.tokens -= 1000
+	return true
+}
+
+// Callback returns a Callback allowing a retry only if next does and a token
+// can be withdrawn from the budget. If next is nil, retries are only limited
+// by the budget.
+func (b *RetryBudget) Callback(next Callback) Callback {
+	if next == nil {
+		next = alwaysRetry
+	}
+	return func(n int, err error) (keepTrying bool, replacement error) {
+		keepTrying, replacement = next(n, err)
+		if keepTrying && !b.Withdraw() {
+			keepTrying = false
+		}
+		return keepTrying, replacement
+	}
+}
+
+// RetryWithBudget is like RetryWithCallback, but requests are only retried
+// while budget allows it.
+func RetryWithBudget(timeout time.Duration, b Balancer, budget *RetryBudget, cb Callback) endpoint.Endpoint {
+	retry := RetryWithCallback(timeout, b, budget.Callback(cb))
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		budget.Deposit()
+		return retry(ctx, request)
+	}
+}
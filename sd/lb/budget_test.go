@@ -0,0 +1,59 @@
+package lb_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/inturn/kit/sd"
+	"github.com/inturn/kit/sd/lb"
+)
+
+func TestRetryWithBudget(t *testing.T) {
+	var (
+		attempts int
+		e        = func(context.Context, interface{}) (interface{}, error) { attempts++; return nil, errors.New("fail") }
+		budget   = lb.NewRetryBudget(0.1, lb.RetryBudgetMax(2))
+		retry    = lb.RetryWithBudget(time.Second, lb.NewRoundRobin(sd.FixedEndpointer{e}), budget, nil)
+		ctx      = context.Background()
+	)
+
+	for _, want := range []int{
+		3, // the full budget allows two retries
+		1, // which exhausted it
+	} {
+		attempts = 0
+		if _, err := retry(ctx, struct{}{}); err == nil {
+			t.Fatal("want error, have none")
+		}
+		if have := attempts; want != have {
+			t.Errorf("want %d attempts, have %d", want, have)
+		}
+	}
+
+	// 0.1 tokens were deposited by the last request, nine more make a retry.
+	for i := 0; i < 9; i++ {
+		budget.Deposit()
+	}
+	if !budget.Withdraw() {
+		t.Error("want retry allowed after deposits, have none")
+	}
+	if budget.Withdraw() {
+		t.Error("want budget exhausted, have retry allowed")
+	}
+}
+
+func TestRetryBudgetCallback(t *testing.T) {
+	var (
+		budget = lb.NewRetryBudget(0.1)
+		myErr  = errors.New("aborting early")
+		cb     = budget.Callback(func(n int, _ error) (bool, error) { return n < 2, myErr })
+	)
+	if keepTrying, err := cb(1, errors.New("fail")); !keepTrying || err != myErr {
+		t.Errorf("want retry with replacement error, have %v, %v", keepTrying, err)
+	}
+	if keepTrying, _ := cb(2, errors.New("fail")); keepTrying {
+		t.Error("want callback to stop retries, have retry")
+	}
+}
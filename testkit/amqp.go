@@ -0,0 +1,83 @@
+package testkit
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// DeclareQueue declares a non-durable queue named name, which is deleted
+// when the test completes.
+func DeclareQueue(t testing.TB, ch *amqp.Channel, name string) amqp.Queue {
+	t.Helper()
+	q, err := ch.QueueDeclare(name, false, false, false, false, nil)
+	if err != nil {
+		t.Fatalf("declaring queue %q: %v", name, err)
+	}
+	t.Cleanup(func() { ch.QueueDelete(q.Name, false, false, false) })
+	return q
+}
+
+// Publish publishes msg to the default exchange with routing key key.
+func Publish(t testing.TB, ch *amqp.Channel, key string, msg amqp.Publishing) {
+	t.Helper()
+	if err := ch.Publish("", key, false, false, msg); err != nil {
+		t.Fatalf("publishing to %q: %v", key, err)
+	}
+}
+
+// Await returns the next delivery of queue, failing the test if none
+// arrives within timeout.
+func Await(t testing.TB, ch *amqp.Channel, queue string, timeout time.Duration) amqp.Delivery {
+	t.Helper()
+	deliveries, err := ch.Consume(queue, "", true, false, false, false, nil)
+	if err != nil {
+		t.Fatalf("consuming %q: %v", queue, err)
+	}
+	select {
+	case d := <-deliveries:
+		return d
+	case <-time.After(timeout):
+		t.Fatalf("no delivery from %q within %s", queue, timeout)
+		return amqp.Delivery{}
+	}
+}
+
+// Call publishes msg with routing key key and returns the reply, as sent by
+// a subscriber to the ReplyTo queue of msg with its CorrelationId. If msg
+// has no ReplyTo queue or CorrelationId, they are generated.
+func Call(t testing.TB, ch *amqp.Channel, key string, msg amqp.Publishing, timeout time.Duration) amqp.Delivery {
+	t.Helper()
+	if msg.ReplyTo == "" {
+		q, err := ch.QueueDeclare("", false, true, true, false, nil)
+		if err != nil {
+			t.Fatalf("declaring reply queue: %v", err)
+		}
+		msg.ReplyTo = q.Name
+	}
+	if msg.CorrelationId == "" {
+		msg.CorrelationId = fmt.Sprintf("%016x", rand.Uint64())
+	}
+
+	deliveries, err := ch.Consume(msg.ReplyTo, "", true, false, false, false, nil)
+	if err != nil {
+		t.Fatalf("consuming %q: %v", msg.ReplyTo, err)
+	}
+	Publish(t, ch, key, msg)
+
+	deadline := time.After(timeout)
+	for {
+		select {
+		case d := <-deliveries:
+			if d.CorrelationId == msg.CorrelationId {
+				return d
+			}
+		case <-deadline:
+			t.Fatalf("no reply to %q within %s", key, timeout)
+			return amqp.Delivery{}
+		}
+	}
+}
@@ -0,0 +1,18 @@
+// Package testkit provides the brokers transport integration tests run
+// against. Brokers are started in Docker containers using dockertest, so
+// tests are reproducible on any machine running Docker, and removed when the
+// test completes. Tests are skipped if Docker isn't available.
+//
+// The brokers are only built with the integration build tag, so dockertest
+// isn't linked into anything else. Integration tests using them must be
+// guarded by that tag, like the other integration tests of Go kit:
+//
+//	//go:build integration
+//
+//	func TestOrders(t *testing.T) {
+//		ch := testkit.NewRabbitMQ(t).Channel(t)
+//		testkit.DeclareQueue(t, ch, "orders")
+//		// serve the queue with an amqptransport.Subscriber ...
+//		reply := testkit.Call(t, ch, "orders", amqp.Publishing{Body: []byte(`{"id":1}`)}, 5*time.Second)
+//	}
+package testkit
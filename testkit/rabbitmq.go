@@ -0,0 +1,123 @@
+//go:build integration
+// +build integration
+
+package testkit
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ory/dockertest/v3"
+	"github.com/ory/dockertest/v3/docker"
+	"github.com/streadway/amqp"
+)
+
+// RabbitMQURLEnv names the environment variable holding the URL of an
+// existing RabbitMQ broker to use instead of starting a container, e.g. one
+// started by docker-compose on CI.
+const RabbitMQURLEnv = "AMQP_URL"
+
+// RabbitMQ is a RabbitMQ broker for a test.
+type RabbitMQ struct {
+	// URL is the AMQP URL of the broker.
+	URL string
+}
+
+// RabbitMQOption sets an optional parameter for NewRabbitMQ.
+type RabbitMQOption func(*rabbitMQConfig)
+
+type rabbitMQConfig struct {
+	tag     string
+	maxWait time.Duration
+}
+
+// RabbitMQTag sets the tag of the rabbitmq image to run. The default is
+// "3-alpine".
+func RabbitMQTag(tag string) RabbitMQOption {
+	return func(c *rabbitMQConfig) { c.tag = tag }
+}
+
+// RabbitMQMaxWait sets how long to wait for the broker to accept
+// connections. The default is a minute.
+func RabbitMQMaxWait(d time.Duration) RabbitMQOption {
+	return func(c *rabbitMQConfig) { c.maxWait = d }
+}
+
+// NewRabbitMQ returns the broker named by RabbitMQURLEnv, or starts one in a
+// Docker container which is removed when the test completes. It skips the
+// test if Docker isn't available, and fails it if the broker can't be
+// started.
+func NewRabbitMQ(t testing.TB, options ...RabbitMQOption) *RabbitMQ {
+	t.Helper()
+	if url := os.Getenv(RabbitMQURLEnv); url != "" {
+		return &RabbitMQ{URL: url}
+	}
+
+	config := rabbitMQConfig{tag: "3-alpine", maxWait: time.Minute}
+	for _, option := range options {
+		option(&config)
+	}
+
+	pool, err := dockertest.NewPool("")
+	if err != nil {
+		t.Skipf("Docker not available: %v", err)
+	}
+	if err := pool.Client.Ping(); err != nil {
+		t.Skipf("Docker not available: %v", err)
+	}
+	pool.MaxWait = config.maxWait
+
+	resource, err := pool.RunWithOptions(&dockertest.RunOptions{
+		Repository: "rabbitmq",
+		Tag:        config.tag,
+	}, func(hc *docker.HostConfig) {
+		hc.AutoRemove = true
+	})
+	if err != nil {
+		t.Fatalf("starting RabbitMQ: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := pool.Purge(resource); err != nil {
+			t.Logf("removing RabbitMQ container: %v", err)
+		}
+	})
+
+	r := &RabbitMQ{
+		URL: fmt.Sprintf("amqp://guest:guest@%s/", resource.GetHostPort("5672/tcp")),
+	}
+	if err := pool.Retry(func() error {
+		conn, err := amqp.Dial(r.URL)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}); err != nil {
+		t.Fatalf("waiting for RabbitMQ: %v", err)
+	}
+	return r
+}
+
+// Dial returns a connection to the broker, which is closed when the test
+// completes.
+func (r *RabbitMQ) Dial(t testing.TB) *amqp.Connection {
+	t.Helper()
+	conn, err := amqp.Dial(r.URL)
+	if err != nil {
+		t.Fatalf("connecting to RabbitMQ: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// Channel returns a channel on a new connection to the broker, which are
+// closed when the test completes.
+func (r *RabbitMQ) Channel(t testing.TB) *amqp.Channel {
+	t.Helper()
+	ch, err := r.Dial(t).Channel()
+	if err != nil {
+		t.Fatalf("opening channel: %v", err)
+	}
+	return ch
+}
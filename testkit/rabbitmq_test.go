@@ -0,0 +1,46 @@
+//go:build integration
+// +build integration
+
+package testkit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+
+	"github.com/inturn/kit/endpoint"
+	"github.com/inturn/kit/testkit"
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+func TestRabbitMQCall(t *testing.T) {
+	var (
+		ch         = testkit.NewRabbitMQ(t).Channel(t)
+		q          = testkit.DeclareQueue(t, ch, "testkit.echo")
+		subscriber = amqptransport.NewSubscriber(
+			endpoint.Nop,
+			func(_ context.Context, d *amqp.Delivery) (interface{}, error) { return nil, nil },
+			func(_ context.Context, pub *amqp.Publishing, _ interface{}) error {
+				pub.Body = []byte("pong")
+				return nil
+			},
+		)
+	)
+
+	deliveries, err := ch.Consume(q.Name, "", true, false, false, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for d := range deliveries {
+			subscriber.ServeDelivery(ch)(&d)
+		}
+	}()
+
+	reply := testkit.Call(t, ch, q.Name, amqp.Publishing{Body: []byte("ping")}, 5*time.Second)
+	if want, have := "pong", string(reply.Body); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
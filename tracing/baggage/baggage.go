@@ -0,0 +1,108 @@
+package baggage
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Header is the name of the header carrying baggage items, as defined by the
+// W3C Baggage specification.
+const Header = "baggage"
+
+// Baggage is a set of baggage items.
+type Baggage map[string]string
+
+type contextKey int
+
+const baggageKey contextKey = iota
+
+// Set returns a new Context holding the baggage of ctx plus the item key with
+// value. The baggage of ctx is not modified.
+func Set(ctx context.Context, key, value string) context.Context {
+	b := FromContext(ctx)
+	b[key] = value
+	return NewContext(ctx, b)
+}
+
+// Get returns the value of the baggage item key in ctx, or an empty string if
+// no such item exists.
+func Get(ctx context.Context, key string) string {
+	b, _ := ctx.Value(baggageKey).(Baggage)
+	return b[key]
+}
+
+// NewContext returns a new Context holding a copy of b, replacing any baggage
+// already in ctx.
+func NewContext(ctx context.Context, b Baggage) context.Context {
+	return context.WithValue(ctx, baggageKey, b.copy())
+}
+
+// FromContext returns a copy of the baggage in ctx. The returned Baggage is
+// never nil and can be modified freely.
+func FromContext(ctx context.Context) Baggage {
+	b, _ := ctx.Value(baggageKey).(Baggage)
+	return b.copy()
+}
+
+func (b Baggage) copy() Baggage {
+	c := make(Baggage, len(b))
+	for k, v := range b {
+		c[k] = v
+	}
+	return c
+}
+
+// String encodes b in the W3C baggage header format, sorted by key.
+func (b Baggage) String() string {
+	keys := make([]string, 0, len(b))
+	for k := range b {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	members := make([]string, len(keys))
+	for i, k := range keys {
+		members[i] = url.PathEscape(k) + "=" + url.PathEscape(b[k])
+	}
+	return strings.Join(members, ",")
+}
+
+// Parse decodes a header in the W3C baggage format. Malformed members and
+// member properties are ignored.
+func Parse(header string) Baggage {
+	b := Baggage{}
+	for _, member := range strings.Split(header, ",") {
+		if i := strings.IndexByte(member, ';'); i >= 0 {
+			member = member[:i]
+		}
+		kv := strings.SplitN(member, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		key, err := url.PathUnescape(strings.TrimSpace(kv[0]))
+		if err != nil || key == "" {
+			continue
+		}
+		value, err := url.PathUnescape(strings.TrimSpace(kv[1]))
+		if err != nil {
+			continue
+		}
+		b[key] = value
+	}
+	return b
+}
+
+// merge returns a context holding the baggage of ctx plus the items of the
+// header. Items already in ctx are overwritten.
+func merge(ctx context.Context, header string) context.Context {
+	if header == "" {
+		return ctx
+	}
+	b := FromContext(ctx)
+	for k, v := range Parse(header) {
+		b[k] = v
+	}
+	return NewContext(ctx, b)
+}
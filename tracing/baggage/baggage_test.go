@@ -0,0 +1,54 @@
+package baggage_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/inturn/kit/tracing/baggage"
+)
+
+func TestSetGet(t *testing.T) {
+	parent := baggage.Set(context.Background(), "tenant", "acme")
+	child := baggage.Set(parent, "locale", "nl-NL")
+
+	if want, have := "acme", baggage.Get(child, "tenant"); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := "nl-NL", baggage.Get(child, "locale"); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := "", baggage.Get(parent, "locale"); want != have {
+		t.Errorf("parent context modified: want %q, have %q", want, have)
+	}
+
+	b := baggage.FromContext(child)
+	b["tenant"] = "other"
+	if want, have := "acme", baggage.Get(child, "tenant"); want != have {
+		t.Errorf("context modified through FromContext: want %q, have %q", want, have)
+	}
+}
+
+func TestParse(t *testing.T) {
+	for _, tc := range []struct {
+		header string
+		want   baggage.Baggage
+	}{
+		{"", baggage.Baggage{}},
+		{"a=1", baggage.Baggage{"a": "1"}},
+		{" a = 1 , b=2;prop=x", baggage.Baggage{"a": "1", "b": "2"}},
+		{"a=hello%20world,broken,=3", baggage.Baggage{"a": "hello world"}},
+	} {
+		if have := baggage.Parse(tc.header); !reflect.DeepEqual(tc.want, have) {
+			t.Errorf("%q: want %v, have %v", tc.header, tc.want, have)
+		}
+	}
+
+	b := baggage.Baggage{"b": "x,y", "a": "hello world"}
+	if want, have := "a=hello%20world,b=x%2Cy", b.String(); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if have := baggage.Parse(b.String()); !reflect.DeepEqual(b, have) {
+		t.Errorf("round trip: want %v, have %v", b, have)
+	}
+}
@@ -0,0 +1,9 @@
+// Package baggage propagates key/value pairs across service boundaries,
+// independently of the tracing system in use.
+//
+// Baggage items are cross-cutting values like a tenant, experiment flags or a
+// locale, which every service on the request path may need. Items are stored
+// in the context with Set and read with Get; the transport request funcs in
+// this package carry them over HTTP headers, gRPC metadata and AMQP headers
+// using the W3C baggage header format.
+package baggage
@@ -0,0 +1,84 @@
+package baggage
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/streadway/amqp"
+	"google.golang.org/grpc/metadata"
+
+	amqptransport "github.com/inturn/kit/transport/amqp"
+	kitgrpc "github.com/inturn/kit/transport/grpc"
+	kithttp "github.com/inturn/kit/transport/http"
+)
+
+// ContextToHTTP returns an http RequestFunc that writes the baggage in ctx to
+// the baggage header of the outgoing request. Use it as a ClientBefore.
+func ContextToHTTP() kithttp.RequestFunc {
+	return func(ctx context.Context, req *http.Request) context.Context {
+		if b := FromContext(ctx); len(b) > 0 {
+			req.Header.Set(Header, b.String())
+		}
+		return ctx
+	}
+}
+
+// HTTPToContext returns an http RequestFunc that reads the baggage header of
+// the incoming request into the context. Use it as a ServerBefore.
+func HTTPToContext() kithttp.RequestFunc {
+	return func(ctx context.Context, req *http.Request) context.Context {
+		return merge(ctx, req.Header.Get(Header))
+	}
+}
+
+// ContextToGRPC returns a grpc ClientRequestFunc that writes the baggage in
+// ctx to the metadata of the outgoing request. Use it as a ClientBefore.
+func ContextToGRPC() kitgrpc.ClientRequestFunc {
+	return func(ctx context.Context, md *metadata.MD) context.Context {
+		if b := FromContext(ctx); len(b) > 0 {
+			(*md)[Header] = []string{b.String()}
+		}
+		return ctx
+	}
+}
+
+// GRPCToContext returns a grpc ServerRequestFunc that reads the baggage from
+// the metadata of the incoming request into the context. Use it as a
+// ServerBefore.
+func GRPCToContext() kitgrpc.ServerRequestFunc {
+	return func(ctx context.Context, md metadata.MD) context.Context {
+		if values := md[Header]; len(values) > 0 {
+			return merge(ctx, values[0])
+		}
+		return ctx
+	}
+}
+
+// ContextToAMQP returns an amqp RequestFunc that writes the baggage in ctx to
+// the headers of the Publishing. Use it as a PublisherBefore, or as a
+// SubscriberBefore after AMQPToContext to propagate the baggage to replies.
+func ContextToAMQP() amqptransport.RequestFunc {
+	return func(ctx context.Context, pub *amqp.Publishing, _ *amqp.Delivery) context.Context {
+		if b := FromContext(ctx); len(b) > 0 {
+			if pub.Headers == nil {
+				pub.Headers = amqp.Table{}
+			}
+			pub.Headers[Header] = b.String()
+		}
+		return ctx
+	}
+}
+
+// AMQPToContext returns an amqp RequestFunc that reads the baggage from the
+// headers of the Delivery into the context. Use it as a SubscriberBefore.
+func AMQPToContext() amqptransport.RequestFunc {
+	return func(ctx context.Context, _ *amqp.Publishing, deliv *amqp.Delivery) context.Context {
+		switch v := deliv.Headers[Header].(type) {
+		case string:
+			return merge(ctx, v)
+		case []byte:
+			return merge(ctx, string(v))
+		}
+		return ctx
+	}
+}
@@ -0,0 +1,63 @@
+package baggage_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/streadway/amqp"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/inturn/kit/tracing/baggage"
+)
+
+func TestHTTPRoundTrip(t *testing.T) {
+	ctx := baggage.Set(context.Background(), "tenant", "acme")
+	req, _ := http.NewRequest("GET", "http://example.com", nil)
+
+	baggage.ContextToHTTP()(ctx, req)
+	have := baggage.HTTPToContext()(context.Background(), req)
+
+	if want, have := "acme", baggage.Get(have, "tenant"); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
+
+func TestGRPCRoundTrip(t *testing.T) {
+	ctx := baggage.Set(context.Background(), "tenant", "acme")
+	md := metadata.MD{}
+
+	baggage.ContextToGRPC()(ctx, &md)
+	have := baggage.GRPCToContext()(context.Background(), md)
+
+	if want, have := "acme", baggage.Get(have, "tenant"); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
+
+func TestAMQPRoundTrip(t *testing.T) {
+	ctx := baggage.Set(context.Background(), "tenant", "acme")
+	pub := amqp.Publishing{}
+
+	baggage.ContextToAMQP()(ctx, &pub, nil)
+	deliv := amqp.Delivery{Headers: pub.Headers}
+	have := baggage.AMQPToContext()(context.Background(), nil, &deliv)
+
+	if want, have := "acme", baggage.Get(have, "tenant"); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
+
+func TestMergeKeepsExistingItems(t *testing.T) {
+	ctx := baggage.Set(context.Background(), "locale", "en")
+	md := metadata.MD{baggage.Header: []string{"tenant=acme"}}
+
+	ctx = baggage.GRPCToContext()(ctx, md)
+
+	if want, have := "en", baggage.Get(ctx, "locale"); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := "acme", baggage.Get(ctx, "tenant"); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
@@ -0,0 +1,10 @@
+// Package jaeger provides helpers to configure a Jaeger tracer and use it
+// with Go kit.
+//
+// The tracer is configured from the standard JAEGER_* environment variables
+// (JAEGER_SERVICE_NAME, JAEGER_SAMPLER_TYPE, JAEGER_AGENT_HOST, etc.) which
+// can be overridden in code using Options. The resulting Tracer implements
+// opentracing.Tracer, so it can be used with all the helpers in the
+// tracing/opentracing package; TraceServer and TraceClient are provided here
+// for convenience.
+package jaeger
@@ -0,0 +1,159 @@
+package jaeger
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	jaeger "github.com/uber/jaeger-client-go"
+	"github.com/uber/jaeger-client-go/config"
+
+	"github.com/inturn/kit/endpoint"
+	"github.com/inturn/kit/log"
+	kitot "github.com/inturn/kit/tracing/opentracing"
+)
+
+// ErrNoServiceName is returned by NewTracer if no service name was provided,
+// neither through the ServiceName option nor the JAEGER_SERVICE_NAME
+// environment variable.
+var ErrNoServiceName = errors.New("jaeger: no service name provided")
+
+// Option sets an optional parameter for the Jaeger tracer.
+type Option func(*tracerOptions)
+
+type tracerOptions struct {
+	cfg  *config.Configuration
+	opts []config.Option
+}
+
+// ServiceName sets the name of the service reporting spans.
+func ServiceName(name string) Option {
+	return func(o *tracerOptions) { o.cfg.ServiceName = name }
+}
+
+// Disabled creates a no-op tracer if set to true.
+func Disabled(disabled bool) Option {
+	return func(o *tracerOptions) { o.cfg.Disabled = disabled }
+}
+
+// Sampler sets the sampler type and its parameter. Valid types are "const",
+// "probabilistic", "rateLimiting" and "remote". See the documentation of
+// config.SamplerConfig for the meaning of param for each of them.
+func Sampler(samplerType string, param float64) Option {
+	return func(o *tracerOptions) {
+		o.cfg.Sampler.Type = samplerType
+		o.cfg.Sampler.Param = param
+	}
+}
+
+// SamplingServerURL sets the address of the jaeger-agent's sampling server,
+// used by the "remote" sampler.
+func SamplingServerURL(url string) Option {
+	return func(o *tracerOptions) { o.cfg.Sampler.SamplingServerURL = url }
+}
+
+// AgentHostPort instructs the reporter to send spans to the jaeger-agent
+// listening on hostPort.
+func AgentHostPort(hostPort string) Option {
+	return func(o *tracerOptions) { o.cfg.Reporter.LocalAgentHostPort = hostPort }
+}
+
+// CollectorEndpoint instructs the reporter to send spans directly to the
+// jaeger-collector at url instead of an agent.
+func CollectorEndpoint(url string) Option {
+	return func(o *tracerOptions) { o.cfg.Reporter.CollectorEndpoint = url }
+}
+
+// QueueSize sets how many spans the reporter keeps in memory before it starts
+// dropping new ones.
+func QueueSize(size int) Option {
+	return func(o *tracerOptions) { o.cfg.Reporter.QueueSize = size }
+}
+
+// FlushInterval sets how often the reporter buffer is flushed, even if it is
+// not full.
+func FlushInterval(d time.Duration) Option {
+	return func(o *tracerOptions) { o.cfg.Reporter.BufferFlushInterval = d }
+}
+
+// Tags adds process level tags, reported with every span of the tracer.
+func Tags(tags map[string]string) Option {
+	return func(o *tracerOptions) {
+		for k, v := range tags {
+			o.cfg.Tags = append(o.cfg.Tags, opentracing.Tag{Key: k, Value: v})
+		}
+	}
+}
+
+// Logger sets the logger used by the tracer to report internal errors and,
+// if JAEGER_REPORTER_LOG_SPANS is set, reported spans.
+func Logger(logger log.Logger) Option {
+	return func(o *tracerOptions) {
+		o.opts = append(o.opts, config.Logger(loggerAdapter{logger}))
+	}
+}
+
+// Reporter replaces the reporter created from the configuration. It is mainly
+// useful for testing, using jaeger.NewInMemoryReporter.
+func Reporter(reporter jaeger.Reporter) Option {
+	return func(o *tracerOptions) {
+		o.opts = append(o.opts, config.Reporter(reporter))
+	}
+}
+
+// TracerOptions passes raw jaeger-client-go options to the tracer, for
+// settings not covered by this package, like metrics or custom propagation.
+func TracerOptions(options ...config.Option) Option {
+	return func(o *tracerOptions) { o.opts = append(o.opts, options...) }
+}
+
+// Tracer is a Jaeger tracer. It must be closed on shutdown to flush buffered
+// spans.
+type Tracer struct {
+	opentracing.Tracer
+	closer io.Closer
+}
+
+// NewTracer returns a Jaeger tracer configured from the JAEGER_* environment
+// variables, overridden by the provided options.
+func NewTracer(options ...Option) (*Tracer, error) {
+	cfg, err := config.FromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	o := tracerOptions{cfg: cfg}
+	for _, option := range options {
+		option(&o)
+	}
+
+	if cfg.ServiceName == "" && !cfg.Disabled {
+		return nil, ErrNoServiceName
+	}
+
+	tracer, closer, err := cfg.NewTracer(o.opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Tracer{Tracer: tracer, closer: closer}, nil
+}
+
+// Close flushes buffered spans and releases the resources held by the
+// tracer.
+func (t *Tracer) Close() error {
+	return t.closer.Close()
+}
+
+// TraceServer returns a Middleware that wraps the `next` Endpoint in a
+// server span called `operationName`. See opentracing.TraceServer.
+func (t *Tracer) TraceServer(operationName string) endpoint.Middleware {
+	return kitot.TraceServer(t.Tracer, operationName)
+}
+
+// TraceClient returns a Middleware that wraps the `next` Endpoint in a
+// client span called `operationName`. See opentracing.TraceClient.
+func (t *Tracer) TraceClient(operationName string) endpoint.Middleware {
+	return kitot.TraceClient(t.Tracer, operationName)
+}
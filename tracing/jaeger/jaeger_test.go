@@ -0,0 +1,58 @@
+package jaeger_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	jaegerclient "github.com/uber/jaeger-client-go"
+
+	"github.com/inturn/kit/endpoint"
+	"github.com/inturn/kit/tracing/jaeger"
+)
+
+func TestTraceServer(t *testing.T) {
+	reporter := jaegerclient.NewInMemoryReporter()
+	tracer, err := jaeger.NewTracer(
+		jaeger.ServiceName("test"),
+		jaeger.Sampler("const", 1),
+		jaeger.Tags(map[string]string{"env": "test"}),
+		jaeger.Reporter(reporter),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tracer.Close()
+
+	ep := tracer.TraceServer("testOp")(endpoint.Nop)
+	if _, err := ep(context.Background(), struct{}{}); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := reporter.GetSpans()
+	if want, have := 1, len(spans); want != have {
+		t.Fatalf("incorrect number of spans, want %d, have %d", want, have)
+	}
+	span := spans[0].(*jaegerclient.Span)
+	if want, have := "testOp", span.OperationName(); want != have {
+		t.Errorf("incorrect operation name, want %q, have %q", want, have)
+	}
+	if !span.Context().(jaegerclient.SpanContext).IsSampled() {
+		t.Error("span not sampled")
+	}
+}
+
+func TestServiceNameFromEnv(t *testing.T) {
+	defer os.Unsetenv("JAEGER_SERVICE_NAME")
+
+	if _, err := jaeger.NewTracer(jaeger.Reporter(jaegerclient.NewNullReporter())); err != jaeger.ErrNoServiceName {
+		t.Fatalf("want %v, have %v", jaeger.ErrNoServiceName, err)
+	}
+
+	os.Setenv("JAEGER_SERVICE_NAME", "env")
+	tracer, err := jaeger.NewTracer(jaeger.Reporter(jaegerclient.NewNullReporter()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracer.Close()
+}
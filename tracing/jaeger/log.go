@@ -0,0 +1,22 @@
+package jaeger
+
+import (
+	"context"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	jaeger "github.com/uber/jaeger-client-go"
+)
+
+// TraceIDs returns the trace and span IDs of the Jaeger span stored in ctx.
+// It can be used as a tracelog.IDFunc.
+func TraceIDs(ctx context.Context) (traceID, spanID string, ok bool) {
+	span := opentracing.SpanFromContext(ctx)
+	if span == nil {
+		return "", "", false
+	}
+	sc, ok := span.Context().(jaeger.SpanContext)
+	if !ok {
+		return "", "", false
+	}
+	return sc.TraceID().String(), sc.SpanID().String(), true
+}
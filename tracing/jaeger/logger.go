@@ -0,0 +1,20 @@
+package jaeger
+
+import (
+	"fmt"
+
+	"github.com/inturn/kit/log"
+)
+
+// loggerAdapter adapts a Go kit logger to the jaeger.Logger interface.
+type loggerAdapter struct {
+	logger log.Logger
+}
+
+func (l loggerAdapter) Error(msg string) {
+	l.logger.Log("level", "error", "msg", msg)
+}
+
+func (l loggerAdapter) Infof(msg string, args ...interface{}) {
+	l.logger.Log("level", "info", "msg", fmt.Sprintf(msg, args...))
+}
@@ -0,0 +1,18 @@
+package opencensus
+
+import (
+	"context"
+
+	"go.opencensus.io/trace"
+)
+
+// TraceIDs returns the trace and span IDs of the span stored in ctx. It can
+// be used as a tracelog.IDFunc.
+func TraceIDs(ctx context.Context) (traceID, spanID string, ok bool) {
+	span := trace.FromContext(ctx)
+	if span == nil {
+		return "", "", false
+	}
+	sc := span.SpanContext()
+	return sc.TraceID.String(), sc.SpanID.String(), true
+}
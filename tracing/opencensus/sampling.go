@@ -0,0 +1,22 @@
+package opencensus
+
+import (
+	"encoding/binary"
+
+	"go.opencensus.io/trace"
+
+	"github.com/inturn/kit/tracing/sampling"
+)
+
+// Sampler adapts a sampling.Sampler to an OpenCensus sampler, to be used with
+// the WithSampler TracerOption or trace.ApplyConfig.
+func Sampler(s sampling.Sampler) trace.Sampler {
+	return func(p trace.SamplingParameters) trace.SamplingDecision {
+		return trace.SamplingDecision{Sample: s.Sample(sampling.Parameters{
+			Name:          p.Name,
+			TraceID:       binary.BigEndian.Uint64(p.TraceID[8:]),
+			HasParent:     p.ParentContext != (trace.SpanContext{}),
+			ParentSampled: p.ParentContext.IsSampled(),
+		})}
+	}
+}
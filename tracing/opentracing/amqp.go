@@ -0,0 +1,102 @@
+package opentracing
+
+import (
+	"context"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/streadway/amqp"
+
+	"github.com/inturn/kit/log"
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+// ContextToAMQP returns an AMQP RequestFunc that injects an OpenTracing Span
+// found in `ctx` into the headers of the outgoing Publishing. If no such
+// Span can be found, the RequestFunc is a noop. It is designed to be used
+// by Publishers.
+func ContextToAMQP(tracer opentracing.Tracer, logger log.Logger) amqptransport.RequestFunc {
+	return func(ctx context.Context, pub *amqp.Publishing, _ *amqp.Delivery) context.Context {
+		if span := opentracing.SpanFromContext(ctx); span != nil {
+			inject(tracer, span, pub, logger)
+		}
+		return ctx
+	}
+}
+
+// AMQPToContext returns an AMQP RequestFunc that tries to join with an
+// OpenTracing trace found in the headers of the delivery and starts a new
+// Span called `operationName` accordingly. If no trace could be found, the
+// Span will be a trace root. The Span is incorporated in the returned
+// Context and can be retrieved with opentracing.SpanFromContext(ctx). It is
+// designed to be used by Subscribers, together with TraceServer finishing
+// the Span after the endpoint call.
+func AMQPToContext(tracer opentracing.Tracer, operationName string, logger log.Logger) amqptransport.RequestFunc {
+	return func(ctx context.Context, _ *amqp.Publishing, deliv *amqp.Delivery) context.Context {
+		wireContext, err := tracer.Extract(opentracing.TextMap, tableReaderWriter(deliv.Headers))
+		if err != nil && err != opentracing.ErrSpanContextNotFound {
+			logger.Log("err", err)
+		}
+
+		span := tracer.StartSpan(operationName, ext.RPCServerOption(wireContext))
+		ext.Component.Set(span, "amqp")
+		if deliv.Exchange != "" {
+			ext.MessageBusDestination.Set(span, deliv.Exchange)
+		}
+		if deliv.RoutingKey != "" {
+			span.SetTag("amqp.routing_key", deliv.RoutingKey)
+		}
+		return opentracing.ContextWithSpan(ctx, span)
+	}
+}
+
+// ContextToAMQPReply returns an AMQP SubscriberResponseFunc that injects
+// the OpenTracing Span found in `ctx`, e.g. the one started by
+// AMQPToContext, into the headers of the reply, so the publisher can join
+// the trace of the subscriber. If no such Span can be found, the
+// SubscriberResponseFunc is a noop.
+func ContextToAMQPReply(tracer opentracing.Tracer, logger log.Logger) amqptransport.SubscriberResponseFunc {
+	return func(ctx context.Context, _ *amqp.Delivery, _ amqptransport.Channel, pub *amqp.Publishing) context.Context {
+		if span := opentracing.SpanFromContext(ctx); span != nil {
+			inject(tracer, span, pub, logger)
+		}
+		return ctx
+	}
+}
+
+func inject(tracer opentracing.Tracer, span opentracing.Span, pub *amqp.Publishing, logger log.Logger) {
+	if pub.Headers == nil {
+		pub.Headers = amqp.Table{}
+	}
+	// There's nothing we can do with an error here.
+	if err := tracer.Inject(span.Context(), opentracing.TextMap, tableReaderWriter(pub.Headers)); err != nil {
+		logger.Log("err", err)
+	}
+}
+
+// A type that conforms to opentracing.TextMapReader and
+// opentracing.TextMapWriter. Only string and []byte header values are
+// read.
+type tableReaderWriter amqp.Table
+
+func (t tableReaderWriter) Set(key, val string) {
+	t[key] = val
+}
+
+func (t tableReaderWriter) ForeachKey(handler func(key, val string) error) error {
+	for k, v := range t {
+		var s string
+		switch v := v.(type) {
+		case string:
+			s = v
+		case []byte:
+			s = string(v)
+		default:
+			continue
+		}
+		if err := handler(k, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
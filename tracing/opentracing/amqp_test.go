@@ -0,0 +1,92 @@
+package opentracing_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/streadway/amqp"
+
+	"github.com/inturn/kit/log"
+	kitot "github.com/inturn/kit/tracing/opentracing"
+)
+
+func TestTraceAMQPRequestRoundtrip(t *testing.T) {
+	logger := log.NewNopLogger()
+	tracer := mocktracer.New()
+
+	// Initialize the ctx with a Span to inject.
+	beforeSpan := tracer.StartSpan("to_inject").(*mocktracer.MockSpan)
+	defer beforeSpan.Finish()
+	beforeSpan.SetBaggageItem("baggage", "check")
+	beforeCtx := opentracing.ContextWithSpan(context.Background(), beforeSpan)
+
+	toAMQPFunc := kitot.ContextToAMQP(tracer, logger)
+	pub := &amqp.Publishing{}
+	// Call the RequestFunc.
+	afterCtx := toAMQPFunc(beforeCtx, pub, nil)
+
+	// The Span should not have changed.
+	afterSpan := opentracing.SpanFromContext(afterCtx)
+	if beforeSpan != afterSpan {
+		t.Error("Should not swap in a new span")
+	}
+
+	// Use AMQPToContext to verify that we can join with the trace given the
+	// headers.
+	fromAMQPFunc := kitot.AMQPToContext(tracer, "joined", logger)
+	reply := &amqp.Publishing{}
+	deliv := &amqp.Delivery{Headers: pub.Headers, Exchange: "orders", RoutingKey: "orders.created"}
+	joinCtx := fromAMQPFunc(context.Background(), reply, deliv)
+	joinedSpan := opentracing.SpanFromContext(joinCtx).(*mocktracer.MockSpan)
+
+	joinedContext := joinedSpan.Context().(mocktracer.MockSpanContext)
+	beforeContext := beforeSpan.Context().(mocktracer.MockSpanContext)
+
+	if joinedContext.SpanID == beforeContext.SpanID {
+		t.Error("SpanID should have changed", joinedContext.SpanID, beforeContext.SpanID)
+	}
+
+	// Check that the parent/child relationship is as expected for the joined span.
+	if want, have := beforeContext.SpanID, joinedSpan.ParentID; want != have {
+		t.Errorf("Want ParentID %d, have %d", want, have)
+	}
+	if want, have := "joined", joinedSpan.OperationName; want != have {
+		t.Errorf("Want %q, have %q", want, have)
+	}
+	if want, have := "check", joinedSpan.BaggageItem("baggage"); want != have {
+		t.Errorf("Want %q, have %q", want, have)
+	}
+	if want, have := "orders", joinedSpan.Tag("message_bus.destination"); want != have {
+		t.Errorf("Want %q, have %q", want, have)
+	}
+
+	// The joined span is propagated to the reply.
+	kitot.ContextToAMQPReply(tracer, logger)(joinCtx, deliv, nil, reply)
+	replyContext, err := tracer.Extract(opentracing.TextMap, opentracing.TextMapCarrier(stringHeaders(reply.Headers)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := joinedContext.SpanID, replyContext.(mocktracer.MockSpanContext).SpanID; want != have {
+		t.Errorf("Want SpanID %d, have %d", want, have)
+	}
+}
+
+func TestAMQPToContextWithoutTrace(t *testing.T) {
+	tracer := mocktracer.New()
+
+	ctx := kitot.AMQPToContext(tracer, "root", log.NewNopLogger())(context.Background(), &amqp.Publishing{}, &amqp.Delivery{})
+	span := opentracing.SpanFromContext(ctx).(*mocktracer.MockSpan)
+	if want, have := 0, span.ParentID; want != have {
+		t.Errorf("Want ParentID %d, have %d", want, have)
+	}
+}
+
+func stringHeaders(headers amqp.Table) map[string]string {
+	m := make(map[string]string, len(headers))
+	for k, v := range headers {
+		m[k], _ = v.(string)
+	}
+	return m
+}
@@ -0,0 +1,195 @@
+package otel
+
+import (
+	"context"
+	"strings"
+
+	"github.com/streadway/amqp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+// AMQPSubscriberTrace enables OpenTelemetry tracing of a Go kit AMQP transport
+// subscriber. The span context is extracted from the delivery headers, and
+// the subscriber's span context is injected into the headers of the reply.
+func AMQPSubscriberTrace(options ...TracerOption) amqptransport.SubscriberOption {
+	cfg := newTracerOptions(options)
+	tracer := cfg.tracer()
+
+	subscriberBefore := amqptransport.SubscriberBefore(
+		func(ctx context.Context, pub *amqp.Publishing, deliv *amqp.Delivery) context.Context {
+			name := cfg.spanName(ctx, deliv, func() string {
+				return amqpSpanName(deliv.Exchange, cfg.routingKey(deliv.RoutingKey), "process")
+			})
+
+			ctx = cfg.Propagator.Extract(ctx, TableCarrier(deliv.Headers))
+			remote := trace.SpanContextFromContext(ctx)
+
+			ctx, span := tracer.Start(
+				ctx,
+				name,
+				cfg.serverStartOptions(remote, trace.SpanKindConsumer)...,
+			)
+			span.SetAttributes(amqpAttributes(deliv.Exchange, deliv.RoutingKey)...)
+			span.SetAttributes(
+				semconv.MessagingOperationProcess,
+				semconv.MessagingMessageIDKey.String(deliv.MessageId),
+				semconv.MessagingConversationIDKey.String(deliv.CorrelationId),
+			)
+			span.SetAttributes(cfg.requestAttributes(ctx, deliv)...)
+
+			if !cfg.Public {
+				cfg.Propagator.Inject(ctx, Carrier(pub))
+			}
+
+			return ctx
+		},
+	)
+
+	subscriberFinalizer := amqptransport.ServerFinalizer(
+		func(ctx context.Context, err error) {
+			span := trace.SpanFromContext(ctx)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			} else {
+				span.SetStatus(codes.Ok, "")
+			}
+			span.End()
+		},
+	)
+
+	return func(s *amqptransport.Subscriber) {
+		subscriberBefore(s)
+		subscriberFinalizer(s)
+	}
+}
+
+// AMQPPublisherTrace enables OpenTelemetry tracing of a Go kit AMQP transport
+// publisher. The span context is injected into the headers of the outgoing
+// Publishing.
+func AMQPPublisherTrace(options ...TracerOption) amqptransport.PublisherOption {
+	cfg := newTracerOptions(options)
+	tracer := cfg.tracer()
+
+	publisherBefore := amqptransport.PublisherBefore(
+		func(ctx context.Context, pub *amqp.Publishing, _ *amqp.Delivery) context.Context {
+			exchange, _ := ctx.Value(amqptransport.ContextKeyExchange).(string)
+			key, _ := ctx.Value(amqptransport.ContextKeyPublishKey).(string)
+
+			name := cfg.spanName(ctx, pub, func() string {
+				return amqpSpanName(exchange, cfg.routingKey(key), "send")
+			})
+
+			ctx, _ = tracer.Start(
+				ctx,
+				name,
+				trace.WithSpanKind(trace.SpanKindProducer),
+				trace.WithAttributes(cfg.Attributes...),
+				trace.WithAttributes(amqpAttributes(exchange, key)...),
+				trace.WithAttributes(
+					semconv.MessagingConversationIDKey.String(pub.CorrelationId),
+				),
+				trace.WithAttributes(cfg.requestAttributes(ctx, pub)...),
+			)
+
+			if !cfg.Public {
+				cfg.Propagator.Inject(ctx, Carrier(pub))
+			}
+
+			return ctx
+		},
+	)
+
+	publisherFinalizer := amqptransport.PublisherFinalizer(
+		func(ctx context.Context, err error) {
+			span := trace.SpanFromContext(ctx)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			} else {
+				span.SetStatus(codes.Ok, "")
+			}
+			span.End()
+		},
+	)
+
+	return func(p *amqptransport.Publisher) {
+		publisherBefore(p)
+		publisherFinalizer(p)
+	}
+}
+
+// routingKey applies the RoutingKeyNormalizer, if any, to key.
+func (o TracerOptions) routingKey(key string) string {
+	if o.RoutingKeyNormalizer == nil {
+		return key
+	}
+	return o.RoutingKeyNormalizer(key)
+}
+
+// NormalizeRoutingKey replaces the words of a dot separated AMQP routing key
+// which look like identifiers, i.e. numbers, UUIDs and hexadecimal hashes,
+// with "*". For instance "orders.1234.created" becomes "orders.*.created".
+func NormalizeRoutingKey(key string) string {
+	words := strings.Split(key, ".")
+	for i, w := range words {
+		if isIdentifier(w) {
+			words[i] = "*"
+		}
+	}
+	return strings.Join(words, ".")
+}
+
+func isIdentifier(w string) bool {
+	if w == "" {
+		return false
+	}
+	var digits, hex int
+	for _, r := range w {
+		switch {
+		case r >= '0' && r <= '9':
+			digits++
+		case r >= 'a' && r <= 'f', r >= 'A' && r <= 'F':
+			hex++
+		case r == '-':
+		default:
+			return false
+		}
+	}
+	if digits == 0 {
+		return false
+	}
+	// all numeric, or long enough to be a UUID or hash rather than a word
+	// made of the letters a to f, like "add" or "face".
+	return hex == 0 || digits+hex >= 16
+}
+
+// amqpSpanName follows the messaging semantic conventions, naming spans after
+// the destination and the operation, e.g. "orders.created process".
+func amqpSpanName(exchange, key, operation string) string {
+	destination := key
+	if destination == "" {
+		destination = exchange
+	}
+	if destination == "" {
+		destination = "(default)"
+	}
+	return destination + " " + operation
+}
+
+func amqpAttributes(exchange, key string) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		semconv.MessagingSystemKey.String("rabbitmq"),
+		semconv.MessagingDestinationKey.String(exchange),
+		semconv.MessagingDestinationKindKey.String("queue"),
+	}
+	if key != "" {
+		attrs = append(attrs, semconv.MessagingRabbitmqRoutingKeyKey.String(key))
+	}
+	return attrs
+}
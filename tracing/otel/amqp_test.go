@@ -0,0 +1,163 @@
+package otel_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/streadway/amqp"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/inturn/kit/endpoint"
+	kitotel "github.com/inturn/kit/tracing/otel"
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+// mockChannel records published messages and replies to each of them with a
+// delivery carrying the same correlation ID.
+type mockChannel struct {
+	published []amqp.Publishing
+}
+
+func (ch *mockChannel) Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	ch.published = append(ch.published, msg)
+	return nil
+}
+
+func (ch *mockChannel) Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error) {
+	c := make(chan amqp.Delivery, 1)
+	last := ch.published[len(ch.published)-1]
+	c <- amqp.Delivery{CorrelationId: last.CorrelationId}
+	return c, nil
+}
+
+func TestAMQPRoundTrip(t *testing.T) {
+	tp, rec := newTracerProvider()
+	ch := &mockChannel{}
+
+	publisher := amqptransport.NewPublisher(
+		ch,
+		&amqp.Queue{Name: "replies"},
+		func(context.Context, *amqp.Publishing, interface{}) error { return nil },
+		func(context.Context, *amqp.Delivery) (interface{}, error) { return nil, nil },
+		amqptransport.PublisherBefore(amqptransport.SetPublishKey("orders")),
+		kitotel.AMQPPublisherTrace(kitotel.WithTracerProvider(tp)),
+	).Endpoint()
+
+	if _, err := publisher(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := rec.Ended()
+	if want, have := 1, len(spans); want != have {
+		t.Fatalf("incorrect number of spans, want %d, have %d", want, have)
+	}
+	publisherSpan := spans[0]
+	if want, have := "orders send", publisherSpan.Name(); want != have {
+		t.Errorf("incorrect span name, want %q, have %q", want, have)
+	}
+	if want, have := trace.SpanKindProducer, publisherSpan.SpanKind(); want != have {
+		t.Errorf("incorrect span kind, want %s, have %s", want, have)
+	}
+	if _, ok := ch.published[0].Headers["traceparent"]; !ok {
+		t.Fatal("traceparent header not injected")
+	}
+
+	subscriber := amqptransport.NewSubscriber(
+		endpoint.Nop,
+		func(context.Context, *amqp.Delivery) (interface{}, error) { return nil, nil },
+		amqptransport.EncodeNopResponse,
+		kitotel.AMQPSubscriberTrace(kitotel.WithTracerProvider(tp)),
+	)
+	reply := &mockChannel{}
+	subscriber.ServeDelivery(reply)(&amqp.Delivery{
+		RoutingKey: "orders",
+		Headers:    ch.published[0].Headers,
+		ReplyTo:    "replies",
+	})
+
+	spans = rec.Ended()
+	if want, have := 2, len(spans); want != have {
+		t.Fatalf("incorrect number of spans, want %d, have %d", want, have)
+	}
+	subscriberSpan := spans[1]
+	if want, have := "orders process", subscriberSpan.Name(); want != have {
+		t.Errorf("incorrect span name, want %q, have %q", want, have)
+	}
+	if want, have := trace.SpanKindConsumer, subscriberSpan.SpanKind(); want != have {
+		t.Errorf("incorrect span kind, want %s, have %s", want, have)
+	}
+	if want, have := publisherSpan.SpanContext().SpanID(), subscriberSpan.Parent().SpanID(); want != have {
+		t.Errorf("incorrect parent span ID, want %s, have %s", want, have)
+	}
+	if want, have := publisherSpan.SpanContext().TraceID(), subscriberSpan.SpanContext().TraceID(); want != have {
+		t.Errorf("incorrect trace ID, want %s, have %s", want, have)
+	}
+	if want, have := 1, len(reply.published); want != have {
+		t.Fatalf("incorrect number of replies, want %d, have %d", want, have)
+	}
+	if _, ok := reply.published[0].Headers["traceparent"]; !ok {
+		t.Error("traceparent header not injected into reply")
+	}
+}
+
+func TestAMQPSubscriberTraceError(t *testing.T) {
+	tp, rec := newTracerProvider()
+
+	subscriber := amqptransport.NewSubscriber(
+		func(context.Context, interface{}) (interface{}, error) { return nil, errors.New("dummy") },
+		func(context.Context, *amqp.Delivery) (interface{}, error) { return nil, nil },
+		amqptransport.EncodeNopResponse,
+		kitotel.AMQPSubscriberTrace(kitotel.WithTracerProvider(tp), kitotel.WithName("custom")),
+	)
+	subscriber.ServeDelivery(&mockChannel{})(&amqp.Delivery{})
+
+	spans := rec.Ended()
+	if want, have := 1, len(spans); want != have {
+		t.Fatalf("incorrect number of spans, want %d, have %d", want, have)
+	}
+	if want, have := "custom", spans[0].Name(); want != have {
+		t.Errorf("incorrect span name, want %q, have %q", want, have)
+	}
+	if want, have := codes.Error, spans[0].Status().Code; want != have {
+		t.Errorf("incorrect status code, want %d, have %d", want, have)
+	}
+}
+
+func TestNormalizeRoutingKey(t *testing.T) {
+	for _, tc := range []struct{ key, want string }{
+		{"orders.created", "orders.created"},
+		{"orders.1234.created", "orders.*.created"},
+		{"users.6ba7b810-9dad-11d1-80b4-00c04fd430c8", "users.*"},
+		{"cache.add.face", "cache.add.face"},
+		{"", ""},
+	} {
+		if want, have := tc.want, kitotel.NormalizeRoutingKey(tc.key); want != have {
+			t.Errorf("%q: want %q, have %q", tc.key, want, have)
+		}
+	}
+}
+
+func TestAMQPSubscriberTraceRoutingKeyNormalizer(t *testing.T) {
+	tp, rec := newTracerProvider()
+
+	subscriber := amqptransport.NewSubscriber(
+		endpoint.Nop,
+		func(context.Context, *amqp.Delivery) (interface{}, error) { return nil, nil },
+		amqptransport.EncodeNopResponse,
+		kitotel.AMQPSubscriberTrace(
+			kitotel.WithTracerProvider(tp),
+			kitotel.WithRoutingKeyNormalizer(kitotel.NormalizeRoutingKey),
+		),
+	)
+	subscriber.ServeDelivery(&mockChannel{})(&amqp.Delivery{RoutingKey: "orders.1234.created"})
+
+	spans := rec.Ended()
+	if want, have := 1, len(spans); want != have {
+		t.Fatalf("incorrect number of spans, want %d, have %d", want, have)
+	}
+	if want, have := "orders.*.created process", spans[0].Name(); want != have {
+		t.Errorf("incorrect span name, want %q, have %q", want, have)
+	}
+}
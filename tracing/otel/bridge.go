@@ -0,0 +1,99 @@
+package otel
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/streadway/amqp"
+	"go.opentelemetry.io/otel/propagation"
+	"google.golang.org/grpc/metadata"
+)
+
+// Carrier returns the propagation.TextMapCarrier for a transport carrier, so
+// a trace can be carried consistently across hops of different protocols.
+// Supported carriers are http.Header, *http.Request, *http.Response,
+// metadata.MD, *metadata.MD, amqp.Table, *amqp.Publishing, *amqp.Delivery and
+// map[string]string. Headers behind a pointer, like those of a Publishing or
+// Delivery, are allocated if nil. Nil maps passed by value can't be written
+// to, so Carrier returns nil for them, like for unsupported carriers.
+func Carrier(carrier interface{}) propagation.TextMapCarrier {
+	switch c := carrier.(type) {
+	case propagation.TextMapCarrier:
+		return c
+	case http.Header:
+		if c == nil {
+			return nil
+		}
+		return propagation.HeaderCarrier(c)
+	case *http.Request:
+		if c.Header == nil {
+			c.Header = http.Header{}
+		}
+		return propagation.HeaderCarrier(c.Header)
+	case *http.Response:
+		if c.Header == nil {
+			c.Header = http.Header{}
+		}
+		return propagation.HeaderCarrier(c.Header)
+	case metadata.MD:
+		if c == nil {
+			return nil
+		}
+		return MetadataCarrier(c)
+	case *metadata.MD:
+		if *c == nil {
+			*c = metadata.MD{}
+		}
+		return MetadataCarrier(*c)
+	case amqp.Table:
+		if c == nil {
+			return nil
+		}
+		return TableCarrier(c)
+	case *amqp.Publishing:
+		if c.Headers == nil {
+			c.Headers = amqp.Table{}
+		}
+		return TableCarrier(c.Headers)
+	case *amqp.Delivery:
+		if c.Headers == nil {
+			c.Headers = amqp.Table{}
+		}
+		return TableCarrier(c.Headers)
+	case map[string]string:
+		if c == nil {
+			return nil
+		}
+		return MapCarrier(c)
+	}
+	return nil
+}
+
+// Inject injects the span context and baggage of ctx into carrier using
+// propagator, or the W3C Trace Context format if propagator is nil. It
+// returns false if the carrier is not supported, see Carrier.
+func Inject(ctx context.Context, propagator propagation.TextMapPropagator, carrier interface{}) bool {
+	c := Carrier(carrier)
+	if c == nil {
+		return false
+	}
+	if propagator == nil {
+		propagator = defaultPropagator
+	}
+	propagator.Inject(ctx, c)
+	return true
+}
+
+// Extract returns a copy of ctx holding the remote span context found in
+// carrier, using propagator, or the W3C Trace Context format if propagator is
+// nil. Unsupported carriers leave ctx unchanged, see Carrier.
+func Extract(ctx context.Context, propagator propagation.TextMapPropagator, carrier interface{}) context.Context {
+	c := Carrier(carrier)
+	if c == nil {
+		return ctx
+	}
+	if propagator == nil {
+		propagator = defaultPropagator
+	}
+	return propagator.Extract(ctx, c)
+}
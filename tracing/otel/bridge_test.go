@@ -0,0 +1,165 @@
+package otel_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/streadway/amqp"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	kitotel "github.com/inturn/kit/tracing/otel"
+	amqptransport "github.com/inturn/kit/transport/amqp"
+	grpctransport "github.com/inturn/kit/transport/grpc"
+	kithttp "github.com/inturn/kit/transport/http"
+)
+
+// TestCrossProtocolTrace follows a single trace across an HTTP request, an
+// AMQP publish, the consumption of that message and a gRPC call.
+func TestCrossProtocolTrace(t *testing.T) {
+	tp, rec := newTracerProvider()
+	ch := &mockChannel{}
+
+	// gRPC hop: capture the outgoing metadata.
+	var outgoing metadata.MD
+	cc, err := grpc.Dial(
+		"",
+		grpc.WithUnaryInterceptor(func(
+			ctx context.Context, method string, req, reply interface{},
+			cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption,
+		) error {
+			outgoing, _ = metadata.FromOutgoingContext(ctx)
+			return nil
+		}),
+		grpc.WithInsecure(),
+	)
+	if err != nil {
+		t.Fatalf("unable to create gRPC dialer: %s", err.Error())
+	}
+	grpcCall := grpctransport.NewClient(
+		cc, "pb.Inventory", "Reserve",
+		func(context.Context, interface{}) (interface{}, error) { return nil, nil },
+		func(context.Context, interface{}) (interface{}, error) { return nil, nil },
+		dummy{},
+		kitotel.GRPCClientTrace(kitotel.WithTracerProvider(tp)),
+	).Endpoint()
+
+	// AMQP hops.
+	publish := amqptransport.NewPublisher(
+		ch,
+		&amqp.Queue{Name: "replies"},
+		func(context.Context, *amqp.Publishing, interface{}) error { return nil },
+		func(context.Context, *amqp.Delivery) (interface{}, error) { return nil, nil },
+		amqptransport.PublisherBefore(amqptransport.SetPublishKey("orders")),
+		kitotel.AMQPPublisherTrace(kitotel.WithTracerProvider(tp)),
+	).Endpoint()
+	subscriber := amqptransport.NewSubscriber(
+		func(ctx context.Context, request interface{}) (interface{}, error) {
+			return grpcCall(ctx, request)
+		},
+		func(context.Context, *amqp.Delivery) (interface{}, error) { return nil, nil },
+		amqptransport.EncodeNopResponse,
+		kitotel.AMQPSubscriberTrace(kitotel.WithTracerProvider(tp)),
+	)
+
+	// HTTP hop.
+	handler := kithttp.NewServer(
+		func(ctx context.Context, request interface{}) (interface{}, error) {
+			return publish(ctx, request)
+		},
+		func(context.Context, *http.Request) (interface{}, error) { return nil, nil },
+		func(context.Context, http.ResponseWriter, interface{}) error { return nil },
+		kitotel.HTTPServerTrace(kitotel.WithTracerProvider(tp)),
+	)
+
+	ctx, root := tp.Tracer("test").Start(context.Background(), "client")
+	req := httptest.NewRequest("POST", "/orders", nil)
+	if !kitotel.Inject(ctx, nil, req) {
+		t.Fatal("unable to inject into HTTP request")
+	}
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	root.End()
+
+	if want, have := 1, len(ch.published); want != have {
+		t.Fatalf("incorrect number of published messages, want %d, have %d", want, have)
+	}
+	subscriber.ServeDelivery(&mockChannel{})(&amqp.Delivery{
+		RoutingKey: "orders",
+		Headers:    ch.published[0].Headers,
+	})
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, span := range rec.Ended() {
+		spans[span.Name()] = span
+	}
+
+	chain := []string{"client", "POST /orders", "orders send", "orders process", "pb.Inventory/Reserve"}
+	for i, name := range chain {
+		span, ok := spans[name]
+		if !ok {
+			t.Fatalf("missing span %q", name)
+		}
+		if want, have := root.SpanContext().TraceID(), span.SpanContext().TraceID(); want != have {
+			t.Errorf("%s: incorrect trace ID, want %s, have %s", name, want, have)
+		}
+		if i == 0 {
+			continue
+		}
+		if want, have := spans[chain[i-1]].SpanContext().SpanID(), span.Parent().SpanID(); want != have {
+			t.Errorf("%s: incorrect parent span ID, want %s, have %s", name, want, have)
+		}
+	}
+
+	// The trace continues downstream of the gRPC call.
+	remote := trace.SpanContextFromContext(kitotel.Extract(context.Background(), nil, outgoing))
+	if want, have := spans["pb.Inventory/Reserve"].SpanContext().SpanID(), remote.SpanID(); want != have {
+		t.Errorf("incorrect propagated span ID, want %s, have %s", want, have)
+	}
+}
+
+func TestCarrier(t *testing.T) {
+	var pub amqp.Publishing
+	for _, c := range []interface{}{
+		http.Header{}, &http.Request{Header: http.Header{}}, metadata.MD{},
+		&metadata.MD{}, amqp.Table{}, &pub, &amqp.Delivery{}, map[string]string{},
+	} {
+		if kitotel.Carrier(c) == nil {
+			t.Errorf("%T: unsupported carrier", c)
+		}
+	}
+	if kitotel.Carrier(42) != nil {
+		t.Error("int: want unsupported carrier")
+	}
+	if pub.Headers == nil {
+		t.Error("Publishing headers not allocated")
+	}
+}
+
+func TestInjectNilCarrier(t *testing.T) {
+	tp, _ := newTracerProvider()
+	ctx, span := tp.Tracer("test").Start(context.Background(), "span")
+	defer span.End()
+
+	for _, c := range []interface{}{
+		http.Header(nil), metadata.MD(nil), amqp.Table(nil), map[string]string(nil),
+	} {
+		if kitotel.Inject(ctx, nil, c) {
+			t.Errorf("%T: want nil map not injected into", c)
+		}
+	}
+
+	var md metadata.MD
+	req := &http.Request{}
+	for _, c := range []interface{}{&md, req} {
+		if !kitotel.Inject(ctx, nil, c) {
+			t.Errorf("%T: want injected", c)
+		}
+	}
+	if md.Get("traceparent") == nil || req.Header.Get("traceparent") == "" {
+		t.Error("want traceparent injected into allocated headers")
+	}
+}
@@ -0,0 +1,95 @@
+package otel
+
+import (
+	"strings"
+
+	"github.com/streadway/amqp"
+	"go.opentelemetry.io/otel/propagation"
+	"google.golang.org/grpc/metadata"
+)
+
+// MetadataCarrier adapts gRPC metadata to the propagation.TextMapCarrier
+// interface. gRPC metadata keys are lowercase, so keys are normalized on
+// both Get and Set.
+type MetadataCarrier metadata.MD
+
+var _ propagation.TextMapCarrier = MetadataCarrier{}
+
+// Get implements propagation.TextMapCarrier.
+func (c MetadataCarrier) Get(key string) string {
+	if v := metadata.MD(c)[strings.ToLower(key)]; len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// Set implements propagation.TextMapCarrier.
+func (c MetadataCarrier) Set(key, value string) {
+	metadata.MD(c)[strings.ToLower(key)] = []string{value}
+}
+
+// Keys implements propagation.TextMapCarrier.
+func (c MetadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// TableCarrier adapts AMQP message headers to the
+// propagation.TextMapCarrier interface. Only string and []byte header values
+// are visible through Get.
+type TableCarrier amqp.Table
+
+var _ propagation.TextMapCarrier = TableCarrier{}
+
+// Get implements propagation.TextMapCarrier.
+func (c TableCarrier) Get(key string) string {
+	switch v := c[key].(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	}
+	return ""
+}
+
+// Set implements propagation.TextMapCarrier.
+func (c TableCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+// Keys implements propagation.TextMapCarrier.
+func (c TableCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// MapCarrier adapts a string map, e.g. Kafka record headers or a message
+// envelope, to the propagation.TextMapCarrier interface.
+type MapCarrier map[string]string
+
+var _ propagation.TextMapCarrier = MapCarrier{}
+
+// Get implements propagation.TextMapCarrier.
+func (c MapCarrier) Get(key string) string {
+	return c[key]
+}
+
+// Set implements propagation.TextMapCarrier.
+func (c MapCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+// Keys implements propagation.TextMapCarrier.
+func (c MapCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
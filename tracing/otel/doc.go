@@ -0,0 +1,16 @@
+// Package otel provides Go kit integration to the OpenTelemetry project.
+// OpenTelemetry is the successor of both OpenTracing and OpenCensus, and
+// defines a vendor neutral API for distributed tracing with exporters for
+// most tracing backends.
+//
+// The package contains an endpoint tracing middleware plus transport options
+// for the HTTP, gRPC, and AMQP transports. The transport options create server
+// and client spans, propagate the span context across process boundaries
+// using the W3C Trace Context format by default, and record errors and status
+// codes on the spans. All of them share one configuration model, so a service
+// exposing the same endpoints over several transports produces consistent
+// traces.
+//
+// Spans are created with the globally registered TracerProvider unless one is
+// passed with WithTracerProvider.
+package otel
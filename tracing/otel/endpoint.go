@@ -0,0 +1,149 @@
+package otel
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"strconv"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/inturn/kit/endpoint"
+	"github.com/inturn/kit/sd/lb"
+)
+
+// TraceEndpointDefaultName is the default endpoint span name to use.
+const TraceEndpointDefaultName = "gokit/endpoint"
+
+// Error types recorded on endpoint spans in the gokit.error.type attribute by
+// the default ErrorClassifier.
+const (
+	ErrorTypeTransport = "transport"
+	ErrorTypeBusiness  = "business"
+	ErrorTypePanic     = "panic"
+)
+
+// TraceEndpoint returns an Endpoint middleware, tracing a Go kit endpoint.
+// This endpoint tracer should be used in combination with a Go kit Transport
+// tracing middleware or custom before and after transport functions, as
+// propagation of the span context is not provided in this middleware.
+//
+// Errors returned by the endpoint are classified as transport errors, errors
+// reported through endpoint.Failer as business errors. Each attempt of an
+// lb.Retry endpoint is recorded as a retry event. Panics are recorded as a
+// panic event before being propagated.
+func TraceEndpoint(name string, options ...EndpointOption) endpoint.Middleware {
+	if name == "" {
+		name = TraceEndpointDefaultName
+	}
+
+	cfg := &EndpointOptions{}
+
+	for _, o := range options {
+		o(cfg)
+	}
+
+	tp := cfg.TracerProvider
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	tracer := tp.Tracer(instrumentationName)
+
+	classify := cfg.ErrorClassifier
+	if classify == nil {
+		classify = func(_ context.Context, _ error, business bool) (string, bool) {
+			if business {
+				return ErrorTypeBusiness, !cfg.IgnoreBusinessError
+			}
+			return ErrorTypeTransport, true
+		}
+	}
+
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+			ctx, span := tracer.Start(ctx, name, trace.WithAttributes(cfg.Attributes...))
+
+			defer func() {
+				if r := recover(); r != nil {
+					recordPanic(span, r)
+					span.End()
+					panic(r)
+				}
+				defer span.End()
+
+				if err != nil {
+					// The error recorded on the span; err is returned as is.
+					recorded := err
+					if lberr, ok := err.(lb.RetryError); ok {
+						// handle errors originating from lb.Retry
+						recordRetries(span, lberr)
+						recorded = lberr.Final
+					}
+					errorType, isError := classify(ctx, recorded, false)
+					span.SetAttributes(errorTypeKey.String(errorType))
+					span.RecordError(recorded)
+					if isError {
+						span.SetStatus(codes.Error, recorded.Error())
+					} else {
+						span.SetStatus(codes.Ok, "")
+					}
+					return
+				}
+
+				// test for business error
+				if res, ok := response.(endpoint.Failer); ok && res.Failed() != nil {
+					errorType, isError := classify(ctx, res.Failed(), true)
+					span.SetAttributes(
+						errorTypeKey.String(errorType),
+						attribute.String("gokit.business.error", res.Failed().Error()),
+					)
+					if isError {
+						// treating business error as real error in span.
+						span.SetStatus(codes.Error, res.Failed().Error())
+						return
+					}
+					span.SetStatus(codes.Ok, "")
+					return
+				}
+
+				// no errors identified
+				span.SetStatus(codes.Ok, "")
+			}()
+			response, err = next(ctx, request)
+			return
+		}
+	}
+}
+
+const errorTypeKey = attribute.Key("gokit.error.type")
+
+// recordRetries records every failed attempt of an lb.Retry endpoint, both as
+// an attribute and as a retry event.
+func recordRetries(span trace.Span, lberr lb.RetryError) {
+	attrs := make([]attribute.KeyValue, 0, len(lberr.RawErrors))
+	for idx, rawErr := range lberr.RawErrors {
+		attrs = append(attrs, attribute.String(
+			"gokit.retry.error."+strconv.Itoa(idx+1), rawErr.Error(),
+		))
+		span.AddEvent("retry", trace.WithAttributes(
+			attribute.Int("gokit.retry.attempt", idx+1),
+			semconv.ExceptionMessageKey.String(rawErr.Error()),
+		))
+	}
+	span.SetAttributes(attrs...)
+}
+
+// recordPanic records a recovered panic on the span.
+func recordPanic(span trace.Span, r interface{}) {
+	msg := fmt.Sprint(r)
+	span.AddEvent("panic", trace.WithAttributes(
+		semconv.ExceptionMessageKey.String(msg),
+		semconv.ExceptionStacktraceKey.String(string(debug.Stack())),
+	))
+	span.SetAttributes(errorTypeKey.String(ErrorTypePanic))
+	span.SetStatus(codes.Error, msg)
+}
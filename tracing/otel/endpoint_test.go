@@ -0,0 +1,237 @@
+package otel_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/inturn/kit/endpoint"
+	"github.com/inturn/kit/sd"
+	"github.com/inturn/kit/sd/lb"
+	kitotel "github.com/inturn/kit/tracing/otel"
+)
+
+const (
+	span1 = ""
+	span2 = "SPAN-2"
+	span3 = "SPAN-3"
+	span4 = "SPAN-4"
+	span5 = "SPAN-5"
+)
+
+var (
+	err1 = errors.New("some error")
+	err2 = errors.New("other error")
+	err3 = errors.New("some business error")
+	err4 = errors.New("other business error")
+)
+
+// compile time assertion
+var _ endpoint.Failer = failedResponse{}
+
+type failedResponse struct {
+	err error
+}
+
+func (r failedResponse) Failed() error { return r.err }
+
+func passEndpoint(_ context.Context, req interface{}) (interface{}, error) {
+	if err, _ := req.(error); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func newTracerProvider() (*sdktrace.TracerProvider, *tracetest.SpanRecorder) {
+	rec := tracetest.NewSpanRecorder()
+	return sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)), rec
+}
+
+func attributeValue(span sdktrace.ReadOnlySpan, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestTraceEndpoint(t *testing.T) {
+	ctx := context.Background()
+	tp, rec := newTracerProvider()
+
+	// span 1
+	span1Attrs := []attribute.KeyValue{
+		attribute.String("string", "value"),
+		attribute.Int64("int64", 42),
+	}
+	mw := kitotel.TraceEndpoint(
+		span1,
+		kitotel.WithEndpointTracerProvider(tp),
+		kitotel.WithEndpointAttributes(span1Attrs...),
+	)
+	mw(endpoint.Nop)(ctx, nil)
+
+	// span 2
+	opts := kitotel.EndpointOptions{TracerProvider: tp}
+	mw = kitotel.TraceEndpoint(span2, kitotel.WithEndpointConfig(opts))
+	mw(passEndpoint)(ctx, err1)
+
+	// span3
+	mw = kitotel.TraceEndpoint(span3, kitotel.WithEndpointTracerProvider(tp))
+	ep := lb.Retry(5, 1*time.Second, lb.NewRoundRobin(sd.FixedEndpointer{passEndpoint}))
+	if _, err := mw(ep)(ctx, err2); err == nil {
+		t.Error("want retry error, have none")
+	} else if _, ok := err.(lb.RetryError); !ok {
+		t.Errorf("want lb.RetryError returned unchanged, have %T", err)
+	}
+
+	// span4
+	mw = kitotel.TraceEndpoint(span4, kitotel.WithEndpointTracerProvider(tp))
+	mw(passEndpoint)(ctx, failedResponse{err: err3})
+
+	// span5
+	mw = kitotel.TraceEndpoint(
+		span5,
+		kitotel.WithEndpointTracerProvider(tp),
+		kitotel.WithIgnoreBusinessError(true),
+	)
+	mw(passEndpoint)(ctx, failedResponse{err: err4})
+
+	spans := rec.Ended()
+	if want, have := 5, len(spans); want != have {
+		t.Fatalf("incorrect number of spans, wanted %d, got %d", want, have)
+	}
+
+	// test span 1
+	span := spans[0]
+	if want, have := codes.Ok, span.Status().Code; want != have {
+		t.Errorf("incorrect status code, wanted %d, got %d", want, have)
+	}
+	if want, have := kitotel.TraceEndpointDefaultName, span.Name(); want != have {
+		t.Errorf("incorrect span name, wanted %q, got %q", want, have)
+	}
+	if want, have := 2, len(span.Attributes()); want != have {
+		t.Fatalf("incorrect attribute count, wanted %d, got %d", want, have)
+	}
+
+	// test span 2
+	span = spans[1]
+	if want, have := codes.Error, span.Status().Code; want != have {
+		t.Errorf("incorrect status code, wanted %d, got %d", want, have)
+	}
+	if want, have := err1.Error(), span.Status().Description; want != have {
+		t.Errorf("incorrect status message, wanted %q, got %q", want, have)
+	}
+	if want, have := 1, len(span.Events()); want != have {
+		t.Errorf("incorrect event count, wanted %d, got %d", want, have)
+	}
+
+	// test span 3
+	span = spans[2]
+	if want, have := codes.Error, span.Status().Code; want != have {
+		t.Errorf("incorrect status code, wanted %d, got %d", want, have)
+	}
+	if want, have := 6, len(span.Attributes()); want != have {
+		t.Fatalf("incorrect attribute count, wanted %d, got %d", want, have)
+	}
+	if v, ok := attributeValue(span, "gokit.retry.error.1"); !ok || v.AsString() != err2.Error() {
+		t.Errorf("incorrect retry attribute, wanted %q, got %q", err2.Error(), v.AsString())
+	}
+	if v, _ := attributeValue(span, "gokit.error.type"); v.AsString() != kitotel.ErrorTypeTransport {
+		t.Errorf("incorrect error type, wanted %q, got %q", kitotel.ErrorTypeTransport, v.AsString())
+	}
+	// one event per attempt and one for the final error
+	if want, have := 6, len(span.Events()); want != have {
+		t.Errorf("incorrect event count, wanted %d, got %d", want, have)
+	}
+
+	// test span 4
+	span = spans[3]
+	if want, have := codes.Error, span.Status().Code; want != have {
+		t.Errorf("incorrect status code, wanted %d, got %d", want, have)
+	}
+	if want, have := err3.Error(), span.Status().Description; want != have {
+		t.Errorf("incorrect status message, wanted %q, got %q", want, have)
+	}
+	if v, ok := attributeValue(span, "gokit.business.error"); !ok || v.AsString() != err3.Error() {
+		t.Errorf("incorrect business error attribute, wanted %q, got %q", err3.Error(), v.AsString())
+	}
+	if v, _ := attributeValue(span, "gokit.error.type"); v.AsString() != kitotel.ErrorTypeBusiness {
+		t.Errorf("incorrect error type, wanted %q, got %q", kitotel.ErrorTypeBusiness, v.AsString())
+	}
+
+	// test span 5
+	span = spans[4]
+	if want, have := codes.Ok, span.Status().Code; want != have {
+		t.Errorf("incorrect status code, wanted %d, got %d", want, have)
+	}
+	if v, ok := attributeValue(span, "gokit.business.error"); !ok || v.AsString() != err4.Error() {
+		t.Errorf("incorrect business error attribute, wanted %q, got %q", err4.Error(), v.AsString())
+	}
+}
+
+func TestTraceEndpointPanic(t *testing.T) {
+	tp, rec := newTracerProvider()
+
+	ep := kitotel.TraceEndpoint("panic", kitotel.WithEndpointTracerProvider(tp))(
+		func(context.Context, interface{}) (interface{}, error) { panic("boom") },
+	)
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("panic not propagated, got %v", r)
+			}
+		}()
+		ep(context.Background(), nil)
+	}()
+
+	spans := rec.Ended()
+	if want, have := 1, len(spans); want != have {
+		t.Fatalf("incorrect number of spans, wanted %d, got %d", want, have)
+	}
+	span := spans[0]
+	if want, have := codes.Error, span.Status().Code; want != have {
+		t.Errorf("incorrect status code, wanted %d, got %d", want, have)
+	}
+	if v, _ := attributeValue(span, "gokit.error.type"); v.AsString() != kitotel.ErrorTypePanic {
+		t.Errorf("incorrect error type, wanted %q, got %q", kitotel.ErrorTypePanic, v.AsString())
+	}
+	if want, have := "panic", span.Events()[0].Name; want != have {
+		t.Errorf("incorrect event name, wanted %q, got %q", want, have)
+	}
+}
+
+func TestTraceEndpointErrorClassifier(t *testing.T) {
+	tp, rec := newTracerProvider()
+
+	mw := kitotel.TraceEndpoint(
+		"classified",
+		kitotel.WithEndpointTracerProvider(tp),
+		kitotel.WithErrorClassifier(func(_ context.Context, err error, business bool) (string, bool) {
+			if err == err1 {
+				return "validation", false
+			}
+			return kitotel.ErrorTypeTransport, true
+		}),
+	)
+	mw(passEndpoint)(context.Background(), err1)
+
+	spans := rec.Ended()
+	if want, have := 1, len(spans); want != have {
+		t.Fatalf("incorrect number of spans, wanted %d, got %d", want, have)
+	}
+	if want, have := codes.Ok, spans[0].Status().Code; want != have {
+		t.Errorf("incorrect status code, wanted %d, got %d", want, have)
+	}
+	if v, _ := attributeValue(spans[0], "gokit.error.type"); v.AsString() != "validation" {
+		t.Errorf("incorrect error type, wanted %q, got %q", "validation", v.AsString())
+	}
+}
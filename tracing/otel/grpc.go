@@ -0,0 +1,137 @@
+package otel
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	kitgrpc "github.com/inturn/kit/transport/grpc"
+)
+
+// GRPCClientTrace enables OpenTelemetry tracing of a Go kit gRPC transport
+// client.
+func GRPCClientTrace(options ...TracerOption) kitgrpc.ClientOption {
+	cfg := newTracerOptions(options)
+	tracer := cfg.tracer()
+
+	clientBefore := kitgrpc.ClientBefore(
+		func(ctx context.Context, md *metadata.MD) context.Context {
+			method, _ := ctx.Value(kitgrpc.ContextKeyRequestMethod).(string)
+			name := cfg.spanName(ctx, *md, func() string {
+				return strings.TrimPrefix(method, "/")
+			})
+
+			ctx, _ = tracer.Start(
+				ctx,
+				name,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(cfg.Attributes...),
+				trace.WithAttributes(rpcAttributes(method)...),
+				trace.WithAttributes(cfg.requestAttributes(ctx, *md)...),
+			)
+
+			if !cfg.Public {
+				cfg.Propagator.Inject(ctx, Carrier(md))
+			}
+
+			return ctx
+		},
+	)
+
+	clientFinalizer := kitgrpc.ClientFinalizer(
+		func(ctx context.Context, err error) {
+			span := trace.SpanFromContext(ctx)
+			setGRPCStatus(span, err)
+			span.End()
+		},
+	)
+
+	return func(c *kitgrpc.Client) {
+		clientBefore(c)
+		clientFinalizer(c)
+	}
+}
+
+// GRPCServerTrace enables OpenTelemetry tracing of a Go kit gRPC transport
+// server. The kitgrpc.Interceptor must be installed on the gRPC server for the
+// method name to be available.
+func GRPCServerTrace(options ...TracerOption) kitgrpc.ServerOption {
+	cfg := newTracerOptions(options)
+	tracer := cfg.tracer()
+
+	serverBefore := kitgrpc.ServerBefore(
+		func(ctx context.Context, md metadata.MD) context.Context {
+			method, _ := ctx.Value(kitgrpc.ContextKeyRequestMethod).(string)
+			name := cfg.spanName(ctx, md, func() string {
+				if method == "" {
+					// we can't find the gRPC method. probably the
+					// unaryInterceptor was not wired up.
+					return "unknown grpc method"
+				}
+				return strings.TrimPrefix(method, "/")
+			})
+
+			ctx = cfg.Propagator.Extract(ctx, MetadataCarrier(md))
+			remote := trace.SpanContextFromContext(ctx)
+
+			ctx, span := tracer.Start(
+				ctx,
+				name,
+				cfg.serverStartOptions(remote, trace.SpanKindServer)...,
+			)
+			span.SetAttributes(rpcAttributes(method)...)
+			span.SetAttributes(cfg.requestAttributes(ctx, md)...)
+
+			return ctx
+		},
+	)
+
+	serverFinalizer := kitgrpc.ServerFinalizer(
+		func(ctx context.Context, err error) {
+			span := trace.SpanFromContext(ctx)
+			setGRPCStatus(span, err)
+			span.End()
+		},
+	)
+
+	return func(s *kitgrpc.Server) {
+		serverBefore(s)
+		serverFinalizer(s)
+	}
+}
+
+// rpcAttributes returns the semantic convention attributes for a full gRPC
+// method name of the form /package.Service/Method.
+func rpcAttributes(fullMethod string) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{semconv.RPCSystemKey.String("grpc")}
+	parts := strings.SplitN(strings.TrimPrefix(fullMethod, "/"), "/", 2)
+	if len(parts) == 2 {
+		attrs = append(attrs,
+			semconv.RPCServiceKey.String(parts[0]),
+			semconv.RPCMethodKey.String(parts[1]),
+		)
+	}
+	return attrs
+}
+
+func setGRPCStatus(span trace.Span, err error) {
+	if err == nil {
+		span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(grpccodes.OK)))
+		span.SetStatus(codes.Ok, "")
+		return
+	}
+	s, ok := status.FromError(err)
+	if !ok {
+		s = status.New(grpccodes.Unknown, err.Error())
+	}
+	span.RecordError(err)
+	span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(s.Code())))
+	span.SetStatus(codes.Error, s.Message())
+}
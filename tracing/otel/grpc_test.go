@@ -0,0 +1,166 @@
+package otel_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/inturn/kit/endpoint"
+	kitotel "github.com/inturn/kit/tracing/otel"
+	grpctransport "github.com/inturn/kit/transport/grpc"
+)
+
+type dummy struct{}
+
+func unaryInterceptor(
+	ctx context.Context, method string, req, reply interface{},
+	cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption,
+) error {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	if len(md["traceparent"]) == 0 {
+		return errors.New("missing traceparent")
+	}
+	return nil
+}
+
+func TestGRPCClientTrace(t *testing.T) {
+	tp, rec := newTracerProvider()
+
+	cc, err := grpc.Dial(
+		"",
+		grpc.WithUnaryInterceptor(unaryInterceptor),
+		grpc.WithInsecure(),
+	)
+	if err != nil {
+		t.Fatalf("unable to create gRPC dialer: %s", err.Error())
+	}
+
+	traces := []struct {
+		name string
+		err  error
+	}{
+		{"", nil},
+		{"CustomName", nil},
+		{"", errors.New("dummy-error")},
+	}
+
+	for _, tr := range traces {
+		ep := grpctransport.NewClient(
+			cc,
+			"dummyService",
+			"dummyMethod",
+			func(context.Context, interface{}) (interface{}, error) {
+				return nil, nil
+			},
+			func(context.Context, interface{}) (interface{}, error) {
+				return nil, tr.err
+			},
+			dummy{},
+			kitotel.GRPCClientTrace(kitotel.WithTracerProvider(tp), kitotel.WithName(tr.name)),
+		).Endpoint()
+
+		ctx, parentSpan := tp.Tracer("test").Start(context.Background(), "test")
+
+		_, err = ep(ctx, nil)
+		if want, have := tr.err, err; want != have {
+			t.Fatalf("unexpected error, want %v, have %v", want, have)
+		}
+
+		spans := rec.Ended()
+		span := spans[len(spans)-1]
+		if want, have := parentSpan.SpanContext().SpanID(), span.Parent().SpanID(); want != have {
+			t.Errorf("incorrect parent ID, want %s, have %s", want, have)
+		}
+
+		if want, have := tr.name, span.Name(); want != have && want != "" {
+			t.Errorf("incorrect span name, want %s, have %s", want, have)
+		}
+
+		if want, have := "dummyService/dummyMethod", span.Name(); want != have && tr.name == "" {
+			t.Errorf("incorrect span name, want %s, have %s", want, have)
+		}
+
+		code := codes.Ok
+		if tr.err != nil {
+			code = codes.Error
+
+			if want, have := err.Error(), span.Status().Description; want != have {
+				t.Errorf("incorrect span status msg, want %s, have %s", want, have)
+			}
+		}
+
+		if want, have := code, span.Status().Code; want != have {
+			t.Errorf("incorrect span status code, want %d, have %d", want, have)
+		}
+	}
+}
+
+func TestGRPCServerTrace(t *testing.T) {
+	tp, rec := newTracerProvider()
+
+	traces := []struct {
+		useParent bool
+		name      string
+		err       error
+	}{
+		{false, "", nil},
+		{true, "", nil},
+		{true, "CustomName", nil},
+		{true, "", errors.New("dummy-error")},
+	}
+
+	for _, tr := range traces {
+		ctx := context.Background()
+
+		server := grpctransport.NewServer(
+			endpoint.Nop,
+			func(context.Context, interface{}) (interface{}, error) {
+				return nil, nil
+			},
+			func(context.Context, interface{}) (interface{}, error) {
+				return nil, tr.err
+			},
+			kitotel.GRPCServerTrace(kitotel.WithTracerProvider(tp), kitotel.WithName(tr.name)),
+		)
+
+		_, parentSpan := tp.Tracer("test").Start(context.Background(), "test")
+		if tr.useParent {
+			md := metadata.MD{}
+			md.Set("traceparent", "00-"+parentSpan.SpanContext().TraceID().String()+"-"+parentSpan.SpanContext().SpanID().String()+"-01")
+			ctx = metadata.NewIncomingContext(ctx, md)
+		}
+
+		server.ServeGRPC(ctx, nil)
+
+		spans := rec.Ended()
+		span := spans[len(spans)-1]
+
+		if tr.useParent {
+			if want, have := parentSpan.SpanContext().TraceID(), span.SpanContext().TraceID(); want != have {
+				t.Errorf("incorrect trace ID, want %s, have %s", want, have)
+			}
+
+			if want, have := parentSpan.SpanContext().SpanID(), span.Parent().SpanID(); want != have {
+				t.Errorf("incorrect span ID, want %s, have %s", want, have)
+			}
+		}
+
+		if want, have := tr.name, span.Name(); want != have && want != "" {
+			t.Errorf("incorrect span name, want %s, have %s", want, have)
+		}
+
+		if tr.err != nil {
+			if want, have := codes.Error, span.Status().Code; want != have {
+				t.Errorf("incorrect span status code, want %d, have %d", want, have)
+			}
+
+			if want, have := tr.err.Error(), span.Status().Description; want != have {
+				t.Errorf("incorrect span status message, want %s, have %s", want, have)
+			}
+		}
+	}
+}
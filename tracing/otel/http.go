@@ -0,0 +1,136 @@
+package otel
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+
+	kithttp "github.com/inturn/kit/transport/http"
+)
+
+// HTTPClientTrace enables OpenTelemetry tracing of a Go kit HTTP transport
+// client.
+func HTTPClientTrace(options ...TracerOption) kithttp.ClientOption {
+	cfg := newTracerOptions(options)
+	tracer := cfg.tracer()
+
+	clientBefore := kithttp.ClientBefore(
+		func(ctx context.Context, req *http.Request) context.Context {
+			name := cfg.spanName(ctx, req, func() string {
+				return "HTTP " + req.Method
+			})
+
+			ctx, _ = tracer.Start(
+				ctx,
+				name,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(cfg.Attributes...),
+				trace.WithAttributes(semconv.HTTPClientAttributesFromHTTPRequest(req)...),
+				trace.WithAttributes(cfg.requestAttributes(ctx, req)...),
+			)
+
+			if !cfg.Public {
+				cfg.Propagator.Inject(ctx, Carrier(req))
+			}
+
+			return ctx
+		},
+	)
+
+	clientAfter := kithttp.ClientAfter(
+		func(ctx context.Context, res *http.Response) context.Context {
+			span := trace.SpanFromContext(ctx)
+			span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(res.StatusCode)...)
+			span.SetStatus(semconv.SpanStatusFromHTTPStatusCode(res.StatusCode))
+			return ctx
+		},
+	)
+
+	clientFinalizer := kithttp.ClientFinalizer(
+		func(ctx context.Context, err error) {
+			span := trace.SpanFromContext(ctx)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		},
+	)
+
+	return func(c *kithttp.Client) {
+		clientBefore(c)
+		clientAfter(c)
+		clientFinalizer(c)
+	}
+}
+
+// HTTPServerTrace enables OpenTelemetry tracing of a Go kit HTTP transport
+// server.
+func HTTPServerTrace(options ...TracerOption) kithttp.ServerOption {
+	cfg := newTracerOptions(options)
+	tracer := cfg.tracer()
+
+	serverBefore := kithttp.ServerBefore(
+		func(ctx context.Context, req *http.Request) context.Context {
+			name := cfg.spanName(ctx, req, func() string {
+				return req.Method + " " + req.URL.Path
+			})
+
+			ctx = cfg.Propagator.Extract(ctx, propagation.HeaderCarrier(req.Header))
+			remote := trace.SpanContextFromContext(ctx)
+
+			ctx, span := tracer.Start(
+				ctx,
+				name,
+				cfg.serverStartOptions(remote, trace.SpanKindServer)...,
+			)
+			span.SetAttributes(semconv.HTTPServerAttributesFromHTTPRequest("", "", req)...)
+			span.SetAttributes(cfg.requestAttributes(ctx, req)...)
+
+			return ctx
+		},
+	)
+
+	serverFinalizer := kithttp.ServerFinalizer(
+		func(ctx context.Context, code int, r *http.Request) {
+			span := trace.SpanFromContext(ctx)
+			span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(code)...)
+			span.SetStatus(semconv.SpanStatusFromHTTPStatusCode(code))
+
+			if rs, ok := ctx.Value(kithttp.ContextKeyResponseSize).(int64); ok {
+				span.SetAttributes(semconv.HTTPResponseContentLengthKey.Int64(rs))
+			}
+
+			span.End()
+		},
+	)
+
+	return func(s *kithttp.Server) {
+		serverBefore(s)
+		serverFinalizer(s)
+	}
+}
+
+// MuxRouteName is a NameFunc naming HTTP server spans after the gorilla/mux
+// route template matching the request, e.g. "GET /users/{id}", instead of the
+// request path. It is meant for servers registered on a mux.Router.
+func MuxRouteName(_ context.Context, req interface{}) string {
+	r, ok := req.(*http.Request)
+	if !ok {
+		return ""
+	}
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return ""
+	}
+	tpl, err := route.GetPathTemplate()
+	if err != nil {
+		return ""
+	}
+	return r.Method + " " + tpl
+}
@@ -0,0 +1,151 @@
+package otel_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/inturn/kit/endpoint"
+	kitotel "github.com/inturn/kit/tracing/otel"
+	kithttp "github.com/inturn/kit/transport/http"
+)
+
+func TestHTTPRoundTrip(t *testing.T) {
+	tp, rec := newTracerProvider()
+
+	handler := kithttp.NewServer(
+		endpoint.Nop,
+		func(context.Context, *http.Request) (interface{}, error) { return nil, nil },
+		func(context.Context, http.ResponseWriter, interface{}) error { return nil },
+		kitotel.HTTPServerTrace(kitotel.WithTracerProvider(tp)),
+	)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL + "/users")
+	client := kithttp.NewClient(
+		"GET",
+		u,
+		func(context.Context, *http.Request, interface{}) error { return nil },
+		func(context.Context, *http.Response) (interface{}, error) { return nil, nil },
+		kitotel.HTTPClientTrace(kitotel.WithTracerProvider(tp)),
+	).Endpoint()
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "parent")
+	if _, err := client(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+	parent.End()
+
+	spans := rec.Ended()
+	if want, have := 3, len(spans); want != have {
+		t.Fatalf("incorrect number of spans, want %d, have %d", want, have)
+	}
+	serverSpan, clientSpan := spans[0], spans[1]
+
+	if want, have := "GET /users", serverSpan.Name(); want != have {
+		t.Errorf("incorrect server span name, want %q, have %q", want, have)
+	}
+	if want, have := trace.SpanKindServer, serverSpan.SpanKind(); want != have {
+		t.Errorf("incorrect server span kind, want %s, have %s", want, have)
+	}
+	if want, have := "HTTP GET", clientSpan.Name(); want != have {
+		t.Errorf("incorrect client span name, want %q, have %q", want, have)
+	}
+	if want, have := parent.SpanContext().SpanID(), clientSpan.Parent().SpanID(); want != have {
+		t.Errorf("incorrect client parent, want %s, have %s", want, have)
+	}
+	if want, have := clientSpan.SpanContext().SpanID(), serverSpan.Parent().SpanID(); want != have {
+		t.Errorf("incorrect server parent, want %s, have %s", want, have)
+	}
+	if want, have := parent.SpanContext().TraceID(), serverSpan.SpanContext().TraceID(); want != have {
+		t.Errorf("incorrect trace ID, want %s, have %s", want, have)
+	}
+	if !serverSpan.Parent().IsRemote() {
+		t.Error("want remote server parent")
+	}
+}
+
+func TestHTTPServerTracePublic(t *testing.T) {
+	tp, rec := newTracerProvider()
+
+	handler := kithttp.NewServer(
+		endpoint.Nop,
+		func(context.Context, *http.Request) (interface{}, error) { return nil, errors.New("dummy") },
+		func(context.Context, http.ResponseWriter, interface{}) error { return nil },
+		kitotel.HTTPServerTrace(
+			kitotel.WithTracerProvider(tp),
+			kitotel.WithName("custom"),
+			kitotel.IsPublic(true),
+		),
+	)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := rec.Ended()
+	if want, have := 1, len(spans); want != have {
+		t.Fatalf("incorrect number of spans, want %d, have %d", want, have)
+	}
+	span := spans[0]
+	if want, have := "custom", span.Name(); want != have {
+		t.Errorf("incorrect span name, want %q, have %q", want, have)
+	}
+	if span.Parent().IsValid() {
+		t.Error("want root span for public server")
+	}
+	if want, have := 1, len(span.Links()); want != have {
+		t.Fatalf("incorrect number of links, want %d, have %d", want, have)
+	}
+	if want, have := "4bf92f3577b34da6a3ce929d0e0e4736", span.Links()[0].SpanContext.TraceID().String(); want != have {
+		t.Errorf("incorrect link trace ID, want %s, have %s", want, have)
+	}
+	if want, have := codes.Error, span.Status().Code; want != have {
+		t.Errorf("incorrect status code, want %d, have %d", want, have)
+	}
+}
+
+func TestHTTPServerTraceNameAndAttributesFunc(t *testing.T) {
+	tp, rec := newTracerProvider()
+
+	handler := kithttp.NewServer(
+		endpoint.Nop,
+		func(context.Context, *http.Request) (interface{}, error) { return nil, nil },
+		func(context.Context, http.ResponseWriter, interface{}) error { return nil },
+		kitotel.HTTPServerTrace(
+			kitotel.WithTracerProvider(tp),
+			kitotel.WithNameFunc(kitotel.MuxRouteName),
+			kitotel.WithAttributesFunc(func(_ context.Context, req interface{}) []attribute.KeyValue {
+				tenant := req.(*http.Request).Header.Get("X-Tenant")
+				return []attribute.KeyValue{attribute.String("tenant", tenant)}
+			}),
+		),
+	)
+
+	r := mux.NewRouter()
+	r.Handle("/users/{id}", handler)
+
+	req := httptest.NewRequest("GET", "/users/42", nil)
+	req.Header.Set("X-Tenant", "acme")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := rec.Ended()
+	if want, have := 1, len(spans); want != have {
+		t.Fatalf("incorrect number of spans, want %d, have %d", want, have)
+	}
+	if want, have := "GET /users/{id}", spans[0].Name(); want != have {
+		t.Errorf("incorrect span name, want %q, have %q", want, have)
+	}
+	if v, _ := attributeValue(spans[0], "tenant"); v.AsString() != "acme" {
+		t.Errorf("incorrect tenant attribute, want %q, have %q", "acme", v.AsString())
+	}
+}
@@ -0,0 +1,270 @@
+package otel
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies this package as the instrumentation library
+// of the spans it creates.
+const instrumentationName = "github.com/inturn/kit/tracing/otel"
+
+// defaultPropagator is used when no propagator is configured. It propagates
+// span contexts using the W3C Trace Context format.
+var defaultPropagator propagation.TextMapPropagator = propagation.TraceContext{}
+
+// TracerOptions holds configuration for our tracing middlewares.
+type TracerOptions struct {
+	// TracerProvider creates the Tracer used to start spans. If nil, the
+	// global TracerProvider is used.
+	TracerProvider trace.TracerProvider
+
+	// Propagator injects and extracts span contexts into and from transport
+	// carriers. If nil, W3C Trace Context propagation is used.
+	Propagator propagation.TextMapPropagator
+
+	// Name overrides the span name. If empty, a name is derived from the
+	// transport request.
+	Name string
+
+	// Public should be set to true for publicly accessible servers and for
+	// clients that should not propagate their current trace metadata.
+	Public bool
+
+	// Attributes are added to every span created by the middleware.
+	Attributes []attribute.KeyValue
+
+	// NameFunc derives the span name from the transport request. It is
+	// ignored if Name is set. If it returns an empty string, the default
+	// name is used.
+	NameFunc NameFunc
+
+	// AttributesFunc returns additional attributes for the span from the
+	// transport request.
+	AttributesFunc AttributesFunc
+
+	// RoutingKeyNormalizer normalizes AMQP routing keys before they are used
+	// in span names, e.g. to strip identifiers and keep the number of
+	// distinct span names bounded.
+	RoutingKeyNormalizer func(key string) string
+}
+
+// NameFunc derives a span name from a transport request. The request is the
+// *http.Request for HTTP, the metadata.MD for gRPC, the *amqp.Delivery for
+// AMQP subscribers and the *amqp.Publishing for AMQP publishers.
+type NameFunc func(ctx context.Context, req interface{}) string
+
+// AttributesFunc returns span attributes derived from a transport request.
+// The request is passed as for NameFunc.
+type AttributesFunc func(ctx context.Context, req interface{}) []attribute.KeyValue
+
+// TracerOption allows for functional options to our OpenTelemetry tracing
+// middleware.
+type TracerOption func(o *TracerOptions)
+
+// WithTracerConfig sets all configuration options at once.
+func WithTracerConfig(options TracerOptions) TracerOption {
+	return func(o *TracerOptions) {
+		*o = options
+	}
+}
+
+// WithTracerProvider sets the TracerProvider used to create spans.
+func WithTracerProvider(tp trace.TracerProvider) TracerOption {
+	return func(o *TracerOptions) {
+		o.TracerProvider = tp
+	}
+}
+
+// WithPropagator sets the propagator used to inject and extract span contexts.
+// Passing nil resets to the default W3C Trace Context propagator.
+func WithPropagator(p propagation.TextMapPropagator) TracerOption {
+	return func(o *TracerOptions) {
+		o.Propagator = p
+	}
+}
+
+// WithName sets the name for an instrumented transport endpoint. If name is
+// omitted at tracing middleware creation, a name derived from the transport
+// request is used.
+func WithName(name string) TracerOption {
+	return func(o *TracerOptions) {
+		o.Name = name
+	}
+}
+
+// IsPublic should be set to true for publicly accessible servers and for
+// clients that should not propagate their current trace metadata.
+// On the server side a new trace will always be started regardless of any
+// trace metadata being found in the incoming request. If any trace metadata
+// is found, it will be added as a link instead.
+func IsPublic(isPublic bool) TracerOption {
+	return func(o *TracerOptions) {
+		o.Public = isPublic
+	}
+}
+
+// WithAttributes sets attributes added to every span created by the
+// middleware.
+func WithAttributes(attrs ...attribute.KeyValue) TracerOption {
+	return func(o *TracerOptions) {
+		o.Attributes = attrs
+	}
+}
+
+// WithNameFunc sets a function deriving the span name from the transport
+// request, e.g. MuxRouteName to name HTTP server spans after route templates.
+func WithNameFunc(f NameFunc) TracerOption {
+	return func(o *TracerOptions) {
+		o.NameFunc = f
+	}
+}
+
+// WithAttributesFunc sets a function returning additional span attributes
+// derived from the context and the transport request.
+func WithAttributesFunc(f AttributesFunc) TracerOption {
+	return func(o *TracerOptions) {
+		o.AttributesFunc = f
+	}
+}
+
+// WithRoutingKeyNormalizer sets a function normalizing AMQP routing keys
+// before they are used in span names, e.g. NormalizeRoutingKey.
+func WithRoutingKeyNormalizer(f func(key string) string) TracerOption {
+	return func(o *TracerOptions) {
+		o.RoutingKeyNormalizer = f
+	}
+}
+
+func newTracerOptions(options []TracerOption) TracerOptions {
+	cfg := TracerOptions{}
+	for _, option := range options {
+		option(&cfg)
+	}
+	if cfg.TracerProvider == nil {
+		cfg.TracerProvider = otel.GetTracerProvider()
+	}
+	if cfg.Propagator == nil {
+		cfg.Propagator = defaultPropagator
+	}
+	return cfg
+}
+
+func (o TracerOptions) tracer() trace.Tracer {
+	return o.TracerProvider.Tracer(instrumentationName)
+}
+
+// spanName returns the name of the span created for req. A static Name takes
+// precedence over NameFunc, which takes precedence over the default name.
+func (o TracerOptions) spanName(ctx context.Context, req interface{}, defaultName func() string) string {
+	if o.Name != "" {
+		return o.Name
+	}
+	if o.NameFunc != nil {
+		if name := o.NameFunc(ctx, req); name != "" {
+			return name
+		}
+	}
+	return defaultName()
+}
+
+// requestAttributes returns the attributes derived from req by AttributesFunc.
+func (o TracerOptions) requestAttributes(ctx context.Context, req interface{}) []attribute.KeyValue {
+	if o.AttributesFunc == nil {
+		return nil
+	}
+	return o.AttributesFunc(ctx, req)
+}
+
+// serverStartOptions returns the span start options for a server span whose
+// remote parent, if any, has been extracted into ctx. Public servers start a
+// new root span and link to the remote parent instead.
+func (o TracerOptions) serverStartOptions(remote trace.SpanContext, kind trace.SpanKind) []trace.SpanStartOption {
+	opts := []trace.SpanStartOption{
+		trace.WithSpanKind(kind),
+		trace.WithAttributes(o.Attributes...),
+	}
+	if o.Public {
+		opts = append(opts, trace.WithNewRoot())
+		if remote.IsValid() {
+			opts = append(opts, trace.WithLinks(trace.Link{SpanContext: remote}))
+		}
+	}
+	return opts
+}
+
+// EndpointOptions holds the options for tracing an endpoint
+type EndpointOptions struct {
+	// TracerProvider creates the Tracer used to start spans. If nil, the
+	// global TracerProvider is used.
+	TracerProvider trace.TracerProvider
+
+	// IgnoreBusinessError if set to true will not treat a business error
+	// identified through the endpoint.Failer interface as a span error.
+	IgnoreBusinessError bool
+
+	// Attributes holds the default attributes which will be set on span
+	// creation by our Endpoint middleware.
+	Attributes []attribute.KeyValue
+
+	// ErrorClassifier classifies the errors observed by the Endpoint
+	// middleware. If nil, errors returned by the endpoint are transport
+	// errors and errors reported through endpoint.Failer are business errors,
+	// which fail the span unless IgnoreBusinessError is set.
+	ErrorClassifier ErrorClassifier
+}
+
+// ErrorClassifier classifies an error observed by the Endpoint middleware.
+// business is true for errors reported through endpoint.Failer. It returns
+// the error type recorded in the gokit.error.type span attribute and whether
+// the span status is set to error.
+type ErrorClassifier func(ctx context.Context, err error, business bool) (errorType string, isError bool)
+
+// EndpointOption allows for functional options to our OpenTelemetry endpoint
+// tracing middleware.
+type EndpointOption func(*EndpointOptions)
+
+// WithEndpointConfig sets all configuration options at once by use of the
+// EndpointOptions struct.
+func WithEndpointConfig(options EndpointOptions) EndpointOption {
+	return func(o *EndpointOptions) {
+		*o = options
+	}
+}
+
+// WithEndpointTracerProvider sets the TracerProvider used by the Endpoint
+// tracer.
+func WithEndpointTracerProvider(tp trace.TracerProvider) EndpointOption {
+	return func(o *EndpointOptions) {
+		o.TracerProvider = tp
+	}
+}
+
+// WithEndpointAttributes sets the default attributes for the spans created by
+// the Endpoint tracer.
+func WithEndpointAttributes(attrs ...attribute.KeyValue) EndpointOption {
+	return func(o *EndpointOptions) {
+		o.Attributes = attrs
+	}
+}
+
+// WithIgnoreBusinessError if set to true will not treat a business error
+// identified through the endpoint.Failer interface as a span error.
+func WithIgnoreBusinessError(val bool) EndpointOption {
+	return func(o *EndpointOptions) {
+		o.IgnoreBusinessError = val
+	}
+}
+
+// WithErrorClassifier sets the function classifying the errors observed by
+// the Endpoint tracer, e.g. to not fail spans for expected errors like
+// validation errors or to distinguish timeouts.
+func WithErrorClassifier(f ErrorClassifier) EndpointOption {
+	return func(o *EndpointOptions) {
+		o.ErrorClassifier = f
+	}
+}
@@ -0,0 +1,41 @@
+package otel
+
+import (
+	"encoding/binary"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/inturn/kit/tracing/sampling"
+)
+
+// Sampler adapts a sampling.Sampler to an OpenTelemetry SDK sampler. Install
+// it on the TracerProvider with sdktrace.WithSampler.
+func Sampler(s sampling.Sampler) sdktrace.Sampler {
+	return sampler{s}
+}
+
+type sampler struct {
+	s sampling.Sampler
+}
+
+func (a sampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	parent := trace.SpanContextFromContext(p.ParentContext)
+	decision := sdktrace.Drop
+	if a.s.Sample(sampling.Parameters{
+		Name:          p.Name,
+		TraceID:       binary.BigEndian.Uint64(p.TraceID[8:]),
+		HasParent:     parent.IsValid(),
+		ParentSampled: parent.IsSampled(),
+	}) {
+		decision = sdktrace.RecordAndSample
+	}
+	return sdktrace.SamplingResult{
+		Decision:   decision,
+		Tracestate: parent.TraceState(),
+	}
+}
+
+func (sampler) Description() string {
+	return "KitSampler"
+}
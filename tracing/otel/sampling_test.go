@@ -0,0 +1,43 @@
+package otel_test
+
+import (
+	"context"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	kitotel "github.com/inturn/kit/tracing/otel"
+	"github.com/inturn/kit/tracing/sampling"
+)
+
+func TestSampler(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(rec),
+		sdktrace.WithSampler(kitotel.Sampler(sampling.ParentBased(sampling.PerName(
+			sampling.Always(),
+			map[string]sampling.Sampler{"health": sampling.Never()},
+		)))),
+	)
+	tracer := tp.Tracer("test")
+
+	_, span := tracer.Start(context.Background(), "health")
+	span.End()
+
+	ctx, parent := tracer.Start(context.Background(), "sum")
+	_, child := tracer.Start(ctx, "health")
+	child.End()
+	parent.End()
+
+	spans := rec.Ended()
+	if want, have := 2, len(spans); want != have {
+		t.Fatalf("incorrect number of spans, want %d, have %d", want, have)
+	}
+	if want, have := "health", spans[0].Name(); want != have {
+		t.Errorf("incorrect span name, want %q, have %q", want, have)
+	}
+	if want, have := "sum", spans[1].Name(); want != have {
+		t.Errorf("incorrect span name, want %q, have %q", want, have)
+	}
+}
@@ -0,0 +1,16 @@
+// Package sampling provides tracer agnostic sampling strategies for the
+// tracing middlewares.
+//
+// Samplers decide per span whether it is recorded and exported. They can be
+// composed, so a service can for instance follow the decision of its callers
+// while down-sampling a hot endpoint:
+//
+//    s := sampling.ParentBased(sampling.PerName(
+//        sampling.Always(),
+//        map[string]sampling.Sampler{"health": sampling.Ratio(0.01)},
+//    ))
+//
+// The zipkin package accepts a Sampler through its Sampler TracerOption, the
+// otel and opencensus packages provide adapters to their native sampler
+// types.
+package sampling
@@ -0,0 +1,106 @@
+package sampling
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Parameters describe the span a sampling decision is made for.
+type Parameters struct {
+	// Name is the name of the span, typically the endpoint or operation name.
+	Name string
+
+	// TraceID holds the lower 64 bits of the trace ID, or 0 if the trace ID
+	// is not known yet at the time of the decision.
+	TraceID uint64
+
+	// HasParent is true if the span continues a trace which already made a
+	// sampling decision, in which case ParentSampled holds that decision.
+	HasParent     bool
+	ParentSampled bool
+}
+
+// Sampler decides whether a span is sampled.
+type Sampler interface {
+	Sample(p Parameters) bool
+}
+
+// SamplerFunc is an adapter to allow the use of ordinary functions as
+// Samplers.
+type SamplerFunc func(p Parameters) bool
+
+// Sample implements Sampler.
+func (f SamplerFunc) Sample(p Parameters) bool { return f(p) }
+
+// Always returns a Sampler which samples every span.
+func Always() Sampler {
+	return SamplerFunc(func(Parameters) bool { return true })
+}
+
+// Never returns a Sampler which samples no span.
+func Never() Sampler {
+	return SamplerFunc(func(Parameters) bool { return false })
+}
+
+// Ratio returns a Sampler which samples the given fraction of traces. If the
+// trace ID is known the decision is derived from it, so all services using
+// the same ratio take the same decision for a trace.
+func Ratio(fraction float64) Sampler {
+	if fraction >= 1 {
+		return Always()
+	}
+	if fraction <= 0 {
+		return Never()
+	}
+	var (
+		bound = uint64(fraction * math.MaxUint64)
+		mtx   sync.Mutex
+		rnd   = rand.New(rand.NewSource(time.Now().UnixNano()))
+	)
+	return SamplerFunc(func(p Parameters) bool {
+		id := p.TraceID
+		if id == 0 {
+			mtx.Lock()
+			id = rnd.Uint64()
+			mtx.Unlock()
+		}
+		return id < bound
+	})
+}
+
+// RateLimited returns a Sampler which samples at most perSecond spans per
+// second, allowing bursts of up to one second worth of spans.
+func RateLimited(perSecond float64) Sampler {
+	burst := int(math.Ceil(perSecond))
+	if burst < 1 {
+		burst = 1
+	}
+	limiter := rate.NewLimiter(rate.Limit(perSecond), burst)
+	return SamplerFunc(func(Parameters) bool { return limiter.Allow() })
+}
+
+// PerName returns a Sampler which delegates to the Sampler registered for the
+// span name in overrides, or to fallback if there is none.
+func PerName(fallback Sampler, overrides map[string]Sampler) Sampler {
+	return SamplerFunc(func(p Parameters) bool {
+		if s, ok := overrides[p.Name]; ok {
+			return s.Sample(p)
+		}
+		return fallback.Sample(p)
+	})
+}
+
+// ParentBased returns a Sampler which follows the decision of the parent
+// span if there is one, and delegates to root otherwise.
+func ParentBased(root Sampler) Sampler {
+	return SamplerFunc(func(p Parameters) bool {
+		if p.HasParent {
+			return p.ParentSampled
+		}
+		return root.Sample(p)
+	})
+}
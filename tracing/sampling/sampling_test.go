@@ -0,0 +1,73 @@
+package sampling_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/inturn/kit/tracing/sampling"
+)
+
+func TestAlwaysNever(t *testing.T) {
+	if !sampling.Always().Sample(sampling.Parameters{}) {
+		t.Error("Always did not sample")
+	}
+	if sampling.Never().Sample(sampling.Parameters{}) {
+		t.Error("Never sampled")
+	}
+}
+
+func TestRatio(t *testing.T) {
+	s := sampling.Ratio(0.5)
+
+	if !s.Sample(sampling.Parameters{TraceID: 1}) {
+		t.Error("low trace ID not sampled")
+	}
+	if s.Sample(sampling.Parameters{TraceID: math.MaxUint64}) {
+		t.Error("high trace ID sampled")
+	}
+
+	var sampled int
+	for i := 0; i < 10000; i++ {
+		if s.Sample(sampling.Parameters{}) {
+			sampled++
+		}
+	}
+	if sampled < 4000 || sampled > 6000 {
+		t.Errorf("want about 5000 sampled spans, have %d", sampled)
+	}
+}
+
+func TestRateLimited(t *testing.T) {
+	s := sampling.RateLimited(2)
+
+	var sampled int
+	for i := 0; i < 10; i++ {
+		if s.Sample(sampling.Parameters{}) {
+			sampled++
+		}
+	}
+	if want, have := 2, sampled; want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+}
+
+func TestPerNameParentBased(t *testing.T) {
+	s := sampling.ParentBased(sampling.PerName(
+		sampling.Always(),
+		map[string]sampling.Sampler{"health": sampling.Never()},
+	))
+
+	for _, tc := range []struct {
+		p    sampling.Parameters
+		want bool
+	}{
+		{sampling.Parameters{Name: "sum"}, true},
+		{sampling.Parameters{Name: "health"}, false},
+		{sampling.Parameters{Name: "health", HasParent: true, ParentSampled: true}, true},
+		{sampling.Parameters{Name: "sum", HasParent: true, ParentSampled: false}, false},
+	} {
+		if want, have := tc.want, s.Sample(tc.p); want != have {
+			t.Errorf("%+v: want %v, have %v", tc.p, want, have)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+// Package tracelog correlates log records with distributed traces.
+//
+// Loggers returned by this package append the trace and span IDs of the span
+// found in the context to every log record. Extracting the IDs is specific to
+// the tracing system in use; the otel, zipkin, opencensus and jaeger packages
+// each provide a TraceIDs function which can be used as an IDFunc.
+//
+// The simplest way to get correlated logs in endpoints is to install the
+// Middleware inside the tracing middleware, and to retrieve the logger from
+// the context with FromContext:
+//
+//    ep = tracelog.Middleware(logger, kitzipkin.TraceIDs)(ep)
+//    ep = kitzipkin.TraceEndpoint(tracer, "sum")(ep)
+//
+// Since AMQP subscribers and the HTTP and gRPC servers start their spans in
+// their before funcs, the logger retrieved in any endpoint wrapped by the
+// Middleware is correlated even without the tracing endpoint middleware.
+package tracelog
@@ -0,0 +1,86 @@
+package tracelog
+
+import (
+	"context"
+
+	"github.com/inturn/kit/endpoint"
+	"github.com/inturn/kit/log"
+)
+
+// Keys used for the trace and span IDs in log records.
+const (
+	TraceIDKey = "trace_id"
+	SpanIDKey  = "span_id"
+)
+
+// IDFunc returns the trace and span IDs of the span stored in ctx. If ctx
+// holds no span, ok is false.
+type IDFunc func(ctx context.Context) (traceID, spanID string, ok bool)
+
+// With returns a logger which appends the trace and span IDs of the span in
+// ctx to every log record. The first IDFunc finding a span wins. If none of
+// them does, logger is returned unchanged.
+func With(ctx context.Context, logger log.Logger, ids ...IDFunc) log.Logger {
+	for _, f := range ids {
+		if traceID, spanID, ok := f(ctx); ok {
+			return log.With(logger, TraceIDKey, traceID, SpanIDKey, spanID)
+		}
+	}
+	return logger
+}
+
+// TraceID returns a Valuer which yields the trace ID of the span in ctx, or
+// an empty string if ctx holds no span.
+func TraceID(ctx context.Context, ids ...IDFunc) log.Valuer {
+	return func() interface{} {
+		traceID, _ := lookup(ctx, ids)
+		return traceID
+	}
+}
+
+// SpanID returns a Valuer which yields the span ID of the span in ctx, or an
+// empty string if ctx holds no span.
+func SpanID(ctx context.Context, ids ...IDFunc) log.Valuer {
+	return func() interface{} {
+		_, spanID := lookup(ctx, ids)
+		return spanID
+	}
+}
+
+func lookup(ctx context.Context, ids []IDFunc) (traceID, spanID string) {
+	for _, f := range ids {
+		if traceID, spanID, ok := f(ctx); ok {
+			return traceID, spanID
+		}
+	}
+	return "", ""
+}
+
+type contextKey int
+
+const loggerKey contextKey = iota
+
+// NewContext returns a new Context carrying logger.
+func NewContext(ctx context.Context, logger log.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, logger)
+}
+
+// FromContext returns the logger stored in ctx by NewContext or the
+// Middleware. If ctx holds no logger, a nop logger is returned.
+func FromContext(ctx context.Context) log.Logger {
+	if logger, ok := ctx.Value(loggerKey).(log.Logger); ok {
+		return logger
+	}
+	return log.NewNopLogger()
+}
+
+// Middleware returns an endpoint middleware which stores logger, decorated
+// with the trace and span IDs of the span in the request context, in the
+// context passed to the next endpoint. Retrieve it with FromContext.
+func Middleware(logger log.Logger, ids ...IDFunc) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			return next(NewContext(ctx, With(ctx, logger, ids...)), request)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package tracelog_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	zipkin "github.com/openzipkin/zipkin-go"
+	"github.com/openzipkin/zipkin-go/reporter/recorder"
+
+	"github.com/inturn/kit/log"
+	"github.com/inturn/kit/tracing/tracelog"
+	kitzipkin "github.com/inturn/kit/tracing/zipkin"
+)
+
+type key struct{}
+
+func fakeIDs(ctx context.Context) (string, string, bool) {
+	if ids, ok := ctx.Value(key{}).([2]string); ok {
+		return ids[0], ids[1], true
+	}
+	return "", "", false
+}
+
+func TestWith(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.NewLogfmtLogger(&buf)
+
+	tracelog.With(context.Background(), logger, fakeIDs).Log("msg", "a")
+	if want, have := "msg=a\n", buf.String(); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+
+	buf.Reset()
+	ctx := context.WithValue(context.Background(), key{}, [2]string{"t1", "s1"})
+	tracelog.With(ctx, logger, fakeIDs).Log("msg", "b")
+	if want, have := "trace_id=t1 span_id=s1 msg=b\n", buf.String(); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+
+	buf.Reset()
+	log.With(logger, "trace", tracelog.TraceID(ctx, fakeIDs), "span", tracelog.SpanID(ctx, fakeIDs)).Log()
+	if want, have := "trace=t1 span=s1\n", buf.String(); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	rec := recorder.NewReporter()
+	defer rec.Close()
+
+	tr, _ := zipkin.NewTracer(rec)
+	span := tr.StartSpan("test")
+	defer span.Finish()
+
+	var buf bytes.Buffer
+	ep := tracelog.Middleware(log.NewLogfmtLogger(&buf), kitzipkin.TraceIDs)(
+		func(ctx context.Context, request interface{}) (interface{}, error) {
+			tracelog.FromContext(ctx).Log("msg", "handled")
+			return nil, nil
+		},
+	)
+
+	if _, err := ep(zipkin.NewContext(context.Background(), span), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	sc := span.Context()
+	want := "trace_id=" + sc.TraceID.String() + " span_id=" + sc.ID.String() + " msg=handled\n"
+	if have := buf.String(); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
+
+func TestFromContextDefault(t *testing.T) {
+	if err := tracelog.FromContext(context.Background()).Log("k", "v"); err != nil {
+		t.Fatal(err)
+	}
+}
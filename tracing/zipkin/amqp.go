@@ -0,0 +1,182 @@
+package zipkin
+
+import (
+	"context"
+
+	zipkin "github.com/openzipkin/zipkin-go"
+	"github.com/openzipkin/zipkin-go/model"
+	"github.com/streadway/amqp"
+
+	"github.com/inturn/kit/log"
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+// AMQPPublisherTrace enables native Zipkin tracing of a Go kit AMQP transport
+// Publisher.
+//
+// The span is created as a Zipkin PRODUCER span and its span context is
+// injected into the headers of the outgoing Publishing in B3 format. If no
+// Name() TracerOption is given, the routing key set by SetPublishKey is used
+// as span name. If publishing to a service outside of your platform, you will
+// probably want to disallow propagation of SpanContext using the
+// AllowPropagation TracerOption and setting it to false.
+func AMQPPublisherTrace(tracer *zipkin.Tracer, options ...TracerOption) amqptransport.PublisherOption {
+	config := tracerOptions{
+		tags:      make(map[string]string),
+		name:      "",
+		logger:    log.NewNopLogger(),
+		propagate: true,
+	}
+
+	for _, option := range options {
+		option(&config)
+	}
+
+	publisherBefore := amqptransport.PublisherBefore(
+		func(ctx context.Context, pub *amqp.Publishing, _ *amqp.Delivery) context.Context {
+			var (
+				spanContext model.SpanContext
+				name        string
+			)
+
+			exchange, _ := ctx.Value(amqptransport.ContextKeyExchange).(string)
+			key, _ := ctx.Value(amqptransport.ContextKeyPublishKey).(string)
+
+			if config.name != "" {
+				name = config.name
+			} else {
+				name = "publish " + key
+			}
+
+			if parent := zipkin.SpanFromContext(ctx); parent != nil {
+				spanContext = parent.Context()
+			}
+
+			config.sample(name, &spanContext)
+
+			span := tracer.StartSpan(
+				name,
+				zipkin.Kind(model.Producer),
+				zipkin.Tags(config.tags),
+				zipkin.Tags(amqpTags(exchange, key)),
+				zipkin.Parent(spanContext),
+				zipkin.FlushOnFinish(false),
+			)
+
+			if config.propagate {
+				if err := InjectAMQP(&pub.Headers)(span.Context()); err != nil {
+					config.logger.Log("err", err)
+				}
+			}
+
+			return zipkin.NewContext(ctx, span)
+		},
+	)
+
+	publisherFinalizer := amqptransport.PublisherFinalizer(
+		func(ctx context.Context, err error) {
+			if span := zipkin.SpanFromContext(ctx); span != nil {
+				if err != nil {
+					zipkin.TagError.Set(span, err.Error())
+				}
+				span.Finish()
+				// send span to the Reporter
+				span.Flush()
+			}
+		},
+	)
+
+	return func(p *amqptransport.Publisher) {
+		publisherBefore(p)
+		publisherFinalizer(p)
+	}
+}
+
+// AMQPSubscriberTrace enables native Zipkin tracing of a Go kit AMQP transport
+// Subscriber.
+//
+// The span is created as a Zipkin CONSUMER span, continuing the trace found in
+// the B3 headers of the Delivery. If no Name() TracerOption is given, the
+// routing key of the Delivery is used as span name. If consuming messages from
+// untrusted publishers, you will probably want to disallow propagation of the
+// publisher's SpanContext using the AllowPropagation TracerOption and setting
+// it to false. Unless disallowed, the span context is also injected into the
+// headers of the reply.
+func AMQPSubscriberTrace(tracer *zipkin.Tracer, options ...TracerOption) amqptransport.SubscriberOption {
+	config := tracerOptions{
+		tags:      make(map[string]string),
+		name:      "",
+		logger:    log.NewNopLogger(),
+		propagate: true,
+	}
+
+	for _, option := range options {
+		option(&config)
+	}
+
+	subscriberBefore := amqptransport.SubscriberBefore(
+		func(ctx context.Context, pub *amqp.Publishing, deliv *amqp.Delivery) context.Context {
+			var (
+				spanContext model.SpanContext
+				name        string
+			)
+
+			if config.name != "" {
+				name = config.name
+			} else {
+				name = "consume " + deliv.RoutingKey
+			}
+
+			if config.propagate {
+				spanContext = tracer.Extract(ExtractAMQP(deliv.Headers))
+				if spanContext.Err != nil {
+					config.logger.Log("err", spanContext.Err)
+				}
+			}
+
+			config.sample(name, &spanContext)
+
+			span := tracer.StartSpan(
+				name,
+				zipkin.Kind(model.Consumer),
+				zipkin.Tags(config.tags),
+				zipkin.Tags(amqpTags(deliv.Exchange, deliv.RoutingKey)),
+				zipkin.Parent(spanContext),
+				zipkin.FlushOnFinish(false),
+			)
+
+			if config.propagate {
+				if err := InjectAMQP(&pub.Headers)(span.Context()); err != nil {
+					config.logger.Log("err", err)
+				}
+			}
+
+			return zipkin.NewContext(ctx, span)
+		},
+	)
+
+	subscriberFinalizer := amqptransport.ServerFinalizer(
+		func(ctx context.Context, err error) {
+			if span := zipkin.SpanFromContext(ctx); span != nil {
+				if err != nil {
+					zipkin.TagError.Set(span, err.Error())
+				}
+				span.Finish()
+				// send span to the Reporter
+				span.Flush()
+			}
+		},
+	)
+
+	return func(s *amqptransport.Subscriber) {
+		subscriberBefore(s)
+		subscriberFinalizer(s)
+	}
+}
+
+func amqpTags(exchange, key string) map[string]string {
+	return map[string]string{
+		"amqp.exchange":    exchange,
+		"amqp.routing_key": key,
+	}
+}
@@ -0,0 +1,192 @@
+package zipkin_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	zipkin "github.com/openzipkin/zipkin-go"
+	"github.com/openzipkin/zipkin-go/model"
+	"github.com/openzipkin/zipkin-go/propagation/b3"
+	"github.com/openzipkin/zipkin-go/reporter/recorder"
+	"github.com/streadway/amqp"
+
+	"github.com/inturn/kit/endpoint"
+	"github.com/inturn/kit/tracing/sampling"
+	kitzipkin "github.com/inturn/kit/tracing/zipkin"
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+type mockChannel struct {
+	published []amqp.Publishing
+}
+
+func (ch *mockChannel) Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	ch.published = append(ch.published, msg)
+	return nil
+}
+
+func (ch *mockChannel) Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error) {
+	c := make(chan amqp.Delivery, 1)
+	last := ch.published[len(ch.published)-1]
+	c <- amqp.Delivery{CorrelationId: last.CorrelationId}
+	return c, nil
+}
+
+func TestAMQPTraceRoundTrip(t *testing.T) {
+	rec := recorder.NewReporter()
+	defer rec.Close()
+
+	tr, _ := zipkin.NewTracer(rec)
+	ch := &mockChannel{}
+
+	publisher := amqptransport.NewPublisher(
+		ch,
+		&amqp.Queue{Name: "replies"},
+		func(context.Context, *amqp.Publishing, interface{}) error { return nil },
+		func(context.Context, *amqp.Delivery) (interface{}, error) { return nil, nil },
+		amqptransport.PublisherBefore(amqptransport.SetPublishKey("orders")),
+		kitzipkin.AMQPPublisherTrace(tr),
+	).Endpoint()
+
+	if _, err := publisher(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := rec.Flush()
+	if want, have := 1, len(spans); want != have {
+		t.Fatalf("incorrect number of spans, want %d, have %d", want, have)
+	}
+	publisherSpan := spans[0]
+	if want, have := "publish orders", publisherSpan.Name; want != have {
+		t.Errorf("incorrect span name, want %q, have %q", want, have)
+	}
+	if want, have := model.Producer, publisherSpan.Kind; want != have {
+		t.Errorf("incorrect span kind, want %q, have %q", want, have)
+	}
+	if want, have := publisherSpan.TraceID.String(), ch.published[0].Headers[b3.TraceID]; want != have {
+		t.Fatalf("incorrect trace ID header, want %v, have %v", want, have)
+	}
+
+	subscriber := amqptransport.NewSubscriber(
+		endpoint.Nop,
+		func(context.Context, *amqp.Delivery) (interface{}, error) { return nil, nil },
+		amqptransport.EncodeNopResponse,
+		kitzipkin.AMQPSubscriberTrace(tr),
+	)
+	replies := &mockChannel{}
+	subscriber.ServeDelivery(replies)(&amqp.Delivery{
+		RoutingKey: "orders",
+		Headers:    ch.published[0].Headers,
+		ReplyTo:    "replies",
+	})
+
+	spans = rec.Flush()
+	if want, have := 1, len(spans); want != have {
+		t.Fatalf("incorrect number of spans, want %d, have %d", want, have)
+	}
+	subscriberSpan := spans[0]
+	if want, have := "consume orders", subscriberSpan.Name; want != have {
+		t.Errorf("incorrect span name, want %q, have %q", want, have)
+	}
+	if want, have := model.Consumer, subscriberSpan.Kind; want != have {
+		t.Errorf("incorrect span kind, want %q, have %q", want, have)
+	}
+	if want, have := publisherSpan.TraceID, subscriberSpan.TraceID; want != have {
+		t.Errorf("incorrect trace ID, want %s, have %s", want, have)
+	}
+	if subscriberSpan.ParentID == nil {
+		t.Fatalf("incorrect parent ID, want %s have nil", publisherSpan.ID)
+	}
+	if want, have := publisherSpan.ID, *subscriberSpan.ParentID; want != have {
+		t.Errorf("incorrect parent ID, want %s, have %s", want, have)
+	}
+	if want, have := 1, len(replies.published); want != have {
+		t.Fatalf("incorrect number of replies, want %d, have %d", want, have)
+	}
+	if want, have := subscriberSpan.ID.String(), replies.published[0].Headers[b3.SpanID]; want != have {
+		t.Errorf("incorrect reply span ID header, want %v, have %v", want, have)
+	}
+}
+
+func TestAMQPSubscriberTraceError(t *testing.T) {
+	rec := recorder.NewReporter()
+	defer rec.Close()
+
+	tr, _ := zipkin.NewTracer(rec)
+
+	subscriber := amqptransport.NewSubscriber(
+		func(context.Context, interface{}) (interface{}, error) { return nil, errors.New("dummy") },
+		func(context.Context, *amqp.Delivery) (interface{}, error) { return nil, nil },
+		amqptransport.EncodeNopResponse,
+		kitzipkin.AMQPSubscriberTrace(tr, kitzipkin.Name("custom")),
+	)
+	subscriber.ServeDelivery(&mockChannel{})(&amqp.Delivery{})
+
+	spans := rec.Flush()
+	if want, have := 1, len(spans); want != have {
+		t.Fatalf("incorrect number of spans, want %d, have %d", want, have)
+	}
+	if want, have := "custom", spans[0].Name; want != have {
+		t.Errorf("incorrect span name, want %q, have %q", want, have)
+	}
+	if want, have := "dummy", spans[0].Tags[string(zipkin.TagError)]; want != have {
+		t.Errorf("incorrect error tag, want %q, have %q", want, have)
+	}
+}
+
+func TestMapPropagation(t *testing.T) {
+	rec := recorder.NewReporter()
+	defer rec.Close()
+
+	tr, _ := zipkin.NewTracer(rec)
+	span := tr.StartSpan("test")
+	defer span.Finish()
+
+	headers := map[string]string{}
+	if err := kitzipkin.InjectMap(headers)(span.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	sc := tr.Extract(kitzipkin.ExtractMap(headers))
+	if sc.Err != nil {
+		t.Fatal(sc.Err)
+	}
+	if want, have := span.Context().TraceID, sc.TraceID; want != have {
+		t.Errorf("incorrect trace ID, want %s, have %s", want, have)
+	}
+	if want, have := span.Context().ID, sc.ID; want != have {
+		t.Errorf("incorrect span ID, want %s, have %s", want, have)
+	}
+
+	if want, have := b3.ErrEmptyContext, kitzipkin.InjectMap(headers)(model.SpanContext{}); want != have {
+		t.Errorf("incorrect error, want %v, have %v", want, have)
+	}
+}
+
+func TestAMQPSubscriberTraceSampler(t *testing.T) {
+	rec := recorder.NewReporter()
+	defer rec.Close()
+
+	tr, _ := zipkin.NewTracer(rec)
+
+	subscriber := amqptransport.NewSubscriber(
+		endpoint.Nop,
+		func(context.Context, *amqp.Delivery) (interface{}, error) { return nil, nil },
+		amqptransport.EncodeNopResponse,
+		kitzipkin.AMQPSubscriberTrace(tr, kitzipkin.Sampler(sampling.PerName(
+			sampling.Always(),
+			map[string]sampling.Sampler{"consume health": sampling.Never()},
+		))),
+	)
+	subscriber.ServeDelivery(&mockChannel{})(&amqp.Delivery{RoutingKey: "health"})
+	subscriber.ServeDelivery(&mockChannel{})(&amqp.Delivery{RoutingKey: "orders"})
+
+	spans := rec.Flush()
+	if want, have := 1, len(spans); want != have {
+		t.Fatalf("incorrect number of spans, want %d, have %d", want, have)
+	}
+	if want, have := "consume orders", spans[0].Name; want != have {
+		t.Errorf("incorrect span name, want %q, have %q", want, have)
+	}
+}
@@ -55,6 +55,8 @@ func GRPCClientTrace(tracer *zipkin.Tracer, options ...TracerOption) kitgrpc.Cli
 				spanContext = parent.Context()
 			}
 
+			config.sample(name, &spanContext)
+
 			span := tracer.StartSpan(
 				name,
 				zipkin.Kind(model.Client),
@@ -160,6 +162,8 @@ func GRPCServerTrace(tracer *zipkin.Tracer, options ...TracerOption) kitgrpc.Ser
 				}
 			}
 
+			config.sample(name, &spanContext)
+
 			span := tracer.StartSpan(
 				name,
 				zipkin.Kind(model.Server),
@@ -59,6 +59,8 @@ func HTTPClientTrace(tracer *zipkin.Tracer, options ...TracerOption) kithttp.Cli
 				string(zipkin.TagHTTPUrl):    req.URL.String(),
 			}
 
+			config.sample(name, &spanContext)
+
 			span := tracer.StartSpan(
 				name,
 				zipkin.Kind(model.Client),
@@ -166,6 +168,8 @@ func HTTPServerTrace(tracer *zipkin.Tracer, options ...TracerOption) kithttp.Ser
 				string(zipkin.TagHTTPPath):   req.URL.Path,
 			}
 
+			config.sample(name, &spanContext)
+
 			span := tracer.StartSpan(
 				name,
 				zipkin.Kind(model.Server),
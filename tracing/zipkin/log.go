@@ -0,0 +1,18 @@
+package zipkin
+
+import (
+	"context"
+
+	zipkin "github.com/openzipkin/zipkin-go"
+)
+
+// TraceIDs returns the trace and span IDs of the span stored in ctx. It can
+// be used as a tracelog.IDFunc.
+func TraceIDs(ctx context.Context) (traceID, spanID string, ok bool) {
+	span := zipkin.SpanFromContext(ctx)
+	if span == nil {
+		return "", "", false
+	}
+	sc := span.Context()
+	return sc.TraceID.String(), sc.ID.String(), true
+}
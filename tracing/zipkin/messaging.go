@@ -0,0 +1,91 @@
+package zipkin
+
+import (
+	"github.com/openzipkin/zipkin-go/model"
+	"github.com/openzipkin/zipkin-go/propagation"
+	"github.com/openzipkin/zipkin-go/propagation/b3"
+	"github.com/streadway/amqp"
+)
+
+// InjectMap will inject a span.Context into a string map using B3 header
+// keys. It is the building block for messaging transports whose headers are
+// plain key/value pairs, like Kafka record headers and NATS 2.x message
+// headers; convert the map into the transport's header type after injection.
+func InjectMap(m map[string]string) propagation.Injector {
+	return func(sc model.SpanContext) error {
+		return injectB3(sc, func(key, value string) { m[key] = value })
+	}
+}
+
+// ExtractMap will extract a span.Context from a string map if found in B3
+// header format. Header keys are expected in lower case.
+func ExtractMap(m map[string]string) propagation.Extractor {
+	return func() (*model.SpanContext, error) {
+		return b3.ParseHeaders(
+			m[b3.TraceID], m[b3.SpanID], m[b3.ParentSpanID], m[b3.Sampled],
+			m[b3.Flags],
+		)
+	}
+}
+
+// InjectAMQP will inject a span.Context into AMQP message headers. The table
+// is allocated if it is nil.
+func InjectAMQP(table *amqp.Table) propagation.Injector {
+	return func(sc model.SpanContext) error {
+		if *table == nil {
+			*table = amqp.Table{}
+		}
+		return injectB3(sc, func(key, value string) { (*table)[key] = value })
+	}
+}
+
+// ExtractAMQP will extract a span.Context from AMQP message headers if found
+// in B3 header format. Both string and []byte header values are accepted.
+func ExtractAMQP(table amqp.Table) propagation.Extractor {
+	return func() (*model.SpanContext, error) {
+		get := func(key string) string {
+			switch v := table[key].(type) {
+			case string:
+				return v
+			case []byte:
+				return string(v)
+			}
+			return ""
+		}
+		return b3.ParseHeaders(
+			get(b3.TraceID), get(b3.SpanID), get(b3.ParentSpanID), get(b3.Sampled),
+			get(b3.Flags),
+		)
+	}
+}
+
+// injectB3 writes the span context as B3 headers through set, mirroring the
+// semantics of the HTTP and gRPC injectors of the b3 package.
+func injectB3(sc model.SpanContext, set func(key, value string)) error {
+	if (model.SpanContext{}) == sc {
+		return b3.ErrEmptyContext
+	}
+
+	if sc.Debug {
+		set(b3.Flags, "1")
+	} else if sc.Sampled != nil {
+		// Debug is encoded as X-B3-Flags: 1. Since Debug implies Sampled,
+		// we don't send "X-B3-Sampled" if Debug is set.
+		if *sc.Sampled {
+			set(b3.Sampled, "1")
+		} else {
+			set(b3.Sampled, "0")
+		}
+	}
+
+	if !sc.TraceID.Empty() && sc.ID > 0 {
+		// set identifiers
+		set(b3.TraceID, sc.TraceID.String())
+		set(b3.SpanID, sc.ID.String())
+		if sc.ParentID != nil {
+			set(b3.ParentSpanID, sc.ParentID.String())
+		}
+	}
+
+	return nil
+}
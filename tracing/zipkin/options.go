@@ -1,6 +1,11 @@
 package zipkin
 
-import "github.com/inturn/kit/log"
+import (
+	"github.com/openzipkin/zipkin-go/model"
+
+	"github.com/inturn/kit/log"
+	"github.com/inturn/kit/tracing/sampling"
+)
 
 // TracerOption allows for functional options to our Zipkin tracing middleware.
 type TracerOption func(o *tracerOptions)
@@ -46,9 +51,38 @@ func AllowPropagation(propagate bool) TracerOption {
 	}
 }
 
+// Sampler sets the sampler deciding whether the spans created by the
+// transport middleware are sampled, overriding the sampler of the Zipkin
+// tracer. Wrap it with sampling.ParentBased to honor the decisions of
+// upstream services. Default is to use the sampler of the Zipkin tracer.
+func Sampler(sampler sampling.Sampler) TracerOption {
+	return func(o *tracerOptions) {
+		o.sampler = sampler
+	}
+}
+
 type tracerOptions struct {
 	tags      map[string]string
 	name      string
 	logger    log.Logger
 	propagate bool
+	sampler   sampling.Sampler
+}
+
+// sample applies the configured sampler to the span context of a span about
+// to be started. Debug contexts are always sampled.
+func (o tracerOptions) sample(name string, sc *model.SpanContext) {
+	if o.sampler == nil || sc.Debug {
+		return
+	}
+	p := sampling.Parameters{
+		Name:      name,
+		TraceID:   sc.TraceID.Low,
+		HasParent: sc.Sampled != nil,
+	}
+	if sc.Sampled != nil {
+		p.ParentSampled = *sc.Sampled
+	}
+	sampled := o.sampler.Sample(p)
+	sc.Sampled = &sampled
 }
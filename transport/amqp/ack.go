@@ -0,0 +1,85 @@
+package amqp
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/streadway/amqp"
+)
+
+// AckMode decides whether and when a Subscriber acknowledges the deliveries
+// it serves.
+type AckMode int
+
+const (
+	// AckManual leaves acknowledging deliveries to the decoders, response
+	// functions and error encoders, e.g. SetAckAfterEndpoint and
+	// SingleNackRequeueErrorEncoder. It is the default.
+	AckManual AckMode = iota
+
+	// AckOnSuccess acknowledges a delivery as soon as the endpoint
+	// succeeded, before the response is encoded and published.
+	AckOnSuccess
+
+	// AckAfterPublish acknowledges a delivery after the response was
+	// published, so deliveries whose reply failed are not lost.
+	AckAfterPublish
+)
+
+// SubscriberAckMode sets the AckMode of the subscriber. In AckOnSuccess and
+// AckAfterPublish modes, deliveries that failed are rejected without
+// requeueing after the error encoder ran, so they are dead-lettered if the
+// queue has a dead letter exchange. Deliveries acknowledged by the error
+// encoder or any other function, e.g. to requeue them, are not
+// acknowledged again.
+func SubscriberAckMode(mode AckMode) SubscriberOption {
+	return func(s *Subscriber) { s.ackMode = mode }
+}
+
+var errDeliveryNotInitialized = errors.New("delivery not initialized")
+
+// trackingAcknowledger records whether a delivery was acknowledged, so the
+// Subscriber doesn't acknowledge it twice, which closes the channel.
+type trackingAcknowledger struct {
+	next amqp.Acknowledger
+
+	mtx  sync.Mutex
+	done bool
+}
+
+func (a *trackingAcknowledger) acknowledged() bool {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	return a.done
+}
+
+func (a *trackingAcknowledger) acknowledge() error {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	if a.next == nil {
+		return errDeliveryNotInitialized
+	}
+	a.done = true
+	return nil
+}
+
+func (a *trackingAcknowledger) Ack(tag uint64, multiple bool) error {
+	if err := a.acknowledge(); err != nil {
+		return err
+	}
+	return a.next.Ack(tag, multiple)
+}
+
+func (a *trackingAcknowledger) Nack(tag uint64, multiple bool, requeue bool) error {
+	if err := a.acknowledge(); err != nil {
+		return err
+	}
+	return a.next.Nack(tag, multiple, requeue)
+}
+
+func (a *trackingAcknowledger) Reject(tag uint64, requeue bool) error {
+	if err := a.acknowledge(); err != nil {
+		return err
+	}
+	return a.next.Reject(tag, requeue)
+}
@@ -0,0 +1,79 @@
+package amqp_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/streadway/amqp"
+
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+type mockAcknowledger struct {
+	acks, nacks, rejects int
+	requeue              bool
+}
+
+func (a *mockAcknowledger) Ack(tag uint64, multiple bool) error {
+	a.acks++
+	return nil
+}
+
+func (a *mockAcknowledger) Nack(tag uint64, multiple bool, requeue bool) error {
+	a.nacks++
+	a.requeue = requeue
+	return nil
+}
+
+func (a *mockAcknowledger) Reject(tag uint64, requeue bool) error {
+	a.rejects++
+	a.requeue = requeue
+	return nil
+}
+
+func TestSubscriberAckMode(t *testing.T) {
+	failing := func(context.Context, interface{}) (interface{}, error) { return nil, errors.New("dummy") }
+	for _, testcase := range []struct {
+		name      string
+		mode      amqptransport.AckMode
+		e         func(context.Context, interface{}) (interface{}, error)
+		ee        amqptransport.ErrorEncoder
+		acks      int
+		nacks     int
+		published int
+	}{
+		{"manual", amqptransport.AckManual, failing, amqptransport.DefaultErrorEncoder, 0, 0, 0},
+		{"success", amqptransport.AckOnSuccess, testEndpoint, amqptransport.DefaultErrorEncoder, 1, 0, 1},
+		{"after publish", amqptransport.AckAfterPublish, testEndpoint, amqptransport.DefaultErrorEncoder, 1, 0, 1},
+		{"failure", amqptransport.AckOnSuccess, failing, amqptransport.DefaultErrorEncoder, 0, 1, 0},
+		{"encoder acks", amqptransport.AckAfterPublish, failing, amqptransport.ReplyAndAckErrorEncoder, 1, 0, 1},
+	} {
+		t.Run(testcase.name, func(t *testing.T) {
+			sub := amqptransport.NewSubscriber(
+				testcase.e,
+				testReqDecoder,
+				amqptransport.EncodeJSONResponse,
+				amqptransport.SubscriberAckMode(testcase.mode),
+				amqptransport.SubscriberErrorEncoder(testcase.ee),
+			)
+			outputChan := make(chan amqp.Publishing, 1)
+			ch := &mockChannel{f: nullFunc, c: outputChan}
+			acker := &mockAcknowledger{}
+			sub.ServeDelivery(ch)(&amqp.Delivery{Acknowledger: acker, Body: []byte(`{"s":437}`)})
+
+			if want, have := testcase.acks, acker.acks; want != have {
+				t.Errorf("incorrect number of acks, want %d, have %d", want, have)
+			}
+			if want, have := testcase.nacks, acker.nacks; want != have {
+				t.Errorf("incorrect number of nacks, want %d, have %d", want, have)
+			}
+			if acker.requeue {
+				t.Error("want no requeue, have requeue")
+			}
+			if want, have := testcase.published, len(outputChan); want != have {
+				t.Errorf("incorrect number of replies, want %d, have %d", want, have)
+			}
+		})
+	}
+}
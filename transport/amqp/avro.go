@@ -0,0 +1,181 @@
+package amqp
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"sync"
+
+	"github.com/linkedin/goavro/v2"
+	"github.com/streadway/amqp"
+)
+
+// AvroContentType is the content type of Avro payloads.
+const AvroContentType = "avro/binary"
+
+// ErrInvalidAvroPayload is returned when decoding a payload that doesn't
+// start with the Confluent wire format header.
+var ErrInvalidAvroPayload = errors.New("invalid Avro payload: missing schema ID header")
+
+// avroMagicByte precedes the schema ID in the Confluent wire format.
+const avroMagicByte = 0
+
+// avroHeaderLen is the length of the magic byte and the schema ID.
+const avroHeaderLen = 5
+
+// AvroCodec encodes and decodes Avro payloads in the Confluent wire format:
+// a zero magic byte and the big-endian 4-byte ID of the writer schema in
+// the SchemaRegistry, followed by the Avro binary encoding of the datum.
+//
+// Schemas are fetched from and registered with the registry once and then
+// cached, so the registry is only asked for schemas AvroCodec hasn't seen.
+//
+// Data are represented as goavro native values, i.e. records are
+// map[string]interface{} values.
+type AvroCodec struct {
+	registry SchemaRegistry
+
+	mtx    sync.RWMutex
+	codecs map[int]*goavro.Codec
+	ids    map[subjectSchema]int
+}
+
+type subjectSchema struct {
+	subject, schema string
+}
+
+// NewAvroCodec returns an AvroCodec resolving schemas with r.
+func NewAvroCodec(r SchemaRegistry) *AvroCodec {
+	return &AvroCodec{
+		registry: r,
+		codecs:   map[int]*goavro.Codec{},
+		ids:      map[subjectSchema]int{},
+	}
+}
+
+// Encode encodes datum with schema, registering schema under subject.
+func (c *AvroCodec) Encode(ctx context.Context, subject, schema string, datum interface{}) ([]byte, error) {
+	id, codec, err := c.register(ctx, subject, schema)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, avroHeaderLen, 64)
+	buf[0] = avroMagicByte
+	binary.BigEndian.PutUint32(buf[1:], uint32(id))
+	return codec.BinaryFromNative(buf, datum)
+}
+
+// Decode decodes b with the writer schema referenced by its header.
+func (c *AvroCodec) Decode(ctx context.Context, b []byte) (interface{}, error) {
+	if len(b) < avroHeaderLen || b[0] != avroMagicByte {
+		return nil, ErrInvalidAvroPayload
+	}
+	codec, err := c.codec(ctx, int(binary.BigEndian.Uint32(b[1:avroHeaderLen])))
+	if err != nil {
+		return nil, err
+	}
+	datum, _, err := codec.NativeFromBinary(b[avroHeaderLen:])
+	return datum, err
+}
+
+func (c *AvroCodec) register(ctx context.Context, subject, schema string) (int, *goavro.Codec, error) {
+	key := subjectSchema{subject, schema}
+	c.mtx.RLock()
+	id, ok := c.ids[key]
+	codec := c.codecs[id]
+	c.mtx.RUnlock()
+	if ok {
+		return id, codec, nil
+	}
+
+	codec, err := goavro.NewCodec(schema)
+	if err != nil {
+		return 0, nil, err
+	}
+	id, err = c.registry.Register(ctx, subject, schema)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.ids[key] = id
+	c.codecs[id] = codec
+	return id, codec, nil
+}
+
+func (c *AvroCodec) codec(ctx context.Context, id int) (*goavro.Codec, error) {
+	c.mtx.RLock()
+	codec, ok := c.codecs[id]
+	c.mtx.RUnlock()
+	if ok {
+		return codec, nil
+	}
+
+	schema, err := c.registry.Schema(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	codec, err = goavro.NewCodec(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.codecs[id] = codec
+	return codec, nil
+}
+
+// EncodeAvroResponse returns an EncodeResponseFunc encoding the response
+// with c and schema, registered under subject, as the payload of the AMQP
+// Publishing object, and setting its ContentType to AvroContentType.
+func EncodeAvroResponse(c *AvroCodec, subject, schema string) EncodeResponseFunc {
+	return func(ctx context.Context, pub *amqp.Publishing, response interface{}) error {
+		return encodeAvro(ctx, c, subject, schema, pub, response)
+	}
+}
+
+// EncodeAvroRequest returns an EncodeRequestFunc encoding the request with
+// c and schema, registered under subject, as the payload of the AMQP
+// Publishing object, and setting its ContentType to AvroContentType. It is
+// designed to be used in Publishers.
+func EncodeAvroRequest(c *AvroCodec, subject, schema string) EncodeRequestFunc {
+	return func(ctx context.Context, pub *amqp.Publishing, request interface{}) error {
+		return encodeAvro(ctx, c, subject, schema, pub, request)
+	}
+}
+
+func encodeAvro(ctx context.Context, c *AvroCodec, subject, schema string, pub *amqp.Publishing, v interface{}) error {
+	b, err := c.Encode(ctx, subject, schema, v)
+	if err != nil {
+		return err
+	}
+	pub.Body = b
+	pub.ContentType = AvroContentType
+	return nil
+}
+
+// DecodeAvroRequest returns a DecodeRequestFunc decoding the Avro payload
+// of the delivery with c. Deliveries with a ContentType other than
+// AvroContentType are rejected.
+func DecodeAvroRequest(c *AvroCodec) DecodeRequestFunc {
+	return func(ctx context.Context, d *amqp.Delivery) (interface{}, error) {
+		return decodeAvro(ctx, c, d)
+	}
+}
+
+// DecodeAvroResponse returns a DecodeResponseFunc decoding the Avro payload
+// of the reply with c. It is designed to be used in Publishers.
+func DecodeAvroResponse(c *AvroCodec) DecodeResponseFunc {
+	return func(ctx context.Context, d *amqp.Delivery) (interface{}, error) {
+		return decodeAvro(ctx, c, d)
+	}
+}
+
+func decodeAvro(ctx context.Context, c *AvroCodec, d *amqp.Delivery) (interface{}, error) {
+	if ct := mediaType(d.ContentType); ct != "" && ct != AvroContentType {
+		return nil, UnsupportedContentTypeError{ContentType: d.ContentType}
+	}
+	return c.Decode(ctx, d.Body)
+}
@@ -0,0 +1,114 @@
+package amqp_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/streadway/amqp"
+
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+const testAvroSchema = `{"type":"record","name":"testRes","fields":[{"name":"squadron","type":"int"},{"name":"name","type":"string"}]}`
+
+// fakeSchemaRegistry serves the subset of the Confluent Schema Registry API
+// used by the client, and counts the requests.
+type fakeSchemaRegistry struct {
+	mtx      sync.Mutex
+	schemas  []string
+	requests int
+}
+
+func (r *fakeSchemaRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.requests++
+
+	switch {
+	case req.Method == "POST" && strings.HasPrefix(req.URL.Path, "/subjects/"):
+		var body struct{ Schema string }
+		json.NewDecoder(req.Body).Decode(&body)
+		r.schemas = append(r.schemas, body.Schema)
+		json.NewEncoder(w).Encode(map[string]int{"id": len(r.schemas)})
+	case req.Method == "GET" && strings.HasPrefix(req.URL.Path, "/schemas/ids/"):
+		id, _ := strconv.Atoi(strings.TrimPrefix(req.URL.Path, "/schemas/ids/"))
+		if id < 1 || id > len(r.schemas) {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]interface{}{"error_code": 40403, "message": "Schema not found"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"schema": r.schemas[id-1]})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestAvroRoundTrip(t *testing.T) {
+	registry := &fakeSchemaRegistry{}
+	server := httptest.NewServer(registry)
+	defer server.Close()
+
+	client := amqptransport.NewSchemaRegistryClient(server.URL)
+	encode := amqptransport.EncodeAvroRequest(amqptransport.NewAvroCodec(client), "test-value", testAvroSchema)
+	datum := map[string]interface{}{"squadron": int32(437), "name": "husky"}
+
+	var pub amqp.Publishing
+	for i := 0; i < 2; i++ {
+		if err := encode(context.Background(), &pub, datum); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want, have := amqptransport.AvroContentType, pub.ContentType; want != have {
+		t.Errorf("incorrect content type, want %q, have %q", want, have)
+	}
+	if want, have := []byte{0, 0, 0, 0, 1}, pub.Body[:5]; !reflect.DeepEqual(want, have) {
+		t.Errorf("incorrect header, want %v, have %v", want, have)
+	}
+
+	// The decoding side resolves the writer schema from the header.
+	decode := amqptransport.DecodeAvroRequest(amqptransport.NewAvroCodec(client))
+	for i := 0; i < 2; i++ {
+		request, err := decode(context.Background(), &amqp.Delivery{ContentType: pub.ContentType, Body: pub.Body})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want, have := datum, request; !reflect.DeepEqual(want, have) {
+			t.Errorf("want %v, have %v", want, have)
+		}
+	}
+
+	// One registration and one lookup; the rest is cached.
+	if want, have := 2, registry.requests; want != have {
+		t.Errorf("incorrect number of registry requests, want %d, have %d", want, have)
+	}
+}
+
+func TestAvroDecodeErrors(t *testing.T) {
+	server := httptest.NewServer(&fakeSchemaRegistry{})
+	defer server.Close()
+
+	decode := amqptransport.DecodeAvroRequest(amqptransport.NewAvroCodec(amqptransport.NewSchemaRegistryClient(server.URL)))
+
+	if _, err := decode(context.Background(), &amqp.Delivery{ContentType: "application/json"}); err == nil {
+		t.Error("want error for JSON delivery, have nil")
+	}
+	if _, err := decode(context.Background(), &amqp.Delivery{Body: []byte{1, 2}}); err != amqptransport.ErrInvalidAvroPayload {
+		t.Errorf("want %v, have %v", amqptransport.ErrInvalidAvroPayload, err)
+	}
+
+	_, err := decode(context.Background(), &amqp.Delivery{Body: []byte{0, 0, 0, 0, 7, 2}})
+	e, ok := err.(amqptransport.SchemaRegistryError)
+	if !ok {
+		t.Fatalf("want SchemaRegistryError, have %v", err)
+	}
+	if want, have := 40403, e.Code; want != have {
+		t.Errorf("incorrect error code, want %d, have %d", want, have)
+	}
+}
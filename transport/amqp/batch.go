@@ -0,0 +1,225 @@
+package amqp
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/streadway/amqp"
+
+	"github.com/inturn/kit/endpoint"
+	"github.com/inturn/kit/log"
+	"github.com/inturn/kit/transport"
+)
+
+// BatchAckMode decides how a BatchSubscriber acknowledges the deliveries of
+// a batch.
+type BatchAckMode int
+
+const (
+	// BatchAckAll acknowledges all deliveries of a batch if the endpoint
+	// succeeded, and rejects all of them otherwise. It is the default.
+	BatchAckAll BatchAckMode = iota
+
+	// BatchAckEach rejects the deliveries of the requests failed by the
+	// endpoint with BatchErrors, and acknowledges the others. Any other
+	// error rejects all deliveries of the batch.
+	BatchAckEach
+)
+
+// BatchErrors is returned by batch endpoints to fail individual requests of
+// a batch in BatchAckEach mode. It maps the index of every failed request
+// in the batch to its error.
+type BatchErrors map[int]error
+
+// Error implements the error interface.
+func (e BatchErrors) Error() string {
+	indexes := make([]int, 0, len(e))
+	for i := range e {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	msgs := make([]string, len(indexes))
+	for j, i := range indexes {
+		msgs[j] = fmt.Sprintf("request %d: %v", i, e[i])
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// BatchSubscriber serves deliveries in batches, for workloads like bulk
+// indexing or database upserts. It decodes every delivery, accumulates the
+// requests until the batch is full or the oldest request waited long
+// enough, and calls the endpoint with the requests as an []interface{}.
+// The response of the endpoint is discarded; nothing is replied.
+type BatchSubscriber struct {
+	e            endpoint.Endpoint
+	dec          DecodeRequestFunc
+	size         int
+	maxWait      time.Duration
+	ackMode      BatchAckMode
+	requeue      bool
+	errorHandler transport.ErrorHandler
+}
+
+// BatchSubscriberOption sets an optional parameter for batch subscribers.
+type BatchSubscriberOption func(*BatchSubscriber)
+
+// BatchSize sets the maximum number of requests in a batch. The default is
+// 100. The prefetch count of the channel should be at least as large, or
+// batches never fill up.
+func BatchSize(n int) BatchSubscriberOption {
+	return func(s *BatchSubscriber) { s.size = n }
+}
+
+// BatchMaxWait sets how long the first request of a batch waits for the
+// batch to fill up before the batch is served anyway. The default is a
+// second.
+func BatchMaxWait(d time.Duration) BatchSubscriberOption {
+	return func(s *BatchSubscriber) { s.maxWait = d }
+}
+
+// BatchSubscriberAckMode sets the BatchAckMode of the batch subscriber.
+func BatchSubscriberAckMode(mode BatchAckMode) BatchSubscriberOption {
+	return func(s *BatchSubscriber) { s.ackMode = mode }
+}
+
+// BatchSubscriberRequeue sets whether failed deliveries are requeued.
+// By default, they are rejected without requeueing, so they are
+// dead-lettered if the queue has a dead letter exchange. Deliveries that
+// can't be decoded are never requeued.
+func BatchSubscriberRequeue(requeue bool) BatchSubscriberOption {
+	return func(s *BatchSubscriber) { s.requeue = requeue }
+}
+
+// BatchSubscriberErrorHandler is used to handle errors decoding, serving and
+// acknowledging deliveries. The ErrorStage of the errors is passed in the
+// context, see ErrorStageFromContext. By default, errors are ignored.
+func BatchSubscriberErrorHandler(errorHandler transport.ErrorHandler) BatchSubscriberOption {
+	return func(s *BatchSubscriber) { s.errorHandler = errorHandler }
+}
+
+// BatchSubscriberErrorLogger is used to log errors decoding, serving and
+// acknowledging deliveries. By default, no errors are logged.
+//
+// Deprecated: Use BatchSubscriberErrorHandler instead.
+func BatchSubscriberErrorLogger(logger log.Logger) BatchSubscriberOption {
+	return func(s *BatchSubscriber) { s.errorHandler = transport.NewLogErrorHandler(logger) }
+}
+
+// NewBatchSubscriber constructs a new batch subscriber serving batches of
+// requests, decoded with dec, with e.
+func NewBatchSubscriber(
+	e endpoint.Endpoint,
+	dec DecodeRequestFunc,
+	options ...BatchSubscriberOption,
+) *BatchSubscriber {
+	s := &BatchSubscriber{
+		e:            e,
+		dec:          dec,
+		size:         100,
+		maxWait:      time.Second,
+		errorHandler: transport.NewLogErrorHandler(log.NewNopLogger()),
+	}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+// ServeDeliveries serves deliveries in batches until ctx is done or the
+// delivery channel is closed. The consumer must not auto-ack deliveries.
+// If ctx is done, the deliveries of the batch being accumulated are
+// requeued and ctx.Err() is returned. If the delivery channel is closed,
+// the last batch is served and ErrDeliveriesClosed is returned. ctx is
+// passed to the decoder and the endpoint, so they can abort their work
+// once it is done.
+func (s BatchSubscriber) ServeDeliveries(ctx context.Context, deliveries <-chan amqp.Delivery) error {
+	var (
+		batch    []amqp.Delivery
+		requests []interface{}
+		timeout  <-chan time.Time // fires when the batch waited long enough
+	)
+	flush := func() {
+		if len(requests) > 0 {
+			s.serve(ctx, batch, requests)
+		}
+		batch, requests, timeout = nil, nil, nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			for _, d := range batch {
+				s.nack(d, true)
+			}
+			return ctx.Err()
+
+		case <-timeout:
+			flush()
+
+		case d, ok := <-deliveries:
+			if !ok {
+				flush()
+				return ErrDeliveriesClosed
+			}
+			request, err := s.dec(ctx, &d)
+			if err != nil {
+				s.handleError(ErrorStageDecode, err)
+				s.nack(d, false)
+				continue
+			}
+			if len(requests) == 0 {
+				timeout = time.After(s.maxWait)
+			}
+			batch = append(batch, d)
+			requests = append(requests, request)
+			if len(requests) >= s.size {
+				flush()
+			}
+		}
+	}
+}
+
+func (s BatchSubscriber) serve(ctx context.Context, batch []amqp.Delivery, requests []interface{}) {
+	_, err := s.e(ctx, requests)
+	if err == nil {
+		for _, d := range batch {
+			s.ack(d)
+		}
+		return
+	}
+
+	s.handleError(ErrorStageEndpoint, err)
+	errs, ok := err.(BatchErrors)
+	if !ok || s.ackMode != BatchAckEach {
+		for _, d := range batch {
+			s.nack(d, s.requeue)
+		}
+		return
+	}
+	for i, d := range batch {
+		if _, failed := errs[i]; failed {
+			s.nack(d, s.requeue)
+		} else {
+			s.ack(d)
+		}
+	}
+}
+
+func (s BatchSubscriber) ack(d amqp.Delivery) {
+	if err := d.Ack(false); err != nil {
+		s.handleError(ErrorStageAcknowledge, err)
+	}
+}
+
+func (s BatchSubscriber) nack(d amqp.Delivery, requeue bool) {
+	if err := d.Nack(false, requeue); err != nil {
+		s.handleError(ErrorStageAcknowledge, err)
+	}
+}
+
+func (s BatchSubscriber) handleError(stage ErrorStage, err error) {
+	s.errorHandler.Handle(context.WithValue(context.Background(), ContextKeyErrorStage, stage), err)
+}
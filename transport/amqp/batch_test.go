@@ -0,0 +1,143 @@
+package amqp_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+func TestBatchSubscriber(t *testing.T) {
+	var batches [][]interface{}
+	sub := amqptransport.NewBatchSubscriber(
+		func(_ context.Context, request interface{}) (interface{}, error) {
+			batches = append(batches, request.([]interface{}))
+			return nil, nil
+		},
+		testReqDecoder,
+		amqptransport.BatchSize(2),
+		amqptransport.BatchMaxWait(10*time.Millisecond),
+	)
+
+	acker := &mockAcknowledger{}
+	deliveries := make(chan amqp.Delivery)
+	errc := make(chan error)
+	go func() { errc <- sub.ServeDeliveries(context.Background(), deliveries) }()
+
+	for _, body := range []string{`{"s":424}`, `{"s":426}`, `{"s":429}`, `not json`} {
+		deliveries <- amqp.Delivery{Acknowledger: acker, Body: []byte(body)}
+	}
+	time.Sleep(50 * time.Millisecond) // the last batch times out
+	deliveries <- amqp.Delivery{Acknowledger: acker, Body: []byte(`{"s":436}`)}
+	close(deliveries)
+	if want, have := amqptransport.ErrDeliveriesClosed, <-errc; want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+
+	if want, have := 3, len(batches); want != have {
+		t.Fatalf("want %d batches, have %d", want, have)
+	}
+	for i, want := range []int{2, 1, 1} {
+		if have := len(batches[i]); want != have {
+			t.Errorf("batch %d: want %d requests, have %d", i, want, have)
+		}
+	}
+	if want, have := 4, acker.acks; want != have {
+		t.Errorf("want %d acks, have %d", want, have)
+	}
+	if want, have := 1, acker.nacks; want != have {
+		t.Errorf("want %d nacks, have %d", want, have)
+	}
+}
+
+func TestBatchSubscriberAckMode(t *testing.T) {
+	for _, testcase := range []struct {
+		name        string
+		mode        amqptransport.BatchAckMode
+		err         error
+		acks, nacks int
+	}{
+		{"all", amqptransport.BatchAckAll, amqptransport.BatchErrors{1: errors.New("dummy")}, 0, 3},
+		{"each", amqptransport.BatchAckEach, amqptransport.BatchErrors{1: errors.New("dummy")}, 2, 1},
+		{"each with batch error", amqptransport.BatchAckEach, errors.New("dummy"), 0, 3},
+	} {
+		t.Run(testcase.name, func(t *testing.T) {
+			sub := amqptransport.NewBatchSubscriber(
+				func(context.Context, interface{}) (interface{}, error) { return nil, testcase.err },
+				testReqDecoder,
+				amqptransport.BatchSize(3),
+				amqptransport.BatchSubscriberAckMode(testcase.mode),
+				amqptransport.BatchSubscriberRequeue(true),
+			)
+			acker := &mockAcknowledger{}
+			deliveries := make(chan amqp.Delivery, 3)
+			for i := 0; i < 3; i++ {
+				deliveries <- amqp.Delivery{Acknowledger: acker, Body: []byte(`{"s":437}`)}
+			}
+			close(deliveries)
+			sub.ServeDeliveries(context.Background(), deliveries)
+
+			if want, have := testcase.acks, acker.acks; want != have {
+				t.Errorf("want %d acks, have %d", want, have)
+			}
+			if want, have := testcase.nacks, acker.nacks; want != have {
+				t.Errorf("want %d nacks, have %d", want, have)
+			}
+			if want, have := true, acker.requeue; want != have {
+				t.Errorf("want requeue %v, have %v", want, have)
+			}
+		})
+	}
+}
+
+func TestBatchSubscriberCancel(t *testing.T) {
+	sub := amqptransport.NewBatchSubscriber(
+		func(context.Context, interface{}) (interface{}, error) { return nil, nil },
+		testReqDecoder,
+	)
+	acker := &mockAcknowledger{}
+	deliveries := make(chan amqp.Delivery, 1)
+	deliveries <- amqp.Delivery{Acknowledger: acker, Body: []byte(`{"s":437}`)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if want, have := context.DeadlineExceeded, sub.ServeDeliveries(ctx, deliveries); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	if want, have := 1, acker.nacks; want != have {
+		t.Errorf("want %d nacks, have %d", want, have)
+	}
+	if !acker.requeue {
+		t.Error("want delivery requeued, have not")
+	}
+}
+
+func TestBatchSubscriberContext(t *testing.T) {
+	type key struct{}
+	var seen []interface{}
+	sub := amqptransport.NewBatchSubscriber(
+		func(ctx context.Context, request interface{}) (interface{}, error) {
+			seen = append(seen, ctx.Value(key{}))
+			return nil, nil
+		},
+		func(ctx context.Context, d *amqp.Delivery) (interface{}, error) {
+			seen = append(seen, ctx.Value(key{}))
+			return testReqDecoder(ctx, d)
+		},
+		amqptransport.BatchSize(1),
+	)
+
+	deliveries := make(chan amqp.Delivery, 1)
+	deliveries <- amqp.Delivery{Acknowledger: &mockAcknowledger{}, Body: []byte(`{"s":424}`)}
+	close(deliveries)
+	sub.ServeDeliveries(context.WithValue(context.Background(), key{}, "v"), deliveries)
+
+	if want, have := "[v v]", fmt.Sprint(seen); want != have {
+		t.Errorf("want decoder and endpoint to see %s, have %s", want, have)
+	}
+}
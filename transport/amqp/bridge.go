@@ -0,0 +1,178 @@
+package amqp
+
+import (
+	"context"
+	"errors"
+
+	"github.com/streadway/amqp"
+
+	"github.com/inturn/kit/endpoint"
+)
+
+// ErrCancelUnsupported is returned when canceling a consumer on a channel
+// that has no Cancel method.
+var ErrCancelUnsupported = errors.New("channel doesn't support Cancel")
+
+// Bridge consumes deliveries from a queue, usually of one broker, and
+// republishes them to an exchange, usually of another broker, e.g. to
+// migrate between clusters or to replicate messages across datacenters
+// without the shovel plugin:
+//
+//	b := amqptransport.NewBridge(dstCh, "orders", amqptransport.BridgeTransform(redact))
+//	err := b.Serve(ctx, srcCh, "orders", "orders-bridge", amqptransport.RunnerPrefetch(100, 0))
+//
+// It is a Subscriber whose reply is the republished message: its
+// properties and body are copied from the delivery, except the UserId,
+// which brokers check against the user of the connection. Deliveries are
+// acknowledged once republished, so publish on a ConfirmChannel to only
+// acknowledge them once the destination broker took responsibility for
+// them. Deliveries failing to be republished are requeued.
+type Bridge struct {
+	dst        Channel
+	exchange   string
+	key        *string
+	transform  endpoint.Endpoint
+	subOptions []SubscriberOption
+	sub        *Subscriber
+}
+
+// BridgeOption sets an optional parameter for bridges.
+type BridgeOption func(*Bridge)
+
+// BridgeRoutingKey sets the routing key messages are republished with. By
+// default, they keep the routing key of their delivery; set it for queues
+// bound to fanout exchanges, since messages with an empty routing key are
+// republished to their ReplyTo like replies.
+func BridgeRoutingKey(key string) BridgeOption {
+	return func(b *Bridge) { b.key = &key }
+}
+
+// BridgeTransform sets an endpoint transforming the messages before they
+// are republished. It is called with the amqp.Publishing copied from the
+// delivery and must return the amqp.Publishing to republish. If it fails,
+// the delivery is handled like other failures.
+func BridgeTransform(e endpoint.Endpoint) BridgeOption {
+	return func(b *Bridge) { b.transform = e }
+}
+
+// BridgeSubscriberOptions sets options of the underlying Subscriber, e.g.
+// SubscriberErrorEncoder to dead-letter deliveries failing to be
+// republished instead of requeueing them, or SubscriberMandatory.
+func BridgeSubscriberOptions(options ...SubscriberOption) BridgeOption {
+	return func(b *Bridge) { b.subOptions = append(b.subOptions, options...) }
+}
+
+// NewBridge returns a Bridge republishing messages on dst to exchange.
+func NewBridge(dst Channel, exchange string, options ...BridgeOption) *Bridge {
+	b := &Bridge{
+		dst:      dst,
+		exchange: exchange,
+		transform: func(_ context.Context, request interface{}) (interface{}, error) {
+			return request, nil
+		},
+	}
+	for _, option := range options {
+		option(b)
+	}
+	b.sub = NewSubscriber(
+		b.transform,
+		decodeBridgeDelivery,
+		encodeBridgePublishing,
+		append([]SubscriberOption{
+			SubscriberBefore(b.setDestination),
+			SubscriberAckMode(AckAfterPublish),
+			SubscriberErrorEncoder(SingleNackRequeueErrorEncoder),
+		}, b.subOptions...)...,
+	)
+	return b
+}
+
+// Serve consumes queue from src with the consumer tag and republishes the
+// deliveries until ctx is done or the delivery channel is closed, see
+// Subscriber.Serve.
+func (b *Bridge) Serve(ctx context.Context, src Channel, queue, consumer string, options ...RunnerOption) error {
+	return b.sub.Serve(ctx, bridgeChannel{src: src, dst: b.dst}, queue, consumer, options...)
+}
+
+// ServeDelivery returns a function republishing deliveries consumed from
+// src, see Subscriber.ServeDelivery.
+func (b *Bridge) ServeDelivery(src Channel) func(deliv *amqp.Delivery) {
+	return b.sub.ServeDelivery(bridgeChannel{src: src, dst: b.dst})
+}
+
+// setDestination routes the republished message to the exchange and
+// routing key of the bridge.
+func (b *Bridge) setDestination(ctx context.Context, pub *amqp.Publishing, d *amqp.Delivery) context.Context {
+	key := d.RoutingKey
+	if b.key != nil {
+		key = *b.key
+	}
+	ctx = context.WithValue(ctx, ContextKeyExchange, b.exchange)
+	return context.WithValue(ctx, ContextKeyPublishKey, key)
+}
+
+// decodeBridgeDelivery copies the message of d into an amqp.Publishing.
+func decodeBridgeDelivery(_ context.Context, d *amqp.Delivery) (interface{}, error) {
+	return publishingOf(d), nil
+}
+
+// publishingOf returns the properties and body of d as an amqp.Publishing,
+// without the UserId.
+func publishingOf(d *amqp.Delivery) amqp.Publishing {
+	return amqp.Publishing{
+		Headers:         d.Headers,
+		ContentType:     d.ContentType,
+		ContentEncoding: d.ContentEncoding,
+		DeliveryMode:    d.DeliveryMode,
+		Priority:        d.Priority,
+		CorrelationId:   d.CorrelationId,
+		ReplyTo:         d.ReplyTo,
+		Expiration:      d.Expiration,
+		MessageId:       d.MessageId,
+		Timestamp:       d.Timestamp,
+		Type:            d.Type,
+		AppId:           d.AppId,
+		Body:            d.Body,
+	}
+}
+
+// encodeBridgePublishing sets the reply to the transformed amqp.Publishing.
+func encodeBridgePublishing(_ context.Context, pub *amqp.Publishing, response interface{}) error {
+	*pub = response.(amqp.Publishing)
+	return nil
+}
+
+// bridgeChannel consumes from src and publishes to dst.
+type bridgeChannel struct {
+	src, dst Channel
+}
+
+func (ch bridgeChannel) Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	return ch.dst.Publish(exchange, key, mandatory, immediate, msg)
+}
+
+func (ch bridgeChannel) Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error) {
+	return ch.src.Consume(queue, consumer, autoAck, exclusive, noLocal, noWait, args)
+}
+
+// Qos sets the prefetch limit of src, see RunnerPrefetch.
+func (ch bridgeChannel) Qos(prefetchCount, prefetchSize int, global bool) error {
+	c, ok := ch.src.(interface {
+		Qos(prefetchCount, prefetchSize int, global bool) error
+	})
+	if !ok {
+		return ErrQoSUnsupported
+	}
+	return c.Qos(prefetchCount, prefetchSize, global)
+}
+
+// Cancel cancels the consumer of src, see RunnerConsumer.
+func (ch bridgeChannel) Cancel(consumer string, noWait bool) error {
+	c, ok := ch.src.(interface {
+		Cancel(consumer string, noWait bool) error
+	})
+	if !ok {
+		return ErrCancelUnsupported
+	}
+	return c.Cancel(consumer, noWait)
+}
@@ -0,0 +1,108 @@
+package amqp_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/streadway/amqp"
+
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+// routedPublishing is a publishing with its exchange and routing key.
+type routedPublishing struct {
+	exchange, key string
+	msg           amqp.Publishing
+}
+
+type routingChannel struct {
+	countingChannel
+	published []routedPublishing
+}
+
+func (ch *routingChannel) Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	ch.published = append(ch.published, routedPublishing{exchange, key, msg})
+	return nil
+}
+
+func TestBridge(t *testing.T) {
+	dst := &routingChannel{}
+	b := amqptransport.NewBridge(dst, "replica",
+		amqptransport.BridgeTransform(func(_ context.Context, request interface{}) (interface{}, error) {
+			pub := request.(amqp.Publishing)
+			if string(pub.Body) == "poison" {
+				return nil, errors.New("dummy")
+			}
+			pub.Headers = amqp.Table{"x-bridged": true}
+			return pub, nil
+		}),
+	)
+
+	src := &countingChannel{}
+	acker := &mockAcknowledger{}
+	b.ServeDelivery(src)(&amqp.Delivery{
+		Acknowledger:  acker,
+		RoutingKey:    "order.created",
+		CorrelationId: "c1",
+		MessageId:     "m1",
+		DeliveryMode:  amqp.Persistent,
+		Body:          []byte("order"),
+	})
+
+	if want, have := 1, len(dst.published); want != have {
+		t.Fatalf("want %d published, have %d", want, have)
+	}
+	p := dst.published[0]
+	if want, have := "replica", p.exchange; want != have {
+		t.Errorf("want exchange %q, have %q", want, have)
+	}
+	if want, have := "order.created", p.key; want != have {
+		t.Errorf("want key %q, have %q", want, have)
+	}
+	if want, have := "m1", p.msg.MessageId; want != have {
+		t.Errorf("want message ID %q, have %q", want, have)
+	}
+	if want, have := amqp.Persistent, p.msg.DeliveryMode; want != have {
+		t.Errorf("want delivery mode %d, have %d", want, have)
+	}
+	if want, have := "order", string(p.msg.Body); want != have {
+		t.Errorf("want body %q, have %q", want, have)
+	}
+	if p.msg.Headers["x-bridged"] != true {
+		t.Error("transform not applied")
+	}
+	if want, have := 1, acker.acks; want != have {
+		t.Errorf("want %d acks, have %d", want, have)
+	}
+	if want, have := int32(0), src.published; want != have {
+		t.Errorf("want %d published on source, have %d", want, have)
+	}
+
+	b.ServeDelivery(src)(&amqp.Delivery{Acknowledger: acker, Body: []byte("poison")})
+	if want, have := 1, len(dst.published); want != have {
+		t.Errorf("want %d published, have %d", want, have)
+	}
+	if want, have := 1, acker.nacks; want != have || !acker.requeue {
+		t.Errorf("want %d requeued nack, have %d (requeue %v)", want, have, acker.requeue)
+	}
+}
+
+func TestBridgeRoutingKey(t *testing.T) {
+	dst := &routingChannel{}
+	b := amqptransport.NewBridge(dst, "replica", amqptransport.BridgeRoutingKey("all"))
+	b.ServeDelivery(&countingChannel{})(&amqp.Delivery{
+		Acknowledger: &mockAcknowledger{},
+		RoutingKey:   "order.created",
+		ReplyTo:      "replies",
+	})
+	if want, have := 1, len(dst.published); want != have {
+		t.Fatalf("want %d published, have %d", want, have)
+	}
+	if want, have := "all", dst.published[0].key; want != have {
+		t.Errorf("want key %q, have %q", want, have)
+	}
+	if want, have := "replies", dst.published[0].msg.ReplyTo; want != have {
+		t.Errorf("want reply to %q, have %q", want, have)
+	}
+}
@@ -0,0 +1,99 @@
+package amqp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/streadway/amqp"
+)
+
+// CheckedConnection is the connection checked by a Checker, like
+// *amqp.Connection.
+type CheckedConnection interface {
+	NotifyClose(receiver chan *amqp.Error) chan *amqp.Error
+	Channel() (*amqp.Channel, error)
+}
+
+// Checker verifies the liveness of an AMQP connection. It implements the
+// Checker interface of package health, so it can be registered with a
+// health.Registry serving /healthz:
+//
+//	registry.Register("amqp", amqptransport.NewChecker(conn, amqptransport.CheckerExchange("orders")), health.Liveness())
+//
+// The connection is down once it was closed, including by the client
+// library after missed heartbeats. Closed connections aren't reopened, so
+// it is usually registered as a liveness check. To check a
+// ConnectionManager, which reconnects by itself, register it directly.
+type Checker struct {
+	conn      CheckedConnection
+	exchanges []string
+
+	mtx sync.Mutex
+	err error
+}
+
+// CheckerOption sets an optional parameter for checkers.
+type CheckerOption func(*Checker)
+
+// CheckerExchange makes the checker passively declare the exchange on a new
+// channel on every check, verifying that the broker still serves the
+// connection and the exchange exists.
+func CheckerExchange(exchange string) CheckerOption {
+	return func(c *Checker) { c.exchanges = append(c.exchanges, exchange) }
+}
+
+// NewChecker returns a Checker of conn.
+func NewChecker(conn CheckedConnection, options ...CheckerOption) *Checker {
+	c := &Checker{conn: conn}
+	for _, option := range options {
+		option(c)
+	}
+	closed := conn.NotifyClose(make(chan *amqp.Error, 1))
+	go func() {
+		err := <-closed
+		c.mtx.Lock()
+		defer c.mtx.Unlock()
+		if err != nil {
+			c.err = fmt.Errorf("connection closed: %v", err)
+		} else {
+			c.err = errors.New("connection closed")
+		}
+	}()
+	return c
+}
+
+// Check returns an error if the connection was closed or an exchange set
+// with CheckerExchange can't be declared passively before ctx is done.
+func (c *Checker) Check(ctx context.Context) error {
+	c.mtx.Lock()
+	err := c.err
+	c.mtx.Unlock()
+	if err != nil || len(c.exchanges) == 0 {
+		return err
+	}
+
+	errc := make(chan error, 1)
+	go func() { errc <- c.declarePassive() }()
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Checker) declarePassive() error {
+	ch, err := c.conn.Channel()
+	if err != nil {
+		return err
+	}
+	defer ch.Close()
+	for _, exchange := range c.exchanges {
+		if err := ch.ExchangeDeclarePassive(exchange, amqp.ExchangeDirect, false, false, false, false, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
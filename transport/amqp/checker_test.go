@@ -0,0 +1,66 @@
+package amqp_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+// checkedConnection is a connection failing to open channels.
+type checkedConnection struct {
+	closed chan *amqp.Error
+}
+
+func (c *checkedConnection) NotifyClose(receiver chan *amqp.Error) chan *amqp.Error {
+	c.closed = receiver
+	return receiver
+}
+
+func (c *checkedConnection) Channel() (*amqp.Channel, error) {
+	return nil, amqp.ErrClosed
+}
+
+func TestChecker(t *testing.T) {
+	conn := &checkedConnection{}
+	c := amqptransport.NewChecker(conn)
+	if err := c.Check(context.Background()); err != nil {
+		t.Fatalf("want connection up, have %v", err)
+	}
+
+	conn.closed <- &amqp.Error{Code: amqp.ConnectionForced, Reason: "missed heartbeats"}
+	deadline := time.Now().Add(time.Second)
+	err := c.Check(context.Background())
+	for err == nil && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+		err = c.Check(context.Background())
+	}
+	if err == nil || !strings.Contains(err.Error(), "missed heartbeats") {
+		t.Errorf("want connection closed, have %v", err)
+	}
+}
+
+func TestCheckerExchange(t *testing.T) {
+	c := amqptransport.NewChecker(&checkedConnection{}, amqptransport.CheckerExchange("orders"))
+	if want, have := amqp.ErrClosed, c.Check(context.Background()); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
+
+func TestConnectionManagerCheck(t *testing.T) {
+	m := amqptransport.NewConnectionManager(func() (*amqp.Connection, error) {
+		return nil, errors.New("connection refused")
+	})
+	if want, have := amqptransport.ErrNotConnected, m.Check(context.Background()); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	m.Close()
+	if want, have := amqptransport.ErrManagerClosed, m.Check(context.Background()); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
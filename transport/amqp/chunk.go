@@ -0,0 +1,265 @@
+package amqp
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// Headers identifying the chunks of a Publishing split by ChunkingChannel.
+const (
+	// ChunkGroupHeader is the ID shared by the chunks of a Publishing, its
+	// MessageId or a random one.
+	ChunkGroupHeader = "x-chunk-group"
+	// ChunkIndexHeader is the position of a chunk in its group, from 0.
+	ChunkIndexHeader = "x-chunk-index"
+	// ChunkCountHeader is the number of chunks in the group.
+	ChunkCountHeader = "x-chunk-count"
+)
+
+// ChunkingChannel is a Channel splitting the bodies of publishings larger
+// than a size into chunks, published in order as separate messages with the
+// properties of the original and the chunk headers. Use it for brokers
+// limiting the size of messages, and a Reassembler on the consuming side.
+type ChunkingChannel struct {
+	Channel
+	size int
+}
+
+// NewChunkingChannel returns a ChunkingChannel publishing on ch in chunks
+// of up to size bytes. It panics if size isn't positive.
+func NewChunkingChannel(ch Channel, size int) *ChunkingChannel {
+	if size <= 0 {
+		panic("amqp: non-positive size for NewChunkingChannel")
+	}
+	return &ChunkingChannel{Channel: ch, size: size}
+}
+
+// Publish implements Channel. It stops at the first chunk that fails to
+// publish; the chunks published before are discarded by the Reassembler
+// once its timeout elapses.
+func (c *ChunkingChannel) Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	if len(msg.Body) <= c.size {
+		return c.Channel.Publish(exchange, key, mandatory, immediate, msg)
+	}
+
+	group := msg.MessageId
+	if group == "" {
+		group = randomString(32)
+	}
+	count := (len(msg.Body) + c.size - 1) / c.size
+	for i := 0; i < count; i++ {
+		chunk := msg
+		chunk.Headers = make(amqp.Table, len(msg.Headers)+3)
+		for k, v := range msg.Headers {
+			chunk.Headers[k] = v
+		}
+		chunk.Headers[ChunkGroupHeader] = group
+		chunk.Headers[ChunkIndexHeader] = int64(i)
+		chunk.Headers[ChunkCountHeader] = int64(count)
+
+		end := (i + 1) * c.size
+		if end > len(msg.Body) {
+			end = len(msg.Body)
+		}
+		chunk.Body = msg.Body[i*c.size : end]
+		if err := c.Channel.Publish(exchange, key, mandatory, immediate, chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reassembler reassembles the chunks published by a ChunkingChannel into
+// the original deliveries. Chunks are held, unacknowledged, until their
+// group is complete, so the prefetch count of the channel must be larger
+// than the number of chunks of the largest message, and all chunks of a
+// group must reach the same consumer, e.g. with a single active consumer.
+type Reassembler struct {
+	timeout   time.Duration
+	maxChunks int
+}
+
+// ReassemblerOption sets an optional parameter for reassemblers.
+type ReassemblerOption func(*Reassembler)
+
+// ReassemblerTimeout sets how long the chunks of an incomplete group are
+// held after the first of them arrived. Afterwards, they are rejected
+// without requeueing. The default is a minute; timeouts that aren't
+// positive are ignored.
+func ReassemblerTimeout(timeout time.Duration) ReassemblerOption {
+	return func(r *Reassembler) {
+		if timeout > 0 {
+			r.timeout = timeout
+		}
+	}
+}
+
+// ReassemblerMaxChunks sets the maximum number of chunks of a group. The
+// chunks of larger groups are rejected without requeueing, so a sender
+// can't make the Reassembler allocate without bounds. The default is 1024.
+func ReassemblerMaxChunks(n int) ReassemblerOption {
+	return func(r *Reassembler) { r.maxChunks = n }
+}
+
+// NewReassembler returns a Reassembler.
+func NewReassembler(options ...ReassemblerOption) *Reassembler {
+	r := &Reassembler{
+		timeout:   time.Minute,
+		maxChunks: 1024,
+	}
+	for _, option := range options {
+		option(r)
+	}
+	return r
+}
+
+// Reassemble returns a channel of the deliveries of deliveries, with the
+// chunks of each group replaced by a single delivery once they all
+// arrived, e.g. for NewRunner. The reassembled delivery has the properties
+// of the last chunk, without the chunk headers, and acknowledging it
+// acknowledges all the chunks. Deliveries without chunk headers are passed
+// as they are. The returned channel is closed once deliveries is; chunks of
+// incomplete groups are left unacknowledged then.
+func (r *Reassembler) Reassemble(deliveries <-chan amqp.Delivery) <-chan amqp.Delivery {
+	out := make(chan amqp.Delivery)
+	go func() {
+		defer close(out)
+
+		groups := map[string]*chunkGroup{}
+		tick := r.timeout / 2
+		if tick <= 0 {
+			tick = r.timeout
+		}
+		ticker := time.NewTicker(tick)
+		defer ticker.Stop()
+		for {
+			select {
+			case d, ok := <-deliveries:
+				if !ok {
+					return
+				}
+				group, ok := d.Headers[ChunkGroupHeader].(string)
+				if !ok {
+					out <- d
+					continue
+				}
+				count := toInt64(d.Headers[ChunkCountHeader])
+				g, ok := groups[group]
+				if !ok && count > 0 && count <= int64(r.maxChunks) {
+					g = &chunkGroup{
+						chunks:  make([]*amqp.Delivery, count),
+						expires: time.Now().Add(r.timeout),
+					}
+					groups[group] = g
+				}
+				if g == nil || !g.add(d) {
+					// Chunks not fitting the group can't be reassembled.
+					d.Reject(false) // don't requeue
+					continue
+				}
+				if g.complete() {
+					delete(groups, group)
+					out <- g.delivery()
+				}
+
+			case now := <-ticker.C:
+				for group, g := range groups {
+					if now.After(g.expires) {
+						delete(groups, group)
+						g.reject()
+					}
+				}
+			}
+		}
+	}()
+	return out
+}
+
+// chunkGroup holds the chunks of a group as they arrive.
+type chunkGroup struct {
+	chunks   []*amqp.Delivery
+	received int
+	expires  time.Time
+}
+
+// add adds the chunk d to g, reporting whether it fits. A chunk received
+// twice, e.g. because it was published twice, replaces the earlier copy,
+// which is acknowledged.
+func (g *chunkGroup) add(d amqp.Delivery) bool {
+	i := toInt64(d.Headers[ChunkIndexHeader])
+	if i < 0 || i >= int64(len(g.chunks)) || toInt64(d.Headers[ChunkCountHeader]) != int64(len(g.chunks)) {
+		return false
+	}
+	if prev := g.chunks[i]; prev != nil {
+		prev.Ack(false)
+	} else {
+		g.received++
+	}
+	g.chunks[i] = &d
+	return true
+}
+
+func (g *chunkGroup) complete() bool {
+	return g.received == len(g.chunks)
+}
+
+// delivery returns the delivery reassembled from the chunks of g.
+func (g *chunkGroup) delivery() amqp.Delivery {
+	var (
+		body bytes.Buffer
+		ack  = make(chunkAcknowledger, len(g.chunks))
+	)
+	for i, c := range g.chunks {
+		body.Write(c.Body)
+		ack[i] = *c
+	}
+	d := *g.chunks[len(g.chunks)-1]
+	d.Headers = make(amqp.Table, len(d.Headers))
+	for k, v := range g.chunks[len(g.chunks)-1].Headers {
+		switch k {
+		case ChunkGroupHeader, ChunkIndexHeader, ChunkCountHeader:
+		default:
+			d.Headers[k] = v
+		}
+	}
+	d.Body = body.Bytes()
+	d.Acknowledger = ack
+	return d
+}
+
+// reject rejects the chunks received of g without requeueing.
+func (g *chunkGroup) reject() {
+	for _, c := range g.chunks {
+		if c != nil {
+			c.Reject(false) // don't requeue
+		}
+	}
+}
+
+// chunkAcknowledger acknowledges the chunks of a reassembled delivery.
+type chunkAcknowledger []amqp.Delivery
+
+func (a chunkAcknowledger) Ack(tag uint64, multiple bool) error {
+	return a.each(func(d amqp.Delivery) error { return d.Ack(multiple) })
+}
+
+func (a chunkAcknowledger) Nack(tag uint64, multiple bool, requeue bool) error {
+	return a.each(func(d amqp.Delivery) error { return d.Nack(multiple, requeue) })
+}
+
+func (a chunkAcknowledger) Reject(tag uint64, requeue bool) error {
+	return a.each(func(d amqp.Delivery) error { return d.Reject(requeue) })
+}
+
+// each calls f with every chunk, returning the first error.
+func (a chunkAcknowledger) each(f func(amqp.Delivery) error) error {
+	var first error
+	for _, d := range a {
+		if err := f(d); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
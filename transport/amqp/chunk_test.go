@@ -0,0 +1,167 @@
+package amqp_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+// chunkDeliveries publishes msg on a ChunkingChannel with chunks of size
+// bytes, returning the chunks as deliveries acknowledged by acker.
+func chunkDeliveries(t *testing.T, msg amqp.Publishing, size int, acker amqp.Acknowledger) []amqp.Delivery {
+	published := make(chan amqp.Publishing, 16)
+	ch := amqptransport.NewChunkingChannel(&mockChannel{f: nullFunc, c: published}, size)
+	if err := ch.Publish("", "fleet", false, false, msg); err != nil {
+		t.Fatal(err)
+	}
+	close(published)
+
+	var deliveries []amqp.Delivery
+	for p := range published {
+		deliveries = append(deliveries, amqp.Delivery{
+			Acknowledger: acker,
+			Headers:      p.Headers,
+			MessageId:    p.MessageId,
+			Body:         p.Body,
+		})
+	}
+	return deliveries
+}
+
+func TestChunkingReassembly(t *testing.T) {
+	acker := &mockAcknowledger{}
+	deliveries := chunkDeliveries(t, amqp.Publishing{
+		Headers:   amqp.Table{"x-ship": "falcon"},
+		MessageId: "m1",
+		Body:      []byte("millennium"),
+	}, 4, acker)
+	if want, have := 3, len(deliveries); want != have {
+		t.Fatalf("want %d chunks, have %d", want, have)
+	}
+
+	in := make(chan amqp.Delivery, len(deliveries)+1)
+	// Chunks arriving out of order are put back in order.
+	in <- deliveries[2]
+	in <- deliveries[0]
+	in <- amqp.Delivery{Body: []byte("whole")}
+	in <- deliveries[1]
+	close(in)
+
+	out := amqptransport.NewReassembler().Reassemble(in)
+	if want, have := "whole", string((<-out).Body); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	d := <-out
+	if want, have := "millennium", string(d.Body); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := "falcon", d.Headers["x-ship"]; want != have {
+		t.Errorf("want header %q, have %q", want, have)
+	}
+	if _, ok := d.Headers[amqptransport.ChunkGroupHeader]; ok {
+		t.Error("chunk headers not removed from reassembled delivery")
+	}
+	if err := d.Ack(false); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := 3, acker.acks; want != have {
+		t.Errorf("want %d chunks acknowledged, have %d", want, have)
+	}
+	if _, ok := <-out; ok {
+		t.Error("reassembled channel not closed")
+	}
+}
+
+func TestChunkingSmallMessage(t *testing.T) {
+	deliveries := chunkDeliveries(t, amqp.Publishing{Body: []byte("xwing")}, 8, nil)
+	if want, have := 1, len(deliveries); want != have {
+		t.Fatalf("want %d message, have %d", want, have)
+	}
+	if _, ok := deliveries[0].Headers[amqptransport.ChunkGroupHeader]; ok {
+		t.Error("small message has chunk headers")
+	}
+}
+
+func TestChunkingChannelSize(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("want panic for zero size, have none")
+		}
+	}()
+	amqptransport.NewChunkingChannel(nil, 0)
+}
+
+func TestReassemblerTimeout(t *testing.T) {
+	acker := &mockAcknowledger{}
+	deliveries := chunkDeliveries(t, amqp.Publishing{Body: []byte("millennium")}, 4, acker)
+
+	in := make(chan amqp.Delivery, len(deliveries))
+	in <- deliveries[0]
+	in <- deliveries[1]
+	out := amqptransport.NewReassembler(amqptransport.ReassemblerTimeout(10 * time.Millisecond)).Reassemble(in)
+
+	time.Sleep(50 * time.Millisecond)
+	close(in)
+	if _, ok := <-out; ok {
+		t.Error("incomplete group delivered")
+	}
+	if want, have := 2, acker.rejects; want != have {
+		t.Errorf("want %d chunks rejected, have %d", want, have)
+	}
+	if acker.requeue {
+		t.Error("expired chunks requeued")
+	}
+}
+
+func TestReassemblerDuplicateChunk(t *testing.T) {
+	acker := &mockAcknowledger{}
+	deliveries := chunkDeliveries(t, amqp.Publishing{Body: []byte("millennium")}, 4, acker)
+
+	in := make(chan amqp.Delivery, len(deliveries)+1)
+	in <- deliveries[0]
+	in <- deliveries[0]
+	in <- deliveries[1]
+	in <- deliveries[2]
+	close(in)
+
+	out := amqptransport.NewReassembler().Reassemble(in)
+	d := <-out
+	if want, have := "millennium", string(d.Body); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := 1, acker.acks; want != have {
+		t.Errorf("want replaced chunk acknowledged, have %d acks", have)
+	}
+	d.Ack(false)
+	if want, have := 4, acker.acks; want != have {
+		t.Errorf("want %d acks, have %d", want, have)
+	}
+}
+
+func TestReassemblerMaxChunks(t *testing.T) {
+	acker := &mockAcknowledger{}
+	in := make(chan amqp.Delivery, 1)
+	in <- amqp.Delivery{
+		Acknowledger: acker,
+		Headers: amqp.Table{
+			amqptransport.ChunkGroupHeader: "g",
+			amqptransport.ChunkIndexHeader: int64(0),
+			amqptransport.ChunkCountHeader: int64(1) << 60,
+		},
+	}
+	close(in)
+
+	out := amqptransport.NewReassembler(
+		amqptransport.ReassemblerMaxChunks(16),
+		amqptransport.ReassemblerTimeout(0),
+	).Reassemble(in)
+	if _, ok := <-out; ok {
+		t.Error("oversized group delivered")
+	}
+	if want, have := 1, acker.rejects; want != have || acker.requeue {
+		t.Errorf("want %d chunk rejected without requeue, have %d (requeue %v)", want, have, acker.requeue)
+	}
+}
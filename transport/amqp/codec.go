@@ -0,0 +1,124 @@
+package amqp
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"strings"
+
+	"github.com/streadway/amqp"
+)
+
+// UnsupportedContentTypeError is returned by CodecRegistry.DecodeRequest
+// for deliveries with a content type no codec is registered for.
+type UnsupportedContentTypeError struct {
+	ContentType string
+}
+
+// Error implements the error interface.
+func (e UnsupportedContentTypeError) Error() string {
+	return fmt.Sprintf("unsupported content type %q", e.ContentType)
+}
+
+// Codec decodes requests of and encodes responses to a content type.
+type Codec struct {
+	Decode DecodeRequestFunc
+	Encode EncodeResponseFunc
+}
+
+// CodecRegistry picks the codec of requests by the ContentType of their
+// delivery, and encodes the responses with the same codec, so a Subscriber
+// can serve clients using different encodings:
+//
+//	codecs := amqptransport.NewCodecRegistry("application/json")
+//	codecs.Register("application/json", amqptransport.Codec{Decode: decodeJSON, Encode: amqptransport.EncodeJSONResponse})
+//	codecs.Register("application/x-protobuf", amqptransport.Codec{Decode: decodeProto, Encode: encodeProto})
+//	sub := amqptransport.NewSubscriber(e, nil, nil, amqptransport.SubscriberCodecs(codecs))
+//
+// Codecs must be registered before the registry is used.
+type CodecRegistry struct {
+	defaultType string
+	codecs      map[string]Codec
+}
+
+// NewCodecRegistry returns an empty CodecRegistry, which uses the codec of
+// defaultType for deliveries without a content type.
+func NewCodecRegistry(defaultType string) *CodecRegistry {
+	return &CodecRegistry{
+		defaultType: defaultType,
+		codecs:      map[string]Codec{},
+	}
+}
+
+// Register registers c for contentType. Parameters of the content type,
+// like charset, are ignored.
+func (r *CodecRegistry) Register(contentType string, c Codec) {
+	r.codecs[mediaType(contentType)] = c
+}
+
+// SubscriberCodecs sets the decoder and encoder of the subscriber to those
+// of r, replacing the ones passed to NewSubscriber.
+func SubscriberCodecs(r *CodecRegistry) SubscriberOption {
+	return func(s *Subscriber) {
+		s.dec = r.DecodeRequest
+		s.enc = r.EncodeResponse
+		s.before = append([]RequestFunc{r.setContentType}, s.before...)
+	}
+}
+
+// setContentType stores the content type of the request in the context.
+func (r *CodecRegistry) setContentType(ctx context.Context, pub *amqp.Publishing, d *amqp.Delivery) context.Context {
+	contentType := mediaType(d.ContentType)
+	if contentType == "" {
+		contentType = r.defaultType
+	}
+	return context.WithValue(ctx, ContextKeyContentType, contentType)
+}
+
+// DecodeRequest is a DecodeRequestFunc decoding the delivery with the codec
+// of its content type. It returns an UnsupportedContentTypeError if there
+// is none.
+func (r *CodecRegistry) DecodeRequest(ctx context.Context, d *amqp.Delivery) (interface{}, error) {
+	contentType := mediaType(d.ContentType)
+	if contentType == "" {
+		contentType = r.defaultType
+	}
+	c, ok := r.codecs[contentType]
+	if !ok {
+		return nil, UnsupportedContentTypeError{ContentType: d.ContentType}
+	}
+	return c.Decode(ctx, d)
+}
+
+// EncodeResponse is an EncodeResponseFunc encoding the response with the
+// codec of the request content type, or the default codec, and setting
+// the ContentType of the reply unless the codec did. The request content
+// type is only known to subscribers configured with SubscriberCodecs.
+func (r *CodecRegistry) EncodeResponse(ctx context.Context, pub *amqp.Publishing, response interface{}) error {
+	contentType := r.defaultType
+	if ct, ok := ctx.Value(ContextKeyContentType).(string); ok {
+		contentType = ct
+	}
+	c, ok := r.codecs[contentType]
+	if !ok {
+		return UnsupportedContentTypeError{ContentType: contentType}
+	}
+	if err := c.Encode(ctx, pub, response); err != nil {
+		return err
+	}
+	if pub.ContentType == "" {
+		pub.ContentType = contentType
+	}
+	return nil
+}
+
+func mediaType(contentType string) string {
+	if contentType == "" {
+		return ""
+	}
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(contentType))
+	}
+	return mt
+}
@@ -0,0 +1,70 @@
+package amqp_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/streadway/amqp"
+
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+func TestSubscriberCodecs(t *testing.T) {
+	codecs := amqptransport.NewCodecRegistry("application/json")
+	codecs.Register("application/json", amqptransport.Codec{
+		Decode: testReqDecoder,
+		Encode: amqptransport.EncodeJSONResponse,
+	})
+	codecs.Register("text/plain", amqptransport.Codec{
+		Decode: func(_ context.Context, d *amqp.Delivery) (interface{}, error) {
+			var req testReq
+			_, err := fmt.Sscan(string(d.Body), &req.Squadron)
+			return req, err
+		},
+		Encode: func(_ context.Context, pub *amqp.Publishing, response interface{}) error {
+			pub.Body = []byte(response.(testRes).Name)
+			return nil
+		},
+	})
+
+	var lastErr error
+	sub := amqptransport.NewSubscriber(testEndpoint, nil, nil,
+		amqptransport.SubscriberCodecs(codecs),
+		amqptransport.ServerFinalizer(func(_ context.Context, err error) { lastErr = err }),
+	)
+
+	for _, testcase := range []struct {
+		contentType string
+		body        string
+		want        string
+		replyType   string
+	}{
+		{"", `{"s":437}`, `{"s":437,"n":"husky"}`, "application/json"},
+		{"application/json; charset=utf-8", `{"s":437}`, `{"s":437,"n":"husky"}`, "application/json"},
+		{"text/plain", "424", "tiger", "text/plain"},
+	} {
+		outputChan := make(chan amqp.Publishing, 1)
+		sub.ServeDelivery(&mockChannel{f: nullFunc, c: outputChan})(&amqp.Delivery{
+			ContentType: testcase.contentType,
+			Body:        []byte(testcase.body),
+		})
+		if lastErr != nil {
+			t.Fatal(lastErr)
+		}
+		reply := <-outputChan
+		if want, have := testcase.want, string(reply.Body); want != have {
+			t.Errorf("%q: want %s, have %s", testcase.contentType, want, have)
+		}
+		if want, have := testcase.replyType, reply.ContentType; want != have {
+			t.Errorf("%q: incorrect content type, want %q, have %q", testcase.contentType, want, have)
+		}
+	}
+
+	sub.ServeDelivery(&mockChannel{f: nullFunc})(&amqp.Delivery{ContentType: "application/xml"})
+	var unsupported amqptransport.UnsupportedContentTypeError
+	if !errors.As(lastErr, &unsupported) {
+		t.Errorf("want UnsupportedContentTypeError, have %v", lastErr)
+	}
+}
@@ -0,0 +1,184 @@
+package amqp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/streadway/amqp"
+)
+
+// Content encodings supported by the compression helpers. As in HTTP,
+// DeflateEncoding denotes the zlib format.
+const (
+	GzipEncoding    = "gzip"
+	DeflateEncoding = "deflate"
+)
+
+// DefaultMaxDecompressedSize is the maximum size of decompressed bodies,
+// unless set otherwise by DecompressMaxSize.
+const DefaultMaxDecompressedSize = 64 << 20
+
+// DecompressedTooLargeError is returned by the decompressing decoders for
+// bodies decompressing to more than the maximum size, e.g. compression
+// bombs.
+type DecompressedTooLargeError struct {
+	MaxSize int64
+}
+
+// Error implements the error interface.
+func (e DecompressedTooLargeError) Error() string {
+	return fmt.Sprintf("decompressed body exceeds %d bytes", e.MaxSize)
+}
+
+// DecompressOption sets an optional parameter for DecompressRequest and
+// DecompressResponse.
+type DecompressOption func(*decompressConfig)
+
+type decompressConfig struct {
+	maxSize int64
+}
+
+// DecompressMaxSize sets the maximum size of decompressed bodies. Bodies
+// exceeding it fail with a DecompressedTooLargeError. The default is
+// DefaultMaxDecompressedSize.
+func DecompressMaxSize(n int64) DecompressOption {
+	return func(c *decompressConfig) { c.maxSize = n }
+}
+
+func newDecompressConfig(options []DecompressOption) decompressConfig {
+	c := decompressConfig{maxSize: DefaultMaxDecompressedSize}
+	for _, option := range options {
+		option(&c)
+	}
+	return c
+}
+
+// UnsupportedContentEncodingError is returned by the compressing encoders
+// for content encodings other than GzipEncoding and DeflateEncoding.
+type UnsupportedContentEncodingError struct {
+	ContentEncoding string
+}
+
+// Error implements the error interface.
+func (e UnsupportedContentEncodingError) Error() string {
+	return fmt.Sprintf("unsupported content encoding %q", e.ContentEncoding)
+}
+
+// CompressResponse returns an EncodeResponseFunc encoding the response with
+// enc, then compressing the body with encoding and setting the
+// ContentEncoding of the reply, if the body is at least threshold bytes
+// long. Bodies the encoder set a ContentEncoding for are left alone.
+func CompressResponse(enc EncodeResponseFunc, encoding string, threshold int) EncodeResponseFunc {
+	return func(ctx context.Context, pub *amqp.Publishing, response interface{}) error {
+		if err := enc(ctx, pub, response); err != nil {
+			return err
+		}
+		return compress(pub, encoding, threshold)
+	}
+}
+
+// CompressRequest returns an EncodeRequestFunc encoding the request with
+// enc, then compressing the body with encoding and setting the
+// ContentEncoding of the publishing, if the body is at least threshold
+// bytes long. It is designed to be used in Publishers.
+func CompressRequest(enc EncodeRequestFunc, encoding string, threshold int) EncodeRequestFunc {
+	return func(ctx context.Context, pub *amqp.Publishing, request interface{}) error {
+		if err := enc(ctx, pub, request); err != nil {
+			return err
+		}
+		return compress(pub, encoding, threshold)
+	}
+}
+
+// DecompressRequest returns a DecodeRequestFunc decompressing deliveries
+// with a ContentEncoding of GzipEncoding or DeflateEncoding before decoding
+// them with dec. Other deliveries are passed to dec as they are.
+func DecompressRequest(dec DecodeRequestFunc, options ...DecompressOption) DecodeRequestFunc {
+	c := newDecompressConfig(options)
+	return func(ctx context.Context, d *amqp.Delivery) (interface{}, error) {
+		d, err := decompress(d, c.maxSize)
+		if err != nil {
+			return nil, err
+		}
+		return dec(ctx, d)
+	}
+}
+
+// DecompressResponse returns a DecodeResponseFunc decompressing replies
+// with a ContentEncoding of GzipEncoding or DeflateEncoding before decoding
+// them with dec. It is designed to be used in Publishers.
+func DecompressResponse(dec DecodeResponseFunc, options ...DecompressOption) DecodeResponseFunc {
+	c := newDecompressConfig(options)
+	return func(ctx context.Context, d *amqp.Delivery) (interface{}, error) {
+		d, err := decompress(d, c.maxSize)
+		if err != nil {
+			return nil, err
+		}
+		return dec(ctx, d)
+	}
+}
+
+func compress(pub *amqp.Publishing, encoding string, threshold int) error {
+	if pub.ContentEncoding != "" || len(pub.Body) < threshold {
+		return nil
+	}
+	var (
+		buf bytes.Buffer
+		w   io.WriteCloser
+	)
+	switch encoding {
+	case GzipEncoding:
+		w = gzip.NewWriter(&buf)
+	case DeflateEncoding:
+		w = zlib.NewWriter(&buf)
+	default:
+		return UnsupportedContentEncodingError{ContentEncoding: encoding}
+	}
+	if _, err := w.Write(pub.Body); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	pub.Body = buf.Bytes()
+	pub.ContentEncoding = encoding
+	return nil
+}
+
+// decompress returns a copy of d with its body decompressed and its
+// ContentEncoding cleared, or d itself if it isn't compressed. Bodies
+// decompressing to more than maxSize bytes fail.
+func decompress(d *amqp.Delivery, maxSize int64) (*amqp.Delivery, error) {
+	var (
+		r   io.ReadCloser
+		err error
+	)
+	switch d.ContentEncoding {
+	case GzipEncoding:
+		r, err = gzip.NewReader(bytes.NewReader(d.Body))
+	case DeflateEncoding:
+		r, err = zlib.NewReader(bytes.NewReader(d.Body))
+	default:
+		return d, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	body, err := ioutil.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > maxSize {
+		return nil, DecompressedTooLargeError{MaxSize: maxSize}
+	}
+	decompressed := *d
+	decompressed.Body = body
+	decompressed.ContentEncoding = ""
+	return &decompressed, nil
+}
@@ -0,0 +1,109 @@
+package amqp_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/streadway/amqp"
+
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+func TestCompressRoundTrip(t *testing.T) {
+	long := testReq{Squadron: 1000}
+	for _, encoding := range []string{amqptransport.GzipEncoding, amqptransport.DeflateEncoding} {
+		t.Run(encoding, func(t *testing.T) {
+			enc := amqptransport.CompressRequest(
+				func(_ context.Context, pub *amqp.Publishing, request interface{}) error {
+					pub.Body = []byte(strings.Repeat("squadron ", request.(testReq).Squadron))
+					return nil
+				},
+				encoding,
+				100,
+			)
+			var pub amqp.Publishing
+			if err := enc(context.Background(), &pub, long); err != nil {
+				t.Fatal(err)
+			}
+			if want, have := encoding, pub.ContentEncoding; want != have {
+				t.Errorf("want content encoding %q, have %q", want, have)
+			}
+			if len(pub.Body) >= 9000 {
+				t.Errorf("want compressed body, have %d bytes", len(pub.Body))
+			}
+
+			dec := amqptransport.DecompressRequest(func(_ context.Context, d *amqp.Delivery) (interface{}, error) {
+				return d, nil
+			})
+			d := &amqp.Delivery{Body: pub.Body, ContentEncoding: pub.ContentEncoding}
+			res, err := dec(context.Background(), d)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want, have := strings.Repeat("squadron ", 1000), string(res.(*amqp.Delivery).Body); want != have {
+				t.Errorf("want decompressed body, have %q", have)
+			}
+			if want, have := "", res.(*amqp.Delivery).ContentEncoding; want != have {
+				t.Errorf("want content encoding %q, have %q", want, have)
+			}
+			if want, have := encoding, d.ContentEncoding; want != have {
+				t.Errorf("want delivery left alone, have content encoding %q", have)
+			}
+		})
+	}
+}
+
+func TestCompressThreshold(t *testing.T) {
+	enc := amqptransport.CompressResponse(amqptransport.EncodeJSONResponse, amqptransport.GzipEncoding, 100)
+	var pub amqp.Publishing
+	if err := enc(context.Background(), &pub, testRes{Squadron: 436, Name: "falcon"}); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "", pub.ContentEncoding; want != have {
+		t.Errorf("want content encoding %q, have %q", want, have)
+	}
+	if want, have := `{"s":436,"n":"falcon"}`, string(pub.Body); want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+}
+
+func TestCompressUnsupportedEncoding(t *testing.T) {
+	enc := amqptransport.CompressResponse(amqptransport.EncodeJSONResponse, "br", 0)
+	err := enc(context.Background(), &amqp.Publishing{}, testRes{})
+	if want, have := (amqptransport.UnsupportedContentEncodingError{ContentEncoding: "br"}), err; want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
+
+func TestDecompressInvalidBody(t *testing.T) {
+	dec := amqptransport.DecompressResponse(func(context.Context, *amqp.Delivery) (interface{}, error) {
+		return nil, nil
+	})
+	_, err := dec(context.Background(), &amqp.Delivery{Body: []byte("plain"), ContentEncoding: amqptransport.GzipEncoding})
+	if err == nil {
+		t.Error("want error, have none")
+	}
+}
+
+func TestDecompressMaxSize(t *testing.T) {
+	enc := amqptransport.CompressRequest(func(_ context.Context, pub *amqp.Publishing, _ interface{}) error {
+		pub.Body = bytes.Repeat([]byte{0}, 1<<20)
+		return nil
+	}, amqptransport.GzipEncoding, 0)
+	var pub amqp.Publishing
+	if err := enc(context.Background(), &pub, nil); err != nil {
+		t.Fatal(err)
+	}
+	deliv := &amqp.Delivery{Body: pub.Body, ContentEncoding: pub.ContentEncoding}
+	nop := func(context.Context, *amqp.Delivery) (interface{}, error) { return nil, nil }
+
+	_, err := amqptransport.DecompressRequest(nop, amqptransport.DecompressMaxSize(1<<10))(context.Background(), deliv)
+	if want, have := (amqptransport.DecompressedTooLargeError{MaxSize: 1 << 10}), err; want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	if _, err := amqptransport.DecompressRequest(nop, amqptransport.DecompressMaxSize(1<<20))(context.Background(), deliv); err != nil {
+		t.Errorf("want no error at the maximum size, have %v", err)
+	}
+}
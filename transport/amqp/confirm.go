@@ -0,0 +1,128 @@
+package amqp
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// ErrPublishNacked is returned by ConfirmChannel.Publish when the broker
+// negatively acknowledged the publish, e.g. because a queue overflowed.
+var ErrPublishNacked = errors.New("publish nacked by broker")
+
+// ErrConfirmTimeout is returned by ConfirmChannel.Publish when the broker
+// didn't confirm the publish in time.
+var ErrConfirmTimeout = errors.New("timed out waiting for publish confirm")
+
+// ConfirmableChannel is a Channel that can be put into confirm mode, like
+// *amqp.Channel.
+type ConfirmableChannel interface {
+	Channel
+	Confirm(noWait bool) error
+	NotifyPublish(confirm chan amqp.Confirmation) chan amqp.Confirmation
+}
+
+// ConfirmChannel is a Channel in confirm mode, whose Publish waits for the
+// broker to confirm the publish. Pass it to Subscriber.ServeDelivery to
+// treat replies as sent only once the broker took responsibility for them;
+// failed publishes are passed to the error encoder and finalizers.
+type ConfirmChannel struct {
+	ch      ConfirmableChannel
+	timeout time.Duration
+
+	publishMtx sync.Mutex // serializes publishes, numbering them
+	seq        uint64
+
+	mtx     sync.Mutex // guards pending and err
+	pending map[uint64]chan bool
+	err     error
+}
+
+// NewConfirmChannel puts ch into confirm mode and returns a ConfirmChannel
+// waiting up to timeout for each publish to be confirmed. ch must not be
+// used for publishing other than through the ConfirmChannel.
+func NewConfirmChannel(ch ConfirmableChannel, timeout time.Duration) (*ConfirmChannel, error) {
+	if err := ch.Confirm(false); err != nil {
+		return nil, err
+	}
+	c := &ConfirmChannel{
+		ch:      ch,
+		timeout: timeout,
+		pending: map[uint64]chan bool{},
+	}
+	go c.receive(ch.NotifyPublish(make(chan amqp.Confirmation, 1)))
+	return c, nil
+}
+
+// Publish implements Channel. It returns ErrPublishNacked or
+// ErrConfirmTimeout if the broker didn't acknowledge the publish.
+func (c *ConfirmChannel) Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	c.publishMtx.Lock()
+	seq := c.seq + 1 // delivery tags of confirms start at 1
+	acked := make(chan bool, 1)
+	c.mtx.Lock()
+	if c.err != nil {
+		c.mtx.Unlock()
+		c.publishMtx.Unlock()
+		return c.err
+	}
+	// The confirm may arrive before Publish returns.
+	c.pending[seq] = acked
+	c.mtx.Unlock()
+
+	if err := c.ch.Publish(exchange, key, mandatory, immediate, msg); err != nil {
+		c.mtx.Lock()
+		delete(c.pending, seq)
+		c.mtx.Unlock()
+		c.publishMtx.Unlock()
+		return err
+	}
+	c.seq = seq
+	c.publishMtx.Unlock()
+
+	t := time.NewTimer(c.timeout)
+	defer t.Stop()
+	select {
+	case ok, open := <-acked:
+		switch {
+		case !open:
+			return amqp.ErrClosed
+		case !ok:
+			return ErrPublishNacked
+		}
+		return nil
+	case <-t.C:
+		c.mtx.Lock()
+		delete(c.pending, seq)
+		c.mtx.Unlock()
+		return ErrConfirmTimeout
+	}
+}
+
+// Consume implements Channel.
+func (c *ConfirmChannel) Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error) {
+	return c.ch.Consume(queue, consumer, autoAck, exclusive, noLocal, noWait, args)
+}
+
+// receive hands confirmations to the waiting publishes until the channel is
+// closed, failing the publishes still waiting then.
+func (c *ConfirmChannel) receive(confirms <-chan amqp.Confirmation) {
+	for confirm := range confirms {
+		c.mtx.Lock()
+		if acked, ok := c.pending[confirm.DeliveryTag]; ok {
+			acked <- confirm.Ack
+			delete(c.pending, confirm.DeliveryTag)
+		}
+		c.mtx.Unlock()
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.err = amqp.ErrClosed
+	for seq, acked := range c.pending {
+		close(acked)
+		delete(c.pending, seq)
+	}
+}
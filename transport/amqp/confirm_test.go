@@ -0,0 +1,66 @@
+package amqp_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+type confirmingChannel struct {
+	mockChannel
+	confirms chan amqp.Confirmation
+	tag      uint64
+	ack      func(tag uint64) (bool, bool)
+}
+
+func (ch *confirmingChannel) Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	ch.tag++
+	if ack, ok := ch.ack(ch.tag); ok {
+		ch.confirms <- amqp.Confirmation{DeliveryTag: ch.tag, Ack: ack}
+	}
+	return nil
+}
+
+func (ch *confirmingChannel) Confirm(noWait bool) error { return nil }
+
+func (ch *confirmingChannel) NotifyPublish(c chan amqp.Confirmation) chan amqp.Confirmation {
+	ch.confirms = c
+	return c
+}
+
+func TestConfirmChannel(t *testing.T) {
+	ch := &confirmingChannel{ack: func(tag uint64) (bool, bool) {
+		switch tag {
+		case 1:
+			return true, true
+		case 2:
+			return false, true
+		}
+		return false, false // never confirmed
+	}}
+	c, err := amqptransport.NewConfirmChannel(ch, 20*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []error{nil, amqptransport.ErrPublishNacked, amqptransport.ErrConfirmTimeout} {
+		if have := c.Publish("", "key", false, false, amqp.Publishing{}); want != have {
+			t.Errorf("want %v, have %v", want, have)
+		}
+	}
+
+	close(ch.confirms)
+	for i := 0; ; i++ {
+		err := c.Publish("", "key", false, false, amqp.Publishing{})
+		if errors.Is(err, amqp.ErrClosed) {
+			break
+		}
+		if i > 100 {
+			t.Fatalf("want %v, have %v", amqp.ErrClosed, err)
+		}
+	}
+}
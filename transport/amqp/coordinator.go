@@ -0,0 +1,296 @@
+package amqp
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/inturn/kit/log"
+	"github.com/inturn/kit/transport"
+	"github.com/streadway/amqp"
+)
+
+// QueueInspector reports the state of a queue, like *amqp.Channel or the
+// client returned by NewManagementClient.
+type QueueInspector interface {
+	QueueInspect(name string) (amqp.Queue, error)
+}
+
+// ScaleFunc returns the number of consumers the whole group of members
+// should run on a queue in state q.
+type ScaleFunc func(q amqp.Queue) int
+
+// DepthScaler returns a ScaleFunc targeting a consumer per perConsumer
+// messages ready in the queue, but at least min and at most max consumers.
+// It panics if perConsumer isn't positive.
+func DepthScaler(perConsumer, min, max int) ScaleFunc {
+	if perConsumer <= 0 {
+		panic("amqp: non-positive perConsumer for DepthScaler")
+	}
+	return func(q amqp.Queue) int {
+		n := (q.Messages + perConsumer - 1) / perConsumer
+		if n < min {
+			n = min
+		}
+		if n > max {
+			n = max
+		}
+		return n
+	}
+}
+
+// Membership tells a Coordinator which share of the consumers of the group
+// to run, e.g. backed by a lock service handing out member slots.
+type Membership interface {
+	// Members returns the number of members of the group and the index of
+	// this member among them, from 0.
+	Members(ctx context.Context) (n, i int, err error)
+}
+
+// Coordinator scales the consumers of a queue run by a member process of a
+// group of competing consumers, so the group runs as many consumers as a
+// ScaleFunc targets from the state of the queue. Every interval, it
+// inspects the queue, and starts or stops local consumers, each serving
+// deliveries with the subscriber on its own channel, see Subscriber.Serve.
+//
+// By default, members coordinate through the consumer count of the queue:
+// each member scales its consumers by the ratio of the target to the
+// consumers of the queue, so the group converges to the target without
+// knowing its members. Every member runs at least the minimum set by
+// CoordinatorMinConsumers. With CoordinatorMembership, members split the
+// target evenly instead.
+type Coordinator struct {
+	sub          *Subscriber
+	acquire      ChannelAcquirer
+	inspector    QueueInspector
+	queue        string
+	scale        ScaleFunc
+	membership   Membership
+	min, max     int
+	interval     time.Duration
+	onScale      []func(from, to int)
+	options      []RunnerOption
+	errorHandler transport.ErrorHandler
+
+	mtx       sync.Mutex
+	consumers []*coordinatedConsumer
+}
+
+// coordinatedConsumer is a consumer started by a Coordinator.
+type coordinatedConsumer struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// CoordinatorOption sets an optional parameter for coordinators.
+type CoordinatorOption func(*Coordinator)
+
+// CoordinatorMembership makes members split the target number of consumers
+// evenly, as told by m, instead of coordinating through the consumer count
+// of the queue.
+func CoordinatorMembership(m Membership) CoordinatorOption {
+	return func(c *Coordinator) { c.membership = m }
+}
+
+// CoordinatorMinConsumers sets the minimum number of consumers of the
+// member. The default is 1.
+func CoordinatorMinConsumers(n int) CoordinatorOption {
+	return func(c *Coordinator) { c.min = n }
+}
+
+// CoordinatorMaxConsumers sets the maximum number of consumers of the
+// member. By default, there is no maximum.
+func CoordinatorMaxConsumers(n int) CoordinatorOption {
+	return func(c *Coordinator) { c.max = n }
+}
+
+// CoordinatorInterval sets how often the queue is inspected. The default is
+// 10 seconds.
+func CoordinatorInterval(interval time.Duration) CoordinatorOption {
+	return func(c *Coordinator) { c.interval = interval }
+}
+
+// CoordinatorOnScale adds functions called with the previous and the new
+// number of consumers of the member whenever it changes, e.g. to record it
+// in a gauge.
+func CoordinatorOnScale(f ...func(from, to int)) CoordinatorOption {
+	return func(c *Coordinator) { c.onScale = append(c.onScale, f...) }
+}
+
+// CoordinatorRunnerOptions sets the options of the Runners serving the
+// deliveries of the consumers, e.g. RunnerPrefetch.
+func CoordinatorRunnerOptions(options ...RunnerOption) CoordinatorOption {
+	return func(c *Coordinator) { c.options = append(c.options, options...) }
+}
+
+// CoordinatorErrorHandler is used to handle errors inspecting the queue,
+// acquiring channels and consuming, which Run retries on the next
+// interval. By default, errors are ignored.
+func CoordinatorErrorHandler(errorHandler transport.ErrorHandler) CoordinatorOption {
+	return func(c *Coordinator) { c.errorHandler = errorHandler }
+}
+
+// NewCoordinator returns a Coordinator running consumers of queue, served
+// by sub on channels from acquire, scaled by scale from the state of the
+// queue reported by inspector. Channels implementing io.Closer are closed
+// once their consumer stops.
+func NewCoordinator(
+	sub *Subscriber,
+	acquire ChannelAcquirer,
+	inspector QueueInspector,
+	queue string,
+	scale ScaleFunc,
+	options ...CoordinatorOption,
+) *Coordinator {
+	c := &Coordinator{
+		sub:          sub,
+		acquire:      acquire,
+		inspector:    inspector,
+		queue:        queue,
+		scale:        scale,
+		min:          1,
+		interval:     10 * time.Second,
+		errorHandler: transport.NewLogErrorHandler(log.NewNopLogger()),
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// Run scales the consumers until ctx is done, then drains them, waiting for
+// the deliveries in progress to complete, and returns ctx.Err().
+func (c *Coordinator) Run(ctx context.Context) error {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		if err := c.Scale(ctx); err != nil {
+			c.errorHandler.Handle(ctx, err)
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			c.mtx.Lock()
+			defer c.mtx.Unlock()
+			c.resize(ctx, 0)
+			return ctx.Err()
+		}
+	}
+}
+
+// Scale inspects the queue once and starts or stops consumers to reach the
+// share of the member of the target.
+func (c *Coordinator) Scale(ctx context.Context) error {
+	q, err := c.inspector.QueueInspect(c.queue)
+	if err != nil {
+		return err
+	}
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.reap()
+	n, err := c.share(ctx, q, c.scale(q))
+	if err != nil {
+		return err
+	}
+	if n < c.min {
+		n = c.min
+	}
+	if c.max > 0 && n > c.max {
+		n = c.max
+	}
+	return c.resize(ctx, n)
+}
+
+// Consumers returns the number of consumers the member runs.
+func (c *Coordinator) Consumers() int {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.reap()
+	return len(c.consumers)
+}
+
+// share returns the number of consumers of the member given the group
+// target.
+func (c *Coordinator) share(ctx context.Context, q amqp.Queue, target int) (int, error) {
+	if c.membership != nil {
+		n, i, err := c.membership.Members(ctx)
+		if err != nil {
+			return 0, err
+		}
+		if n < 1 {
+			return target, nil
+		}
+		share := target / n
+		if i < target%n {
+			share++
+		}
+		return share, nil
+	}
+
+	local := len(c.consumers)
+	if q.Consumers == 0 || local == 0 {
+		return target, nil
+	}
+	// Round up, so members don't all scale down to nothing.
+	return (target*local + q.Consumers - 1) / q.Consumers, nil
+}
+
+// resize starts or stops consumers until the member runs n. Stopped
+// consumers drain in the background, except when stopping all of them.
+func (c *Coordinator) resize(ctx context.Context, n int) error {
+	from := len(c.consumers)
+	var err error
+	for len(c.consumers) < n {
+		var ch Channel
+		if ch, err = c.acquire(ctx); err != nil {
+			break
+		}
+		c.consumers = append(c.consumers, c.start(ch))
+	}
+	for len(c.consumers) > n {
+		last := c.consumers[len(c.consumers)-1]
+		c.consumers = c.consumers[:len(c.consumers)-1]
+		last.cancel()
+		if n == 0 {
+			<-last.done
+		}
+	}
+	if to := len(c.consumers); to != from {
+		for _, f := range c.onScale {
+			f(from, to)
+		}
+	}
+	return err
+}
+
+// start starts consuming the queue on ch.
+func (c *Coordinator) start(ch Channel) *coordinatedConsumer {
+	ctx, cancel := context.WithCancel(context.Background())
+	cc := &coordinatedConsumer{cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(cc.done)
+		err := c.sub.Serve(ctx, ch, c.queue, "ctag-"+randomString(16), c.options...)
+		if err != nil && err != context.Canceled {
+			c.errorHandler.Handle(ctx, err)
+		}
+		if closer, ok := ch.(io.Closer); ok {
+			closer.Close()
+		}
+	}()
+	return cc
+}
+
+// reap forgets the consumers that stopped by themselves, e.g. because
+// their channel was closed, so Scale replaces them.
+func (c *Coordinator) reap() {
+	running := c.consumers[:0]
+	for _, cc := range c.consumers {
+		select {
+		case <-cc.done:
+		default:
+			running = append(running, cc)
+		}
+	}
+	c.consumers = running
+}
@@ -0,0 +1,145 @@
+package amqp_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+type queueInspector struct{ q amqp.Queue }
+
+func (i *queueInspector) QueueInspect(name string) (amqp.Queue, error) {
+	q := i.q
+	q.Name = name
+	return q, nil
+}
+
+type staticMembership struct{ n, i int }
+
+func (m staticMembership) Members(context.Context) (int, int, error) { return m.n, m.i, nil }
+
+func newCoordinatedSubscriber() *amqptransport.Subscriber {
+	return amqptransport.NewSubscriber(
+		func(context.Context, interface{}) (interface{}, error) { return struct{}{}, nil },
+		func(context.Context, *amqp.Delivery) (interface{}, error) { return struct{}{}, nil },
+		amqptransport.EncodeNopResponse,
+	)
+}
+
+func acquireCountingChannel(context.Context) (amqptransport.Channel, error) {
+	return &countingChannel{}, nil
+}
+
+func TestDepthScaler(t *testing.T) {
+	scale := amqptransport.DepthScaler(10, 1, 5)
+	for messages, want := range map[int]int{0: 1, 10: 1, 11: 2, 45: 5, 1000: 5} {
+		if have := scale(amqp.Queue{Messages: messages}); want != have {
+			t.Errorf("%d messages: want %d consumers, have %d", messages, want, have)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("want panic for zero perConsumer, have none")
+		}
+	}()
+	amqptransport.DepthScaler(0, 1, 5)
+}
+
+func TestCoordinatorConsumerCount(t *testing.T) {
+	var scaled [][2]int
+	inspector := &queueInspector{q: amqp.Queue{Messages: 100}}
+	c := amqptransport.NewCoordinator(
+		newCoordinatedSubscriber(),
+		acquireCountingChannel,
+		inspector,
+		"orders",
+		amqptransport.DepthScaler(10, 1, 20),
+		amqptransport.CoordinatorOnScale(func(from, to int) { scaled = append(scaled, [2]int{from, to}) }),
+	)
+
+	ctx := context.Background()
+	if err := c.Scale(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := 10, c.Consumers(); want != have {
+		t.Errorf("want %d consumers, have %d", want, have)
+	}
+
+	// Another member runs as many consumers.
+	inspector.q.Consumers = 20
+	if err := c.Scale(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := 5, c.Consumers(); want != have {
+		t.Errorf("want %d consumers, have %d", want, have)
+	}
+
+	want := [][2]int{{0, 10}, {10, 5}}
+	if len(want) != len(scaled) {
+		t.Fatalf("want scalings %v, have %v", want, scaled)
+	}
+	for i := range want {
+		if want[i] != scaled[i] {
+			t.Errorf("want scalings %v, have %v", want, scaled)
+		}
+	}
+}
+
+func TestCoordinatorMembership(t *testing.T) {
+	for _, testcase := range []struct {
+		membership staticMembership
+		want       int
+	}{
+		{staticMembership{n: 3, i: 0}, 4},
+		{staticMembership{n: 3, i: 2}, 3},
+		{staticMembership{n: 20, i: 15}, 1}, // minimum
+	} {
+		c := amqptransport.NewCoordinator(
+			newCoordinatedSubscriber(),
+			acquireCountingChannel,
+			&queueInspector{q: amqp.Queue{Messages: 100}},
+			"orders",
+			amqptransport.DepthScaler(10, 1, 20),
+			amqptransport.CoordinatorMembership(testcase.membership),
+		)
+		if err := c.Scale(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if want, have := testcase.want, c.Consumers(); want != have {
+			t.Errorf("%v: want %d consumers, have %d", testcase.membership, want, have)
+		}
+	}
+}
+
+func TestCoordinatorRun(t *testing.T) {
+	c := amqptransport.NewCoordinator(
+		newCoordinatedSubscriber(),
+		acquireCountingChannel,
+		&queueInspector{q: amqp.Queue{Messages: 30}},
+		"orders",
+		amqptransport.DepthScaler(10, 1, 20),
+		amqptransport.CoordinatorInterval(time.Millisecond),
+		amqptransport.CoordinatorMaxConsumers(2),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- c.Run(ctx) }()
+	time.Sleep(20 * time.Millisecond)
+	if want, have := 2, c.Consumers(); want != have {
+		t.Errorf("want %d consumers, have %d", want, have)
+	}
+
+	cancel()
+	if want, have := context.Canceled, <-errc; want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	if want, have := 0, c.Consumers(); want != have {
+		t.Errorf("want %d consumers after Run, have %d", want, have)
+	}
+}
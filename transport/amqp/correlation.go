@@ -0,0 +1,37 @@
+package amqp
+
+import (
+	"context"
+
+	"github.com/pborman/uuid"
+	"github.com/streadway/amqp"
+)
+
+// EnsureCorrelationID returns a RequestFunc that stores the correlation ID
+// of the delivery in the request context and sets it on the reply. The
+// correlation ID is the CorrelationId of the delivery, its MessageId if it
+// has none, or a new UUID if it has neither, so requests of fire-and-forget
+// producers can be traced as well.
+// It is designed to be used by Subscribers.
+func EnsureCorrelationID() RequestFunc {
+	return func(ctx context.Context, pub *amqp.Publishing, d *amqp.Delivery) context.Context {
+		id := d.CorrelationId
+		if id == "" {
+			id = d.MessageId
+		}
+		if id == "" {
+			id = uuid.New()
+		}
+		if pub.CorrelationId == "" {
+			pub.CorrelationId = id
+		}
+		return context.WithValue(ctx, ContextKeyCorrelationID, id)
+	}
+}
+
+// CorrelationIDFromContext returns the correlation ID stored by
+// EnsureCorrelationID, or the empty string.
+func CorrelationIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ContextKeyCorrelationID).(string)
+	return id
+}
@@ -0,0 +1,39 @@
+package amqp_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pborman/uuid"
+	"github.com/streadway/amqp"
+
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+func TestEnsureCorrelationID(t *testing.T) {
+	for _, testcase := range []struct {
+		name  string
+		deliv amqp.Delivery
+		want  string
+	}{
+		{"correlation ID", amqp.Delivery{CorrelationId: "c", MessageId: "m"}, "c"},
+		{"message ID", amqp.Delivery{MessageId: "m"}, "m"},
+		{"generated", amqp.Delivery{}, ""},
+	} {
+		t.Run(testcase.name, func(t *testing.T) {
+			reply := &amqp.Publishing{}
+			ctx := amqptransport.EnsureCorrelationID()(context.Background(), reply, &testcase.deliv)
+			have := amqptransport.CorrelationIDFromContext(ctx)
+			if want := testcase.want; want == "" {
+				if uuid.Parse(have) == nil {
+					t.Errorf("want UUID, have %q", have)
+				}
+			} else if want != have {
+				t.Errorf("want %q, have %q", want, have)
+			}
+			if want, have := have, reply.CorrelationId; want != have {
+				t.Errorf("incorrect reply correlation ID, want %q, have %q", want, have)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package amqp
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// SetDeadlineFromExpiration returns a RequestFunc that sets the deadline of
+// the request context to the time the delivery expires, so endpoints stop
+// working on requests whose publisher gave up waiting for the reply.
+// Deliveries expire after the milliseconds in their Expiration property,
+// counted from their Timestamp, or from now if they have none. Timestamps in
+// whole seconds, as carried by AMQP, are rounded up, but not past now, so
+// the deadline is late rather than early. The deadline
+// is left alone for deliveries without an Expiration.
+// It is designed to be used by Subscribers.
+func SetDeadlineFromExpiration() RequestFunc {
+	return func(ctx context.Context, pub *amqp.Publishing, d *amqp.Delivery) context.Context {
+		deadline, ok := expiration(d, time.Now())
+		if !ok {
+			return ctx
+		}
+		ctx, cancel := context.WithDeadline(ctx, deadline)
+		// The Subscriber cancels the request context when the delivery is
+		// done, which cancels ctx as well.
+		go func() {
+			<-ctx.Done()
+			cancel()
+		}()
+		return ctx
+	}
+}
+
+// SetExpirationFromDeadline returns a RequestFunc that sets the Expiration
+// and Timestamp of the request to the time left until the deadline of the
+// request context, e.g. the PublisherTimeout, so the broker discards
+// requests nobody waits for anymore, and Subscribers using
+// SetDeadlineFromExpiration stop working on them. Requests without a
+// deadline are left alone.
+// It is designed to be used by Publishers.
+func SetExpirationFromDeadline() RequestFunc {
+	return func(ctx context.Context, pub *amqp.Publishing, d *amqp.Delivery) context.Context {
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			return ctx
+		}
+		now := time.Now()
+		left := deadline.Sub(now) / time.Millisecond
+		if left < 0 {
+			left = 0
+		}
+		pub.Timestamp = now
+		pub.Expiration = strconv.FormatInt(int64(left), 10)
+		return ctx
+	}
+}
+
+// expiration returns the time d expires.
+func expiration(d *amqp.Delivery, now time.Time) (time.Time, bool) {
+	if d.Expiration == "" {
+		return time.Time{}, false
+	}
+	ms, err := strconv.ParseInt(d.Expiration, 10, 64)
+	if err != nil || ms < 0 {
+		return time.Time{}, false
+	}
+	published := d.Timestamp
+	switch {
+	case published.IsZero():
+		published = now
+	case published.Equal(published.Truncate(time.Second)):
+		// AMQP carries timestamps in whole seconds, so the delivery was
+		// published up to a second later. Round up, so the deadline isn't
+		// early, but not past receipt.
+		published = published.Add(time.Second)
+		if published.After(now) {
+			published = now
+		}
+	}
+	return published.Add(time.Duration(ms) * time.Millisecond), true
+}
@@ -0,0 +1,114 @@
+package amqp_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+func TestSetDeadlineFromExpiration(t *testing.T) {
+	published := time.Now().Add(-time.Second)
+	for _, testcase := range []struct {
+		name  string
+		deliv amqp.Delivery
+		want  time.Time
+		ok    bool
+	}{
+		{"none", amqp.Delivery{}, time.Time{}, false},
+		{"invalid", amqp.Delivery{Expiration: "soon"}, time.Time{}, false},
+		{"timestamp", amqp.Delivery{Expiration: "5000", Timestamp: published}, published.Add(5 * time.Second), true},
+		{"expired", amqp.Delivery{Expiration: "500", Timestamp: published}, published.Add(500 * time.Millisecond), true},
+	} {
+		t.Run(testcase.name, func(t *testing.T) {
+			parent, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			ctx := amqptransport.SetDeadlineFromExpiration()(parent, &amqp.Publishing{}, &testcase.deliv)
+			have, ok := ctx.Deadline()
+			if want := testcase.ok; want != ok {
+				t.Fatalf("want deadline %v, have %v", want, ok)
+			}
+			if want := testcase.want; !want.Equal(have) {
+				t.Errorf("incorrect deadline, want %s, have %s", want, have)
+			}
+		})
+	}
+
+	deadline, _ := amqptransport.SetDeadlineFromExpiration()(
+		context.Background(), &amqp.Publishing{}, &amqp.Delivery{Expiration: "1000"},
+	).Deadline()
+	if have := time.Until(deadline); have <= 0 || have > time.Second {
+		t.Errorf("want deadline within a second, have %s", have)
+	}
+}
+
+func TestSetDeadlineFromExpirationTruncatedTimestamp(t *testing.T) {
+	// The timestamp of a message published just now, as it arrives over
+	// AMQP, truncated to the second.
+	now := time.Now()
+	deliv := amqp.Delivery{Expiration: "300", Timestamp: now.Truncate(time.Second)}
+	deadline, ok := amqptransport.SetDeadlineFromExpiration()(context.Background(), &amqp.Publishing{}, &deliv).Deadline()
+	if !ok {
+		t.Fatal("want deadline, have none")
+	}
+	if want := now.Add(300 * time.Millisecond); deadline.Before(want) {
+		t.Errorf("deadline %s before %s", deadline, want)
+	}
+
+	// Whole second timestamps long past still count.
+	old := now.Truncate(time.Second).Add(-10 * time.Second)
+	deliv = amqp.Delivery{Expiration: "5000", Timestamp: old}
+	deadline, _ = amqptransport.SetDeadlineFromExpiration()(context.Background(), &amqp.Publishing{}, &deliv).Deadline()
+	if want := old.Add(6 * time.Second); !want.Equal(deadline) {
+		t.Errorf("want deadline %s, have %s", want, deadline)
+	}
+}
+
+func TestSubscriberDeadline(t *testing.T) {
+	var err error
+	sub := amqptransport.NewSubscriber(
+		func(ctx context.Context, _ interface{}) (interface{}, error) {
+			err = ctx.Err()
+			return nil, nil
+		},
+		func(context.Context, *amqp.Delivery) (interface{}, error) { return nil, nil },
+		amqptransport.EncodeNopResponse,
+		amqptransport.SubscriberBefore(amqptransport.SetDeadlineFromExpiration()),
+	)
+	outputChan := make(chan amqp.Publishing, 1)
+	sub.ServeDelivery(&mockChannel{f: nullFunc, c: outputChan})(&amqp.Delivery{
+		Expiration: "100",
+		Timestamp:  time.Now().Add(-time.Second),
+	})
+	if want, have := context.DeadlineExceeded, err; want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
+
+func TestSetExpirationFromDeadline(t *testing.T) {
+	var pub amqp.Publishing
+	amqptransport.SetExpirationFromDeadline()(context.Background(), &pub, nil)
+	if pub.Expiration != "" {
+		t.Errorf("want no expiration without a deadline, have %q", pub.Expiration)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	amqptransport.SetExpirationFromDeadline()(ctx, &pub, nil)
+	deliv := amqp.Delivery{Expiration: pub.Expiration, Timestamp: pub.Timestamp}
+	have := amqptransport.SetDeadlineFromExpiration()(context.Background(), &amqp.Publishing{}, &deliv)
+	want, _ := ctx.Deadline()
+	if deadline, _ := have.Deadline(); deadline.After(want) || want.Sub(deadline) > 10*time.Millisecond {
+		t.Errorf("want deadline %s, have %s", want, deadline)
+	}
+
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	amqptransport.SetExpirationFromDeadline()(expired, &pub, nil)
+	if want, have := "0", pub.Expiration; want != have {
+		t.Errorf("want expiration %q, have %q", want, have)
+	}
+}
@@ -0,0 +1,122 @@
+package amqp
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// DedupStore records the message IDs of processed deliveries, so that
+// deliveries redelivered after they were processed, e.g. because their
+// acknowledgement was lost, can be skipped. Implementations must be safe
+// for concurrent use.
+type DedupStore interface {
+	// Contains reports whether id was added before.
+	Contains(ctx context.Context, id string) (bool, error)
+
+	// Add records id.
+	Add(ctx context.Context, id string) error
+}
+
+// SubscriberDeduplication makes the subscriber skip deliveries whose
+// MessageId is in store, acknowledging them without calling the endpoint or
+// replying. The MessageId of every delivery served successfully, including
+// its reply, is added to store. Deliveries without a MessageId are always
+// served. If store fails, the error is logged and the delivery is served.
+//
+// Deliveries redelivered while still being served aren't caught, so
+// endpoints should be idempotent regardless.
+func SubscriberDeduplication(store DedupStore) SubscriberOption {
+	return func(s *Subscriber) { s.dedup = store }
+}
+
+// MemoryDedupStore is a DedupStore keeping the most recently added message
+// IDs in memory. It only catches redeliveries to the same instance.
+type MemoryDedupStore struct {
+	mtx   sync.Mutex
+	size  int
+	order *list.List // most recently added first
+	ids   map[string]*list.Element
+}
+
+// NewMemoryDedupStore returns a MemoryDedupStore holding up to size message
+// IDs, evicting the least recently added ones.
+func NewMemoryDedupStore(size int) *MemoryDedupStore {
+	return &MemoryDedupStore{
+		size:  size,
+		order: list.New(),
+		ids:   map[string]*list.Element{},
+	}
+}
+
+// Contains implements DedupStore.
+func (s *MemoryDedupStore) Contains(_ context.Context, id string) (bool, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	_, ok := s.ids[id]
+	return ok, nil
+}
+
+// Add implements DedupStore.
+func (s *MemoryDedupStore) Add(_ context.Context, id string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if e, ok := s.ids[id]; ok {
+		s.order.MoveToFront(e)
+		return nil
+	}
+	s.ids[id] = s.order.PushFront(id)
+	for s.order.Len() > s.size {
+		e := s.order.Back()
+		s.order.Remove(e)
+		delete(s.ids, e.Value.(string))
+	}
+	return nil
+}
+
+// RedisCommander runs commands on a Redis server. Wrap the Do method of
+// your Redis client of choice.
+type RedisCommander interface {
+	Do(ctx context.Context, cmd string, args ...interface{}) (interface{}, error)
+}
+
+// RedisDedupStore is a DedupStore keeping message IDs in Redis, so that
+// redeliveries to any instance of a service are caught.
+type RedisDedupStore struct {
+	client RedisCommander
+	prefix string
+	ttl    time.Duration
+}
+
+// NewRedisDedupStore returns a RedisDedupStore keeping every message ID for
+// ttl, in a key made of prefix and the ID. A ttl of zero or less keeps them
+// until they are evicted by Redis.
+func NewRedisDedupStore(client RedisCommander, prefix string, ttl time.Duration) *RedisDedupStore {
+	return &RedisDedupStore{
+		client: client,
+		prefix: prefix,
+		ttl:    ttl,
+	}
+}
+
+// Contains implements DedupStore.
+func (s *RedisDedupStore) Contains(ctx context.Context, id string) (bool, error) {
+	n, err := s.client.Do(ctx, "EXISTS", s.prefix+id)
+	if err != nil {
+		return false, err
+	}
+	return toInt64(n) > 0, nil
+}
+
+// Add implements DedupStore.
+func (s *RedisDedupStore) Add(ctx context.Context, id string) error {
+	if s.ttl <= 0 {
+		_, err := s.client.Do(ctx, "SET", s.prefix+id, 1)
+		return err
+	}
+	// Redis rejects expirations of zero milliseconds.
+	ms := int64((s.ttl + time.Millisecond - 1) / time.Millisecond)
+	_, err := s.client.Do(ctx, "SET", s.prefix+id, 1, "PX", ms)
+	return err
+}
@@ -0,0 +1,129 @@
+package amqp_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gomodule/redigo/redis"
+	"github.com/streadway/amqp"
+
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+func TestSubscriberDeduplication(t *testing.T) {
+	var calls int
+	sub := amqptransport.NewSubscriber(
+		func(ctx context.Context, request interface{}) (interface{}, error) {
+			calls++
+			return testEndpoint(ctx, request)
+		},
+		testReqDecoder,
+		amqptransport.EncodeJSONResponse,
+		amqptransport.SubscriberDeduplication(amqptransport.NewMemoryDedupStore(10)),
+	)
+	ch := &countingChannel{}
+	acker := &mockAcknowledger{}
+	for _, id := range []string{"a", "b", "a", "", ""} {
+		sub.ServeDelivery(ch)(&amqp.Delivery{
+			Acknowledger: acker,
+			MessageId:    id,
+			Body:         []byte(`{"s":437}`),
+		})
+	}
+
+	if want, have := 4, calls; want != have {
+		t.Errorf("incorrect number of endpoint calls, want %d, have %d", want, have)
+	}
+	if want, have := int32(4), ch.published; want != have {
+		t.Errorf("incorrect number of replies, want %d, have %d", want, have)
+	}
+	if want, have := 1, acker.acks; want != have {
+		t.Errorf("incorrect number of acks, want %d, have %d", want, have)
+	}
+}
+
+func TestMemoryDedupStore(t *testing.T) {
+	ctx := context.Background()
+	s := amqptransport.NewMemoryDedupStore(2)
+	s.Add(ctx, "a")
+	s.Add(ctx, "b")
+	s.Add(ctx, "a") // b is now the least recently added
+	s.Add(ctx, "c")
+
+	for id, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if have, _ := s.Contains(ctx, id); want != have {
+			t.Errorf("%s: want %v, have %v", id, want, have)
+		}
+	}
+}
+
+type redigoCommander struct {
+	conn redis.Conn
+}
+
+func (c redigoCommander) Do(_ context.Context, cmd string, args ...interface{}) (interface{}, error) {
+	return c.conn.Do(cmd, args...)
+}
+
+func TestRedisDedupStore(t *testing.T) {
+	srv, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	conn, err := redis.Dial("tcp", srv.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx := context.Background()
+	s := amqptransport.NewRedisDedupStore(redigoCommander{conn}, "dedup:", time.Minute)
+	if err := s.Add(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	for id, want := range map[string]bool{"a": true, "b": false} {
+		have, err := s.Contains(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want != have {
+			t.Errorf("%s: want %v, have %v", id, want, have)
+		}
+	}
+
+	srv.FastForward(time.Minute)
+	if have, _ := s.Contains(ctx, "a"); have {
+		t.Error("want expired ID, have it")
+	}
+}
+
+func TestRedisDedupStoreTTL(t *testing.T) {
+	srv, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	conn, err := redis.Dial("tcp", srv.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx := context.Background()
+	for _, ttl := range []time.Duration{0, time.Microsecond} {
+		s := amqptransport.NewRedisDedupStore(redigoCommander{conn}, fmt.Sprintf("dedup:%s:", ttl), ttl)
+		if err := s.Add(ctx, "a"); err != nil {
+			t.Errorf("ttl %s: %v", ttl, err)
+		}
+	}
+	if have := srv.TTL("dedup:0s:a"); have != 0 {
+		t.Errorf("want no expiration with zero ttl, have %s", have)
+	}
+	if want, have := time.Millisecond, srv.TTL("dedup:1µs:a"); want != have {
+		t.Errorf("want ttl rounded up to %s, have %s", want, have)
+	}
+}
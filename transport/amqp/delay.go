@@ -0,0 +1,109 @@
+package amqp
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/streadway/amqp"
+
+	"github.com/inturn/kit/util/backoff"
+)
+
+// DelayHeader is the header in which the delayed message exchange plugin
+// expects the delay of a message in milliseconds.
+const DelayHeader = "x-delay"
+
+// TTLRetryErrorEncoder returns an ErrorEncoder retrying failed deliveries
+// up to maxRetries times after a delay, without blocking the consumer. The
+// deliveries are published to waitQueue with the delay as per-message TTL
+// and acknowledged. Once expired, waitQueue dead-letters them back to the
+// work queue, see RetryQueueTopology. Afterwards, they are rejected without
+// requeueing, so they land in the dead letter exchange of the work queue,
+// if it has one. If publishing fails, the delivery is requeued instead.
+//
+// The delay before attempt n is delay(n, ...), with attempts counted by
+// RetryCount. RabbitMQ only expires messages at the head of a queue, so a
+// message with a short delay waits for those ahead of it with longer ones.
+// Use a constant delay, or one wait queue per delay, if that matters.
+func TTLRetryErrorEncoder(maxRetries int, waitQueue string, delay backoff.Strategy) ErrorEncoder {
+	return func(ctx context.Context, err error, deliv *amqp.Delivery, ch Channel, pub *amqp.Publishing) {
+		retryDelayed(deliv, maxRetries, delay, func(d time.Duration) error {
+			retry := publishingFromDelivery(deliv)
+			retry.Expiration = strconv.FormatInt(int64(d/time.Millisecond), 10)
+			return ch.Publish("", waitQueue, false, false, retry)
+		})
+	}
+}
+
+// DelayedExchangeRetryErrorEncoder returns an ErrorEncoder retrying failed
+// deliveries up to maxRetries times after a delay using the delayed message
+// exchange plugin of RabbitMQ. The deliveries are published to exchange,
+// declared with DelayedExchangeTopology, with their routing key and the
+// delay in the DelayHeader, and acknowledged. Afterwards, they are rejected
+// without requeueing, so they land in the dead letter exchange of the work
+// queue, if it has one. If publishing fails, the delivery is requeued
+// instead.
+//
+// The delay before attempt n is delay(n, ...), with attempts counted by
+// RetryCount. Unlike with TTLRetryErrorEncoder, every message waits for its
+// own delay only.
+func DelayedExchangeRetryErrorEncoder(maxRetries int, exchange string, delay backoff.Strategy) ErrorEncoder {
+	return func(ctx context.Context, err error, deliv *amqp.Delivery, ch Channel, pub *amqp.Publishing) {
+		retryDelayed(deliv, maxRetries, delay, func(d time.Duration) error {
+			retry := publishingFromDelivery(deliv)
+			retry.Headers[RetryCountHeader] = toInt64(deliv.Headers[RetryCountHeader]) + 1
+			retry.Headers[DelayHeader] = int64(d / time.Millisecond)
+			return ch.Publish(exchange, deliv.RoutingKey, false, false, retry)
+		})
+	}
+}
+
+// retryDelayed publishes deliv for another attempt, unless it reached
+// maxRetries, and acknowledges it.
+func retryDelayed(deliv *amqp.Delivery, maxRetries int, delay backoff.Strategy, publish func(time.Duration) error) {
+	retries := RetryCount(deliv)
+	if retries >= int64(maxRetries) {
+		deliv.Reject(false) //requeue
+		return
+	}
+
+	if err := publish(retryDelay(delay, int(retries)+1)); err != nil {
+		deliv.Nack(
+			false, //multiple
+			true,  //requeue
+		)
+		return
+	}
+	deliv.Ack(false)
+}
+
+// RetryQueueTopology returns a function declaring the durable waitQueue of
+// TTLRetryErrorEncoder, which dead-letters expired messages to queue
+// through the default exchange. It can be passed to
+// ConnectionManagerTopology.
+func RetryQueueTopology(queue, waitQueue string) func(*amqp.Channel) error {
+	return func(ch *amqp.Channel) error {
+		_, err := ch.QueueDeclare(waitQueue, true, false, false, false, amqp.Table{
+			"x-dead-letter-exchange":    "",
+			"x-dead-letter-routing-key": queue,
+		})
+		return err
+	}
+}
+
+// DelayedExchangeTopology returns a function declaring the durable exchange
+// of DelayedExchangeRetryErrorEncoder, which routes messages like an
+// exchange of type kind, e.g. "direct", after their delay, and binding
+// queue to it with key. It requires the delayed message exchange plugin. It
+// can be passed to ConnectionManagerTopology.
+func DelayedExchangeTopology(exchange, kind, queue, key string) func(*amqp.Channel) error {
+	return func(ch *amqp.Channel) error {
+		if err := ch.ExchangeDeclare(exchange, "x-delayed-message", true, false, false, false, amqp.Table{
+			"x-delayed-type": kind,
+		}); err != nil {
+			return err
+		}
+		return ch.QueueBind(queue, key, exchange, false, nil)
+	}
+}
@@ -0,0 +1,98 @@
+package amqp_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+
+	amqptransport "github.com/inturn/kit/transport/amqp"
+	"github.com/inturn/kit/util/backoff"
+)
+
+func TestTTLRetryErrorEncoder(t *testing.T) {
+	var (
+		exchange, key string
+		outputChan    = make(chan amqp.Publishing, 1)
+		ch            = &mockChannel{
+			f: func(e, k string, mandatory, immediate bool) { exchange, key = e, k },
+			c: outputChan,
+		}
+		ee = amqptransport.TTLRetryErrorEncoder(3, "orders.wait", backoff.Exponential(time.Second))
+	)
+
+	acker := &mockAcknowledger{}
+	deliv := &amqp.Delivery{
+		Acknowledger: acker,
+		RoutingKey:   "orders",
+		Headers: amqp.Table{"x-death": []interface{}{
+			amqp.Table{"queue": "orders.wait", "count": int64(1)},
+		}},
+		Body: []byte("body"),
+	}
+	ee(context.Background(), errors.New("dummy"), deliv, ch, &amqp.Publishing{})
+
+	if want, have := 1, acker.acks; want != have {
+		t.Errorf("incorrect number of acks, want %d, have %d", want, have)
+	}
+	retry := <-outputChan
+	if want, have := " orders.wait", exchange+" "+key; want != have {
+		t.Errorf("incorrect destination, want %q, have %q", want, have)
+	}
+	if want, have := "2000", retry.Expiration; want != have {
+		t.Errorf("incorrect expiration, want %q, have %q", want, have)
+	}
+	if _, ok := retry.Headers[amqptransport.RetryCountHeader]; ok {
+		t.Error("want retries counted by x-death only, have retry count header")
+	}
+
+	acker = &mockAcknowledger{}
+	deliv.Acknowledger = acker
+	deliv.Headers = amqp.Table{"x-death": []interface{}{
+		amqp.Table{"queue": "orders.wait", "count": int64(3)},
+	}}
+	ee(context.Background(), errors.New("dummy"), deliv, ch, &amqp.Publishing{})
+	if want, have := 1, acker.rejects; want != have {
+		t.Errorf("incorrect number of rejects, want %d, have %d", want, have)
+	}
+	if want, have := 0, len(outputChan); want != have {
+		t.Errorf("incorrect number of publishings, want %d, have %d", want, have)
+	}
+}
+
+func TestDelayedExchangeRetryErrorEncoder(t *testing.T) {
+	var (
+		exchange, key string
+		outputChan    = make(chan amqp.Publishing, 1)
+		ch            = &mockChannel{
+			f: func(e, k string, mandatory, immediate bool) { exchange, key = e, k },
+			c: outputChan,
+		}
+		ee = amqptransport.DelayedExchangeRetryErrorEncoder(3, "delayed", backoff.Linear(time.Second))
+	)
+
+	acker := &mockAcknowledger{}
+	deliv := &amqp.Delivery{
+		Acknowledger: acker,
+		Exchange:     "orders",
+		RoutingKey:   "create",
+		Headers:      amqp.Table{amqptransport.RetryCountHeader: int64(2)},
+	}
+	ee(context.Background(), errors.New("dummy"), deliv, ch, &amqp.Publishing{})
+
+	if want, have := 1, acker.acks; want != have {
+		t.Errorf("incorrect number of acks, want %d, have %d", want, have)
+	}
+	retry := <-outputChan
+	if want, have := "delayed create", exchange+" "+key; want != have {
+		t.Errorf("incorrect destination, want %q, have %q", want, have)
+	}
+	if want, have := int64(3000), retry.Headers[amqptransport.DelayHeader]; want != have {
+		t.Errorf("incorrect delay, want %v, have %v", want, have)
+	}
+	if want, have := int64(3), retry.Headers[amqptransport.RetryCountHeader]; want != have {
+		t.Errorf("incorrect retry count, want %v, have %v", want, have)
+	}
+}
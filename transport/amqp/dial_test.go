@@ -0,0 +1,141 @@
+package amqp_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+// writeCertificate writes a self-signed certificate and its key to dir.
+func writeCertificate(t *testing.T, dir string) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "squadron"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IsCA:         true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func TestDialOptionsConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "amqp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	certFile, keyFile := writeCertificate(t, dir)
+
+	config, err := amqptransport.DialOptions{}.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.TLSClientConfig != nil || config.SASL != nil {
+		t.Errorf("want no TLS and the URL credentials, have %v and %v", config.TLSClientConfig, config.SASL)
+	}
+	if want, have := 10*time.Second, config.Heartbeat; want != have {
+		t.Errorf("want heartbeat %s, have %s", want, have)
+	}
+
+	config, err = amqptransport.DialOptions{
+		CAFile:   certFile,
+		CertFile: certFile,
+		KeyFile:  keyFile,
+		Auth:     amqptransport.AuthExternal,
+		Vhost:    "squadrons",
+	}.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.TLSClientConfig == nil || config.TLSClientConfig.RootCAs == nil {
+		t.Fatal("want TLS with custom certificate authorities")
+	}
+	if want, have := 1, len(config.TLSClientConfig.Certificates); want != have {
+		t.Errorf("want %d client certificate, have %d", want, have)
+	}
+	if want, have := "EXTERNAL", config.SASL[0].Mechanism(); want != have {
+		t.Errorf("want mechanism %s, have %s", want, have)
+	}
+	if want, have := "squadrons", config.Vhost; want != have {
+		t.Errorf("want vhost %s, have %s", want, have)
+	}
+
+	config, err = amqptransport.DialOptions{Username: "maverick", Password: "goose"}.Config()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auth, ok := config.SASL[0].(*amqp.PlainAuth); !ok || auth.Username != "maverick" || auth.Password != "goose" {
+		t.Errorf("want plain credentials, have %v", config.SASL)
+	}
+
+	for name, o := range map[string]amqptransport.DialOptions{
+		"external without certificate": {Auth: amqptransport.AuthExternal},
+		"unsupported mechanism":        {Auth: "AMQPLAIN"},
+		"missing CA file":              {CAFile: filepath.Join(dir, "missing.pem")},
+		"invalid CA file":              {CAFile: keyFile},
+	} {
+		if _, err := o.Config(); err == nil {
+			t.Errorf("%s: want error, have none", name)
+		}
+	}
+}
+
+func TestDialTimeout(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		// Accept connections, but never complete the handshake.
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	begin := time.Now()
+	_, err = amqptransport.Dial("amqp://"+ln.Addr().String(), amqptransport.DialOptions{DialTimeout: 50 * time.Millisecond})
+	if err == nil {
+		t.Fatal("want error, have none")
+	}
+	if have := time.Since(begin); have > 5*time.Second {
+		t.Errorf("want handshake to time out, took %s", have)
+	}
+}
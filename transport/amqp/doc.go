@@ -1,2 +1,16 @@
 // Package amqp implements an AMQP transport.
+//
+// A Subscriber serves AMQP deliveries with an endpoint, decoding the
+// request from the delivery and publishing the encoded response to the
+// queue named by its ReplyTo property.
+//
+// A Publisher is the client side of this request/response pattern. Its
+// endpoint publishes the encoded request, by default to the routing key set
+// with SetPublishKey on the default exchange, and waits for the reply with
+// a matching correlation ID on the reply queue it was constructed with,
+// until the PublisherTimeout expires. Publishers constructed with
+// NewDirectReplyToPublisher receive the reply through RabbitMQ's direct
+// reply-to instead, which Subscribers reply to on the default exchange.
+// Publishers sharing a ResponseRouter, set with PublisherResponseRouter,
+// consume a single reply queue for all their concurrent requests.
 package amqp
@@ -0,0 +1,202 @@
+package amqp
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/streadway/amqp"
+)
+
+// EncryptionKeyIDHeader is the header carrying the ID of the key the body
+// of a message was encrypted with by EncryptingEncoder.
+const EncryptionKeyIDHeader = "x-encryption-key-id"
+
+// ErrCiphertextTooShort is returned by DecryptingDecoder for bodies too
+// short to have been encrypted by EncryptingEncoder.
+var ErrCiphertextTooShort = errors.New("ciphertext too short")
+
+// ErrUnencrypted is returned by DecryptingDecoder for deliveries without an
+// EncryptionKeyIDHeader, unless plaintext is allowed by
+// DecryptAllowPlaintext.
+var ErrUnencrypted = errors.New("delivery is not encrypted")
+
+// KeyProvider provides the AES keys of EncryptingEncoder and
+// DecryptingDecoder, e.g. data keys of a KMS. Keys must be 16, 24 or 32
+// bytes long, selecting AES-128, AES-192 or AES-256. Implementations must
+// be safe for concurrent use.
+type KeyProvider interface {
+	// EncryptionKey returns the key to encrypt with, and its ID.
+	EncryptionKey(ctx context.Context) (id string, key []byte, err error)
+
+	// DecryptionKey returns the key with the given ID.
+	DecryptionKey(ctx context.Context, id string) ([]byte, error)
+}
+
+// UnknownKeyError is returned by StaticKeyProvider for unknown key IDs.
+type UnknownKeyError struct {
+	ID string
+}
+
+// Error implements the error interface.
+func (e UnknownKeyError) Error() string {
+	return fmt.Sprintf("unknown encryption key %q", e.ID)
+}
+
+// StaticKeyProvider is a KeyProvider holding its keys in memory. Keys can
+// be rotated by adding a key and making it current, keeping the previous
+// ones to decrypt the messages still in the broker.
+type StaticKeyProvider struct {
+	mtx     sync.RWMutex
+	current string
+	keys    map[string][]byte
+}
+
+// NewStaticKeyProvider returns a StaticKeyProvider encrypting with key,
+// identified by id.
+func NewStaticKeyProvider(id string, key []byte) *StaticKeyProvider {
+	return &StaticKeyProvider{
+		current: id,
+		keys:    map[string][]byte{id: key},
+	}
+}
+
+// Rotate adds key, identified by id, and makes it the key to encrypt with.
+func (p *StaticKeyProvider) Rotate(id string, key []byte) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.keys[id] = key
+	p.current = id
+}
+
+// EncryptionKey implements KeyProvider.
+func (p *StaticKeyProvider) EncryptionKey(context.Context) (string, []byte, error) {
+	p.mtx.RLock()
+	defer p.mtx.RUnlock()
+	return p.current, p.keys[p.current], nil
+}
+
+// DecryptionKey implements KeyProvider.
+func (p *StaticKeyProvider) DecryptionKey(_ context.Context, id string) ([]byte, error) {
+	p.mtx.RLock()
+	defer p.mtx.RUnlock()
+	key, ok := p.keys[id]
+	if !ok {
+		return nil, UnknownKeyError{ID: id}
+	}
+	return key, nil
+}
+
+// EncryptingEncoder returns an encoder encoding with enc, then encrypting
+// the body with AES-GCM under the encryption key of keys, and recording
+// the ID of the key in the EncryptionKeyIDHeader. It can be used both as
+// an EncodeRequestFunc in Publishers and as an EncodeResponseFunc in
+// Subscribers. The body is prefixed with the random nonce, and the key ID
+// is authenticated along with it. Properties and other headers are left
+// in the clear. To compress bodies too, compress them before encrypting:
+//
+//	enc := amqptransport.EncryptingEncoder(amqptransport.CompressRequest(encodeJSON, amqptransport.GzipEncoding, 1024), keys)
+func EncryptingEncoder(
+	enc func(context.Context, *amqp.Publishing, interface{}) error,
+	keys KeyProvider,
+) func(context.Context, *amqp.Publishing, interface{}) error {
+	return func(ctx context.Context, pub *amqp.Publishing, v interface{}) error {
+		if err := enc(ctx, pub, v); err != nil {
+			return err
+		}
+		id, key, err := keys.EncryptionKey(ctx)
+		if err != nil {
+			return err
+		}
+		aead, err := newGCM(key)
+		if err != nil {
+			return err
+		}
+		nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(pub.Body)+aead.Overhead())
+		if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+			return err
+		}
+		pub.Body = aead.Seal(nonce, nonce, pub.Body, []byte(id))
+		if pub.Headers == nil {
+			pub.Headers = amqp.Table{}
+		}
+		pub.Headers[EncryptionKeyIDHeader] = id
+		return nil
+	}
+}
+
+// DecryptOption sets an optional parameter for DecryptingDecoder.
+type DecryptOption func(*decryptConfig)
+
+type decryptConfig struct {
+	allowPlaintext bool
+}
+
+// DecryptAllowPlaintext sets whether deliveries without an
+// EncryptionKeyIDHeader are passed to the decoder as they are, e.g. while
+// publishers are migrated to encryption. By default they are rejected with
+// ErrUnencrypted, so an attacker able to publish can't bypass the
+// encryption by leaving the header out.
+func DecryptAllowPlaintext(allow bool) DecryptOption {
+	return func(c *decryptConfig) { c.allowPlaintext = allow }
+}
+
+// DecryptingDecoder returns a decoder decrypting the body of deliveries
+// with the key of the ID in their EncryptionKeyIDHeader, then decoding them
+// with dec. It can be used both as a DecodeRequestFunc in Subscribers and
+// as a DecodeResponseFunc in Publishers. Deliveries without the header fail
+// with ErrUnencrypted, see DecryptAllowPlaintext. To decompress bodies too,
+// decompress them after decrypting:
+//
+//	dec := amqptransport.DecryptingDecoder(amqptransport.DecompressRequest(decodeJSON), keys)
+func DecryptingDecoder(
+	dec func(context.Context, *amqp.Delivery) (interface{}, error),
+	keys KeyProvider,
+	options ...DecryptOption,
+) func(context.Context, *amqp.Delivery) (interface{}, error) {
+	var c decryptConfig
+	for _, option := range options {
+		option(&c)
+	}
+	return func(ctx context.Context, d *amqp.Delivery) (interface{}, error) {
+		id, ok := d.Headers[EncryptionKeyIDHeader].(string)
+		if !ok {
+			if !c.allowPlaintext {
+				return nil, ErrUnencrypted
+			}
+			return dec(ctx, d)
+		}
+		key, err := keys.DecryptionKey(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := newGCM(key)
+		if err != nil {
+			return nil, err
+		}
+		if len(d.Body) < aead.NonceSize() {
+			return nil, ErrCiphertextTooShort
+		}
+		nonce, ciphertext := d.Body[:aead.NonceSize()], d.Body[aead.NonceSize():]
+		body, err := aead.Open(nil, nonce, ciphertext, []byte(id))
+		if err != nil {
+			return nil, err
+		}
+		decrypted := *d
+		decrypted.Body = body
+		return dec(ctx, &decrypted)
+	}
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,83 @@
+package amqp_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/streadway/amqp"
+
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+func TestEncryptRoundTrip(t *testing.T) {
+	keys := amqptransport.NewStaticKeyProvider("k1", bytes.Repeat([]byte{1}, 32))
+	var enc amqptransport.EncodeRequestFunc = amqptransport.EncryptingEncoder(amqptransport.EncodeJSONRequest, keys)
+	var dec amqptransport.DecodeRequestFunc = amqptransport.DecryptingDecoder(testReqDecoder, keys)
+
+	var pub amqp.Publishing
+	if err := enc(context.Background(), &pub, testReq{Squadron: 437}); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "k1", pub.Headers[amqptransport.EncryptionKeyIDHeader]; want != have {
+		t.Errorf("want key ID %q, have %v", want, have)
+	}
+	if bytes.Contains(pub.Body, []byte("437")) {
+		t.Errorf("body not encrypted: %q", pub.Body)
+	}
+
+	// Messages encrypted with a rotated key still decrypt.
+	keys.Rotate("k2", bytes.Repeat([]byte{2}, 16))
+	req, err := dec(context.Background(), &amqp.Delivery{Headers: pub.Headers, Body: pub.Body})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := 437, req.(testReq).Squadron; want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+
+	// Tampering with the key ID fails authentication, even with the same
+	// key bytes.
+	keys.Rotate("k3", bytes.Repeat([]byte{1}, 32))
+	tampered := amqp.Table{amqptransport.EncryptionKeyIDHeader: "k3"}
+	if _, err := dec(context.Background(), &amqp.Delivery{Headers: tampered, Body: pub.Body}); err == nil {
+		t.Error("want authentication error, have none")
+	}
+}
+
+func TestDecryptingDecoderErrors(t *testing.T) {
+	keys := amqptransport.NewStaticKeyProvider("k1", bytes.Repeat([]byte{1}, 32))
+	dec := amqptransport.DecryptingDecoder(testReqDecoder, keys)
+
+	_, err := dec(context.Background(), &amqp.Delivery{Body: []byte(`{"s":424}`)})
+	if want, have := amqptransport.ErrUnencrypted, err; want != have {
+		t.Errorf("unencrypted delivery: want %v, have %v", want, have)
+	}
+
+	plain := amqptransport.DecryptingDecoder(testReqDecoder, keys, amqptransport.DecryptAllowPlaintext(true))
+	req, err := plain(context.Background(), &amqp.Delivery{Body: []byte(`{"s":424}`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := 424, req.(testReq).Squadron; want != have {
+		t.Errorf("unencrypted delivery: want %d, have %d", want, have)
+	}
+
+	_, err = dec(context.Background(), &amqp.Delivery{
+		Headers: amqp.Table{amqptransport.EncryptionKeyIDHeader: "k9"},
+		Body:    []byte("ciphertext"),
+	})
+	var unknown amqptransport.UnknownKeyError
+	if !errors.As(err, &unknown) || unknown.ID != "k9" {
+		t.Errorf("want UnknownKeyError for k9, have %v", err)
+	}
+
+	_, err = dec(context.Background(), &amqp.Delivery{
+		Headers: amqp.Table{amqptransport.EncryptionKeyIDHeader: "k1"},
+		Body:    []byte("short"),
+	})
+	if want, have := amqptransport.ErrCiphertextTooShort, err; want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
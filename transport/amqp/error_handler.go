@@ -0,0 +1,49 @@
+package amqp
+
+import (
+	"context"
+
+	"github.com/inturn/kit/transport"
+)
+
+// ErrorStage is the stage of serving a delivery in which an error passed to
+// the subscriber's ErrorHandler occurred.
+type ErrorStage string
+
+// Stages of serving a delivery.
+const (
+	ErrorStageDecode      ErrorStage = "decode"
+	ErrorStageValidate    ErrorStage = "validate"
+	ErrorStageEndpoint    ErrorStage = "endpoint"
+	ErrorStageEncode      ErrorStage = "encode"
+	ErrorStagePublish     ErrorStage = "publish"
+	ErrorStageAcknowledge ErrorStage = "acknowledge"
+	ErrorStageDeduplicate ErrorStage = "deduplicate"
+	ErrorStagePanic       ErrorStage = "panic"
+	ErrorStageCancel      ErrorStage = "cancel"
+	ErrorStageOffset      ErrorStage = "offset"
+	ErrorStageThrottle    ErrorStage = "throttle"
+)
+
+// ErrorStageFromContext returns the stage in which the error passed to an
+// ErrorHandler along with ctx occurred, e.g. to tell failures of the
+// endpoint from those of the transport.
+func ErrorStageFromContext(ctx context.Context) ErrorStage {
+	stage, _ := ctx.Value(ContextKeyErrorStage).(ErrorStage)
+	return stage
+}
+
+// SubscriberErrorHandler is used to handle non-terminal errors. By default,
+// non-terminal errors are ignored. This is intended as a diagnostic measure.
+// Finer-grained control of error handling, including logging in more
+// detail, should be performed in a custom SubscriberErrorEncoder which has
+// access to the context.
+func SubscriberErrorHandler(errorHandler transport.ErrorHandler) SubscriberOption {
+	return func(s *Subscriber) { s.errorHandler = errorHandler }
+}
+
+// handleError passes err, which occurred in stage, to the error handler of
+// the subscriber.
+func (s Subscriber) handleError(ctx context.Context, stage ErrorStage, err error) {
+	s.errorHandler.Handle(context.WithValue(ctx, ContextKeyErrorStage, stage), err)
+}
@@ -0,0 +1,71 @@
+package amqp_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/streadway/amqp"
+
+	"github.com/inturn/kit/log"
+	"github.com/inturn/kit/transport"
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+func TestSubscriberErrorHandler(t *testing.T) {
+	var stages []amqptransport.ErrorStage
+	handler := transport.ErrorHandlerFunc(func(ctx context.Context, err error) {
+		stages = append(stages, amqptransport.ErrorStageFromContext(ctx))
+	})
+
+	sub := amqptransport.NewSubscriber(
+		testEndpoint,
+		testReqDecoder,
+		func(_ context.Context, _ *amqp.Publishing, response interface{}) error {
+			if response.(testRes).Name == "husky" {
+				return errors.New("dummy")
+			}
+			return nil
+		},
+		amqptransport.SubscriberErrorHandler(handler),
+	)
+	for _, body := range []string{`not json`, `{"s":1}`, `{"s":437}`, `{"s":436}`} {
+		sub.ServeDelivery(&countingChannel{})(&amqp.Delivery{Body: []byte(body)})
+	}
+
+	want := []amqptransport.ErrorStage{
+		amqptransport.ErrorStageDecode,
+		amqptransport.ErrorStageEndpoint,
+		amqptransport.ErrorStageEncode,
+	}
+	if len(want) != len(stages) {
+		t.Fatalf("want stages %v, have %v", want, stages)
+	}
+	for i := range want {
+		if want[i] != stages[i] {
+			t.Errorf("want stages %v, have %v", want, stages)
+		}
+	}
+}
+
+func TestSubscriberErrorHandlerLogsPanicStack(t *testing.T) {
+	var keyvals []interface{}
+	logger := log.LoggerFunc(func(kv ...interface{}) error {
+		keyvals = kv
+		return nil
+	})
+	amqptransport.NewSubscriber(
+		func(context.Context, interface{}) (interface{}, error) { panic("dummy") },
+		testReqDecoder,
+		amqptransport.EncodeJSONResponse,
+		amqptransport.SubscriberErrorHandler(transport.NewLogErrorHandler(logger)),
+	).ServeDelivery(&countingChannel{})(&amqp.Delivery{Acknowledger: &mockAcknowledger{}, Body: []byte(`{"s":437}`)})
+
+	if len(keyvals) != 4 || keyvals[2] != "stack" {
+		t.Fatalf("want err and stack logged, have %v", keyvals)
+	}
+	if stack := keyvals[3].(string); !strings.Contains(stack, "TestSubscriberErrorHandlerLogsPanicStack") {
+		t.Errorf("want stack of the panic, have %s", stack)
+	}
+}
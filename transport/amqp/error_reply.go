@@ -0,0 +1,113 @@
+package amqp
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/streadway/amqp"
+)
+
+// ErrorCodeHeader is the header of error replies carrying the error code,
+// see StructuredReplyErrorEncoder.
+const ErrorCodeHeader = "x-error-code"
+
+// DefaultErrorCode is the code of error replies for errors not implementing
+// ErrorCoder.
+const DefaultErrorCode = "internal"
+
+// ErrorCoder is checked by StructuredReplyErrorEncoder. If an error value
+// implements ErrorCoder, its ErrorCode is the code of the error reply.
+// By default, DefaultErrorCode is used.
+type ErrorCoder interface {
+	ErrorCode() string
+}
+
+// Headerer is checked by StructuredReplyErrorEncoder. If an error value
+// implements Headerer, the provided headers are added to the error reply.
+type Headerer interface {
+	Headers() amqp.Table
+}
+
+// ErrorResponse is the structure of error replies published by
+// StructuredReplyErrorEncoder. It extends DefaultErrorResponse with the
+// error code.
+type ErrorResponse struct {
+	Error string `json:"err"`
+	Code  string `json:"code"`
+}
+
+// StructuredReplyErrorEncoder serializes the error as an ErrorResponse JSON
+// and sends it to the ReplyTo address, setting the ErrorCodeHeader and the
+// headers of errors implementing Headerer, so clients can branch on the
+// error code without parsing the body; see DecodeReplyError.
+func StructuredReplyErrorEncoder(
+	ctx context.Context,
+	err error,
+	deliv *amqp.Delivery,
+	ch Channel,
+	pub *amqp.Publishing,
+) {
+	code := DefaultErrorCode
+	if coder, ok := err.(ErrorCoder); ok {
+		code = coder.ErrorCode()
+	}
+
+	b, merr := json.Marshal(ErrorResponse{Error: err.Error(), Code: code})
+	if merr != nil {
+		return
+	}
+	pub.Body = b
+	pub.ContentType = "application/json"
+
+	if pub.Headers == nil {
+		pub.Headers = amqp.Table{}
+	}
+	if headerer, ok := err.(Headerer); ok {
+		for k, v := range headerer.Headers() {
+			pub.Headers[k] = v
+		}
+	}
+	pub.Headers[ErrorCodeHeader] = code
+
+	publishReply(ctx, deliv, ch, pub)
+}
+
+// ReplyError is an error reply published by StructuredReplyErrorEncoder.
+type ReplyError struct {
+	Code    string
+	Message string
+	Headers amqp.Table
+}
+
+// Error implements the error interface.
+func (e ReplyError) Error() string {
+	return e.Message
+}
+
+// ErrorCode implements ErrorCoder, so errors can be passed on with their
+// code.
+func (e ReplyError) ErrorCode() string {
+	return e.Code
+}
+
+// DecodeReplyError returns the ReplyError of a reply published by
+// StructuredReplyErrorEncoder, or nil if d isn't an error reply. It is
+// designed to be used in the DecodeResponseFunc of Publishers:
+//
+//	func decodeResponse(ctx context.Context, d *amqp.Delivery) (interface{}, error) {
+//		if err := amqptransport.DecodeReplyError(d); err != nil {
+//			return nil, err
+//		}
+//		...
+//	}
+func DecodeReplyError(d *amqp.Delivery) error {
+	code, ok := d.Headers[ErrorCodeHeader].(string)
+	if !ok {
+		return nil
+	}
+	var res ErrorResponse
+	if err := json.Unmarshal(d.Body, &res); err != nil {
+		return err
+	}
+	return ReplyError{Code: code, Message: res.Error, Headers: d.Headers}
+}
@@ -0,0 +1,71 @@
+package amqp_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/streadway/amqp"
+
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+type notFoundError struct{}
+
+func (notFoundError) Error() string       { return "squadron not found" }
+func (notFoundError) ErrorCode() string   { return "not_found" }
+func (notFoundError) Headers() amqp.Table { return amqp.Table{"x-retryable": false} }
+
+func TestStructuredReplyErrorEncoder(t *testing.T) {
+	for _, testcase := range []struct {
+		name     string
+		err      error
+		code     string
+		headerer bool
+	}{
+		{"plain", errors.New("dummy"), amqptransport.DefaultErrorCode, false},
+		{"coded", notFoundError{}, "not_found", true},
+		{"invalid", amqptransport.ValidationError{Err: errors.New("dummy")}, "invalid_request", false},
+	} {
+		t.Run(testcase.name, func(t *testing.T) {
+			var key string
+			outputChan := make(chan amqp.Publishing, 1)
+			ch := &mockChannel{
+				f: func(exchange, k string, mandatory, immediate bool) { key = k },
+				c: outputChan,
+			}
+			pub := &amqp.Publishing{}
+			deliv := &amqp.Delivery{ReplyTo: "replies", CorrelationId: "cid"}
+			amqptransport.StructuredReplyErrorEncoder(context.Background(), testcase.err, deliv, ch, pub)
+			reply := <-outputChan
+
+			if want, have := "replies", key; want != have {
+				t.Errorf("want key %q, have %q", want, have)
+			}
+			if want, have := "cid", reply.CorrelationId; want != have {
+				t.Errorf("want correlation ID %q, have %q", want, have)
+			}
+			if _, have := reply.Headers["x-retryable"]; testcase.headerer != have {
+				t.Errorf("want error headers %v, have %v", testcase.headerer, have)
+			}
+
+			err := amqptransport.DecodeReplyError(&amqp.Delivery{Headers: reply.Headers, Body: reply.Body})
+			replyErr, ok := err.(amqptransport.ReplyError)
+			if !ok {
+				t.Fatalf("want ReplyError, have %v", err)
+			}
+			if want, have := testcase.code, replyErr.ErrorCode(); want != have {
+				t.Errorf("want code %q, have %q", want, have)
+			}
+			if want, have := testcase.err.Error(), replyErr.Error(); want != have {
+				t.Errorf("want message %q, have %q", want, have)
+			}
+		})
+	}
+}
+
+func TestDecodeReplyErrorNoError(t *testing.T) {
+	if err := amqptransport.DecodeReplyError(&amqp.Delivery{Body: []byte(`{"s":436}`)}); err != nil {
+		t.Errorf("want no error, have %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package amqp
+
+import (
+	"context"
+	"strings"
+
+	"github.com/streadway/amqp"
+)
+
+// HeaderFilter selects the AMQP headers propagated by PropagateHeaders.
+type HeaderFilter func(name string) bool
+
+// HeaderNames selects the headers named names.
+func HeaderNames(names ...string) HeaderFilter {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	return func(name string) bool { return set[name] }
+}
+
+// HeaderPrefix selects the headers starting with one of prefixes, e.g.
+// "x-tenant-".
+func HeaderPrefix(prefixes ...string) HeaderFilter {
+	return func(name string) bool {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// PropagateHeaders returns a RequestFunc that copies the headers of the
+// delivery selected by f to the reply and to the request context, where
+// HeadersFromContext returns them and SetPublishHeadersFromContext passes
+// them on to the requests of Publishers called by the endpoint.
+// It is designed to be used by Subscribers.
+func PropagateHeaders(f HeaderFilter) RequestFunc {
+	return func(ctx context.Context, pub *amqp.Publishing, d *amqp.Delivery) context.Context {
+		headers := amqp.Table{}
+		for k, v := range HeadersFromContext(ctx) {
+			headers[k] = v
+		}
+		for k, v := range d.Headers {
+			if f(k) {
+				headers[k] = v
+			}
+		}
+		if len(headers) == 0 {
+			return ctx
+		}
+		setHeaders(pub, headers)
+		return context.WithValue(ctx, ContextKeyHeaders, headers)
+	}
+}
+
+// HeadersFromContext returns the headers propagated by PropagateHeaders.
+// The table must not be modified.
+func HeadersFromContext(ctx context.Context) amqp.Table {
+	headers, _ := ctx.Value(ContextKeyHeaders).(amqp.Table)
+	return headers
+}
+
+// SetPublishHeadersFromContext returns a RequestFunc that sets the headers
+// propagated by PropagateHeaders on the outgoing Publishing, unless they
+// are set already.
+// It is designed to be used by Publishers.
+func SetPublishHeadersFromContext() RequestFunc {
+	return func(ctx context.Context, pub *amqp.Publishing, d *amqp.Delivery) context.Context {
+		setHeaders(pub, HeadersFromContext(ctx))
+		return ctx
+	}
+}
+
+func setHeaders(pub *amqp.Publishing, headers amqp.Table) {
+	if len(headers) == 0 {
+		return
+	}
+	if pub.Headers == nil {
+		pub.Headers = make(amqp.Table, len(headers))
+	}
+	for k, v := range headers {
+		if _, ok := pub.Headers[k]; !ok {
+			pub.Headers[k] = v
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package amqp_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/streadway/amqp"
+
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+func TestPropagateHeaders(t *testing.T) {
+	deliv := &amqp.Delivery{Headers: amqp.Table{
+		"x-request-id": "r1",
+		"x-tenant-id":  "t1",
+		"x-tenant-eu":  true,
+		"other":        "o",
+	}}
+	reply := &amqp.Publishing{}
+	ctx := context.Background()
+	ctx = amqptransport.PropagateHeaders(amqptransport.HeaderNames("x-request-id"))(ctx, reply, deliv)
+	ctx = amqptransport.PropagateHeaders(amqptransport.HeaderPrefix("x-tenant-"))(ctx, reply, deliv)
+
+	want := amqp.Table{"x-request-id": "r1", "x-tenant-id": "t1", "x-tenant-eu": true}
+	for name, table := range map[string]amqp.Table{
+		"context": amqptransport.HeadersFromContext(ctx),
+		"reply":   reply.Headers,
+	} {
+		if len(want) != len(table) {
+			t.Errorf("%s: want %v, have %v", name, want, table)
+		}
+		for k, v := range want {
+			if table[k] != v {
+				t.Errorf("%s: want %v, have %v", name, want, table)
+			}
+		}
+	}
+
+	pub := &amqp.Publishing{Headers: amqp.Table{"x-request-id": "r2"}}
+	amqptransport.SetPublishHeadersFromContext()(ctx, pub, nil)
+	if want, have := "r2", pub.Headers["x-request-id"]; want != have {
+		t.Errorf("incorrect request ID, want %v, have %v", want, have)
+	}
+	if want, have := "t1", pub.Headers["x-tenant-id"]; want != have {
+		t.Errorf("incorrect tenant ID, want %v, have %v", want, have)
+	}
+}
@@ -0,0 +1,37 @@
+package amqp
+
+import (
+	"time"
+
+	"github.com/inturn/kit/metrics"
+)
+
+// SubscriberInstrumentation makes the subscriber count every delivery in
+// requests and every delivery it failed to handle in failures, and observe
+// the time in seconds from receipt of every delivery until it was handled,
+// including publishing the reply and running the finalizers, in latency.
+// All metrics are recorded with the label value "routing_key".
+func SubscriberInstrumentation(requests, failures metrics.Counter, latency metrics.Histogram) SubscriberOption {
+	return func(s *Subscriber) {
+		s.instrumentation = &instrumentation{
+			requests: requests,
+			failures: failures,
+			latency:  latency,
+		}
+	}
+}
+
+type instrumentation struct {
+	requests metrics.Counter
+	failures metrics.Counter
+	latency  metrics.Histogram
+}
+
+func (i *instrumentation) observe(routingKey string, begin time.Time, err error) {
+	lvs := []string{"routing_key", routingKey}
+	i.requests.With(lvs...).Add(1)
+	if err != nil {
+		i.failures.With(lvs...).Add(1)
+	}
+	i.latency.With(lvs...).Observe(time.Since(begin).Seconds())
+}
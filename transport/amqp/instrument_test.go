@@ -0,0 +1,94 @@
+package amqp_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/streadway/amqp"
+
+	"github.com/inturn/kit/metrics"
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+// recorder records metric values by label values.
+type recorder struct {
+	mtx    sync.Mutex
+	values map[string][]float64
+}
+
+func newRecorder() *recorder { return &recorder{values: map[string][]float64{}} }
+
+func (r *recorder) record(lvs []string, v float64) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	key := strings.Join(lvs, ",")
+	r.values[key] = append(r.values[key], v)
+}
+
+func (r *recorder) get(lvs ...string) []float64 {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.values[strings.Join(lvs, ",")]
+}
+
+type recordingCounter struct {
+	r   *recorder
+	lvs []string
+}
+
+func (c recordingCounter) With(lvs ...string) metrics.Counter {
+	return recordingCounter{c.r, append(c.lvs[:len(c.lvs):len(c.lvs)], lvs...)}
+}
+
+func (c recordingCounter) Add(delta float64) { c.r.record(c.lvs, delta) }
+
+type recordingHistogram struct {
+	r   *recorder
+	lvs []string
+}
+
+func (h recordingHistogram) With(lvs ...string) metrics.Histogram {
+	return recordingHistogram{h.r, append(h.lvs[:len(h.lvs):len(h.lvs)], lvs...)}
+}
+
+func (h recordingHistogram) Observe(value float64) { h.r.record(h.lvs, value) }
+
+func TestSubscriberInstrumentation(t *testing.T) {
+	requests, failures, latency := newRecorder(), newRecorder(), newRecorder()
+	sub := amqptransport.NewSubscriber(
+		testEndpoint,
+		testReqDecoder,
+		amqptransport.EncodeJSONResponse,
+		amqptransport.SubscriberInstrumentation(
+			recordingCounter{r: requests},
+			recordingCounter{r: failures},
+			recordingHistogram{r: latency},
+		),
+	)
+	serve := sub.ServeDelivery(&countingChannel{})
+	serve(&amqp.Delivery{RoutingKey: "squadrons.get", Body: []byte(`{"s":436}`)})
+	serve(&amqp.Delivery{RoutingKey: "squadrons.get", Body: []byte(`{"s":1}`)})
+	serve(&amqp.Delivery{RoutingKey: "squadrons.list", Body: []byte(`{"s":437}`)})
+
+	for _, tc := range []struct {
+		r    *recorder
+		key  string
+		want int
+	}{
+		{requests, "squadrons.get", 2},
+		{failures, "squadrons.get", 1},
+		{latency, "squadrons.get", 2},
+		{requests, "squadrons.list", 1},
+		{failures, "squadrons.list", 0},
+	} {
+		if want, have := tc.want, len(tc.r.get("routing_key", tc.key)); want != have {
+			t.Errorf("%s: want %d observations, have %d", tc.key, want, have)
+		}
+	}
+	for _, v := range latency.get("routing_key", "squadrons.get") {
+		if v <= 0 {
+			t.Errorf("want positive latency, have %f", v)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package amqp
+
+import (
+	"context"
+
+	"github.com/inturn/kit/log"
+	"github.com/streadway/amqp"
+)
+
+// SubscriberDeliveryLogger makes the subscriber put a logger in the context
+// of every delivery it serves, before the RequestFuncs run. The logger is
+// logger with the identity of the delivery as keyvals, see DeliveryLogger,
+// so everything the endpoint logs with LoggerFromContext can be traced back
+// to the delivery. Pair it with NewDeliveryLogErrorHandler to log the
+// subscriber's errors the same way.
+func SubscriberDeliveryLogger(logger log.Logger) SubscriberOption {
+	return func(s *Subscriber) { s.deliveryLogger = logger }
+}
+
+// DeliveryLogger returns logger with the exchange, routing key,
+// correlation ID, message ID and redelivered flag of deliv as keyvals.
+func DeliveryLogger(logger log.Logger, deliv *amqp.Delivery) log.Logger {
+	return log.With(logger,
+		"exchange", deliv.Exchange,
+		"key", deliv.RoutingKey,
+		"correlation_id", deliv.CorrelationId,
+		"message_id", deliv.MessageId,
+		"redelivered", deliv.Redelivered,
+	)
+}
+
+// ContextWithLogger returns a copy of ctx carrying logger.
+func ContextWithLogger(ctx context.Context, logger log.Logger) context.Context {
+	return context.WithValue(ctx, ContextKeyLogger, logger)
+}
+
+// LoggerFromContext returns the logger in ctx, set by
+// SubscriberDeliveryLogger or ContextWithLogger, or a nop logger if there is
+// none.
+func LoggerFromContext(ctx context.Context) log.Logger {
+	if logger, ok := ctx.Value(ContextKeyLogger).(log.Logger); ok {
+		return logger
+	}
+	return log.NewNopLogger()
+}
+
+// DeliveryLogErrorHandler is a transport.ErrorHandler logging errors, with
+// their ErrorStage, to the logger in their context, so they carry the
+// identity of the delivery when the subscriber is configured with
+// SubscriberDeliveryLogger.
+type DeliveryLogErrorHandler struct {
+	logger log.Logger
+}
+
+// NewDeliveryLogErrorHandler returns a DeliveryLogErrorHandler logging to
+// logger errors whose context carries no logger.
+func NewDeliveryLogErrorHandler(logger log.Logger) *DeliveryLogErrorHandler {
+	return &DeliveryLogErrorHandler{logger: logger}
+}
+
+// Handle implements transport.ErrorHandler.
+func (h *DeliveryLogErrorHandler) Handle(ctx context.Context, err error) {
+	logger, ok := ctx.Value(ContextKeyLogger).(log.Logger)
+	if !ok {
+		logger = h.logger
+	}
+	if stage := ErrorStageFromContext(ctx); stage != "" {
+		logger.Log("stage", stage, "err", err)
+		return
+	}
+	logger.Log("err", err)
+}
@@ -0,0 +1,56 @@
+package amqp_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/streadway/amqp"
+
+	"github.com/inturn/kit/log"
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+func TestSubscriberDeliveryLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.NewLogfmtLogger(&buf)
+	sub := amqptransport.NewSubscriber(
+		func(ctx context.Context, request interface{}) (interface{}, error) {
+			amqptransport.LoggerFromContext(ctx).Log("msg", "serving")
+			return nil, errors.New("dummy")
+		},
+		func(context.Context, *amqp.Delivery) (interface{}, error) { return struct{}{}, nil },
+		amqptransport.EncodeNopResponse,
+		amqptransport.SubscriberDeliveryLogger(logger),
+		amqptransport.SubscriberErrorHandler(amqptransport.NewDeliveryLogErrorHandler(log.NewNopLogger())),
+	)
+	sub.ServeDelivery(&countingChannel{})(&amqp.Delivery{
+		Exchange:      "fleet",
+		RoutingKey:    "squadrons",
+		CorrelationId: "c1",
+		MessageId:     "m1",
+		Redelivered:   true,
+	})
+
+	want := []string{
+		"exchange=fleet key=squadrons correlation_id=c1 message_id=m1 redelivered=true msg=serving",
+		"exchange=fleet key=squadrons correlation_id=c1 message_id=m1 redelivered=true stage=endpoint err=dummy",
+	}
+	have := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(want) != len(have) {
+		t.Fatalf("want %d lines, have %q", len(want), have)
+	}
+	for i := range want {
+		if want[i] != have[i] {
+			t.Errorf("line %d: want %q, have %q", i, want[i], have[i])
+		}
+	}
+}
+
+func TestLoggerFromContextDefault(t *testing.T) {
+	if err := amqptransport.LoggerFromContext(context.Background()).Log("msg", "dropped"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,402 @@
+package amqp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/streadway/amqp"
+
+	"github.com/inturn/kit/log"
+	"github.com/inturn/kit/util/backoff"
+)
+
+// ErrNotConnected is returned by ConnectionManager.Publish while the
+// connection to the broker is down.
+var ErrNotConnected = errors.New("not connected to AMQP broker")
+
+// ErrManagerClosed is returned by ConnectionManager methods called after
+// Close.
+var ErrManagerClosed = errors.New("connection manager closed")
+
+// Dialer returns a new AMQP connection, e.g.
+//
+//	func() (*amqp.Connection, error) { return amqp.Dial(url) }
+type Dialer func() (*amqp.Connection, error)
+
+// ConnectionManager owns an AMQP connection and re-dials it with backoff
+// whenever it is closed, e.g. because the broker restarted. After every
+// dial, it re-declares the topology and re-registers the consumers.
+//
+// ConnectionManager implements Channel, so Subscribers and Publishers can
+// use it in place of an *amqp.Channel. The delivery channels returned by
+// Consume stay open across reconnects until the manager is closed, so a
+// Runner keeps serving them. Deliveries received before a reconnect can't
+// be acknowledged afterwards; the broker redelivers them.
+type ConnectionManager struct {
+	dial     func() (connection, error)
+	strategy backoff.Strategy
+	topology []func(*amqp.Channel) error
+	logger   log.Logger
+
+	mtx       sync.Mutex
+	conn      connection
+	lost      chan struct{}  // closed when conn is closed
+	ch        managedChannel // shared by publishes
+	chClosed  chan *amqp.Error
+	consumers []*managedConsumer
+	closed    bool
+
+	done chan struct{}
+	wg   sync.WaitGroup // run loop and consumers
+}
+
+type managedConsumer struct {
+	queue, consumer                     string
+	autoAck, exclusive, noLocal, noWait bool
+	args                                amqp.Table
+	out                                 chan amqp.Delivery
+}
+
+// ConnectionManagerOption sets an optional parameter for
+// NewConnectionManager.
+type ConnectionManagerOption func(*ConnectionManager)
+
+// ConnectionManagerBackoff sets the strategy spacing out attempts to dial
+// the broker and to restart consumers. The default is an exponential
+// backoff starting at a second, capped at 30 seconds.
+func ConnectionManagerBackoff(s backoff.Strategy) ConnectionManagerOption {
+	return func(m *ConnectionManager) { m.strategy = s }
+}
+
+// ConnectionManagerTopology adds a function declaring exchanges, queues and
+// bindings, which is called with a channel of every new connection before
+// consumers are registered.
+func ConnectionManagerTopology(declare func(*amqp.Channel) error) ConnectionManagerOption {
+	return func(m *ConnectionManager) { m.topology = append(m.topology, declare) }
+}
+
+// ConnectionManagerLogger sets the logger for connection errors. By
+// default, no errors are logged.
+func ConnectionManagerLogger(logger log.Logger) ConnectionManagerOption {
+	return func(m *ConnectionManager) { m.logger = logger }
+}
+
+// connection is the part of an *amqp.Connection used by ConnectionManager.
+type connection interface {
+	channel() (managedChannel, error)
+	NotifyClose(c chan *amqp.Error) chan *amqp.Error
+	Close() error
+}
+
+// managedChannel is the part of an *amqp.Channel used by ConnectionManager.
+type managedChannel interface {
+	Channel
+	NotifyClose(c chan *amqp.Error) chan *amqp.Error
+	Close() error
+	declare(topology []func(*amqp.Channel) error) error
+}
+
+type amqpConnection struct {
+	*amqp.Connection
+}
+
+func (c amqpConnection) channel() (managedChannel, error) {
+	ch, err := c.Channel()
+	if err != nil {
+		return nil, err
+	}
+	return amqpChannel{ch}, nil
+}
+
+type amqpChannel struct {
+	*amqp.Channel
+}
+
+func (ch amqpChannel) declare(topology []func(*amqp.Channel) error) error {
+	for _, declare := range topology {
+		if err := declare(ch.Channel); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewConnectionManager returns a ConnectionManager dialing connections with
+// dial. It dials in the background; Publish fails with ErrNotConnected
+// until the connection is up. Close it when it is no longer used.
+func NewConnectionManager(dial Dialer, options ...ConnectionManagerOption) *ConnectionManager {
+	return newConnectionManager(func() (connection, error) {
+		conn, err := dial()
+		if err != nil {
+			return nil, err
+		}
+		return amqpConnection{conn}, nil
+	}, options...)
+}
+
+func newConnectionManager(dial func() (connection, error), options ...ConnectionManagerOption) *ConnectionManager {
+	m := &ConnectionManager{
+		dial:     dial,
+		strategy: backoff.Capped(backoff.Jitter(backoff.Exponential(time.Second), 0.5), 30*time.Second),
+		logger:   log.NewNopLogger(),
+		done:     make(chan struct{}),
+	}
+	for _, option := range options {
+		option(m)
+	}
+	m.wg.Add(1)
+	go m.run()
+	return m
+}
+
+// Connected returns whether the connection to the broker is up.
+func (m *ConnectionManager) Connected() bool {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	return m.conn != nil
+}
+
+// Check returns ErrNotConnected while the connection to the broker is down,
+// so the ConnectionManager can be registered with a health.Registry, and
+// ErrManagerClosed once the manager was closed.
+func (m *ConnectionManager) Check(context.Context) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	switch {
+	case m.closed:
+		return ErrManagerClosed
+	case m.conn == nil:
+		return ErrNotConnected
+	}
+	return nil
+}
+
+// Publish implements Channel, publishing on a channel shared by all
+// publishes. The channel is reopened if the broker closed it, e.g. after a
+// publish to an exchange that doesn't exist.
+func (m *ConnectionManager) Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	ch, err := m.channel()
+	if err != nil {
+		return err
+	}
+	return ch.Publish(exchange, key, mandatory, immediate, msg)
+}
+
+// Consume implements Channel, consuming queue on a channel of its own. If
+// the connection is up, errors starting the consumer are returned. The
+// consumer is restarted after reconnects and after the broker canceled it.
+func (m *ConnectionManager) Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error) {
+	c := &managedConsumer{
+		queue:     queue,
+		consumer:  consumer,
+		autoAck:   autoAck,
+		exclusive: exclusive,
+		noLocal:   noLocal,
+		noWait:    noWait,
+		args:      args,
+		out:       make(chan amqp.Delivery),
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	if m.closed {
+		return nil, ErrManagerClosed
+	}
+	if m.conn != nil {
+		ch, deliveries, err := c.start(m.conn)
+		if err != nil {
+			return nil, err
+		}
+		m.wg.Add(1)
+		go m.consume(m.conn, m.lost, c, ch, deliveries)
+	}
+	m.consumers = append(m.consumers, c)
+	return c.out, nil
+}
+
+// Close closes the connection and the delivery channels returned by
+// Consume, after the deliveries in transit were handed over.
+func (m *ConnectionManager) Close() error {
+	m.mtx.Lock()
+	if m.closed {
+		m.mtx.Unlock()
+		return ErrManagerClosed
+	}
+	m.closed = true
+	close(m.done)
+	m.mtx.Unlock()
+
+	m.wg.Wait()
+	for _, c := range m.consumers {
+		close(c.out)
+	}
+	return nil
+}
+
+func (m *ConnectionManager) run() {
+	defer m.wg.Done()
+	b := backoff.New(m.strategy)
+	for {
+		conn, err := m.connect()
+		if err == nil {
+			b.Reset()
+			closed := conn.NotifyClose(make(chan *amqp.Error, 1))
+			select {
+			case err := <-closed:
+				m.logger.Log("msg", "connection closed", "err", err)
+				m.disconnect()
+				continue
+			case <-m.done:
+				m.disconnect()
+				conn.Close()
+				return
+			}
+		}
+
+		m.logger.Log("msg", "dialing failed", "err", err)
+		t := time.NewTimer(b.Next())
+		select {
+		case <-t.C:
+		case <-m.done:
+			t.Stop()
+			return
+		}
+	}
+}
+
+// connect dials a connection, declares the topology and starts the
+// consumers.
+func (m *ConnectionManager) connect() (connection, error) {
+	conn, err := m.dial()
+	if err != nil {
+		return nil, err
+	}
+	ch, err := conn.channel()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := ch.declare(m.topology); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	if m.closed {
+		conn.Close()
+		return nil, ErrManagerClosed
+	}
+	m.conn = conn
+	m.lost = make(chan struct{})
+	m.ch = ch
+	m.chClosed = ch.NotifyClose(make(chan *amqp.Error, 1))
+	for _, c := range m.consumers {
+		m.wg.Add(1)
+		go m.consume(conn, m.lost, c, nil, nil)
+	}
+	return conn, nil
+}
+
+func (m *ConnectionManager) disconnect() {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	if m.lost != nil {
+		close(m.lost)
+	}
+	m.conn, m.lost, m.ch, m.chClosed = nil, nil, nil, nil
+}
+
+// channel returns the channel shared by publishes, reopening it if it was
+// closed.
+func (m *ConnectionManager) channel() (managedChannel, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	switch {
+	case m.closed:
+		return nil, ErrManagerClosed
+	case m.conn == nil:
+		return nil, ErrNotConnected
+	}
+
+	select {
+	case <-m.chClosed:
+		ch, err := m.conn.channel()
+		if err != nil {
+			return nil, err
+		}
+		m.ch = ch
+		m.chClosed = ch.NotifyClose(make(chan *amqp.Error, 1))
+	default:
+	}
+	return m.ch, nil
+}
+
+// consume forwards the deliveries of c on conn, restarting it with backoff
+// when its delivery channel is closed, until conn is lost or the manager is
+// closed. If deliveries is nil, the consumer is started first; otherwise ch
+// is the channel it was started on. The channel is closed once its
+// deliveries are closed, as it stays open if the broker canceled the
+// consumer.
+func (m *ConnectionManager) consume(conn connection, lost <-chan struct{}, c *managedConsumer, ch managedChannel, deliveries <-chan amqp.Delivery) {
+	defer m.wg.Done()
+	b := backoff.New(m.strategy)
+	for {
+		if deliveries == nil {
+			var err error
+			if ch, deliveries, err = c.start(conn); err != nil {
+				m.logger.Log("msg", "consuming failed", "queue", c.queue, "err", err)
+			}
+		}
+		if deliveries != nil {
+			b.Reset()
+			forwarded := m.forward(deliveries, c.out)
+			ch.Close()
+			if !forwarded {
+				return
+			}
+			deliveries = nil
+		}
+
+		t := time.NewTimer(b.Next())
+		select {
+		case <-t.C:
+		case <-lost:
+			t.Stop()
+			return
+		case <-m.done:
+			t.Stop()
+			return
+		}
+	}
+}
+
+// forward hands over deliveries to out until deliveries is closed. It
+// returns false if the manager was closed.
+func (m *ConnectionManager) forward(deliveries <-chan amqp.Delivery, out chan<- amqp.Delivery) bool {
+	for d := range deliveries {
+		select {
+		case out <- d:
+		case <-m.done:
+			return false
+		}
+	}
+	return true
+}
+
+// start consumes c on a new channel of conn, returning the channel and its
+// deliveries.
+func (c *managedConsumer) start(conn connection) (managedChannel, <-chan amqp.Delivery, error) {
+	ch, err := conn.channel()
+	if err != nil {
+		return nil, nil, err
+	}
+	deliveries, err := ch.Consume(c.queue, c.consumer, c.autoAck, c.exclusive, c.noLocal, c.noWait, c.args)
+	if err != nil {
+		ch.Close()
+		return nil, nil, err
+	}
+	return ch, deliveries, nil
+}
@@ -0,0 +1,373 @@
+package amqp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+
+	"github.com/inturn/kit/util/backoff"
+)
+
+// fakeConnection is a connection whose broker side is driven by the test.
+type fakeConnection struct {
+	mtx      sync.Mutex
+	closes   []chan *amqp.Error
+	channels []*fakeChannel
+	closed   bool
+}
+
+func (c *fakeConnection) channel() (managedChannel, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.closed {
+		return nil, amqp.ErrClosed
+	}
+	ch := &fakeChannel{}
+	c.channels = append(c.channels, ch)
+	return ch, nil
+}
+
+func (c *fakeConnection) NotifyClose(ch chan *amqp.Error) chan *amqp.Error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.closes = append(c.closes, ch)
+	return ch
+}
+
+func (c *fakeConnection) Close() error {
+	c.shutdown(nil)
+	return nil
+}
+
+// shutdown closes the connection and its channels, notifying listeners
+// of err like the broker closing it.
+func (c *fakeConnection) shutdown(err *amqp.Error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.closed {
+		return
+	}
+	c.closed = true
+	for _, ch := range c.channels {
+		ch.shutdown(err)
+	}
+	for _, closes := range c.closes {
+		if err != nil {
+			closes <- err
+		}
+		close(closes)
+	}
+}
+
+// consumer returns the channel of the nth consumer started on c.
+func (c *fakeConnection) consumer(n int) *fakeChannel {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	for _, ch := range c.channels {
+		if ch.consuming() {
+			if n == 0 {
+				return ch
+			}
+			n--
+		}
+	}
+	return nil
+}
+
+type fakeChannel struct {
+	mtx        sync.Mutex
+	closes     []chan *amqp.Error
+	deliveries chan amqp.Delivery
+	published  []string
+	closed     bool
+}
+
+func (ch *fakeChannel) Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	ch.mtx.Lock()
+	defer ch.mtx.Unlock()
+	if ch.closed {
+		return amqp.ErrClosed
+	}
+	ch.published = append(ch.published, string(msg.Body))
+	return nil
+}
+
+func (ch *fakeChannel) Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error) {
+	ch.mtx.Lock()
+	defer ch.mtx.Unlock()
+	ch.deliveries = make(chan amqp.Delivery)
+	return ch.deliveries, nil
+}
+
+func (ch *fakeChannel) NotifyClose(c chan *amqp.Error) chan *amqp.Error {
+	ch.mtx.Lock()
+	defer ch.mtx.Unlock()
+	ch.closes = append(ch.closes, c)
+	return c
+}
+
+func (ch *fakeChannel) Close() error {
+	ch.shutdown(nil)
+	return nil
+}
+
+func (ch *fakeChannel) declare(topology []func(*amqp.Channel) error) error {
+	for _, declare := range topology {
+		if err := declare(nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ch *fakeChannel) shutdown(err *amqp.Error) {
+	ch.mtx.Lock()
+	defer ch.mtx.Unlock()
+	if ch.closed {
+		return
+	}
+	ch.closed = true
+	if ch.deliveries != nil {
+		close(ch.deliveries)
+	}
+	for _, closes := range ch.closes {
+		if err != nil {
+			closes <- err
+		}
+		close(closes)
+	}
+}
+
+// cancel closes the deliveries of the consumer but not ch, like the broker
+// canceling the consumer.
+func (ch *fakeChannel) cancel() {
+	ch.mtx.Lock()
+	defer ch.mtx.Unlock()
+	close(ch.deliveries)
+	ch.deliveries = nil
+}
+
+func (ch *fakeChannel) isClosed() bool {
+	ch.mtx.Lock()
+	defer ch.mtx.Unlock()
+	return ch.closed
+}
+
+func (ch *fakeChannel) consuming() bool {
+	ch.mtx.Lock()
+	defer ch.mtx.Unlock()
+	return ch.deliveries != nil && !ch.closed
+}
+
+// fakeDialer dials fakeConnections, failing while fail is set.
+type fakeDialer struct {
+	mtx   sync.Mutex
+	fail  bool
+	conns []*fakeConnection
+}
+
+func (d *fakeDialer) dial() (connection, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if d.fail {
+		return nil, errors.New("connection refused")
+	}
+	conn := &fakeConnection{}
+	d.conns = append(d.conns, conn)
+	return conn, nil
+}
+
+func (d *fakeDialer) conn(n int) *fakeConnection {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if n >= len(d.conns) {
+		return nil
+	}
+	return d.conns[n]
+}
+
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for i := 0; !cond(); i++ {
+		if i > 500 {
+			t.Fatalf("timed out waiting until %s", what)
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+}
+
+func newTestConnectionManager(d *fakeDialer, options ...ConnectionManagerOption) *ConnectionManager {
+	options = append([]ConnectionManagerOption{ConnectionManagerBackoff(backoff.Constant(time.Millisecond))}, options...)
+	return newConnectionManager(d.dial, options...)
+}
+
+func TestConnectionManagerFakeReconnect(t *testing.T) {
+	var declared int
+	d := &fakeDialer{}
+	m := newTestConnectionManager(d, ConnectionManagerTopology(func(*amqp.Channel) error {
+		declared++
+		return nil
+	}))
+	defer m.Close()
+	eventually(t, "connected", m.Connected)
+
+	out, err := m.Consume("q", "", false, false, false, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.conn(0).consumer(0).deliveries <- amqp.Delivery{MessageId: "m1"}
+	if want, have := "m1", (<-out).MessageId; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+
+	d.conn(0).shutdown(&amqp.Error{Code: amqp.ConnectionForced, Reason: "broker restart"})
+	eventually(t, "reconnected", func() bool { return d.conn(1) != nil && d.conn(1).consumer(0) != nil })
+	if want, have := 2, declared; want != have {
+		t.Errorf("want topology declared %d times, have %d", want, have)
+	}
+
+	// the delivery channel returned by Consume stays open across reconnects
+	d.conn(1).consumer(0).deliveries <- amqp.Delivery{MessageId: "m2"}
+	if want, have := "m2", (<-out).MessageId; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
+
+func TestConnectionManagerFakeDialFailure(t *testing.T) {
+	d := &fakeDialer{fail: true}
+	m := newTestConnectionManager(d)
+	defer m.Close()
+
+	if want, have := ErrNotConnected, m.Check(context.Background()); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	if want, have := ErrNotConnected, m.Publish("", "q", false, false, amqp.Publishing{}); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+
+	// consumers registered while the connection is down start once it is up
+	out, err := m.Consume("q", "", false, false, false, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.mtx.Lock()
+	d.fail = false
+	d.mtx.Unlock()
+	eventually(t, "consuming", func() bool { return d.conn(0) != nil && d.conn(0).consumer(0) != nil })
+	if err := m.Check(context.Background()); err != nil {
+		t.Errorf("want no error, have %v", err)
+	}
+	d.conn(0).consumer(0).deliveries <- amqp.Delivery{MessageId: "m1"}
+	if want, have := "m1", (<-out).MessageId; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
+
+func TestConnectionManagerFakeConsumerRestart(t *testing.T) {
+	d := &fakeDialer{}
+	m := newTestConnectionManager(d)
+	defer m.Close()
+	eventually(t, "connected", m.Connected)
+
+	out, err := m.Consume("q", "", false, false, false, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the broker canceled the consumer, closing its channel
+	first := d.conn(0).consumer(0)
+	first.shutdown(&amqp.Error{Code: amqp.NotFound, Reason: "queue deleted"})
+	eventually(t, "consumer restarted", func() bool { return d.conn(0).consumer(0) != nil })
+	if d.conn(1) != nil {
+		t.Error("want consumer restarted on the same connection, have reconnect")
+	}
+	d.conn(0).consumer(0).deliveries <- amqp.Delivery{MessageId: "m1"}
+	if want, have := "m1", (<-out).MessageId; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
+
+func TestConnectionManagerFakeConsumerCanceled(t *testing.T) {
+	d := &fakeDialer{}
+	m := newTestConnectionManager(d)
+	defer m.Close()
+	eventually(t, "connected", m.Connected)
+
+	out, err := m.Consume("q", "", false, false, false, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the broker canceled the consumer, leaving its channel open
+	first := d.conn(0).consumer(0)
+	first.cancel()
+	eventually(t, "consumer restarted", func() bool { return d.conn(0).consumer(0) != nil })
+	eventually(t, "channel of the canceled consumer closed", first.isClosed)
+	d.conn(0).consumer(0).deliveries <- amqp.Delivery{MessageId: "m1"}
+	if want, have := "m1", (<-out).MessageId; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+}
+
+func TestConnectionManagerFakePublish(t *testing.T) {
+	d := &fakeDialer{}
+	m := newTestConnectionManager(d)
+	defer m.Close()
+	eventually(t, "connected", m.Connected)
+
+	if err := m.Publish("", "q", false, false, amqp.Publishing{Body: []byte("a")}); err != nil {
+		t.Fatal(err)
+	}
+	shared := d.conn(0).channels[0]
+	shared.shutdown(&amqp.Error{Code: amqp.NotFound, Reason: "no exchange"})
+
+	// the shared channel is reopened after the broker closed it
+	if err := m.Publish("", "q", false, false, amqp.Publishing{Body: []byte("b")}); err != nil {
+		t.Fatal(err)
+	}
+	reopened := d.conn(0).channels[1]
+	if want, have := "[a]", fmt.Sprint(shared.published); want != have {
+		t.Errorf("want %s published on the first channel, have %s", want, have)
+	}
+	if want, have := "[b]", fmt.Sprint(reopened.published); want != have {
+		t.Errorf("want %s published on the reopened channel, have %s", want, have)
+	}
+}
+
+func TestConnectionManagerFakeClose(t *testing.T) {
+	d := &fakeDialer{}
+	m := newTestConnectionManager(d)
+	eventually(t, "connected", m.Connected)
+
+	out, err := m.Consume("q", "", false, false, false, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := <-out; ok {
+		t.Error("want delivery channel closed, have delivery")
+	}
+	d.conn(0).mtx.Lock()
+	closed := d.conn(0).closed
+	d.conn(0).mtx.Unlock()
+	if !closed {
+		t.Error("want connection closed")
+	}
+	if want, have := ErrManagerClosed, m.Check(context.Background()); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	if _, err := m.Consume("q", "", false, false, false, false, nil); err != ErrManagerClosed {
+		t.Errorf("want %v, have %v", ErrManagerClosed, err)
+	}
+	if want, have := ErrManagerClosed, m.Close(); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
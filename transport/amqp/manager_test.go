@@ -0,0 +1,96 @@
+//go:build integration
+// +build integration
+
+package amqp_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+
+	"github.com/inturn/kit/endpoint"
+	"github.com/inturn/kit/testkit"
+	amqptransport "github.com/inturn/kit/transport/amqp"
+	"github.com/inturn/kit/util/backoff"
+)
+
+func TestConnectionManagerReconnect(t *testing.T) {
+	var (
+		broker = testkit.NewRabbitMQ(t)
+		mtx    sync.Mutex
+		conns  []*amqp.Connection
+	)
+	m := amqptransport.NewConnectionManager(
+		func() (*amqp.Connection, error) {
+			conn, err := amqp.Dial(broker.URL)
+			if err == nil {
+				mtx.Lock()
+				conns = append(conns, conn)
+				mtx.Unlock()
+			}
+			return conn, err
+		},
+		amqptransport.ConnectionManagerBackoff(backoff.Constant(10*time.Millisecond)),
+		amqptransport.ConnectionManagerTopology(func(ch *amqp.Channel) error {
+			_, err := ch.QueueDeclare("manager.echo", false, true, false, false, nil)
+			return err
+		}),
+	)
+	defer m.Close()
+	waitConnected(t, m)
+
+	deliveries, err := m.Consume("manager.echo", "", true, false, false, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub := amqptransport.NewSubscriber(
+		endpoint.Nop,
+		func(context.Context, *amqp.Delivery) (interface{}, error) { return nil, nil },
+		func(_ context.Context, pub *amqp.Publishing, _ interface{}) error {
+			pub.Body = []byte("pong")
+			return nil
+		},
+	)
+	go amqptransport.NewRunner(sub, m, deliveries).Run()
+
+	ch := broker.Channel(t)
+	call := func() {
+		t.Helper()
+		reply := testkit.Call(t, ch, "manager.echo", amqp.Publishing{Body: []byte("ping")}, 5*time.Second)
+		if want, have := "pong", string(reply.Body); want != have {
+			t.Errorf("want %q, have %q", want, have)
+		}
+	}
+	call()
+
+	mtx.Lock()
+	conns[0].Close()
+	mtx.Unlock()
+	for i := 0; ; i++ {
+		mtx.Lock()
+		n := len(conns)
+		mtx.Unlock()
+		if n == 2 {
+			break
+		}
+		if i > 500 {
+			t.Fatal("manager didn't reconnect")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	waitConnected(t, m)
+	call()
+}
+
+func waitConnected(t *testing.T, m *amqptransport.ConnectionManager) {
+	t.Helper()
+	for i := 0; !m.Connected(); i++ {
+		if i > 500 {
+			t.Fatal("manager didn't connect")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,47 @@
+package amqp
+
+import (
+	"context"
+
+	"github.com/streadway/amqp"
+)
+
+// DeliveryHandler serves a delivery. ctx is canceled once the delivery is
+// served, or once the channel is done if it is a WatchedChannel.
+type DeliveryHandler func(ctx context.Context, deliv *amqp.Delivery)
+
+// DeliveryMiddleware is a chainable behavior modifier for DeliveryHandlers,
+// the transport level counterpart of endpoint.Middleware. Unlike endpoint
+// middleware, it sees every delivery, including those failing to decode, and
+// the raw delivery rather than the decoded request.
+type DeliveryMiddleware func(next DeliveryHandler) DeliveryHandler
+
+// SubscriberWrap wraps the handler serving deliveries in middleware, e.g. to
+// start a span from the headers of every delivery and put it in the context
+// passed to the before functions and the endpoint:
+//
+//	amqptransport.SubscriberWrap(func(next amqptransport.DeliveryHandler) amqptransport.DeliveryHandler {
+//	    return func(ctx context.Context, deliv *amqp.Delivery) {
+//	        span, ctx := startSpan(ctx, deliv.Headers)
+//	        defer span.Finish()
+//	        next(ctx, deliv)
+//	    }
+//	})
+//
+// The first middleware is the outermost. Middleware added by repeated
+// options wraps inside the middleware added before.
+func SubscriberWrap(middleware ...DeliveryMiddleware) SubscriberOption {
+	return func(s *Subscriber) { s.middleware = append(s.middleware, middleware...) }
+}
+
+// ChainDeliveryMiddleware is a helper function for composing middlewares.
+// Requests will traverse them in the order they're declared. That is, the
+// first middleware is treated as the outermost middleware.
+func ChainDeliveryMiddleware(outer DeliveryMiddleware, others ...DeliveryMiddleware) DeliveryMiddleware {
+	return func(next DeliveryHandler) DeliveryHandler {
+		for i := len(others) - 1; i >= 0; i-- {
+			next = others[i](next)
+		}
+		return outer(next)
+	}
+}
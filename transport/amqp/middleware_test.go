@@ -0,0 +1,70 @@
+package amqp_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/streadway/amqp"
+
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+type traceKey struct{}
+
+func TestSubscriberWrap(t *testing.T) {
+	var calls []string
+	trace := func(name string) amqptransport.DeliveryMiddleware {
+		return func(next amqptransport.DeliveryHandler) amqptransport.DeliveryHandler {
+			return func(ctx context.Context, deliv *amqp.Delivery) {
+				calls = append(calls, name+" before")
+				next(context.WithValue(ctx, traceKey{}, name), deliv)
+				calls = append(calls, name+" after")
+			}
+		}
+	}
+	sub := amqptransport.NewSubscriber(
+		func(ctx context.Context, request interface{}) (interface{}, error) {
+			calls = append(calls, "endpoint in "+ctx.Value(traceKey{}).(string))
+			return testEndpoint(ctx, request)
+		},
+		testReqDecoder,
+		amqptransport.EncodeJSONResponse,
+		amqptransport.SubscriberWrap(amqptransport.ChainDeliveryMiddleware(trace("outer"), trace("middle"))),
+		amqptransport.SubscriberWrap(trace("inner")),
+	)
+	sub.ServeDelivery(&countingChannel{})(&amqp.Delivery{
+		Acknowledger: &mockAcknowledger{},
+		Body:         []byte(`{"s":437}`),
+	})
+
+	want := []string{
+		"outer before", "middle before", "inner before",
+		"endpoint in inner",
+		"inner after", "middle after", "outer after",
+	}
+	if have := calls; !reflect.DeepEqual(want, have) {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
+
+func TestSubscriberWrapDecodeError(t *testing.T) {
+	var wrapped int
+	sub := amqptransport.NewSubscriber(
+		testEndpoint,
+		func(context.Context, *amqp.Delivery) (interface{}, error) { return nil, errors.New("bad") },
+		amqptransport.EncodeJSONResponse,
+		amqptransport.SubscriberWrap(func(next amqptransport.DeliveryHandler) amqptransport.DeliveryHandler {
+			return func(ctx context.Context, deliv *amqp.Delivery) {
+				wrapped++
+				next(ctx, deliv)
+			}
+		}),
+	)
+	sub.ServeDelivery(&countingChannel{})(&amqp.Delivery{Acknowledger: &mockAcknowledger{}})
+
+	if want, have := 1, wrapped; want != have {
+		t.Errorf("want %d wrapped deliveries, have %d", want, have)
+	}
+}
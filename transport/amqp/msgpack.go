@@ -0,0 +1,75 @@
+package amqp
+
+import (
+	"context"
+
+	"github.com/streadway/amqp"
+	msgpack "gopkg.in/vmihailenco/msgpack.v2"
+)
+
+// MsgPackContentType is the content type of MessagePack payloads.
+const MsgPackContentType = "application/msgpack"
+
+// EncodeMsgPackResponse marshals the response as MessagePack as part of the
+// payload of the AMQP Publishing object, and sets its ContentType to
+// MsgPackContentType.
+func EncodeMsgPackResponse(
+	ctx context.Context,
+	pub *amqp.Publishing,
+	response interface{},
+) error {
+	return encodeMsgPack(pub, response)
+}
+
+// EncodeMsgPackRequest marshals the request as MessagePack as part of the
+// payload of the AMQP Publishing object, and sets its ContentType to
+// MsgPackContentType. It is designed to be used in Publishers.
+func EncodeMsgPackRequest(
+	ctx context.Context,
+	pub *amqp.Publishing,
+	request interface{},
+) error {
+	return encodeMsgPack(pub, request)
+}
+
+func encodeMsgPack(pub *amqp.Publishing, v interface{}) error {
+	b, err := msgpack.Marshal(v)
+	if err != nil {
+		return err
+	}
+	pub.Body = b
+	pub.ContentType = MsgPackContentType
+	return nil
+}
+
+// DecodeMsgPackRequest returns a DecodeRequestFunc unmarshaling the
+// MessagePack payload of the delivery into the pointer returned by
+// newRequest, e.g.
+//
+//	amqptransport.DecodeMsgPackRequest(func() interface{} { return &createRequest{} })
+//
+// Deliveries with a ContentType other than MsgPackContentType are rejected.
+func DecodeMsgPackRequest(newRequest func() interface{}) DecodeRequestFunc {
+	return func(ctx context.Context, d *amqp.Delivery) (interface{}, error) {
+		return decodeMsgPack(d, newRequest())
+	}
+}
+
+// DecodeMsgPackResponse returns a DecodeResponseFunc unmarshaling the
+// MessagePack payload of the reply into the pointer returned by
+// newResponse. It is designed to be used in Publishers.
+func DecodeMsgPackResponse(newResponse func() interface{}) DecodeResponseFunc {
+	return func(ctx context.Context, d *amqp.Delivery) (interface{}, error) {
+		return decodeMsgPack(d, newResponse())
+	}
+}
+
+func decodeMsgPack(d *amqp.Delivery, v interface{}) (interface{}, error) {
+	if ct := mediaType(d.ContentType); ct != "" && ct != MsgPackContentType {
+		return nil, UnsupportedContentTypeError{ContentType: d.ContentType}
+	}
+	if err := msgpack.Unmarshal(d.Body, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
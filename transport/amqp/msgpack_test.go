@@ -0,0 +1,33 @@
+package amqp_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/streadway/amqp"
+
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+func TestMsgPackRoundTrip(t *testing.T) {
+	var pub amqp.Publishing
+	if err := amqptransport.EncodeMsgPackRequest(context.Background(), &pub, testRes{Squadron: 437, Name: "husky"}); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := amqptransport.MsgPackContentType, pub.ContentType; want != have {
+		t.Errorf("incorrect content type, want %q, have %q", want, have)
+	}
+
+	decode := amqptransport.DecodeMsgPackRequest(func() interface{} { return &testRes{} })
+	request, err := decode(context.Background(), &amqp.Delivery{ContentType: pub.ContentType, Body: pub.Body})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := (testRes{Squadron: 437, Name: "husky"}), *request.(*testRes); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+
+	if _, err := decode(context.Background(), &amqp.Delivery{ContentType: "application/json"}); err == nil {
+		t.Error("want error for JSON delivery, have nil")
+	}
+}
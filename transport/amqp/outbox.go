@@ -0,0 +1,254 @@
+package amqp
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/streadway/amqp"
+
+	"github.com/inturn/kit/endpoint"
+	"github.com/inturn/kit/log"
+	"github.com/inturn/kit/transport"
+)
+
+// OutboxMessage is a publishing waiting in an OutboxStore to be relayed to
+// the broker.
+type OutboxMessage struct {
+	ID         int64
+	Exchange   string
+	Key        string
+	Publishing amqp.Publishing
+
+	// Err is set by stores for messages they failed to read, which the
+	// OutboxRelay sets aside instead of publishing.
+	Err error
+}
+
+// InvalidOutboxMessageError is passed to the error handler of an
+// OutboxRelay for messages that can never be published, e.g. because their
+// headers hold values AMQP doesn't support, and were set aside.
+type InvalidOutboxMessageError struct {
+	ID  int64
+	Err error
+}
+
+// Error implements the error interface.
+func (e InvalidOutboxMessageError) Error() string {
+	return fmt.Sprintf("invalid outbox message %d: %v", e.ID, e.Err)
+}
+
+// Unwrap returns the reason the message is invalid.
+func (e InvalidOutboxMessageError) Unwrap() error {
+	return e.Err
+}
+
+// OutboxStore stores the messages of an outbox. Implementations must be
+// safe for concurrent use.
+type OutboxStore interface {
+	// Add stores m, within the transaction of the caller if ctx carries
+	// one the store understands, see ContextWithOutboxTx.
+	Add(ctx context.Context, m OutboxMessage) error
+
+	// Pending returns up to limit stored messages, oldest first, with their
+	// IDs set.
+	Pending(ctx context.Context, limit int) ([]OutboxMessage, error)
+
+	// Remove removes the messages with the given IDs.
+	Remove(ctx context.Context, ids ...int64) error
+}
+
+// OutboxPublisher is the write side of a transactional outbox. Its endpoint
+// encodes requests as publishings and adds them to an OutboxStore instead
+// of publishing them, so business events are only published if, and as
+// soon as, the transaction changing the business data commits. An
+// OutboxRelay publishes the stored messages.
+//
+// Messages are relayed at least once. Every message gets a MessageId unless
+// the encoder set one, so consumers can skip duplicates, see
+// SubscriberDeduplication.
+type OutboxPublisher struct {
+	store  OutboxStore
+	enc    EncodeRequestFunc
+	before []RequestFunc
+}
+
+// OutboxPublisherOption sets an optional parameter for outbox publishers.
+type OutboxPublisherOption func(*OutboxPublisher)
+
+// OutboxPublisherBefore sets the RequestFuncs that are applied to the
+// outgoing publishing before it's stored. The exchange and routing key of
+// the message are set with SetPublishExchange and SetPublishKey.
+func OutboxPublisherBefore(before ...RequestFunc) OutboxPublisherOption {
+	return func(p *OutboxPublisher) { p.before = append(p.before, before...) }
+}
+
+// NewOutboxPublisher constructs an OutboxPublisher adding the requests
+// encoded with enc to store.
+func NewOutboxPublisher(store OutboxStore, enc EncodeRequestFunc, options ...OutboxPublisherOption) *OutboxPublisher {
+	p := &OutboxPublisher{
+		store: store,
+		enc:   enc,
+	}
+	for _, option := range options {
+		option(p)
+	}
+	return p
+}
+
+// Endpoint returns a usable endpoint that stores the request in the
+// outbox. Its response is always nil. To store the message within a
+// transaction, pass it in the context, see ContextWithOutboxTx.
+func (p OutboxPublisher) Endpoint() endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		var pub amqp.Publishing
+		if err := p.enc(ctx, &pub, request); err != nil {
+			return nil, err
+		}
+		for _, f := range p.before {
+			ctx = f(ctx, &pub, nil)
+		}
+		if pub.MessageId == "" {
+			pub.MessageId = randomString(32)
+		}
+		return nil, p.store.Add(ctx, OutboxMessage{
+			Exchange:   getPublishExchange(ctx),
+			Key:        getPublishKey(ctx),
+			Publishing: pub,
+		})
+	}
+}
+
+// OutboxRelay publishes the messages of an OutboxStore, in the order they
+// were stored, and removes them once published. Use a ConfirmChannel to
+// remove messages only once the broker took responsibility for them. Run a
+// single relay per store; messages relayed concurrently are published
+// twice.
+type OutboxRelay struct {
+	store        OutboxStore
+	ch           Channel
+	batchSize    int
+	interval     time.Duration
+	deadLetters  OutboxStore
+	errorHandler transport.ErrorHandler
+}
+
+// OutboxRelayOption sets an optional parameter for outbox relays.
+type OutboxRelayOption func(*OutboxRelay)
+
+// OutboxRelayBatchSize sets the maximum number of messages fetched from the
+// store at once. The default is 100.
+func OutboxRelayBatchSize(n int) OutboxRelayOption {
+	return func(r *OutboxRelay) { r.batchSize = n }
+}
+
+// OutboxRelayInterval sets how long Run waits before polling the store
+// again once it relayed all messages. The default is a second.
+func OutboxRelayInterval(interval time.Duration) OutboxRelayOption {
+	return func(r *OutboxRelay) { r.interval = interval }
+}
+
+// OutboxRelayDeadLetters sets the store that messages which can never be
+// published, e.g. because the broker rejects their headers, are moved to,
+// such as a second table of a SQLOutboxStore, so they can be inspected and
+// fixed. By default, they are only passed to the error handler as an
+// InvalidOutboxMessageError, and dropped.
+func OutboxRelayDeadLetters(store OutboxStore) OutboxRelayOption {
+	return func(r *OutboxRelay) { r.deadLetters = store }
+}
+
+// OutboxRelayErrorHandler is used to handle errors relaying messages in
+// Run, which keeps retrying. By default, errors are ignored.
+func OutboxRelayErrorHandler(errorHandler transport.ErrorHandler) OutboxRelayOption {
+	return func(r *OutboxRelay) { r.errorHandler = errorHandler }
+}
+
+// NewOutboxRelay returns an OutboxRelay publishing the messages of store
+// on ch.
+func NewOutboxRelay(store OutboxStore, ch Channel, options ...OutboxRelayOption) *OutboxRelay {
+	r := &OutboxRelay{
+		store:        store,
+		ch:           ch,
+		batchSize:    100,
+		interval:     time.Second,
+		errorHandler: transport.NewLogErrorHandler(log.NewNopLogger()),
+	}
+	for _, option := range options {
+		option(r)
+	}
+	return r
+}
+
+// Relay publishes a batch of pending messages and returns how many it
+// published. It stops at the first message that fails to publish, keeping
+// it and the following messages in the store. Messages that can never be
+// published are set aside, see OutboxRelayDeadLetters, so they don't hold
+// up the others.
+func (r *OutboxRelay) Relay(ctx context.Context) (int, error) {
+	messages, err := r.store.Pending(ctx, r.batchSize)
+	if err != nil {
+		return 0, err
+	}
+	var (
+		done      []int64
+		published int
+		perr      error
+	)
+	for _, m := range messages {
+		err := m.Err
+		if err == nil {
+			err = m.Publishing.Headers.Validate()
+		}
+		if err != nil {
+			if perr = r.setAside(ctx, m, err); perr != nil {
+				break
+			}
+			done = append(done, m.ID)
+			continue
+		}
+		if perr = r.ch.Publish(m.Exchange, m.Key, false, false, m.Publishing); perr != nil {
+			break
+		}
+		done = append(done, m.ID)
+		published++
+	}
+	if len(done) > 0 {
+		if err := r.store.Remove(ctx, done...); err != nil {
+			return 0, err
+		}
+	}
+	return published, perr
+}
+
+// setAside moves m, which can't be published because of err, to the dead
+// letter store, if any.
+func (r *OutboxRelay) setAside(ctx context.Context, m OutboxMessage, err error) error {
+	r.errorHandler.Handle(ctx, InvalidOutboxMessageError{ID: m.ID, Err: err})
+	if r.deadLetters == nil {
+		return nil
+	}
+	m.Err = nil
+	return r.deadLetters.Add(ctx, m)
+}
+
+// Run relays messages until ctx is done, and returns ctx.Err(). It polls
+// the store again right away as long as it finds full batches.
+func (r *OutboxRelay) Run(ctx context.Context) error {
+	for {
+		n, err := r.Relay(ctx)
+		if err != nil {
+			r.errorHandler.Handle(ctx, err)
+		}
+		if err == nil && n == r.batchSize {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			continue
+		}
+		select {
+		case <-time.After(r.interval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
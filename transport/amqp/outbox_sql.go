@@ -0,0 +1,156 @@
+package amqp
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+func init() {
+	// Header values are stored as interfaces, so gob must know the types
+	// besides the basic ones AMQP tables can hold.
+	gob.Register(amqp.Table{})
+	gob.Register([]interface{}{})
+	gob.Register(time.Time{})
+	gob.Register(amqp.Decimal{})
+}
+
+// ContextWithOutboxTx returns a copy of ctx carrying tx, so a SQLOutboxStore
+// adds messages within tx.
+func ContextWithOutboxTx(ctx context.Context, tx *sql.Tx) context.Context {
+	return context.WithValue(ctx, ContextKeyOutboxTx, tx)
+}
+
+// SQLOutboxStore is an OutboxStore keeping messages in a SQL table:
+//
+//	CREATE TABLE outbox (
+//		id          BIGINT AUTO_INCREMENT PRIMARY KEY, -- BIGSERIAL in PostgreSQL
+//		exchange    VARCHAR(255) NOT NULL,
+//		routing_key VARCHAR(255) NOT NULL,
+//		publishing  BLOB NOT NULL                      -- BYTEA in PostgreSQL
+//	)
+//
+// Publishings are stored with encoding/gob, so header values keep their
+// types. Publishings stored as JSON by earlier versions are still read, with
+// header values restored as JSON types; those the broker doesn't accept are
+// set aside by the OutboxRelay.
+type SQLOutboxStore struct {
+	db          *sql.DB
+	table       string
+	placeholder func(n int) string
+}
+
+// SQLOutboxStoreOption sets an optional parameter for SQL outbox stores.
+type SQLOutboxStoreOption func(*SQLOutboxStore)
+
+// SQLOutboxPlaceholder sets the function returning the placeholder of the
+// nth query parameter, starting at 1. The default is "?", as used by MySQL
+// and SQLite; see DollarPlaceholder for PostgreSQL.
+func SQLOutboxPlaceholder(placeholder func(n int) string) SQLOutboxStoreOption {
+	return func(s *SQLOutboxStore) { s.placeholder = placeholder }
+}
+
+// DollarPlaceholder returns the PostgreSQL placeholder "$n".
+func DollarPlaceholder(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
+
+// NewSQLOutboxStore returns a SQLOutboxStore keeping messages in table of
+// db.
+func NewSQLOutboxStore(db *sql.DB, table string, options ...SQLOutboxStoreOption) *SQLOutboxStore {
+	s := &SQLOutboxStore{
+		db:          db,
+		table:       table,
+		placeholder: func(int) string { return "?" },
+	}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+// Add implements OutboxStore. The message is inserted within the
+// transaction passed with ContextWithOutboxTx, if any.
+func (s *SQLOutboxStore) Add(ctx context.Context, m OutboxMessage) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(m.Publishing); err != nil {
+		return err
+	}
+	b := buf.Bytes()
+	var err error
+	query := fmt.Sprintf(
+		"INSERT INTO %s (exchange, routing_key, publishing) VALUES (%s, %s, %s)",
+		s.table, s.placeholder(1), s.placeholder(2), s.placeholder(3),
+	)
+	if tx, ok := ctx.Value(ContextKeyOutboxTx).(*sql.Tx); ok {
+		_, err = tx.ExecContext(ctx, query, m.Exchange, m.Key, b)
+	} else {
+		_, err = s.db.ExecContext(ctx, query, m.Exchange, m.Key, b)
+	}
+	return err
+}
+
+// Pending implements OutboxStore.
+func (s *SQLOutboxStore) Pending(ctx context.Context, limit int) ([]OutboxMessage, error) {
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(
+		"SELECT id, exchange, routing_key, publishing FROM %s ORDER BY id LIMIT %s",
+		s.table, s.placeholder(1),
+	), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []OutboxMessage
+	for rows.Next() {
+		var (
+			m OutboxMessage
+			b []byte
+		)
+		if err := rows.Scan(&m.ID, &m.Exchange, &m.Key, &b); err != nil {
+			return nil, err
+		}
+		m.Publishing, m.Err = decodePublishing(b)
+		messages = append(messages, m)
+	}
+	return messages, rows.Err()
+}
+
+// Remove implements OutboxStore.
+func (s *SQLOutboxStore) Remove(ctx context.Context, ids ...int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		placeholders[i] = s.placeholder(i + 1)
+		args[i] = id
+	}
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf(
+		"DELETE FROM %s WHERE id IN (%s)",
+		s.table, strings.Join(placeholders, ", "),
+	), args...)
+	return err
+}
+
+// decodePublishing decodes a publishing stored by Add, or as JSON by earlier
+// versions.
+func decodePublishing(b []byte) (amqp.Publishing, error) {
+	var pub amqp.Publishing
+	err := gob.NewDecoder(bytes.NewReader(b)).Decode(&pub)
+	if err != nil {
+		pub = amqp.Publishing{}
+		if json.Unmarshal(b, &pub) == nil {
+			return pub, nil
+		}
+	}
+	return pub, err
+}
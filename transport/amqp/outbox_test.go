@@ -0,0 +1,262 @@
+package amqp_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/streadway/amqp"
+
+	"github.com/inturn/kit/transport"
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+// memoryOutboxStore is an OutboxStore keeping messages in a slice.
+type memoryOutboxStore struct {
+	mtx      sync.Mutex
+	nextID   int64
+	messages []amqptransport.OutboxMessage
+}
+
+func (s *memoryOutboxStore) Add(_ context.Context, m amqptransport.OutboxMessage) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.nextID++
+	m.ID = s.nextID
+	s.messages = append(s.messages, m)
+	return nil
+}
+
+func (s *memoryOutboxStore) Pending(_ context.Context, limit int) ([]amqptransport.OutboxMessage, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if limit > len(s.messages) {
+		limit = len(s.messages)
+	}
+	return append([]amqptransport.OutboxMessage(nil), s.messages[:limit]...), nil
+}
+
+func (s *memoryOutboxStore) Remove(_ context.Context, ids ...int64) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	removed := map[int64]bool{}
+	for _, id := range ids {
+		removed[id] = true
+	}
+	var kept []amqptransport.OutboxMessage
+	for _, m := range s.messages {
+		if !removed[m.ID] {
+			kept = append(kept, m)
+		}
+	}
+	s.messages = kept
+	return nil
+}
+
+func TestOutbox(t *testing.T) {
+	store := &memoryOutboxStore{}
+	pub := amqptransport.NewOutboxPublisher(
+		store,
+		testReqEncoder,
+		amqptransport.OutboxPublisherBefore(
+			amqptransport.SetPublishExchange("squadrons"),
+			amqptransport.SetPublishKey("squadron.created"),
+		),
+	)
+	for _, squadron := range []int{424, 426, 429} {
+		if _, err := pub.Endpoint()(context.Background(), testReq{Squadron: squadron}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var (
+		published []amqp.Publishing
+		keys      []string
+	)
+	c := make(chan amqp.Publishing, 3)
+	ch := &mockChannel{
+		f: func(exchange, key string, mandatory, immediate bool) { keys = append(keys, exchange+" "+key) },
+		c: c,
+	}
+	relay := amqptransport.NewOutboxRelay(store, ch, amqptransport.OutboxRelayBatchSize(2))
+	for _, want := range []int{2, 1, 0} {
+		n, err := relay.Relay(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want != n {
+			t.Errorf("want %d messages relayed, have %d", want, n)
+		}
+	}
+	close(c)
+	for p := range c {
+		published = append(published, p)
+	}
+
+	if want, have := 3, len(published); want != have {
+		t.Fatalf("want %d publishings, have %d", want, have)
+	}
+	for i, squadron := range []int{424, 426, 429} {
+		var req testReq
+		if err := json.Unmarshal(published[i].Body, &req); err != nil {
+			t.Fatal(err)
+		}
+		if want, have := squadron, req.Squadron; want != have {
+			t.Errorf("publishing %d: want squadron %d, have %d", i, want, have)
+		}
+		if published[i].MessageId == "" {
+			t.Errorf("publishing %d: want message ID, have none", i)
+		}
+		if want, have := "squadrons squadron.created", keys[i]; want != have {
+			t.Errorf("publishing %d: want %q, have %q", i, want, have)
+		}
+	}
+}
+
+func TestOutboxRelayKeepsFailedMessages(t *testing.T) {
+	store := &memoryOutboxStore{}
+	for i := 0; i < 3; i++ {
+		store.Add(context.Background(), amqptransport.OutboxMessage{Key: "squadron.created"})
+	}
+	ch := &flakyChannel{err: errors.New("dummy")}
+	relay := amqptransport.NewOutboxRelay(store, ch)
+
+	ch.failures = 1
+	if n, err := relay.Relay(context.Background()); n != 0 || err != ch.err {
+		t.Errorf("want 0 messages relayed and %v, have %d and %v", ch.err, n, err)
+	}
+	if want, have := 3, len(store.messages); want != have {
+		t.Errorf("want %d messages kept, have %d", want, have)
+	}
+	if n, err := relay.Relay(context.Background()); n != 3 || err != nil {
+		t.Errorf("want 3 messages relayed, have %d and %v", n, err)
+	}
+	if want, have := 0, len(store.messages); want != have {
+		t.Errorf("want %d messages kept, have %d", want, have)
+	}
+}
+
+// capture is a sqlmock.Argument capturing the value it is matched with.
+type capture struct{ v driver.Value }
+
+func (c *capture) Match(v driver.Value) bool {
+	c.v = v
+	return true
+}
+
+func TestOutboxRelaySetsAsideInvalidMessages(t *testing.T) {
+	store, deadLetters := &memoryOutboxStore{}, &memoryOutboxStore{}
+	store.Add(context.Background(), amqptransport.OutboxMessage{Key: "a"})
+	store.Add(context.Background(), amqptransport.OutboxMessage{
+		Key:        "b",
+		Publishing: amqp.Publishing{Headers: amqp.Table{"n": 1.5 + 0i}},
+	})
+	store.Add(context.Background(), amqptransport.OutboxMessage{Key: "c", Err: errors.New("unreadable")})
+	store.Add(context.Background(), amqptransport.OutboxMessage{Key: "d"})
+
+	var invalid []int64
+	relay := amqptransport.NewOutboxRelay(store, &countingChannel{},
+		amqptransport.OutboxRelayDeadLetters(deadLetters),
+		amqptransport.OutboxRelayErrorHandler(transport.ErrorHandlerFunc(func(_ context.Context, err error) {
+			var e amqptransport.InvalidOutboxMessageError
+			if errors.As(err, &e) {
+				invalid = append(invalid, e.ID)
+			}
+		})),
+	)
+	if n, err := relay.Relay(context.Background()); n != 2 || err != nil {
+		t.Errorf("want 2 messages relayed, have %d and %v", n, err)
+	}
+	if want, have := 0, len(store.messages); want != have {
+		t.Errorf("want %d messages kept, have %d", want, have)
+	}
+	if want, have := []int64{2, 3}, invalid; !reflect.DeepEqual(want, have) {
+		t.Errorf("want invalid messages %v, have %v", want, have)
+	}
+	if want, have := 2, len(deadLetters.messages); want != have {
+		t.Errorf("want %d dead letters, have %d", want, have)
+	}
+}
+
+func TestSQLOutboxStore(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	store := amqptransport.NewSQLOutboxStore(db, "outbox", amqptransport.SQLOutboxPlaceholder(amqptransport.DollarPlaceholder))
+
+	headers := amqp.Table{
+		"count":  int64(3),
+		"raw":    []byte("raw"),
+		"nested": amqp.Table{"at": time.Unix(1500000000, 0).UTC(), "keys": []interface{}{"a", int32(1)}},
+	}
+	stored := &capture{}
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO outbox \(exchange, routing_key, publishing\) VALUES \(\$1, \$2, \$3\)`).
+		WithArgs("squadrons", "squadron.created", stored).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := amqptransport.ContextWithOutboxTx(context.Background(), tx)
+	if err := store.Add(ctx, amqptransport.OutboxMessage{
+		Exchange:   "squadrons",
+		Key:        "squadron.created",
+		Publishing: amqp.Publishing{Headers: headers, Body: []byte(`{"s":424}`)},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	legacy, _ := json.Marshal(amqp.Publishing{Body: []byte(`{"s":426}`)})
+	mock.ExpectQuery(`SELECT id, exchange, routing_key, publishing FROM outbox ORDER BY id LIMIT \$1`).
+		WithArgs(10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "exchange", "routing_key", "publishing"}).
+			AddRow(int64(1), "squadrons", "squadron.created", stored.v).
+			AddRow(int64(2), "squadrons", "squadron.created", legacy).
+			AddRow(int64(3), "squadrons", "squadron.created", []byte("garbage")))
+	messages, err := store.Pending(context.Background(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := 3, len(messages); want != have {
+		t.Fatalf("want %d messages, have %d", want, have)
+	}
+	if want, have := `{"s":424}`, string(messages[0].Publishing.Body); want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+	if want, have := headers, messages[0].Publishing.Headers; !reflect.DeepEqual(want, have) {
+		t.Errorf("want headers %#v, have %#v", want, have)
+	}
+	if err := messages[0].Publishing.Headers.Validate(); err != nil {
+		t.Errorf("restored headers invalid: %v", err)
+	}
+	if want, have := `{"s":426}`, string(messages[1].Publishing.Body); want != have || messages[1].Err != nil {
+		t.Errorf("legacy row: want %s, have %s (%v)", want, have, messages[1].Err)
+	}
+	if messages[2].Err == nil {
+		t.Error("garbage row: want error, have none")
+	}
+
+	mock.ExpectExec(`DELETE FROM outbox WHERE id IN \(\$1, \$2\)`).
+		WithArgs(driver.Value(int64(1)), driver.Value(int64(2))).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	if err := store.Remove(context.Background(), 1, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
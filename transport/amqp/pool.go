@@ -0,0 +1,158 @@
+package amqp
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/streadway/amqp"
+)
+
+// ErrPoolClosed is returned by ChannelPool methods called after Close.
+var ErrPoolClosed = errors.New("channel pool closed")
+
+// ChannelOpener opens a new channel, e.g. conn.Channel of an
+// *amqp.Connection.
+type ChannelOpener func() (Channel, error)
+
+// ConnectionChannelOpener returns a ChannelOpener opening channels on conns
+// in turn, spreading the channels of a ChannelPool across connections.
+func ConnectionChannelOpener(conns ...*amqp.Connection) ChannelOpener {
+	var next uint32
+	return func() (Channel, error) {
+		conn := conns[int(atomic.AddUint32(&next, 1)-1)%len(conns)]
+		return conn.Channel()
+	}
+}
+
+// ChannelPool is a Channel publishing on a pool of channels, so concurrent
+// publishes aren't serialized on a single channel. Every publish has a
+// channel to itself. Channels are opened on demand, and replaced after
+// they were closed, e.g. by the broker after a failed publish, or a
+// publish on them failed with amqp.ErrClosed.
+type ChannelPool struct {
+	open  ChannelOpener
+	slots chan *poolSlot
+	all   []*poolSlot
+
+	mtx    sync.Mutex // guards closed
+	closed bool
+}
+
+type poolSlot struct {
+	ch   Channel
+	dead *int32 // set when ch is closed
+}
+
+// NewChannelPool returns a ChannelPool of up to size channels opened with
+// open. Close it when it is no longer used.
+func NewChannelPool(open ChannelOpener, size int) *ChannelPool {
+	p := &ChannelPool{
+		open:  open,
+		slots: make(chan *poolSlot, size),
+	}
+	for i := 0; i < size; i++ {
+		s := &poolSlot{}
+		p.all = append(p.all, s)
+		p.slots <- s
+	}
+	return p
+}
+
+// Publish implements Channel, publishing on a channel of the pool. It
+// blocks while all channels are in use.
+func (p *ChannelPool) Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	s := <-p.slots
+	defer func() { p.slots <- s }()
+
+	p.mtx.Lock()
+	closed := p.closed
+	p.mtx.Unlock()
+	if closed {
+		return ErrPoolClosed
+	}
+
+	if s.ch == nil || atomic.LoadInt32(s.dead) == 1 {
+		if err := p.replace(s); err != nil {
+			return err
+		}
+	}
+	err := s.ch.Publish(exchange, key, mandatory, immediate, msg)
+	if err == amqp.ErrClosed {
+		atomic.StoreInt32(s.dead, 1)
+	}
+	return err
+}
+
+// replace opens a new channel for s, closing its current one.
+func (p *ChannelPool) replace(s *poolSlot) error {
+	if s.ch != nil {
+		closeChannel(s.ch)
+	}
+	s.ch = nil
+	ch, err := p.open()
+	if err != nil {
+		return err
+	}
+	dead := new(int32)
+	if n, ok := ch.(interface {
+		NotifyClose(c chan *amqp.Error) chan *amqp.Error
+	}); ok {
+		closed := n.NotifyClose(make(chan *amqp.Error, 1))
+		go func() {
+			<-closed
+			atomic.StoreInt32(dead, 1)
+		}()
+	}
+	s.ch, s.dead = ch, dead
+	return nil
+}
+
+// Consume implements Channel, consuming queue on a new channel outside of
+// the pool, which is closed when the connection is.
+func (p *ChannelPool) Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error) {
+	p.mtx.Lock()
+	closed := p.closed
+	p.mtx.Unlock()
+	if closed {
+		return nil, ErrPoolClosed
+	}
+	ch, err := p.open()
+	if err != nil {
+		return nil, err
+	}
+	return ch.Consume(queue, consumer, autoAck, exclusive, noLocal, noWait, args)
+}
+
+// Close closes the channels of the pool, waiting for publishes in progress
+// to complete.
+func (p *ChannelPool) Close() error {
+	p.mtx.Lock()
+	if p.closed {
+		p.mtx.Unlock()
+		return nil
+	}
+	p.closed = true
+	p.mtx.Unlock()
+
+	slots := make([]*poolSlot, 0, len(p.all))
+	for range p.all {
+		s := <-p.slots
+		if s.ch != nil {
+			closeChannel(s.ch)
+			s.ch = nil
+		}
+		slots = append(slots, s)
+	}
+	for _, s := range slots {
+		p.slots <- s
+	}
+	return nil
+}
+
+// closeChannel closes ch if it has a Close method, like *amqp.Channel.
+func closeChannel(ch Channel) {
+	if c, ok := ch.(interface{ Close() error }); ok {
+		c.Close()
+	}
+}
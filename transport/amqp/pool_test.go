@@ -0,0 +1,126 @@
+package amqp_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+// poolChannel is a channel of a ChannelPool under test.
+type poolChannel struct {
+	countingChannel
+	pool   *poolChannels
+	closed chan *amqp.Error
+	err    error
+}
+
+func (ch *poolChannel) Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	if ch.err != nil {
+		return ch.err
+	}
+	n := atomic.AddInt32(&ch.pool.active, 1)
+	defer atomic.AddInt32(&ch.pool.active, -1)
+	for {
+		max := atomic.LoadInt32(&ch.pool.max)
+		if n <= max || atomic.CompareAndSwapInt32(&ch.pool.max, max, n) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+	return ch.countingChannel.Publish(exchange, key, mandatory, immediate, msg)
+}
+
+func (ch *poolChannel) NotifyClose(c chan *amqp.Error) chan *amqp.Error {
+	ch.closed = c
+	return c
+}
+
+func (ch *poolChannel) Close() error {
+	atomic.AddInt32(&ch.pool.closed, 1)
+	return nil
+}
+
+// poolChannels opens poolChannels.
+type poolChannels struct {
+	mtx      sync.Mutex
+	opened   []*poolChannel
+	active   int32
+	max      int32
+	closed   int32
+	failWith error
+}
+
+func (p *poolChannels) open() (amqptransport.Channel, error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	ch := &poolChannel{pool: p, err: p.failWith}
+	p.opened = append(p.opened, ch)
+	return ch, nil
+}
+
+func TestChannelPoolConcurrency(t *testing.T) {
+	channels := &poolChannels{}
+	pool := amqptransport.NewChannelPool(channels.open, 3)
+	defer pool.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 12; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := pool.Publish("", "squadrons", false, false, amqp.Publishing{}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if want, have := 3, len(channels.opened); want != have {
+		t.Errorf("want %d channels, have %d", want, have)
+	}
+	if want, have := int32(3), channels.max; want != have {
+		t.Errorf("want %d concurrent publishes, have %d", want, have)
+	}
+}
+
+func TestChannelPoolReplacesClosedChannels(t *testing.T) {
+	channels := &poolChannels{failWith: amqp.ErrClosed}
+	pool := amqptransport.NewChannelPool(channels.open, 1)
+
+	if want, have := amqp.ErrClosed, pool.Publish("", "squadrons", false, false, amqp.Publishing{}); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	channels.failWith = nil
+	if err := pool.Publish("", "squadrons", false, false, amqp.Publishing{}); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := 2, len(channels.opened); want != have {
+		t.Fatalf("want %d channels, have %d", want, have)
+	}
+
+	// The broker closes the channel.
+	channels.opened[1].closed <- &amqp.Error{Code: amqp.ChannelError}
+	close(channels.opened[1].closed)
+	deadline := time.Now().Add(time.Second)
+	for len(channels.opened) < 3 && time.Now().Before(deadline) {
+		if err := pool.Publish("", "squadrons", false, false, amqp.Publishing{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want, have := 3, len(channels.opened); want != have {
+		t.Errorf("want %d channels, have %d", want, have)
+	}
+
+	pool.Close()
+	if want, have := int32(3), atomic.LoadInt32(&channels.closed); want != have {
+		t.Errorf("want %d channels closed, have %d", want, have)
+	}
+	if want, have := amqptransport.ErrPoolClosed, pool.Publish("", "squadrons", false, false, amqp.Publishing{}); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
@@ -0,0 +1,41 @@
+package amqp
+
+import (
+	"context"
+
+	"github.com/streadway/amqp"
+)
+
+// SetPublishPriority returns a RequestFunc that sets the Priority field of
+// an AMQP Publishing. Priorities only take effect in priority queues, see
+// PriorityQueueTopology.
+func SetPublishPriority(priority uint8) RequestFunc {
+	return func(ctx context.Context, pub *amqp.Publishing, d *amqp.Delivery) context.Context {
+		pub.Priority = priority
+		return ctx
+	}
+}
+
+// SetReplyPriorityFromDelivery returns a RequestFunc that sets the Priority
+// field of the reply to the priority of the delivery, so replies to
+// latency-sensitive requests jump the queue of the publisher, too.
+// It is designed to be used by Subscribers.
+func SetReplyPriorityFromDelivery() RequestFunc {
+	return func(ctx context.Context, pub *amqp.Publishing, d *amqp.Delivery) context.Context {
+		pub.Priority = d.Priority
+		return ctx
+	}
+}
+
+// PriorityQueueTopology returns a function declaring the durable priority
+// queue, delivering messages with a higher Priority, up to maxPriority,
+// first. RabbitMQ recommends a maxPriority of at most 10. It can be passed
+// to ConnectionManagerTopology.
+func PriorityQueueTopology(queue string, maxPriority uint8) func(*amqp.Channel) error {
+	return func(ch *amqp.Channel) error {
+		_, err := ch.QueueDeclare(queue, true, false, false, false, amqp.Table{
+			"x-max-priority": int32(maxPriority),
+		})
+		return err
+	}
+}
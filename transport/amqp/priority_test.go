@@ -0,0 +1,37 @@
+package amqp_test
+
+import (
+	"testing"
+
+	"github.com/streadway/amqp"
+
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+func TestReplyPriority(t *testing.T) {
+	for _, testcase := range []struct {
+		name   string
+		before amqptransport.RequestFunc
+		want   uint8
+	}{
+		{"static", amqptransport.SetPublishPriority(3), 3},
+		{"from delivery", amqptransport.SetReplyPriorityFromDelivery(), 7},
+	} {
+		t.Run(testcase.name, func(t *testing.T) {
+			outputChan := make(chan amqp.Publishing, 1)
+			sub := amqptransport.NewSubscriber(
+				testEndpoint,
+				testReqDecoder,
+				amqptransport.EncodeJSONResponse,
+				amqptransport.SubscriberBefore(testcase.before),
+			)
+			sub.ServeDelivery(&mockChannel{f: nullFunc, c: outputChan})(&amqp.Delivery{
+				Priority: 7,
+				Body:     []byte(`{"s":437}`),
+			})
+			if want, have := testcase.want, (<-outputChan).Priority; want != have {
+				t.Errorf("incorrect priority, want %d, have %d", want, have)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package amqp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/streadway/amqp"
+)
+
+// ProtoContentType is the content type of protocol buffer payloads.
+const ProtoContentType = "application/x-protobuf"
+
+// EncodeProtoResponse marshals the response, which must be a proto.Message,
+// as part of the payload of the AMQP Publishing object, and sets its
+// ContentType to ProtoContentType. The ContentEncoding is cleared, as the
+// payload is not compressed.
+func EncodeProtoResponse(
+	ctx context.Context,
+	pub *amqp.Publishing,
+	response interface{},
+) error {
+	return encodeProto(pub, response)
+}
+
+// EncodeProtoRequest marshals the request, which must be a proto.Message,
+// as part of the payload of the AMQP Publishing object, and sets its
+// ContentType to ProtoContentType. It is designed to be used in Publishers.
+func EncodeProtoRequest(
+	ctx context.Context,
+	pub *amqp.Publishing,
+	request interface{},
+) error {
+	return encodeProto(pub, request)
+}
+
+func encodeProto(pub *amqp.Publishing, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("%T is not a proto.Message", v)
+	}
+	b, err := proto.Marshal(m)
+	if err != nil {
+		return err
+	}
+	pub.Body = b
+	pub.ContentType = ProtoContentType
+	pub.ContentEncoding = ""
+	return nil
+}
+
+// DecodeProtoRequest returns a DecodeRequestFunc unmarshaling the payload
+// of the delivery into the message returned by newRequest, e.g.
+//
+//	amqptransport.DecodeProtoRequest(func() proto.Message { return &pb.CreateRequest{} })
+//
+// Deliveries with a ContentType other than ProtoContentType are rejected.
+func DecodeProtoRequest(newRequest func() proto.Message) DecodeRequestFunc {
+	return func(ctx context.Context, d *amqp.Delivery) (interface{}, error) {
+		return decodeProto(d, newRequest())
+	}
+}
+
+// DecodeProtoResponse returns a DecodeResponseFunc unmarshaling the payload
+// of the reply into the message returned by newResponse. It is designed to
+// be used in Publishers.
+func DecodeProtoResponse(newResponse func() proto.Message) DecodeResponseFunc {
+	return func(ctx context.Context, d *amqp.Delivery) (interface{}, error) {
+		return decodeProto(d, newResponse())
+	}
+}
+
+func decodeProto(d *amqp.Delivery, m proto.Message) (proto.Message, error) {
+	if ct := mediaType(d.ContentType); ct != "" && ct != ProtoContentType {
+		return nil, UnsupportedContentTypeError{ContentType: d.ContentType}
+	}
+	if err := proto.Unmarshal(d.Body, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
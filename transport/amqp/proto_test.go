@@ -0,0 +1,38 @@
+package amqp_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/streadway/amqp"
+
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+func TestProtoRoundTrip(t *testing.T) {
+	var pub amqp.Publishing
+	if err := amqptransport.EncodeProtoResponse(context.Background(), &pub, &wrappers.StringValue{Value: "husky"}); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := amqptransport.ProtoContentType, pub.ContentType; want != have {
+		t.Errorf("incorrect content type, want %q, have %q", want, have)
+	}
+
+	decode := amqptransport.DecodeProtoRequest(func() proto.Message { return &wrappers.StringValue{} })
+	request, err := decode(context.Background(), &amqp.Delivery{ContentType: pub.ContentType, Body: pub.Body})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := "husky", request.(*wrappers.StringValue).Value; want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+
+	if _, err := decode(context.Background(), &amqp.Delivery{ContentType: "application/json"}); err == nil {
+		t.Error("want error for JSON delivery, have nil")
+	}
+	if err := amqptransport.EncodeProtoRequest(context.Background(), &pub, "husky"); err == nil {
+		t.Error("want error for string request, have nil")
+	}
+}
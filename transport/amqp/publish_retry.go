@@ -0,0 +1,71 @@
+package amqp
+
+import (
+	"context"
+	"time"
+
+	"github.com/streadway/amqp"
+
+	"github.com/inturn/kit/util/backoff"
+)
+
+// ChannelAcquirer returns a channel to publish on in place of one that
+// failed, e.g. from a pool.
+type ChannelAcquirer func(ctx context.Context) (Channel, error)
+
+// SubscriberPublishRetry makes the subscriber retry publishing a reply up
+// to maxRetries times, waiting as long as delay decides before every
+// retry, if publishing fails, e.g. because of a transient channel error.
+// Replies are not retried on a closed channel. Only once the reply can't be
+// published, the error is passed to the error encoder. By default, replies
+// aren't retried.
+func SubscriberPublishRetry(maxRetries int, delay backoff.Strategy) SubscriberOption {
+	return func(s *Subscriber) {
+		s.publishRetries = maxRetries
+		s.publishDelay = delay
+	}
+}
+
+// SubscriberPublishFallback sets the function acquiring another channel to
+// publish a reply on once publishing it on the channel passed to
+// ServeDelivery failed, including the retries set by
+// SubscriberPublishRetry. The reply is published once on the acquired
+// channel before the error is passed to the error encoder.
+func SubscriberPublishFallback(acquire ChannelAcquirer) SubscriberOption {
+	return func(s *Subscriber) { s.publishFallback = acquire }
+}
+
+// publishWithRetry publishes the reply pub on ch, retrying and falling back
+// to another channel as configured.
+func (s Subscriber) publishWithRetry(
+	ctx context.Context,
+	deliv *amqp.Delivery,
+	ch Channel,
+	pub *amqp.Publishing,
+) error {
+	err := publishReply(ctx, deliv, ch, pub)
+	if err != nil && s.publishRetries > 0 {
+		delay := backoff.Constant(0)
+		if s.publishDelay != nil {
+			delay = s.publishDelay
+		}
+		b := backoff.New(delay)
+		for i := 0; i < s.publishRetries && err != nil && err != amqp.ErrClosed; i++ {
+			select {
+			case <-time.After(b.Next()):
+			case <-ctx.Done():
+				return err
+			}
+			err = publishReply(ctx, deliv, ch, pub)
+		}
+	}
+	if err != nil && s.publishFallback != nil {
+		fallback, aerr := s.publishFallback(ctx)
+		if aerr != nil {
+			s.handleError(ctx, ErrorStagePublish, aerr)
+			return err
+		}
+		err = publishReply(ctx, deliv, fallback, pub)
+	}
+	return err
+}
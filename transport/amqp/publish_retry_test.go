@@ -0,0 +1,74 @@
+package amqp_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+
+	amqptransport "github.com/inturn/kit/transport/amqp"
+	"github.com/inturn/kit/util/backoff"
+)
+
+// flakyChannel fails the first failures publishes with err.
+type flakyChannel struct {
+	countingChannel
+	failures int
+	err      error
+}
+
+func (ch *flakyChannel) Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	if ch.failures > 0 {
+		ch.failures--
+		return ch.err
+	}
+	return ch.countingChannel.Publish(exchange, key, mandatory, immediate, msg)
+}
+
+func TestSubscriberPublishRetry(t *testing.T) {
+	for _, testcase := range []struct {
+		name      string
+		failures  int
+		err       error
+		fallback  bool
+		published int32
+		fallbacks int32
+		failed    bool
+	}{
+		{"recovered", 2, errors.New("dummy"), false, 1, 0, false},
+		{"exhausted", 4, errors.New("dummy"), false, 0, 0, true},
+		{"fallback", 4, errors.New("dummy"), true, 0, 1, false},
+		{"closed", 1, amqp.ErrClosed, true, 0, 1, false},
+	} {
+		t.Run(testcase.name, func(t *testing.T) {
+			var failed bool
+			fallback := &countingChannel{}
+			options := []amqptransport.SubscriberOption{
+				amqptransport.SubscriberPublishRetry(3, backoff.Constant(time.Millisecond)),
+				amqptransport.SubscriberErrorEncoder(func(context.Context, error, *amqp.Delivery, amqptransport.Channel, *amqp.Publishing) {
+					failed = true
+				}),
+			}
+			if testcase.fallback {
+				options = append(options, amqptransport.SubscriberPublishFallback(func(context.Context) (amqptransport.Channel, error) {
+					return fallback, nil
+				}))
+			}
+			sub := amqptransport.NewSubscriber(testEndpoint, testReqDecoder, amqptransport.EncodeJSONResponse, options...)
+			ch := &flakyChannel{failures: testcase.failures, err: testcase.err}
+			sub.ServeDelivery(ch)(&amqp.Delivery{Body: []byte(`{"s":436}`)})
+
+			if want, have := testcase.published, ch.published; want != have {
+				t.Errorf("want %d replies, have %d", want, have)
+			}
+			if want, have := testcase.fallbacks, fallback.published; want != have {
+				t.Errorf("want %d replies on the fallback channel, have %d", want, have)
+			}
+			if want, have := testcase.failed, failed; want != have {
+				t.Errorf("want failed %v, have %v", want, have)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package amqp
 
 import (
 	"context"
+	"encoding/json"
 	"time"
 
 	"github.com/inturn/kit/endpoint"
@@ -15,13 +16,16 @@ const maxCorrelationIdLength = 255
 // Publisher wraps an AMQP channel and queue, and provides a method that
 // implements endpoint.Endpoint.
 type Publisher struct {
-	ch      Channel
-	q       *amqp.Queue
-	enc     EncodeRequestFunc
-	dec     DecodeResponseFunc
-	before  []RequestFunc
-	after   []PublisherResponseFunc
-	timeout time.Duration
+	ch        Channel
+	q         *amqp.Queue
+	enc       EncodeRequestFunc
+	dec       DecodeResponseFunc
+	before    []RequestFunc
+	after     []PublisherResponseFunc
+	finalizer []PublisherFinalizerFunc
+	timeout   time.Duration
+	router    *ResponseRouter
+	keyFunc   PublishKeyFunc
 }
 
 // NewPublisher constructs a usable Publisher for a single remote method.
@@ -49,38 +53,67 @@ func NewPublisher(
 type PublisherOption func(*Publisher)
 
 // PublisherBefore sets the RequestFuncs that are applied to the outgoing AMQP
-// request before it's invoked.
+// request before it's invoked. They are applied after the request is
+// encoded, so they may set the headers, priority or expiration of the
+// Publishing, e.g. with SetPublishPriority or SetExpirationFromDeadline.
 func PublisherBefore(before ...RequestFunc) PublisherOption {
 	return func(p *Publisher) { p.before = append(p.before, before...) }
 }
 
-// PublisherAfter sets the ClientResponseFuncs applied to the incoming AMQP
+// PublisherAfter sets the PublisherResponseFuncs applied to the incoming AMQP
 // request prior to it being decoded. This is useful for obtaining anything off
 // of the response and adding onto the context prior to decoding.
 func PublisherAfter(after ...PublisherResponseFunc) PublisherOption {
 	return func(p *Publisher) { p.after = append(p.after, after...) }
 }
 
-// PublisherTimeout sets the available timeout for an AMQP request.
+// PublisherTimeout sets the available timeout for an AMQP request, which is
+// the deadline of the request context. The default is 10 seconds.
 func PublisherTimeout(timeout time.Duration) PublisherOption {
 	return func(p *Publisher) { p.timeout = timeout }
 }
 
+// PublisherResponseRouter sets the ResponseRouter replies are received
+// with. Requests are sent with the queue of the router as their ReplyTo,
+// instead of the queue of the Publisher, which may then be nil, and wait for
+// their reply until the PublisherTimeout elapses.
+func PublisherResponseRouter(r *ResponseRouter) PublisherOption {
+	return func(p *Publisher) { p.router = r }
+}
+
+// PublisherFinalizer is executed at the end of every AMQP request.
+// By default, no finalizer is registered.
+func PublisherFinalizer(f ...PublisherFinalizerFunc) PublisherOption {
+	return func(p *Publisher) { p.finalizer = append(p.finalizer, f...) }
+}
+
 // Endpoint returns a usable endpoint that invokes the remote endpoint.
 func (p Publisher) Endpoint() endpoint.Endpoint {
-	return func(ctx context.Context, request interface{}) (interface{}, error) {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
 		ctx, cancel := context.WithTimeout(ctx, p.timeout)
 		defer cancel()
 
+		if len(p.finalizer) > 0 {
+			defer func() {
+				for _, f := range p.finalizer {
+					f(ctx, err)
+				}
+			}()
+		}
+
 		pub := amqp.Publishing{
-			ReplyTo:       p.q.Name,
+			ReplyTo:       p.replyTo(),
 			CorrelationId: randomString(randInt(5, maxCorrelationIdLength)),
 		}
 
-		if err := p.enc(ctx, &pub, request); err != nil {
+		if err = p.enc(ctx, &pub, request); err != nil {
 			return nil, err
 		}
 
+		if p.keyFunc != nil {
+			ctx = context.WithValue(ctx, ContextKeyPublishKey, p.keyFunc(ctx, request))
+		}
+
 		for _, f := range p.before {
 			ctx = f(ctx, &pub, nil)
 		}
@@ -93,7 +126,7 @@ func (p Publisher) Endpoint() endpoint.Endpoint {
 		for _, f := range p.after {
 			ctx = f(ctx, deliv)
 		}
-		response, err := p.dec(ctx, deliv)
+		response, err = p.dec(ctx, deliv)
 		if err != nil {
 			return nil, err
 		}
@@ -102,6 +135,35 @@ func (p Publisher) Endpoint() endpoint.Endpoint {
 	}
 }
 
+// EncodeJSONRequest marshals the request as JSON as part of the payload of
+// the AMQP Publishing object.
+func EncodeJSONRequest(
+	ctx context.Context,
+	pub *amqp.Publishing,
+	request interface{},
+) error {
+	b, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	pub.Body = b
+	return nil
+}
+
+// EncodeNopRequest is a request function that does nothing.
+func EncodeNopRequest(
+	ctx context.Context,
+	pub *amqp.Publishing,
+	request interface{},
+) error {
+	return nil
+}
+
+// PublisherFinalizerFunc can be used to perform work at the end of a client
+// AMQP request, after the response is returned or the request has failed. The
+// principal intended use is for error logging and closing tracing spans.
+type PublisherFinalizerFunc func(ctx context.Context, err error)
+
 // publishAndConsumeFirstMatchingResponse publishes the specified Publishing
 // and returns the first Delivery object with the matching correlationId.
 // If the context times out while waiting for a reply, an error will be returned.
@@ -109,14 +171,38 @@ func (p Publisher) publishAndConsumeFirstMatchingResponse(
 	ctx context.Context,
 	pub *amqp.Publishing,
 ) (*amqp.Delivery, error) {
-	err := p.ch.Publish(
-		getPublishExchange(ctx),
-		getPublishKey(ctx),
-		false, //mandatory
-		false, //immediate
-		*pub,
-	)
-	if err != nil {
+	if p.router != nil {
+		return p.publishAndWaitForResponse(ctx, pub)
+	}
+
+	// Direct reply-to requires consuming the pseudo-queue in no-ack mode
+	// before publishing the request.
+	if isDirectReplyTo(p.q.Name) {
+		consumer := randomString(32)
+		msg, err := p.ch.Consume(
+			p.q.Name,
+			consumer,
+			true,  //autoAck
+			false, //exclusive
+			false, //noLocal
+			false, //noWait
+			nil,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if c, ok := p.ch.(interface {
+			Cancel(consumer string, noWait bool) error
+		}); ok {
+			defer c.Cancel(consumer, false)
+		}
+		if err := p.publish(ctx, pub); err != nil {
+			return nil, err
+		}
+		return firstMatchingResponse(ctx, msg, pub.CorrelationId, true)
+	}
+
+	if err := p.publish(ctx, pub); err != nil {
 		return nil, err
 	}
 	autoAck := getConsumeAutoAck(ctx)
@@ -133,11 +219,65 @@ func (p Publisher) publishAndConsumeFirstMatchingResponse(
 	if err != nil {
 		return nil, err
 	}
+	return firstMatchingResponse(ctx, msg, pub.CorrelationId, autoAck)
+}
+
+// publishAndWaitForResponse publishes the specified Publishing and waits
+// for the ResponseRouter to receive the reply with its correlationId.
+func (p Publisher) publishAndWaitForResponse(
+	ctx context.Context,
+	pub *amqp.Publishing,
+) (*amqp.Delivery, error) {
+	c, err := p.router.register(pub.CorrelationId)
+	if err != nil {
+		return nil, err
+	}
+	defer p.router.deregister(pub.CorrelationId)
+
+	if err := p.publish(ctx, pub); err != nil {
+		return nil, err
+	}
+	select {
+	case d, ok := <-c:
+		if !ok {
+			return nil, ErrResponseRouterClosed
+		}
+		return &d, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// replyTo returns the queue replies are sent to.
+func (p Publisher) replyTo() string {
+	if p.router != nil {
+		return p.router.Queue()
+	}
+	return p.q.Name
+}
 
+func (p Publisher) publish(ctx context.Context, pub *amqp.Publishing) error {
+	return p.ch.Publish(
+		getPublishExchange(ctx),
+		getPublishKey(ctx),
+		getPublishMandatory(ctx),
+		getPublishImmediate(ctx),
+		*pub,
+	)
+}
+
+// firstMatchingResponse returns the first Delivery of msg with the
+// correlationId, acknowledging it unless autoAck is set.
+func firstMatchingResponse(
+	ctx context.Context,
+	msg <-chan amqp.Delivery,
+	correlationId string,
+	autoAck bool,
+) (*amqp.Delivery, error) {
 	for {
 		select {
 		case d := <-msg:
-			if d.CorrelationId == pub.CorrelationId {
+			if d.CorrelationId == correlationId {
 				if !autoAck {
 					d.Ack(false) //multiple
 				}
@@ -148,5 +288,4 @@ func (p Publisher) publishAndConsumeFirstMatchingResponse(
 			return nil, ctx.Err()
 		}
 	}
-
 }
@@ -224,3 +224,90 @@ func TestSuccessfulPublisher(t *testing.T) {
 		t.Errorf("want %s, have %s", want, have)
 	}
 }
+
+// TestPublisherFinalizer ensures that finalizers receive the request error.
+func TestPublisherFinalizer(t *testing.T) {
+	ch := &mockChannel{
+		f:          nullFunc,
+		c:          make(chan amqp.Publishing, 1),
+		deliveries: []amqp.Delivery{}, // no reply from mock subscriber
+	}
+	q := &amqp.Queue{Name: "some queue"}
+
+	finalizerErr := make(chan error, 1)
+	pub := amqptransport.NewPublisher(
+		ch,
+		q,
+		func(context.Context, *amqp.Publishing, interface{}) error { return nil },
+		func(context.Context, *amqp.Delivery) (response interface{}, err error) {
+			return struct{}{}, nil
+		},
+		amqptransport.PublisherTimeout(10*time.Millisecond),
+		amqptransport.PublisherFinalizer(func(ctx context.Context, err error) {
+			finalizerErr <- err
+		}),
+	)
+
+	_, err := pub.Endpoint()(context.Background(), struct{}{})
+
+	select {
+	case have := <-finalizerErr:
+		if want := err; want != have {
+			t.Errorf("want %v, have %v", want, have)
+		}
+
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("timed out waiting for finalizer")
+	}
+}
+
+func TestEncodeJSONRequest(t *testing.T) {
+	reqChan := make(chan amqp.Publishing, 1)
+	ch := &mockChannel{f: nullFunc, c: reqChan}
+	pub := amqptransport.NewPublisher(
+		ch,
+		&amqp.Queue{Name: "some queue"},
+		amqptransport.EncodeJSONRequest,
+		func(context.Context, *amqp.Delivery) (interface{}, error) { return nil, nil },
+		amqptransport.PublisherTimeout(10*time.Millisecond),
+	)
+	pub.Endpoint()(context.Background(), testReq{437})
+
+	var req testReq
+	if err := json.Unmarshal((<-reqChan).Body, &req); err != nil {
+		t.Fatal(err)
+	}
+	if want, have := 437, req.Squadron; want != have {
+		t.Errorf("want %d, have %d", want, have)
+	}
+}
+
+func TestPublisherBeforeSetsProperties(t *testing.T) {
+	reqChan := make(chan amqp.Publishing, 1)
+	ch := &mockChannel{f: nullFunc, c: reqChan}
+	pub := amqptransport.NewPublisher(
+		ch,
+		&amqp.Queue{Name: "some queue"},
+		testReqEncoder,
+		testResDeliveryDecoder,
+		amqptransport.PublisherBefore(
+			amqptransport.SetPublishPriority(5),
+			amqptransport.SetPublishExpiration(1500*time.Millisecond),
+		),
+		amqptransport.PublisherTimeout(10*time.Millisecond),
+	)
+	if _, err := pub.Endpoint()(context.Background(), testReq{Squadron: 436}); err != context.DeadlineExceeded {
+		t.Errorf("want %v, have %v", context.DeadlineExceeded, err)
+	}
+
+	publishing := <-reqChan
+	if want, have := uint8(5), publishing.Priority; want != have {
+		t.Errorf("want priority %d, have %d", want, have)
+	}
+	if want, have := "1500", publishing.Expiration; want != have {
+		t.Errorf("want expiration %q, have %q", want, have)
+	}
+	if want, have := defaultContentType, publishing.ContentType; want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+}
@@ -0,0 +1,206 @@
+package amqp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/inturn/kit/log"
+	"github.com/inturn/kit/metrics"
+	"github.com/inturn/kit/transport"
+	"github.com/streadway/amqp"
+)
+
+// QueueMonitor periodically inspects queues and sets the number of messages
+// ready in each of them, i.e. the backlog of its consumers, and its number
+// of consumers in gauges, e.g. to alert on backlog or to autoscale
+// consumers. Queues are inspected with a QueueInspector: an *amqp.Channel,
+// which declares them passively, or the RabbitMQ management API, see
+// NewManagementClient. Both gauges are set with the label value "queue".
+//
+// A failed passive declare closes the channel, so inspect queues on a
+// channel of their own.
+type QueueMonitor struct {
+	inspector    QueueInspector
+	queues       []string
+	messages     metrics.Gauge
+	consumers    metrics.Gauge
+	interval     time.Duration
+	errorHandler transport.ErrorHandler
+}
+
+// QueueMonitorOption sets an optional parameter for queue monitors.
+type QueueMonitorOption func(*QueueMonitor)
+
+// QueueMonitorInterval sets how often Run inspects the queues. The default
+// is 15 seconds.
+func QueueMonitorInterval(interval time.Duration) QueueMonitorOption {
+	return func(m *QueueMonitor) { m.interval = interval }
+}
+
+// QueueMonitorErrorHandler is used to handle errors inspecting queues in
+// Run, which keeps polling. By default, errors are ignored.
+func QueueMonitorErrorHandler(errorHandler transport.ErrorHandler) QueueMonitorOption {
+	return func(m *QueueMonitor) { m.errorHandler = errorHandler }
+}
+
+// NewQueueMonitor returns a QueueMonitor inspecting queues with inspector
+// and setting their messages ready and consumers in the gauges.
+func NewQueueMonitor(
+	inspector QueueInspector,
+	queues []string,
+	messages, consumers metrics.Gauge,
+	options ...QueueMonitorOption,
+) *QueueMonitor {
+	m := &QueueMonitor{
+		inspector:    inspector,
+		queues:       queues,
+		messages:     messages,
+		consumers:    consumers,
+		interval:     15 * time.Second,
+		errorHandler: transport.NewLogErrorHandler(log.NewNopLogger()),
+	}
+	for _, option := range options {
+		option(m)
+	}
+	return m
+}
+
+// Poll inspects every queue once and sets the gauges. Gauges of queues
+// that fail to be inspected keep their previous value; Poll returns the
+// first error.
+func (m *QueueMonitor) Poll() error {
+	var first error
+	for _, queue := range m.queues {
+		q, err := m.inspector.QueueInspect(queue)
+		if err != nil {
+			if first == nil {
+				first = err
+			}
+			continue
+		}
+		m.messages.With("queue", queue).Set(float64(q.Messages))
+		m.consumers.With("queue", queue).Set(float64(q.Consumers))
+	}
+	return first
+}
+
+// Run polls the queues until ctx is done, and returns ctx.Err().
+func (m *QueueMonitor) Run(ctx context.Context) error {
+	for {
+		if err := m.Poll(); err != nil {
+			m.errorHandler.Handle(ctx, err)
+		}
+		select {
+		case <-time.After(m.interval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// ManagementError is returned by the client of NewManagementClient for
+// failed requests.
+type ManagementError struct {
+	StatusCode int
+	Reason     string `json:"reason"`
+}
+
+// Error implements the error interface.
+func (e ManagementError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("management api: %s", http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("management api: %s", e.Reason)
+}
+
+type managementClient struct {
+	url                string
+	vhost              string
+	username, password string
+	client             *http.Client
+	timeout            time.Duration
+}
+
+// ManagementClientOption sets an optional parameter for
+// NewManagementClient.
+type ManagementClientOption func(*managementClient)
+
+// ManagementHTTPClient sets the HTTP client used for requests to the
+// management API, e.g. to set TLS options. The default is
+// http.DefaultClient.
+func ManagementHTTPClient(client *http.Client) ManagementClientOption {
+	return func(c *managementClient) { c.client = client }
+}
+
+// ManagementVhost sets the virtual host of the inspected queues. The
+// default is "/".
+func ManagementVhost(vhost string) ManagementClientOption {
+	return func(c *managementClient) { c.vhost = vhost }
+}
+
+// ManagementTimeout sets the timeout of requests to the management API.
+// The default is 10 seconds.
+func ManagementTimeout(timeout time.Duration) ManagementClientOption {
+	return func(c *managementClient) { c.timeout = timeout }
+}
+
+// NewManagementClient returns a QueueInspector using the HTTP API of the
+// RabbitMQ management plugin at baseURL, e.g. "http://localhost:15672",
+// authenticating with username and password. Unlike a passive declare, it
+// doesn't need a channel and reports queues without closing anything when
+// they don't exist. Messages of the returned amqp.Queue are the messages
+// ready, as with a passive declare. The statistics of the management API
+// are only refreshed every few seconds.
+func NewManagementClient(baseURL, username, password string, options ...ManagementClientOption) QueueInspector {
+	c := &managementClient{
+		url:      strings.TrimSuffix(baseURL, "/"),
+		vhost:    "/",
+		username: username,
+		password: password,
+		client:   http.DefaultClient,
+		timeout:  10 * time.Second,
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+func (c *managementClient) QueueInspect(name string) (amqp.Queue, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	req, err := http.NewRequest("GET", c.url+"/api/queues/"+url.PathEscape(c.vhost)+"/"+url.PathEscape(name), nil)
+	if err != nil {
+		return amqp.Queue{}, err
+	}
+	req = req.WithContext(ctx)
+	req.SetBasicAuth(c.username, c.password)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return amqp.Queue{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		e := ManagementError{StatusCode: resp.StatusCode}
+		json.NewDecoder(resp.Body).Decode(&e)
+		return amqp.Queue{}, e
+	}
+	var res struct {
+		Name          string `json:"name"`
+		MessagesReady int    `json:"messages_ready"`
+		Consumers     int    `json:"consumers"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return amqp.Queue{}, err
+	}
+	return amqp.Queue{Name: res.Name, Messages: res.MessagesReady, Consumers: res.Consumers}, nil
+}
@@ -0,0 +1,90 @@
+package amqp_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/streadway/amqp"
+
+	"github.com/inturn/kit/metrics"
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+type recordingGauge struct {
+	r   *recorder
+	lvs []string
+}
+
+func (g recordingGauge) With(lvs ...string) metrics.Gauge {
+	return recordingGauge{g.r, append(g.lvs[:len(g.lvs):len(g.lvs)], lvs...)}
+}
+
+func (g recordingGauge) Set(value float64) { g.r.record(g.lvs, value) }
+
+func (g recordingGauge) Add(delta float64) { g.r.record(g.lvs, delta) }
+
+type queueInspectorFunc func(name string) (amqp.Queue, error)
+
+func (f queueInspectorFunc) QueueInspect(name string) (amqp.Queue, error) { return f(name) }
+
+func TestQueueMonitorPoll(t *testing.T) {
+	messages, consumers := newRecorder(), newRecorder()
+	inspector := queueInspectorFunc(func(name string) (amqp.Queue, error) {
+		if name == "missing" {
+			return amqp.Queue{}, errors.New("not found")
+		}
+		return amqp.Queue{Name: name, Messages: 42, Consumers: 3}, nil
+	})
+	m := amqptransport.NewQueueMonitor(
+		inspector,
+		[]string{"orders", "missing"},
+		recordingGauge{r: messages},
+		recordingGauge{r: consumers},
+	)
+
+	if err := m.Poll(); err == nil {
+		t.Error("want error for missing queue, have none")
+	}
+	if want, have := []float64{42}, messages.get("queue", "orders"); len(have) != 1 || want[0] != have[0] {
+		t.Errorf("want messages %v, have %v", want, have)
+	}
+	if want, have := []float64{3}, consumers.get("queue", "orders"); len(have) != 1 || want[0] != have[0] {
+		t.Errorf("want consumers %v, have %v", want, have)
+	}
+	if have := messages.get("queue", "missing"); len(have) != 0 {
+		t.Errorf("want no messages for missing queue, have %v", have)
+	}
+}
+
+func TestManagementClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); user != "guest" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.EscapedPath() {
+		case "/api/queues/%2F/orders":
+			w.Write([]byte(`{"name":"orders","messages":50,"messages_ready":42,"consumers":3}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"Object Not Found","reason":"Not Found"}`))
+		}
+	}))
+	defer server.Close()
+
+	c := amqptransport.NewManagementClient(server.URL, "guest", "secret")
+	q, err := c.QueueInspect("orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := (amqp.Queue{Name: "orders", Messages: 42, Consumers: 3}), q; want != have {
+		t.Errorf("want %+v, have %+v", want, have)
+	}
+
+	_, err = c.QueueInspect("missing")
+	if e, ok := err.(amqptransport.ManagementError); !ok || e.StatusCode != http.StatusNotFound {
+		t.Errorf("want not found ManagementError, have %v", err)
+	}
+}
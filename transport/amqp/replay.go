@@ -0,0 +1,273 @@
+package amqp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/streadway/amqp"
+
+	"github.com/inturn/kit/log"
+	"github.com/inturn/kit/ratelimit"
+)
+
+// GetChannel fetches single messages from queues, like *amqp.Channel.
+type GetChannel interface {
+	Get(queue string, autoAck bool) (amqp.Delivery, bool, error)
+}
+
+// ReplayFilter selects the dead-lettered deliveries a Replayer replays.
+type ReplayFilter func(d *amqp.Delivery) bool
+
+// ReplayHeader selects the deliveries whose header key equals value.
+func ReplayHeader(key string, value interface{}) ReplayFilter {
+	return func(d *amqp.Delivery) bool { return d.Headers[key] == value }
+}
+
+// ReplayMinAge selects the deliveries dead-lettered at least age ago, e.g.
+// to leave those of an ongoing incident alone.
+func ReplayMinAge(age time.Duration) ReplayFilter {
+	return func(d *amqp.Delivery) bool {
+		died, ok := lastDeath(d)
+		return ok && time.Since(died) >= age
+	}
+}
+
+// ReplayMaxAge selects the deliveries dead-lettered at most age ago, e.g.
+// to drop those that went stale.
+func ReplayMaxAge(age time.Duration) ReplayFilter {
+	return func(d *amqp.Delivery) bool {
+		died, ok := lastDeath(d)
+		return ok && time.Since(died) <= age
+	}
+}
+
+// ReplayReport counts the deliveries of a dead-letter queue handled by
+// Replayer.Replay.
+type ReplayReport struct {
+	// Replayed is the number of deliveries republished, or in dry-run
+	// mode, that would have been.
+	Replayed int
+	// Skipped is the number of deliveries left in the queue, because no
+	// filter selected them or their origin is unknown.
+	Skipped int
+}
+
+// Replayer republishes the deliveries of a dead-letter queue to the
+// exchange and routing key they were first dead-lettered from, as recorded
+// by RabbitMQ in their x-death header, e.g. once the bug that made them
+// fail was fixed:
+//
+//	r := amqptransport.NewReplayer(ch,
+//		amqptransport.ReplayerFilter(amqptransport.ReplayMaxAge(24*time.Hour)),
+//		amqptransport.ReplayerRate(rate.NewLimiter(100, 1)),
+//	)
+//	report, err := r.Replay(ctx, ch, "orders.dlq")
+//
+// The dead-letter history, x-death and the x-first-death and x-last-death
+// headers, is stripped from republished messages, so they are handled like
+// new ones, e.g. by RetryCount.
+type Replayer struct {
+	dst      Channel
+	filters  []ReplayFilter
+	limiter  ratelimit.Waiter
+	max      int
+	exchange *string
+	key      string
+	dryRun   bool
+	dec      DecodeRequestFunc
+	logger   log.Logger
+}
+
+// ReplayerOption sets an optional parameter for replayers.
+type ReplayerOption func(*Replayer)
+
+// ReplayerFilter adds filters selecting the deliveries to replay. A
+// delivery is replayed if all filters select it. By default, all
+// deliveries are replayed.
+func ReplayerFilter(filters ...ReplayFilter) ReplayerOption {
+	return func(r *Replayer) { r.filters = append(r.filters, filters...) }
+}
+
+// ReplayerRate limits the rate of republished messages with limiter, e.g.
+// a *rate.Limiter of golang.org/x/time/rate, so replaying a large backlog
+// doesn't overload its consumers. By default, the rate isn't limited.
+func ReplayerRate(limiter ratelimit.Waiter) ReplayerOption {
+	return func(r *Replayer) { r.limiter = limiter }
+}
+
+// ReplayerMax sets the maximum number of deliveries Replay replays. By
+// default, it replays until the queue is empty.
+func ReplayerMax(n int) ReplayerOption {
+	return func(r *Replayer) { r.max = n }
+}
+
+// ReplayerDestination republishes all messages to exchange with key,
+// instead of the exchange and routing key they were dead-lettered from,
+// e.g. to a parking queue. Deliveries without an x-death header are then
+// replayed too.
+func ReplayerDestination(exchange, key string) ReplayerOption {
+	return func(r *Replayer) { r.exchange, r.key = &exchange, key }
+}
+
+// ReplayerDryRun makes Replay log the deliveries it would replay, decoded
+// with dec if it isn't nil, to logger, instead of republishing them. All
+// deliveries are left in the queue.
+func ReplayerDryRun(dec DecodeRequestFunc, logger log.Logger) ReplayerOption {
+	return func(r *Replayer) { r.dryRun, r.dec, r.logger = true, dec, logger }
+}
+
+// NewReplayer returns a Replayer republishing messages on dst.
+func NewReplayer(dst Channel, options ...ReplayerOption) *Replayer {
+	r := &Replayer{
+		dst:    dst,
+		logger: log.NewNopLogger(),
+	}
+	for _, option := range options {
+		option(r)
+	}
+	return r
+}
+
+// Replay fetches the deliveries of queue from src one at a time, until the
+// queue is empty, ctx is done or the maximum set by ReplayerMax was
+// replayed, republishes the selected ones and acknowledges them. The other
+// deliveries are held unacknowledged, so they aren't fetched again, and
+// requeued once Replay returns, in their original order. Replay stops at
+// the first delivery failing to be republished, which is requeued too.
+func (r *Replayer) Replay(ctx context.Context, src GetChannel, queue string) (ReplayReport, error) {
+	var (
+		report ReplayReport
+		held   []amqp.Delivery
+	)
+	defer func() {
+		for _, d := range held {
+			d.Nack(false, true)
+		}
+	}()
+
+	for r.max <= 0 || report.Replayed < r.max {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		d, ok, err := src.Get(queue, false)
+		if err != nil {
+			return report, err
+		}
+		if !ok {
+			return report, nil
+		}
+
+		exchange, key, ok := r.destination(&d)
+		if !ok || !r.selects(&d) {
+			held = append(held, d)
+			report.Skipped++
+			continue
+		}
+
+		if r.dryRun {
+			held = append(held, d)
+			report.Replayed++
+			r.log(ctx, &d, exchange, key)
+			continue
+		}
+
+		if r.limiter != nil {
+			if err := r.limiter.Wait(ctx); err != nil {
+				held = append(held, d)
+				return report, err
+			}
+		}
+		pub := publishingOf(&d)
+		pub.Headers = stripDeaths(d.Headers)
+		if err := r.dst.Publish(exchange, key, false, false, pub); err != nil {
+			held = append(held, d)
+			return report, err
+		}
+		if err := d.Ack(false); err != nil {
+			return report, err
+		}
+		report.Replayed++
+	}
+	return report, nil
+}
+
+// destination returns the exchange and routing key d is republished to.
+func (r *Replayer) destination(d *amqp.Delivery) (string, string, bool) {
+	if r.exchange != nil {
+		return *r.exchange, r.key, true
+	}
+	deaths, _ := d.Headers["x-death"].([]interface{})
+	if len(deaths) == 0 {
+		return "", "", false
+	}
+	// RabbitMQ records the most recent death first; the original
+	// destination is that of the oldest.
+	death, ok := deaths[len(deaths)-1].(amqp.Table)
+	if !ok {
+		return "", "", false
+	}
+	exchange, _ := death["exchange"].(string)
+	keys, _ := death["routing-keys"].([]interface{})
+	if len(keys) == 0 {
+		return "", "", false
+	}
+	key, ok := keys[0].(string)
+	return exchange, key, ok
+}
+
+func (r *Replayer) selects(d *amqp.Delivery) bool {
+	for _, f := range r.filters {
+		if !f(d) {
+			return false
+		}
+	}
+	return true
+}
+
+// log logs d as a delivery that would be replayed.
+func (r *Replayer) log(ctx context.Context, d *amqp.Delivery, exchange, key string) {
+	keyvals := []interface{}{
+		"msg", "would replay",
+		"exchange", exchange,
+		"key", key,
+		"message_id", d.MessageId,
+		"deaths", DeathCount(d, ""),
+	}
+	if r.dec != nil {
+		request, err := r.dec(ctx, d)
+		if err != nil {
+			keyvals = append(keyvals, "err", err)
+		} else {
+			keyvals = append(keyvals, "request", fmt.Sprintf("%+v", request))
+		}
+	}
+	r.logger.Log(keyvals...)
+}
+
+// lastDeath returns the time d was last dead-lettered.
+func lastDeath(d *amqp.Delivery) (time.Time, bool) {
+	deaths, _ := d.Headers["x-death"].([]interface{})
+	if len(deaths) == 0 {
+		return time.Time{}, false
+	}
+	death, ok := deaths[0].(amqp.Table)
+	if !ok {
+		return time.Time{}, false
+	}
+	t, ok := death["time"].(time.Time)
+	return t, ok
+}
+
+// stripDeaths returns a copy of headers without the dead-letter history.
+func stripDeaths(headers amqp.Table) amqp.Table {
+	stripped := make(amqp.Table, len(headers))
+	for k, v := range headers {
+		if k == "x-death" || strings.HasPrefix(k, "x-first-death-") || strings.HasPrefix(k, "x-last-death-") {
+			continue
+		}
+		stripped[k] = v
+	}
+	return stripped
+}
@@ -0,0 +1,141 @@
+package amqp_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+
+	"github.com/inturn/kit/log"
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+// dlq is a dead-letter queue served by Get.
+type dlq struct {
+	deliveries []amqp.Delivery
+}
+
+func (q *dlq) Get(queue string, autoAck bool) (amqp.Delivery, bool, error) {
+	if len(q.deliveries) == 0 {
+		return amqp.Delivery{}, false, nil
+	}
+	d := q.deliveries[0]
+	q.deliveries = q.deliveries[1:]
+	return d, true, nil
+}
+
+func deadLettered(acker amqp.Acknowledger, body string, died time.Time, headers amqp.Table) amqp.Delivery {
+	if headers == nil {
+		headers = amqp.Table{}
+	}
+	headers["x-death"] = []interface{}{
+		amqp.Table{"queue": "orders.wait", "exchange": "", "routing-keys": []interface{}{"orders.wait"}, "count": int64(2), "time": died},
+		amqp.Table{"queue": "orders", "exchange": "shop", "routing-keys": []interface{}{"order.created"}, "count": int64(1), "time": died},
+	}
+	headers["x-first-death-queue"] = "orders"
+	return amqp.Delivery{Acknowledger: acker, Headers: headers, Body: []byte(body)}
+}
+
+func TestReplayer(t *testing.T) {
+	acker := &mockAcknowledger{}
+	now := time.Now()
+	src := &dlq{deliveries: []amqp.Delivery{
+		deadLettered(acker, "old", now.Add(-48*time.Hour), nil),
+		deadLettered(acker, "recent", now.Add(-time.Hour), amqp.Table{"x-tenant": "acme"}),
+		deadLettered(acker, "other tenant", now.Add(-time.Hour), amqp.Table{"x-tenant": "umbrella"}),
+		{Acknowledger: acker, Body: []byte("unknown origin")},
+	}}
+	dst := &routingChannel{}
+	r := amqptransport.NewReplayer(dst, amqptransport.ReplayerFilter(
+		amqptransport.ReplayMaxAge(24*time.Hour),
+		amqptransport.ReplayHeader("x-tenant", "acme"),
+	))
+
+	report, err := r.Replay(context.Background(), src, "orders.dlq")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := (amqptransport.ReplayReport{Replayed: 1, Skipped: 3}), report; want != have {
+		t.Errorf("want %+v, have %+v", want, have)
+	}
+	if want, have := 1, len(dst.published); want != have {
+		t.Fatalf("want %d published, have %d", want, have)
+	}
+	p := dst.published[0]
+	if want, have := "shop order.created", p.exchange+" "+p.key; want != have {
+		t.Errorf("want destination %q, have %q", want, have)
+	}
+	if want, have := "recent", string(p.msg.Body); want != have {
+		t.Errorf("want body %q, have %q", want, have)
+	}
+	for k := range p.msg.Headers {
+		if k == "x-death" || strings.HasPrefix(k, "x-first-death") {
+			t.Errorf("dead-letter header %q not stripped", k)
+		}
+	}
+	if want, have := "acme", p.msg.Headers["x-tenant"]; want != have {
+		t.Errorf("want header %q, have %v", want, have)
+	}
+	if want, have := 1, acker.acks; want != have {
+		t.Errorf("want %d acks, have %d", want, have)
+	}
+	if want, have := 3, acker.nacks; want != have || !acker.requeue {
+		t.Errorf("want %d requeued nacks, have %d (requeue %v)", want, have, acker.requeue)
+	}
+}
+
+func TestReplayerDryRun(t *testing.T) {
+	acker := &mockAcknowledger{}
+	src := &dlq{deliveries: []amqp.Delivery{
+		deadLettered(acker, `{"s":437}`, time.Now(), nil),
+	}}
+	dst := &routingChannel{}
+	var buf bytes.Buffer
+	r := amqptransport.NewReplayer(dst, amqptransport.ReplayerDryRun(testReqDecoder, log.NewLogfmtLogger(&buf)))
+
+	report, err := r.Replay(context.Background(), src, "orders.dlq")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := 1, report.Replayed; want != have {
+		t.Errorf("want %d replayed, have %d", want, have)
+	}
+	if want, have := 0, len(dst.published); want != have {
+		t.Errorf("want %d published, have %d", want, have)
+	}
+	if want, have := 1, acker.nacks; want != have {
+		t.Errorf("want %d requeued, have %d", want, have)
+	}
+	if want, have := `msg="would replay" exchange=shop key=order.created message_id= deaths=3 request={Squadron:437}`, strings.TrimSpace(buf.String()); want != have {
+		t.Errorf("want log %q, have %q", want, have)
+	}
+}
+
+func TestReplayerDestinationAndMax(t *testing.T) {
+	acker := &mockAcknowledger{}
+	src := &dlq{deliveries: []amqp.Delivery{
+		{Acknowledger: acker, Body: []byte("a")},
+		{Acknowledger: acker, Body: []byte("b")},
+	}}
+	dst := &routingChannel{}
+	r := amqptransport.NewReplayer(dst,
+		amqptransport.ReplayerDestination("", "orders.parking"),
+		amqptransport.ReplayerMax(1),
+	)
+	report, err := r.Replay(context.Background(), src, "orders.dlq")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := 1, report.Replayed; want != have {
+		t.Errorf("want %d replayed, have %d", want, have)
+	}
+	if want, have := " orders.parking", dst.published[0].exchange+" "+dst.published[0].key; want != have {
+		t.Errorf("want destination %q, have %q", want, have)
+	}
+	if want, have := 1, len(src.deliveries); want != have {
+		t.Errorf("want %d left in queue, have %d", want, have)
+	}
+}
@@ -0,0 +1,32 @@
+package amqp
+
+import (
+	"strings"
+
+	"github.com/streadway/amqp"
+)
+
+// DirectReplyTo is the name of RabbitMQ's direct reply-to pseudo-queue.
+// Publishers consuming it receive replies without declaring a reply queue,
+// see NewDirectReplyToPublisher.
+const DirectReplyTo = "amq.rabbitmq.reply-to"
+
+// NewDirectReplyToPublisher constructs a Publisher receiving replies through
+// RabbitMQ's direct reply-to instead of a declared reply queue. The
+// broker allows a single direct reply-to consumer per channel, so ch must
+// not be shared by concurrent requests.
+func NewDirectReplyToPublisher(
+	ch Channel,
+	enc EncodeRequestFunc,
+	dec DecodeResponseFunc,
+	options ...PublisherOption,
+) *Publisher {
+	return NewPublisher(ch, &amqp.Queue{Name: DirectReplyTo}, enc, dec, options...)
+}
+
+// isDirectReplyTo reports whether name addresses the direct reply-to
+// pseudo-queue, either as consumed by a publisher or as the ReplyTo
+// property rewritten by the broker.
+func isDirectReplyTo(name string) bool {
+	return name == DirectReplyTo || strings.HasPrefix(name, DirectReplyTo+".")
+}
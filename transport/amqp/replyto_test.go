@@ -0,0 +1,103 @@
+package amqp_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/streadway/amqp"
+
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+// directReplyToChannel echoes published requests to its direct reply-to
+// consumer, recording the calls it receives.
+type directReplyToChannel struct {
+	calls   []string
+	autoAck bool
+	replies chan amqp.Delivery
+}
+
+func (ch *directReplyToChannel) Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	ch.calls = append(ch.calls, "publish")
+	if ch.replies != nil {
+		ch.replies <- amqp.Delivery{CorrelationId: msg.CorrelationId, Body: msg.Body}
+	}
+	return nil
+}
+
+func (ch *directReplyToChannel) Consume(queue, consumer string, autoAck, exclusive, noLocal, noWail bool, args amqp.Table) (<-chan amqp.Delivery, error) {
+	ch.calls = append(ch.calls, "consume "+queue)
+	ch.autoAck = autoAck
+	ch.replies = make(chan amqp.Delivery, 1)
+	return ch.replies, nil
+}
+
+func (ch *directReplyToChannel) Cancel(consumer string, noWait bool) error {
+	ch.calls = append(ch.calls, "cancel")
+	return nil
+}
+
+func TestDirectReplyToPublisher(t *testing.T) {
+	ch := &directReplyToChannel{}
+	var replyTo string
+	pub := amqptransport.NewDirectReplyToPublisher(
+		ch,
+		func(_ context.Context, p *amqp.Publishing, request interface{}) error {
+			replyTo = p.ReplyTo
+			return testReqEncoder(context.Background(), p, request)
+		},
+		func(_ context.Context, d *amqp.Delivery) (interface{}, error) {
+			return string(d.Body), nil
+		},
+	)
+	res, err := pub.Endpoint()(context.Background(), testReq{Squadron: 436})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := `{"s":436}`, res; want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+	if want, have := amqptransport.DirectReplyTo, replyTo; want != have {
+		t.Errorf("want ReplyTo %q, have %q", want, have)
+	}
+	if !ch.autoAck {
+		t.Error("want reply consumer in no-ack mode")
+	}
+	want := []string{"consume " + amqptransport.DirectReplyTo, "publish", "cancel"}
+	if len(want) != len(ch.calls) {
+		t.Fatalf("want calls %v, have %v", want, ch.calls)
+	}
+	for i := range want {
+		if want[i] != ch.calls[i] {
+			t.Errorf("want calls %v, have %v", want, ch.calls)
+			break
+		}
+	}
+}
+
+func TestSubscriberDirectReplyTo(t *testing.T) {
+	var exchange, key string
+	outputChan := make(chan amqp.Publishing, 1)
+	ch := &mockChannel{
+		f: func(e, k string, mandatory, immediate bool) { exchange, key = e, k },
+		c: outputChan,
+	}
+	sub := amqptransport.NewSubscriber(
+		testEndpoint,
+		testReqDecoder,
+		amqptransport.EncodeJSONResponse,
+		amqptransport.SubscriberBefore(amqptransport.SetPublishExchange("replies")),
+	)
+	sub.ServeDelivery(ch)(&amqp.Delivery{
+		ReplyTo: amqptransport.DirectReplyTo + ".g2dkAA",
+		Body:    []byte(`{"s":436}`),
+	})
+	<-outputChan
+
+	if want, have := "", exchange; want != have {
+		t.Errorf("want exchange %q, have %q", want, have)
+	}
+	if want, have := amqptransport.DirectReplyTo+".g2dkAA", key; want != have {
+		t.Errorf("want key %q, have %q", want, have)
+	}
+}
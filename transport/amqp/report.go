@@ -0,0 +1,36 @@
+package amqp
+
+import (
+	"context"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// DeliveryReport describes how a Subscriber served a delivery, for access
+// logging in a DeliveryFinalizerFunc. Durations of stages that weren't
+// reached are zero.
+type DeliveryReport struct {
+	// Delivery is the delivery served.
+	Delivery *amqp.Delivery
+
+	// Reply is the reply published, or nil if none was published by the
+	// subscriber. Replies published by the error encoder aren't included.
+	Reply *amqp.Publishing
+
+	Decode   time.Duration
+	Endpoint time.Duration
+	Encode   time.Duration
+	Publish  time.Duration
+}
+
+// DeliveryFinalizerFunc is like SubscriberFinalizerFunc, but also receives
+// the DeliveryReport of the delivery.
+type DeliveryFinalizerFunc func(ctx context.Context, err error, report DeliveryReport)
+
+// SubscriberDeliveryFinalizer is executed at the end of every delivery,
+// after the finalizers set by ServerFinalizer. By default, no finalizer is
+// registered.
+func SubscriberDeliveryFinalizer(f ...DeliveryFinalizerFunc) SubscriberOption {
+	return func(s *Subscriber) { s.deliveryFinalizer = append(s.deliveryFinalizer, f...) }
+}
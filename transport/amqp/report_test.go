@@ -0,0 +1,68 @@
+package amqp_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+func TestSubscriberDeliveryFinalizer(t *testing.T) {
+	for _, testcase := range []struct {
+		name     string
+		endpoint func(context.Context, interface{}) (interface{}, error)
+		err      bool
+		reply    bool
+	}{
+		{"success", func(ctx context.Context, request interface{}) (interface{}, error) {
+			time.Sleep(10 * time.Millisecond)
+			return testEndpoint(ctx, request)
+		}, false, true},
+		{"failure", func(context.Context, interface{}) (interface{}, error) {
+			time.Sleep(10 * time.Millisecond)
+			return nil, errors.New("dummy")
+		}, true, false},
+	} {
+		t.Run(testcase.name, func(t *testing.T) {
+			var (
+				report amqptransport.DeliveryReport
+				err    error
+			)
+			sub := amqptransport.NewSubscriber(
+				testcase.endpoint,
+				testReqDecoder,
+				amqptransport.EncodeJSONResponse,
+				amqptransport.SubscriberDeliveryFinalizer(func(_ context.Context, e error, r amqptransport.DeliveryReport) {
+					err, report = e, r
+				}),
+			)
+			deliv := &amqp.Delivery{MessageId: "msg", Body: []byte(`{"s":436}`)}
+			sub.ServeDelivery(&countingChannel{})(deliv)
+
+			if want, have := testcase.err, err != nil; want != have {
+				t.Errorf("want error %v, have %v", want, have)
+			}
+			if want, have := "msg", report.Delivery.MessageId; want != have {
+				t.Errorf("want delivery %q, have %q", want, have)
+			}
+			if want, have := testcase.reply, report.Reply != nil; want != have {
+				t.Errorf("want reply %v, have %v", want, have)
+			}
+			if report.Reply != nil {
+				if want, have := `{"s":436,"n":"tusker"}`, string(report.Reply.Body); want != have {
+					t.Errorf("want reply %s, have %s", want, have)
+				}
+			}
+			if report.Endpoint < 10*time.Millisecond {
+				t.Errorf("want endpoint duration of at least 10ms, have %v", report.Endpoint)
+			}
+			if !testcase.err && report.Decode+report.Encode+report.Publish == 0 {
+				t.Error("want stage durations, have none")
+			}
+		})
+	}
+}
@@ -2,9 +2,14 @@ package amqp
 
 import (
 	"context"
+	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/streadway/amqp"
+
+	"github.com/inturn/kit/internal/lru"
+	"github.com/inturn/kit/util/backoff"
 )
 
 // RequestFunc may take information from a publisher request and put it into a
@@ -27,7 +32,8 @@ type SubscriberResponseFunc func(context.Context,
 type PublisherResponseFunc func(context.Context, *amqp.Delivery) context.Context
 
 // SetPublishExchange returns a RequestFunc that sets the Exchange field
-// of an AMQP Publish call.
+// of an AMQP Publish call. Used as a Subscriber before function, it routes
+// the reply through the exchange instead of the default exchange.
 func SetPublishExchange(publishExchange string) RequestFunc {
 	return func(ctx context.Context, pub *amqp.Publishing, d *amqp.Delivery) context.Context {
 		return context.WithValue(ctx, ContextKeyExchange, publishExchange)
@@ -35,13 +41,31 @@ func SetPublishExchange(publishExchange string) RequestFunc {
 }
 
 // SetPublishKey returns a RequestFunc that sets the Key field
-// of an AMQP Publish call.
+// of an AMQP Publish call. Used as a Subscriber before function, it
+// overrides the ReplyTo of the delivery as the routing key of the reply.
 func SetPublishKey(publishKey string) RequestFunc {
 	return func(ctx context.Context, pub *amqp.Publishing, d *amqp.Delivery) context.Context {
 		return context.WithValue(ctx, ContextKeyPublishKey, publishKey)
 	}
 }
 
+// SetPublishMandatory returns a RequestFunc that sets the mandatory flag of
+// an AMQP Publish call, making the broker return publishings it can't route
+// to a queue. See HandleReturns.
+func SetPublishMandatory(mandatory bool) RequestFunc {
+	return func(ctx context.Context, pub *amqp.Publishing, d *amqp.Delivery) context.Context {
+		return context.WithValue(ctx, ContextKeyMandatory, mandatory)
+	}
+}
+
+// SetPublishImmediate returns a RequestFunc that sets the immediate flag of
+// an AMQP Publish call. RabbitMQ doesn't support it.
+func SetPublishImmediate(immediate bool) RequestFunc {
+	return func(ctx context.Context, pub *amqp.Publishing, d *amqp.Delivery) context.Context {
+		return context.WithValue(ctx, ContextKeyImmediate, immediate)
+	}
+}
+
 // SetPublishDeliveryMode sets the delivery mode of a Publishing.
 // Please refer to AMQP delivery mode constants in the AMQP package.
 func SetPublishDeliveryMode(dmode uint8) RequestFunc {
@@ -51,6 +75,34 @@ func SetPublishDeliveryMode(dmode uint8) RequestFunc {
 	}
 }
 
+// SetPublishExpiration sets the Expiration of a Publishing, so the broker
+// discards it if it isn't consumed in time.
+func SetPublishExpiration(expiration time.Duration) RequestFunc {
+	return func(ctx context.Context, pub *amqp.Publishing, d *amqp.Delivery) context.Context {
+		pub.Expiration = strconv.FormatInt(int64(expiration/time.Millisecond), 10)
+		return ctx
+	}
+}
+
+// SetReplyDeliveryMode returns a RequestFunc that sets the delivery mode of
+// the reply, overriding whatever the encoder left in the Publishing, e.g.
+// amqp.Persistent so replies survive broker restarts.
+// It is designed to be used by Subscribers.
+func SetReplyDeliveryMode(dmode uint8) RequestFunc {
+	return func(ctx context.Context, pub *amqp.Publishing, d *amqp.Delivery) context.Context {
+		return context.WithValue(ctx, ContextKeyDeliveryMode, dmode)
+	}
+}
+
+// SetReplyExpiration returns a RequestFunc that sets the Expiration of the
+// reply, so the broker discards replies nobody consumed in time.
+// It is designed to be used by Subscribers.
+func SetReplyExpiration(expiration time.Duration) RequestFunc {
+	return func(ctx context.Context, pub *amqp.Publishing, d *amqp.Delivery) context.Context {
+		return context.WithValue(ctx, ContextKeyExpiration, expiration)
+	}
+}
+
 // SetNackSleepDuration returns a RequestFunc that sets the amount of time
 // to sleep in the event of a Nack.
 // This has to be used in conjunction with an error encoder that Nack and sleeps.
@@ -62,6 +114,54 @@ func SetNackSleepDuration(duration time.Duration) RequestFunc {
 	}
 }
 
+// SetNackBackoff returns a RequestFunc that sets the strategy deciding the
+// amount of time to sleep in the event of a Nack, from the number of times
+// the delivery was attempted before, e.g.
+//
+//	backoff.Capped(backoff.Jitter(backoff.Exponential(100*time.Millisecond), 0.5), 10*time.Second)
+//
+// Requeued deliveries carry no count, so attempts are counted by RetryCount
+// plus the number of times the returned RequestFunc saw a delivery with the
+// same MessageId Nack'd before. These counts are kept in memory for the most
+// recent nackBackoffSize message IDs, so they are per process and start over
+// when another consumer gets the delivery; a redelivered delivery counts as
+// at least one attempt. Deliveries without a MessageId are counted by
+// RetryCount, plus one if they are redelivered.
+// It takes precedence over SetNackSleepDuration and has to be used in
+// conjunction with an error encoder that Nack and sleeps, like
+// SingleNackRequeueErrorEncoder.
+// It is designed to be used by Subscribers.
+func SetNackBackoff(s backoff.Strategy) RequestFunc {
+	b := &nackBackoff{strategy: s, nacks: lru.New(nackBackoffSize)}
+	return func(ctx context.Context, pub *amqp.Publishing, d *amqp.Delivery) context.Context {
+		return context.WithValue(ctx, ContextKeyNackBackoff, b)
+	}
+}
+
+// nackBackoffSize is the maximum number of message IDs SetNackBackoff counts
+// the Nacks of.
+const nackBackoffSize = 10000
+
+// nackBackoff decides the duration to sleep for after a Nack, see
+// SetNackBackoff.
+type nackBackoff struct {
+	strategy backoff.Strategy
+	nacks    *lru.Cache
+}
+
+// attempt records a Nack of deliv and returns the attempt it was Nack'd at.
+func (b *nackBackoff) attempt(deliv *amqp.Delivery) int {
+	var before int
+	if deliv.MessageId != "" {
+		nacks := b.nacks.GetOrCreate(deliv.MessageId, func() interface{} { return new(int64) }).(*int64)
+		before = int(atomic.AddInt64(nacks, 1)) - 1
+	}
+	if deliv.Redelivered && before == 0 {
+		before = 1
+	}
+	return int(RetryCount(deliv)) + before + 1
+}
+
 // SetConsumeAutoAck returns a RequestFunc that sets whether or not to autoAck
 // messages when consuming.
 // When set to false, the publisher will Ack the first message it receives with
@@ -138,13 +238,46 @@ func getPublishKey(ctx context.Context) string {
 	return ""
 }
 
-func getNackSleepDuration(ctx context.Context) time.Duration {
+func getPublishMandatory(ctx context.Context) bool {
+	mandatory, _ := ctx.Value(ContextKeyMandatory).(bool)
+	return mandatory
+}
+
+func getPublishImmediate(ctx context.Context) bool {
+	immediate, _ := ctx.Value(ContextKeyImmediate).(bool)
+	return immediate
+}
+
+// setReplyProperties sets the delivery mode and expiration of the reply
+// pub from ctx, see SetReplyDeliveryMode and SetReplyExpiration.
+func setReplyProperties(ctx context.Context, pub *amqp.Publishing) {
+	if dmode, ok := ctx.Value(ContextKeyDeliveryMode).(uint8); ok {
+		pub.DeliveryMode = dmode
+	}
+	if expiration, ok := ctx.Value(ContextKeyExpiration).(time.Duration); ok {
+		pub.Expiration = strconv.FormatInt(int64(expiration/time.Millisecond), 10)
+	}
+}
+
+func getNackSleepDuration(ctx context.Context, deliv *amqp.Delivery) time.Duration {
+	if b, ok := ctx.Value(ContextKeyNackBackoff).(*nackBackoff); ok {
+		return retryDelay(b.strategy, b.attempt(deliv))
+	}
 	if duration := ctx.Value(ContextKeyNackSleepDuration); duration != nil {
 		return duration.(time.Duration)
 	}
 	return 0
 }
 
+// retryDelay returns the delay s waits before attempt n.
+func retryDelay(s backoff.Strategy, n int) time.Duration {
+	var d time.Duration
+	for i := 1; i <= n; i++ {
+		d = s(i, d)
+	}
+	return d
+}
+
 func getConsumeAutoAck(ctx context.Context) bool {
 	if autoAck := ctx.Value(ContextKeyAutoAck); autoAck != nil {
 		return autoAck.(bool)
@@ -162,11 +295,12 @@ func getConsumeArgs(ctx context.Context) amqp.Table {
 type contextKey int
 
 const (
-	// ContextKeyExchange is the value of the reply Exchange in
-	// amqp.Publish.
+	// ContextKeyExchange is the value of the exchange in amqp.Publish, set
+	// by SetPublishExchange.
 	ContextKeyExchange contextKey = iota
-	// ContextKeyPublishKey is the value of the ReplyTo field in
-	// amqp.Publish.
+	// ContextKeyPublishKey is the value of the routing key in
+	// amqp.Publish, set by SetPublishKey. Subscribers reply to the ReplyTo
+	// of the delivery without it.
 	ContextKeyPublishKey
 	// ContextKeyNackSleepDuration is the duration to sleep for if the
 	// service Nack and requeues a message.
@@ -179,4 +313,42 @@ const (
 	// ContextKeyConsumeArgs is the value of consumeArgs field when calling
 	// amqp.Channel.Consume.
 	ContextKeyConsumeArgs
+	// ContextKeyNackBackoff holds the state of SetNackBackoff, i.e. its
+	// backoff.Strategy and the Nacks counted per message ID, deciding the
+	// duration to sleep for if the service Nack a message. Its value is
+	// unexported; it is read by the error encoders of this package.
+	ContextKeyNackBackoff
+	// ContextKeyHeaders is the amqp.Table of headers propagated from the
+	// delivery by PropagateHeaders.
+	ContextKeyHeaders
+	// ContextKeyCorrelationID is the correlation ID of the request set by
+	// EnsureCorrelationID.
+	ContextKeyCorrelationID
+	// ContextKeyMandatory is the value of the mandatory flag in
+	// amqp.Publish.
+	ContextKeyMandatory
+	// ContextKeyImmediate is the value of the immediate flag in
+	// amqp.Publish.
+	ContextKeyImmediate
+	// ContextKeyContentType is the media type of the request, set by
+	// subscribers configured with SubscriberCodecs.
+	ContextKeyContentType
+	// ContextKeyErrorStage is the ErrorStage of the error passed to the
+	// subscriber's ErrorHandler.
+	ContextKeyErrorStage
+	// ContextKeyDeliveryMode is the DeliveryMode of the reply, set by
+	// SetReplyDeliveryMode.
+	ContextKeyDeliveryMode
+	// ContextKeyExpiration is the time.Duration after which the reply
+	// expires, set by SetReplyExpiration.
+	ContextKeyExpiration
+	// ContextKeyOutboxTx is the *sql.Tx a SQLOutboxStore adds messages
+	// within, set by ContextWithOutboxTx.
+	ContextKeyOutboxTx
+	// ContextKeyLogger is the log.Logger of the delivery, set by
+	// subscribers configured with SubscriberDeliveryLogger.
+	ContextKeyLogger
+	// ContextKeySchemaVersion is the schema version of the request, set by
+	// subscribers configured with SubscriberVersions.
+	ContextKeySchemaVersion
 )
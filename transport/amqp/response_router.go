@@ -0,0 +1,98 @@
+package amqp
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/streadway/amqp"
+)
+
+// ErrResponseRouterClosed is returned to callers waiting on a ResponseRouter
+// whose reply queue stopped delivering, e.g. because its channel was closed.
+var ErrResponseRouterClosed = errors.New("response router closed")
+
+// ResponseRouter consumes a single reply queue and hands each reply to the
+// caller waiting for its CorrelationId, so concurrent requests of one or
+// more Publishers share a reply queue instead of consuming it in turn.
+// Replies nobody waits for, e.g. those arriving after their request timed
+// out, are dropped.
+type ResponseRouter struct {
+	queue string
+
+	mtx     sync.Mutex
+	pending map[string]chan amqp.Delivery
+	closed  bool
+}
+
+// NewResponseRouter consumes queue on ch in no-ack mode and returns a
+// ResponseRouter demultiplexing its deliveries. The queue may be
+// DirectReplyTo, in which case requests must be published on ch as well.
+func NewResponseRouter(ch Channel, queue string) (*ResponseRouter, error) {
+	msg, err := ch.Consume(
+		queue,
+		"",    //consumer
+		true,  //autoAck
+		false, //exclusive
+		false, //noLocal
+		false, //noWait
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+	r := &ResponseRouter{
+		queue:   queue,
+		pending: map[string]chan amqp.Delivery{},
+	}
+	go r.route(msg)
+	return r, nil
+}
+
+// Queue returns the name of the reply queue, to be set as the ReplyTo of
+// requests.
+func (r *ResponseRouter) Queue() string {
+	return r.queue
+}
+
+// register registers a caller waiting for the reply with the
+// correlationId, returning the channel the reply is sent on. The channel is
+// closed if the reply queue stops delivering first.
+func (r *ResponseRouter) register(correlationId string) (<-chan amqp.Delivery, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if r.closed {
+		return nil, ErrResponseRouterClosed
+	}
+	c := make(chan amqp.Delivery, 1)
+	r.pending[correlationId] = c
+	return c, nil
+}
+
+// deregister removes the caller waiting for the reply with the
+// correlationId.
+func (r *ResponseRouter) deregister(correlationId string) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	delete(r.pending, correlationId)
+}
+
+// route hands the deliveries of msg to the waiting callers until msg is
+// closed, and then fails those still waiting.
+func (r *ResponseRouter) route(msg <-chan amqp.Delivery) {
+	for d := range msg {
+		r.mtx.Lock()
+		if c, ok := r.pending[d.CorrelationId]; ok {
+			delete(r.pending, d.CorrelationId)
+			c <- d // never blocks, c is buffered and sent on once
+		}
+		r.mtx.Unlock()
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.closed = true
+	for id, c := range r.pending {
+		close(c)
+		delete(r.pending, id)
+	}
+}
@@ -0,0 +1,153 @@
+package amqp_test
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+// echoChannel replies to published requests on its single consumer, in
+// reverse order of their squadrons, unless they are dropped.
+type echoChannel struct {
+	replies chan amqp.Delivery
+	drop    func(testReq) bool
+}
+
+func (ch *echoChannel) Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	var req testReq
+	if err := json.Unmarshal(msg.Body, &req); err != nil {
+		return err
+	}
+	if ch.drop != nil && ch.drop(req) {
+		return nil
+	}
+	go func() {
+		time.Sleep(time.Duration(1000-req.Squadron) * time.Millisecond / 100)
+		ch.replies <- amqp.Delivery{CorrelationId: msg.CorrelationId, Body: msg.Body}
+	}()
+	return nil
+}
+
+func (ch *echoChannel) Consume(queue, consumer string, autoAck, exclusive, noLocal, noWail bool, args amqp.Table) (<-chan amqp.Delivery, error) {
+	return ch.replies, nil
+}
+
+func TestResponseRouter(t *testing.T) {
+	ch := &echoChannel{replies: make(chan amqp.Delivery)}
+	router, err := amqptransport.NewResponseRouter(ch, "replies")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := amqptransport.NewPublisher(
+		ch,
+		nil,
+		testReqEncoder,
+		func(_ context.Context, d *amqp.Delivery) (interface{}, error) {
+			var req testReq
+			err := json.Unmarshal(d.Body, &req)
+			return req, err
+		},
+		amqptransport.PublisherResponseRouter(router),
+	)
+
+	squadrons := []int{424, 426, 429, 436}
+	var wg sync.WaitGroup
+	errs := make(chan error, len(squadrons))
+	for _, squadron := range squadrons {
+		wg.Add(1)
+		go func(squadron int) {
+			defer wg.Done()
+			res, err := pub.Endpoint()(context.Background(), testReq{Squadron: squadron})
+			if err != nil {
+				errs <- err
+				return
+			}
+			if want, have := squadron, res.(testReq).Squadron; want != have {
+				t.Errorf("want squadron %d, have %d", want, have)
+			}
+		}(squadron)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestResponseRouterTimeout(t *testing.T) {
+	ch := &echoChannel{
+		replies: make(chan amqp.Delivery),
+		drop:    func(req testReq) bool { return req.Squadron == 424 },
+	}
+	router, err := amqptransport.NewResponseRouter(ch, "replies")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var replyTo string
+	pub := amqptransport.NewPublisher(
+		ch,
+		nil,
+		testReqEncoder,
+		func(context.Context, *amqp.Delivery) (interface{}, error) { return nil, nil },
+		amqptransport.PublisherResponseRouter(router),
+		amqptransport.PublisherTimeout(50*time.Millisecond),
+		amqptransport.PublisherBefore(func(ctx context.Context, p *amqp.Publishing, _ *amqp.Delivery) context.Context {
+			replyTo = p.ReplyTo
+			return ctx
+		}),
+	)
+
+	if _, err := pub.Endpoint()(context.Background(), testReq{Squadron: 424}); err != context.DeadlineExceeded {
+		t.Errorf("want %v, have %v", context.DeadlineExceeded, err)
+	}
+	if _, err := pub.Endpoint()(context.Background(), testReq{Squadron: 999}); err != nil {
+		t.Errorf("want no error, have %v", err)
+	}
+	if want, have := "replies", replyTo; want != have {
+		t.Errorf("want ReplyTo %q, have %q", want, have)
+	}
+}
+
+func TestResponseRouterClosed(t *testing.T) {
+	ch := &echoChannel{
+		replies: make(chan amqp.Delivery),
+		drop:    func(testReq) bool { return true },
+	}
+	router, err := amqptransport.NewResponseRouter(ch, "replies")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := amqptransport.NewPublisher(
+		ch,
+		nil,
+		testReqEncoder,
+		func(context.Context, *amqp.Delivery) (interface{}, error) { return nil, nil },
+		amqptransport.PublisherResponseRouter(router),
+	)
+
+	errc := make(chan error, 1)
+	go func() {
+		_, err := pub.Endpoint()(context.Background(), testReq{Squadron: 436})
+		errc <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(ch.replies)
+
+	select {
+	case err := <-errc:
+		if want, have := amqptransport.ErrResponseRouterClosed, err; want != have {
+			t.Errorf("want %v, have %v", want, have)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("request still waiting after the reply queue was closed")
+	}
+	if _, err := pub.Endpoint()(context.Background(), testReq{Squadron: 436}); err != amqptransport.ErrResponseRouterClosed {
+		t.Errorf("want %v, have %v", amqptransport.ErrResponseRouterClosed, err)
+	}
+}
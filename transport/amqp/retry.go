@@ -0,0 +1,145 @@
+package amqp
+
+import (
+	"context"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// RetryCountHeader is the header in which RetryErrorEncoder counts the
+// attempts of a delivery it republished.
+const RetryCountHeader = "x-retry-count"
+
+// Headers set by DeadLetterErrorEncoder on dead-lettered messages.
+const (
+	// ErrorMessageHeader is the error the delivery failed with.
+	ErrorMessageHeader = "x-error"
+	// FailedAtHeader is the time the delivery failed.
+	FailedAtHeader = "x-failed-at"
+)
+
+// DeathCount returns how many times the delivery was dead-lettered, as
+// recorded by RabbitMQ in the x-death header, e.g. when it cycles through a
+// retry queue with a message TTL. If queue isn't empty, only deaths in that
+// queue are counted.
+func DeathCount(deliv *amqp.Delivery, queue string) int64 {
+	deaths, _ := deliv.Headers["x-death"].([]interface{})
+	var n int64
+	for _, death := range deaths {
+		table, ok := death.(amqp.Table)
+		if !ok {
+			continue
+		}
+		if q, _ := table["queue"].(string); queue != "" && q != queue {
+			continue
+		}
+		n += toInt64(table["count"])
+	}
+	return n
+}
+
+// RetryCount returns how many times the delivery was retried before, by
+// RetryErrorEncoder or by dead-lettering.
+func RetryCount(deliv *amqp.Delivery) int64 {
+	return toInt64(deliv.Headers[RetryCountHeader]) + DeathCount(deliv, "")
+}
+
+func toInt64(v interface{}) int64 {
+	switch v := v.(type) {
+	case int64:
+		return v
+	case int32:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int8:
+		return int64(v)
+	case int:
+		return int64(v)
+	}
+	return 0
+}
+
+// RetryErrorEncoder returns an ErrorEncoder retrying failed deliveries up to
+// maxRetries times, and rejecting them without requeueing afterwards so
+// they land in the dead letter exchange of the queue, if it has one.
+//
+// Requeued deliveries carry no record of their attempts, so deliveries are
+// retried by republishing them with an incremented RetryCountHeader and
+// acknowledging the original. They are republished to queue, the queue
+// they were consumed from, through the default exchange, so the retry
+// doesn't reach the other queues bound to their exchange. Retries are
+// immediate; see TTLRetryErrorEncoder and DelayedExchangeRetryErrorEncoder
+// to retry after a delay without blocking the consumer. If republishing
+// fails, the delivery is requeued instead. It does not reply the message.
+func RetryErrorEncoder(maxRetries int, queue string) ErrorEncoder {
+	return func(ctx context.Context, err error, deliv *amqp.Delivery, ch Channel, pub *amqp.Publishing) {
+		retries := RetryCount(deliv)
+		if retries >= int64(maxRetries) {
+			deliv.Reject(false) //requeue
+			return
+		}
+
+		retry := publishingFromDelivery(deliv)
+		retry.Headers[RetryCountHeader] = toInt64(deliv.Headers[RetryCountHeader]) + 1
+		if err := ch.Publish("", queue, false, false, retry); err != nil {
+			deliv.Nack(
+				false, //multiple
+				true,  //requeue
+			)
+			return
+		}
+		deliv.Ack(false)
+	}
+}
+
+// DeadLetterErrorEncoder returns an ErrorEncoder dead-lettering failed
+// deliveries annotated with the error and the time they failed, in
+// ErrorMessageHeader and FailedAtHeader. Brokers don't let consumers change
+// the headers of messages they reject, so the delivery is republished to
+// exchange, with key as its routing key, or its own if key is empty, and
+// acknowledged. Use the dead letter exchange of the queue as exchange. If
+// republishing fails, the delivery is rejected without requeueing as with
+// NackNoRequeueErrorEncoder, losing the annotations. It does not reply the
+// message.
+func DeadLetterErrorEncoder(exchange, key string) ErrorEncoder {
+	return func(ctx context.Context, err error, deliv *amqp.Delivery, ch Channel, pub *amqp.Publishing) {
+		dead := publishingFromDelivery(deliv)
+		dead.Headers[ErrorMessageHeader] = err.Error()
+		dead.Headers[FailedAtHeader] = time.Now()
+		routingKey := key
+		if routingKey == "" {
+			routingKey = deliv.RoutingKey
+		}
+		if err := ch.Publish(exchange, routingKey, false, false, dead); err != nil {
+			NackNoRequeueErrorEncoder(ctx, err, deliv, ch, pub)
+			return
+		}
+		deliv.Ack(false)
+	}
+}
+
+// publishingFromDelivery returns a Publishing republishing deliv.
+func publishingFromDelivery(deliv *amqp.Delivery) amqp.Publishing {
+	headers := make(amqp.Table, len(deliv.Headers)+1)
+	for k, v := range deliv.Headers {
+		headers[k] = v
+	}
+	return amqp.Publishing{
+		Headers:         headers,
+		ContentType:     deliv.ContentType,
+		ContentEncoding: deliv.ContentEncoding,
+		DeliveryMode:    deliv.DeliveryMode,
+		Priority:        deliv.Priority,
+		CorrelationId:   deliv.CorrelationId,
+		ReplyTo:         deliv.ReplyTo,
+		Expiration:      deliv.Expiration,
+		MessageId:       deliv.MessageId,
+		Timestamp:       deliv.Timestamp,
+		Type:            deliv.Type,
+		UserId:          deliv.UserId,
+		AppId:           deliv.AppId,
+		Body:            deliv.Body,
+	}
+}
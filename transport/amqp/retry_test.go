@@ -0,0 +1,207 @@
+package amqp_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+func TestRetryCount(t *testing.T) {
+	deliv := &amqp.Delivery{Headers: amqp.Table{
+		amqptransport.RetryCountHeader: int64(2),
+		"x-death": []interface{}{
+			amqp.Table{"queue": "orders", "count": int64(3)},
+			amqp.Table{"queue": "orders.retry", "count": int64(3)},
+		},
+	}}
+	if want, have := int64(3), amqptransport.DeathCount(deliv, "orders"); want != have {
+		t.Errorf("incorrect death count, want %d, have %d", want, have)
+	}
+	if want, have := int64(8), amqptransport.RetryCount(deliv); want != have {
+		t.Errorf("incorrect retry count, want %d, have %d", want, have)
+	}
+	if want, have := int64(0), amqptransport.RetryCount(&amqp.Delivery{}); want != have {
+		t.Errorf("incorrect retry count, want %d, have %d", want, have)
+	}
+}
+
+func TestRetryErrorEncoder(t *testing.T) {
+	var (
+		exchange, key string
+		outputChan    = make(chan amqp.Publishing, 1)
+		ch            = &mockChannel{
+			f: func(e, k string, mandatory, immediate bool) { exchange, key = e, k },
+			c: outputChan,
+		}
+		ee = amqptransport.RetryErrorEncoder(2, "orders.create")
+	)
+
+	acker := &mockAcknowledger{}
+	deliv := &amqp.Delivery{
+		Acknowledger: acker,
+		Exchange:     "orders",
+		RoutingKey:   "create",
+		Headers:      amqp.Table{amqptransport.RetryCountHeader: int64(1)},
+		Body:         []byte("body"),
+	}
+	ee(context.Background(), errors.New("dummy"), deliv, ch, &amqp.Publishing{})
+
+	if want, have := 1, acker.acks; want != have {
+		t.Errorf("incorrect number of acks, want %d, have %d", want, have)
+	}
+	retry := <-outputChan
+	if want, have := " orders.create", exchange+" "+key; want != have {
+		t.Errorf("incorrect destination, want %q, have %q", want, have)
+	}
+	if want, have := int64(2), retry.Headers[amqptransport.RetryCountHeader]; want != have {
+		t.Errorf("incorrect retry count, want %v, have %v", want, have)
+	}
+	if want, have := "body", string(retry.Body); want != have {
+		t.Errorf("incorrect body, want %q, have %q", want, have)
+	}
+
+	acker = &mockAcknowledger{}
+	deliv = &amqp.Delivery{
+		Acknowledger: acker,
+		Headers:      amqp.Table{amqptransport.RetryCountHeader: int64(2)},
+	}
+	ee(context.Background(), errors.New("dummy"), deliv, ch, &amqp.Publishing{})
+	if want, have := 1, acker.rejects; want != have {
+		t.Errorf("incorrect number of rejects, want %d, have %d", want, have)
+	}
+	if acker.requeue {
+		t.Error("want no requeue, have requeue")
+	}
+	if want, have := 0, len(outputChan); want != have {
+		t.Errorf("incorrect number of publishings, want %d, have %d", want, have)
+	}
+}
+
+func TestSetNackBackoff(t *testing.T) {
+	var attempts []int
+	strategy := func(n int, previous time.Duration) time.Duration {
+		attempts = append(attempts, n)
+		return 0
+	}
+	ctx := amqptransport.SetNackBackoff(strategy)(context.Background(), nil, nil)
+
+	deliv := &amqp.Delivery{
+		Acknowledger: &mockAcknowledger{},
+		Redelivered:  true,
+		Headers:      amqp.Table{amqptransport.RetryCountHeader: int64(1)},
+	}
+	amqptransport.SingleNackRequeueErrorEncoder(ctx, errors.New("dummy"), deliv, nil, &amqp.Publishing{})
+
+	// one retry and one redelivery before, so this is the third attempt
+	if want, have := "[1 2 3]", fmt.Sprint(attempts); want != have {
+		t.Errorf("incorrect attempts, want %s, have %s", want, have)
+	}
+}
+
+func TestSetNackBackoffRequeued(t *testing.T) {
+	var attempts []int
+	strategy := func(n int, previous time.Duration) time.Duration {
+		attempts = append(attempts, n)
+		return 0
+	}
+	before := amqptransport.SetNackBackoff(strategy)
+
+	var last []int
+	for i := 0; i < 3; i++ {
+		attempts = nil
+		deliv := &amqp.Delivery{
+			Acknowledger: &mockAcknowledger{},
+			MessageId:    "m1",
+			Redelivered:  i > 0,
+		}
+		ctx := before(context.Background(), nil, deliv)
+		amqptransport.SingleNackRequeueErrorEncoder(ctx, errors.New("dummy"), deliv, nil, &amqp.Publishing{})
+		last = append(last, attempts[len(attempts)-1])
+	}
+
+	// requeued deliveries carry no count, the backoff grows anyway
+	if want, have := "[1 2 3]", fmt.Sprint(last); want != have {
+		t.Errorf("incorrect attempts, want %s, have %s", want, have)
+	}
+}
+
+func TestSetNackSleepDuration(t *testing.T) {
+	ctx := amqptransport.SetNackSleepDuration(20*time.Millisecond)(context.Background(), nil, nil)
+	ack := &mockAcknowledger{}
+	deliv := &amqp.Delivery{Acknowledger: ack}
+
+	begin := time.Now()
+	amqptransport.SingleNackRequeueErrorEncoder(ctx, errors.New("dummy"), deliv, nil, &amqp.Publishing{})
+	if have := time.Since(begin); have < 20*time.Millisecond {
+		t.Errorf("want sleep of at least 20ms, have %s", have)
+	}
+	if want, have := 1, ack.nacks; want != have {
+		t.Errorf("want %d nacks, have %d", want, have)
+	}
+}
+
+func TestNackNoRequeueErrorEncoder(t *testing.T) {
+	acker := &mockAcknowledger{requeue: true}
+	amqptransport.NackNoRequeueErrorEncoder(context.Background(), errors.New("dummy"), &amqp.Delivery{Acknowledger: acker}, nil, &amqp.Publishing{})
+	if want, have := 1, acker.nacks; want != have {
+		t.Errorf("incorrect number of nacks, want %d, have %d", want, have)
+	}
+	if acker.requeue {
+		t.Error("want no requeue")
+	}
+}
+
+func TestDeadLetterErrorEncoder(t *testing.T) {
+	var (
+		exchange, key string
+		outputChan    = make(chan amqp.Publishing, 1)
+		ch            = &mockChannel{
+			f: func(e, k string, mandatory, immediate bool) { exchange, key = e, k },
+			c: outputChan,
+		}
+	)
+
+	acker := &mockAcknowledger{}
+	deliv := &amqp.Delivery{
+		Acknowledger: acker,
+		Exchange:     "orders",
+		RoutingKey:   "create",
+		Headers:      amqp.Table{"tenant": "acme"},
+		Body:         []byte("body"),
+	}
+	before := time.Now()
+	amqptransport.DeadLetterErrorEncoder("orders.dlx", "")(context.Background(), errors.New("dummy"), deliv, ch, &amqp.Publishing{})
+
+	if want, have := 1, acker.acks; want != have {
+		t.Errorf("incorrect number of acks, want %d, have %d", want, have)
+	}
+	dead := <-outputChan
+	if want, have := "orders.dlx create", exchange+" "+key; want != have {
+		t.Errorf("incorrect destination, want %q, have %q", want, have)
+	}
+	if want, have := "dummy", dead.Headers[amqptransport.ErrorMessageHeader]; want != have {
+		t.Errorf("incorrect error header, want %v, have %v", want, have)
+	}
+	if failedAt, _ := dead.Headers[amqptransport.FailedAtHeader].(time.Time); failedAt.Before(before) {
+		t.Errorf("incorrect failure time %v", dead.Headers[amqptransport.FailedAtHeader])
+	}
+	if want, have := "acme", dead.Headers["tenant"]; want != have {
+		t.Errorf("incorrect header, want %v, have %v", want, have)
+	}
+	if _, ok := deliv.Headers[amqptransport.ErrorMessageHeader]; ok {
+		t.Error("want delivery headers left alone")
+	}
+
+	acker = &mockAcknowledger{requeue: true}
+	failing := &flakyChannel{failures: 1, err: errors.New("channel closed")}
+	amqptransport.DeadLetterErrorEncoder("orders.dlx", "dead")(context.Background(), errors.New("dummy"), &amqp.Delivery{Acknowledger: acker}, failing, &amqp.Publishing{})
+	if want, have := 1, acker.nacks; want != have || acker.requeue {
+		t.Errorf("want %d nack without requeue, have %d, requeue %v", want, have, acker.requeue)
+	}
+}
@@ -0,0 +1,44 @@
+package amqp
+
+import (
+	"github.com/streadway/amqp"
+
+	"github.com/inturn/kit/log"
+)
+
+// ReturnNotifier is implemented by channels notifying about returned
+// publishings, like *amqp.Channel.
+type ReturnNotifier interface {
+	NotifyReturn(c chan amqp.Return) chan amqp.Return
+}
+
+// ReturnHandler handles a publishing returned by the broker because it was
+// published with the mandatory or immediate flag and couldn't be routed or
+// delivered.
+type ReturnHandler func(amqp.Return)
+
+// HandleReturns calls f with every publishing returned on ch, until ch is
+// closed.
+func HandleReturns(ch ReturnNotifier, f ReturnHandler) {
+	returns := ch.NotifyReturn(make(chan amqp.Return, 1))
+	go func() {
+		for r := range returns {
+			f(r)
+		}
+	}()
+}
+
+// LogReturns returns a ReturnHandler logging returned publishings to
+// logger.
+func LogReturns(logger log.Logger) ReturnHandler {
+	return func(r amqp.Return) {
+		logger.Log(
+			"msg", "publishing returned",
+			"exchange", r.Exchange,
+			"key", r.RoutingKey,
+			"correlation_id", r.CorrelationId,
+			"reply_code", r.ReplyCode,
+			"reply_text", r.ReplyText,
+		)
+	}
+}
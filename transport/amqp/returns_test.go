@@ -0,0 +1,69 @@
+package amqp_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+
+	"github.com/inturn/kit/endpoint"
+	"github.com/inturn/kit/log"
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+func TestSubscriberMandatory(t *testing.T) {
+	var mandatory []bool
+	outputChan := make(chan amqp.Publishing, 2)
+	ch := &mockChannel{
+		f: func(exchange, key string, m, immediate bool) { mandatory = append(mandatory, m) },
+		c: outputChan,
+	}
+	decode := func(context.Context, *amqp.Delivery) (interface{}, error) { return nil, nil }
+
+	amqptransport.NewSubscriber(endpoint.Nop, decode, amqptransport.EncodeNopResponse,
+		amqptransport.SubscriberMandatory(true),
+	).ServeDelivery(ch)(&amqp.Delivery{})
+	amqptransport.NewSubscriber(endpoint.Nop, decode, amqptransport.EncodeNopResponse,
+		amqptransport.SubscriberMandatory(true),
+		amqptransport.SubscriberBefore(amqptransport.SetPublishMandatory(false)),
+	).ServeDelivery(ch)(&amqp.Delivery{})
+
+	if want, have := "[true false]", fmt.Sprint(mandatory); want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+}
+
+type returningChannel struct{ returns chan amqp.Return }
+
+func (ch *returningChannel) NotifyReturn(c chan amqp.Return) chan amqp.Return {
+	ch.returns = c
+	return c
+}
+
+func TestHandleReturns(t *testing.T) {
+	var (
+		buf  bytes.Buffer
+		done = make(chan struct{})
+		ch   = &returningChannel{}
+		logf = amqptransport.LogReturns(log.NewLogfmtLogger(&buf))
+	)
+	amqptransport.HandleReturns(ch, func(r amqp.Return) {
+		logf(r)
+		close(done)
+	})
+	ch.returns <- amqp.Return{RoutingKey: "replies", ReplyCode: 312, ReplyText: "NO_ROUTE"}
+	close(ch.returns)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("return not handled")
+	}
+	if want, have := "key=replies", buf.String(); !strings.Contains(have, want) {
+		t.Errorf("want %q in %q", want, have)
+	}
+}
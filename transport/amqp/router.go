@@ -0,0 +1,133 @@
+package amqp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/streadway/amqp"
+
+	"github.com/inturn/kit/log"
+	"github.com/inturn/kit/transport"
+)
+
+// NoRouteError is passed to the error handler of a Router for deliveries
+// whose routing key matches none of its patterns.
+type NoRouteError struct {
+	RoutingKey string
+}
+
+// Error implements the error interface.
+func (e NoRouteError) Error() string {
+	return fmt.Sprintf("no route for routing key %q", e.RoutingKey)
+}
+
+// Router dispatches the deliveries of a single consumer to Subscribers by
+// routing key, so one queue bound with several routing keys can carry
+// different message types, each decoded and served by its own endpoint.
+//
+//	r := amqptransport.NewRouter()
+//	r.Handle("order.created", createdSubscriber)
+//	r.Handle("order.*.cancelled", cancelledSubscriber)
+//	handler := r.ServeDelivery(ch)
+//	for d := range deliveries {
+//		handler(&d)
+//	}
+type Router struct {
+	routes       []route
+	notFound     *Subscriber
+	errorHandler transport.ErrorHandler
+}
+
+type route struct {
+	pattern []string
+	s       *Subscriber
+}
+
+// RouterOption sets an optional parameter for routers.
+type RouterOption func(*Router)
+
+// RouterNotFound sets the Subscriber serving deliveries whose routing key
+// matches no pattern. By default, they are rejected without requeue, so
+// they are dead-lettered if the queue has a dead letter exchange.
+func RouterNotFound(s *Subscriber) RouterOption {
+	return func(r *Router) { r.notFound = s }
+}
+
+// RouterErrorHandler is used to handle deliveries matching no pattern,
+// as a NoRouteError, and failures rejecting them. By default, errors are
+// ignored.
+func RouterErrorHandler(errorHandler transport.ErrorHandler) RouterOption {
+	return func(r *Router) { r.errorHandler = errorHandler }
+}
+
+// NewRouter returns a Router without routes.
+func NewRouter(options ...RouterOption) *Router {
+	r := &Router{
+		errorHandler: transport.NewLogErrorHandler(log.NewNopLogger()),
+	}
+	for _, option := range options {
+		option(r)
+	}
+	return r
+}
+
+// Handle serves deliveries whose routing key matches pattern with s.
+// Patterns follow the syntax of topic exchange bindings: words are
+// delimited by dots, "*" matches exactly one word and "#" matches zero or
+// more words. Deliveries are served by the first route they match, in the
+// order of registration. Handle must not be called concurrently with
+// deliveries being served.
+func (r *Router) Handle(pattern string, s *Subscriber) {
+	r.routes = append(r.routes, route{pattern: strings.Split(pattern, "."), s: s})
+}
+
+// ServeDelivery returns a handler for AMQP Delivery messages consumed from
+// ch, serving them with the Subscriber of the matching route.
+func (r *Router) ServeDelivery(ch Channel) func(deliv *amqp.Delivery) {
+	return func(deliv *amqp.Delivery) {
+		key := strings.Split(deliv.RoutingKey, ".")
+		for _, route := range r.routes {
+			if matchTopic(route.pattern, key) {
+				route.s.ServeDelivery(ch)(deliv)
+				return
+			}
+		}
+		if r.notFound != nil {
+			r.notFound.ServeDelivery(ch)(deliv)
+			return
+		}
+
+		ctx := context.Background()
+		r.errorHandler.Handle(ctx, NoRouteError{RoutingKey: deliv.RoutingKey})
+		if err := deliv.Nack(false, false); err != nil {
+			r.errorHandler.Handle(ctx, err)
+		}
+	}
+}
+
+// matchTopic reports whether the words of a routing key match the words of
+// a topic pattern.
+func matchTopic(pattern, key []string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case "#":
+			for i := 0; i <= len(key); i++ {
+				if matchTopic(pattern[1:], key[i:]) {
+					return true
+				}
+			}
+			return false
+		case "*":
+			if len(key) == 0 {
+				return false
+			}
+		default:
+			if len(key) == 0 || pattern[0] != key[0] {
+				return false
+			}
+		}
+		pattern, key = pattern[1:], key[1:]
+	}
+	return len(key) == 0
+}
@@ -0,0 +1,88 @@
+package amqp_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/streadway/amqp"
+
+	"github.com/inturn/kit/transport"
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+func TestRouter(t *testing.T) {
+	var served string
+	subscriber := func(name string) *amqptransport.Subscriber {
+		return amqptransport.NewSubscriber(
+			func(context.Context, interface{}) (interface{}, error) {
+				served = name
+				return nil, nil
+			},
+			func(context.Context, *amqp.Delivery) (interface{}, error) { return nil, nil },
+			amqptransport.EncodeNopResponse,
+		)
+	}
+	r := amqptransport.NewRouter()
+	r.Handle("order.created", subscriber("created"))
+	r.Handle("order.*.cancelled", subscriber("cancelled"))
+	r.Handle("audit.#", subscriber("audit"))
+	r.Handle("#.failed", subscriber("failed"))
+	handler := r.ServeDelivery(&countingChannel{})
+
+	for _, testcase := range []struct {
+		key, want string
+	}{
+		{"order.created", "created"},
+		{"order.eu.cancelled", "cancelled"},
+		{"order.cancelled", ""},
+		{"order.eu.west.cancelled", ""},
+		{"audit", "audit"},
+		{"audit.order.created", "audit"},
+		{"payment.failed", "failed"},
+		{"failed", "failed"},
+		{"order.created.v2", ""},
+	} {
+		served = ""
+		handler(&amqp.Delivery{Acknowledger: &mockAcknowledger{}, RoutingKey: testcase.key})
+		if want, have := testcase.want, served; want != have {
+			t.Errorf("%s: want %q, have %q", testcase.key, want, have)
+		}
+	}
+}
+
+func TestRouterNoRoute(t *testing.T) {
+	var handled error
+	r := amqptransport.NewRouter(
+		amqptransport.RouterErrorHandler(transport.ErrorHandlerFunc(func(_ context.Context, err error) {
+			handled = err
+		})),
+	)
+	acker := &mockAcknowledger{}
+	r.ServeDelivery(&countingChannel{})(&amqp.Delivery{Acknowledger: acker, RoutingKey: "order.created"})
+
+	if want, have := (amqptransport.NoRouteError{RoutingKey: "order.created"}), handled; want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	if want, have := 1, acker.nacks; want != have {
+		t.Errorf("want %d nacks, have %d", want, have)
+	}
+	if acker.requeue {
+		t.Error("want delivery rejected without requeue")
+	}
+}
+
+func TestRouterNotFound(t *testing.T) {
+	var served bool
+	r := amqptransport.NewRouter(amqptransport.RouterNotFound(amqptransport.NewSubscriber(
+		func(context.Context, interface{}) (interface{}, error) {
+			served = true
+			return nil, nil
+		},
+		func(context.Context, *amqp.Delivery) (interface{}, error) { return nil, nil },
+		amqptransport.EncodeNopResponse,
+	)))
+	r.ServeDelivery(&countingChannel{})(&amqp.Delivery{Acknowledger: &mockAcknowledger{}, RoutingKey: "order.created"})
+	if !served {
+		t.Error("want delivery served by the not found subscriber")
+	}
+}
@@ -0,0 +1,280 @@
+package amqp
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"runtime/debug"
+	"sync"
+
+	"github.com/streadway/amqp"
+)
+
+// ErrDeliveriesClosed is returned by Runner.Run when the delivery channel
+// was closed, usually because the AMQP channel or connection was closed.
+var ErrDeliveriesClosed = errors.New("delivery channel closed")
+
+// ErrQoSUnsupported is returned by Runner.Run when RunnerPrefetch is set
+// but the channel has no Qos method.
+var ErrQoSUnsupported = errors.New("channel doesn't support QoS")
+
+// SubscriberConcurrency sets the number of deliveries a Runner serves
+// concurrently. The default is 1, serving deliveries in order.
+func SubscriberConcurrency(n int) SubscriberOption {
+	return func(s *Subscriber) { s.concurrency = n }
+}
+
+// Runner serves the deliveries of a consumer with a Subscriber, using a
+// pool of as many workers as set by SubscriberConcurrency. It takes the
+// place of the consume loop:
+//
+//	deliveries, err := ch.Consume(queue, "", false, false, false, false, nil)
+//	...
+//	r := amqptransport.NewRunner(sub, ch, deliveries)
+//	go r.Run()
+//	...
+//	r.Stop(ctx)
+//
+// Runner.Run and Runner.Stop can be used as the Execute and Interrupt
+// functions of a util/group actor. To drain the consumer before the process
+// exits, e.g. in a Kubernetes preStop hook, use Runner.Shutdown instead.
+type Runner struct {
+	s          *Subscriber
+	ch         Channel
+	handler    func(*amqp.Delivery)
+	deliveries <-chan amqp.Delivery
+	consumer   string
+	partition  DeliveryKeyFunc
+
+	prefetchCount, prefetchSize int
+
+	quit     chan struct{}
+	quitOnce sync.Once
+	stopped  chan struct{} // closed when no more deliveries are taken
+	done     chan struct{}
+}
+
+// RunnerOption sets an optional parameter for NewRunner.
+type RunnerOption func(*Runner)
+
+// RunnerConsumer sets the consumer tag deliveries were consumed with. If
+// it is set and the channel has a Cancel method, like *amqp.Channel,
+// Runner.Shutdown cancels the consumer, so the broker stops sending
+// deliveries.
+func RunnerConsumer(consumer string) RunnerOption {
+	return func(r *Runner) { r.consumer = consumer }
+}
+
+// RunnerPrefetch limits the deliveries the broker sends before they are
+// acknowledged to prefetchCount deliveries and prefetchSize bytes, zero
+// meaning no limit, applying backpressure once the Runner falls behind.
+// Run sets the limit on the channel, which must have a Qos method, like
+// *amqp.Channel; it applies to all consumers of the channel. Deliveries
+// taken by the Runner count against the limit until acknowledged, so
+// prefetchCount should be at least the number of workers set by
+// SubscriberConcurrency, or workers sit idle; a small multiple of it keeps
+// them busy while acknowledgements travel to the broker. Prefetched
+// deliveries wait in memory and are requeued by Runner.Shutdown. Auto-ack
+// consumers aren't limited.
+func RunnerPrefetch(prefetchCount, prefetchSize int) RunnerOption {
+	return func(r *Runner) { r.prefetchCount, r.prefetchSize = prefetchCount, prefetchSize }
+}
+
+// RunnerPartitionKey serves the deliveries with the same key, e.g.
+// DeliveryHeader("x-order-id") or DeliveryRoutingKey, on the same worker,
+// in the order they were delivered, while the deliveries of different keys
+// are still served concurrently by the workers set by
+// SubscriberConcurrency. Keys are mapped to workers by hash, so a worker
+// busy with a slow delivery holds up the deliveries of the other keys
+// mapped to it, and the Runner stops taking deliveries until it is free.
+// Order is only kept among the deliveries of one Runner, and not for
+// deliveries requeued by the subscriber.
+func RunnerPartitionKey(key DeliveryKeyFunc) RunnerOption {
+	return func(r *Runner) { r.partition = key }
+}
+
+// NewRunner returns a Runner serving deliveries, consumed from ch, with s.
+func NewRunner(s *Subscriber, ch Channel, deliveries <-chan amqp.Delivery, options ...RunnerOption) *Runner {
+	r := &Runner{
+		s:          s,
+		ch:         ch,
+		handler:    s.ServeDelivery(ch),
+		deliveries: deliveries,
+		quit:       make(chan struct{}),
+		stopped:    make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	for _, option := range options {
+		option(r)
+	}
+	return r
+}
+
+// Run serves deliveries until the Runner is stopped or the delivery channel
+// is closed, and waits for the deliveries in progress to complete. It
+// returns nil if the Runner was stopped and ErrDeliveriesClosed otherwise.
+// A panic serving a delivery is logged and doesn't affect other deliveries.
+func (r *Runner) Run() error {
+	defer close(r.done)
+
+	n := r.s.concurrency
+	if n < 1 {
+		n = 1
+	}
+	// Without a partition key, all workers take jobs from the same
+	// channel; with one, every worker has its own.
+	jobs := make([]chan amqp.Delivery, 1)
+	if r.partition != nil {
+		jobs = make([]chan amqp.Delivery, n)
+	}
+	for i := range jobs {
+		jobs[i] = make(chan amqp.Delivery)
+	}
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func(jobs <-chan amqp.Delivery) {
+			defer wg.Done()
+			for d := range jobs {
+				r.serve(d)
+			}
+		}(jobs[i%len(jobs)])
+	}
+	defer wg.Wait()
+	defer func() {
+		for _, c := range jobs {
+			close(c)
+		}
+	}()
+	defer close(r.stopped)
+
+	if r.prefetchCount > 0 || r.prefetchSize > 0 {
+		c, ok := r.ch.(interface {
+			Qos(prefetchCount, prefetchSize int, global bool) error
+		})
+		if !ok {
+			return ErrQoSUnsupported
+		}
+		if err := c.Qos(r.prefetchCount, r.prefetchSize, true); err != nil {
+			return err
+		}
+	}
+
+	for {
+		// Don't take another delivery once stopped, even if one is ready.
+		select {
+		case <-r.quit:
+			return nil
+		default:
+		}
+
+		select {
+		case <-r.quit:
+			return nil
+		case d, ok := <-r.deliveries:
+			if !ok {
+				return ErrDeliveriesClosed
+			}
+			jobs[r.worker(&d, len(jobs))] <- d
+		}
+	}
+}
+
+// worker returns the index of the worker, out of n, serving d.
+func (r *Runner) worker(d *amqp.Delivery, n int) int {
+	if n == 1 {
+		return 0
+	}
+	h := fnv.New32a()
+	h.Write([]byte(r.partition(d)))
+	return int(h.Sum32() % uint32(n))
+}
+
+func (r *Runner) serve(d amqp.Delivery) {
+	defer func() {
+		if v := recover(); v != nil {
+			r.s.handleError(context.Background(), ErrorStagePanic, PanicError{Value: v, Stack: debug.Stack()})
+		}
+	}()
+	r.handler(&d)
+}
+
+// Stop stops the Runner from taking further deliveries, leaving them to be
+// redelivered by the broker once the channel is closed, and waits for Run
+// to return until ctx is done.
+func (r *Runner) Stop(ctx context.Context) error {
+	r.quitOnce.Do(func() { close(r.quit) })
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Shutdown drains the Runner: it stops taking deliveries, cancels the
+// consumer, see RunnerConsumer, requeues the deliveries already sent by the
+// broker but not served yet with a Nack, and waits for the deliveries in progress to
+// complete until ctx is done. Deliveries still in progress then are
+// redelivered by the broker once the channel is closed. Shutdown must only
+// be used with consumers acknowledging deliveries themselves, i.e. without
+// auto-ack.
+func (r *Runner) Shutdown(ctx context.Context) error {
+	r.quitOnce.Do(func() { close(r.quit) })
+	select {
+	case <-r.stopped:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	canceled := false
+	if c, ok := r.ch.(interface {
+		Cancel(consumer string, noWait bool) error
+	}); ok && r.consumer != "" {
+		if err := c.Cancel(r.consumer, false); err != nil {
+			r.s.handleError(context.Background(), ErrorStageCancel, err)
+		} else {
+			canceled = true
+		}
+	}
+	if err := r.requeue(ctx, canceled); err != nil {
+		return err
+	}
+
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// requeue nacks the deliveries left in the delivery channel. Once the
+// consumer was canceled, the channel is closed after the last delivery, so
+// requeue waits for it; otherwise it only nacks those already buffered.
+func (r *Runner) requeue(ctx context.Context, canceled bool) error {
+	for {
+		var (
+			d  amqp.Delivery
+			ok bool
+		)
+		if canceled {
+			select {
+			case d, ok = <-r.deliveries:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		} else {
+			select {
+			case d, ok = <-r.deliveries:
+			default:
+			}
+		}
+		if !ok {
+			return nil
+		}
+		if err := d.Nack(false, true); err != nil {
+			r.s.handleError(context.Background(), ErrorStageAcknowledge, err)
+		}
+	}
+}
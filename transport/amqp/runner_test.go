@@ -0,0 +1,288 @@
+package amqp_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+type countingChannel struct{ published int32 }
+
+func (ch *countingChannel) Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	atomic.AddInt32(&ch.published, 1)
+	return nil
+}
+
+func (ch *countingChannel) Consume(queue, consumer string, autoAck, exclusive, noLocal, noWail bool, args amqp.Table) (<-chan amqp.Delivery, error) {
+	return nil, nil
+}
+
+func TestRunnerConcurrency(t *testing.T) {
+	var (
+		mtx     sync.Mutex
+		active  int
+		maximum int
+	)
+	sub := amqptransport.NewSubscriber(
+		func(context.Context, interface{}) (interface{}, error) {
+			mtx.Lock()
+			active++
+			if active > maximum {
+				maximum = active
+			}
+			mtx.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			mtx.Lock()
+			active--
+			mtx.Unlock()
+			return nil, nil
+		},
+		func(context.Context, *amqp.Delivery) (interface{}, error) { return nil, nil },
+		amqptransport.EncodeNopResponse,
+		amqptransport.SubscriberConcurrency(3),
+	)
+
+	deliveries := make(chan amqp.Delivery, 10)
+	for i := 0; i < 10; i++ {
+		deliveries <- amqp.Delivery{}
+	}
+	close(deliveries)
+
+	ch := &countingChannel{}
+	if want, have := amqptransport.ErrDeliveriesClosed, amqptransport.NewRunner(sub, ch, deliveries).Run(); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	if want, have := int32(10), atomic.LoadInt32(&ch.published); want != have {
+		t.Errorf("incorrect number of replies, want %d, have %d", want, have)
+	}
+	if want, have := 3, maximum; want != have {
+		t.Errorf("incorrect concurrency, want %d, have %d", want, have)
+	}
+}
+
+func TestRunnerPartitionKey(t *testing.T) {
+	var (
+		mtx     sync.Mutex
+		active  int
+		maximum int
+		seen    = map[string][]uint64{}
+	)
+	sub := amqptransport.NewSubscriber(
+		func(_ context.Context, request interface{}) (interface{}, error) {
+			d := request.(*amqp.Delivery)
+			mtx.Lock()
+			active++
+			if active > maximum {
+				maximum = active
+			}
+			mtx.Unlock()
+			time.Sleep(time.Millisecond)
+			mtx.Lock()
+			active--
+			key := d.Headers["x-order-id"].(string)
+			seen[key] = append(seen[key], d.DeliveryTag)
+			mtx.Unlock()
+			return nil, nil
+		},
+		func(_ context.Context, d *amqp.Delivery) (interface{}, error) { return d, nil },
+		amqptransport.EncodeNopResponse,
+		amqptransport.SubscriberConcurrency(4),
+	)
+
+	deliveries := make(chan amqp.Delivery, 100)
+	for i := 0; i < 100; i++ {
+		deliveries <- amqp.Delivery{
+			DeliveryTag: uint64(i),
+			Headers:     amqp.Table{"x-order-id": string(rune('a' + i%8))},
+		}
+	}
+	close(deliveries)
+
+	r := amqptransport.NewRunner(sub, &countingChannel{}, deliveries,
+		amqptransport.RunnerPartitionKey(amqptransport.DeliveryHeader("x-order-id")),
+	)
+	if want, have := amqptransport.ErrDeliveriesClosed, r.Run(); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	for key, tags := range seen {
+		for i := 1; i < len(tags); i++ {
+			if tags[i] < tags[i-1] {
+				t.Errorf("%s: out of order: %v", key, tags)
+				break
+			}
+		}
+	}
+	if maximum < 2 {
+		t.Errorf("want concurrent workers, have at most %d", maximum)
+	}
+}
+
+func TestRunnerPanic(t *testing.T) {
+	sub := amqptransport.NewSubscriber(
+		func(_ context.Context, request interface{}) (interface{}, error) {
+			if request == "panic" {
+				panic("dummy")
+			}
+			return nil, nil
+		},
+		func(_ context.Context, d *amqp.Delivery) (interface{}, error) { return string(d.Body), nil },
+		amqptransport.EncodeNopResponse,
+	)
+
+	deliveries := make(chan amqp.Delivery, 2)
+	deliveries <- amqp.Delivery{Body: []byte("panic")}
+	deliveries <- amqp.Delivery{}
+	close(deliveries)
+
+	ch := &countingChannel{}
+	amqptransport.NewRunner(sub, ch, deliveries).Run()
+	if want, have := int32(1), atomic.LoadInt32(&ch.published); want != have {
+		t.Errorf("incorrect number of replies, want %d, have %d", want, have)
+	}
+}
+
+func TestRunnerStop(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	sub := amqptransport.NewSubscriber(
+		func(context.Context, interface{}) (interface{}, error) {
+			close(started)
+			<-release
+			return nil, nil
+		},
+		func(context.Context, *amqp.Delivery) (interface{}, error) { return nil, nil },
+		amqptransport.EncodeNopResponse,
+	)
+
+	deliveries := make(chan amqp.Delivery, 2)
+	deliveries <- amqp.Delivery{}
+	ch := &countingChannel{}
+	r := amqptransport.NewRunner(sub, ch, deliveries)
+	errc := make(chan error)
+	go func() { errc <- r.Run() }()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if want, have := context.DeadlineExceeded, r.Stop(ctx); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+
+	deliveries <- amqp.Delivery{} // not taken anymore
+	close(release)
+	if err := r.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if want, have := int32(1), atomic.LoadInt32(&ch.published); want != have {
+		t.Errorf("incorrect number of replies, want %d, have %d", want, have)
+	}
+	if want, have := 1, len(deliveries); want != have {
+		t.Errorf("incorrect number of remaining deliveries, want %d, have %d", want, have)
+	}
+}
+
+type cancelingChannel struct {
+	countingChannel
+	deliveries chan amqp.Delivery
+	canceled   string
+}
+
+func (ch *cancelingChannel) Cancel(consumer string, noWait bool) error {
+	ch.canceled = consumer
+	close(ch.deliveries)
+	return nil
+}
+
+func TestRunnerShutdown(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+	sub := amqptransport.NewSubscriber(
+		func(context.Context, interface{}) (interface{}, error) {
+			once.Do(func() { close(started) })
+			<-release
+			return nil, nil
+		},
+		func(context.Context, *amqp.Delivery) (interface{}, error) { return nil, nil },
+		amqptransport.EncodeNopResponse,
+	)
+
+	ch := &cancelingChannel{deliveries: make(chan amqp.Delivery, 3)}
+	acker := &mockAcknowledger{}
+	for i := 0; i < 3; i++ {
+		ch.deliveries <- amqp.Delivery{Acknowledger: acker}
+	}
+	r := amqptransport.NewRunner(sub, ch, ch.deliveries, amqptransport.RunnerConsumer("ctag"))
+	errc := make(chan error)
+	go func() { errc <- r.Run() }()
+	<-started
+
+	shutdown := make(chan error)
+	go func() { shutdown <- r.Shutdown(context.Background()) }()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	if err := <-shutdown; err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+
+	if want, have := "ctag", ch.canceled; want != have {
+		t.Errorf("incorrect canceled consumer, want %q, have %q", want, have)
+	}
+	served := int(atomic.LoadInt32(&ch.published))
+	if served < 1 || acker.nacks < 1 || served+acker.nacks != 3 {
+		t.Errorf("want 3 deliveries served or requeued, have %d served and %d requeued", served, acker.nacks)
+	}
+	if !acker.requeue {
+		t.Error("want deliveries requeued, have not")
+	}
+}
+
+type qosChannel struct {
+	countingChannel
+	prefetchCount, prefetchSize int
+	global                      bool
+}
+
+func (ch *qosChannel) Qos(prefetchCount, prefetchSize int, global bool) error {
+	ch.prefetchCount, ch.prefetchSize, ch.global = prefetchCount, prefetchSize, global
+	return nil
+}
+
+func TestRunnerPrefetch(t *testing.T) {
+	sub := amqptransport.NewSubscriber(
+		testEndpoint,
+		testReqDecoder,
+		amqptransport.EncodeJSONResponse,
+	)
+	deliveries := make(chan amqp.Delivery)
+	close(deliveries)
+
+	ch := &qosChannel{}
+	r := amqptransport.NewRunner(sub, ch, deliveries, amqptransport.RunnerPrefetch(20, 0))
+	if want, have := amqptransport.ErrDeliveriesClosed, r.Run(); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	if want, have := 20, ch.prefetchCount; want != have {
+		t.Errorf("want prefetch count %d, have %d", want, have)
+	}
+	if !ch.global {
+		t.Error("want channel-wide prefetch limit")
+	}
+
+	r = amqptransport.NewRunner(sub, &countingChannel{}, deliveries, amqptransport.RunnerPrefetch(20, 0))
+	if want, have := amqptransport.ErrQoSUnsupported, r.Run(); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
@@ -0,0 +1,125 @@
+package amqp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// SchemaRegistry stores Avro schemas by ID. The Confluent Schema Registry
+// is the reference implementation, see NewSchemaRegistryClient.
+type SchemaRegistry interface {
+	// Schema returns the schema with the given ID.
+	Schema(ctx context.Context, id int) (string, error)
+
+	// Register registers schema under subject, if it isn't registered yet,
+	// and returns its ID.
+	Register(ctx context.Context, subject, schema string) (int, error)
+}
+
+// SchemaRegistryError is returned by the client of NewSchemaRegistryClient
+// for failed requests.
+type SchemaRegistryError struct {
+	StatusCode int
+	Code       int    `json:"error_code"`
+	Message    string `json:"message"`
+}
+
+// Error implements the error interface.
+func (e SchemaRegistryError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("schema registry: %s", http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("schema registry: %s (%d)", e.Message, e.Code)
+}
+
+const schemaRegistryContentType = "application/vnd.schemaregistry.v1+json"
+
+type schemaRegistryClient struct {
+	url    string
+	client *http.Client
+}
+
+// SchemaRegistryClientOption sets an optional parameter for
+// NewSchemaRegistryClient.
+type SchemaRegistryClientOption func(*schemaRegistryClient)
+
+// SchemaRegistryHTTPClient sets the HTTP client used for requests to the
+// registry, e.g. to set a timeout or credentials. The default is
+// http.DefaultClient.
+func SchemaRegistryHTTPClient(client *http.Client) SchemaRegistryClientOption {
+	return func(c *schemaRegistryClient) { c.client = client }
+}
+
+// NewSchemaRegistryClient returns a SchemaRegistry using the REST API of
+// the Confluent Schema Registry at baseURL, e.g. "http://localhost:8081".
+// It doesn't cache schemas; AvroCodec does.
+func NewSchemaRegistryClient(baseURL string, options ...SchemaRegistryClientOption) SchemaRegistry {
+	c := &schemaRegistryClient{
+		url:    strings.TrimSuffix(baseURL, "/"),
+		client: http.DefaultClient,
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+func (c *schemaRegistryClient) Schema(ctx context.Context, id int) (string, error) {
+	var res struct {
+		Schema string `json:"schema"`
+	}
+	if err := c.do(ctx, "GET", "/schemas/ids/"+strconv.Itoa(id), nil, &res); err != nil {
+		return "", err
+	}
+	return res.Schema, nil
+}
+
+func (c *schemaRegistryClient) Register(ctx context.Context, subject, schema string) (int, error) {
+	req := struct {
+		Schema string `json:"schema"`
+	}{schema}
+	var res struct {
+		ID int `json:"id"`
+	}
+	if err := c.do(ctx, "POST", "/subjects/"+url.PathEscape(subject)+"/versions", req, &res); err != nil {
+		return 0, err
+	}
+	return res.ID, nil
+}
+
+func (c *schemaRegistryClient) do(ctx context.Context, method, path string, body, v interface{}) error {
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, c.url+path, &buf)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", schemaRegistryContentType)
+	if body != nil {
+		req.Header.Set("Content-Type", schemaRegistryContentType)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		e := SchemaRegistryError{StatusCode: resp.StatusCode}
+		json.NewDecoder(resp.Body).Decode(&e)
+		return e
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
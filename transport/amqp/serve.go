@@ -0,0 +1,55 @@
+package amqp
+
+import (
+	"context"
+
+	"github.com/streadway/amqp"
+)
+
+// Serve consumes queue from ch with the consumer tag and serves the
+// deliveries with a Runner until ctx is done or the delivery channel is
+// closed, replacing the usual consume loop:
+//
+//	err := sub.Serve(ctx, ch, "orders", "orders-service", amqptransport.RunnerPrefetch(20, 0))
+//
+// Deliveries aren't auto-acked, so acknowledge them with an AckMode or the
+// error encoder. Once ctx is done, Serve drains the Runner, see
+// Runner.Shutdown, waiting for the deliveries in progress to complete, and
+// returns ctx.Err(). If the delivery channel is closed, usually because the
+// AMQP channel or connection was closed, it returns ErrDeliveriesClosed,
+// so the caller can consume again on a new channel. Pass a non-empty
+// consumer tag, so the consumer can be canceled on shutdown.
+func (s Subscriber) Serve(ctx context.Context, ch Channel, queue, consumer string, options ...RunnerOption) error {
+	return s.serve(ctx, ch, queue, consumer, nil, options...)
+}
+
+// serve implements Serve, consuming queue with args.
+func (s Subscriber) serve(ctx context.Context, ch Channel, queue, consumer string, args amqp.Table, options ...RunnerOption) error {
+	deliveries, err := ch.Consume(
+		queue,
+		consumer,
+		false, //autoAck
+		false, //exclusive
+		false, //noLocal
+		false, //noWait
+		args,
+	)
+	if err != nil {
+		return err
+	}
+
+	r := NewRunner(&s, ch, deliveries, append([]RunnerOption{RunnerConsumer(consumer)}, options...)...)
+	errc := make(chan error, 1)
+	go func() { errc <- r.Run() }()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+		if err := r.Shutdown(context.Background()); err != nil {
+			return err
+		}
+		<-errc
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,73 @@
+package amqp_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+// consumingChannel is a cancelingChannel handing out its deliveries on
+// Consume.
+type consumingChannel struct {
+	cancelingChannel
+	queue   string
+	autoAck bool
+}
+
+func (ch *consumingChannel) Consume(queue, consumer string, autoAck, exclusive, noLocal, noWail bool, args amqp.Table) (<-chan amqp.Delivery, error) {
+	ch.queue, ch.autoAck = queue, autoAck
+	return ch.deliveries, nil
+}
+
+func TestSubscriberServe(t *testing.T) {
+	sub := amqptransport.NewSubscriber(testEndpoint, testReqDecoder, amqptransport.EncodeJSONResponse)
+	ch := &consumingChannel{cancelingChannel: cancelingChannel{deliveries: make(chan amqp.Delivery, 3)}}
+	for i := 0; i < 3; i++ {
+		ch.deliveries <- amqp.Delivery{Acknowledger: &mockAcknowledger{}, Body: []byte(`{"s":436}`)}
+	}
+	close(ch.deliveries)
+
+	if want, have := amqptransport.ErrDeliveriesClosed, sub.Serve(context.Background(), ch, "squadrons", "ctag"); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	if want, have := "squadrons", ch.queue; want != have {
+		t.Errorf("want queue %q, have %q", want, have)
+	}
+	if ch.autoAck {
+		t.Error("want deliveries consumed without auto-ack")
+	}
+	if want, have := int32(3), atomic.LoadInt32(&ch.published); want != have {
+		t.Errorf("want %d replies, have %d", want, have)
+	}
+}
+
+func TestSubscriberServeCanceled(t *testing.T) {
+	sub := amqptransport.NewSubscriber(testEndpoint, testReqDecoder, amqptransport.EncodeJSONResponse)
+	ch := &consumingChannel{cancelingChannel: cancelingChannel{deliveries: make(chan amqp.Delivery)}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- sub.Serve(ctx, ch, "squadrons", "ctag") }()
+	ch.deliveries <- amqp.Delivery{Acknowledger: &mockAcknowledger{}, Body: []byte(`{"s":436}`)}
+	cancel()
+
+	select {
+	case err := <-errc:
+		if want, have := context.Canceled, err; want != have {
+			t.Errorf("want %v, have %v", want, have)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for Serve to return")
+	}
+	if want, have := "ctag", ch.canceled; want != have {
+		t.Errorf("want canceled consumer %q, have %q", want, have)
+	}
+	if want, have := int32(1), atomic.LoadInt32(&ch.published); want != have {
+		t.Errorf("want %d replies, have %d", want, have)
+	}
+}
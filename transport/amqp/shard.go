@@ -0,0 +1,83 @@
+package amqp
+
+import (
+	"context"
+	"hash/fnv"
+	"strconv"
+
+	"github.com/streadway/amqp"
+)
+
+// ExchangeConsistentHash is the kind of the exchanges of the RabbitMQ
+// consistent hash exchange plugin, which route every message to one of
+// the queues bound to them by a hash of its routing key.
+const ExchangeConsistentHash = "x-consistent-hash"
+
+// PublishKeyFunc derives the routing key of a request from the request.
+type PublishKeyFunc func(ctx context.Context, request interface{}) string
+
+// PublisherKeyFunc sets a function deriving the routing key of every
+// request from the request, e.g. its aggregate ID, so requests for the same
+// entity are routed to the same queue of a partitioned topology and
+// consumed in order. Published to an exchange of kind
+// ExchangeConsistentHash, the broker hashes the key; otherwise use
+// ShardKey. The key is set before the RequestFuncs set with
+// PublisherBefore run, so SetPublishKey still overrides it.
+func PublisherKeyFunc(f PublishKeyFunc) PublisherOption {
+	return func(p *Publisher) { p.keyFunc = f }
+}
+
+// ShardKey returns a PublishKeyFunc mapping the ID returned by id for a
+// request to one of keys by consistent hashing, e.g. to publish to a direct
+// exchange with a queue bound per key. Appending keys only moves the IDs
+// that map to the new keys, so per-entity ordering is kept for the others
+// while the topology grows.
+func ShardKey(keys []string, id PublishKeyFunc) PublishKeyFunc {
+	return func(ctx context.Context, request interface{}) string {
+		if len(keys) == 0 {
+			return ""
+		}
+		h := fnv.New64a()
+		h.Write([]byte(id(ctx, request)))
+		return keys[jumpHash(h.Sum64(), len(keys))]
+	}
+}
+
+// jumpHash maps key to one of n buckets with the jump consistent hash of
+// Lamping and Veach.
+func jumpHash(key uint64, n int) int {
+	var b, j int64 = -1, 0
+	for j < int64(n) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return int(b)
+}
+
+// ConsistentHashExchange returns a durable exchange of kind
+// ExchangeConsistentHash named name, for a Topology. If header is set, the
+// exchange hashes the value of that header of messages instead of their
+// routing key.
+func ConsistentHashExchange(name, header string) Exchange {
+	e := Exchange{
+		Name:    name,
+		Kind:    ExchangeConsistentHash,
+		Durable: true,
+	}
+	if header != "" {
+		e.Args = amqp.Table{"hash-header": header}
+	}
+	return e
+}
+
+// ConsistentHashBinding returns the binding of queue to the consistent hash
+// exchange, for a Topology. Queues get a share of the messages
+// proportional to their weight, which is the routing key of the binding.
+func ConsistentHashBinding(queue, exchange string, weight int) Binding {
+	return Binding{
+		Queue:    queue,
+		Exchange: exchange,
+		Key:      strconv.Itoa(weight),
+	}
+}
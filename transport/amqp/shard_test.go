@@ -0,0 +1,75 @@
+package amqp_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+func aggregateID(_ context.Context, request interface{}) string {
+	return fmt.Sprint(request.(testReq).Squadron)
+}
+
+func TestPublisherKeyFunc(t *testing.T) {
+	keys := make(chan string, 1)
+	ch := &mockChannel{
+		f:          func(exchange, key string, mandatory, immediate bool) { keys <- key },
+		c:          make(chan amqp.Publishing, 1),
+		deliveries: []amqp.Delivery{},
+	}
+	pub := amqptransport.NewPublisher(
+		ch,
+		&amqp.Queue{Name: "replies"},
+		amqptransport.EncodeJSONRequest,
+		func(context.Context, *amqp.Delivery) (interface{}, error) { return nil, nil },
+		amqptransport.PublisherKeyFunc(aggregateID),
+		amqptransport.PublisherTimeout(10*time.Millisecond),
+	)
+	pub.Endpoint()(context.Background(), testReq{Squadron: 437})
+	if want, have := "437", <-keys; want != have {
+		t.Errorf("want key %q, have %q", want, have)
+	}
+}
+
+func TestShardKey(t *testing.T) {
+	keys := []string{"shard.0", "shard.1", "shard.2"}
+	shard := amqptransport.ShardKey(keys, aggregateID)
+	grown := amqptransport.ShardKey(append(keys, "shard.3"), aggregateID)
+
+	used := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		req := testReq{Squadron: i}
+		key := shard(context.Background(), req)
+		if again := shard(context.Background(), req); key != again {
+			t.Fatalf("%d: unstable key, %q then %q", i, key, again)
+		}
+		used[key]++
+		// Growing the shards only moves IDs to the new shard.
+		if moved := grown(context.Background(), req); moved != key && moved != "shard.3" {
+			t.Errorf("%d: moved from %q to %q", i, key, moved)
+		}
+	}
+	for _, key := range keys {
+		if used[key] < 250 {
+			t.Errorf("shard %q underused: %d of 1000", key, used[key])
+		}
+	}
+}
+
+func TestConsistentHashTopology(t *testing.T) {
+	e := amqptransport.ConsistentHashExchange("orders", "x-order-id")
+	if want, have := amqptransport.ExchangeConsistentHash, e.Kind; want != have {
+		t.Errorf("want kind %q, have %q", want, have)
+	}
+	if want, have := "x-order-id", e.Args["hash-header"]; want != have {
+		t.Errorf("want hash header %q, have %v", want, have)
+	}
+	if want, have := "2", amqptransport.ConsistentHashBinding("orders.0", "orders", 2).Key; want != have {
+		t.Errorf("want binding key %q, have %q", want, have)
+	}
+}
@@ -0,0 +1,108 @@
+package amqp
+
+import (
+	"context"
+
+	"github.com/streadway/amqp"
+)
+
+// StreamOffsetHeader is the header carrying the offset of deliveries
+// consumed from a stream, and the consumer argument selecting the offset to
+// start consuming at.
+const StreamOffsetHeader = "x-stream-offset"
+
+// StreamOffset returns the offset of a delivery consumed from a stream, and
+// whether it has one.
+func StreamOffset(d *amqp.Delivery) (int64, bool) {
+	v, ok := d.Headers[StreamOffsetHeader]
+	if !ok {
+		return 0, false
+	}
+	return toInt64(v), true
+}
+
+// OffsetStore stores the offset of the last delivery of a stream a consumer
+// served, so it can resume after it. Implementations must be safe for
+// concurrent use.
+type OffsetStore interface {
+	// Load returns the offset saved for the consumer of stream, and
+	// whether there is one.
+	Load(ctx context.Context, stream, consumer string) (offset int64, ok bool, err error)
+
+	// Save saves the offset of the last delivery the consumer of stream
+	// served.
+	Save(ctx context.Context, stream, consumer string, offset int64) error
+}
+
+// ServeStream is like Serve for RabbitMQ streams, i.e. queues declared with
+// QueueTypeStream. It resumes consuming stream after the offset saved in
+// store for the consumer, or at the first message of the stream if none is
+// saved, and saves the offset of every delivery once it was served. Failed
+// deliveries aren't redelivered by streams, so the offset is saved
+// regardless; errors saving it are passed to the error handler with
+// ErrorStageOffset.
+//
+// Streams require a prefetch limit for the consumer, which defaults to
+// RunnerPrefetch(100, 0). ServeStream sets it per consumer before
+// consuming, as the broker refuses to consume a stream otherwise, so ch
+// must have a Qos method, like *amqp.Channel.
+//
+// Streams still count deliveries against the prefetch limit until they are
+// acknowledged. With the default AckManual mode and an error encoder not
+// acknowledging them, like DefaultErrorEncoder, failed deliveries are never
+// acknowledged, and once as many failed as the prefetch limit allows, the
+// broker stops sending deliveries, although their offsets were saved. Use
+// SubscriberAckMode(AckOnSuccess) or AckAfterPublish, or an error encoder
+// acknowledging failed deliveries, to keep consuming past them.
+//
+// Offsets are saved as deliveries complete, so with SubscriberConcurrency
+// above one, a later offset may be saved before an earlier delivery
+// completed, which is then skipped after a restart.
+func (s Subscriber) ServeStream(ctx context.Context, ch Channel, stream, consumer string, store OffsetStore, options ...RunnerOption) error {
+	offset, ok, err := store.Load(ctx, stream, consumer)
+	if err != nil {
+		return err
+	}
+	var start interface{} = "first"
+	if ok {
+		start = offset + 1
+	}
+
+	n := len(s.deliveryFinalizer)
+	s.deliveryFinalizer = append(s.deliveryFinalizer[:n:n], func(ctx context.Context, _ error, r DeliveryReport) {
+		offset, ok := StreamOffset(r.Delivery)
+		if !ok {
+			return
+		}
+		if err := store.Save(ctx, stream, consumer, offset); err != nil {
+			s.handleError(ctx, ErrorStageOffset, err)
+		}
+	})
+	options = append([]RunnerOption{RunnerPrefetch(100, 0)}, options...)
+	if err := streamQos(ch, options); err != nil {
+		return err
+	}
+	// The prefetch limit is set, so the Runner mustn't set it again for
+	// the whole channel.
+	options = append(options, RunnerPrefetch(0, 0))
+	return s.serve(ctx, ch, stream, consumer, amqp.Table{StreamOffsetHeader: start}, options...)
+}
+
+// streamQos sets the prefetch limit set by options for the consumers ch
+// starts next.
+func streamQos(ch Channel, options []RunnerOption) error {
+	var r Runner
+	for _, option := range options {
+		option(&r)
+	}
+	if r.prefetchCount == 0 && r.prefetchSize == 0 {
+		return nil
+	}
+	c, ok := ch.(interface {
+		Qos(prefetchCount, prefetchSize int, global bool) error
+	})
+	if !ok {
+		return ErrQoSUnsupported
+	}
+	return c.Qos(r.prefetchCount, r.prefetchSize, false)
+}
@@ -0,0 +1,113 @@
+package amqp_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/streadway/amqp"
+
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+// streamChannel is a consumingChannel recording the consumer arguments and
+// the calls setting the prefetch limit and consuming.
+type streamChannel struct {
+	consumingChannel
+	args  amqp.Table
+	calls []string
+}
+
+func (ch *streamChannel) Consume(queue, consumer string, autoAck, exclusive, noLocal, noWail bool, args amqp.Table) (<-chan amqp.Delivery, error) {
+	ch.args = args
+	ch.calls = append(ch.calls, "Consume")
+	return ch.consumingChannel.Consume(queue, consumer, autoAck, exclusive, noLocal, noWail, args)
+}
+
+func (ch *streamChannel) Qos(prefetchCount, prefetchSize int, global bool) error {
+	ch.calls = append(ch.calls, fmt.Sprintf("Qos(%d, %d, %t)", prefetchCount, prefetchSize, global))
+	return nil
+}
+
+// memoryOffsetStore is an OffsetStore keeping offsets in a map.
+type memoryOffsetStore struct {
+	mtx     sync.Mutex
+	offsets map[string]int64
+}
+
+func (s *memoryOffsetStore) Load(_ context.Context, stream, consumer string) (int64, bool, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	offset, ok := s.offsets[stream+"/"+consumer]
+	return offset, ok, nil
+}
+
+func (s *memoryOffsetStore) Save(_ context.Context, stream, consumer string, offset int64) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.offsets[stream+"/"+consumer] = offset
+	return nil
+}
+
+func TestSubscriberServeStream(t *testing.T) {
+	sub := amqptransport.NewSubscriber(testEndpoint, testReqDecoder, amqptransport.EncodeJSONResponse)
+	store := &memoryOffsetStore{offsets: map[string]int64{}}
+
+	for _, testcase := range []struct {
+		name    string
+		offsets []int64
+		start   interface{}
+		saved   int64
+	}{
+		{"first", []int64{0, 1, 2}, "first", 2},
+		{"resumed", []int64{3, 4}, int64(3), 4},
+	} {
+		t.Run(testcase.name, func(t *testing.T) {
+			ch := &streamChannel{consumingChannel: consumingChannel{
+				cancelingChannel: cancelingChannel{deliveries: make(chan amqp.Delivery, len(testcase.offsets))},
+			}}
+			for _, offset := range testcase.offsets {
+				ch.deliveries <- amqp.Delivery{
+					Acknowledger: &mockAcknowledger{},
+					Headers:      amqp.Table{amqptransport.StreamOffsetHeader: offset},
+					Body:         []byte(`{"s":436}`),
+				}
+			}
+			close(ch.deliveries)
+
+			err := sub.ServeStream(context.Background(), ch, "events", "projector", store)
+			if want, have := amqptransport.ErrDeliveriesClosed, err; want != have {
+				t.Errorf("want %v, have %v", want, have)
+			}
+			if want, have := testcase.start, ch.args[amqptransport.StreamOffsetHeader]; want != have {
+				t.Errorf("want start offset %v, have %v", want, have)
+			}
+			// the broker refuses to consume a stream without a
+			// prefetch limit set for the consumer before
+			if want, have := "[Qos(100, 0, false) Consume]", fmt.Sprint(ch.calls); want != have {
+				t.Errorf("want calls %s, have %s", want, have)
+			}
+			if want, have := testcase.saved, store.offsets["events/projector"]; want != have {
+				t.Errorf("want saved offset %d, have %d", want, have)
+			}
+		})
+	}
+}
+
+func TestSubscriberServeStreamPrefetch(t *testing.T) {
+	sub := amqptransport.NewSubscriber(testEndpoint, testReqDecoder, amqptransport.EncodeJSONResponse)
+	store := &memoryOffsetStore{offsets: map[string]int64{}}
+	ch := &streamChannel{consumingChannel: consumingChannel{
+		cancelingChannel: cancelingChannel{deliveries: make(chan amqp.Delivery)},
+	}}
+	close(ch.deliveries)
+
+	err := sub.ServeStream(context.Background(), ch, "events", "projector", store, amqptransport.RunnerPrefetch(20, 0))
+	if want, have := amqptransport.ErrDeliveriesClosed, err; want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	if want, have := "[Qos(20, 0, false) Consume]", fmt.Sprint(ch.calls); want != have {
+		t.Errorf("want calls %s, have %s", want, have)
+	}
+}
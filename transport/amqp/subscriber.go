@@ -3,23 +3,51 @@ package amqp
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"runtime/debug"
 	"time"
 
 	"github.com/inturn/kit/endpoint"
 	"github.com/inturn/kit/log"
+	"github.com/inturn/kit/ratelimit"
+	"github.com/inturn/kit/transport"
+	"github.com/inturn/kit/util/backoff"
 	"github.com/streadway/amqp"
 )
 
 // Subscriber wraps an endpoint and provides a handler for AMQP Delivery messages.
 type Subscriber struct {
-	e            endpoint.Endpoint
-	dec          DecodeRequestFunc
-	enc          EncodeResponseFunc
-	before       []RequestFunc
-	after        []SubscriberResponseFunc
-	finalizer    []SubscriberFinalizerFunc
-	errorEncoder ErrorEncoder
-	logger       log.Logger
+	e                 endpoint.Endpoint
+	dec               DecodeRequestFunc
+	enc               EncodeResponseFunc
+	before            []RequestFunc
+	after             []SubscriberResponseFunc
+	finalizer         []SubscriberFinalizerFunc
+	deliveryFinalizer []DeliveryFinalizerFunc
+	errorEncoder      ErrorEncoder
+	errorHandler      transport.ErrorHandler
+	ackMode           AckMode
+	concurrency       int
+	mandatory         bool
+	immediate         bool
+	recoverPanics     bool
+	deliveryMode      uint8
+	expiration        time.Duration
+	timeout           time.Duration
+
+	publishRetries  int
+	publishDelay    backoff.Strategy
+	publishFallback ChannelAcquirer
+
+	instrumentation *instrumentation
+	dedup           DedupStore
+	deliveryLogger  log.Logger
+	throttle        *throttle
+	middleware      []DeliveryMiddleware
+
+	validators             []ValidateRequestFunc
+	validationErrorEncoder ErrorEncoder
+	timeoutErrorEncoder    ErrorEncoder
 }
 
 // NewSubscriber constructs a new subscriber, which provides a handler
@@ -31,11 +59,12 @@ func NewSubscriber(
 	options ...SubscriberOption,
 ) *Subscriber {
 	s := &Subscriber{
-		e:            e,
-		dec:          dec,
-		enc:          enc,
-		errorEncoder: DefaultErrorEncoder,
-		logger:       log.NewNopLogger(),
+		e:             e,
+		dec:           dec,
+		enc:           enc,
+		errorEncoder:  DefaultErrorEncoder,
+		errorHandler:  transport.NewLogErrorHandler(log.NewNopLogger()),
+		recoverPanics: true,
 	}
 	for _, option := range options {
 		option(s)
@@ -70,8 +99,10 @@ func SubscriberErrorEncoder(ee ErrorEncoder) SubscriberOption {
 // are logged. This is intended as a diagnostic measure. Finer-grained control
 // of error handling, including logging in more detail, should be performed in a
 // custom SubscriberErrorEncoder which has access to the context.
+//
+// Deprecated: Use SubscriberErrorHandler instead.
 func SubscriberErrorLogger(logger log.Logger) SubscriberOption {
-	return func(s *Subscriber) { s.logger = logger }
+	return func(s *Subscriber) { s.errorHandler = transport.NewLogErrorHandler(logger) }
 }
 
 // ServerFinalizer is executed at the end of every MQ request.
@@ -80,15 +111,116 @@ func ServerFinalizer(f ...SubscriberFinalizerFunc) SubscriberOption {
 	return func(s *Subscriber) { s.finalizer = append(s.finalizer, f...) }
 }
 
+// SubscriberMandatory sets the mandatory flag of replies, making the broker
+// return replies it can't route to a queue instead of dropping them.
+// Returned replies are passed to the channel's NotifyReturn listeners, see
+// HandleReturns. It can be overridden per request with
+// SetPublishMandatory.
+func SubscriberMandatory(mandatory bool) SubscriberOption {
+	return func(s *Subscriber) { s.mandatory = mandatory }
+}
+
+// SubscriberImmediate sets the immediate flag of replies, making the broker
+// return replies it can't deliver to a consumer right away. RabbitMQ
+// doesn't support it and closes the connection. It can be overridden per
+// request with SetPublishImmediate.
+func SubscriberImmediate(immediate bool) SubscriberOption {
+	return func(s *Subscriber) { s.immediate = immediate }
+}
+
+// SubscriberReplyDeliveryMode sets the delivery mode of replies, overriding
+// whatever the encoder left in the Publishing. By default, replies are sent
+// transient unless the encoder says otherwise, and are lost if the broker
+// restarts before they are consumed. It can be overridden per request with
+// SetReplyDeliveryMode.
+func SubscriberReplyDeliveryMode(dmode uint8) SubscriberOption {
+	return func(s *Subscriber) { s.deliveryMode = dmode }
+}
+
+// SubscriberReplyExpiration sets the Expiration of replies, so the broker
+// discards replies nobody consumed in time. It can be overridden per
+// request with SetReplyExpiration.
+func SubscriberReplyExpiration(expiration time.Duration) SubscriberOption {
+	return func(s *Subscriber) { s.expiration = expiration }
+}
+
+// SubscriberRecoverPanics sets whether panics of the decoder, the endpoint
+// or any other function serving a delivery are recovered. Recovered panics
+// are handled like errors, as a PanicError passed to the error handler,
+// which logs their stack trace if it is a transport.LogErrorHandler, and to
+// the error encoder, so the delivery is still acknowledged according to the
+// error encoder and the ack mode. The default is true.
+func SubscriberRecoverPanics(enabled bool) SubscriberOption {
+	return func(s *Subscriber) { s.recoverPanics = enabled }
+}
+
 // ServeDelivery handles AMQP Delivery messages
 // It is strongly recommended to use *amqp.Channel as the
-// Channel interface implementation.
+// Channel interface implementation. If ch is a WatchedChannel, the context
+// of the request is canceled once the channel is done.
 func (s Subscriber) ServeDelivery(ch Channel) func(deliv *amqp.Delivery) {
+	handler := s.serveDelivery(ch)
+	for i := len(s.middleware) - 1; i >= 0; i-- {
+		handler = s.middleware[i](handler)
+	}
 	return func(deliv *amqp.Delivery) {
 		ctx, cancel := context.WithCancel(context.Background())
-		var err error
 		defer cancel()
 
+		// Cancel the request once a WatchedChannel is done.
+		if w, ok := ch.(interface{ Done() <-chan struct{} }); ok {
+			go func(done <-chan struct{}) {
+				select {
+				case <-w.Done():
+					cancel()
+				case <-done:
+				}
+			}(ctx.Done())
+		}
+
+		handler(ctx, deliv)
+	}
+}
+
+// serveDelivery returns the DeliveryHandler wrapped by the middleware set
+// by SubscriberWrap.
+func (s Subscriber) serveDelivery(ch Channel) DeliveryHandler {
+	return func(ctx context.Context, deliv *amqp.Delivery) {
+		var err error
+
+		if s.instrumentation != nil {
+			defer func(begin time.Time) {
+				s.instrumentation.observe(deliv.RoutingKey, begin, err)
+			}(time.Now())
+		}
+
+		if s.mandatory {
+			ctx = context.WithValue(ctx, ContextKeyMandatory, true)
+		}
+		if s.immediate {
+			ctx = context.WithValue(ctx, ContextKeyImmediate, true)
+		}
+		if s.deliveryMode != 0 {
+			ctx = context.WithValue(ctx, ContextKeyDeliveryMode, s.deliveryMode)
+		}
+		if s.expiration > 0 {
+			ctx = context.WithValue(ctx, ContextKeyExpiration, s.expiration)
+		}
+		if s.deliveryLogger != nil {
+			ctx = ContextWithLogger(ctx, DeliveryLogger(s.deliveryLogger, deliv))
+		}
+
+		pub := amqp.Publishing{}
+		report := DeliveryReport{Delivery: deliv}
+
+		if len(s.deliveryFinalizer) > 0 {
+			defer func() {
+				for _, f := range s.deliveryFinalizer {
+					f(ctx, err, report)
+				}
+			}()
+		}
+
 		if len(s.finalizer) > 0 {
 			defer func() {
 				for _, f := range s.finalizer {
@@ -97,50 +229,164 @@ func (s Subscriber) ServeDelivery(ch Channel) func(deliv *amqp.Delivery) {
 			}()
 		}
 
-		pub := amqp.Publishing{}
+		var acker *trackingAcknowledger
+		if s.ackMode != AckManual {
+			acker = &trackingAcknowledger{next: deliv.Acknowledger}
+			d := *deliv
+			d.Acknowledger = acker
+			deliv = &d
+		}
+
+		fail := func(stage ErrorStage, err error) {
+			s.handleError(ctx, stage, err)
+			ee := s.errorEncoder
+			if _, ok := err.(ValidationError); ok && s.validationErrorEncoder != nil {
+				ee = s.validationErrorEncoder
+			}
+			if _, ok := err.(TimeoutError); ok && s.timeoutErrorEncoder != nil {
+				ee = s.timeoutErrorEncoder
+			}
+			ee(ctx, err, deliv, ch, &pub)
+			if acker != nil && !acker.acknowledged() {
+				if err := deliv.Nack(false, false); err != nil {
+					s.handleError(ctx, ErrorStageAcknowledge, err)
+				}
+			}
+		}
+		ack := func() {
+			if acker.acknowledged() {
+				return
+			}
+			if err := deliv.Ack(false); err != nil {
+				s.handleError(ctx, ErrorStageAcknowledge, err)
+			}
+		}
+
+		if s.recoverPanics {
+			defer func() {
+				if v := recover(); v != nil {
+					err = PanicError{Value: v, Stack: debug.Stack()}
+					fail(ErrorStagePanic, err)
+				}
+			}()
+		}
 
 		for _, f := range s.before {
 			ctx = f(ctx, &pub, deliv)
 		}
 
+		if s.seen(ctx, deliv) {
+			if err := deliv.Ack(false); err != nil {
+				s.handleError(ctx, ErrorStageAcknowledge, err)
+			}
+			return
+		}
+
+		if s.throttle != nil {
+			if err = s.throttle.wait(ctx, deliv); err != nil {
+				if err != ratelimit.ErrLimited {
+					s.handleError(ctx, ErrorStageThrottle, err)
+				}
+				if err := deliv.Nack(false, true); err != nil {
+					s.handleError(ctx, ErrorStageAcknowledge, err)
+				}
+				return
+			}
+		}
+
+		begin := time.Now()
 		request, err := s.dec(ctx, deliv)
+		report.Decode = time.Since(begin)
 		if err != nil {
-			s.logger.Log("err", err)
-			s.errorEncoder(ctx, err, deliv, ch, &pub)
+			fail(ErrorStageDecode, err)
 			return
 		}
 
-		response, err := s.e(ctx, request)
+		for _, validate := range s.validators {
+			if err = validate(ctx, request); err != nil {
+				err = ValidationError{Err: err}
+				fail(ErrorStageValidate, err)
+				return
+			}
+		}
+
+		begin = time.Now()
+		response, err := s.callEndpoint(ctx, request)
+		report.Endpoint = time.Since(begin)
 		if err != nil {
-			s.logger.Log("err", err)
-			s.errorEncoder(ctx, err, deliv, ch, &pub)
+			fail(ErrorStageEndpoint, err)
 			return
 		}
 
+		if s.ackMode == AckOnSuccess {
+			ack()
+		}
+
 		for _, f := range s.after {
 			ctx = f(ctx, deliv, ch, &pub)
 		}
 
-		if err = s.enc(ctx, &pub, response); err != nil {
-			s.logger.Log("err", err)
-			s.errorEncoder(ctx, err, deliv, ch, &pub)
+		begin = time.Now()
+		err = s.enc(ctx, &pub, response)
+		report.Encode = time.Since(begin)
+		if err != nil {
+			fail(ErrorStageEncode, err)
 			return
 		}
 
-		if err = s.publishResponse(ctx, deliv, ch, &pub); err != nil {
-			s.logger.Log("err", err)
-			s.errorEncoder(ctx, err, deliv, ch, &pub)
+		begin = time.Now()
+		err = s.publishResponse(ctx, deliv, ch, &pub)
+		report.Publish = time.Since(begin)
+		if err != nil {
+			fail(ErrorStagePublish, err)
 			return
 		}
+		report.Reply = &pub
+
+		if s.dedup != nil && deliv.MessageId != "" {
+			if err := s.dedup.Add(ctx, deliv.MessageId); err != nil {
+				s.handleError(ctx, ErrorStageDeduplicate, err)
+			}
+		}
+
+		if s.ackMode == AckAfterPublish {
+			ack()
+		}
 	}
 
 }
 
+// seen reports whether the delivery was served before, according to the
+// DedupStore set by SubscriberDeduplication.
+func (s Subscriber) seen(ctx context.Context, deliv *amqp.Delivery) bool {
+	if s.dedup == nil || deliv.MessageId == "" {
+		return false
+	}
+	seen, err := s.dedup.Contains(ctx, deliv.MessageId)
+	if err != nil {
+		s.handleError(ctx, ErrorStageDeduplicate, err)
+		return false
+	}
+	return seen
+}
+
 func (s Subscriber) publishResponse(
 	ctx context.Context,
 	deliv *amqp.Delivery,
 	ch Channel,
 	pub *amqp.Publishing,
+) error {
+	return s.publishWithRetry(ctx, deliv, ch, pub)
+}
+
+// publishReply publishes pub as the reply to deliv, to the exchange and key
+// set with SetPublishExchange and SetPublishKey, or to the ReplyTo address
+// of deliv on the default exchange.
+func publishReply(
+	ctx context.Context,
+	deliv *amqp.Delivery,
+	ch Channel,
+	pub *amqp.Publishing,
 ) error {
 	if pub.CorrelationId == "" {
 		pub.CorrelationId = deliv.CorrelationId
@@ -151,12 +397,17 @@ func (s Subscriber) publishResponse(
 	if replyTo == "" {
 		replyTo = deliv.ReplyTo
 	}
+	// Replies to direct reply-to must be published on the default exchange.
+	if isDirectReplyTo(replyTo) {
+		replyExchange = ""
+	}
+	setReplyProperties(ctx, pub)
 
 	return ch.Publish(
 		replyExchange,
 		replyTo,
-		false, // mandatory
-		false, // immediate
+		getPublishMandatory(ctx),
+		getPublishImmediate(ctx),
 		*pub,
 	)
 }
@@ -198,15 +449,29 @@ func DefaultErrorEncoder(ctx context.Context,
 	err error, deliv *amqp.Delivery, ch Channel, pub *amqp.Publishing) {
 }
 
+// NackNoRequeueErrorEncoder issues a Nack to the delivery with multiple and
+// requeue flags set as false, so it lands in the dead letter exchange of
+// the queue, if it has one, or is dropped. It does not reply the message.
+// See DeadLetterErrorEncoder for dead-lettering annotated with the error.
+func NackNoRequeueErrorEncoder(ctx context.Context,
+	err error, deliv *amqp.Delivery, ch Channel, pub *amqp.Publishing) {
+	deliv.Nack(
+		false, //multiple
+		false, //requeue
+	)
+}
+
 // SingleNackRequeueErrorEncoder issues a Nack to the delivery with multiple flag set as false
 // and requeue flag set as true. It does not reply the message.
+// Deliveries that always fail are redelivered forever; see RetryErrorEncoder
+// for a bounded alternative.
 func SingleNackRequeueErrorEncoder(ctx context.Context,
 	err error, deliv *amqp.Delivery, ch Channel, pub *amqp.Publishing) {
 	deliv.Nack(
 		false, //multiple
 		true,  //requeue
 	)
-	duration := getNackSleepDuration(ctx)
+	duration := getNackSleepDuration(ctx, deliv)
 	time.Sleep(duration)
 }
 
@@ -220,16 +485,6 @@ func ReplyErrorEncoder(
 	pub *amqp.Publishing,
 ) {
 
-	if pub.CorrelationId == "" {
-		pub.CorrelationId = deliv.CorrelationId
-	}
-
-	replyExchange := getPublishExchange(ctx)
-	replyTo := getPublishKey(ctx)
-	if replyTo == "" {
-		replyTo = deliv.ReplyTo
-	}
-
 	response := DefaultErrorResponse{err.Error()}
 
 	b, err := json.Marshal(response)
@@ -238,13 +493,7 @@ func ReplyErrorEncoder(
 	}
 	pub.Body = b
 
-	ch.Publish(
-		replyExchange,
-		replyTo,
-		false, // mandatory
-		false, // immediate
-		*pub,
-	)
+	publishReply(ctx, deliv, ch, pub)
 }
 
 // ReplyAndAckErrorEncoder serializes the error message as a DefaultErrorResponse
@@ -261,6 +510,24 @@ type DefaultErrorResponse struct {
 	Error string `json:"err"`
 }
 
+// PanicError is passed to the error encoder for panics recovered while
+// serving a delivery, see SubscriberRecoverPanics.
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+// Error implements the error interface.
+func (e PanicError) Error() string {
+	return fmt.Sprintf("panic serving delivery: %v", e.Value)
+}
+
+// StackTrace returns the stack trace of the panic, so a
+// transport.LogErrorHandler logs it along with the error.
+func (e PanicError) StackTrace() []byte {
+	return e.Stack
+}
+
 // ServerFinalizerFunc can be used to perform work at the end of an MQ
 // request, after the response has been written to the client. The principal
 // intended use is for request logging. In addition to the response code
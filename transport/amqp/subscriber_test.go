@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/inturn/kit/endpoint"
 	amqptransport "github.com/inturn/kit/transport/amqp"
 	"github.com/streadway/amqp"
 )
@@ -327,6 +328,94 @@ func TestDefaultContentMetaData(t *testing.T) {
 	}
 }
 
+func TestSubscriberReplyProperties(t *testing.T) {
+	for _, testcase := range []struct {
+		name       string
+		before     []amqptransport.RequestFunc
+		endpoint   endpoint.Endpoint
+		expiration string
+	}{
+		{"reply", nil, testEndpoint, "60000"},
+		{"error reply", nil, func(context.Context, interface{}) (interface{}, error) {
+			return nil, errors.New("err!")
+		}, "60000"},
+		{"per request", []amqptransport.RequestFunc{
+			amqptransport.SetReplyExpiration(time.Second),
+		}, testEndpoint, "1000"},
+	} {
+		t.Run(testcase.name, func(t *testing.T) {
+			sub := amqptransport.NewSubscriber(
+				testcase.endpoint,
+				testReqDecoder,
+				amqptransport.EncodeJSONResponse,
+				amqptransport.SubscriberErrorEncoder(amqptransport.ReplyErrorEncoder),
+				amqptransport.SubscriberReplyDeliveryMode(amqp.Persistent),
+				amqptransport.SubscriberReplyExpiration(time.Minute),
+				amqptransport.SubscriberBefore(testcase.before...),
+			)
+			outputChan := make(chan amqp.Publishing, 1)
+			ch := &mockChannel{f: nullFunc, c: outputChan}
+			sub.ServeDelivery(ch)(&amqp.Delivery{Body: []byte(`{"s":436}`)})
+			msg := <-outputChan
+
+			if want, have := amqp.Persistent, msg.DeliveryMode; want != have {
+				t.Errorf("want delivery mode %d, have %d", want, have)
+			}
+			if want, have := testcase.expiration, msg.Expiration; want != have {
+				t.Errorf("want expiration %q, have %q", want, have)
+			}
+		})
+	}
+}
+
+func TestSubscriberRecoverPanics(t *testing.T) {
+	var (
+		encoded   error
+		finalized error
+	)
+	sub := amqptransport.NewSubscriber(
+		func(context.Context, interface{}) (interface{}, error) { panic("dummy") },
+		testReqDecoder,
+		amqptransport.EncodeJSONResponse,
+		amqptransport.SubscriberAckMode(amqptransport.AckOnSuccess),
+		amqptransport.SubscriberErrorEncoder(func(ctx context.Context, err error, deliv *amqp.Delivery, ch amqptransport.Channel, pub *amqp.Publishing) {
+			encoded = err
+		}),
+		amqptransport.ServerFinalizer(func(ctx context.Context, err error) { finalized = err }),
+	)
+	acker := &mockAcknowledger{}
+	sub.ServeDelivery(&mockChannel{})(&amqp.Delivery{Acknowledger: acker, Body: []byte(`{"s":436}`)})
+
+	e, ok := encoded.(amqptransport.PanicError)
+	if !ok {
+		t.Fatalf("want PanicError, have %v", encoded)
+	}
+	if want, have := "dummy", e.Value; want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	if len(e.Stack) == 0 {
+		t.Error("want stack trace, have none")
+	}
+	if want, have := encoded, finalized; want.Error() != have.Error() {
+		t.Errorf("want finalizer error %v, have %v", want, have)
+	}
+	if want, have := 1, acker.nacks; want != have {
+		t.Errorf("want %d nacks, have %d", want, have)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("want panic with recovery disabled, have none")
+		}
+	}()
+	amqptransport.NewSubscriber(
+		func(context.Context, interface{}) (interface{}, error) { panic("dummy") },
+		testReqDecoder,
+		amqptransport.EncodeJSONResponse,
+		amqptransport.SubscriberRecoverPanics(false),
+	).ServeDelivery(&mockChannel{})(&amqp.Delivery{Body: []byte(`{"s":436}`)})
+}
+
 func decodeSubscriberError(pub amqp.Publishing) (amqptransport.DefaultErrorResponse, error) {
 	var res amqptransport.DefaultErrorResponse
 	err := json.Unmarshal(pub.Body, &res)
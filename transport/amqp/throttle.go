@@ -0,0 +1,96 @@
+package amqp
+
+import (
+	"context"
+
+	"github.com/streadway/amqp"
+
+	"github.com/inturn/kit/internal/lru"
+	"github.com/inturn/kit/ratelimit"
+)
+
+// throttleSize is the maximum number of keys a throttled subscriber keeps a
+// limiter for.
+const throttleSize = 10000
+
+// DeliveryKeyFunc derives the key a delivery is throttled by from the
+// delivery, e.g. its routing key or a tenant header.
+type DeliveryKeyFunc func(d *amqp.Delivery) string
+
+// DeliveryRoutingKey is a DeliveryKeyFunc returning the routing key of d.
+func DeliveryRoutingKey(d *amqp.Delivery) string {
+	return d.RoutingKey
+}
+
+// DeliveryHeader returns a DeliveryKeyFunc returning the string value of the
+// header key. Deliveries without it share the empty key.
+func DeliveryHeader(key string) DeliveryKeyFunc {
+	return func(d *amqp.Delivery) string {
+		s, _ := d.Headers[key].(string)
+		return s
+	}
+}
+
+// throttle limits the rate at which a subscriber serves deliveries per key.
+type throttle struct {
+	key      DeliveryKeyFunc
+	limiters *lru.Cache
+	create   func(key string) interface{}
+	delay    bool
+}
+
+// SubscriberThrottle limits the rate at which deliveries are served per key,
+// so a burst of one kind of message can't overload the dependencies of its
+// endpoint. The limiter of a key, e.g. a *rate.Limiter of
+// golang.org/x/time/rate, is created by newLimiter when the key is first
+// seen:
+//
+//	amqptransport.SubscriberThrottle(amqptransport.DeliveryRoutingKey, func(string) ratelimit.Allower {
+//	    return rate.NewLimiter(10, 10)
+//	})
+//
+// Deliveries exceeding the limit of their key are requeued without being
+// decoded, and the finalizers see ratelimit.ErrLimited. The broker
+// redelivers them right away, so combine it with a small prefetch count, or
+// use SubscriberThrottleDelay if deliveries of other keys should not be
+// held up by the consumer.
+func SubscriberThrottle(key DeliveryKeyFunc, newLimiter func(key string) ratelimit.Allower) SubscriberOption {
+	return func(s *Subscriber) {
+		s.throttle = &throttle{
+			key:      key,
+			limiters: lru.New(throttleSize),
+			create:   func(k string) interface{} { return newLimiter(k) },
+		}
+	}
+}
+
+// SubscriberThrottleDelay limits the rate at which deliveries are served per
+// key like SubscriberThrottle, but delays deliveries exceeding the limit of
+// their key until the limiter created by newLimiter allows them, instead of
+// requeueing them. Delayed deliveries hold their prefetch slot, so with
+// SubscriberConcurrency the other keys are served in the meantime. If the
+// channel closes while waiting, the delivery is requeued.
+func SubscriberThrottleDelay(key DeliveryKeyFunc, newLimiter func(key string) ratelimit.Waiter) SubscriberOption {
+	return func(s *Subscriber) {
+		s.throttle = &throttle{
+			key:      key,
+			limiters: lru.New(throttleSize),
+			create:   func(k string) interface{} { return newLimiter(k) },
+			delay:    true,
+		}
+	}
+}
+
+// wait returns nil once deliv may be served, or ratelimit.ErrLimited if it
+// exceeds the limit of its key and isn't to be delayed.
+func (t *throttle) wait(ctx context.Context, deliv *amqp.Delivery) error {
+	k := t.key(deliv)
+	limiter := t.limiters.GetOrCreate(k, func() interface{} { return t.create(k) })
+	if t.delay {
+		return limiter.(ratelimit.Waiter).Wait(ctx)
+	}
+	if !limiter.(ratelimit.Allower).Allow() {
+		return ratelimit.ErrLimited
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package amqp_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/streadway/amqp"
+
+	"github.com/inturn/kit/ratelimit"
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+func TestSubscriberThrottle(t *testing.T) {
+	var calls int
+	var errs []error
+	sub := amqptransport.NewSubscriber(
+		func(ctx context.Context, request interface{}) (interface{}, error) {
+			calls++
+			return testEndpoint(ctx, request)
+		},
+		testReqDecoder,
+		amqptransport.EncodeJSONResponse,
+		amqptransport.SubscriberThrottle(amqptransport.DeliveryRoutingKey, func(string) ratelimit.Allower {
+			budget := 2
+			return ratelimit.AllowerFunc(func() bool {
+				budget--
+				return budget >= 0
+			})
+		}),
+		amqptransport.ServerFinalizer(func(ctx context.Context, err error) { errs = append(errs, err) }),
+	)
+	ch := &countingChannel{}
+	acker := &mockAcknowledger{}
+	for _, key := range []string{"orders", "orders", "orders", "payments"} {
+		sub.ServeDelivery(ch)(&amqp.Delivery{
+			Acknowledger: acker,
+			RoutingKey:   key,
+			Body:         []byte(`{"s":437}`),
+		})
+	}
+
+	if want, have := 3, calls; want != have {
+		t.Errorf("want %d endpoint calls, have %d", want, have)
+	}
+	if want, have := ratelimit.ErrLimited, errs[2]; want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	if want, have := 1, acker.nacks; want != have || !acker.requeue {
+		t.Errorf("want %d requeued nacks, have %d (requeue %v)", want, have, acker.requeue)
+	}
+}
+
+func TestSubscriberThrottleDelay(t *testing.T) {
+	var waited []string
+	sub := amqptransport.NewSubscriber(
+		testEndpoint,
+		testReqDecoder,
+		amqptransport.EncodeJSONResponse,
+		amqptransport.SubscriberThrottleDelay(amqptransport.DeliveryHeader("x-tenant"), func(tenant string) ratelimit.Waiter {
+			return ratelimit.WaiterFunc(func(ctx context.Context) error {
+				waited = append(waited, tenant)
+				if tenant == "closed" {
+					return context.Canceled
+				}
+				return nil
+			})
+		}),
+	)
+	ch := &countingChannel{}
+	acker := &mockAcknowledger{}
+	for _, tenant := range []string{"acme", "closed"} {
+		sub.ServeDelivery(ch)(&amqp.Delivery{
+			Acknowledger: acker,
+			Headers:      amqp.Table{"x-tenant": tenant},
+			Body:         []byte(`{"s":437}`),
+		})
+	}
+
+	if want, have := 2, len(waited); want != have {
+		t.Fatalf("want %d waits, have %d", want, have)
+	}
+	if want, have := int32(1), ch.published; want != have {
+		t.Errorf("want %d replies, have %d", want, have)
+	}
+	if want, have := 1, acker.nacks; want != have || !acker.requeue {
+		t.Errorf("want %d requeued nacks, have %d (requeue %v)", want, have, acker.requeue)
+	}
+}
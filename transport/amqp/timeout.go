@@ -0,0 +1,67 @@
+package amqp
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// TimeoutError wraps the errors of endpoints that ran past the timeout set
+// by SubscriberTimeout.
+type TimeoutError struct {
+	Timeout time.Duration
+	Err     error
+}
+
+// Error implements the error interface.
+func (e TimeoutError) Error() string {
+	return fmt.Sprintf("endpoint timed out after %v: %v", e.Timeout, e.Err)
+}
+
+// ErrorCode implements ErrorCoder, returning "timeout".
+func (e TimeoutError) ErrorCode() string {
+	return "timeout"
+}
+
+// Unwrap returns the error of the endpoint.
+func (e TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// SubscriberTimeout bounds the time the endpoint works on a request to
+// timeout, by setting the deadline of the context passed to it. Endpoints
+// must give up once the context is done, the deadline doesn't interrupt
+// them. If the endpoint fails after the timeout elapsed, its error, wrapped
+// in a TimeoutError, is passed to the error encoder set by
+// SubscriberTimeoutErrorEncoder, or to the subscriber's error encoder if
+// none is set. By default, there is no timeout.
+func SubscriberTimeout(timeout time.Duration) SubscriberOption {
+	return func(s *Subscriber) { s.timeout = timeout }
+}
+
+// SubscriberTimeoutErrorEncoder sets the error encoder of requests whose
+// endpoint timed out, see SubscriberTimeout, keeping them apart from other
+// failures, e.g. to requeue them while replying to the others:
+//
+//	amqptransport.SubscriberTimeout(5 * time.Second),
+//	amqptransport.SubscriberTimeoutErrorEncoder(amqptransport.RetryErrorEncoder(3, "orders")),
+//	amqptransport.SubscriberErrorEncoder(amqptransport.ReplyAndAckErrorEncoder),
+func SubscriberTimeoutErrorEncoder(ee ErrorEncoder) SubscriberOption {
+	return func(s *Subscriber) { s.timeoutErrorEncoder = ee }
+}
+
+// callEndpoint calls the endpoint of the subscriber with the timeout set
+// by SubscriberTimeout.
+func (s Subscriber) callEndpoint(ctx context.Context, request interface{}) (interface{}, error) {
+	if s.timeout <= 0 {
+		return s.e(ctx, request)
+	}
+	ectx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	response, err := s.e(ectx, request)
+	// Only wrap errors caused by the timeout, not by a deadline of ctx.
+	if err != nil && ectx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+		err = TimeoutError{Timeout: s.timeout, Err: err}
+	}
+	return response, err
+}
@@ -0,0 +1,56 @@
+package amqp_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+func TestSubscriberTimeout(t *testing.T) {
+	var timedOut, other []error
+	sub := amqptransport.NewSubscriber(
+		func(ctx context.Context, request interface{}) (interface{}, error) {
+			if request.(testReq).Squadron == 0 {
+				return nil, errors.New("dummy")
+			}
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(time.Second):
+				return testRes{}, nil
+			}
+		},
+		testReqDecoder,
+		amqptransport.EncodeJSONResponse,
+		amqptransport.SubscriberTimeout(10*time.Millisecond),
+		amqptransport.SubscriberErrorEncoder(func(_ context.Context, err error, _ *amqp.Delivery, _ amqptransport.Channel, _ *amqp.Publishing) {
+			other = append(other, err)
+		}),
+		amqptransport.SubscriberTimeoutErrorEncoder(func(_ context.Context, err error, _ *amqp.Delivery, _ amqptransport.Channel, _ *amqp.Publishing) {
+			timedOut = append(timedOut, err)
+		}),
+	)
+	ch := &countingChannel{}
+	for _, body := range []string{`{"s":437}`, `{"s":0}`} {
+		sub.ServeDelivery(ch)(&amqp.Delivery{Body: []byte(body)})
+	}
+
+	if want, have := 1, len(timedOut); want != have {
+		t.Fatalf("incorrect number of timeouts, want %d, have %d", want, have)
+	}
+	var te amqptransport.TimeoutError
+	if !errors.As(timedOut[0], &te) || !errors.Is(timedOut[0], context.DeadlineExceeded) {
+		t.Errorf("want TimeoutError wrapping deadline exceeded, have %v", timedOut[0])
+	}
+	if want, have := 1, len(other); want != have {
+		t.Errorf("incorrect number of other errors, want %d, have %d", want, have)
+	}
+	if want, have := int32(0), ch.published; want != have {
+		t.Errorf("incorrect number of replies, want %d, have %d", want, have)
+	}
+}
@@ -0,0 +1,155 @@
+package amqp
+
+import (
+	"github.com/streadway/amqp"
+)
+
+// TopologyChannel declares exchanges, queues and bindings, like
+// *amqp.Channel.
+type TopologyChannel interface {
+	ExchangeDeclare(name, kind string, durable, autoDelete, internal, noWait bool, args amqp.Table) error
+	QueueDeclare(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error)
+	QueueBind(name, key, exchange string, noWait bool, args amqp.Table) error
+	Qos(prefetchCount, prefetchSize int, global bool) error
+}
+
+// Topology describes the exchanges, queues and bindings a service relies
+// on. Declarations are idempotent as long as the arguments don't change,
+// so every instance of a service can apply the same Topology on startup.
+type Topology struct {
+	Exchanges []Exchange
+	Queues    []Queue
+	Bindings  []Binding
+
+	// QoS, if set, is applied to the channel the Topology is applied to.
+	QoS *QoS
+}
+
+// Exchange describes an exchange.
+type Exchange struct {
+	Name       string
+	Kind       string // e.g. amqp.ExchangeDirect
+	Durable    bool
+	AutoDelete bool
+	Internal   bool
+	Args       amqp.Table
+}
+
+// QueueType is the type of a RabbitMQ queue.
+type QueueType string
+
+// Queue types. Quorum queues and streams are replicated and must be
+// durable.
+const (
+	QueueTypeClassic QueueType = "classic"
+	QueueTypeQuorum  QueueType = "quorum"
+	QueueTypeStream  QueueType = "stream"
+)
+
+// Queue describes a queue.
+type Queue struct {
+	Name       string
+	Durable    bool
+	AutoDelete bool
+	Exclusive  bool
+	Args       amqp.Table
+
+	// Type, if set, is the type of the queue. The broker declares classic
+	// queues by default.
+	Type QueueType
+
+	// DeadLetterExchange, if set, is the exchange rejected and expired
+	// messages are republished to, with their routing key or
+	// DeadLetterRoutingKey if set.
+	DeadLetterExchange   string
+	DeadLetterRoutingKey string
+
+	// DeliveryLimit, if set, is the number of times a quorum queue
+	// redelivers a message before it is dead-lettered or dropped.
+	DeliveryLimit int
+
+	// MaxLengthBytes, if set, is the size of a stream, or of a queue, in
+	// bytes beyond which the oldest messages are discarded.
+	MaxLengthBytes int64
+
+	// MaxAge, if set, is the age beyond which the segments of a stream
+	// are discarded, e.g. "7D" or "12h".
+	MaxAge string
+}
+
+// Binding describes the binding of a queue to an exchange.
+type Binding struct {
+	Queue    string
+	Exchange string
+	Key      string
+	Args     amqp.Table
+}
+
+// QoS describes the prefetch limits of a channel, see amqp.Channel.Qos.
+type QoS struct {
+	PrefetchCount int
+	PrefetchSize  int
+	Global        bool
+}
+
+// Apply declares the exchanges, then the queues, then the bindings of t on
+// ch, and sets its QoS. It stops at the first error.
+func (t Topology) Apply(ch TopologyChannel) error {
+	for _, e := range t.Exchanges {
+		if err := ch.ExchangeDeclare(e.Name, e.Kind, e.Durable, e.AutoDelete, e.Internal, false, e.Args); err != nil {
+			return err
+		}
+	}
+	for _, q := range t.Queues {
+		if _, err := ch.QueueDeclare(q.Name, q.Durable, q.AutoDelete, q.Exclusive, false, q.args()); err != nil {
+			return err
+		}
+	}
+	for _, b := range t.Bindings {
+		if err := ch.QueueBind(b.Queue, b.Key, b.Exchange, false, b.Args); err != nil {
+			return err
+		}
+	}
+	if t.QoS != nil {
+		return ch.Qos(t.QoS.PrefetchCount, t.QoS.PrefetchSize, t.QoS.Global)
+	}
+	return nil
+}
+
+// args returns the arguments of q, including those of its fields.
+func (q Queue) args() amqp.Table {
+	if q.Type == "" && q.DeadLetterExchange == "" && q.DeliveryLimit == 0 && q.MaxLengthBytes == 0 && q.MaxAge == "" {
+		return q.Args
+	}
+	args := amqp.Table{}
+	for k, v := range q.Args {
+		args[k] = v
+	}
+	if q.Type != "" {
+		args["x-queue-type"] = string(q.Type)
+	}
+	if q.DeadLetterExchange != "" {
+		args["x-dead-letter-exchange"] = q.DeadLetterExchange
+		if q.DeadLetterRoutingKey != "" {
+			args["x-dead-letter-routing-key"] = q.DeadLetterRoutingKey
+		}
+	}
+	if q.DeliveryLimit > 0 {
+		args["x-delivery-limit"] = int64(q.DeliveryLimit)
+	}
+	if q.MaxLengthBytes > 0 {
+		args["x-max-length-bytes"] = q.MaxLengthBytes
+	}
+	if q.MaxAge != "" {
+		args["x-max-age"] = q.MaxAge
+	}
+	return args
+}
+
+// ConnectionManagerDeclare adds a Topology, which is applied with a channel
+// of every new connection before consumers are registered. Consumers of a
+// ConnectionManager use channels of their own, so the QoS of t doesn't
+// apply to them.
+func ConnectionManagerDeclare(t Topology) ConnectionManagerOption {
+	return ConnectionManagerTopology(func(ch *amqp.Channel) error { return t.Apply(ch) })
+}
@@ -0,0 +1,126 @@
+package amqp_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/streadway/amqp"
+
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+// topologyChannel records the declarations it receives.
+type topologyChannel struct {
+	calls []string
+	args  map[string]amqp.Table
+	err   error
+}
+
+func (ch *topologyChannel) ExchangeDeclare(name, kind string, durable, autoDelete, internal, noWait bool, args amqp.Table) error {
+	ch.calls = append(ch.calls, fmt.Sprintf("exchange %s %s", name, kind))
+	return ch.err
+}
+
+func (ch *topologyChannel) QueueDeclare(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error) {
+	ch.calls = append(ch.calls, "queue "+name)
+	if ch.args == nil {
+		ch.args = map[string]amqp.Table{}
+	}
+	ch.args[name] = args
+	return amqp.Queue{Name: name}, nil
+}
+
+func (ch *topologyChannel) QueueBind(name, key, exchange string, noWait bool, args amqp.Table) error {
+	ch.calls = append(ch.calls, fmt.Sprintf("bind %s %s %s", name, key, exchange))
+	return nil
+}
+
+func (ch *topologyChannel) Qos(prefetchCount, prefetchSize int, global bool) error {
+	ch.calls = append(ch.calls, fmt.Sprintf("qos %d", prefetchCount))
+	return nil
+}
+
+func TestTopologyApply(t *testing.T) {
+	topology := amqptransport.Topology{
+		Exchanges: []amqptransport.Exchange{
+			{Name: "orders", Kind: amqp.ExchangeTopic, Durable: true},
+			{Name: "orders.dlx", Kind: amqp.ExchangeFanout, Durable: true},
+		},
+		Queues: []amqptransport.Queue{
+			{
+				Name:               "orders.created",
+				Durable:            true,
+				Args:               amqp.Table{"x-max-priority": int32(5)},
+				DeadLetterExchange: "orders.dlx",
+			},
+			{Name: "orders.dead", Durable: true},
+		},
+		Bindings: []amqptransport.Binding{
+			{Queue: "orders.created", Exchange: "orders", Key: "order.created"},
+			{Queue: "orders.dead", Exchange: "orders.dlx"},
+		},
+		QoS: &amqptransport.QoS{PrefetchCount: 10},
+	}
+	ch := &topologyChannel{}
+	if err := topology.Apply(ch); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"exchange orders topic",
+		"exchange orders.dlx fanout",
+		"queue orders.created",
+		"queue orders.dead",
+		"bind orders.created order.created orders",
+		"bind orders.dead  orders.dlx",
+		"qos 10",
+	}
+	if !reflect.DeepEqual(want, ch.calls) {
+		t.Errorf("want calls %q, have %q", want, ch.calls)
+	}
+	wantArgs := amqp.Table{"x-max-priority": int32(5), "x-dead-letter-exchange": "orders.dlx"}
+	if have := ch.args["orders.created"]; !reflect.DeepEqual(wantArgs, have) {
+		t.Errorf("want args %v, have %v", wantArgs, have)
+	}
+	if have := topology.Queues[0].Args; len(have) != 1 {
+		t.Errorf("want queue args left alone, have %v", have)
+	}
+}
+
+func TestTopologyApplyError(t *testing.T) {
+	ch := &topologyChannel{err: errors.New("access refused")}
+	err := amqptransport.Topology{
+		Exchanges: []amqptransport.Exchange{{Name: "orders", Kind: amqp.ExchangeTopic}},
+		Queues:    []amqptransport.Queue{{Name: "orders.created"}},
+	}.Apply(ch)
+	if want, have := ch.err, err; want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	if want, have := 1, len(ch.calls); want != have {
+		t.Errorf("want %d calls, have %d", want, have)
+	}
+}
+
+func TestTopologyQueueTypes(t *testing.T) {
+	ch := &topologyChannel{}
+	err := amqptransport.Topology{
+		Queues: []amqptransport.Queue{
+			{Name: "orders", Durable: true, Type: amqptransport.QueueTypeQuorum, DeliveryLimit: 5},
+			{Name: "events", Durable: true, Type: amqptransport.QueueTypeStream, MaxLengthBytes: 1 << 30, MaxAge: "7D"},
+		},
+	}.Apply(ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for queue, want := range map[string]amqp.Table{
+		"orders": {"x-queue-type": "quorum", "x-delivery-limit": int64(5)},
+		"events": {"x-queue-type": "stream", "x-max-length-bytes": int64(1 << 30), "x-max-age": "7D"},
+	} {
+		if have := ch.args[queue]; !reflect.DeepEqual(want, have) {
+			t.Errorf("%s: want args %v, have %v", queue, want, have)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package amqp
+
+import (
+	"context"
+)
+
+// ValidateRequestFunc validates a decoded request before it is passed to the
+// endpoint.
+type ValidateRequestFunc func(ctx context.Context, request interface{}) error
+
+// ValidationError wraps the errors of ValidateRequestFuncs. Invalid requests
+// fail the same way on every attempt, so error encoders should not retry
+// them.
+type ValidationError struct {
+	Err error
+}
+
+// Error implements the error interface.
+func (e ValidationError) Error() string {
+	return "invalid request: " + e.Err.Error()
+}
+
+// ErrorCode implements ErrorCoder, returning the code of the error of the
+// ValidateRequestFunc, if it has one, or "invalid_request".
+func (e ValidationError) ErrorCode() string {
+	if coder, ok := e.Err.(ErrorCoder); ok {
+		return coder.ErrorCode()
+	}
+	return "invalid_request"
+}
+
+// Unwrap returns the error of the ValidateRequestFunc.
+func (e ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// SubscriberValidator adds functions validating every request after it was
+// decoded. If one fails, the endpoint isn't called, and the error, wrapped
+// in a ValidationError, is passed to the error encoder set by
+// SubscriberValidationErrorEncoder, or to the subscriber's error encoder if
+// none is set.
+func SubscriberValidator(validate ...ValidateRequestFunc) SubscriberOption {
+	return func(s *Subscriber) { s.validators = append(s.validators, validate...) }
+}
+
+// SubscriberValidationErrorEncoder sets the error encoder for requests that
+// failed validation, keeping them apart from transient failures handled by
+// the subscriber's error encoder, e.g. to reply to invalid requests and
+// reject them to the dead letter exchange while retrying the others:
+//
+//	amqptransport.SubscriberErrorEncoder(amqptransport.RetryErrorEncoder(5, "orders")),
+//	amqptransport.SubscriberValidationErrorEncoder(amqptransport.ReplyErrorEncoder),
+//	amqptransport.SubscriberAckMode(amqptransport.AckOnSuccess),
+func SubscriberValidationErrorEncoder(ee ErrorEncoder) SubscriberOption {
+	return func(s *Subscriber) { s.validationErrorEncoder = ee }
+}
@@ -0,0 +1,49 @@
+package amqp_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/streadway/amqp"
+
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+func TestSubscriberValidator(t *testing.T) {
+	var transient, invalid []error
+	sub := amqptransport.NewSubscriber(
+		testEndpoint,
+		testReqDecoder,
+		amqptransport.EncodeJSONResponse,
+		amqptransport.SubscriberValidator(func(_ context.Context, request interface{}) error {
+			if request.(testReq).Squadron < 0 {
+				return errors.New("negative squadron")
+			}
+			return nil
+		}),
+		amqptransport.SubscriberErrorEncoder(func(_ context.Context, err error, _ *amqp.Delivery, _ amqptransport.Channel, _ *amqp.Publishing) {
+			transient = append(transient, err)
+		}),
+		amqptransport.SubscriberValidationErrorEncoder(func(_ context.Context, err error, _ *amqp.Delivery, _ amqptransport.Channel, _ *amqp.Publishing) {
+			invalid = append(invalid, err)
+		}),
+	)
+	ch := &countingChannel{}
+	for _, body := range []string{`{"s":-1}`, `{"s":1}`, `{"s":437}`} {
+		sub.ServeDelivery(ch)(&amqp.Delivery{Body: []byte(body)})
+	}
+
+	if want, have := 1, len(invalid); want != have {
+		t.Fatalf("incorrect number of validation errors, want %d, have %d", want, have)
+	}
+	if want, have := "invalid request: negative squadron", invalid[0].Error(); want != have {
+		t.Errorf("want %q, have %q", want, have)
+	}
+	if want, have := 1, len(transient); want != have {
+		t.Errorf("incorrect number of other errors, want %d, have %d", want, have)
+	}
+	if want, have := int32(1), ch.published; want != have {
+		t.Errorf("incorrect number of replies, want %d, have %d", want, have)
+	}
+}
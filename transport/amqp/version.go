@@ -0,0 +1,149 @@
+package amqp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/streadway/amqp"
+)
+
+// SchemaVersionHeader is the header carrying the version of the schema of
+// a request or a reply body.
+const SchemaVersionHeader = "x-schema-version"
+
+// UnsupportedSchemaVersionError is returned by VersionRegistry.DecodeRequest
+// for deliveries with a schema version no codec is registered for.
+type UnsupportedSchemaVersionError struct {
+	Version string
+}
+
+// Error implements the error interface.
+func (e UnsupportedSchemaVersionError) Error() string {
+	return fmt.Sprintf("unsupported schema version %q", e.Version)
+}
+
+// VersionRegistry picks the codec of requests by the SchemaVersionHeader of
+// their delivery, and encodes the responses with the same codec, stamping
+// the reply with the version used, so the payloads of an API can evolve
+// while clients still send older versions:
+//
+//	versions := amqptransport.NewVersionRegistry("1")
+//	versions.Register("1", amqptransport.Codec{Decode: decodeV1, Encode: encodeV1})
+//	versions.Register("2", amqptransport.Codec{Decode: decodeV2, Encode: encodeV2})
+//	sub := amqptransport.NewSubscriber(e, nil, nil, amqptransport.SubscriberVersions(versions))
+//
+// Clients pick the version of their requests with SetSchemaVersion. Codecs
+// must be registered before the registry is used.
+type VersionRegistry struct {
+	defaultVersion string
+	codecs         map[string]Codec
+}
+
+// NewVersionRegistry returns an empty VersionRegistry, which uses the
+// codec of defaultVersion for deliveries without a schema version.
+func NewVersionRegistry(defaultVersion string) *VersionRegistry {
+	return &VersionRegistry{
+		defaultVersion: defaultVersion,
+		codecs:         map[string]Codec{},
+	}
+}
+
+// Register registers c for version.
+func (r *VersionRegistry) Register(version string, c Codec) {
+	r.codecs[version] = c
+}
+
+// SubscriberVersions sets the decoder and encoder of the subscriber to
+// those of r, replacing the ones passed to NewSubscriber.
+func SubscriberVersions(r *VersionRegistry) SubscriberOption {
+	return func(s *Subscriber) {
+		s.dec = r.DecodeRequest
+		s.enc = r.EncodeResponse
+		s.before = append([]RequestFunc{r.setSchemaVersion}, s.before...)
+	}
+}
+
+// setSchemaVersion stores the schema version of the request in the context.
+func (r *VersionRegistry) setSchemaVersion(ctx context.Context, pub *amqp.Publishing, d *amqp.Delivery) context.Context {
+	return context.WithValue(ctx, ContextKeySchemaVersion, r.version(d))
+}
+
+// version returns the schema version of d, or the default version.
+func (r *VersionRegistry) version(d *amqp.Delivery) string {
+	if version := SchemaVersion(d); version != "" {
+		return version
+	}
+	return r.defaultVersion
+}
+
+// DecodeRequest is a DecodeRequestFunc decoding the delivery with the codec
+// of its schema version. It returns an UnsupportedSchemaVersionError if
+// there is none.
+func (r *VersionRegistry) DecodeRequest(ctx context.Context, d *amqp.Delivery) (interface{}, error) {
+	version := r.version(d)
+	c, ok := r.codecs[version]
+	if !ok {
+		return nil, UnsupportedSchemaVersionError{Version: version}
+	}
+	return c.Decode(ctx, d)
+}
+
+// EncodeResponse is an EncodeResponseFunc encoding the response with the
+// codec of the request schema version, or the default codec, and setting
+// the SchemaVersionHeader of the reply to that version. The request schema
+// version is only known to subscribers configured with SubscriberVersions.
+func (r *VersionRegistry) EncodeResponse(ctx context.Context, pub *amqp.Publishing, response interface{}) error {
+	version := r.defaultVersion
+	if v, ok := ctx.Value(ContextKeySchemaVersion).(string); ok {
+		version = v
+	}
+	c, ok := r.codecs[version]
+	if !ok {
+		return UnsupportedSchemaVersionError{Version: version}
+	}
+	if err := c.Encode(ctx, pub, response); err != nil {
+		return err
+	}
+	setSchemaVersion(pub, version)
+	return nil
+}
+
+// SchemaVersionFromContext returns the schema version of the request, set
+// by subscribers configured with SubscriberVersions.
+func SchemaVersionFromContext(ctx context.Context) string {
+	version, _ := ctx.Value(ContextKeySchemaVersion).(string)
+	return version
+}
+
+// SetSchemaVersion returns a RequestFunc that sets the SchemaVersionHeader
+// of the outgoing Publishing to version.
+// It is designed to be used by Publishers.
+func SetSchemaVersion(version string) RequestFunc {
+	return func(ctx context.Context, pub *amqp.Publishing, d *amqp.Delivery) context.Context {
+		setSchemaVersion(pub, version)
+		return ctx
+	}
+}
+
+// SchemaVersion returns the SchemaVersionHeader of d, e.g. for a Publisher
+// to tell the version of a reply, or "" if it has none. Numeric versions
+// are formatted in decimal.
+func SchemaVersion(d *amqp.Delivery) string {
+	switch v := d.Headers[SchemaVersionHeader].(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func setSchemaVersion(pub *amqp.Publishing, version string) {
+	if pub.Headers == nil {
+		pub.Headers = amqp.Table{}
+	}
+	pub.Headers[SchemaVersionHeader] = version
+}
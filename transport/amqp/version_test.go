@@ -0,0 +1,85 @@
+package amqp_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/streadway/amqp"
+
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+func TestSubscriberVersions(t *testing.T) {
+	versions := amqptransport.NewVersionRegistry("1")
+	versions.Register("1", amqptransport.Codec{
+		Decode: func(_ context.Context, d *amqp.Delivery) (interface{}, error) {
+			var req testReq
+			_, err := fmt.Sscan(string(d.Body), &req.Squadron)
+			return req, err
+		},
+		Encode: func(_ context.Context, pub *amqp.Publishing, response interface{}) error {
+			pub.Body = []byte(response.(testRes).Name)
+			return nil
+		},
+	})
+	versions.Register("2", amqptransport.Codec{
+		Decode: testReqDecoder,
+		Encode: amqptransport.EncodeJSONResponse,
+	})
+
+	var lastErr error
+	sub := amqptransport.NewSubscriber(testEndpoint, nil, nil,
+		amqptransport.SubscriberVersions(versions),
+		amqptransport.ServerFinalizer(func(_ context.Context, err error) { lastErr = err }),
+	)
+
+	for _, testcase := range []struct {
+		version interface{}
+		body    string
+		want    string
+		reply   string
+	}{
+		{nil, "424", "tiger", "1"},
+		{"1", "424", "tiger", "1"},
+		{"2", `{"s":437}`, `{"s":437,"n":"husky"}`, "2"},
+		{int64(2), `{"s":437}`, `{"s":437,"n":"husky"}`, "2"},
+	} {
+		pub := amqp.Publishing{Body: []byte(testcase.body)}
+		if testcase.version != nil {
+			pub.Headers = amqp.Table{amqptransport.SchemaVersionHeader: testcase.version}
+		}
+		outputChan := make(chan amqp.Publishing, 1)
+		sub.ServeDelivery(&mockChannel{f: nullFunc, c: outputChan})(&amqp.Delivery{
+			Headers: pub.Headers,
+			Body:    pub.Body,
+		})
+		if lastErr != nil {
+			t.Fatal(lastErr)
+		}
+		reply := <-outputChan
+		if want, have := testcase.want, string(reply.Body); want != have {
+			t.Errorf("%v: want %s, have %s", testcase.version, want, have)
+		}
+		if want, have := testcase.reply, amqptransport.SchemaVersion(&amqp.Delivery{Headers: reply.Headers}); want != have {
+			t.Errorf("%v: incorrect reply version, want %q, have %q", testcase.version, want, have)
+		}
+	}
+
+	sub.ServeDelivery(&mockChannel{f: nullFunc})(&amqp.Delivery{
+		Headers: amqp.Table{amqptransport.SchemaVersionHeader: "3"},
+	})
+	var unsupported amqptransport.UnsupportedSchemaVersionError
+	if !errors.As(lastErr, &unsupported) {
+		t.Errorf("want UnsupportedSchemaVersionError, have %v", lastErr)
+	}
+}
+
+func TestSetSchemaVersion(t *testing.T) {
+	var pub amqp.Publishing
+	amqptransport.SetSchemaVersion("2")(context.Background(), &pub, nil)
+	if want, have := "2", pub.Headers[amqptransport.SchemaVersionHeader]; want != have {
+		t.Errorf("want %q, have %v", want, have)
+	}
+}
@@ -0,0 +1,107 @@
+package amqp
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/streadway/amqp"
+)
+
+// NotifyingChannel is a Channel notifying listeners when it is closed or
+// the broker canceled one of its consumers, like *amqp.Channel.
+type NotifyingChannel interface {
+	Channel
+	NotifyClose(c chan *amqp.Error) chan *amqp.Error
+	NotifyCancel(c chan string) chan string
+}
+
+// ConsumerCanceledError is the error of a WatchedChannel whose consumer was
+// canceled by the broker, e.g. because its queue was deleted.
+type ConsumerCanceledError struct {
+	Consumer string
+}
+
+// Error implements the error interface.
+func (e ConsumerCanceledError) Error() string {
+	return fmt.Sprintf("consumer %q canceled by broker", e.Consumer)
+}
+
+// WatchedChannel is a Channel that is done once the underlying channel is
+// closed or the broker canceled one of its consumers. Pass it to
+// Subscriber.ServeDelivery to cancel the context of the requests in
+// progress then, so long-running endpoints can abort work whose reply
+// can't be published or acknowledged anymore.
+type WatchedChannel struct {
+	NotifyingChannel
+
+	once sync.Once
+	done chan struct{}
+	err  error
+}
+
+// NewWatchedChannel returns a WatchedChannel watching ch.
+func NewWatchedChannel(ch NotifyingChannel) *WatchedChannel {
+	c := &WatchedChannel{
+		NotifyingChannel: ch,
+		done:             make(chan struct{}),
+	}
+	closed := ch.NotifyClose(make(chan *amqp.Error, 1))
+	canceled := ch.NotifyCancel(make(chan string, 1))
+	// streadway/amqp sends to the listeners with blocking sends until it
+	// closes them on shutdown, so keep receiving after the channel is done.
+	go func() {
+		for closed != nil || canceled != nil {
+			select {
+			case err, ok := <-closed:
+				if !ok {
+					closed = nil
+					c.stop(amqp.ErrClosed)
+					continue
+				}
+				if err == nil {
+					err = amqp.ErrClosed
+				}
+				c.stop(err)
+			case consumer, ok := <-canceled:
+				if !ok {
+					canceled = nil
+					continue
+				}
+				c.stop(ConsumerCanceledError{Consumer: consumer})
+			}
+		}
+	}()
+	return c
+}
+
+func (c *WatchedChannel) stop(err error) {
+	c.once.Do(func() {
+		c.err = err
+		close(c.done)
+	})
+}
+
+// Done returns a channel that is closed once the underlying channel is
+// closed or one of its consumers was canceled.
+func (c *WatchedChannel) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns why the channel is done, or nil if it isn't.
+func (c *WatchedChannel) Err() error {
+	select {
+	case <-c.done:
+		return c.err
+	default:
+		return nil
+	}
+}
+
+// Publish implements Channel. It fails with the error returned by Err
+// without publishing once the channel is done.
+func (c *WatchedChannel) Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	if err := c.Err(); err != nil {
+		return err
+	}
+	return c.NotifyingChannel.Publish(exchange, key, mandatory, immediate, msg)
+}
@@ -0,0 +1,120 @@
+package amqp_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+
+	amqptransport "github.com/inturn/kit/transport/amqp"
+)
+
+// notifyingChannel is a mockChannel handing out its close and cancel
+// listeners.
+type notifyingChannel struct {
+	mockChannel
+	closed   chan *amqp.Error
+	canceled chan string
+}
+
+func (ch *notifyingChannel) NotifyClose(c chan *amqp.Error) chan *amqp.Error {
+	ch.closed = c
+	return c
+}
+
+func (ch *notifyingChannel) NotifyCancel(c chan string) chan string {
+	ch.canceled = c
+	return c
+}
+
+func TestWatchedChannelCancelsRequests(t *testing.T) {
+	published := make(chan amqp.Publishing, 1)
+	ch := &notifyingChannel{mockChannel: mockChannel{f: nullFunc, c: published}}
+	watched := amqptransport.NewWatchedChannel(ch)
+
+	started := make(chan struct{})
+	var endpointErr error
+	sub := amqptransport.NewSubscriber(
+		func(ctx context.Context, request interface{}) (interface{}, error) {
+			close(started)
+			<-ctx.Done()
+			endpointErr = ctx.Err()
+			return nil, ctx.Err()
+		},
+		testReqDecoder,
+		amqptransport.EncodeJSONResponse,
+		amqptransport.SubscriberErrorEncoder(amqptransport.ReplyErrorEncoder),
+	)
+	done := make(chan struct{})
+	go func() {
+		sub.ServeDelivery(watched)(&amqp.Delivery{Body: []byte(`{"s":436}`)})
+		close(done)
+	}()
+
+	<-started
+	ch.closed <- &amqp.Error{Code: amqp.ChannelError, Reason: "channel closed"}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the request to be canceled")
+	}
+
+	if want, have := context.Canceled, endpointErr; want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	if watched.Err() == nil {
+		t.Error("want channel error, have none")
+	}
+	select {
+	case <-published:
+		t.Error("want no reply on a closed channel, have one")
+	default:
+	}
+}
+
+func TestWatchedChannelConsumerCanceled(t *testing.T) {
+	ch := &notifyingChannel{mockChannel: mockChannel{f: nullFunc}}
+	watched := amqptransport.NewWatchedChannel(ch)
+	if err := watched.Err(); err != nil {
+		t.Fatalf("want no error, have %v", err)
+	}
+
+	ch.canceled <- "ctag"
+	select {
+	case <-watched.Done():
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the channel to be done")
+	}
+	want := amqptransport.ConsumerCanceledError{Consumer: "ctag"}
+	if have := watched.Err(); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
+
+func TestWatchedChannelDrainsNotifications(t *testing.T) {
+	ch := &notifyingChannel{mockChannel: mockChannel{f: nullFunc}}
+	watched := amqptransport.NewWatchedChannel(ch)
+
+	sent := make(chan struct{})
+	go func() {
+		// the broker may cancel every consumer of the channel before
+		// closing it, each a blocking send
+		ch.canceled <- "ctag1"
+		ch.canceled <- "ctag2"
+		ch.canceled <- "ctag3"
+		ch.closed <- &amqp.Error{Code: 320, Reason: "shutdown"}
+		close(ch.canceled)
+		close(ch.closed)
+		close(sent)
+	}()
+	select {
+	case <-sent:
+	case <-time.After(time.Second):
+		t.Fatal("timed out sending notifications")
+	}
+	want := amqptransport.ConsumerCanceledError{Consumer: "ctag1"}
+	if have := watched.Err(); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
@@ -0,0 +1,18 @@
+// Package amqp10 implements an AMQP 1.0 transport, for brokers like Azure
+// Service Bus, ActiveMQ Artemis and Apache Qpid, with the same Subscriber
+// and Publisher abstractions as package transport/amqp, which implements
+// the RabbitMQ flavored AMQP 0.9.1.
+//
+// AMQP 1.0 has no exchanges, queues or bindings in the protocol: messages
+// are sent over links to addresses, and every message received is settled
+// with a disposition, accepted, rejected or released, instead of being
+// acknowledged. The package doesn't depend on a client library; links are
+// represented by the Sender and Receiver interfaces, which the links of
+// libraries like github.com/Azure/go-amqp satisfy with a thin adapter.
+//
+// A Subscriber serves the messages of a Receiver with an endpoint, decoding
+// the request from the message and sending the encoded response to the
+// address in its ReplyTo property. A Publisher sends requests to an address
+// and waits for the reply with the matching correlation ID on the Receiver
+// of its reply address.
+package amqp10
@@ -0,0 +1,21 @@
+package amqp10
+
+import (
+	"context"
+)
+
+// DecodeRequestFunc extracts a user-domain request object from an AMQP 1.0
+// Message. It is designed to be used in Subscribers.
+type DecodeRequestFunc func(context.Context, *Message) (request interface{}, err error)
+
+// EncodeRequestFunc encodes the passed request object into an AMQP 1.0
+// Message. It is designed to be used in Publishers.
+type EncodeRequestFunc func(context.Context, *Message, interface{}) error
+
+// EncodeResponseFunc encodes the passed response object into the reply
+// Message. It is designed to be used in Subscribers.
+type EncodeResponseFunc func(context.Context, *Message, interface{}) error
+
+// DecodeResponseFunc extracts a user-domain response object from the reply
+// Message. It is designed to be used in Publishers.
+type DecodeResponseFunc func(context.Context, *Message) (response interface{}, err error)
@@ -0,0 +1,84 @@
+package amqp10
+
+import (
+	"context"
+	"time"
+)
+
+// Message is an AMQP 1.0 message, with the properties and the application
+// properties section most brokers support, and a single data section.
+type Message struct {
+	// MessageID identifies the message.
+	MessageID string
+	// CorrelationID is the MessageID of the request a reply answers.
+	CorrelationID string
+	// To is the address the message is sent to. Senders attached to the
+	// anonymous relay, without a target address, route by it.
+	To string
+	// ReplyTo is the address replies are sent to.
+	ReplyTo string
+	// Subject is the subject of the message, e.g. its type.
+	Subject string
+	// ContentType is the media type of Data.
+	ContentType string
+	// ContentEncoding is the encoding of Data, e.g. gzip.
+	ContentEncoding string
+	// GroupID is the group of the message, e.g. the session ID of Azure
+	// Service Bus.
+	GroupID string
+	// CreationTime is the time the message was created.
+	CreationTime time.Time
+	// ApplicationProperties are the application properties of the message,
+	// the counterpart of AMQP 0.9.1 headers.
+	ApplicationProperties map[string]interface{}
+
+	// Durable makes the broker store the message durably.
+	Durable bool
+	// Priority is the priority of the message, from 0 to 9.
+	Priority uint8
+	// TTL is the time after which the broker discards the message. Zero
+	// means the message doesn't expire.
+	TTL time.Duration
+	// DeliveryCount is the number of failed delivery attempts of the
+	// message before this one.
+	DeliveryCount uint32
+
+	// Data is the body of the message.
+	Data []byte
+}
+
+// Sender is a link sending messages, like the *amqp.Sender of
+// github.com/Azure/go-amqp. Subscribers send replies to the address of
+// their ReplyTo, so their Sender must be attached to the anonymous relay,
+// or route messages by their To address itself.
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// Receiver is a link receiving messages, like the *amqp.Receiver of
+// github.com/Azure/go-amqp, along with the dispositions settling them.
+type Receiver interface {
+	// Receive returns the next message, blocking until one arrives or ctx
+	// is done.
+	Receive(ctx context.Context) (*Message, error)
+
+	// Accept settles msg as processed.
+	Accept(ctx context.Context, msg *Message) error
+
+	// Reject settles msg as invalid, with the error that made it so. Most
+	// brokers dead-letter rejected messages.
+	Reject(ctx context.Context, msg *Message, err error) error
+
+	// Release settles msg as not processed, so the broker delivers it
+	// again, without counting a failed attempt.
+	Release(ctx context.Context, msg *Message) error
+}
+
+// SenderFunc is an adapter to allow the use of ordinary functions as
+// Senders.
+type SenderFunc func(ctx context.Context, msg *Message) error
+
+// Send calls f(ctx, msg).
+func (f SenderFunc) Send(ctx context.Context, msg *Message) error {
+	return f(ctx, msg)
+}
@@ -0,0 +1,248 @@
+package amqp10
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/inturn/kit/endpoint"
+)
+
+// ErrPublisherClosed is returned by the endpoint of a Publisher that was
+// closed, or whose reply Receiver failed, while waiting for a reply.
+var ErrPublisherClosed = errors.New("publisher closed")
+
+// Publisher wraps an address and provides a method that implements
+// endpoint.Endpoint, sending requests to the address and waiting for their
+// replies.
+type Publisher struct {
+	snd       Sender
+	to        string
+	replies   Receiver
+	replyTo   string
+	enc       EncodeRequestFunc
+	dec       DecodeResponseFunc
+	before    []RequestFunc
+	after     []PublisherResponseFunc
+	finalizer []PublisherFinalizerFunc
+	timeout   time.Duration
+
+	once    sync.Once
+	ctx     context.Context
+	cancel  context.CancelFunc
+	mtx     sync.Mutex
+	pending map[string]chan *Message
+	closed  bool
+}
+
+// NewPublisher constructs a usable Publisher sending requests on snd to the
+// address to. Replies are received from replies, a Receiver attached to
+// the address replyTo, which is set as the ReplyTo of requests; it must
+// only be used by this Publisher, which accepts every reply it receives,
+// routing them to the requests by their CorrelationID. With a nil
+// replies, the endpoint returns right after sending the request, with a
+// nil response, for one-way messages.
+func NewPublisher(
+	snd Sender,
+	to string,
+	replies Receiver,
+	replyTo string,
+	enc EncodeRequestFunc,
+	dec DecodeResponseFunc,
+	options ...PublisherOption,
+) *Publisher {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Publisher{
+		snd:     snd,
+		to:      to,
+		replies: replies,
+		replyTo: replyTo,
+		enc:     enc,
+		dec:     dec,
+		timeout: 10 * time.Second,
+		ctx:     ctx,
+		cancel:  cancel,
+		pending: map[string]chan *Message{},
+	}
+	for _, option := range options {
+		option(p)
+	}
+	return p
+}
+
+// PublisherOption sets an optional parameter for publishers.
+type PublisherOption func(*Publisher)
+
+// PublisherBefore sets the RequestFuncs that are applied to the outgoing
+// request message before it's sent.
+func PublisherBefore(before ...RequestFunc) PublisherOption {
+	return func(p *Publisher) { p.before = append(p.before, before...) }
+}
+
+// PublisherAfter sets the PublisherResponseFuncs applied to the reply
+// prior to it being decoded. This is useful for obtaining anything off of
+// the reply and adding onto the context prior to decoding.
+func PublisherAfter(after ...PublisherResponseFunc) PublisherOption {
+	return func(p *Publisher) { p.after = append(p.after, after...) }
+}
+
+// PublisherTimeout sets the available timeout for a request, including
+// waiting for its reply. The default is 10 seconds.
+func PublisherTimeout(timeout time.Duration) PublisherOption {
+	return func(p *Publisher) { p.timeout = timeout }
+}
+
+// PublisherFinalizer is executed at the end of every request.
+// By default, no finalizer is registered.
+func PublisherFinalizer(f ...PublisherFinalizerFunc) PublisherOption {
+	return func(p *Publisher) { p.finalizer = append(p.finalizer, f...) }
+}
+
+// Endpoint returns a usable endpoint that invokes the remote endpoint.
+func (p *Publisher) Endpoint() endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		ctx, cancel := context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+
+		if len(p.finalizer) > 0 {
+			defer func() {
+				for _, f := range p.finalizer {
+					f(ctx, err)
+				}
+			}()
+		}
+
+		msg := &Message{
+			MessageID: newMessageID(),
+			To:        p.to,
+		}
+		if p.replies != nil {
+			msg.ReplyTo = p.replyTo
+		}
+		if err = p.enc(ctx, msg, request); err != nil {
+			return nil, err
+		}
+		for _, f := range p.before {
+			ctx = f(ctx, msg, nil)
+		}
+
+		if p.replies == nil {
+			return nil, p.snd.Send(ctx, msg)
+		}
+
+		reply, err := p.sendAndWaitForReply(ctx, msg)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range p.after {
+			ctx = f(ctx, reply)
+		}
+		return p.dec(ctx, reply)
+	}
+}
+
+// Close stops receiving replies. Requests waiting for a reply fail with
+// ErrPublisherClosed.
+func (p *Publisher) Close() error {
+	p.cancel()
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.closeLocked()
+	return nil
+}
+
+func (p *Publisher) sendAndWaitForReply(ctx context.Context, msg *Message) (*Message, error) {
+	p.once.Do(func() { go p.receive() })
+
+	c := make(chan *Message, 1)
+	p.mtx.Lock()
+	if p.closed {
+		p.mtx.Unlock()
+		return nil, ErrPublisherClosed
+	}
+	p.pending[msg.MessageID] = c
+	p.mtx.Unlock()
+	defer func() {
+		p.mtx.Lock()
+		delete(p.pending, msg.MessageID)
+		p.mtx.Unlock()
+	}()
+
+	if err := p.snd.Send(ctx, msg); err != nil {
+		return nil, err
+	}
+	select {
+	case reply, ok := <-c:
+		if !ok {
+			return nil, ErrPublisherClosed
+		}
+		return reply, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// receive routes the replies to the requests waiting for them until the
+// publisher is closed or receiving fails.
+func (p *Publisher) receive() {
+	for {
+		reply, err := p.replies.Receive(p.ctx)
+		if err != nil {
+			p.mtx.Lock()
+			p.closeLocked()
+			p.mtx.Unlock()
+			return
+		}
+		p.replies.Accept(p.ctx, reply)
+
+		p.mtx.Lock()
+		if c, ok := p.pending[reply.CorrelationID]; ok {
+			c <- reply
+			delete(p.pending, reply.CorrelationID)
+		}
+		p.mtx.Unlock()
+	}
+}
+
+func (p *Publisher) closeLocked() {
+	if p.closed {
+		return
+	}
+	p.closed = true
+	for id, c := range p.pending {
+		close(c)
+		delete(p.pending, id)
+	}
+}
+
+// EncodeJSONRequest is an EncodeRequestFunc that serializes the request as
+// a JSON object to the data of the message.
+func EncodeJSONRequest(_ context.Context, msg *Message, request interface{}) error {
+	b, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	msg.ContentType = "application/json"
+	msg.Data = b
+	return nil
+}
+
+// EncodeNopRequest is an EncodeRequestFunc that does nothing.
+func EncodeNopRequest(context.Context, *Message, interface{}) error {
+	return nil
+}
+
+// PublisherFinalizerFunc can be used to perform work at the end of a
+// request, after the reply was received or the request failed.
+type PublisherFinalizerFunc func(ctx context.Context, err error)
+
+// newMessageID returns a random message ID.
+func newMessageID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
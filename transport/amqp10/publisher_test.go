@@ -0,0 +1,96 @@
+package amqp10_test
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/inturn/kit/transport/amqp10"
+)
+
+func testResDecoder(_ context.Context, msg *amqp10.Message) (interface{}, error) {
+	var res testRes
+	err := json.Unmarshal(msg.Data, &res)
+	return res, err
+}
+
+func TestPublisherRoundTrip(t *testing.T) {
+	b := newBroker()
+	sub := amqp10.NewSubscriber(testEndpoint, testReqDecoder, amqp10.EncodeJSONResponse)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go sub.Serve(ctx, b.receiver("squadrons"), b)
+
+	pub := amqp10.NewPublisher(b, "squadrons", b.receiver("replies"), "replies",
+		amqp10.EncodeJSONRequest,
+		testResDecoder,
+		amqp10.PublisherTimeout(time.Second),
+	)
+	defer pub.Close()
+
+	var wg sync.WaitGroup
+	for squadron, name := range names {
+		wg.Add(1)
+		go func(squadron int, name string) {
+			defer wg.Done()
+			res, err := pub.Endpoint()(context.Background(), testReq{Squadron: squadron})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if want, have := name, res.(testRes).Name; want != have {
+				t.Errorf("want %q, have %q", want, have)
+			}
+		}(squadron, name)
+	}
+	wg.Wait()
+}
+
+func TestPublisherTimeout(t *testing.T) {
+	b := newBroker()
+	pub := amqp10.NewPublisher(b, "nowhere", b.receiver("replies"), "replies",
+		amqp10.EncodeJSONRequest,
+		testResDecoder,
+		amqp10.PublisherTimeout(10*time.Millisecond),
+	)
+	defer pub.Close()
+
+	if _, err := pub.Endpoint()(context.Background(), testReq{}); err != context.DeadlineExceeded {
+		t.Errorf("want %v, have %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestPublisherClose(t *testing.T) {
+	b := newBroker()
+	pub := amqp10.NewPublisher(b, "nowhere", b.receiver("replies"), "replies",
+		amqp10.EncodeJSONRequest,
+		testResDecoder,
+	)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		pub.Close()
+	}()
+	if _, err := pub.Endpoint()(context.Background(), testReq{}); err != amqp10.ErrPublisherClosed {
+		t.Errorf("want %v, have %v", amqp10.ErrPublisherClosed, err)
+	}
+}
+
+func TestPublisherOneWay(t *testing.T) {
+	b := newBroker()
+	pub := amqp10.NewPublisher(b, "events", nil, "", amqp10.EncodeJSONRequest, testResDecoder,
+		amqp10.PublisherBefore(amqp10.SetSubject("created")),
+	)
+	res, err := pub.Endpoint()(context.Background(), testReq{Squadron: 437})
+	if err != nil || res != nil {
+		t.Fatalf("want nil response and error, have %v, %v", res, err)
+	}
+	msg := <-b.address("events")
+	if want, have := "created", msg.Subject; want != have {
+		t.Errorf("want subject %q, have %q", want, have)
+	}
+	if msg.ReplyTo != "" {
+		t.Errorf("want no reply address, have %q", msg.ReplyTo)
+	}
+}
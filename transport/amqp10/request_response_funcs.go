@@ -0,0 +1,53 @@
+package amqp10
+
+import (
+	"context"
+)
+
+// RequestFunc may take information from a request message and put it into
+// a request context. In Subscribers, RequestFuncs are executed prior to
+// decoding the request, with the message received and the reply. In
+// Publishers, they are executed on the request message before it is sent,
+// with a nil reply.
+type RequestFunc func(ctx context.Context, msg *Message, reply *Message) context.Context
+
+// SubscriberResponseFunc may take information from a request context and
+// use it to manipulate the reply. SubscriberResponseFuncs are only executed
+// in subscribers, after invoking the endpoint but prior to sending the
+// reply.
+type SubscriberResponseFunc func(ctx context.Context, msg *Message, reply *Message) context.Context
+
+// PublisherResponseFunc may take information from a reply and make the
+// response available for consumption. PublisherResponseFuncs are only
+// executed in publishers, after the reply was received, but prior to it
+// being decoded.
+type PublisherResponseFunc func(context.Context, *Message) context.Context
+
+// SetTo returns a RequestFunc that sets the To address of the message, e.g.
+// to route the request of a Publisher to another address than its default.
+func SetTo(to string) RequestFunc {
+	return func(ctx context.Context, msg *Message, _ *Message) context.Context {
+		msg.To = to
+		return ctx
+	}
+}
+
+// SetSubject returns a RequestFunc that sets the Subject of the message.
+func SetSubject(subject string) RequestFunc {
+	return func(ctx context.Context, msg *Message, _ *Message) context.Context {
+		msg.Subject = subject
+		return ctx
+	}
+}
+
+// SetApplicationProperty returns a RequestFunc that sets the application
+// property key of the message to value.
+func SetApplicationProperty(key string, value interface{}) RequestFunc {
+	return func(ctx context.Context, msg *Message, _ *Message) context.Context {
+		if msg.ApplicationProperties == nil {
+			msg.ApplicationProperties = map[string]interface{}{}
+		}
+		msg.ApplicationProperties[key] = value
+		return ctx
+	}
+}
@@ -0,0 +1,235 @@
+package amqp10
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/inturn/kit/endpoint"
+	"github.com/inturn/kit/log"
+	"github.com/inturn/kit/transport"
+)
+
+// Subscriber wraps an endpoint and provides a handler for AMQP 1.0
+// messages.
+type Subscriber struct {
+	e            endpoint.Endpoint
+	dec          DecodeRequestFunc
+	enc          EncodeResponseFunc
+	before       []RequestFunc
+	after        []SubscriberResponseFunc
+	finalizer    []SubscriberFinalizerFunc
+	errorEncoder ErrorEncoder
+	errorHandler transport.ErrorHandler
+}
+
+// NewSubscriber constructs a new subscriber, which provides a handler for
+// AMQP 1.0 messages.
+func NewSubscriber(
+	e endpoint.Endpoint,
+	dec DecodeRequestFunc,
+	enc EncodeResponseFunc,
+	options ...SubscriberOption,
+) *Subscriber {
+	s := &Subscriber{
+		e:            e,
+		dec:          dec,
+		enc:          enc,
+		errorEncoder: DefaultErrorEncoder,
+		errorHandler: transport.NewLogErrorHandler(log.NewNopLogger()),
+	}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+// SubscriberOption sets an optional parameter for subscribers.
+type SubscriberOption func(*Subscriber)
+
+// SubscriberBefore functions are executed on the request message and the
+// reply before the request is decoded.
+func SubscriberBefore(before ...RequestFunc) SubscriberOption {
+	return func(s *Subscriber) { s.before = append(s.before, before...) }
+}
+
+// SubscriberAfter functions are executed on the subscriber reply after the
+// endpoint is invoked, but before the reply is sent.
+func SubscriberAfter(after ...SubscriberResponseFunc) SubscriberOption {
+	return func(s *Subscriber) { s.after = append(s.after, after...) }
+}
+
+// SubscriberErrorEncoder is used to settle messages, and possibly reply to
+// them, whenever an error is encountered in the processing of a request.
+// By default, messages are rejected with the DefaultErrorEncoder.
+func SubscriberErrorEncoder(ee ErrorEncoder) SubscriberOption {
+	return func(s *Subscriber) { s.errorEncoder = ee }
+}
+
+// SubscriberErrorHandler is used to handle non-terminal errors. By default,
+// non-terminal errors are ignored. This is intended as a diagnostic measure.
+func SubscriberErrorHandler(errorHandler transport.ErrorHandler) SubscriberOption {
+	return func(s *Subscriber) { s.errorHandler = errorHandler }
+}
+
+// SubscriberFinalizer is executed at the end of every message served.
+// By default, no finalizer is registered.
+func SubscriberFinalizer(f ...SubscriberFinalizerFunc) SubscriberOption {
+	return func(s *Subscriber) { s.finalizer = append(s.finalizer, f...) }
+}
+
+// ServeMessage returns a function serving messages received from r,
+// sending replies to messages with a ReplyTo address on s, and settling
+// the messages on r. Messages served successfully are accepted once their
+// reply was sent; messages that failed are passed to the error encoder,
+// which settles them.
+func (s Subscriber) ServeMessage(r Receiver, snd Sender) func(ctx context.Context, msg *Message) {
+	return func(ctx context.Context, msg *Message) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		var err error
+		if len(s.finalizer) > 0 {
+			defer func() {
+				for _, f := range s.finalizer {
+					f(ctx, err)
+				}
+			}()
+		}
+
+		reply := &Message{}
+		fail := func() {
+			s.errorHandler.Handle(ctx, err)
+			s.errorEncoder(ctx, err, msg, r, snd, reply)
+		}
+
+		for _, f := range s.before {
+			ctx = f(ctx, msg, reply)
+		}
+
+		request, err := s.dec(ctx, msg)
+		if err != nil {
+			fail()
+			return
+		}
+
+		response, err := s.e(ctx, request)
+		if err != nil {
+			fail()
+			return
+		}
+
+		for _, f := range s.after {
+			ctx = f(ctx, msg, reply)
+		}
+
+		if err = s.enc(ctx, reply, response); err != nil {
+			fail()
+			return
+		}
+
+		if err = sendReply(ctx, msg, snd, reply); err != nil {
+			fail()
+			return
+		}
+
+		if err := r.Accept(ctx, msg); err != nil {
+			s.errorHandler.Handle(ctx, err)
+		}
+	}
+}
+
+// Serve receives messages from r and serves them, one at a time, until ctx
+// is done or receiving fails, see ServeMessage. It returns ctx.Err() or the
+// error receiving.
+func (s Subscriber) Serve(ctx context.Context, r Receiver, snd Sender) error {
+	handler := s.ServeMessage(r, snd)
+	for {
+		msg, err := r.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		handler(ctx, msg)
+	}
+}
+
+// sendReply sends reply to the ReplyTo address of msg, correlated with its
+// MessageID, unless msg has no ReplyTo address.
+func sendReply(ctx context.Context, msg *Message, snd Sender, reply *Message) error {
+	if msg.ReplyTo == "" {
+		return nil
+	}
+	if reply.To == "" {
+		reply.To = msg.ReplyTo
+	}
+	if reply.CorrelationID == "" {
+		reply.CorrelationID = msg.MessageID
+	}
+	return snd.Send(ctx, reply)
+}
+
+// EncodeJSONResponse marshals the response as JSON as the data of the
+// reply.
+func EncodeJSONResponse(_ context.Context, reply *Message, response interface{}) error {
+	b, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	reply.ContentType = "application/json"
+	reply.Data = b
+	return nil
+}
+
+// EncodeNopResponse is an EncodeResponseFunc that does nothing.
+func EncodeNopResponse(context.Context, *Message, interface{}) error {
+	return nil
+}
+
+// ErrorEncoder is responsible for settling messages whose processing
+// failed, and possibly replying with the error. Users are encouraged to use
+// custom ErrorEncoders to encode errors to their replies, and will likely
+// want to pass and check for their own error types.
+type ErrorEncoder func(ctx context.Context, err error, msg *Message, r Receiver, snd Sender, reply *Message)
+
+// DefaultErrorEncoder rejects the message with the error, so most brokers
+// dead-letter it. It doesn't reply.
+func DefaultErrorEncoder(ctx context.Context, err error, msg *Message, r Receiver, _ Sender, _ *Message) {
+	r.Reject(ctx, msg, err)
+}
+
+// ReleaseErrorEncoder releases the message, so the broker delivers it
+// again. It doesn't reply. Messages that always fail are redelivered until
+// the broker's maximum delivery count, if it has one.
+func ReleaseErrorEncoder(ctx context.Context, _ error, msg *Message, r Receiver, _ Sender, _ *Message) {
+	r.Release(ctx, msg)
+}
+
+// ReplyErrorEncoder replies to the message with the error serialized as a
+// DefaultErrorResponse JSON and accepts it.
+func ReplyErrorEncoder(ctx context.Context, err error, msg *Message, r Receiver, snd Sender, reply *Message) {
+	b, merr := json.Marshal(DefaultErrorResponse{err.Error()})
+	if merr != nil {
+		r.Reject(ctx, msg, err)
+		return
+	}
+	reply.ContentType = "application/json"
+	reply.Data = b
+	if err := sendReply(ctx, msg, snd, reply); err != nil {
+		r.Release(ctx, msg)
+		return
+	}
+	r.Accept(ctx, msg)
+}
+
+// DefaultErrorResponse is the default structure of responses in the event
+// of an error.
+type DefaultErrorResponse struct {
+	Error string `json:"err"`
+}
+
+// SubscriberFinalizerFunc can be used to perform work at the end of every
+// message served, after it was settled. The principal intended use is for
+// request logging.
+type SubscriberFinalizerFunc func(ctx context.Context, err error)
@@ -0,0 +1,179 @@
+package amqp10_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/inturn/kit/transport/amqp10"
+)
+
+// broker routes messages to receivers by address, recording dispositions.
+type broker struct {
+	mtx       sync.Mutex
+	addresses map[string]chan *amqp10.Message
+	accepted  []*amqp10.Message
+	rejected  []*amqp10.Message
+	released  []*amqp10.Message
+}
+
+func newBroker() *broker {
+	return &broker{addresses: map[string]chan *amqp10.Message{}}
+}
+
+func (b *broker) address(name string) chan *amqp10.Message {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	c, ok := b.addresses[name]
+	if !ok {
+		c = make(chan *amqp10.Message, 16)
+		b.addresses[name] = c
+	}
+	return c
+}
+
+// Send implements amqp10.Sender as the anonymous relay.
+func (b *broker) Send(ctx context.Context, msg *amqp10.Message) error {
+	select {
+	case b.address(msg.To) <- msg:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *broker) receiver(address string) amqp10.Receiver {
+	return &receiver{b: b, c: b.address(address)}
+}
+
+type receiver struct {
+	b *broker
+	c chan *amqp10.Message
+}
+
+func (r *receiver) Receive(ctx context.Context) (*amqp10.Message, error) {
+	select {
+	case msg := <-r.c:
+		return msg, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (r *receiver) Accept(_ context.Context, msg *amqp10.Message) error {
+	r.b.mtx.Lock()
+	defer r.b.mtx.Unlock()
+	r.b.accepted = append(r.b.accepted, msg)
+	return nil
+}
+
+func (r *receiver) Reject(_ context.Context, msg *amqp10.Message, _ error) error {
+	r.b.mtx.Lock()
+	defer r.b.mtx.Unlock()
+	r.b.rejected = append(r.b.rejected, msg)
+	return nil
+}
+
+func (r *receiver) Release(_ context.Context, msg *amqp10.Message) error {
+	r.b.mtx.Lock()
+	defer r.b.mtx.Unlock()
+	r.b.released = append(r.b.released, msg)
+	return nil
+}
+
+type testReq struct {
+	Squadron int `json:"s"`
+}
+
+type testRes struct {
+	Squadron int    `json:"s"`
+	Name     string `json:"n"`
+}
+
+var names = map[int]string{
+	424: "tiger",
+	426: "thunderbird",
+	429: "bravo",
+	430: "falcon",
+	431: "wildcat",
+	432: "fledgling",
+	437: "husky",
+}
+
+func testEndpoint(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(testReq)
+	name, ok := names[req.Squadron]
+	if !ok {
+		return nil, errors.New("unknown squadron name")
+	}
+	return testRes{Squadron: req.Squadron, Name: name}, nil
+}
+
+func testReqDecoder(_ context.Context, msg *amqp10.Message) (interface{}, error) {
+	var req testReq
+	err := json.Unmarshal(msg.Data, &req)
+	return req, err
+}
+
+func TestSubscriberReply(t *testing.T) {
+	b := newBroker()
+	sub := amqp10.NewSubscriber(testEndpoint, testReqDecoder, amqp10.EncodeJSONResponse)
+	sub.ServeMessage(b.receiver("squadrons"), b)(context.Background(), &amqp10.Message{
+		MessageID: "m1",
+		ReplyTo:   "replies",
+		Data:      []byte(`{"s":437}`),
+	})
+
+	reply := <-b.address("replies")
+	if want, have := "m1", reply.CorrelationID; want != have {
+		t.Errorf("want correlation ID %q, have %q", want, have)
+	}
+	if want, have := `{"s":437,"n":"husky"}`, string(reply.Data); want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+	if want, have := 1, len(b.accepted); want != have {
+		t.Errorf("want %d accepted, have %d", want, have)
+	}
+}
+
+func TestSubscriberErrorEncoders(t *testing.T) {
+	for _, testcase := range []struct {
+		name                         string
+		ee                           amqp10.ErrorEncoder
+		accepted, rejected, released int
+		replies                      int
+	}{
+		{"default", amqp10.DefaultErrorEncoder, 0, 1, 0, 0},
+		{"release", amqp10.ReleaseErrorEncoder, 0, 0, 1, 0},
+		{"reply", amqp10.ReplyErrorEncoder, 1, 0, 0, 1},
+	} {
+		b := newBroker()
+		var lastErr error
+		sub := amqp10.NewSubscriber(testEndpoint, testReqDecoder, amqp10.EncodeJSONResponse,
+			amqp10.SubscriberErrorEncoder(testcase.ee),
+			amqp10.SubscriberFinalizer(func(_ context.Context, err error) { lastErr = err }),
+		)
+		sub.ServeMessage(b.receiver("squadrons"), b)(context.Background(), &amqp10.Message{
+			ReplyTo: "replies",
+			Data:    []byte(`{"s":1}`),
+		})
+
+		if lastErr == nil {
+			t.Errorf("%s: want error, have none", testcase.name)
+		}
+		if want, have := testcase.accepted, len(b.accepted); want != have {
+			t.Errorf("%s: want %d accepted, have %d", testcase.name, want, have)
+		}
+		if want, have := testcase.rejected, len(b.rejected); want != have {
+			t.Errorf("%s: want %d rejected, have %d", testcase.name, want, have)
+		}
+		if want, have := testcase.released, len(b.released); want != have {
+			t.Errorf("%s: want %d released, have %d", testcase.name, want, have)
+		}
+		if want, have := testcase.replies, len(b.address("replies")); want != have {
+			t.Errorf("%s: want %d replies, have %d", testcase.name, want, have)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package transport
+
+import (
+	"context"
+
+	"github.com/inturn/kit/log"
+)
+
+// ErrorHandler receives a transport error to be processed for diagnostic
+// purposes. Usually this means logging the error, but it may also be
+// reported to an error tracker or counted in metrics.
+type ErrorHandler interface {
+	Handle(ctx context.Context, err error)
+}
+
+// LogErrorHandler is a transport error handler implementation which logs an
+// error.
+type LogErrorHandler struct {
+	logger log.Logger
+}
+
+// NewLogErrorHandler returns a LogErrorHandler logging errors to logger.
+func NewLogErrorHandler(logger log.Logger) *LogErrorHandler {
+	return &LogErrorHandler{
+		logger: logger,
+	}
+}
+
+// Handle implements ErrorHandler. Errors carrying a stack trace, like the
+// panics recovered by transports, are logged along with it.
+func (h *LogErrorHandler) Handle(ctx context.Context, err error) {
+	if e, ok := err.(stackTracer); ok {
+		h.logger.Log("err", err, "stack", string(e.StackTrace()))
+		return
+	}
+	h.logger.Log("err", err)
+}
+
+// stackTracer is implemented by errors carrying the stack trace of where
+// they occurred.
+type stackTracer interface {
+	StackTrace() []byte
+}
+
+// The ErrorHandlerFunc type is an adapter to allow the use of ordinary
+// function as ErrorHandler. If f is a function with the appropriate
+// signature, ErrorHandlerFunc(f) is a ErrorHandler that calls f.
+type ErrorHandlerFunc func(ctx context.Context, err error)
+
+// Handle calls f(ctx, err).
+func (f ErrorHandlerFunc) Handle(ctx context.Context, err error) {
+	f(ctx, err)
+}
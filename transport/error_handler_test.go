@@ -0,0 +1,51 @@
+package transport_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/inturn/kit/log"
+	"github.com/inturn/kit/transport"
+)
+
+func TestLogErrorHandler(t *testing.T) {
+	var output []interface{}
+
+	logger := log.Logger(log.LoggerFunc(func(keyvals ...interface{}) error {
+		output = append(output, keyvals...)
+		return nil
+	}))
+
+	errorHandler := transport.NewLogErrorHandler(logger)
+
+	err := errors.New("error")
+
+	errorHandler.Handle(context.Background(), err)
+
+	if output[1] != err {
+		t.Errorf("expected an error log event: have %v, want %v", output[1], err)
+	}
+}
+
+type panicError struct{}
+
+func (panicError) Error() string      { return "panic" }
+func (panicError) StackTrace() []byte { return []byte("goroutine 1") }
+
+func TestLogErrorHandlerStackTrace(t *testing.T) {
+	var output []interface{}
+	logger := log.LoggerFunc(func(keyvals ...interface{}) error {
+		output = append(output, keyvals...)
+		return nil
+	})
+
+	transport.NewLogErrorHandler(logger).Handle(context.Background(), panicError{})
+
+	if want, have := 4, len(output); want != have {
+		t.Fatalf("want %d keyvals, have %v", want, output)
+	}
+	if want, have := "goroutine 1", output[3]; want != have {
+		t.Errorf("want stack %q, have %v", want, have)
+	}
+}
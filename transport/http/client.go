@@ -9,6 +9,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/inturn/kit/endpoint"
 )
@@ -29,6 +30,8 @@ type Client struct {
 	after          []ClientResponseFunc
 	finalizer      []ClientFinalizerFunc
 	bufferedStream bool
+	bufferedBody   bool
+	timeout        time.Duration
 }
 
 // NewClient constructs a usable Client for a single remote method.
@@ -90,10 +93,32 @@ func BufferedStream(buffered bool) ClientOption {
 	return func(c *Client) { c.bufferedStream = buffered }
 }
 
+// BufferedBody sets whether the encoded request body is read into memory
+// before the request is sent, setting its ContentLength and GetBody, so the
+// body can be sent again, e.g. by the http.Client when following redirects
+// or by an HTTPClient retrying failed requests. Bodies are streamed by
+// default.
+func BufferedBody(buffered bool) ClientOption {
+	return func(c *Client) { c.bufferedBody = buffered }
+}
+
+// ClientTimeout sets a deadline for every call, from encoding the request to
+// decoding the response, on top of any deadline of the context the endpoint
+// is called with. With BufferedStream, the deadline also applies to reading
+// the response body. By default, calls only end with their context.
+func ClientTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) { c.timeout = timeout }
+}
+
 // Endpoint returns a usable endpoint that invokes the remote endpoint.
 func (c Client) Endpoint() endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
-		ctx, cancel := context.WithCancel(ctx)
+		var cancel context.CancelFunc
+		if c.timeout > 0 {
+			ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		} else {
+			ctx, cancel = context.WithCancel(ctx)
+		}
 
 		var (
 			resp *http.Response
@@ -122,6 +147,13 @@ func (c Client) Endpoint() endpoint.Endpoint {
 			return nil, err
 		}
 
+		if c.bufferedBody {
+			if err = bufferBody(req); err != nil {
+				cancel()
+				return nil, err
+			}
+		}
+
 		for _, f := range c.before {
 			ctx = f(ctx, req)
 		}
@@ -169,6 +201,25 @@ func (bwc bodyWithCancel) Close() error {
 	return nil
 }
 
+// bufferBody reads the body of req into memory, so it can be read again
+// through req.GetBody.
+func bufferBody(req *http.Request) error {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return err
+	}
+	req.ContentLength = int64(len(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(body)), nil
+	}
+	req.Body, _ = req.GetBody()
+	return nil
+}
+
 // ClientFinalizerFunc can be used to perform work at the end of a client HTTP
 // request, after the response is returned. The principal
 // intended use is for error logging. Additional response parameters are
@@ -297,6 +297,73 @@ func TestSetClient(t *testing.T) {
 	}
 }
 
+func TestBufferedBody(t *testing.T) {
+	var bodies []string
+	retrying := httpClientFunc(func(req *http.Request) (*http.Response, error) {
+		for attempt := 0; attempt < 2; attempt++ {
+			body := req.Body
+			if attempt > 0 {
+				var err error
+				if body, err = req.GetBody(); err != nil {
+					return nil, err
+				}
+			}
+			b, _ := ioutil.ReadAll(body)
+			bodies = append(bodies, string(b))
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+		}, nil
+	})
+
+	client := httptransport.NewClient(
+		"POST",
+		&url.URL{},
+		httptransport.EncodeJSONRequest,
+		func(context.Context, *http.Response) (interface{}, error) { return nil, nil },
+		httptransport.SetClient(retrying),
+		httptransport.BufferedBody(true),
+	).Endpoint()
+
+	if _, err := client(context.Background(), map[string]int{"a": 1}); err != nil {
+		t.Fatal(err)
+	}
+	want := "{\"a\":1}\n"
+	if len(bodies) != 2 || bodies[0] != want || bodies[1] != want {
+		t.Errorf("want body %q sent twice, have %q", want, bodies)
+	}
+}
+
+func TestClientTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	client := httptransport.NewClient(
+		"GET",
+		mustParse(server.URL),
+		func(context.Context, *http.Request, interface{}) error { return nil },
+		func(context.Context, *http.Response) (interface{}, error) { return nil, nil },
+		httptransport.ClientTimeout(10*time.Millisecond),
+	).Endpoint()
+
+	errs := make(chan error, 1)
+	go func() {
+		_, err := client(context.Background(), struct{}{})
+		errs <- err
+	}()
+	select {
+	case err := <-errs:
+		if err == nil {
+			t.Error("want deadline error, have none")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("call not ended by timeout")
+	}
+}
+
 func mustParse(s string) *url.URL {
 	u, err := url.Parse(s)
 	if err != nil {
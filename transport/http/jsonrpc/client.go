@@ -30,6 +30,7 @@ type Client struct {
 	finalizer      httptransport.ClientFinalizerFunc
 	requestID      RequestIDGenerator
 	bufferedStream bool
+	notification   bool
 }
 
 type clientRequest struct {
@@ -39,6 +40,13 @@ type clientRequest struct {
 	ID      interface{}     `json:"id"`
 }
 
+// clientNotification is a clientRequest without an ID.
+type clientNotification struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
 // NewClient constructs a usable Client for a single remote method.
 func NewClient(
 	tgt *url.URL,
@@ -139,6 +147,14 @@ func BufferedStream(buffered bool) ClientOption {
 	return func(c *Client) { c.bufferedStream = buffered }
 }
 
+// Notification sets whether requests are sent as notifications, without an
+// ID. The server doesn't answer notifications, not even with an error, so the
+// endpoint returns a nil response, and only fails if the request couldn't be
+// sent.
+func Notification(notification bool) ClientOption {
+	return func(c *Client) { c.notification = notification }
+}
+
 // Endpoint returns a usable endpoint that invokes the remote endpoint.
 func (c Client) Endpoint() endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
@@ -163,12 +179,19 @@ func (c Client) Endpoint() endpoint.Endpoint {
 		if params, err = c.enc(ctx, request); err != nil {
 			return nil, err
 		}
-		rpcReq := clientRequest{
+		var rpcReq interface{} = clientRequest{
 			JSONRPC: "",
 			Method:  c.method,
 			Params:  params,
 			ID:      c.requestID.Generate(),
 		}
+		if c.notification {
+			rpcReq = clientNotification{
+				JSONRPC: Version,
+				Method:  c.method,
+				Params:  params,
+			}
+		}
 
 		req, err := http.NewRequest("POST", c.tgt.String(), nil)
 		if err != nil {
@@ -196,6 +219,13 @@ func (c Client) Endpoint() endpoint.Endpoint {
 			defer resp.Body.Close()
 		}
 
+		if c.notification {
+			for _, f := range c.after {
+				ctx = f(ctx, resp)
+			}
+			return nil, nil
+		}
+
 		// Decode the body into an object
 		var rpcRes Response
 		err = json.NewDecoder(resp.Body).Decode(&rpcRes)
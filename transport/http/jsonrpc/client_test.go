@@ -251,6 +251,25 @@ func TestClientCanHandleJSONRPCError(t *testing.T) {
 	}
 }
 
+func TestClientNotification(t *testing.T) {
+	var calls int
+	server := addServer(&calls)
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+	sut := jsonrpc.NewClient(u, "add", jsonrpc.Notification(true))
+
+	res, err := sut.Endpoint()(context.Background(), []int{3, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != nil {
+		t.Errorf("want nil response, have %v", res)
+	}
+	if want, have := 1, calls; want != have {
+		t.Errorf("want %d calls, have %d", want, have)
+	}
+}
+
 func TestDefaultAutoIncrementer(t *testing.T) {
 	sut := jsonrpc.NewAutoIncrementID(0)
 	var want uint64
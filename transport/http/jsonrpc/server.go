@@ -1,6 +1,7 @@
 package jsonrpc
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -95,63 +96,147 @@ func (s Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		ctx = f(ctx, r)
 	}
 
-	// Decode the body into an  object
-	var req Request
-	err := json.NewDecoder(r.Body).Decode(&req)
+	// Decode the body into an object, or an array of them for a batch.
+	var raw json.RawMessage
+	err := json.NewDecoder(r.Body).Decode(&raw)
+	if err == nil && isBatch(raw) {
+		var reqs []json.RawMessage
+		if err = json.Unmarshal(raw, &reqs); err == nil {
+			s.serveBatch(ctx, w, reqs)
+			return
+		}
+	}
 	if err != nil {
 		rpcerr := parseError("JSON could not be decoded: " + err.Error())
 		s.logger.Log("err", rpcerr)
 		s.errorEncoder(ctx, rpcerr, w)
 		return
 	}
-
-	// Get the endpoint and codecs from the map using the method
-	// defined in the JSON  object
-	ecm, ok := s.ecm[req.Method]
-	if !ok {
-		err := methodNotFoundError(fmt.Sprintf("Method %s was not found.", req.Method))
+	req, notification, err := decodeRequest(raw)
+	if err != nil {
 		s.logger.Log("err", err)
 		s.errorEncoder(ctx, err, w)
 		return
 	}
 
-	// Decode the JSON "params"
-	reqParams, err := ecm.Decode(ctx, req.Params)
+	result, err := s.call(ctx, w, req)
+	if notification {
+		// Notifications are never answered, not even with an error.
+		if err != nil {
+			s.logger.Log("err", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
 	if err != nil {
 		s.logger.Log("err", err)
 		s.errorEncoder(ctx, err, w)
 		return
 	}
 
-	// Call the Endpoint with the params
-	response, err := ecm.Endpoint(ctx, reqParams)
-	if err != nil {
+	w.Header().Set("Content-Type", ContentType)
+	_ = json.NewEncoder(w).Encode(Response{
+		ID:      req.ID,
+		JSONRPC: Version,
+		Result:  result,
+	})
+}
+
+// serveBatch serves a batch of requests, in order, and answers with the
+// array of the responses to the requests that aren't notifications. Elements
+// that aren't valid request objects are answered with an Invalid Request
+// error each. Errors are encoded as error objects like DefaultErrorEncoder
+// does, since the server's error encoder writes whole HTTP responses.
+func (s Server) serveBatch(ctx context.Context, w http.ResponseWriter, reqs []json.RawMessage) {
+	if len(reqs) == 0 {
+		err := invalidRequestError("Batch is empty.")
 		s.logger.Log("err", err)
 		s.errorEncoder(ctx, err, w)
 		return
 	}
 
-	for _, f := range s.after {
-		ctx = f(ctx, w)
+	var responses []Response
+	for _, raw := range reqs {
+		req, notification, err := decodeRequest(raw)
+		var result json.RawMessage
+		if err == nil {
+			result, err = s.call(ctx, w, req)
+		}
+		if err != nil {
+			s.logger.Log("err", err)
+		}
+		if notification {
+			continue
+		}
+		res := Response{
+			ID:      req.ID,
+			JSONRPC: Version,
+			Result:  result,
+		}
+		if err != nil {
+			res.Result, res.Error = nil, errorObject(err)
+		}
+		responses = append(responses, res)
 	}
 
-	res := Response{
-		ID:      req.ID,
-		JSONRPC: Version,
+	if len(responses) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
 	}
+	w.Header().Set("Content-Type", ContentType)
+	_ = json.NewEncoder(w).Encode(responses)
+}
 
-	// Encode the response from the Endpoint
-	resParams, err := ecm.Encode(ctx, response)
+// call invokes the endpoint of the method of req and returns its encoded
+// result.
+func (s Server) call(ctx context.Context, w http.ResponseWriter, req Request) (json.RawMessage, error) {
+	// Get the endpoint and codecs from the map using the method
+	// defined in the JSON  object
+	ecm, ok := s.ecm[req.Method]
+	if !ok {
+		return nil, methodNotFoundError(fmt.Sprintf("Method %s was not found.", req.Method))
+	}
+
+	// Decode the JSON "params"
+	reqParams, err := ecm.Decode(ctx, req.Params)
 	if err != nil {
-		s.logger.Log("err", err)
-		s.errorEncoder(ctx, err, w)
-		return
+		return nil, err
 	}
 
-	res.Result = resParams
+	// Call the Endpoint with the params
+	response, err := ecm.Endpoint(ctx, reqParams)
+	if err != nil {
+		return nil, err
+	}
 
-	w.Header().Set("Content-Type", ContentType)
-	_ = json.NewEncoder(w).Encode(res)
+	for _, f := range s.after {
+		ctx = f(ctx, w)
+	}
+
+	// Encode the response from the Endpoint
+	return ecm.Encode(ctx, response)
+}
+
+// decodeRequest decodes the request object raw. Requests without an id
+// member are notifications, unlike requests with a null id, which are
+// answered.
+func decodeRequest(raw json.RawMessage) (req Request, notification bool, err error) {
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return Request{}, false, invalidRequestError("Request object could not be decoded: " + err.Error())
+	}
+	var id struct {
+		ID json.RawMessage `json:"id"`
+	}
+	if err := json.Unmarshal(raw, &id); err != nil {
+		return Request{}, false, invalidRequestError("Request object could not be decoded: " + err.Error())
+	}
+	return req, id.ID == nil, nil
+}
+
+// isBatch reports whether the JSON value raw is an array.
+func isBatch(raw json.RawMessage) bool {
+	raw = bytes.TrimLeft(raw, " \t\r\n")
+	return len(raw) > 0 && raw[0] == '['
 }
 
 // DefaultErrorEncoder writes the error to the ResponseWriter,
@@ -168,6 +253,16 @@ func DefaultErrorEncoder(_ context.Context, err error, w http.ResponseWriter) {
 		}
 	}
 
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(Response{
+		JSONRPC: Version,
+		Error:   errorObject(err),
+	})
+}
+
+// errorObject returns the JSON RPC error object of err, with the code of
+// err if it implements ErrorCoder, and InternalError otherwise.
+func errorObject(err error) *Error {
 	e := Error{
 		Code:    InternalError,
 		Message: err.Error(),
@@ -175,12 +270,7 @@ func DefaultErrorEncoder(_ context.Context, err error, w http.ResponseWriter) {
 	if sc, ok := err.(ErrorCoder); ok {
 		e.Code = sc.ErrorCode()
 	}
-
-	w.WriteHeader(http.StatusOK)
-	_ = json.NewEncoder(w).Encode(Response{
-		JSONRPC: Version,
-		Error:   &e,
-	})
+	return &e
 }
 
 // ErrorCoder is checked by DefaultErrorEncoder. If an error value implements
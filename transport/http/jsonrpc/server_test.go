@@ -199,6 +199,161 @@ func TestServerHappyPath(t *testing.T) {
 	}
 }
 
+func addServer(calls *int) *httptest.Server {
+	ecm := jsonrpc.EndpointCodecMap{
+		"add": jsonrpc.EndpointCodec{
+			Endpoint: func(_ context.Context, request interface{}) (interface{}, error) {
+				*calls++
+				return request.([]int)[0] + request.([]int)[1], nil
+			},
+			Decode: func(_ context.Context, params json.RawMessage) (interface{}, error) {
+				var ints []int
+				err := json.Unmarshal(params, &ints)
+				return ints, err
+			},
+			Encode: func(_ context.Context, response interface{}) (json.RawMessage, error) {
+				return json.Marshal(response)
+			},
+		},
+	}
+	return httptest.NewServer(jsonrpc.NewServer(ecm))
+}
+
+func TestServerBatch(t *testing.T) {
+	var calls int
+	server := addServer(&calls)
+	defer server.Close()
+	resp, err := http.Post(server.URL, "application/json", body(`[
+		{"jsonrpc": "2.0", "method": "add", "params": [3, 2], "id": 1},
+		{"jsonrpc": "2.0", "method": "add", "params": [1, 1]},
+		{"jsonrpc": "2.0", "method": "sub", "params": [3, 2], "id": "b"}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close() // nolint
+	buf, _ := ioutil.ReadAll(resp.Body)
+
+	var rs []jsonrpc.Response
+	if err := json.Unmarshal(buf, &rs); err != nil {
+		t.Fatalf("Cant' decode response. err=%s, body=%s", err, buf)
+	}
+	if want, have := 2, calls; want != have {
+		t.Errorf("want %d calls, have %d", want, have)
+	}
+	if want, have := 2, len(rs); want != have {
+		t.Fatalf("want %d responses, have %d: %s", want, have, buf)
+	}
+	if id, _ := rs[0].ID.Int(); id != 1 || string(rs[0].Result) != "5" {
+		t.Errorf("want result 5 for ID 1, have %s for %d", rs[0].Result, id)
+	}
+	if id, _ := rs[1].ID.String(); id != "b" || rs[1].Error == nil || rs[1].Error.Code != jsonrpc.MethodNotFoundError {
+		t.Errorf("want method not found error for ID b, have %+v for %q", rs[1].Error, id)
+	}
+}
+
+func TestServerBatchInvalidRequests(t *testing.T) {
+	var calls int
+	server := addServer(&calls)
+	defer server.Close()
+	resp, err := http.Post(server.URL, "application/json", body(`[
+		1,
+		{"jsonrpc": "2.0", "method": "add", "params": [3, 2], "id": 1},
+		{"jsonrpc": "2.0", "method": 7, "id": 2}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close() // nolint
+	buf, _ := ioutil.ReadAll(resp.Body)
+
+	var rs []jsonrpc.Response
+	if err := json.Unmarshal(buf, &rs); err != nil {
+		t.Fatalf("Cant' decode response. err=%s, body=%s", err, buf)
+	}
+	if want, have := 3, len(rs); want != have {
+		t.Fatalf("want %d responses, have %d: %s", want, have, buf)
+	}
+	for _, i := range []int{0, 2} {
+		if rs[i].ID != nil || rs[i].Error == nil || rs[i].Error.Code != jsonrpc.InvalidRequestError {
+			t.Errorf("response %d: want invalid request error for null ID, have %s", i, buf)
+		}
+	}
+	if id, _ := rs[1].ID.Int(); id != 1 || string(rs[1].Result) != "5" {
+		t.Errorf("want result 5 for ID 1, have %s for %d", rs[1].Result, id)
+	}
+}
+
+func TestServerNullID(t *testing.T) {
+	var calls int
+	server := addServer(&calls)
+	defer server.Close()
+	for _, b := range []string{
+		`{"jsonrpc": "2.0", "method": "add", "params": [3, 2], "id": null}`,
+		`[{"jsonrpc": "2.0", "method": "add", "params": [3, 2], "id": null}]`,
+	} {
+		resp, err := http.Post(server.URL, "application/json", body(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close() // nolint
+		if want, have := http.StatusOK, resp.StatusCode; want != have || !strings.Contains(string(buf), `"result":5`) {
+			t.Errorf("%s: want %d with result, have %d: %s", b, want, have, buf)
+		}
+	}
+}
+
+func TestServerInvalidRequest(t *testing.T) {
+	var calls int
+	server := addServer(&calls)
+	defer server.Close()
+	resp, err := http.Post(server.URL, "application/json", body(`"add"`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close() // nolint
+	buf, _ := ioutil.ReadAll(resp.Body)
+	expectErrorCode(t, jsonrpc.InvalidRequestError, buf)
+}
+
+func TestServerEmptyBatch(t *testing.T) {
+	var calls int
+	server := addServer(&calls)
+	defer server.Close()
+	resp, err := http.Post(server.URL, "application/json", body(`[]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close() // nolint
+	buf, _ := ioutil.ReadAll(resp.Body)
+	expectErrorCode(t, jsonrpc.InvalidRequestError, buf)
+}
+
+func TestServerNotification(t *testing.T) {
+	var calls int
+	server := addServer(&calls)
+	defer server.Close()
+	for _, b := range []string{
+		`{"jsonrpc": "2.0", "method": "add", "params": [3, 2]}`,
+		`{"jsonrpc": "2.0", "method": "sub", "params": [3, 2]}`,
+		`[{"jsonrpc": "2.0", "method": "add", "params": [3, 2]}]`,
+	} {
+		resp, err := http.Post(server.URL, "application/json", body(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close() // nolint
+		if want, have := http.StatusNoContent, resp.StatusCode; want != have || len(buf) > 0 {
+			t.Errorf("%s: want %d without body, have %d: %s", b, want, have, buf)
+		}
+	}
+	if want, have := 2, calls; want != have {
+		t.Errorf("want %d calls, have %d", want, have)
+	}
+}
+
 func TestMultipleServerBefore(t *testing.T) {
 	var done = make(chan struct{})
 	ecm := jsonrpc.EndpointCodecMap{
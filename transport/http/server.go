@@ -7,6 +7,7 @@ import (
 
 	"github.com/inturn/kit/endpoint"
 	"github.com/inturn/kit/log"
+	"github.com/inturn/kit/transport"
 )
 
 // Server wraps an endpoint and implements http.Handler.
@@ -18,7 +19,7 @@ type Server struct {
 	after        []ServerResponseFunc
 	errorEncoder ErrorEncoder
 	finalizer    []ServerFinalizerFunc
-	logger       log.Logger
+	errorHandler transport.ErrorHandler
 }
 
 // NewServer constructs a new server, which implements http.Handler and wraps
@@ -34,7 +35,7 @@ func NewServer(
 		dec:          dec,
 		enc:          enc,
 		errorEncoder: DefaultErrorEncoder,
-		logger:       log.NewNopLogger(),
+		errorHandler: transport.NewLogErrorHandler(log.NewNopLogger()),
 	}
 	for _, option := range options {
 		option(s)
@@ -70,8 +71,19 @@ func ServerErrorEncoder(ee ErrorEncoder) ServerOption {
 // of error handling, including logging in more detail, should be performed in a
 // custom ServerErrorEncoder or ServerFinalizer, both of which have access to
 // the context.
+//
+// Deprecated: Use ServerErrorHandler instead.
 func ServerErrorLogger(logger log.Logger) ServerOption {
-	return func(s *Server) { s.logger = logger }
+	return func(s *Server) { s.errorHandler = transport.NewLogErrorHandler(logger) }
+}
+
+// ServerErrorHandler is used to handle non-terminal errors. By default,
+// non-terminal errors are ignored. This is intended as a diagnostic measure.
+// Finer-grained control of error handling, including logging in more
+// detail, should be performed in a custom ServerErrorEncoder or
+// ServerFinalizer, both of which have access to the context.
+func ServerErrorHandler(errorHandler transport.ErrorHandler) ServerOption {
+	return func(s *Server) { s.errorHandler = errorHandler }
 }
 
 // ServerFinalizer is executed at the end of every HTTP request.
@@ -102,14 +114,14 @@ func (s Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	request, err := s.dec(ctx, r)
 	if err != nil {
-		s.logger.Log("err", err)
+		s.errorHandler.Handle(ctx, err)
 		s.errorEncoder(ctx, err, w)
 		return
 	}
 
 	response, err := s.e(ctx, request)
 	if err != nil {
-		s.logger.Log("err", err)
+		s.errorHandler.Handle(ctx, err)
 		s.errorEncoder(ctx, err, w)
 		return
 	}
@@ -119,7 +131,7 @@ func (s Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := s.enc(ctx, w, response); err != nil {
-		s.logger.Log("err", err)
+		s.errorHandler.Handle(ctx, err)
 		s.errorEncoder(ctx, err, w)
 		return
 	}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/inturn/kit/endpoint"
+	"github.com/inturn/kit/transport"
 	httptransport "github.com/inturn/kit/transport/http"
 )
 
@@ -78,6 +79,25 @@ func TestServerErrorEncoder(t *testing.T) {
 	}
 }
 
+func TestServerErrorHandler(t *testing.T) {
+	errTeapot := errors.New("teapot")
+	msgChan := make(chan string, 1)
+	handler := httptransport.NewServer(
+		func(context.Context, interface{}) (interface{}, error) { return struct{}{}, errTeapot },
+		func(context.Context, *http.Request) (interface{}, error) { return struct{}{}, nil },
+		func(context.Context, http.ResponseWriter, interface{}) error { return nil },
+		httptransport.ServerErrorHandler(transport.ErrorHandlerFunc(func(ctx context.Context, err error) {
+			msgChan <- err.Error()
+		})),
+	)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	http.Get(server.URL)
+	if want, have := errTeapot.Error(), <-msgChan; want != have {
+		t.Errorf("want %s, have %s", want, have)
+	}
+}
+
 func TestServerHappyPath(t *testing.T) {
 	step, response := testServer(t)
 	step()
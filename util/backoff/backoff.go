@@ -0,0 +1,141 @@
+package backoff
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+const maxDuration = time.Duration(1<<63 - 1)
+
+// Strategy returns the delay before retry attempt n, starting at 1. previous
+// is the delay returned for the previous attempt, or zero for the first.
+type Strategy func(n int, previous time.Duration) time.Duration
+
+// Constant waits d before every attempt.
+func Constant(d time.Duration) Strategy {
+	return func(int, time.Duration) time.Duration {
+		return d
+	}
+}
+
+// Linear waits step before the first attempt and step longer for every
+// further attempt. It should be capped with Capped.
+func Linear(step time.Duration) Strategy {
+	return func(n int, _ time.Duration) time.Duration {
+		if n < 1 {
+			n = 1
+		}
+		if step > 0 && time.Duration(n) > maxDuration/step {
+			return maxDuration
+		}
+		return time.Duration(n) * step
+	}
+}
+
+// Exponential waits base before the first attempt and doubles the delay for
+// every further attempt. It should be capped with Capped.
+func Exponential(base time.Duration) Strategy {
+	return func(n int, _ time.Duration) time.Duration {
+		if n < 1 {
+			n = 1
+		}
+		d := base
+		for i := 1; i < n && d < maxDuration/2; i++ {
+			d *= 2
+		}
+		return d
+	}
+}
+
+// Decorrelated waits a random duration between base and three times the
+// previous delay, capped at max. It spreads out clients that started failing
+// at the same time better than jittering an exponential backoff does.
+func Decorrelated(base, max time.Duration) Strategy {
+	return func(n int, previous time.Duration) time.Duration {
+		if previous < base {
+			previous = base
+		}
+		upper := previous * 3
+		if upper > max || upper < previous {
+			upper = max
+		}
+		if upper <= base {
+			return upper
+		}
+		return base + time.Duration(rand.Int63n(int64(upper-base)))
+	}
+}
+
+// Capped limits the delays of s to max.
+func Capped(s Strategy, max time.Duration) Strategy {
+	return func(n int, previous time.Duration) time.Duration {
+		if d := s(n, previous); d < max {
+			return d
+		}
+		return max
+	}
+}
+
+// FullJitter waits a random duration between zero and the delay of s.
+func FullJitter(s Strategy) Strategy {
+	return func(n int, previous time.Duration) time.Duration {
+		d := s(n, previous)
+		if d <= 0 {
+			return 0
+		}
+		return time.Duration(rand.Int63n(int64(d)))
+	}
+}
+
+// Jitter randomizes the delays of s by +/- factor, e.g. 0.5 for +/- 50%.
+func Jitter(s Strategy, factor float64) Strategy {
+	return func(n int, previous time.Duration) time.Duration {
+		d := s(n, previous)
+		return time.Duration(float64(d) * (1 + factor*(2*rand.Float64()-1)))
+	}
+}
+
+// Backoff tracks the attempts of a retried operation. It is not safe for
+// concurrent use.
+type Backoff struct {
+	strategy Strategy
+	attempt  int
+	previous time.Duration
+}
+
+// New returns a Backoff spacing out attempts according to s.
+func New(s Strategy) *Backoff {
+	return &Backoff{strategy: s}
+}
+
+// Next returns the delay before the next attempt.
+func (b *Backoff) Next() time.Duration {
+	b.attempt++
+	b.previous = b.strategy(b.attempt, b.previous)
+	return b.previous
+}
+
+// Attempt returns the number of delays returned since the last Reset.
+func (b *Backoff) Attempt() int {
+	return b.attempt
+}
+
+// Reset starts over, typically after the operation succeeded.
+func (b *Backoff) Reset() {
+	b.attempt = 0
+	b.previous = 0
+}
+
+// Wait sleeps for the delay before the next attempt. It returns early with
+// the error of ctx if ctx is done first.
+func (b *Backoff) Wait(ctx context.Context) error {
+	t := time.NewTimer(b.Next())
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
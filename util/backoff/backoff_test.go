@@ -0,0 +1,104 @@
+package backoff_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/inturn/kit/util/backoff"
+)
+
+func TestExponential(t *testing.T) {
+	b := backoff.New(backoff.Capped(backoff.Exponential(time.Second), 5*time.Second))
+	for _, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		if have := b.Next(); want != have {
+			t.Errorf("want %s, have %s", want, have)
+		}
+	}
+	b.Reset()
+	if want, have := time.Second, b.Next(); want != have {
+		t.Errorf("after reset: want %s, have %s", want, have)
+	}
+
+	if want, have := time.Duration(1<<62), backoff.Exponential(1)(100, 0); have < want {
+		t.Errorf("want overflow to saturate at >= %s, have %s", want, have)
+	}
+}
+
+func TestLinear(t *testing.T) {
+	b := backoff.New(backoff.Linear(time.Second))
+	for _, want := range []time.Duration{time.Second, 2 * time.Second, 3 * time.Second} {
+		if have := b.Next(); want != have {
+			t.Errorf("want %s, have %s", want, have)
+		}
+	}
+	if want, have := time.Duration(1<<63-1), backoff.Linear(time.Hour)(1<<62, 0); want != have {
+		t.Errorf("want overflow to saturate at %s, have %s", want, have)
+	}
+}
+
+func TestJitter(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		strategy backoff.Strategy
+		min, max time.Duration
+	}{
+		{"full", backoff.FullJitter(backoff.Constant(time.Second)), 0, time.Second},
+		{"proportional", backoff.Jitter(backoff.Constant(time.Second), 0.5), 500 * time.Millisecond, 1500 * time.Millisecond},
+		{"decorrelated", backoff.Decorrelated(time.Second, 10*time.Second), time.Second, 10 * time.Second},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			b := backoff.New(tc.strategy)
+			for i := 0; i < 100; i++ {
+				if d := b.Next(); d < tc.min || d > tc.max {
+					t.Fatalf("want delay in [%s, %s], have %s", tc.min, tc.max, d)
+				}
+			}
+		})
+	}
+}
+
+func TestRetry(t *testing.T) {
+	var (
+		errFail  = errors.New("fail")
+		attempts int
+		op       = func(context.Context) error { attempts++; return errFail }
+	)
+	if want, have := errFail, backoff.Retry(context.Background(), backoff.Constant(0), op, backoff.MaxAttempts(3)); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	if want, have := 3, attempts; want != have {
+		t.Errorf("want %d attempts, have %d", want, have)
+	}
+
+	attempts = 0
+	backoff.Retry(context.Background(), backoff.Constant(0), op, backoff.RetryIf(func(err error) bool { return err != errFail }))
+	if want, have := 1, attempts; want != have {
+		t.Errorf("want %d attempts, have %d", want, have)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if want, have := context.DeadlineExceeded, backoff.Retry(ctx, backoff.Constant(time.Second), op); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+}
+
+func TestRetryAttemptTimeout(t *testing.T) {
+	var attempts int
+	err := backoff.Retry(context.Background(), backoff.Constant(0), func(ctx context.Context) error {
+		attempts++
+		if attempts == 1 {
+			<-ctx.Done() // the first attempt hangs
+			return ctx.Err()
+		}
+		return nil
+	}, backoff.AttemptTimeout(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, have := 2, attempts; want != have {
+		t.Errorf("want %d attempts, have %d", want, have)
+	}
+}
@@ -0,0 +1,13 @@
+// Package backoff provides strategies for spacing out retries of operations
+// that may fail, e.g. reconnecting to a broker or re-querying a service
+// discovery system, so that a fleet of clients doesn't hammer a struggling
+// dependency in lockstep.
+//
+// Strategies compose, e.g. an exponential backoff starting at 100ms with full
+// jitter and capped at 30s:
+//
+//	backoff.Capped(backoff.FullJitter(backoff.Exponential(100*time.Millisecond)), 30*time.Second)
+//
+// See https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/
+// for a comparison of jitter strategies.
+package backoff
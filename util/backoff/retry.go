@@ -0,0 +1,68 @@
+package backoff
+
+import (
+	"context"
+	"time"
+)
+
+type retryConfig struct {
+	attempts int
+	timeout  time.Duration
+	retryIf  func(error) bool
+}
+
+// RetryOption sets an optional parameter for Retry.
+type RetryOption func(*retryConfig)
+
+// MaxAttempts limits the number of times the operation is called, including
+// the first. By default, it is retried until it succeeds or ctx is done.
+func MaxAttempts(n int) RetryOption {
+	return func(c *retryConfig) { c.attempts = n }
+}
+
+// AttemptTimeout gives every attempt its own deadline of d, so that a hanging
+// attempt doesn't use up the time available for retrying.
+func AttemptTimeout(d time.Duration) RetryOption {
+	return func(c *retryConfig) { c.timeout = d }
+}
+
+// RetryIf only retries errors for which f returns true. By default, all
+// errors are retried.
+func RetryIf(f func(error) bool) RetryOption {
+	return func(c *retryConfig) { c.retryIf = f }
+}
+
+// Retry calls op until it succeeds, waiting between attempts according to s.
+// It returns the error of the last attempt if op can't be retried anymore, or
+// the error of ctx if ctx is done while waiting.
+func Retry(ctx context.Context, s Strategy, op func(context.Context) error, options ...RetryOption) error {
+	c := retryConfig{
+		retryIf: func(error) bool { return true },
+	}
+	for _, option := range options {
+		option(&c)
+	}
+
+	b := New(s)
+	for {
+		err := attempt(ctx, c.timeout, op)
+		if err == nil || !c.retryIf(err) {
+			return err
+		}
+		if c.attempts > 0 && b.Attempt()+1 >= c.attempts {
+			return err
+		}
+		if err := b.Wait(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+func attempt(ctx context.Context, timeout time.Duration, op func(context.Context) error) error {
+	if timeout <= 0 {
+		return op(ctx)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return op(ctx)
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/inturn/kit/log"
+	"github.com/inturn/kit/util/backoff"
 )
 
 // Dialer imitates net.Dial. Dialer is assumed to yield connections that are
@@ -87,7 +88,7 @@ func (m *Manager) loop() {
 		conn       = dial(m.dialer, m.network, m.address, m.logger) // may block slightly
 		connc      = make(chan net.Conn, 1)
 		reconnectc <-chan time.Time // initially nil
-		backoff    = time.Second
+		b          = backoff.New(defaultBackoff)
 	)
 
 	// If the initial dial fails, we need to trigger a reconnect via the loop
@@ -104,12 +105,11 @@ func (m *Manager) loop() {
 		case conn = <-connc:
 			if conn == nil {
 				// didn't work
-				backoff = Exponential(backoff) // wait longer
-				reconnectc = m.after(backoff)  // try again
+				reconnectc = m.after(b.Next()) // try again, waiting longer
 			} else {
 				// worked!
-				backoff = time.Second // reset wait time
-				reconnectc = nil      // no retry necessary
+				b.Reset()        // reset wait time
+				reconnectc = nil // no retry necessary
 			}
 
 		case m.takec <- conn:
@@ -133,9 +133,15 @@ func dial(d Dialer, network, address string, logger log.Logger) net.Conn {
 	return conn
 }
 
+// defaultBackoff waits 2s before the first reconnect and twice as long for
+// every further one, +/- 50%, up to a minute.
+var defaultBackoff = backoff.Capped(backoff.Jitter(backoff.Exponential(2*time.Second), 0.5), time.Minute)
+
 // Exponential takes a duration and returns another one that is twice as long, +/- 50%. It is
 // used to provide backoff for operations that may fail and should avoid thundering herds.
 // See https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/ for rationale
+//
+// Deprecated: use package github.com/inturn/kit/util/backoff.
 func Exponential(d time.Duration) time.Duration {
 	d *= 2
 	jitter := rand.Float64() + 0.5
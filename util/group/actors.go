@@ -0,0 +1,107 @@
+package group
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+
+	"google.golang.org/grpc"
+)
+
+// HTTPServer returns an Actor serving srv on l. It is interrupted by
+// shutting down srv, which waits for active requests to complete until the
+// shutdown deadline, and closes srv afterwards.
+func HTTPServer(srv *http.Server, l net.Listener) Actor {
+	return Actor{
+		Execute: func() error {
+			if err := srv.Serve(l); err != http.ErrServerClosed {
+				return err
+			}
+			return nil
+		},
+		Interrupt: func(ctx context.Context) error {
+			if err := srv.Shutdown(ctx); err != nil {
+				srv.Close()
+				return err
+			}
+			return nil
+		},
+	}
+}
+
+// GRPCServer returns an Actor serving srv on l. It is interrupted by
+// stopping srv gracefully, and forcefully when the shutdown deadline is
+// reached.
+func GRPCServer(srv *grpc.Server, l net.Listener) Actor {
+	return Actor{
+		Execute: func() error {
+			return srv.Serve(l)
+		},
+		Interrupt: func(ctx context.Context) error {
+			stopped := make(chan struct{})
+			go func() {
+				srv.GracefulStop()
+				close(stopped)
+			}()
+			select {
+			case <-stopped:
+				return nil
+			case <-ctx.Done():
+				srv.Stop()
+				return ctx.Err()
+			}
+		},
+	}
+}
+
+// Func returns an Actor running f, e.g. an AMQP consumer or a scheduler,
+// until f returns. It is interrupted by canceling the context passed to f;
+// f should return promptly after that.
+func Func(f func(ctx context.Context) error) Actor {
+	ctx, cancel := context.WithCancel(context.Background())
+	return Actor{
+		Execute: func() error {
+			return f(ctx)
+		},
+		Interrupt: func(context.Context) error {
+			cancel()
+			return nil
+		},
+	}
+}
+
+// SignalError is returned by the Signal actor when a signal arrived.
+type SignalError struct {
+	Signal os.Signal
+}
+
+// Error implements the error interface.
+func (e SignalError) Error() string {
+	return fmt.Sprintf("received signal %s", e.Signal)
+}
+
+// Signal returns an Actor exiting with a SignalError when one of signals
+// arrives, e.g. syscall.SIGINT and syscall.SIGTERM, stopping the group.
+func Signal(signals ...os.Signal) Actor {
+	c := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	return Actor{
+		Execute: func() error {
+			signal.Notify(c, signals...)
+			defer signal.Stop(c)
+			select {
+			case sig := <-c:
+				return SignalError{Signal: sig}
+			case <-done:
+				return nil
+			}
+		},
+		Interrupt: func(context.Context) error {
+			close(done)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,15 @@
+// Package group runs the components of a service, e.g. HTTP and gRPC
+// servers, AMQP consumers, schedulers and signal handlers, as a group of
+// actors. All actors are started together; when the first one exits, e.g.
+// because a signal arrived or a listener failed, all others are stopped
+// gracefully, each within its own shutdown deadline.
+//
+//	var g group.Group
+//	g.Add("http", group.HTTPServer(&http.Server{Handler: handler}, httpListener))
+//	g.Add("grpc", group.GRPCServer(grpcServer, grpcListener))
+//	g.Add("consumer", group.Func(consume), group.ShutdownTimeout(time.Minute))
+//	g.Add("signal", group.Signal(syscall.SIGINT, syscall.SIGTERM))
+//	logger.Log("exit", g.Run())
+//
+// It is modeled after github.com/oklog/run, adding shutdown deadlines.
+package group
@@ -0,0 +1,143 @@
+package group
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/inturn/kit/log"
+)
+
+// DefaultShutdownTimeout is the time actors are given to stop if not set
+// otherwise.
+const DefaultShutdownTimeout = 30 * time.Second
+
+// ErrShutdownTimeout is logged for actors that didn't stop within their
+// shutdown deadline.
+var ErrShutdownTimeout = errors.New("actor did not stop within its shutdown deadline")
+
+// Actor is a component of a service.
+type Actor struct {
+	// Execute runs the actor until it is interrupted or fails.
+	Execute func() error
+
+	// Interrupt makes Execute return, gracefully if possible within the
+	// deadline of ctx. It is called once, after the first actor of the
+	// group exited, even if that was this actor.
+	Interrupt func(ctx context.Context) error
+}
+
+// Group runs actors together. The zero value is an empty group using the
+// DefaultShutdownTimeout and no logger.
+type Group struct {
+	actors  []namedActor
+	timeout time.Duration
+	logger  log.Logger
+}
+
+type namedActor struct {
+	Actor
+	name    string
+	timeout time.Duration
+}
+
+// GroupOption sets an optional parameter for groups.
+type GroupOption func(*Group)
+
+// GroupShutdownTimeout sets the default time actors are given to stop.
+func GroupShutdownTimeout(d time.Duration) GroupOption {
+	return func(g *Group) { g.timeout = d }
+}
+
+// GroupLogger sets the logger actor exits and failures to stop are logged
+// to. By default, nothing is logged.
+func GroupLogger(logger log.Logger) GroupOption {
+	return func(g *Group) { g.logger = logger }
+}
+
+// New returns an empty group.
+func New(options ...GroupOption) *Group {
+	g := &Group{}
+	for _, option := range options {
+		option(g)
+	}
+	return g
+}
+
+// ActorOption sets an optional parameter for an actor of a group.
+type ActorOption func(*namedActor)
+
+// ShutdownTimeout sets the time the actor is given to stop, overriding the
+// default of the group.
+func ShutdownTimeout(d time.Duration) ActorOption {
+	return func(a *namedActor) { a.timeout = d }
+}
+
+// Add adds an actor to the group. The name is used for logging.
+func (g *Group) Add(name string, a Actor, options ...ActorOption) {
+	na := namedActor{Actor: a, name: name}
+	for _, option := range options {
+		option(&na)
+	}
+	g.actors = append(g.actors, na)
+}
+
+// Run starts all actors and blocks until they all stopped. When the first
+// actor exits, all actors are interrupted and given their shutdown timeout
+// to stop. Run returns the error of the first actor to exit, which may be
+// nil; actors that don't stop in time are logged and abandoned.
+func (g *Group) Run() error {
+	if len(g.actors) == 0 {
+		return nil
+	}
+	logger := g.logger
+	if logger == nil {
+		logger = log.NewNopLogger()
+	}
+
+	errs := make([]chan error, len(g.actors))
+	first := make(chan int, len(g.actors))
+	for i, a := range g.actors {
+		errs[i] = make(chan error, 1)
+		go func(i int, a namedActor) {
+			err := a.Execute()
+			errs[i] <- err
+			first <- i
+		}(i, a)
+	}
+
+	i := <-first
+	err := <-errs[i]
+	errs[i] <- err // keep it for the loop below
+	logger.Log("actor", g.actors[i].name, "msg", "exited", "err", err)
+
+	done := make(chan struct{})
+	for i, a := range g.actors {
+		go func(i int, a namedActor) {
+			defer func() { done <- struct{}{} }()
+
+			timeout := a.timeout
+			if timeout <= 0 {
+				timeout = g.timeout
+			}
+			if timeout <= 0 {
+				timeout = DefaultShutdownTimeout
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			if err := a.Interrupt(ctx); err != nil {
+				logger.Log("actor", a.name, "msg", "interrupt failed", "err", err)
+			}
+			select {
+			case <-errs[i]:
+			case <-ctx.Done():
+				logger.Log("actor", a.name, "err", ErrShutdownTimeout)
+			}
+		}(i, a)
+	}
+	for range g.actors {
+		<-done
+	}
+	return err
+}
@@ -0,0 +1,86 @@
+package group_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/inturn/kit/util/group"
+)
+
+func TestRun(t *testing.T) {
+	var (
+		g           group.Group
+		errFailed   = errors.New("failed")
+		interrupted = make(chan struct{}, 2)
+		blocking    = func() group.Actor {
+			stop := make(chan struct{})
+			return group.Actor{
+				Execute: func() error { <-stop; return nil },
+				Interrupt: func(context.Context) error {
+					interrupted <- struct{}{}
+					close(stop)
+					return nil
+				},
+			}
+		}
+	)
+	g.Add("a", blocking())
+	g.Add("b", blocking())
+	g.Add("failing", group.Func(func(context.Context) error { return errFailed }))
+
+	if want, have := errFailed, g.Run(); want != have {
+		t.Errorf("want %v, have %v", want, have)
+	}
+	if want, have := 2, len(interrupted); want != have {
+		t.Errorf("want %d actors interrupted, have %d", want, have)
+	}
+}
+
+func TestRunShutdownTimeout(t *testing.T) {
+	g := group.New(group.GroupShutdownTimeout(time.Hour))
+	g.Add("stuck", group.Actor{
+		Execute:   func() error { select {} },
+		Interrupt: func(context.Context) error { return nil },
+	}, group.ShutdownTimeout(10*time.Millisecond))
+	g.Add("exiting", group.Func(func(context.Context) error { return nil }))
+
+	done := make(chan error)
+	go func() { done <- g.Run() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after the shutdown timeout")
+	}
+}
+
+func TestHTTPServer(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var g group.Group
+	g.Add("http", group.HTTPServer(&http.Server{Handler: http.NotFoundHandler()}, l))
+	g.Add("client", group.Func(func(context.Context) error {
+		resp, err := http.Get("http://" + l.Addr().String())
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		return nil
+	}))
+
+	if err := g.Run(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := http.Get("http://" + l.Addr().String()); err == nil {
+		t.Error("want server stopped, have response")
+	}
+}